@@ -1,11 +1,13 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"net"
-	"net/http"
 	"os"
 
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/disc"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 	"github.com/spf13/cobra"
 
@@ -24,6 +26,8 @@ var (
 	mockNodes      int
 	mockMaxTps     int
 	mockMaxRoutes  int
+	mockSeed       int64
+	mockScenario   string
 )
 
 func init() {
@@ -33,12 +37,14 @@ func init() {
 	rootCmd.Flags().IntVar(&mockNodes, "mock-nodes", 5, "number of app nodes to have in mock mode")
 	rootCmd.Flags().IntVar(&mockMaxTps, "mock-max-tps", 10, "max number of transports per mock app node")
 	rootCmd.Flags().IntVar(&mockMaxRoutes, "mock-max-routes", 30, "max number of routes per node")
+	rootCmd.Flags().Int64Var(&mockSeed, "mock-seed", 0, "seed of mock data, which is reproducible with the same seed (random if 0)")
+	rootCmd.Flags().StringVar(&mockScenario, "mock-scenario", "", "scenario file of mock mode, in place of the other mock flags")
 }
 
 var rootCmd = &cobra.Command{
 	Use:   "hypervisor",
 	Short: "Manages Skywire App Nodes",
-	Run: func(_ *cobra.Command, args []string) {
+	Run: func(cmd *cobra.Command, args []string) {
 		if configPath == "" {
 			configPath = pathutil.FindConfigPath(args, -1, configEnv, pathutil.HypervisorDefaults())
 		}
@@ -71,20 +77,49 @@ var rootCmd = &cobra.Command{
 			}
 		}()
 
+		if config.Dmsg != nil {
+			dmsgC := dmsg.NewClient(config.PK, config.SK, disc.NewHTTP(config.Dmsg.Discovery),
+				dmsg.SetLogger(logging.MustGetLogger("hypervisor.dmsgC")))
+			go func() {
+				if err := dmsgC.InitiateServerConnections(context.Background(), 1); err != nil {
+					log.Fatalln("Failed to connect to dmsg servers:", err)
+				}
+				log.Infof("managing %d visors over dmsg", len(config.Dmsg.Visors))
+				m.ServeDmsg(context.Background(), dmsgC)
+			}()
+		}
+
+		go m.RunAlerts(context.Background())
+		go m.RunHistory(context.Background())
+		go m.RunStatus(context.Background())
+
 		if mock {
-			err := m.AddMockData(hypervisor.MockConfig{
+			mockConfig := hypervisor.MockConfig{
 				Nodes:            mockNodes,
 				MaxTpsPerNode:    mockMaxTps,
 				MaxRoutesPerNode: mockMaxRoutes,
 				EnableAuth:       mockEnableAuth,
-			})
-			if err != nil {
+			}
+			if mockScenario != "" {
+				if mockConfig, err = hypervisor.ParseMockConfig(mockScenario); err != nil {
+					log.Fatalln("Failed to parse mock scenario:", err)
+				}
+			}
+			if cmd.Flags().Changed("mock-seed") {
+				mockConfig.Seed = mockSeed
+			}
+			if err := m.AddMockData(mockConfig); err != nil {
 				log.Fatalln("Failed to add mock data:", err)
 			}
+			go m.RunMock(context.Background())
 		}
 
-		log.Infof("serving HTTP on '%s'", httpAddr)
-		if err := http.ListenAndServe(httpAddr, m); err != nil {
+		if config.TLS != nil {
+			log.Infof("serving HTTPS on '%s'", httpAddr)
+		} else {
+			log.Infof("serving HTTP on '%s'", httpAddr)
+		}
+		if err := m.ListenAndServe(); err != nil {
 			log.Fatalln("Hypervisor exited with error:", err)
 		}
 
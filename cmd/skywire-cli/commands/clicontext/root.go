@@ -0,0 +1,155 @@
+package clicontext
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+// RootCmd is the command that contains sub-commands which manage the contexts of the CLI config.
+var RootCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manages named contexts, which select the visor the node sub-commands interact with",
+	Long: "Manages named contexts, which select the visor the node sub-commands interact with, by its RPC address " +
+		"or its public key over dmsg, and the token authenticating to it.\n\n" +
+		"Flags given to the node sub-commands take precedence over the context. Contexts are stored in " +
+		internal.CLIConfigPath() + ", or the file of $" + internal.CLIConfigEnv + ".",
+}
+
+func init() {
+	RootCmd.AddCommand(lsCmd, useCmd, setCmd, rmCmd)
+	internal.CompleteArgs(useCmd, internal.CompleteContext)
+	internal.CompleteArgs(rmCmd, internal.CompleteContext)
+	internal.RegisterCompleter(internal.CompleteContext, func() ([]string, error) {
+		conf, err := internal.ReadCLIConfig()
+		if err != nil {
+			return nil, err
+		}
+		return conf.ContextNames(), nil
+	})
+}
+
+// contextEntry is a context as listed by 'context ls'.
+type contextEntry struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+	*internal.Context
+}
+
+var lsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "Lists the contexts, marking the current one",
+	Run: func(_ *cobra.Command, _ []string) {
+		conf, err := internal.ReadCLIConfig()
+		internal.Catch(err)
+
+		entries := make([]contextEntry, 0, len(conf.Contexts))
+		for _, name := range conf.ContextNames() {
+			entries = append(entries, contextEntry{Name: name, Current: name == conf.Current, Context: conf.Contexts[name]})
+		}
+		internal.PrintOutput(entries, func(out io.Writer) {
+			w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+			_, err := fmt.Fprintln(w, "current\tname\trpc\tremote\ttoken")
+			internal.Catch(err)
+			for _, e := range entries {
+				current, token := "", ""
+				if e.Current {
+					current = "*"
+				}
+				if e.RPCToken != "" {
+					token = "set"
+				}
+				_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", current, e.Name, e.RPCAddr, e.Remote, token)
+				internal.Catch(err)
+			}
+			internal.Catch(w.Flush())
+		})
+	},
+}
+
+var useCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Makes a context the current one",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		conf, err := internal.ReadCLIConfig()
+		internal.Catch(err)
+		if _, ok := conf.Contexts[args[0]]; !ok {
+			internal.Catch(fmt.Errorf("context %q not found", args[0]))
+		}
+		conf.Current = args[0]
+		internal.Catch(internal.WriteCLIConfig(conf))
+		internal.PrintOK()
+	},
+}
+
+var setCtx internal.Context
+
+func init() {
+	setCmd.Flags().StringVar(&setCtx.RPCAddr, "rpc", "", "RPC server address of the visor")
+	setCmd.Flags().StringVar(&setCtx.RPCToken, "rpc-token", "", "token authenticating mutating RPC calls")
+	setCmd.Flags().StringVar(&setCtx.Remote, "remote", "", "public key of a remote visor to manage over dmsg")
+	setCmd.Flags().StringVar(&setCtx.RemoteKey, "remote-key", "",
+		"file of the local keys which authenticate to the remote visor")
+}
+
+var setCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Creates a context, or updates the fields of an existing one which are given",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if setCtx.Remote != "" {
+			internal.Catch(new(cipher.PubKey).Set(setCtx.Remote), "invalid --remote public key:")
+		}
+		conf, err := internal.ReadCLIConfig()
+		internal.Catch(err)
+
+		ctx, ok := conf.Contexts[args[0]]
+		if !ok {
+			ctx = new(internal.Context)
+			conf.Contexts[args[0]] = ctx
+		}
+		flags := cmd.Flags()
+		if flags.Changed("rpc") {
+			ctx.RPCAddr = setCtx.RPCAddr
+		}
+		if flags.Changed("rpc-token") {
+			ctx.RPCToken = setCtx.RPCToken
+		}
+		if flags.Changed("remote") {
+			ctx.Remote = setCtx.Remote
+		}
+		if flags.Changed("remote-key") {
+			ctx.RemoteKey = setCtx.RemoteKey
+		}
+		if conf.Current == "" {
+			conf.Current = args[0]
+		}
+		internal.Catch(internal.WriteCLIConfig(conf))
+		internal.PrintOK()
+	},
+}
+
+var rmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Removes a context",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		conf, err := internal.ReadCLIConfig()
+		internal.Catch(err)
+		if _, ok := conf.Contexts[args[0]]; !ok {
+			internal.Catch(fmt.Errorf("context %q not found", args[0]))
+		}
+		delete(conf.Contexts, args[0])
+		if conf.Current == args[0] {
+			conf.Current = ""
+		}
+		internal.Catch(internal.WriteCLIConfig(conf))
+		internal.PrintOK()
+	},
+}
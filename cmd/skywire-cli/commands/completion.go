@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+func init() {
+	rootCmd.AddCommand(completionCmd, completeCmd)
+	internal.CompleteArgs(completionCmd, "bash|zsh|fish")
+}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion (bash|zsh|fish)",
+	Short: "Prints the shell completion script of skywire-cli",
+	Long: `Prints the shell completion script of skywire-cli. Transport IDs, app names and public keys are completed by
+querying the local visor over RPC.
+
+  bash: source <(skywire-cli completion bash)
+  zsh:  source <(skywire-cli completion zsh)
+  fish: skywire-cli completion fish | source`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Run: func(_ *cobra.Command, args []string) {
+		script, ok := completionScripts[args[0]]
+		if !ok {
+			internal.Catch(fmt.Errorf("unsupported shell: %s", args[0]))
+		}
+		_, err := fmt.Fprintf(os.Stdout, script, rootCmd.Name(), completeCmd.Name())
+		internal.Catch(err)
+	},
+}
+
+// completeCmd prints the candidates of the last of the words, which the completion scripts pass the words of the
+// command line to.
+var completeCmd = &cobra.Command{
+	Use:                "__complete [words...]",
+	Hidden:             true,
+	DisableFlagParsing: true,
+	Run: func(_ *cobra.Command, args []string) {
+		for _, c := range internal.Complete(rootCmd, args) {
+			_, err := fmt.Fprintln(os.Stdout, c)
+			internal.Catch(err)
+		}
+	},
+}
+
+// completionScripts are the completion scripts of each shell, formatted with the name of the program and of the
+// command which completes the words.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for %[1]s
+__%[1]s_complete() {
+	local IFS=$'\n'
+	COMPREPLY=($("${COMP_WORDS[0]}" %[2]s "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F __%[1]s_complete %[1]s
+`,
+	"zsh": `#compdef %[1]s
+# zsh completion for %[1]s
+__%[1]s_complete() {
+	local -a candidates
+	candidates=("${(@f)$("${words[1]}" %[2]s "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	if [[ -n "${candidates[1]}" ]]; then
+		compadd -a candidates
+	else
+		_files
+	fi
+}
+compdef __%[1]s_complete %[1]s
+`,
+	"fish": `# fish completion for %[1]s
+function __%[1]s_complete
+	set -l words (commandline -opc)
+	set -e words[1]
+	%[1]s %[2]s $words (commandline -ct) 2>/dev/null
+end
+complete -c %[1]s -f -a '(__%[1]s_complete)'
+`,
+}
@@ -3,7 +3,7 @@ package mdisc
 import (
 	"context"
 	"fmt"
-	"os"
+	"io"
 	"text/tabwriter"
 	"time"
 
@@ -44,7 +44,10 @@ var entryCmd = &cobra.Command{
 		pk := internal.ParsePK("node-public-key", args[0])
 		entry, err := disc.NewHTTP(mdAddr).Entry(ctx, pk)
 		internal.Catch(err)
-		fmt.Println(entry)
+		internal.PrintOutput(entry, func(w io.Writer) {
+			_, err := fmt.Fprintln(w, entry)
+			internal.Catch(err)
+		})
 	},
 }
 
@@ -56,12 +59,12 @@ var availableServersCmd = &cobra.Command{
 		defer cancel()
 		entries, err := disc.NewHTTP(mdAddr).AvailableServers(ctx)
 		internal.Catch(err)
-		printAvailableServers(entries)
+		internal.PrintOutput(entries, func(w io.Writer) { printAvailableServers(w, entries) })
 	},
 }
 
-func printAvailableServers(entries []*disc.Entry) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 5, ' ', tabwriter.TabIndent)
+func printAvailableServers(out io.Writer, entries []*disc.Entry) {
+	w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
 	_, err := fmt.Fprintln(w, "version\tregistered\tpublic-key\taddress\tport\tconns")
 	internal.Catch(err)
 	for _, entry := range entries {
@@ -2,7 +2,7 @@ package node
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -31,20 +31,7 @@ var lsAppsCmd = &cobra.Command{
 	Run: func(_ *cobra.Command, _ []string) {
 		states, err := rpcClient().Apps()
 		internal.Catch(err)
-
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 5, ' ', tabwriter.TabIndent)
-		_, err = fmt.Fprintln(w, "app\tports\tauto_start\tstatus")
-		internal.Catch(err)
-
-		for _, state := range states {
-			status := "stopped"
-			if state.Status == visor.AppStatusRunning {
-				status = "running"
-			}
-			_, err = fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", state.Name, strconv.Itoa(int(state.Port)), state.AutoStart, status)
-			internal.Catch(err)
-		}
-		internal.Catch(w.Flush())
+		internal.PrintOutput(states, func(out io.Writer) { printApps(out, states) })
 	},
 }
 
@@ -54,7 +41,7 @@ var startAppCmd = &cobra.Command{
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(_ *cobra.Command, args []string) {
 		internal.Catch(rpcClient().StartApp(args[0]))
-		fmt.Println("OK")
+		internal.PrintOK()
 	},
 }
 
@@ -64,7 +51,7 @@ var stopAppCmd = &cobra.Command{
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(_ *cobra.Command, args []string) {
 		internal.Catch(rpcClient().StopApp(args[0]))
-		fmt.Println("OK")
+		internal.PrintOK()
 	},
 }
 
@@ -83,7 +70,7 @@ var setAppAutostartCmd = &cobra.Command{
 			internal.Catch(fmt.Errorf("invalid args[1] value: %s", args[1]))
 		}
 		internal.Catch(rpcClient().SetAutoStart(args[0], autostart))
-		fmt.Println("OK")
+		internal.PrintOK()
 	},
 }
 
@@ -104,11 +91,15 @@ var appLogsSinceCmd = &cobra.Command{
 		}
 		logs, err := rpcClient().LogsSince(t, args[0])
 		internal.Catch(err)
-		if len(logs) > 0 {
-			fmt.Println(logs)
-		} else {
-			fmt.Println("no logs")
-		}
+		internal.PrintOutput(logs, func(w io.Writer) {
+			var err error
+			if len(logs) > 0 {
+				_, err = fmt.Fprintln(w, logs)
+			} else {
+				_, err = fmt.Fprintln(w, "no logs")
+			}
+			internal.Catch(err)
+		})
 	},
 }
 
@@ -119,6 +110,30 @@ var execCmd = &cobra.Command{
 	Run: func(_ *cobra.Command, args []string) {
 		out, err := rpcClient().Exec(strings.Join(args, " "))
 		internal.Catch(err)
-		fmt.Print(string(out))
+		internal.PrintOutput(map[string]string{"output": string(out)}, func(w io.Writer) {
+			_, err := fmt.Fprint(w, string(out))
+			internal.Catch(err)
+		})
 	},
 }
+
+func printApps(out io.Writer, states []*visor.AppState) {
+	w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+	_, err := fmt.Fprintln(w, "app\tports\tauto_start\tstatus\tcpu\tmemory")
+	internal.Catch(err)
+
+	for _, state := range states {
+		status := "stopped"
+		if state.Status == visor.AppStatusRunning {
+			status = "running"
+		}
+		cpu, mem := "-", "-"
+		if state.Usage != nil {
+			cpu = fmt.Sprintf("%.1f%%", state.Usage.CPU)
+			mem = strconv.FormatUint(state.Usage.Memory, 10)
+		}
+		_, err = fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\n", state.Name, strconv.Itoa(int(state.Port)), state.AutoStart, status, cpu, mem)
+		internal.Catch(err)
+	}
+	internal.Catch(w.Flush())
+}
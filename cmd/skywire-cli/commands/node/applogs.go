@@ -0,0 +1,89 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+var (
+	followLogs bool
+	logsSince  string
+	logsUntil  string
+	logsLines  int
+)
+
+func init() {
+	RootCmd.AddCommand(appCmd)
+	appCmd.AddCommand(appLogsCmd)
+	appLogsCmd.Flags().BoolVarP(&followLogs, "follow", "f", false, "print new lines as they are logged until interrupted")
+	appLogsCmd.Flags().StringVar(&logsSince, "since", "",
+		"only lines logged since an RFC3339 time, or a duration ago such as 10m")
+	appLogsCmd.Flags().StringVar(&logsUntil, "until", "",
+		"only lines logged before an RFC3339 time, or a duration ago such as 10m")
+	appLogsCmd.Flags().IntVarP(&logsLines, "lines", "n", visor.DefaultTailLines,
+		"number of last lines to print, unless --since is specified")
+}
+
+var appCmd = &cobra.Command{
+	Use:   "app",
+	Short: "Contains sub-commands for the apps of the node",
+}
+
+var appLogsCmd = &cobra.Command{
+	Use:   "logs <name>",
+	Short: "Prints the logs of an app, following new lines with --follow",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		now := time.Now()
+		in := visor.TailLogsIn{App: args[0], Lines: logsLines, Wait: -1}
+		if logsSince != "" {
+			// After is exclusive, and the first lines are followed by all lines since.
+			in.After, in.Lines = parseLogsTime("since", logsSince, now).Add(-time.Nanosecond), math.MaxInt32
+		}
+		if logsUntil != "" {
+			in.Until = parseLogsTime("until", logsUntil, now)
+		}
+
+		rpc := streamRPCClient()
+		for {
+			out, err := rpc.TailLogs(in)
+			internal.Catch(err)
+			if !followLogs {
+				internal.PrintOutput(out.Lines, func(w io.Writer) { printLogLines(w, out.Lines) })
+				return
+			}
+			for _, line := range out.Lines {
+				line := line
+				internal.PrintStreamOutput(line, func(w io.Writer) { printLogLines(w, []string{line}) })
+			}
+			if out.Done {
+				return
+			}
+			in.After, in.Lines, in.Wait = out.Next, math.MaxInt32, 0
+		}
+	},
+}
+
+func printLogLines(w io.Writer, lines []string) {
+	for _, line := range lines {
+		_, err := fmt.Fprintln(w, line)
+		internal.Catch(err)
+	}
+}
+
+// parseLogsTime parses an RFC3339 time, or a duration before now.
+func parseLogsTime(name, v string, now time.Time) time.Time {
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(-d)
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	internal.Catch(err, fmt.Sprintf("failed to parse --%s:", name))
+	return t
+}
@@ -0,0 +1,75 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+var restoreConfigPath string
+
+func init() {
+	RootCmd.AddCommand(backupCmd, restoreCmd)
+	restoreCmd.Flags().StringVar(&restoreConfigPath, "config", "",
+		"restore to this config path without a running node (such as on a fresh machine)")
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <file>",
+	Short: "Writes an encrypted backup of the config, keys, persistent transports and dmsgpty whitelist of the node",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		passphrase := readPassphrase("Backup passphrase: ")
+		if readPassphrase("Repeat passphrase: ") != passphrase {
+			log.Fatal("Passphrases differ")
+		}
+		backup, err := rpcClient().Backup(passphrase)
+		internal.Catch(err)
+		internal.Catch(ioutil.WriteFile(args[0], backup, 0600))
+		internal.PrintOutput(map[string]string{"file": args[0]}, func(w io.Writer) {
+			_, err := fmt.Fprintln(w, "Backup written to", args[0])
+			internal.Catch(err)
+		})
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Restores an encrypted backup over the state of the node, taking effect after a restart",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		backup, err := ioutil.ReadFile(args[0])
+		internal.Catch(err)
+		passphrase := readPassphrase("Backup passphrase: ")
+
+		var res *visor.RestoreResult
+		if restoreConfigPath != "" {
+			res, err = visor.RestoreBackup(backup, passphrase, restoreConfigPath)
+		} else {
+			res, err = rpcClient().Restore(backup, passphrase)
+		}
+		internal.Catch(err)
+		internal.PrintOutput(res, func(w io.Writer) {
+			p := func(a ...interface{}) {
+				_, err := fmt.Fprintln(w, a...)
+				internal.Catch(err)
+			}
+			p("public key:", res.Manifest.PubKey)
+			p("created:", res.Manifest.Created.Format("2006-01-02T15:04:05Z07:00"))
+			for _, path := range res.Written {
+				p("restored:", path)
+			}
+			if res.Manifest.KeySealed {
+				p("The secret key is sealed with the passphrase of the backup, which unseals it on start.")
+			}
+			if restoreConfigPath == "" {
+				p("Restart the node to apply the restored state.")
+			}
+		})
+	},
+}
@@ -0,0 +1,41 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+func init() {
+	RootCmd.AddCommand(bandwidthCmd)
+}
+
+var bandwidthCmd = &cobra.Command{
+	Use:   "bandwidth",
+	Short: "Lists the usage of the bandwidth quotas of the node",
+	Run: func(_ *cobra.Command, _ []string) {
+		usage, err := rpcClient().BandwidthUsage()
+		internal.Catch(err)
+
+		internal.PrintOutput(usage, func(out io.Writer) {
+			w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+			_, err := fmt.Fprintln(w, "remote\tperiod\tsince\tused\tlimit\texceeded")
+			internal.Catch(err)
+			for _, u := range usage {
+				remote := u.Remote.String()
+				if u.Relay {
+					remote = "(relay)"
+				}
+				_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%t\n",
+					remote, u.Period, u.Since.Format(time.RFC3339), u.Used, u.Limit, u.Exceeded)
+				internal.Catch(err)
+			}
+			internal.Catch(w.Flush())
+		})
+	},
+}
@@ -0,0 +1,46 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+func init() {
+	RootCmd.AddCommand(checkConfigCmd)
+}
+
+var checkConfigCmd = &cobra.Command{
+	Use:   "check-config [<config-path>]",
+	Short: "Validates a config with the node without applying it, or the config file of the node if none is given",
+	Long: "Validates a config with the node without applying it, or the config file of the node if none is given.\n" +
+		"App binaries and files referenced by the config are checked on the host of the node.",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		var data []byte
+		if len(args) == 1 {
+			var err error
+			data, err = ioutil.ReadFile(filepath.Clean(args[0]))
+			internal.Catch(err)
+		}
+		problems, err := rpcClient().CheckConfig(data)
+		internal.Catch(err)
+		if len(problems) == 0 {
+			internal.PrintOK()
+			return
+		}
+		internal.PrintOutput(problems, func(w io.Writer) {
+			for _, p := range problems {
+				_, err := fmt.Fprintln(w, p)
+				internal.Catch(err)
+			}
+		})
+		os.Exit(1)
+	},
+}
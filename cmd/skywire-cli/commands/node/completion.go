@@ -0,0 +1,90 @@
+package node
+
+import (
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+// completionDialTimeout is short, so that completion does not hang if the visor is not running.
+const completionDialTimeout = 500 * time.Millisecond
+
+func init() {
+	internal.RegisterCompleter(internal.CompleteTransportID, completeTransportIDs)
+	internal.RegisterCompleter(internal.CompleteApp, completeApps)
+	internal.RegisterCompleter(internal.CompletePK, completePKs)
+
+	internal.CompleteArgs(tpCmd, internal.CompleteTransportID)
+	internal.CompleteArgs(tpStatsCmd, internal.CompleteTransportID)
+	internal.CompleteArgs(rmTpCmd, internal.CompleteTransportID)
+	internal.CompleteArgs(addTpCmd, internal.CompletePK)
+	internal.CompleteArgs(pingCmd, internal.CompletePK)
+	internal.CompleteArgs(startAppCmd, internal.CompleteApp)
+	internal.CompleteArgs(stopAppCmd, internal.CompleteApp)
+	internal.CompleteArgs(setAppAutostartCmd, internal.CompleteApp, "on|off")
+	internal.CompleteArgs(appLogsSinceCmd, internal.CompleteApp)
+	internal.CompleteArgs(appLogsCmd, internal.CompleteApp)
+	internal.CompleteArgs(trustedAddCmd, internal.CompletePK+"...")
+	internal.CompleteArgs(trustedRmCmd, internal.CompletePK+"...")
+	internal.CompleteArgs(ptyWhitelistAddCmd, internal.CompletePK+"...")
+	internal.CompleteArgs(ptyWhitelistRemoveCmd, internal.CompletePK+"...")
+	internal.CompleteArgs(addSTCPCmd, internal.CompletePK)
+	internal.CompleteArgs(rmSTCPCmd, internal.CompletePK)
+	internal.CompleteArgs(logLevelCmd, "debug|info|warn|error|fatal|panic")
+}
+
+func completeTransportIDs() ([]string, error) {
+	rpc, err := dialRPC(completionDialTimeout, rpcConnDuration)
+	if err != nil {
+		return nil, err
+	}
+	tps, err := rpc.Transports(nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(tps))
+	for i, tp := range tps {
+		ids[i] = tp.ID.String()
+	}
+	return ids, nil
+}
+
+func completeApps() ([]string, error) {
+	rpc, err := dialRPC(completionDialTimeout, rpcConnDuration)
+	if err != nil {
+		return nil, err
+	}
+	apps, err := rpc.Apps()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(apps))
+	for i, app := range apps {
+		names[i] = app.Name
+	}
+	return names, nil
+}
+
+// completePKs completes the public keys of the remotes of transports and of the trusted visors.
+func completePKs() ([]string, error) {
+	rpc, err := dialRPC(completionDialTimeout, rpcConnDuration)
+	if err != nil {
+		return nil, err
+	}
+	tps, err := rpc.Transports(nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	pks := make([]string, 0, len(tps))
+	for _, tp := range tps {
+		pks = append(pks, tp.Remote.String())
+	}
+	trusted, err := rpc.TrustedVisors()
+	if err != nil {
+		return pks, nil
+	}
+	for _, pk := range trusted {
+		pks = append(pks, pk.String())
+	}
+	return pks, nil
+}
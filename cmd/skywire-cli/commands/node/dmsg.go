@@ -0,0 +1,36 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+func init() {
+	RootCmd.AddCommand(lsDmsgSessionsCmd)
+}
+
+var lsDmsgSessionsCmd = &cobra.Command{
+	Use:   "ls-dmsg-sessions",
+	Short: "Lists the sessions with dmsg servers",
+	Run: func(_ *cobra.Command, _ []string) {
+		sessions, err := rpcClient().DmsgSessions()
+		internal.Catch(err)
+
+		internal.PrintOutput(sessions, func(out io.Writer) {
+			w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+			_, err := fmt.Fprintln(w, "server\taddress\tconnected\tstreams\tsent\treceived\treconnects\trtt")
+			internal.Catch(err)
+			for _, s := range sessions {
+				_, err = fmt.Fprintf(w, "%s\t%s\t%t\t%d\t%d\t%d\t%d\t%s\n",
+					s.Server, s.Address, s.Connected, s.Streams, s.BytesSent, s.BytesRecv, s.Reconnects, s.RTT)
+				internal.Catch(err)
+			}
+			internal.Catch(w.Flush())
+		})
+	},
+}
@@ -0,0 +1,234 @@
+package node
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// Deployment types of the config wizard.
+const (
+	deployHome       = "home"       // behind NAT, reachable over dmsg only.
+	deployPublic     = "public"     // reachable on a public address, serving stcp and a proxy server.
+	deployHypervisor = "hypervisor" // managed by hypervisors.
+)
+
+// defaultSTCPAddr is the stcp address suggested by the config wizard.
+const defaultSTCPAddr = ":7033"
+
+// prompter asks questions on out and reads the answers from in, until they are valid.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask asks the question, returning the answer or def if the answer is empty, once parse accepts it.
+func (p *prompter) ask(question, def string, parse func(answer string) error) (string, error) {
+	for {
+		if def != "" {
+			_, _ = fmt.Fprintf(p.out, "%s [%s]: ", question, def) //nolint:errcheck
+		} else {
+			_, _ = fmt.Fprintf(p.out, "%s: ", question) //nolint:errcheck
+		}
+		answer, err := p.in.ReadString('\n')
+		if err != nil && (err != io.EOF || answer == "") {
+			return "", err
+		}
+		if answer = strings.TrimSpace(answer); answer == "" {
+			answer = def
+		}
+		if err := parse(answer); err != nil {
+			_, _ = fmt.Fprintln(p.out, "  ", err) //nolint:errcheck
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// choose asks to choose one of the options, by name or number.
+func (p *prompter) choose(question string, options []string, def string) (string, error) {
+	for i, o := range options {
+		_, _ = fmt.Fprintf(p.out, "  %d) %s\n", i+1, o) //nolint:errcheck
+	}
+	var choice string
+	_, err := p.ask(question, def, func(answer string) error {
+		if i, err := strconv.Atoi(answer); err == nil && i >= 1 && i <= len(options) {
+			choice = options[i-1]
+			return nil
+		}
+		for _, o := range options {
+			if strings.HasPrefix(o, answer+" ") || o == answer {
+				choice = o
+				return nil
+			}
+		}
+		return fmt.Errorf("choose one of 1-%d", len(options))
+	})
+	return choice, err
+}
+
+// confirm asks a yes or no question.
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	defAnswer := "n"
+	if def {
+		defAnswer = "y"
+	}
+	var yes bool
+	_, err := p.ask(question+" (y/n)", defAnswer, func(answer string) error {
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			yes = true
+		case "n", "no":
+			yes = false
+		default:
+			return errors.New("answer y or n")
+		}
+		return nil
+	})
+	return yes, err
+}
+
+// runConfigWizard asks about the deployment of the node, and adjusts the config to it.
+func runConfigWizard(conf *visor.Config, p *prompter) error {
+	deployments := []string{
+		deployHome + " (behind NAT, reachable over dmsg only)",
+		deployPublic + " (reachable on a public address, offering stcp and a proxy server)",
+		deployHypervisor + " (managed by hypervisors)",
+	}
+	deployment, err := p.choose("Deployment type", deployments, "1")
+	if err != nil {
+		return err
+	}
+	deployment = strings.Fields(deployment)[0]
+
+	if err := configureApps(conf, p, deployment); err != nil {
+		return err
+	}
+	if err := configureSTCP(conf, p, deployment); err != nil {
+		return err
+	}
+	return configureHypervisors(conf, p, deployment)
+}
+
+func configureApps(conf *visor.Config, p *prompter, deployment string) error {
+	apps := []struct {
+		name string
+		desc string
+		def  bool
+		conf visor.AppConfig
+	}{
+		{name: skyenv.SkychatName, desc: "chat with other visors", def: true, conf: defaultSkychatConfig()},
+		{name: skyenv.SkyproxyName, desc: "proxy server for other visors", def: deployment == deployPublic,
+			conf: defaultSkyproxyConfig("")},
+		{name: skyenv.SkyproxyClientName, desc: "proxy client connecting to a proxy server", def: true,
+			conf: defaultSkyproxyClientConfig()},
+	}
+	conf.Apps = make([]visor.AppConfig, 0, len(apps))
+	for _, app := range apps {
+		enabled, err := p.confirm(fmt.Sprintf("Enable %s (%s)?", app.name, app.desc), app.def)
+		if err != nil {
+			return err
+		}
+		if enabled {
+			conf.Apps = append(conf.Apps, app.conf)
+		}
+	}
+	return nil
+}
+
+func configureSTCP(conf *visor.Config, p *prompter, deployment string) error {
+	enabled, err := p.confirm("Accept stcp transports on a TCP address?", deployment == deployPublic)
+	if err != nil || !enabled {
+		return err
+	}
+	conf.STCP.LocalAddr, err = p.ask("stcp address", defaultSTCPAddr, validateAddr)
+	if err != nil {
+		return err
+	}
+	if deployment != deployPublic {
+		conf.STCP.PortMapping, err = p.confirm("Map the stcp port on the router with NAT-PMP/UPnP?", true)
+	}
+	return err
+}
+
+// validateAddr validates a TCP address of the form [host]:port.
+func validateAddr(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+		return fmt.Errorf("invalid port: %s", port)
+	}
+	return nil
+}
+
+func configureHypervisors(conf *visor.Config, p *prompter, deployment string) error {
+	if deployment != deployHypervisor {
+		managed, err := p.confirm("Manage the node with a hypervisor?", false)
+		if err != nil || !managed {
+			return err
+		}
+	}
+	var pks []cipher.PubKey
+	_, err := p.ask("Public keys of hypervisors (comma-separated)", "", func(answer string) error {
+		pks = pks[:0]
+		for _, s := range strings.Split(answer, ",") {
+			var pk cipher.PubKey
+			if err := pk.Set(strings.TrimSpace(s)); err != nil || pk.Null() {
+				return fmt.Errorf("invalid public key: %s", s)
+			}
+			pks = append(pks, pk)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	conf.Hypervisors = make([]visor.HypervisorConfig, 0, len(pks))
+	for _, pk := range pks {
+		addr, err := p.ask(fmt.Sprintf("Address of hypervisor %s (empty to connect over dmsg)", pk), "",
+			func(answer string) error {
+				if answer == "" {
+					return nil
+				}
+				return validateAddr(answer)
+			})
+		if err != nil {
+			return err
+		}
+		if addr == "" && conf.DmsgRPC == nil {
+			conf.DmsgRPC = &visor.DmsgRPCConfig{}
+		}
+		conf.Hypervisors = append(conf.Hypervisors, visor.HypervisorConfig{PubKey: pk, Addr: addr})
+	}
+	return nil
+}
+
+// validateWizardConfig checks the config, and asks whether to write it anyway if it has problems, such as app
+// binaries which are not built yet.
+func validateWizardConfig(conf *visor.Config, p *prompter) (bool, error) {
+	data, err := json.Marshal(conf)
+	if err != nil {
+		return false, err
+	}
+	problems := visor.CheckConfig(data, "")
+	if len(problems) == 0 {
+		return true, nil
+	}
+	_, _ = fmt.Fprintln(p.out, "The config has problems:") //nolint:errcheck
+	for _, problem := range problems {
+		_, _ = fmt.Fprintln(p.out, "  ", problem) //nolint:errcheck
+	}
+	return p.confirm("Write the config anyway?", false)
+}
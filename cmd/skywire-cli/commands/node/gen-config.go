@@ -1,7 +1,9 @@
 package node
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -24,6 +26,8 @@ var (
 	replace       bool
 	configLocType = pathutil.WorkingDirLoc
 	testenv       bool
+	dmsgSessions  int
+	interactive   bool
 )
 
 func init() {
@@ -31,6 +35,8 @@ func init() {
 	genConfigCmd.Flags().BoolVarP(&replace, "replace", "r", false, "whether to allow rewrite of a file that already exists.")
 	genConfigCmd.Flags().VarP(&configLocType, "type", "m", fmt.Sprintf("config generation mode. Valid values: %v", pathutil.AllConfigLocationTypes()))
 	genConfigCmd.Flags().BoolVarP(&testenv, "testing-environment", "t", false, "whether to use production or test deployment service.")
+	genConfigCmd.Flags().IntVar(&dmsgSessions, "dmsg-sessions", 1, "min number of concurrent dmsg server sessions.")
+	genConfigCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "asks about the deployment of the node, such as apps, stcp and hypervisors.")
 }
 
 var genConfigCmd = &cobra.Command{
@@ -58,6 +64,15 @@ var genConfigCmd = &cobra.Command{
 		default:
 			log.Fatalln("invalid config type:", configLocType)
 		}
+		if interactive {
+			p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+			if err := runConfigWizard(conf, p); err != nil {
+				log.WithError(err).Fatalln("Config wizard failed")
+			}
+			if ok, err := validateWizardConfig(conf, p); err != nil || !ok {
+				log.WithError(err).Fatalln("Config was not written")
+			}
+		}
 		pathutil.WriteJSONConfig(conf, output, replace)
 	},
 }
@@ -66,6 +81,9 @@ func homeConfig() *visor.Config {
 	c := defaultConfig()
 	c.AppsPath = filepath.Join(pathutil.HomeDir(), ".skycoin/skywire/apps")
 	c.Transport.LogStore.Location = filepath.Join(pathutil.HomeDir(), ".skycoin/skywire/transport_logs")
+	c.Transport.LabelStore.Location = filepath.Join(pathutil.HomeDir(), ".skycoin/skywire/transport_labels.json")
+	c.Transport.NonceFile = filepath.Join(pathutil.HomeDir(), ".skycoin/skywire/transport_nonces.json")
+	c.Messaging.CacheFile = filepath.Join(pathutil.HomeDir(), ".skycoin/skywire/dmsg_discovery_cache.json")
 	c.Routing.Table.Location = filepath.Join(pathutil.HomeDir(), ".skycoin/skywire/routing.db")
 	return c
 }
@@ -74,13 +92,16 @@ func localConfig() *visor.Config {
 	c := defaultConfig()
 	c.AppsPath = "/usr/local/skycoin/skywire/apps"
 	c.Transport.LogStore.Location = "/usr/local/skycoin/skywire/transport_logs"
+	c.Transport.LabelStore.Location = "/usr/local/skycoin/skywire/transport_labels.json"
+	c.Transport.NonceFile = "/usr/local/skycoin/skywire/transport_nonces.json"
+	c.Messaging.CacheFile = "/usr/local/skycoin/skywire/dmsg_discovery_cache.json"
 	c.Routing.Table.Location = "/usr/local/skycoin/skywire/routing.db"
 	return c
 }
 
 func defaultConfig() *visor.Config {
 	conf := &visor.Config{}
-	conf.Version = "1.0"
+	conf.Version = visor.ConfigVersion
 
 	pk, sk := cipher.GenerateKeyPair()
 	conf.Node.StaticPubKey = pk
@@ -91,7 +112,8 @@ func defaultConfig() *visor.Config {
 	} else {
 		conf.Messaging.Discovery = skyenv.DefaultDmsgDiscAddr
 	}
-	conf.Messaging.ServerCount = 1
+	conf.Messaging.ServerCount = dmsgSessions
+	conf.Messaging.CacheFile = "./skywire/dmsg_discovery_cache.json"
 
 	ptyConf := defaultDmsgPtyConfig()
 	conf.DmsgPty = &ptyConf
@@ -113,6 +135,9 @@ func defaultConfig() *visor.Config {
 
 	conf.Transport.LogStore.Type = "file"
 	conf.Transport.LogStore.Location = "./skywire/transport_logs"
+	conf.Transport.LabelStore.Type = "file"
+	conf.Transport.LabelStore.Location = "./skywire/transport_labels.json"
+	conf.Transport.NonceFile = "./skywire/transport_nonces.json"
 
 	if testenv {
 		conf.Routing.RouteFinder = skyenv.TestRouteFinderAddr
@@ -0,0 +1,40 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+func init() {
+	RootCmd.AddCommand(healthCmd)
+}
+
+var healthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Checks the health of the external services the node depends on",
+	Run: func(_ *cobra.Command, _ []string) {
+		health, err := rpcClient().Health()
+		internal.Catch(err)
+
+		internal.PrintOutput(health, func(out io.Writer) {
+			w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+			_, err := fmt.Fprintln(w, "service\taddress\thealthy\tstatus\tlatency\tversion\terror")
+			internal.Catch(err)
+			for _, s := range health.Services {
+				_, err = fmt.Fprintf(w, "%s\t%s\t%t\t%d\t%s\t%s\t%s\n",
+					s.Service, s.Address, s.Healthy(), s.Status, s.Latency, s.Version, s.Error)
+				internal.Catch(err)
+			}
+			internal.Catch(w.Flush())
+			for _, warning := range health.Warnings {
+				_, err = fmt.Fprintln(out, "warning:", warning)
+				internal.Catch(err)
+			}
+		})
+	},
+}
@@ -0,0 +1,94 @@
+package node
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+var keyGracePeriod time.Duration
+
+func init() {
+	RootCmd.AddCommand(rotateKeysCmd)
+	rotateKeysCmd.Flags().DurationVar(&keyGracePeriod, "grace", visor.DefaultKeyGracePeriod,
+		"period for which the old key is announced as deprecated and its transports are migrated")
+}
+
+var rotateKeysCmd = &cobra.Command{
+	Use:   "rotate-keys",
+	Short: "Replaces the keypair of the node in its config, taking effect on the next restart",
+	Run: func(_ *cobra.Command, _ []string) {
+		res, err := rpcClient().RotateKeys(keyGracePeriod)
+		internal.Catch(err)
+		internal.PrintOutput(res, func(w io.Writer) {
+			_, err := fmt.Fprintf(w, "old public key: %s\nnew public key: %s\ndeprecated until: %s\n"+
+				"transports to migrate: %d\nRestart the node to apply the new keys.\n",
+				res.OldPubKey, res.NewPubKey, res.Until.Format(time.RFC3339), res.Transports)
+			internal.Catch(err)
+		})
+	},
+}
+
+var sealKeyring bool
+
+func init() {
+	RootCmd.AddCommand(sealKeyCmd, unsealCmd)
+	sealKeyCmd.Flags().BoolVar(&sealKeyring, "keyring", false,
+		"seal the key with a random passphrase stored in the OS keyring of the node")
+}
+
+var sealKeyCmd = &cobra.Command{
+	Use:   "seal-key",
+	Short: "Encrypts the secret key of the node in its config with a passphrase",
+	Long: "Encrypts the secret key of the node in its config with a passphrase, read from the terminal or STDIN.\n\n" +
+		"On startup, the visor unseals the key with the passphrase of $" + visor.KeyPassphraseEnv + ", the OS keyring, " +
+		"or the terminal, or waits for 'skywire-cli node unseal' otherwise.",
+	Run: func(_ *cobra.Command, _ []string) {
+		var passphrase string
+		if !sealKeyring {
+			passphrase = readPassphrase("Passphrase: ")
+			if readPassphrase("Repeat passphrase: ") != passphrase {
+				log.Fatal("Passphrases differ")
+			}
+		}
+		internal.Catch(rpcClient().SealKey(passphrase, sealKeyring))
+		internal.PrintOK()
+	},
+}
+
+var unsealCmd = &cobra.Command{
+	Use:   "unseal",
+	Short: "Unseals the secret key of a visor waiting for its passphrase on startup",
+	Run: func(_ *cobra.Command, _ []string) {
+		internal.Catch(rpcClient().Unseal(readPassphrase("Passphrase: ")))
+		internal.PrintOK()
+	},
+}
+
+// readPassphrase reads a passphrase from the terminal without echoing it, or a line of STDIN if it is not a terminal.
+func readPassphrase(prompt string) string {
+	fd := int(os.Stdin.Fd())
+	if terminal.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt) //nolint:errcheck
+		passphrase, err := terminal.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr) //nolint:errcheck
+		internal.Catch(err)
+		return string(passphrase)
+	}
+	line, err := stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		internal.Catch(err)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+var stdin = bufio.NewReader(os.Stdin)
@@ -0,0 +1,56 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+var logModule string
+
+func init() {
+	logLevelCmd.Flags().StringVar(&logModule, "module", "", "module to set the level of (all modules if empty)")
+	RootCmd.AddCommand(logLevelCmd)
+}
+
+var logLevelCmd = &cobra.Command{
+	Use:   "log-level [debug|info|warn|error|fatal|panic]",
+	Short: "Lists or sets the log levels of the node without restarting it",
+	Long: "Lists the log levels of the node if no level is given, or sets the log level of the module (or all " +
+		"modules) otherwise. An empty level (\"\") resets the module to the global level.",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		client := rpcClient()
+		if len(args) == 1 {
+			internal.Catch(client.SetLogLevel(logModule, args[0]))
+			internal.PrintOK()
+			return
+		}
+
+		levels, err := client.LogLevels()
+		internal.Catch(err)
+		modules := make([]string, 0, len(levels.Modules))
+		for m := range levels.Modules {
+			modules = append(modules, m)
+		}
+		sort.Strings(modules)
+
+		internal.PrintOutput(levels, func(out io.Writer) {
+			w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+			_, err := fmt.Fprintln(w, "module\tlevel")
+			internal.Catch(err)
+			_, err = fmt.Fprintf(w, "*\t%s\n", levels.Global)
+			internal.Catch(err)
+			for _, m := range modules {
+				_, err = fmt.Fprintf(w, "%s\t%s\n", m, levels.Modules[m])
+				internal.Catch(err)
+			}
+			internal.Catch(w.Flush())
+		})
+	},
+}
@@ -0,0 +1,57 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+var pingIn visor.PingIn
+
+func init() {
+	RootCmd.AddCommand(pingCmd)
+	pingCmd.Flags().IntVarP(&pingIn.Count, "count", "c", visor.DefaultPingCount, "number of packets to send")
+	pingCmd.Flags().IntVar(&pingIn.Size, "size", visor.DefaultPingSize, "size of the payload of the packets")
+	pingCmd.Flags().DurationVar(&pingIn.Interval, "interval", visor.DefaultPingInterval, "delay between sending packets")
+	pingCmd.Flags().DurationVar(&pingIn.Timeout, "timeout", visor.DefaultPingTimeout,
+		"time to wait for the reply of the last packet")
+}
+
+var pingCmd = &cobra.Command{
+	Use:   "ping <remote-public-key>",
+	Short: "Measures the round-trip time and loss of packets over a route to a remote visor",
+	Long: "Measures the round-trip time and loss of packets over a route to a remote visor, which echoes them back " +
+		"from port " + fmt.Sprint(uint16(router.EchoPort)) + ". Exits with status 1 if no packet is echoed back.",
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		pingIn.PK = internal.ParsePK("remote-public-key", args[0])
+		out, err := streamRPCClient().Ping(pingIn)
+		internal.Catch(err)
+
+		internal.PrintOutput(out, func(w io.Writer) {
+			for _, r := range out.Replies {
+				var err error
+				if r.Lost {
+					_, err = fmt.Fprintf(w, "seq=%d lost\n", r.Seq)
+				} else {
+					_, err = fmt.Fprintf(w, "seq=%d rtt=%s\n", r.Seq, r.RTT.Round(time.Microsecond))
+				}
+				internal.Catch(err)
+			}
+			_, err := fmt.Fprintf(w, "%d sent, %d received, %.1f%% loss, rtt min/avg/max = %s/%s/%s\n",
+				out.Sent, out.Received, out.Loss*100, out.MinRTT.Round(time.Microsecond),
+				out.AvgRTT.Round(time.Microsecond), out.MaxRTT.Round(time.Microsecond))
+			internal.Catch(err)
+		})
+		if out.Received == 0 {
+			os.Exit(1)
+		}
+	},
+}
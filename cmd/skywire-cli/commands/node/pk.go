@@ -2,12 +2,32 @@ package node
 
 import (
 	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
 
+	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/qrcode"
+)
+
+var (
+	pkQR       bool
+	pkQRInvert bool
+	pkQRPNG    string
 )
 
+// pkQRScale is the number of pixels per module of the QR codes written to PNG files.
+const pkQRScale = 8
+
 func init() {
 	RootCmd.AddCommand(pkCmd)
+	pkCmd.Flags().BoolVar(&pkQR, "qr", false, "also print the public key as a QR code")
+	pkCmd.Flags().BoolVar(&pkQRInvert, "qr-invert", false, "print the QR code for terminals with dark text on a light background")
+	pkCmd.Flags().StringVar(&pkQRPNG, "qr-png", "", "if specified, writes the QR code of the public key to a PNG file")
 }
 
 var pkCmd = &cobra.Command{
@@ -21,6 +41,35 @@ var pkCmd = &cobra.Command{
 			log.Fatal("Failed to connect:", err)
 		}
 
-		fmt.Println(summary.PubKey)
+		var code *qrcode.Code
+		if pkQR || pkQRPNG != "" {
+			code, err = qrcode.Encode([]byte(summary.PubKey.Hex()))
+			internal.Catch(err)
+		}
+		if pkQRPNG != "" {
+			internal.Catch(writeQRPNG(code, pkQRPNG))
+		}
+
+		internal.PrintOutput(map[string]cipher.PubKey{"public_key": summary.PubKey}, func(w io.Writer) {
+			if pkQR {
+				_, err := fmt.Fprint(w, code.String(pkQRInvert))
+				internal.Catch(err)
+			}
+			_, err := fmt.Fprintln(w, summary.PubKey)
+			internal.Catch(err)
+		})
 	},
 }
+
+func writeQRPNG(code *qrcode.Code, path string) (err error) {
+	f, err := os.Create(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := f.Close(); err == nil {
+			err = cErr
+		}
+	}()
+	return png.Encode(f, code.Image(pkQRScale))
+}
@@ -0,0 +1,64 @@
+package node
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+func init() {
+	RootCmd.AddCommand(ptyCmd)
+	ptyCmd.AddCommand(ptyWhitelistCmd, ptyWhitelistAddCmd, ptyWhitelistRemoveCmd)
+}
+
+var ptyCmd = &cobra.Command{
+	Use:   "pty",
+	Short: "Manages the dmsgpty host of the node",
+}
+
+var ptyWhitelistCmd = &cobra.Command{
+	Use:   "whitelist",
+	Short: "Lists the public keys allowed to open remote ptys",
+	Run: func(_ *cobra.Command, _ []string) {
+		pks, err := rpcClient().PtyWhitelist()
+		internal.Catch(err)
+		internal.PrintOutput(pks, func(w io.Writer) {
+			for _, pk := range pks {
+				_, err := fmt.Fprintln(w, pk)
+				internal.Catch(err)
+			}
+		})
+	},
+}
+
+var ptyWhitelistAddCmd = &cobra.Command{
+	Use:   "whitelist-add <public-key>...",
+	Short: "Allows public keys to open remote ptys",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		internal.Catch(rpcClient().AddPtyWhitelist(parsePKs(args)...))
+		internal.PrintOK()
+	},
+}
+
+var ptyWhitelistRemoveCmd = &cobra.Command{
+	Use:   "whitelist-remove <public-key>...",
+	Short: "Disallows public keys to open remote ptys",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		internal.Catch(rpcClient().RemovePtyWhitelist(parsePKs(args)...))
+		internal.PrintOK()
+	},
+}
+
+func parsePKs(args []string) []cipher.PubKey {
+	pks := make([]cipher.PubKey, len(args))
+	for i, arg := range args {
+		pks[i] = internal.ParsePK("public-key", arg)
+	}
+	return pks
+}
@@ -0,0 +1,29 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+func init() {
+	RootCmd.AddCommand(reloadCmd)
+}
+
+var reloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reloads the config of the node, applying the changes which do not require a restart",
+	Run: func(_ *cobra.Command, _ []string) {
+		res, err := rpcClient().Reload()
+		internal.Catch(err)
+		internal.PrintOutput(res, func(w io.Writer) {
+			_, err := fmt.Fprintf(w, "applied:          %s\nrestart required: %s\n",
+				strings.Join(res.Applied, ", "), strings.Join(res.RestartRequired, ", "))
+			internal.Catch(err)
+		})
+	},
+}
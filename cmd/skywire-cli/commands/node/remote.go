@@ -0,0 +1,112 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/disc"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/pathutil"
+)
+
+var (
+	remotePK      string
+	remoteKeyFile string
+	remotePort    uint16
+	replaceRemote bool
+)
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&remotePK, "remote", "",
+		"public key of a remote visor to manage over dmsg instead of the local RPC server")
+	RootCmd.PersistentFlags().StringVar(&remoteKeyFile, "remote-key", defaultRemoteKeyFile(),
+		"file of the local keys which authenticate to remote visors (see 'remote-keygen')")
+	RootCmd.PersistentFlags().Uint16Var(&remotePort, "remote-port", skyenv.DmsgRPCPort,
+		"dmsg port on which the remote visor serves its RPC")
+
+	RootCmd.AddCommand(remoteKeygenCmd)
+	remoteKeygenCmd.Flags().BoolVarP(&replaceRemote, "replace", "r", false, "whether to replace an existing key file")
+}
+
+func defaultRemoteKeyFile() string {
+	return filepath.Join(pathutil.HomeDir(), ".skycoin/skywire/cli-remote.json")
+}
+
+// remoteKeys is the content of the key file with which the CLI connects to remote visors over dmsg. Remote visors
+// only accept the public key if it is in the 'hypervisors' field of their config, and serve their RPC over dmsg
+// if the 'dmsg_rpc' field is set.
+type remoteKeys struct {
+	PK            cipher.PubKey `json:"public_key"`
+	SK            cipher.SecKey `json:"secret_key"`
+	DmsgDiscovery string        `json:"dmsg_discovery"`
+}
+
+var remoteKeygenCmd = &cobra.Command{
+	Use:   "remote-keygen",
+	Short: "Generates the keys with which to manage remote visors over dmsg",
+	Long: "Generates the keys with which to manage remote visors over dmsg, and writes them to the file of " +
+		"--remote-key.\n\nAdd the printed public key to the 'hypervisors' field of the config of the remote visors, " +
+		"and set their 'dmsg_rpc' field, then pass --remote <visor-public-key> to the node sub-commands.",
+	Run: func(_ *cobra.Command, _ []string) {
+		pk, sk := cipher.GenerateKeyPair()
+		keys := remoteKeys{PK: pk, SK: sk, DmsgDiscovery: skyenv.DefaultDmsgDiscAddr}
+		pathutil.WriteJSONConfig(keys, remoteKeyFile, replaceRemote)
+		internal.PrintOutput(keys.PK, func(w io.Writer) {
+			_, err := fmt.Fprintln(w, keys.PK)
+			internal.Catch(err)
+		})
+	},
+}
+
+func readRemoteKeys(path string) (remoteKeys, error) {
+	var keys remoteKeys
+	raw, err := ioutil.ReadFile(path) // nolint:gosec
+	if err != nil {
+		return keys, fmt.Errorf("failed to read remote key file (see 'remote-keygen'): %v", err)
+	}
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return keys, fmt.Errorf("invalid remote key file %s: %v", path, err)
+	}
+	if keys.DmsgDiscovery == "" {
+		keys.DmsgDiscovery = skyenv.DefaultDmsgDiscAddr
+	}
+	return keys, nil
+}
+
+// dialRemote connects to the RPC of the visor of --remote over dmsg, with the keys of --remote-key.
+func dialRemote(dialTimeout time.Duration) (net.Conn, error) {
+	var pk cipher.PubKey
+	if err := pk.Set(remotePK); err != nil {
+		return nil, fmt.Errorf("invalid --remote public key: %v", err)
+	}
+	keys, err := readRemoteKeys(remoteKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	dmsgC := dmsg.NewClient(keys.PK, keys.SK, disc.NewHTTP(keys.DmsgDiscovery),
+		dmsg.SetLogger(logging.MustGetLogger("skywire-cli.dmsgC")))
+	if err := dmsgC.InitiateServerConnections(ctx, 1); err != nil {
+		return nil, fmt.Errorf("failed to connect to dmsg servers: %v", err)
+	}
+	tp, err := dmsgC.Dial(ctx, pk, remotePort)
+	if err != nil {
+		_ = dmsgC.Close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to dial remote visor %s over dmsg: %v", pk, err)
+	}
+	return tp, nil
+}
@@ -0,0 +1,38 @@
+package node
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func init() {
+	RootCmd.AddCommand(rewardAddressCmd)
+}
+
+var rewardAddressCmd = &cobra.Command{
+	Use:   "reward-address [<address>]",
+	Short: "Shows or sets the Skycoin address which rewards of the node are paid to",
+	Long: "Shows the reward address of the node if no address is given, or sets it otherwise. An empty address " +
+		"(\"\") unsets the reward address.",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		client := rpcClient()
+		if len(args) == 1 {
+			internal.Catch(visor.ValidateRewardAddress(args[0]))
+			internal.Catch(client.SetRewardAddress(args[0]))
+			internal.PrintOK()
+			return
+		}
+		summary, err := client.Summary()
+		internal.Catch(err)
+		internal.PrintOutput(map[string]string{"reward_address": summary.RewardAddress}, func(w io.Writer) {
+			_, err := fmt.Fprintln(w, summary.RewardAddress)
+			internal.Catch(err)
+		})
+	},
+}
@@ -1,39 +1,107 @@
 package node
 
 import (
+	"fmt"
 	"net"
 	"net/rpc"
+	"os"
 	"time"
 
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 	"github.com/spf13/cobra"
 
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
 )
 
 var log = logging.MustGetLogger("skywire-cli")
 
-var rpcAddr string
+var (
+	rpcAddr  string
+	rpcToken string
+)
 
 func init() {
 	RootCmd.PersistentFlags().StringVarP(&rpcAddr, "rpc", "", "localhost:3435", "RPC server address")
+	RootCmd.PersistentFlags().StringVar(&rpcToken, "rpc-token", os.Getenv(visor.RPCTokenEnv),
+		"token authenticating mutating RPC calls (defaults to $"+visor.RPCTokenEnv+")")
 }
 
 // RootCmd contains commands that interact with the skywire-visor
 var RootCmd = &cobra.Command{
 	Use:   "node",
-	Short: "Contains sub-commands that interact with the local Skywire Visor",
+	Short: "Contains sub-commands that interact with the local Skywire Visor, or a remote one over dmsg",
 }
 
 func rpcClient() visor.RPCClient {
-	conn, err := net.DialTimeout("tcp", rpcAddr, rpcDialTimeout)
+	return dialRPCClient(rpcConnDuration)
+}
+
+// streamRPCClient returns an RPC client for commands which run until interrupted, such as those which subscribe to
+// events.
+func streamRPCClient() visor.RPCClient {
+	return dialRPCClient(0)
+}
+
+// dialRPCClient dials the RPC server, limiting the duration of the connection unless connDuration is 0.
+func dialRPCClient(connDuration time.Duration) visor.RPCClient {
+	client, err := dialRPC(rpcDialTimeout, connDuration)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client
+}
+
+// dialRPC dials the RPC server of --rpc, or of the remote visor of --remote over dmsg if it is set.
+func dialRPC(dialTimeout, connDuration time.Duration) (visor.RPCClient, error) {
+	if err := applyContext(); err != nil {
+		return nil, err
+	}
+	var conn net.Conn
+	var err error
+	if remotePK != "" {
+		conn, err = dialRemote(dialTimeout)
+	} else {
+		conn, err = net.DialTimeout("tcp", rpcAddr, dialTimeout)
+	}
 	if err != nil {
-		log.Fatal("RPC connection failed:", err)
+		return nil, fmt.Errorf("RPC connection failed: %v", err)
+	}
+	if connDuration > 0 {
+		if err := conn.SetDeadline(time.Now().Add(connDuration)); err != nil {
+			return nil, fmt.Errorf("RPC connection failed: %v", err)
+		}
+	}
+	client := visor.NewRPCClient(rpc.NewClient(conn), visor.RPCPrefix)
+	if rpcToken != "" {
+		if err := client.Authenticate(rpcToken); err != nil {
+			return nil, fmt.Errorf("RPC authentication failed: %v", err)
+		}
+	}
+	return client, nil
+}
+
+// applyContext sets the flags which select and authenticate to the visor from the context of the CLI config, unless
+// they are given. Giving either --rpc or --remote ignores the visor of the context.
+func applyContext() error {
+	ctx, err := internal.CurrentContext()
+	if err != nil || ctx == nil {
+		return err
+	}
+	flags := RootCmd.PersistentFlags()
+	if !flags.Changed("rpc") && !flags.Changed("remote") {
+		if ctx.RPCAddr != "" {
+			rpcAddr = ctx.RPCAddr
+		}
+		remotePK = ctx.Remote
+	}
+	if !flags.Changed("remote-key") && ctx.RemoteKey != "" {
+		remoteKeyFile = ctx.RemoteKey
 	}
-	if err := conn.SetDeadline(time.Now().Add(rpcConnDuration)); err != nil {
-		log.Fatal("RPC connection failed:", err)
+	if !flags.Changed("rpc-token") && ctx.RPCToken != "" {
+		rpcToken = ctx.RPCToken
 	}
-	return visor.NewRPCClient(rpc.NewClient(conn), visor.RPCPrefix)
+	return nil
 }
 
 const (
@@ -0,0 +1,87 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func init() {
+	RootCmd.AddCommand(routeCmd)
+	routeCmd.AddCommand(routeLsCmd, routeAddCmd, routeRmCmd)
+	routeAddCmd.Flags().DurationVar(&keepAlive, "keep-alive", router.DefaultRouteKeepAlive, keepAliveUsage)
+}
+
+var routeCmd = &cobra.Command{
+	Use:   "route",
+	Short: "Manages the routing rules of the node, for debugging routes",
+}
+
+var routeLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "Lists the decoded routing rules with their next hops and expiry",
+	Run: func(_ *cobra.Command, _ []string) {
+		infos, err := rpcClient().RuleInfos()
+		internal.Catch(err)
+		internal.PrintOutput(infos, func(w io.Writer) { printRuleInfos(w, infos...) })
+	},
+}
+
+var routeAddCmd = &cobra.Command{
+	Use:   "add " + addRuleUsage,
+	Short: "Injects a routing rule, which expires after the keep-alive duration unless it is used",
+	Args:  addRuleArgs,
+	Run:   runAddRule,
+}
+
+var routeRmCmd = &cobra.Command{
+	Use:   "rm <route-id>...",
+	Short: "Removes routing rules",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		ids := make([]routing.RouteID, len(args))
+		for i, arg := range args {
+			ids[i] = routing.RouteID(parseUint("route-id", arg, 32))
+		}
+		rpc := rpcClient()
+		for _, id := range ids {
+			internal.Catch(rpc.RemoveRoutingRule(id), fmt.Sprintf("failed to remove rule %d:", id))
+		}
+		internal.PrintOK()
+	},
+}
+
+func printRuleInfos(out io.Writer, infos ...visor.RuleInfo) {
+	w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+	_, err := fmt.Fprintln(w, "id\ttype\tremote-pk\tlocal-port\tremote-port\tnext-route-id\tnext-hop\texpires")
+	internal.Catch(err)
+	for _, info := range infos {
+		s := info.Summary
+		expires := "-"
+		if info.ExpiresAt != nil {
+			expires = fmt.Sprintf("%s (in %s)", info.ExpiresAt.Format(time.RFC3339),
+				time.Until(*info.ExpiresAt).Truncate(time.Second))
+		}
+		if s.AppFields != nil {
+			_, err = fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%d\t%s\t%s\n", info.RouteID, s.Type, s.AppFields.RemotePK,
+				s.AppFields.LocalPort, s.AppFields.RemotePort, s.AppFields.RespRID, "-", expires)
+		} else {
+			nextHop := "unknown (" + s.ForwardFields.NextTID.String() + ")"
+			if info.NextHop != nil {
+				nextHop = info.NextHop.String()
+			}
+			_, err = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", info.RouteID, s.Type, "-", "-", "-",
+				s.ForwardFields.NextRID, nextHop, expires)
+		}
+		internal.Catch(err)
+	}
+	internal.Catch(w.Flush())
+}
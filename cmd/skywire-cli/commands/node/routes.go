@@ -1,10 +1,10 @@
 package node
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"strconv"
 	"text/tabwriter"
 	"time"
@@ -32,8 +32,7 @@ var lsRulesCmd = &cobra.Command{
 	Run: func(_ *cobra.Command, _ []string) {
 		rules, err := rpcClient().RoutingRules()
 		internal.Catch(err)
-
-		printRoutingRules(rules...)
+		internal.PrintOutput(makeRuleOutputs(rules...), func(w io.Writer) { printRoutingRules(w, rules...) })
 	},
 }
 
@@ -48,7 +47,8 @@ var ruleCmd = &cobra.Command{
 		rule, err := rpcClient().RoutingRule(routing.RouteID(id))
 		internal.Catch(err)
 
-		printRoutingRules(&visor.RoutingEntry{Key: rule.RouteID(), Value: rule})
+		entry := &visor.RoutingEntry{Key: rule.RouteID(), Value: rule}
+		internal.PrintOutput(makeRuleOutputs(entry)[0], func(w io.Writer) { printRoutingRules(w, entry) })
 	},
 }
 
@@ -60,61 +60,87 @@ var rmRuleCmd = &cobra.Command{
 		id, err := strconv.ParseUint(args[0], 10, 32)
 		internal.Catch(err)
 		internal.Catch(rpcClient().RemoveRoutingRule(routing.RouteID(id)))
-		fmt.Println("OK")
+		internal.PrintOK()
 	},
 }
 
 var keepAlive time.Duration
 
+const keepAliveUsage = "duration after which routing rule will expire if no activity is present"
+
 func init() {
-	addRuleCmd.PersistentFlags().DurationVar(&keepAlive, "keep-alive", router.DefaultRouteKeepAlive, "duration after which routing rule will expire if no activity is present")
+	addRuleCmd.PersistentFlags().DurationVar(&keepAlive, "keep-alive", router.DefaultRouteKeepAlive, keepAliveUsage)
 }
 
 var addRuleCmd = &cobra.Command{
-	Use:   "add-rule (app <route-id> <remote-pk> <remote-port> <local-port> | fwd <next-route-id> <next-transport-id>)",
+	Use:   "add-rule " + addRuleUsage,
 	Short: "Adds a new routing rule",
-	Args: func(_ *cobra.Command, args []string) error {
-		if len(args) > 0 {
-			switch rt := args[0]; rt {
-			case "app":
-				if len(args[0:]) == 4 {
-					return nil
-				}
-				return errors.New("expected 4 args after 'app'")
-			case "fwd":
-				if len(args[0:]) == 2 {
-					return nil
-				}
-				return errors.New("expected 2 args after 'fwd'")
-			}
-		}
-		return errors.New("expected 'app' or 'fwd' after 'add-rule'")
-	},
-	Run: func(_ *cobra.Command, args []string) {
-		var rule routing.Rule
-		switch args[0] {
+	Args:  addRuleArgs,
+	Run:   runAddRule,
+}
+
+const addRuleUsage = "(app <route-id> <remote-pk> <remote-port> <local-port> | fwd <next-route-id> <next-transport-id>)"
+
+func addRuleArgs(_ *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		switch rt := args[0]; rt {
 		case "app":
-			var (
-				routeID    = routing.RouteID(parseUint("route-id", args[1], 32))
-				remotePK   = internal.ParsePK("remote-pk", args[2])
-				remotePort = routing.Port(parseUint("remote-port", args[3], 16))
-				localPort  = routing.Port(parseUint("local-port", args[4], 16))
-			)
-			rule = routing.AppRule(keepAlive, 0, routeID, remotePK, localPort, remotePort)
+			if len(args[1:]) == 4 {
+				return nil
+			}
+			return errors.New("expected 4 args after 'app'")
 		case "fwd":
-			var (
-				nextRouteID = routing.RouteID(parseUint("next-route-id", args[1], 32))
-				nextTpID    = internal.ParseUUID("next-transport-id", args[2])
-			)
-			rule = routing.ForwardRule(keepAlive, nextRouteID, nextTpID, 0)
+			if len(args[1:]) == 2 {
+				return nil
+			}
+			return errors.New("expected 2 args after 'fwd'")
 		}
-		rIDKey, err := rpcClient().AddRoutingRule(rule)
+	}
+	return errors.New("expected 'app' or 'fwd' rule type")
+}
+
+func runAddRule(_ *cobra.Command, args []string) {
+	var rule routing.Rule
+	switch args[0] {
+	case "app":
+		var (
+			routeID    = routing.RouteID(parseUint("route-id", args[1], 32))
+			remotePK   = internal.ParsePK("remote-pk", args[2])
+			remotePort = routing.Port(parseUint("remote-port", args[3], 16))
+			localPort  = routing.Port(parseUint("local-port", args[4], 16))
+		)
+		rule = routing.AppRule(keepAlive, 0, routeID, remotePK, localPort, remotePort)
+	case "fwd":
+		var (
+			nextRouteID = routing.RouteID(parseUint("next-route-id", args[1], 32))
+			nextTpID    = internal.ParseUUID("next-transport-id", args[2])
+		)
+		rule = routing.ForwardRule(keepAlive, nextRouteID, nextTpID, 0)
+	}
+	rIDKey, err := rpcClient().AddRoutingRule(rule)
+	internal.Catch(err)
+	internal.PrintOutput(map[string]routing.RouteID{"key": rIDKey}, func(w io.Writer) {
+		_, err := fmt.Fprintln(w, "Routing Rule Key:", rIDKey)
 		internal.Catch(err)
-		fmt.Println("Routing Rule Key:", rIDKey)
-	},
+	})
+}
+
+// ruleOutput is a routing rule as printed in JSON or YAML, as the rules of the hypervisor API.
+type ruleOutput struct {
+	Key     routing.RouteID      `json:"key"`
+	Rule    string               `json:"rule"`
+	Summary *routing.RuleSummary `json:"rule_summary"`
+}
+
+func makeRuleOutputs(rules ...*visor.RoutingEntry) []ruleOutput {
+	out := make([]ruleOutput, len(rules))
+	for i, rule := range rules {
+		out[i] = ruleOutput{Key: rule.Key, Rule: hex.EncodeToString(rule.Value), Summary: rule.Value.Summary()}
+	}
+	return out
 }
 
-func printRoutingRules(rules ...*visor.RoutingEntry) {
+func printRoutingRules(out io.Writer, rules ...*visor.RoutingEntry) {
 	printAppRule := func(w io.Writer, id routing.RouteID, s *routing.RuleSummary) {
 		_, err := fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\t%d\t%s\t%s\t%s\n", id, s.Type, s.AppFields.LocalPort,
 			s.AppFields.RemotePort, s.AppFields.RemotePK, s.AppFields.RespRID, "-", "-", s.KeepAlive)
@@ -125,7 +151,7 @@ func printRoutingRules(rules ...*visor.RoutingEntry) {
 			"-", "-", "-", s.ForwardFields.NextRID, s.ForwardFields.NextTID, s.KeepAlive)
 		internal.Catch(err)
 	}
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 5, ' ', tabwriter.TabIndent)
+	w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
 	_, err := fmt.Fprintln(w, "id\ttype\tlocal-port\tremote-port\tremote-pk\tresp-id\tnext-route-id\tnext-transport-id\texpire-at")
 	internal.Catch(err)
 	for _, rule := range rules {
@@ -0,0 +1,68 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+func init() {
+	RootCmd.AddCommand(
+		lsSTCPCmd,
+		addSTCPCmd,
+		rmSTCPCmd,
+	)
+}
+
+var lsSTCPCmd = &cobra.Command{
+	Use:   "ls-stcp",
+	Short: "Lists the entries of the stcp PK table",
+	Run: func(_ *cobra.Command, _ []string) {
+		entries, err := rpcClient().STCPTable()
+		internal.Catch(err)
+
+		lines := make([]string, 0, len(entries))
+		for pk, addr := range entries {
+			lines = append(lines, fmt.Sprintf("%s\t%s", pk, addr))
+		}
+		sort.Strings(lines)
+
+		internal.PrintOutput(entries, func(out io.Writer) {
+			w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+			_, err := fmt.Fprintln(w, "pk\taddress")
+			internal.Catch(err)
+			for _, line := range lines {
+				_, err = fmt.Fprintln(w, line)
+				internal.Catch(err)
+			}
+			internal.Catch(w.Flush())
+		})
+	},
+}
+
+var addSTCPCmd = &cobra.Command{
+	Use:   "add-stcp <remote-public-key> <address>",
+	Short: "Adds or replaces an entry of the stcp PK table",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		pk := internal.ParsePK("remote-public-key", args[0])
+		internal.Catch(rpcClient().AddSTCPEntry(pk, args[1]))
+		internal.PrintOK()
+	},
+}
+
+var rmSTCPCmd = &cobra.Command{
+	Use:   "rm-stcp <remote-public-key>",
+	Short: "Removes an entry of the stcp PK table",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		pk := internal.ParsePK("remote-public-key", args[0])
+		internal.Catch(rpcClient().RemoveSTCPEntry(pk))
+		internal.PrintOK()
+	},
+}
@@ -0,0 +1,189 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+var watchSummary bool
+
+func init() {
+	RootCmd.AddCommand(summaryCmd)
+	summaryCmd.Flags().BoolVarP(&watchSummary, "watch", "w", false,
+		"redraw the summary whenever transports, routes or apps change, until interrupted")
+}
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary",
+	Short: "Summarizes the state of the node and its modules",
+	Run: func(_ *cobra.Command, _ []string) {
+		if watchSummary {
+			watchSummaries()
+			return
+		}
+		summary, err := rpcClient().Summary()
+		internal.Catch(err)
+		internal.PrintOutput(summary, func(w io.Writer) { printSummary(w, summary) })
+	},
+}
+
+// summaryRedrawInterval limits how often the summary is redrawn by --watch, as events tend to come in bursts.
+const summaryRedrawInterval = time.Second
+
+// watchSummaries prints the summary, and prints it again after events of transports, routes and apps until
+// interrupted. The terminal is cleared before each summary if the output is a terminal, like 'watch' does.
+func watchSummaries() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	client := streamRPCClient()
+	clearScreen := internal.OutputFormat() == internal.OutputText && terminal.IsTerminal(int(os.Stdout.Fd()))
+	redraw := func(last *visor.StreamEvent) {
+		summary, err := client.Summary()
+		internal.Catch(err)
+		internal.PrintStreamOutput(summary, func(w io.Writer) {
+			if clearScreen {
+				_, err := fmt.Fprint(w, "\033[H\033[2J")
+				internal.Catch(err)
+			}
+			_, err := fmt.Fprintf(w, "Updated at %s", time.Now().Format("2006-01-02T15:04:05"))
+			internal.Catch(err)
+			if last != nil {
+				_, err = fmt.Fprintf(w, " after %s %s: %s", last.Type, last.Subject, last.Message)
+				internal.Catch(err)
+			}
+			_, err = fmt.Fprint(w, "\n\n")
+			internal.Catch(err)
+			printSummary(w, summary)
+			if !clearScreen {
+				_, err = fmt.Fprintln(w)
+				internal.Catch(err)
+			}
+		})
+	}
+
+	redraw(nil)
+	errCh := make(chan error, 1)
+	events := visor.Watch(ctx, client, errCh, visor.EventKindTransport, visor.EventKindRoute, visor.EventKindApp)
+	for e := range events {
+		last := e
+		// Coalesce the events of a burst into one redraw.
+		timer := time.NewTimer(summaryRedrawInterval)
+	coalesce:
+		for {
+			select {
+			case e, ok := <-events:
+				if !ok {
+					break coalesce
+				}
+				last = e
+			case <-timer.C:
+				break coalesce
+			}
+		}
+		timer.Stop()
+		if ctx.Err() != nil {
+			break
+		}
+		redraw(&last)
+	}
+	select {
+	case err := <-errCh:
+		internal.Catch(err)
+	default:
+	}
+}
+
+func printSummary(out io.Writer, s *visor.Summary) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', tabwriter.TabIndent)
+	p := func(format string, a ...interface{}) {
+		_, err := fmt.Fprintf(w, format, a...)
+		internal.Catch(err)
+	}
+
+	p("public key:\t%s\n", s.PubKey)
+	p("version:\t%s (app protocol %s)\n", s.NodeVersion, s.AppProtoVersion)
+	if s.Identity != "" {
+		p("identity:\t%s\n", s.Identity)
+	}
+	if s.Profile != "" {
+		p("config profile:\t%s\n", s.Profile)
+	}
+	if s.RewardAddress != "" {
+		p("reward address:\t%s\n", s.RewardAddress)
+	}
+	if s.Location != nil {
+		p("location:\t%v,%v %s\n", s.Location.Lat, s.Location.Lon, s.Location.Region)
+	}
+	for network, reason := range s.Networks {
+		if reason != "" {
+			p("network %s:\tunavailable: %s\n", network, reason)
+		}
+	}
+
+	p("\nDMSG SESSIONS\n")
+	p("server\taddress\tconnected\tstreams\treconnects\trtt\n")
+	for _, sess := range s.DmsgSessions {
+		p("%s\t%s\t%t\t%d\t%d\t%s\n", sess.Server, sess.Address, sess.Connected, sess.Streams, sess.Reconnects, sess.RTT)
+	}
+
+	if s.STCP != nil {
+		p("\nSTCP\n")
+		p("listening:\t%t\n", s.STCP.Listening)
+		p("local address:\t%s\n", s.STCP.LocalAddr)
+		if s.STCP.ExternalAddr != "" {
+			p("external address:\t%s\n", s.STCP.ExternalAddr)
+		}
+		p("mux sessions:\t%d\n", s.STCP.Sessions)
+		if s.STCP.Error != "" {
+			p("error:\t%s\n", s.STCP.Error)
+		}
+	}
+
+	p("\nROUTING\n")
+	p("transports:\t%d\n", len(s.Transports))
+	types := make([]string, 0, len(s.RoutesByType))
+	for t := range s.RoutesByType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	p("rules:\t%d\n", s.RoutesCount)
+	for _, t := range types {
+		p("  %s:\t%d\n", t, s.RoutesByType[t])
+	}
+	if s.SetupNode != nil {
+		p("setup node:\t%s\n", s.SetupNode)
+	}
+
+	p("\nAPPS\n")
+	p("app\trunning\tstarts\tcrashes\tlast error\n")
+	for _, a := range s.AppHealth {
+		p("%s\t%t\t%d\t%d\t%s\n", a.Name, a.Running, a.Starts, a.Crashes, a.LastError)
+	}
+
+	if len(s.RecentErrors) > 0 {
+		p("\nRECENT ERRORS\n")
+		for _, e := range s.RecentErrors {
+			p("%s\t%s\t%s\t%s\n", e.Time.Format(time.RFC3339), e.Type, e.Subject, e.Message)
+		}
+	}
+	internal.Catch(w.Flush())
+}
@@ -0,0 +1,64 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+func init() {
+	tpCmd.AddCommand(tpStatsCmd)
+}
+
+var tpStatsCmd = &cobra.Command{
+	Use:   "stats [transport-id]",
+	Short: "Shows the bytes, recent rates and reconnects of all transports, or of the given transport",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		tpID := uuid.Nil
+		if len(args) > 0 {
+			tpID = internal.ParseUUID("transport-id", args[0])
+		}
+		stats, err := rpcClient().TransportBandwidth(tpID)
+		internal.Catch(err)
+		internal.PrintOutput(stats, func(w io.Writer) { printBandwidthStats(w, stats...) })
+	},
+}
+
+func printBandwidthStats(out io.Writer, stats ...transport.BandwidthStats) {
+	w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+	_, err := fmt.Fprint(w, "id\tremote\ttype\tsent\trecv")
+	internal.Catch(err)
+	for _, window := range transport.RateWindows {
+		_, err = fmt.Fprintf(w, "\trate-%s", window)
+		internal.Catch(err)
+	}
+	_, err = fmt.Fprintln(w, "\treconnects")
+	internal.Catch(err)
+
+	for _, s := range stats {
+		remote, tpType := s.Remote.String(), s.Type
+		if !s.Managed {
+			remote, tpType = "-", "(removed)"
+		}
+		_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d", s.ID, remote, tpType, s.SentBytes, s.RecvBytes)
+		internal.Catch(err)
+		for i := range transport.RateWindows {
+			rate := "-"
+			if i < len(s.Rates) {
+				rate = fmt.Sprintf("%.0f/%.0f B/s", s.Rates[i].SentRate, s.Rates[i].RecvRate)
+			}
+			_, err = fmt.Fprintf(w, "\t%s", rate)
+			internal.Catch(err)
+		}
+		_, err = fmt.Fprintf(w, "\t%d\n", s.Reconnects)
+		internal.Catch(err)
+	}
+	internal.Catch(w.Flush())
+}
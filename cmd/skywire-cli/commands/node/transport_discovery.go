@@ -3,8 +3,9 @@ package node
 import (
 	"errors"
 	"fmt"
-	"os"
+	"io"
 	"text/tabwriter"
+	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/google/uuid"
@@ -19,13 +20,19 @@ func init() {
 }
 
 var (
-	tpID transportID
-	tpPK cipher.PubKey
+	tpID     transportID
+	tpPK     cipher.PubKey
+	tpQuery  transport.EdgeQuery
+	tpSeenIn time.Duration
 )
 
 func init() {
 	discTpCmd.Flags().Var(&tpID, "id", "if specified, obtains a single transport of given ID")
 	discTpCmd.Flags().Var(&tpPK, "pk", "if specified, obtains transports associated with given public key")
+	discTpCmd.Flags().StringVar(&tpQuery.Type, "type", "", "only show transports of given type (used with --pk)")
+	discTpCmd.Flags().DurationVar(&tpSeenIn, "seen-within", 0, "only show transports seen within given duration (used with --pk)")
+	discTpCmd.Flags().IntVar(&tpQuery.Offset, "offset", 0, "number of transports to skip (used with --pk)")
+	discTpCmd.Flags().IntVar(&tpQuery.Limit, "limit", 0, "maximum number of transports to show, 0 for no limit (used with --pk)")
 }
 
 var discTpCmd = &cobra.Command{
@@ -49,17 +56,28 @@ var discTpCmd = &cobra.Command{
 		if rc := rpcClient(); tpPK.Null() {
 			entry, err := rc.DiscoverTransportByID(uuid.UUID(tpID))
 			internal.Catch(err)
-			printTransportEntries(entry)
+			internal.PrintOutput(entry, func(w io.Writer) { printTransportEntries(w, entry) })
 		} else {
-			entries, err := rc.DiscoverTransportsByPK(tpPK)
+			if tpSeenIn > 0 {
+				tpQuery.SeenSince = time.Now().Add(-tpSeenIn)
+			}
+			entries, total, err := rc.QueryTransportsByPK(tpPK, tpQuery)
 			internal.Catch(err)
-			printTransportEntries(entries...)
+			out := struct {
+				Entries []*transport.EntryWithStatus `json:"entries"`
+				Total   int                          `json:"total"`
+			}{entries, total}
+			internal.PrintOutput(out, func(w io.Writer) {
+				printTransportEntries(w, entries...)
+				_, err := fmt.Fprintf(w, "showing %d of %d transport(s)\n", len(entries), total)
+				internal.Catch(err)
+			})
 		}
 	},
 }
 
-func printTransportEntries(entries ...*transport.EntryWithStatus) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 5, ' ', tabwriter.TabIndent)
+func printTransportEntries(out io.Writer, entries ...*transport.EntryWithStatus) {
+	w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
 	_, err := fmt.Fprintln(w, "id\ttype\tpublic\tregistered\tup\tedge1\tedge2\topinion1\topinion2")
 	internal.Catch(err)
 	for _, e := range entries {
@@ -1,17 +1,23 @@
 package node
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
-	"github.com/SkycoinProject/dmsg"
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/spf13/cobra"
 
 	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
 )
 
@@ -31,9 +37,12 @@ var lsTypesCmd = &cobra.Command{
 	Run: func(_ *cobra.Command, _ []string) {
 		types, err := rpcClient().TransportTypes()
 		internal.Catch(err)
-		for _, t := range types {
-			fmt.Println(t)
-		}
+		internal.PrintOutput(types, func(w io.Writer) {
+			for _, t := range types {
+				_, err := fmt.Fprintln(w, t)
+				internal.Catch(err)
+			}
+		})
 	},
 }
 
@@ -55,7 +64,8 @@ var lsTpCmd = &cobra.Command{
 	Run: func(_ *cobra.Command, _ []string) {
 		transports, err := rpcClient().Transports(filterTypes, filterPubKeys, showLogs)
 		internal.Catch(err)
-		printTransports(transports...)
+		sortTransports(transports...)
+		internal.PrintOutput(transports, func(w io.Writer) { printTransports(w, transports...) })
 	},
 }
 
@@ -67,7 +77,7 @@ var tpCmd = &cobra.Command{
 		tpID := internal.ParseUUID("transport-id", args[0])
 		tp, err := rpcClient().Transport(tpID)
 		internal.Catch(err)
-		printTransports(tp)
+		internal.PrintOutput(tp, func(w io.Writer) { printTransports(w, tp) })
 	},
 }
 
@@ -75,26 +85,115 @@ var (
 	transportType string
 	public        bool
 	timeout       time.Duration
+	labels        []string
+	tpsFile       string
+	parallel      int
 )
 
 func init() {
-	addTpCmd.Flags().StringVar(&transportType, "type", dmsg.Type, "type of transport to add")
+	addTpCmd.Flags().StringVar(&transportType, "type", "", "type of transport to add (defaults to the preferred network of the visor)")
 	addTpCmd.Flags().BoolVar(&public, "public", true, "whether to make the transport public")
 	addTpCmd.Flags().DurationVarP(&timeout, "timeout", "t", 0, "if specified, sets an operation timeout")
+	addTpCmd.Flags().StringSliceVarP(&labels, "label", "l", nil, "labels to attach to the transport (e.g. home-fiber)")
+	addTpCmd.Flags().StringVar(&tpsFile, "from-file", "",
+		"adds the transports of a JSON file instead, formatted like the 'persistent_transports' of the visor config")
+	addTpCmd.Flags().IntVar(&parallel, "parallel", 1, "number of transports of --from-file to add concurrently")
 }
 
 var addTpCmd = &cobra.Command{
-	Use:   "add-tp <remote-public-key>",
-	Short: "Adds a new transport",
-	Args:  cobra.MinimumNArgs(1),
+	Use:   "add-tp (<remote-public-key> | --from-file <path>)",
+	Short: "Adds a new transport, or the transports of a file",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if tpsFile != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	Run: func(_ *cobra.Command, args []string) {
+		if tpsFile != "" {
+			addTransportsFromFile()
+			return
+		}
 		pk := internal.ParsePK("remote-public-key", args[0])
-		tp, err := rpcClient().AddTransport(pk, transportType, public, timeout)
+		tp, err := rpcClient().AddTransport(pk, transportType, public, timeout, labels)
 		internal.Catch(err)
-		printTransports(tp)
+		internal.PrintOutput(tp, func(w io.Writer) { printTransports(w, tp) })
 	},
 }
 
+// addTpResult is the outcome of adding one of the transports of --from-file.
+type addTpResult struct {
+	transport.PersistentTransport
+	Transport *visor.TransportSummary `json:"transport,omitempty"`
+	Error     string                  `json:"error,omitempty"`
+}
+
+// addTransportsFromFile adds the transports of --from-file, up to --parallel at a time, reporting the outcome of
+// each and exiting with status 1 if any failed.
+func addTransportsFromFile() {
+	data, err := ioutil.ReadFile(filepath.Clean(tpsFile))
+	internal.Catch(err)
+	var entries []transport.PersistentTransport
+	internal.Catch(json.Unmarshal(data, &entries), "invalid transports file:")
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	// Adding many transports may exceed the duration of regular connections.
+	rpc := streamRPCClient()
+	results := make([]addTpResult, len(entries))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, entry transport.PersistentTransport) {
+			defer func() { <-sem; wg.Done() }()
+			var tpLabels []string
+			if entry.Label != "" {
+				tpLabels = []string{entry.Label}
+			}
+			if entry.Type == "" {
+				entry.Type = transportType
+			}
+			results[i].PersistentTransport = entry
+			tp, err := rpc.AddTransport(entry.PK, entry.Type, public, timeout, tpLabels)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Transport = tp
+		}(i, entry)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	internal.PrintOutput(results, func(out io.Writer) {
+		w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+		_, err := fmt.Fprintln(w, "remote\ttype\tlabel\tid\terror")
+		internal.Catch(err)
+		for _, r := range results {
+			id := ""
+			if r.Transport != nil {
+				id = r.Transport.ID.String()
+			}
+			_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.PK, r.Type, r.Label, id, r.Error)
+			internal.Catch(err)
+		}
+		internal.Catch(w.Flush())
+		_, err = fmt.Fprintf(out, "%d/%d transports added\n", len(results)-failed, len(results))
+		internal.Catch(err)
+	})
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
 var rmTpCmd = &cobra.Command{
 	Use:   "rm-tp <transport-id>",
 	Short: "Removes transport with given id",
@@ -102,14 +201,13 @@ var rmTpCmd = &cobra.Command{
 	Run: func(_ *cobra.Command, args []string) {
 		tID := internal.ParseUUID("transport-id", args[0])
 		internal.Catch(rpcClient().RemoveTransport(tID))
-		fmt.Println("OK")
+		internal.PrintOK()
 	},
 }
 
-func printTransports(tps ...*visor.TransportSummary) {
-	sortTransports(tps...)
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 5, ' ', tabwriter.TabIndent)
-	_, err := fmt.Fprintln(w, "type\tid\tremote\tmode")
+func printTransports(out io.Writer, tps ...*visor.TransportSummary) {
+	w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+	_, err := fmt.Fprintln(w, "type\tid\tremote\tmode\tlabels")
 	internal.Catch(err)
 	for _, tp := range tps {
 		tpMode := "regular"
@@ -117,7 +215,7 @@ func printTransports(tps ...*visor.TransportSummary) {
 			tpMode = "setup"
 		}
 
-		_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", tp.Type, tp.ID, tp.Remote, tpMode)
+		_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", tp.Type, tp.ID, tp.Remote, tpMode, strings.Join(tp.Labels, ","))
 		internal.Catch(err)
 	}
 	internal.Catch(w.Flush())
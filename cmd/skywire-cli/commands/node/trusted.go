@@ -0,0 +1,55 @@
+package node
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+)
+
+func init() {
+	RootCmd.AddCommand(trustedCmd)
+	trustedCmd.AddCommand(trustedLsCmd, trustedAddCmd, trustedRmCmd)
+}
+
+var trustedCmd = &cobra.Command{
+	Use:   "trusted",
+	Short: "Manages the trusted visors of the node",
+}
+
+var trustedLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "Lists the public keys of trusted visors",
+	Run: func(_ *cobra.Command, _ []string) {
+		pks, err := rpcClient().TrustedVisors()
+		internal.Catch(err)
+		internal.PrintOutput(pks, func(w io.Writer) {
+			for _, pk := range pks {
+				_, err := fmt.Fprintln(w, pk)
+				internal.Catch(err)
+			}
+		})
+	},
+}
+
+var trustedAddCmd = &cobra.Command{
+	Use:   "add <public-key>...",
+	Short: "Trusts visors, accepting their transports and loops while draining",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		internal.Catch(rpcClient().AddTrustedVisors(parsePKs(args)...))
+		internal.PrintOK()
+	},
+}
+
+var trustedRmCmd = &cobra.Command{
+	Use:   "rm <public-key>...",
+	Short: "No longer trusts visors",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		internal.Catch(rpcClient().RemoveTrustedVisors(parsePKs(args)...))
+		internal.PrintOK()
+	},
+}
@@ -0,0 +1,51 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func init() {
+	RootCmd.AddCommand(watchCmd)
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [kind...]",
+	Short: "Prints events of the node as they happen until interrupted",
+	Long: "Prints events of the node as they happen until interrupted.\n\nKinds of events: " + strings.Join([]string{
+		visor.EventKindTransport, visor.EventKindRoute, visor.EventKindApp, visor.EventKindHealth, visor.EventKindNode,
+	}, ", ") + " (all if none are given).",
+	Run: func(_ *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt)
+		go func() {
+			<-sigCh
+			cancel()
+		}()
+
+		errCh := make(chan error, 1)
+		for e := range visor.Watch(ctx, streamRPCClient(), errCh, args...) {
+			internal.PrintStreamOutput(e, func(w io.Writer) {
+				_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					e.Time.Format("2006-01-02T15:04:05"), e.Kind, e.Type, e.Subject, e.Message)
+				internal.Catch(err)
+			})
+		}
+		select {
+		case err := <-errCh:
+			internal.Catch(err)
+		default:
+		}
+	},
+}
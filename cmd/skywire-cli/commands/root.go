@@ -5,9 +5,12 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/commands/clicontext"
 	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/commands/mdisc"
 	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/commands/node"
 	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/commands/rtfind"
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/commands/services"
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
 )
 
 var rootCmd = &cobra.Command{
@@ -16,10 +19,14 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
+	internal.AddOutputFlags(rootCmd)
+	internal.AddContextFlag(rootCmd)
 	rootCmd.AddCommand(
 		node.RootCmd,
+		clicontext.RootCmd,
 		mdisc.RootCmd,
 		rtfind.RootCmd,
+		services.RootCmd,
 	)
 }
 
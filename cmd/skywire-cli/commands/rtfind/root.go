@@ -2,6 +2,8 @@ package rtfind
 
 import (
 	"fmt"
+	"io"
+	"strconv"
 	"time"
 
 	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
@@ -11,17 +13,20 @@ import (
 
 	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/route-finder/client"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 )
 
 var frAddr string
 var frMinHops, frMaxHops uint16
 var timeout time.Duration
+var weights map[string]string
 
 func init() {
 	RootCmd.Flags().StringVar(&frAddr, "addr", skyenv.DefaultRouteFinderAddr, "address in which to contact route finder service")
 	RootCmd.Flags().Uint16Var(&frMinHops, "min-hops", 1, "min hops for the returning routeFinderRoutesCmd")
 	RootCmd.Flags().Uint16Var(&frMaxHops, "max-hops", 1000, "max hops for the returning routeFinderRoutesCmd")
 	RootCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "timeout for remote server requests")
+	RootCmd.Flags().StringToStringVar(&weights, "weights", nil, "cost weights of transport types, e.g. stcp=1,dmsg=3")
 }
 
 // RootCmd is the command that queries the route-finder.
@@ -30,7 +35,13 @@ var RootCmd = &cobra.Command{
 	Short: "Queries the Route Finder for available routes between two nodes",
 	Args:  cobra.MinimumNArgs(2),
 	Run: func(_ *cobra.Command, args []string) {
-		rfc := client.NewHTTP(frAddr, timeout)
+		tpWeights := make(map[string]float64, len(weights))
+		for tpType, w := range weights {
+			v, err := strconv.ParseFloat(w, 64)
+			internal.Catch(err)
+			tpWeights[tpType] = v
+		}
+		rfc := client.NewHTTP(frAddr, timeout, tpWeights)
 
 		var srcPK, dstPK cipher.PubKey
 		internal.Catch(srcPK.Set(args[0]))
@@ -39,7 +50,13 @@ var RootCmd = &cobra.Command{
 		forward, reverse, err := rfc.PairedRoutes(srcPK, dstPK, frMinHops, frMaxHops)
 		internal.Catch(err)
 
-		fmt.Println("forward: ", forward)
-		fmt.Println("reverse: ", reverse)
+		out := struct {
+			Forward []routing.Route `json:"forward"`
+			Reverse []routing.Route `json:"reverse"`
+		}{forward, reverse}
+		internal.PrintOutput(out, func(w io.Writer) {
+			_, err := fmt.Fprintf(w, "forward:  %v\nreverse:  %v\n", forward, reverse)
+			internal.Catch(err)
+		})
 	},
 }
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/pathutil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// configEnv is the environment variable of the config path of skywire-visor.
+const configEnv = "SW_CONFIG"
+
+// RootCmd is the command that contains sub-commands which interact with the external services of visors.
+var RootCmd = &cobra.Command{
+	Use:   "services",
+	Short: "Contains sub-commands that interact with the external services of Skywire Visors",
+}
+
+func init() {
+	RootCmd.AddCommand(checkCmd)
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check [<config-path>]",
+	Short: "Checks the reachability of the services of a visor config, or of the default services",
+	Long: "Checks the reachability, latency and version of the dmsg discovery, transport discovery, route finder " +
+		"and setup nodes of a visor config, which is looked up like skywire-visor does if none is given. " +
+		"The default services are checked if no config is found.\n\n" +
+		"Exits with status 1 if any service is unhealthy.",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		conf := readConfig(args)
+		health := visor.CheckHealth(context.Background(), conf)
+
+		healthy := 0
+		for _, s := range health.Services {
+			if s.Healthy() {
+				healthy++
+			}
+		}
+		internal.PrintOutput(health.Services, func(out io.Writer) {
+			w := tabwriter.NewWriter(out, 0, 0, 5, ' ', tabwriter.TabIndent)
+			_, err := fmt.Fprintln(w, "service\taddress\thealthy\tstatus\tlatency\tversion\terror")
+			internal.Catch(err)
+			for _, s := range health.Services {
+				_, err = fmt.Fprintf(w, "%s\t%s\t%t\t%d\t%s\t%s\t%s\n",
+					s.Service, s.Address, s.Healthy(), s.Status, s.Latency, s.Version, s.Error)
+				internal.Catch(err)
+			}
+			internal.Catch(w.Flush())
+			_, err = fmt.Fprintf(out, "%d/%d services healthy\n", healthy, len(health.Services))
+			internal.Catch(err)
+		})
+		if healthy < len(health.Services) {
+			os.Exit(1)
+		}
+	},
+}
+
+// readConfig reads the visor config of args, of $SW_CONFIG, or of the default paths, falling back to a config of
+// the default services if none is found.
+func readConfig(args []string) *visor.Config {
+	path := ""
+	if len(args) == 1 {
+		path = args[0]
+	} else if env, ok := os.LookupEnv(configEnv); ok {
+		path = env
+	} else {
+		defaults := pathutil.NodeDefaults()
+		for _, cpType := range pathutil.AllConfigLocationTypes() {
+			if p, ok := defaults[cpType]; ok {
+				if _, err := os.Stat(p); err == nil {
+					path = p
+					break
+				}
+			}
+		}
+	}
+	if path == "" {
+		return defaultConfig()
+	}
+	conf, err := visor.ReadConfig(path)
+	internal.Catch(err)
+	return conf
+}
+
+func defaultConfig() *visor.Config {
+	var setupPK cipher.PubKey
+	internal.Catch(setupPK.Set(skyenv.DefaultSetupPK))
+
+	conf := new(visor.Config)
+	conf.Messaging.Discovery = skyenv.DefaultDmsgDiscAddr
+	conf.Transport.Discovery = skyenv.DefaultTpDiscAddr
+	conf.Routing.RouteFinder = skyenv.DefaultRouteFinderAddr
+	conf.Routing.SetupNodes = []cipher.PubKey{setupPK}
+	return conf
+}
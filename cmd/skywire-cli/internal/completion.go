@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Kinds of arguments which are completed dynamically, mostly by querying the local visor.
+const (
+	CompleteTransportID = "transport-id"
+	CompleteApp         = "app"
+	CompletePK          = "pk"      // public keys of visors known to the local visor.
+	CompleteContext     = "context" // names of the contexts of the CLI config.
+)
+
+// completeArgsAnnotation is the annotation of commands which holds the kinds of their arguments.
+const completeArgsAnnotation = "skywire_cli_complete_args"
+
+// Completer lists the candidates of a kind of argument. Flags of the command line, such as the RPC address, are
+// parsed before it is called.
+type Completer func() ([]string, error)
+
+var completers = make(map[string]Completer)
+
+// RegisterCompleter registers the completer of a kind of argument.
+func RegisterCompleter(kind string, complete Completer) {
+	completers[kind] = complete
+}
+
+// CompleteArgs sets the kinds of the arguments of the command, by position. The last kind applies to the remaining
+// arguments if it ends with "...". Kinds which contain '|' are completed with the words they separate.
+func CompleteArgs(cmd *cobra.Command, kinds ...string) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[completeArgsAnnotation] = strings.Join(kinds, " ")
+}
+
+// Complete returns the candidates of the last of the words of a command line of the root command, which are typed
+// after the name of the program.
+func Complete(root *cobra.Command, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	prev, cur := words[:len(words)-1], words[len(words)-1]
+	cmd, rest, err := root.Find(prev)
+	if err != nil || cmd == nil {
+		return nil
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		return filterPrefix(flagNames(cmd), cur)
+	}
+	if len(prev) > 0 && expectsFlagValue(cmd, prev[len(prev)-1]) {
+		return nil
+	}
+
+	// Errors leave the flags which were parsed, such as the RPC address, and the candidates are all we are after.
+	_ = cmd.ParseFlags(rest) //nolint:errcheck
+	args := cmd.Flags().Args()
+
+	var candidates []string
+	if len(args) == 0 {
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() {
+				candidates = append(candidates, sub.Name())
+			}
+		}
+	}
+	if kind := argKind(cmd, len(args)); kind != "" {
+		if strings.Contains(kind, "|") {
+			candidates = append(candidates, strings.Split(kind, "|")...)
+		} else if complete, ok := completers[kind]; ok {
+			if words, err := complete(); err == nil {
+				candidates = append(candidates, words...)
+			}
+		}
+	}
+	return filterPrefix(candidates, cur)
+}
+
+// argKind returns the kind of the argument of the command at the position, or "" if it is not completed.
+func argKind(cmd *cobra.Command, pos int) string {
+	kinds := strings.Fields(cmd.Annotations[completeArgsAnnotation])
+	if len(kinds) == 0 {
+		return ""
+	}
+	if pos < len(kinds) {
+		return strings.TrimSuffix(kinds[pos], "...")
+	}
+	if last := kinds[len(kinds)-1]; strings.HasSuffix(last, "...") {
+		return strings.TrimSuffix(last, "...")
+	}
+	return ""
+}
+
+func flagNames(cmd *cobra.Command) []string {
+	var names []string
+	add := func(f *pflag.Flag) {
+		if f.Hidden {
+			return
+		}
+		names = append(names, "--"+f.Name)
+		if f.Shorthand != "" {
+			names = append(names, "-"+f.Shorthand)
+		}
+	}
+	cmd.NonInheritedFlags().VisitAll(add)
+	cmd.InheritedFlags().VisitAll(add)
+	return names
+}
+
+// expectsFlagValue reports whether the word is a flag of the command which takes the next word as its value.
+func expectsFlagValue(cmd *cobra.Command, word string) bool {
+	if !strings.HasPrefix(word, "-") || strings.Contains(word, "=") {
+		return false
+	}
+	var f *pflag.Flag
+	if strings.HasPrefix(word, "--") {
+		f = cmd.Flags().Lookup(word[2:])
+		if f == nil {
+			f = cmd.InheritedFlags().Lookup(word[2:])
+		}
+	} else if len(word) == 2 {
+		f = cmd.Flags().ShorthandLookup(word[1:])
+		if f == nil {
+			f = cmd.InheritedFlags().ShorthandLookup(word[1:])
+		}
+	}
+	return f != nil && f.NoOptDefVal == ""
+}
+
+// filterPrefix returns the sorted distinct candidates which start with the prefix.
+func filterPrefix(candidates []string, prefix string) []string {
+	seen := make(map[string]bool, len(candidates))
+	var out []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) && !seen[c] {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
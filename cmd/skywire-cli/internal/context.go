@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/pathutil"
+)
+
+// CLIConfigEnv is the environment variable of the path of the CLI config, which holds the contexts.
+const CLIConfigEnv = "SKYWIRE_CLI_CONFIG"
+
+// Context is a named set of defaults for the flags which select and authenticate to a visor.
+type Context struct {
+	RPCAddr   string `json:"rpc,omitempty"`
+	RPCToken  string `json:"rpc_token,omitempty"`
+	Remote    string `json:"remote,omitempty"`     // public key of a visor managed over dmsg, instead of RPCAddr.
+	RemoteKey string `json:"remote_key,omitempty"` // file of the keys which authenticate to the remote visor.
+}
+
+// CLIConfig is the config file of skywire-cli.
+type CLIConfig struct {
+	Current  string              `json:"current,omitempty"` // context used unless --context is given.
+	Contexts map[string]*Context `json:"contexts"`
+}
+
+// ContextNames returns the names of the contexts in order.
+func (c *CLIConfig) ContextNames() []string {
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var contextName string
+
+// AddContextFlag adds the --context flag, which selects the context of the command and its sub-commands.
+func AddContextFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&contextName, "context", "",
+		"name of the context to use, instead of the current one (see 'context')")
+}
+
+// CLIConfigPath returns the path of the CLI config, from $SKYWIRE_CLI_CONFIG or the home directory.
+func CLIConfigPath() string {
+	if path, ok := os.LookupEnv(CLIConfigEnv); ok {
+		return path
+	}
+	return filepath.Join(pathutil.HomeDir(), ".skycoin/skywire/cli-config.json")
+}
+
+// ReadCLIConfig reads the CLI config, which is empty if the file does not exist.
+func ReadCLIConfig() (*CLIConfig, error) {
+	conf := &CLIConfig{Contexts: make(map[string]*Context)}
+	data, err := ioutil.ReadFile(filepath.Clean(CLIConfigPath()))
+	if os.IsNotExist(err) {
+		return conf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, conf); err != nil {
+		return nil, fmt.Errorf("invalid CLI config %s: %v", CLIConfigPath(), err)
+	}
+	if conf.Contexts == nil {
+		conf.Contexts = make(map[string]*Context)
+	}
+	return conf, nil
+}
+
+// WriteCLIConfig writes the CLI config, readable only by the user as contexts may hold RPC tokens.
+func WriteCLIConfig(conf *CLIConfig) error {
+	data, err := json.MarshalIndent(conf, "", "\t")
+	if err != nil {
+		return err
+	}
+	path := CLIConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// CurrentContext returns the context selected by --context, or the current context of the CLI config, and nil if
+// neither is set.
+func CurrentContext() (*Context, error) {
+	conf, err := ReadCLIConfig()
+	if err != nil {
+		return nil, err
+	}
+	name := contextName
+	if name == "" {
+		name = conf.Current
+	}
+	if name == "" {
+		return nil, nil
+	}
+	ctx, ok := conf.Contexts[name]
+	if !ok {
+		return nil, fmt.Errorf("context %q not found in %s", name, CLIConfigPath())
+	}
+	return ctx, nil
+}
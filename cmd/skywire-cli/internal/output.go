@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats of the results of commands.
+const (
+	OutputText = "text" // tables and messages for humans.
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+var (
+	jsonOutput bool
+	yamlOutput bool
+)
+
+// AddOutputFlags adds the --json and --yaml flags, which select the output format of the command and its
+// sub-commands.
+func AddOutputFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "print results as JSON, for scripts and monitoring")
+	cmd.PersistentFlags().BoolVar(&yamlOutput, "yaml", false, "print results as YAML, for scripts and monitoring")
+	prerun := cmd.PersistentPreRunE
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if jsonOutput && yamlOutput {
+			return errors.New("cannot specify --json and --yaml flag")
+		}
+		if prerun != nil {
+			return prerun(cmd, args)
+		}
+		return nil
+	}
+}
+
+// OutputFormat returns the output format selected by the flags.
+func OutputFormat() string {
+	switch {
+	case jsonOutput:
+		return OutputJSON
+	case yamlOutput:
+		return OutputYAML
+	default:
+		return OutputText
+	}
+}
+
+// PrintOutput prints the result v of a command as JSON or YAML if selected, or else with printText.
+func PrintOutput(v interface{}, printText func(w io.Writer)) {
+	if OutputFormat() == OutputText {
+		printText(os.Stdout)
+		return
+	}
+	Catch(writeOutput(os.Stdout, OutputFormat(), v, false))
+}
+
+// PrintStreamOutput prints a result of a command which streams results, such as events, as a line of JSON or a YAML
+// document if selected, or else with printText.
+func PrintStreamOutput(v interface{}, printText func(w io.Writer)) {
+	if OutputFormat() == OutputText {
+		printText(os.Stdout)
+		return
+	}
+	Catch(writeOutput(os.Stdout, OutputFormat(), v, true))
+}
+
+// PrintOK prints the success of a command which has no result.
+func PrintOK() {
+	PrintOutput(map[string]bool{"ok": true}, func(w io.Writer) {
+		_, err := fmt.Fprintln(w, "OK")
+		Catch(err)
+	})
+}
+
+// writeOutput writes v in the format. YAML is converted from JSON, so that both formats have the same fields.
+func writeOutput(w io.Writer, format string, v interface{}, stream bool) error {
+	var data []byte
+	var err error
+	if stream {
+		data, err = json.Marshal(v)
+	} else {
+		data, err = json.MarshalIndent(v, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	if format == OutputYAML {
+		var obj interface{}
+		if err := yaml.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		if data, err = yaml.Marshal(obj); err != nil {
+			return err
+		}
+		if stream {
+			data = append([]byte("---\n"), data...)
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
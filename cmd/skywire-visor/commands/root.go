@@ -2,14 +2,13 @@ package commands
 
 // NOTE: "net/http/pprof" is used for profiling.
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
 	"log/syslog"
+	"net"
 	"net/http"
 	_ "net/http/pprof" // nolint:gosec // TODO: consider removing for security reasons
 	"os"
@@ -21,10 +20,14 @@ import (
 
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 	"github.com/pkg/profile"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	logrussyslog "github.com/sirupsen/logrus/hooks/syslog"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
 
-	"github.com/SkycoinProject/skywire-mainnet/internal/utclient"
+	"github.com/SkycoinProject/skywire-mainnet/internal/sdnotify"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/restart"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/util/pathutil"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
 )
@@ -41,13 +44,21 @@ type runCfg struct {
 	cfgFromStdin bool
 	profileMode  string
 	port         string
+	metricsAddr  string
+	confProfile  string
+	checkConfig  bool
 	args         []string
 
 	profileStop  func()
 	logger       *logging.Logger
 	masterLogger *logging.MasterLogger
 	conf         visor.Config
-	node         *visor.Node
+	confs        []*visor.Config // configs of the identities, primary identity first.
+	nodes        []*visor.Node   // primary identity first.
+	startedAt    time.Time
+	restartCtx   *restart.Context // nil if restarts are not supported.
+	restart      bool             // whether the visor is stopped to restart.
+	restartReq   chan struct{}    // restarts requested by nodes, such as after config updates.
 }
 
 var cfg *runCfg
@@ -57,24 +68,37 @@ var rootCmd = &cobra.Command{
 	Short: "Visor for skywire",
 	Run: func(_ *cobra.Command, args []string) {
 		cfg.args = args
+		if cfg.checkConfig {
+			cfg.checkConfigAndExit()
+		}
 
 		cfg.startProfiler().
 			startLogger().
 			readConfig().
-			runNode().
+			captureRestartContext().
+			unsealKeys().
+			runNodes().
+			notifySystemd().
 			waitOsSignals().
-			stopNode()
+			stopNodes().
+			restartNode()
 	},
 	Version: visor.Version,
 }
 
 func init() {
 	cfg = &runCfg{}
+	rootCmd.Long = "Visor for skywire.\n\nConfig fields may be overridden by the environment variables:\n  " +
+		strings.Join(visor.EnvOverrides(), "\n  ") +
+		"\n\nThe passphrase of sealed secret keys may be set by $" + visor.KeyPassphraseEnv + "."
 	rootCmd.Flags().StringVarP(&cfg.syslogAddr, "syslog", "", "none", "syslog server address. E.g. localhost:514")
 	rootCmd.Flags().StringVarP(&cfg.tag, "tag", "", "skywire", "logging tag")
 	rootCmd.Flags().BoolVarP(&cfg.cfgFromStdin, "stdin", "i", false, "read config from STDIN")
 	rootCmd.Flags().StringVarP(&cfg.profileMode, "profile", "p", "none", "enable profiling with pprof. Mode:  none or one of: [cpu, mem, mutex, block, trace, http]")
 	rootCmd.Flags().StringVarP(&cfg.port, "port", "", "6060", "port for http-mode of pprof")
+	rootCmd.Flags().StringVarP(&cfg.metricsAddr, "metrics", "m", "", "address to bind metrics API to (disabled if empty)")
+	rootCmd.Flags().BoolVar(&cfg.checkConfig, "check-config", false, "validate the config and exit without starting the visor")
+	rootCmd.Flags().StringVar(&cfg.confProfile, "config-profile", os.Getenv(visor.ProfileEnv), "name of the config profile to apply (defaults to $"+visor.ProfileEnv+")")
 }
 
 // Execute executes root CLI command.
@@ -84,6 +108,30 @@ func Execute() {
 	}
 }
 
+// checkConfigAndExit validates the config without starting any component, printing its problems.
+// It exits with status 1 if the config is invalid.
+func (cfg *runCfg) checkConfigAndExit() {
+	var data []byte
+	var err error
+	if cfg.cfgFromStdin {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(filepath.Clean(pathutil.FindConfigPath(cfg.args, 0, configEnv, pathutil.NodeDefaults())))
+	}
+	if err != nil {
+		log.Fatal("Failed to read config: ", err)
+	}
+	problems := visor.CheckConfig(data, cfg.confProfile)
+	if len(problems) == 0 {
+		fmt.Println("Config is valid")
+		os.Exit(0)
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	os.Exit(1)
+}
+
 func (cfg *runCfg) startProfiler() *runCfg {
 	var option func(*profile.Profile)
 	switch cfg.profileMode {
@@ -128,79 +176,344 @@ func (cfg *runCfg) startLogger() *runCfg {
 }
 
 func (cfg *runCfg) readConfig() *runCfg {
-	var rdr io.Reader
-	var err error
 	if !cfg.cfgFromStdin {
 		configPath := pathutil.FindConfigPath(cfg.args, 0, configEnv, pathutil.NodeDefaults())
-		rdr, err = os.Open(filepath.Clean(configPath))
+		from, migrated, err := visor.MigrateConfigFile(configPath)
 		if err != nil {
-			cfg.logger.Fatalf("Failed to open config: %s", err)
+			cfg.logger.Fatal(err)
+		}
+		if migrated {
+			cfg.logger.Infof("Migrated config from version %s to %s", from, visor.ConfigVersion)
 		}
+		conf, err := visor.ReadConfig(configPath)
+		if err != nil {
+			cfg.logger.Fatal(err)
+		}
+		cfg.conf = *conf
 	} else {
 		cfg.logger.Info("Reading config from STDIN")
-		rdr = bufio.NewReader(os.Stdin)
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			cfg.logger.Fatal("Failed to read config: ", err)
+		}
+		from, migrated, err := visor.MigrateConfig(data)
+		if err != nil {
+			cfg.logger.Fatal(err)
+		}
+		if migrated != nil {
+			cfg.logger.Infof("Migrated config from version %s to %s", from, visor.ConfigVersion)
+			data = migrated
+		}
+		cfg.conf = visor.Config{}
+		if err := json.Unmarshal(data, &cfg.conf); err != nil {
+			cfg.logger.Fatalf("Failed to decode config: %s", err)
+		}
 	}
-
-	cfg.conf = visor.Config{}
-	if err := json.NewDecoder(rdr).Decode(&cfg.conf); err != nil {
-		cfg.logger.Fatalf("Failed to decode %s: %s", rdr, err)
+	if err := cfg.conf.ApplyProfile(cfg.confProfile); err != nil {
+		cfg.logger.Fatal(err)
+	}
+	if cfg.confProfile != "" {
+		cfg.logger.Infof("Applied config profile %s", cfg.confProfile)
+	}
+	applied, err := cfg.conf.ApplyEnv(os.LookupEnv)
+	if err != nil {
+		cfg.logger.Fatal(err)
+	}
+	if len(applied) > 0 {
+		cfg.logger.Infof("Config overridden by environment: %s", strings.Join(applied, ", "))
 	}
 	fmt.Println("TCP Factory conf:", cfg.conf.STCP)
 	return cfg
 }
 
-func (cfg *runCfg) runNode() *runCfg {
-	node, err := visor.NewNode(&cfg.conf, cfg.masterLogger)
+func (cfg *runCfg) captureRestartContext() *runCfg {
+	if cfg.conf.Restart != nil {
+		if err := cfg.conf.Restart.Validate(); err != nil {
+			cfg.logger.Fatal(err)
+		}
+	}
+	if cfg.cfgFromStdin {
+		if cfg.conf.Restart != nil {
+			cfg.logger.Warn("Scheduled restarts are disabled when reading config from STDIN")
+		}
+		return cfg
+	}
+	ctx, err := restart.CaptureContext()
 	if err != nil {
-		cfg.logger.Fatal("Failed to initialize node: ", err)
+		cfg.logger.Error("Restarts are disabled: ", err)
+		return cfg
 	}
+	cfg.restartCtx = ctx
+	cfg.restartReq = make(chan struct{}, 1)
+	return cfg
+}
 
-	if cfg.conf.Uptime.Tracker != "" {
-		uptimeTracker, err := utclient.NewHTTP(cfg.conf.Uptime.Tracker, cfg.conf.Node.StaticPubKey, cfg.conf.Node.StaticSecKey)
-		if err != nil {
-			cfg.logger.Error("Failed to connect to uptime tracker: ", err)
-		} else {
-			ticker := time.NewTicker(1 * time.Second)
-			defer ticker.Stop()
+// unsealKeys unseals sealed secret keys with the passphrase of the environment or the OS keyring, prompting for it
+// if STDIN is a terminal, or waiting for it to be sent over the RPC interface otherwise.
+func (cfg *runCfg) unsealKeys() *runCfg {
+	confs, err := cfg.conf.IdentityConfigs()
+	if err != nil {
+		cfg.logger.Fatal("Invalid identities: ", err)
+	}
+	cfg.confs = confs
 
-			go func() {
-				for range ticker.C {
-					ctx := context.Background()
-					if err := uptimeTracker.UpdateNodeUptime(ctx); err != nil {
-						cfg.logger.Error("Failed to update node uptime: ", err)
-					}
-				}
-			}()
+	var sealed []*visor.Config
+	for _, conf := range confs {
+		if !conf.Sealed() {
+			continue
+		}
+		switch err := conf.UnsealFromEnv(os.LookupEnv); err {
+		case nil:
+			cfg.logger.Infof("Unsealed secret key of %s", conf.Node.StaticPubKey)
+		case visor.ErrKeySealed:
+			sealed = append(sealed, conf)
+		default:
+			cfg.logger.Fatalf("Failed to unseal secret key of %s: %v", conf.Node.StaticPubKey, err)
 		}
 	}
+	if len(sealed) == 0 {
+		return cfg
+	}
+
+	if fd := int(os.Stdin.Fd()); !cfg.cfgFromStdin && terminal.IsTerminal(fd) {
+		for _, conf := range sealed {
+			fmt.Fprintf(os.Stderr, "Passphrase of the secret key of %s: ", conf.Node.StaticPubKey) //nolint:errcheck
+			passphrase, err := terminal.ReadPassword(fd)
+			fmt.Fprintln(os.Stderr) //nolint:errcheck
+			if err == nil {
+				err = conf.Unseal(passphrase)
+			}
+			if err != nil {
+				cfg.logger.Fatalf("Failed to unseal secret key of %s: %v", conf.Node.StaticPubKey, err)
+			}
+		}
+		return cfg
+	}
+
+	addr := cfg.conf.Interfaces.RPCAddress
+	if addr == "" {
+		cfg.logger.Fatalf("Secret keys are sealed: set $%s, or enable the RPC interface to unseal them", visor.KeyPassphraseEnv)
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		cfg.logger.Fatal("Failed to listen for unseal requests: ", err)
+	}
+	cfg.logger.Infof("Secret keys are sealed, waiting for 'skywire-cli node unseal' on %s", addr)
+	if err := visor.ServeUnseal(l, sealed...); err != nil {
+		cfg.logger.Fatal("Failed to serve unseal requests: ", err)
+	}
+	cfg.logger.Info("Unsealed secret keys")
+	return cfg
+}
+
+func (cfg *runCfg) runNodes() *runCfg {
+	for _, conf := range cfg.confs {
+		cfg.nodes = append(cfg.nodes, cfg.runNode(conf))
+	}
+
+	if cfg.metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		go func() {
+			if err := http.ListenAndServe(cfg.metricsAddr, mux); err != nil {
+				cfg.logger.Error("Failed to start metrics API: ", err)
+			}
+		}()
+	}
+
+	if cfg.conf.ShutdownTimeout == 0 {
+		cfg.conf.ShutdownTimeout = defaultShutdownTimeout
+	}
+	cfg.startedAt = time.Now()
+	return cfg
+}
+
+func (cfg *runCfg) runNode(conf *visor.Config) *visor.Node {
+	name, label := conf.Identity(), "node"
+	if name != "" {
+		label = "identity " + name
+		cfg.logger.Infof("Starting %s (%s)", label, conf.Node.StaticPubKey)
+	}
+	node, err := visor.NewNode(conf, cfg.masterLogger)
+	if err != nil {
+		cfg.logger.Fatalf("Failed to initialize %s: %v", label, err)
+	}
+	if cfg.restartCtx != nil {
+		node.SetRestarter(func() {
+			select {
+			case cfg.restartReq <- struct{}{}:
+			default:
+			}
+		})
+	}
+
+	if cfg.metricsAddr != "" || conf.DmsgHTTP != nil {
+		// Metrics of additional identities are distinguished by label, as they share the registry.
+		var reg prometheus.Registerer = prometheus.DefaultRegisterer
+		if name != "" {
+			reg = prometheus.WrapRegistererWith(prometheus.Labels{"identity": name}, reg)
+		}
+		reg.MustRegister(node.TransportMetrics(), node.DialMetrics(), node.DmsgMetrics())
+	}
+	if conf.DmsgHTTP != nil {
+		go func() {
+			if err := node.ServeDmsgHTTP(node.HTTPHandler(promhttp.Handler())); err != nil {
+				cfg.logger.Error("Failed to serve HTTP API over dmsg: ", err)
+			}
+		}()
+	}
+
+	if conf.RESTAPI != nil {
+		go func() {
+			if err := node.ServeRESTAPI(); err != nil {
+				cfg.logger.Error("Failed to serve REST API: ", err)
+			}
+		}()
+	}
 
 	go func() {
 		if err := node.Start(); err != nil {
-			cfg.logger.Fatal("Failed to start node: ", err)
+			cfg.logger.Fatalf("Failed to start %s: %v", label, err)
 		}
 	}()
+	return node
+}
 
-	if cfg.conf.ShutdownTimeout == 0 {
-		cfg.conf.ShutdownTimeout = defaultShutdownTimeout
+// notifySystemd notifies systemd once all nodes are ready, and pings the systemd watchdog while all nodes are alive,
+// if the visor is run as a service of 'Type=notify', optionally with 'WatchdogSec'.
+func (cfg *runCfg) notifySystemd() *runCfg {
+	interval, err := sdnotify.WatchdogInterval()
+	if err != nil {
+		cfg.logger.Error("Systemd watchdog is disabled: ", err)
 	}
-	cfg.node = node
+	go func() {
+		for _, node := range cfg.nodes {
+			<-node.Ready()
+		}
+		sent, err := sdnotify.Notify(sdnotify.Ready)
+		if err != nil {
+			cfg.logger.Error("Failed to notify systemd: ", err)
+		}
+		if !sent || interval == 0 {
+			return
+		}
+		cfg.logger.Infof("Pinging systemd watchdog every %s", interval/2)
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := cfg.checkAlive(interval / 4); err != nil {
+				cfg.logger.Error("Skipping systemd watchdog ping: ", err)
+				continue
+			}
+			if _, err := sdnotify.Notify(sdnotify.Watchdog); err != nil {
+				cfg.logger.Error("Failed to ping systemd watchdog: ", err)
+			}
+		}
+	}()
 	return cfg
 }
 
-func (cfg *runCfg) stopNode() *runCfg {
+func (cfg *runCfg) nodesReady() bool {
+	for _, node := range cfg.nodes {
+		select {
+		case <-node.Ready():
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (cfg *runCfg) checkAlive(timeout time.Duration) error {
+	for i, node := range cfg.nodes {
+		if err := node.CheckAlive(timeout); err != nil {
+			return fmt.Errorf("node %s: %v", cfg.confs[i].Node.StaticPubKey, err)
+		}
+	}
+	return nil
+}
+
+func (cfg *runCfg) stopNodes() *runCfg {
 	defer cfg.profileStop()
-	if err := cfg.node.Close(); err != nil {
-		if !strings.Contains(err.Error(), "closed") {
+	if _, err := sdnotify.Notify(sdnotify.Stopping); err != nil {
+		cfg.logger.Error("Failed to notify systemd: ", err)
+	}
+	grace := cfg.conf.ShutdownGracePeriod
+	if grace == 0 {
+		grace = visor.DefaultShutdownGracePeriod
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(grace))
+	defer cancel()
+
+	errs := make(chan error, len(cfg.nodes))
+	for _, node := range cfg.nodes {
+		go func(node *visor.Node) {
+			errs <- node.Shutdown(ctx)
+		}(node)
+	}
+	for range cfg.nodes {
+		if err := <-errs; err != nil && !strings.Contains(err.Error(), "closed") {
 			cfg.logger.Fatal("Failed to close node: ", err)
 		}
 	}
 	return cfg
 }
 
+func (cfg *runCfg) restartNode() *runCfg {
+	if !cfg.restart {
+		return cfg
+	}
+	if err := cfg.restartCtx.Exec(); err != nil {
+		cfg.logger.Fatal("Failed to restart: ", err)
+	}
+	return cfg
+}
+
 func (cfg *runCfg) waitOsSignals() *runCfg {
 	ch := make(chan os.Signal, 2)
-	signal.Notify(ch, []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT}...)
-	<-ch
+	signal.Notify(ch, []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP}...)
+	var restartC <-chan time.Time
+	if cfg.restartCtx != nil && cfg.conf.Restart != nil {
+		if next, ok := cfg.conf.Restart.Next(cfg.startedAt, time.Now()); ok {
+			cfg.logger.Infof("Scheduled restart at %s", next.Format(time.RFC3339))
+			timer := time.NewTimer(time.Until(next))
+			defer timer.Stop()
+			restartC = timer.C
+		}
+	}
+	for {
+		var s os.Signal
+		select {
+		case s = <-ch:
+		case <-restartC:
+			cfg.logger.Info("Restarting on schedule")
+			cfg.restart = true
+		case <-cfg.restartReq:
+			cfg.logger.Info("Restarting on request")
+			cfg.restart = true
+		}
+		if s != syscall.SIGHUP {
+			break
+		}
+		ready := cfg.nodesReady()
+		if ready {
+			sdnotify.Notify(sdnotify.Reloading) //nolint:errcheck
+		}
+		for _, node := range cfg.nodes {
+			res, err := node.Reload()
+			if err != nil {
+				cfg.logger.Error("Failed to reload config: ", err)
+				continue
+			}
+			if len(res.RestartRequired) > 0 {
+				cfg.logger.Warnf("Config changes require a restart: %s", strings.Join(res.RestartRequired, ", "))
+			}
+		}
+		if ready {
+			sdnotify.Notify(sdnotify.Ready) //nolint:errcheck
+		}
+	}
+	signal.Ignore(syscall.SIGHUP)
 	go func() {
 		select {
 		case <-time.After(time.Duration(cfg.conf.ShutdownTimeout)):
@@ -0,0 +1,204 @@
+// Package fec implements systematic Reed-Solomon erasure coding over GF(2^8).
+//
+// Data is split into data shards, from which parity shards are computed. Any combination of data and parity
+// shards which has at least as many shards as there are data shards is enough to reconstruct the data.
+package fec
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTooFewShards occurs when there are not enough shards to reconstruct the data.
+	ErrTooFewShards = errors.New("too few shards to reconstruct data")
+
+	// ErrShardSize occurs when shards are not all of the same size.
+	ErrShardSize = errors.New("shards are of different sizes")
+
+	// ErrShardCount occurs when the number of shards given does not match the codec.
+	ErrShardCount = errors.New("unexpected number of shards")
+)
+
+// MaxShards is the maximum total number of shards supported by a Codec.
+const MaxShards = 256
+
+// Codec encodes and reconstructs shards.
+type Codec struct {
+	dataShards   int
+	parityShards int
+	matrix       [][]byte // (dataShards+parityShards) x dataShards, top rows form the identity matrix.
+}
+
+// New creates a Codec with the given number of data and parity shards.
+func New(dataShards, parityShards int) (*Codec, error) {
+	if dataShards <= 0 || parityShards < 0 {
+		return nil, fmt.Errorf("invalid shard counts: data(%d) parity(%d)", dataShards, parityShards)
+	}
+	if dataShards+parityShards > MaxShards {
+		return nil, fmt.Errorf("too many shards: %d > %d", dataShards+parityShards, MaxShards)
+	}
+
+	total := dataShards + parityShards
+	vm := vandermonde(total, dataShards)
+	top, err := invert(vm[:dataShards])
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		matrix:       mulMatrix(vm, top),
+	}, nil
+}
+
+// DataShards returns the number of data shards.
+func (c *Codec) DataShards() int { return c.dataShards }
+
+// ParityShards returns the number of parity shards.
+func (c *Codec) ParityShards() int { return c.parityShards }
+
+// Split splits data into data shards padded with zeros to equal size, and allocates empty parity shards.
+func (c *Codec) Split(data []byte) [][]byte {
+	size := (len(data) + c.dataShards - 1) / c.dataShards
+	if size == 0 {
+		size = 1
+	}
+	shards := make([][]byte, c.dataShards+c.parityShards)
+	for i := range shards {
+		shards[i] = make([]byte, size)
+		if i < c.dataShards && i*size < len(data) {
+			copy(shards[i], data[i*size:])
+		}
+	}
+	return shards
+}
+
+// Join concatenates the data shards and truncates the result to the given size.
+func (c *Codec) Join(shards [][]byte, size int) ([]byte, error) {
+	if len(shards) < c.dataShards {
+		return nil, ErrShardCount
+	}
+	out := make([]byte, 0, size)
+	for _, s := range shards[:c.dataShards] {
+		if s == nil {
+			return nil, ErrTooFewShards
+		}
+		out = append(out, s...)
+	}
+	if len(out) < size {
+		return nil, ErrShardSize
+	}
+	return out[:size], nil
+}
+
+// Encode computes the parity shards from the data shards.
+// All shards must be allocated and of equal size.
+func (c *Codec) Encode(shards [][]byte) error {
+	size, err := c.checkShards(shards, false)
+	if err != nil {
+		return err
+	}
+	for i := c.dataShards; i < len(shards); i++ {
+		c.encodeRow(shards, i, size)
+	}
+	return nil
+}
+
+// Reconstruct recreates missing shards (represented by nil entries) in place.
+func (c *Codec) Reconstruct(shards [][]byte) error {
+	size, err := c.checkShards(shards, true)
+	if err != nil {
+		return err
+	}
+
+	// Select the first dataShards shards which are present.
+	present := make([]int, 0, c.dataShards)
+	var missingData bool
+	for i, s := range shards {
+		if s == nil {
+			missingData = missingData || i < c.dataShards
+			continue
+		}
+		if len(present) < c.dataShards {
+			present = append(present, i)
+		}
+	}
+	if len(present) < c.dataShards {
+		return ErrTooFewShards
+	}
+
+	if missingData {
+		sub := make([][]byte, c.dataShards)
+		for i, idx := range present {
+			sub[i] = c.matrix[idx]
+		}
+		dec, err := invert(sub)
+		if err != nil {
+			return err
+		}
+		for d := 0; d < c.dataShards; d++ {
+			if shards[d] != nil {
+				continue
+			}
+			out := make([]byte, size)
+			for j, idx := range present {
+				mulAdd(out, shards[idx], dec[d][j])
+			}
+			shards[d] = out
+		}
+	}
+
+	for i := c.dataShards; i < len(shards); i++ {
+		if shards[i] == nil {
+			shards[i] = make([]byte, size)
+			c.encodeRow(shards, i, size)
+		}
+	}
+	return nil
+}
+
+func (c *Codec) encodeRow(shards [][]byte, row, size int) {
+	out := shards[row]
+	for b := 0; b < size; b++ {
+		out[b] = 0
+	}
+	for j := 0; j < c.dataShards; j++ {
+		mulAdd(out, shards[j], c.matrix[row][j])
+	}
+}
+
+// checkShards returns the size of the shards.
+func (c *Codec) checkShards(shards [][]byte, allowNil bool) (int, error) {
+	if len(shards) != c.dataShards+c.parityShards {
+		return 0, ErrShardCount
+	}
+	size := -1
+	for _, s := range shards {
+		if s == nil {
+			if !allowNil {
+				return 0, ErrShardSize
+			}
+			continue
+		}
+		if size == -1 {
+			size = len(s)
+		} else if len(s) != size {
+			return 0, ErrShardSize
+		}
+	}
+	if size <= 0 {
+		return 0, ErrTooFewShards
+	}
+	return size, nil
+}
+
+// mulAdd performs out ^= in * f.
+func mulAdd(out, in []byte, f byte) {
+	if f == 0 {
+		return
+	}
+	for i, v := range in {
+		out[i] ^= gfMul(v, f)
+	}
+}
@@ -0,0 +1,59 @@
+package fec
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec_Reconstruct(t *testing.T) {
+	cases := []struct {
+		data, parity int
+	}{
+		{1, 1},
+		{4, 2},
+		{10, 4},
+		{200, 56},
+	}
+	for _, tc := range cases {
+		c, err := New(tc.data, tc.parity)
+		require.NoError(t, err)
+
+		data := make([]byte, 1000+rand.Intn(1000))
+		rand.Read(data) // nolint:gosec
+
+		shards := c.Split(data)
+		require.NoError(t, c.Encode(shards))
+
+		// Drop as many shards as there are parity shards.
+		orig := make([][]byte, len(shards))
+		copy(orig, shards)
+		for _, i := range rand.Perm(len(shards))[:tc.parity] {
+			shards[i] = nil
+		}
+		require.NoError(t, c.Reconstruct(shards))
+		for i := range shards {
+			assert.True(t, bytes.Equal(orig[i], shards[i]), "shard %d", i)
+		}
+
+		out, err := c.Join(shards, len(data))
+		require.NoError(t, err)
+		assert.Equal(t, data, out)
+
+		// Dropping one more shard than there are parity shards is unrecoverable.
+		for _, i := range rand.Perm(len(shards))[:tc.parity+1] {
+			shards[i] = nil
+		}
+		assert.Equal(t, ErrTooFewShards, c.Reconstruct(shards))
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(0, 1)
+	assert.Error(t, err)
+	_, err = New(200, 57)
+	assert.Error(t, err)
+}
@@ -0,0 +1,123 @@
+package fec
+
+import "errors"
+
+// errSingular occurs when attempting to invert a singular matrix.
+var errSingular = errors.New("matrix is singular")
+
+// gfPoly is the primitive polynomial used to generate GF(2^8): x^8 + x^4 + x^3 + x^2 + 1.
+const gfPoly = 0x11d
+
+var (
+	gfExp [512]byte
+	gfLog [256]int
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= gfPoly
+		}
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+255-gfLog[b]]
+}
+
+func gfPow(a byte, n int) byte {
+	if n == 0 {
+		return 1
+	}
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(gfLog[a]*n)%255]
+}
+
+// vandermonde returns a rows x cols matrix where m[r][c] = r^c.
+func vandermonde(rows, cols int) [][]byte {
+	m := make([][]byte, rows)
+	for r := range m {
+		m[r] = make([]byte, cols)
+		for c := range m[r] {
+			m[r][c] = gfPow(byte(r), c)
+		}
+	}
+	return m
+}
+
+func mulMatrix(a, b [][]byte) [][]byte {
+	out := make([][]byte, len(a))
+	for r := range a {
+		out[r] = make([]byte, len(b[0]))
+		for c := range out[r] {
+			var v byte
+			for i := range b {
+				v ^= gfMul(a[r][i], b[i][c])
+			}
+			out[r][c] = v
+		}
+	}
+	return out
+}
+
+// invert inverts a square matrix using Gauss-Jordan elimination.
+func invert(m [][]byte) ([][]byte, error) {
+	n := len(m)
+	aug := make([][]byte, n)
+	for r := range aug {
+		aug[r] = make([]byte, 2*n)
+		copy(aug[r], m[r])
+		aug[r][n+r] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := -1
+		for r := col; r < n; r++ {
+			if aug[r][col] != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot == -1 {
+			return nil, errSingular
+		}
+		aug[col], aug[pivot] = aug[pivot], aug[col]
+
+		if f := aug[col][col]; f != 1 {
+			for c := range aug[col] {
+				aug[col][c] = gfDiv(aug[col][c], f)
+			}
+		}
+		for r := 0; r < n; r++ {
+			if r == col || aug[r][col] == 0 {
+				continue
+			}
+			mulAdd(aug[r], aug[col], aug[r][col])
+		}
+	}
+
+	out := make([][]byte, n)
+	for r := range out {
+		out[r] = aug[r][n:]
+	}
+	return out, nil
+}
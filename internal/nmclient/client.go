@@ -0,0 +1,97 @@
+// Package nmclient implements the network-monitor reporting client.
+package nmclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+)
+
+var log = logging.MustGetLogger("nmclient")
+
+// Error is the object returned to the client when there's an error.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// Report is an anonymized health report of a visor. It does not identify the visor: Instance is random for each
+// run of the visor, so that reports of a run may be aggregated without linking them to the keys of the visor.
+type Report struct {
+	Instance   string         `json:"instance"`
+	Version    string         `json:"version"`
+	Uptime     float64        `json:"uptime"`     // seconds since the visor started.
+	Transports map[string]int `json:"transports"` // number of transports of each type.
+	Time       time.Time      `json:"time"`
+}
+
+// APIClient implements the network-monitor API client.
+type APIClient interface {
+	SubmitReport(ctx context.Context, r *Report) error
+}
+
+// httpClient implements Client for the network-monitor API.
+type httpClient struct {
+	addr   string
+	client *http.Client
+}
+
+// NewHTTP creates a new client of the network monitor at addr.
+// Reports are not signed, as signing them would identify the visor.
+func NewHTTP(addr string) APIClient {
+	return &httpClient{addr: strings.TrimRight(addr, "/"), client: &http.Client{}}
+}
+
+// SubmitReport submits a health report.
+func (c *httpClient) SubmitReport(ctx context.Context, r *Report) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.addr+"/reports", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if resp != nil {
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				log.WithError(err).Warn("Failed to close response body")
+			}
+		}()
+	}
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("status: %d, error: %v", resp.StatusCode, extractError(resp.Body))
+	}
+	return nil
+}
+
+// extractError returns the decoded error message from Body.
+func extractError(r io.Reader) error {
+	var apiError Error
+
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, &apiError); err != nil {
+		return errors.New(string(body))
+	}
+
+	return errors.New(apiError.Error)
+}
@@ -0,0 +1,53 @@
+package nmclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitReport(t *testing.T) {
+	reports := make(chan Report, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/reports", r.URL.String())
+		assert.Empty(t, r.Header.Get("SW-Public"))
+		var report Report
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid report"}`)) //nolint:errcheck
+			return
+		}
+		reports <- report
+	}))
+	defer srv.Close()
+
+	want := Report{
+		Instance:   "abc",
+		Version:    "1.0",
+		Uptime:     60,
+		Transports: map[string]int{"dmsg": 2},
+		Time:       time.Now().UTC().Truncate(time.Second),
+	}
+	c := NewHTTP(srv.URL + "/")
+	require.NoError(t, c.SubmitReport(context.TODO(), &want))
+	got := <-reports
+	assert.Equal(t, want.Instance, got.Instance)
+	assert.Equal(t, want.Transports, got.Transports)
+	assert.True(t, want.Time.Equal(got.Time))
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"unavailable"}`)) //nolint:errcheck
+	}))
+	defer failing.Close()
+	err := NewHTTP(failing.URL).SubmitReport(context.TODO(), &want)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unavailable")
+}
@@ -0,0 +1,112 @@
+package portmap
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// NAT-PMP constants (RFC 6886).
+const (
+	natpmpPort        = 5351
+	natpmpVersion     = 0
+	natpmpOpExtAddr   = 0
+	natpmpOpMapTCP    = 2
+	natpmpMaxAttempts = 4
+	natpmpInitTimeout = 250 * time.Millisecond
+)
+
+// NATPMP is a NAT-PMP client.
+type NATPMP struct {
+	addr string
+}
+
+// NewNATPMP creates a NAT-PMP client for the given gateway.
+func NewNATPMP(gateway net.IP) *NATPMP {
+	return &NATPMP{addr: net.JoinHostPort(gateway.String(), fmt.Sprint(natpmpPort))}
+}
+
+// Type implements Mapper.
+func (*NATPMP) Type() string { return "nat-pmp" }
+
+// ExternalIP implements Mapper.
+func (c *NATPMP) ExternalIP(ctx context.Context) (net.IP, error) {
+	resp, err := c.call(ctx, []byte{natpmpVersion, natpmpOpExtAddr}, 12)
+	if err != nil {
+		return nil, err
+	}
+	return net.IPv4(resp[8], resp[9], resp[10], resp[11]), nil
+}
+
+// AddMapping implements Mapper.
+func (c *NATPMP) AddMapping(ctx context.Context, internalPort, externalPort uint16, lease time.Duration) (uint16, error) {
+	resp, err := c.call(ctx, natpmpMapRequest(internalPort, externalPort, uint32(lease/time.Second)), 16)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(resp[10:12]), nil
+}
+
+// DeleteMapping implements Mapper.
+func (c *NATPMP) DeleteMapping(ctx context.Context, internalPort, _ uint16) error {
+	_, err := c.call(ctx, natpmpMapRequest(internalPort, 0, 0), 16)
+	return err
+}
+
+func natpmpMapRequest(internalPort, externalPort uint16, lifetime uint32) []byte {
+	req := make([]byte, 12)
+	req[0] = natpmpVersion
+	req[1] = natpmpOpMapTCP
+	binary.BigEndian.PutUint16(req[4:6], internalPort)
+	binary.BigEndian.PutUint16(req[6:8], externalPort)
+	binary.BigEndian.PutUint32(req[8:12], lifetime)
+	return req
+}
+
+// call sends the request and waits for a response of at least the given size.
+// Retransmissions double the timeout, as recommended by the RFC.
+func (c *NATPMP) call(ctx context.Context, req []byte, size int) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp4", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }() //nolint:errcheck
+
+	resp := make([]byte, 16)
+	timeout := natpmpInitTimeout
+	for i := 0; i < natpmpMaxAttempts; i++ {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		n, err := conn.Read(resp)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				timeout *= 2
+				continue
+			}
+			return nil, err
+		}
+		if n < size || resp[0] != natpmpVersion || resp[1] != req[1]|0x80 {
+			return nil, errors.New("nat-pmp: malformed response")
+		}
+		if code := binary.BigEndian.Uint16(resp[2:4]); code != 0 {
+			return nil, fmt.Errorf("nat-pmp: result code %d", code)
+		}
+		return resp[:n], nil
+	}
+	return nil, errors.New("nat-pmp: gateway did not respond")
+}
@@ -0,0 +1,62 @@
+package portmap
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGateway answers NAT-PMP requests on a local UDP socket.
+func fakeGateway(t *testing.T) (*NATPMP, func()) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var resp []byte
+			switch {
+			case n == 2 && buf[1] == natpmpOpExtAddr:
+				resp = make([]byte, 12)
+				copy(resp[8:], net.IPv4(1, 2, 3, 4).To4())
+			case n == 12 && buf[1] == natpmpOpMapTCP:
+				resp = make([]byte, 16)
+				copy(resp[8:10], buf[4:6])
+				binary.BigEndian.PutUint16(resp[10:12], binary.BigEndian.Uint16(buf[6:8])+1)
+				copy(resp[12:16], buf[8:12])
+			default:
+				continue
+			}
+			resp[1] = buf[1] | 0x80
+			_, _ = conn.WriteTo(resp, addr) //nolint:errcheck
+		}
+	}()
+
+	return &NATPMP{addr: conn.LocalAddr().String()}, func() { _ = conn.Close() } //nolint:errcheck
+}
+
+func TestNATPMP(t *testing.T) {
+	c, stop := fakeGateway(t)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ip, err := c.ExternalIP(ctx)
+	require.NoError(t, err)
+	require.True(t, ip.Equal(net.IPv4(1, 2, 3, 4)))
+
+	port, err := c.AddMapping(ctx, 7777, 7777, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, uint16(7778), port)
+
+	require.NoError(t, c.DeleteMapping(ctx, 7777, port))
+}
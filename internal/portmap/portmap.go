@@ -0,0 +1,94 @@
+// Package portmap implements minimal NAT-PMP and UPnP IGD clients which are used to
+// open ports on home routers for incoming connections.
+package portmap
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultLease is the default lifetime of a port mapping.
+const DefaultLease = time.Hour
+
+var (
+	// ErrNoGateway occurs when the default gateway cannot be determined.
+	ErrNoGateway = errors.New("failed to determine default gateway")
+
+	// ErrNoMapper occurs when neither NAT-PMP nor UPnP is available on the local network.
+	ErrNoMapper = errors.New("no NAT-PMP or UPnP gateway found")
+)
+
+// Mapper maps ports on a NAT gateway.
+type Mapper interface {
+	// Type returns the mapping protocol name.
+	Type() string
+
+	// ExternalIP obtains the external IP address of the gateway.
+	ExternalIP(ctx context.Context) (net.IP, error)
+
+	// AddMapping maps the internal TCP port to an external port for the duration of lease.
+	// The returned external port may differ from the requested one.
+	AddMapping(ctx context.Context, internalPort, externalPort uint16, lease time.Duration) (uint16, error)
+
+	// DeleteMapping removes a previously added mapping.
+	DeleteMapping(ctx context.Context, internalPort, externalPort uint16) error
+}
+
+// Discover attempts to find a port mapper on the local network.
+// NAT-PMP is attempted first, followed by UPnP IGD.
+func Discover(ctx context.Context) (Mapper, error) {
+	if gw, err := DefaultGateway(); err == nil {
+		pmp := NewNATPMP(gw)
+		if _, err := pmp.ExternalIP(ctx); err == nil {
+			return pmp, nil
+		}
+	}
+	if igd, err := DiscoverUPnP(ctx); err == nil {
+		return igd, nil
+	}
+	return nil, ErrNoMapper
+}
+
+// DefaultGateway returns the IPv4 address of the default gateway.
+// Only linux is supported at the moment (via /proc/net/route).
+func DefaultGateway() (net.IP, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, ErrNoGateway
+	}
+	defer func() { _ = f.Close() }() //nolint:errcheck
+
+	s := bufio.NewScanner(f)
+	s.Scan() // skip header
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		b, err := hex.DecodeString(fields[2])
+		if err != nil || len(b) != net.IPv4len {
+			continue
+		}
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(b))
+		return ip, nil
+	}
+	return nil, ErrNoGateway
+}
+
+// LocalIP returns the local address which is used to reach the given remote address.
+func LocalIP(remote net.IP) (net.IP, error) {
+	conn, err := net.Dial("udp4", net.JoinHostPort(remote.String(), "1"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }() //nolint:errcheck
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
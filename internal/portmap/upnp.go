@@ -0,0 +1,248 @@
+package portmap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ssdpAddr   = "239.255.255.250:1900"
+	ssdpTarget = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+	ssdpWait   = 2 * time.Second
+
+	upnpDescription = "skywire"
+)
+
+var upnpServiceTypes = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:1",
+	"urn:schemas-upnp-org:service:WANPPPConnection:1",
+}
+
+// UPnP is a client of an UPnP Internet Gateway Device.
+type UPnP struct {
+	controlURL  string
+	serviceType string
+	localIP     net.IP
+	httpC       http.Client
+}
+
+// Type implements Mapper.
+func (*UPnP) Type() string { return "upnp" }
+
+// DiscoverUPnP searches the local network for an Internet Gateway Device via SSDP.
+func DiscoverUPnP(ctx context.Context) (*UPnP, error) {
+	location, err := ssdpSearch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := &UPnP{httpC: http.Client{Timeout: 5 * time.Second}}
+	if err := c.fetchDescription(ctx, location); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func ssdpSearch(ctx context.Context) (string, error) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = conn.Close() }() //nolint:errcheck
+
+	dst, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return "", err
+	}
+	req := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpAddr + "\r\n" +
+		"ST: " + ssdpTarget + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 2\r\n\r\n"
+	if _, err := conn.WriteTo([]byte(req), dst); err != nil {
+		return "", err
+	}
+
+	deadline := time.Now().Add(ssdpWait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return "", fmt.Errorf("ssdp: %v", err)
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		if loc := resp.Header.Get("Location"); loc != "" {
+			return loc, nil
+		}
+	}
+}
+
+type upnpDevice struct {
+	Services []struct {
+		ServiceType string `xml:"serviceType"`
+		ControlURL  string `xml:"controlURL"`
+	} `xml:"serviceList>service"`
+	Devices []upnpDevice `xml:"deviceList>device"`
+}
+
+func (d *upnpDevice) find(serviceType string) (string, bool) {
+	for _, s := range d.Services {
+		if s.ServiceType == serviceType {
+			return s.ControlURL, true
+		}
+	}
+	for i := range d.Devices {
+		if u, ok := d.Devices[i].find(serviceType); ok {
+			return u, true
+		}
+	}
+	return "", false
+}
+
+func (c *UPnP) fetchDescription(ctx context.Context, location string) error {
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpC.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
+
+	var root struct {
+		URLBase string     `xml:"URLBase"`
+		Device  upnpDevice `xml:"device"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&root); err != nil {
+		return fmt.Errorf("upnp: invalid device description: %v", err)
+	}
+
+	base, err := url.Parse(location)
+	if err != nil {
+		return err
+	}
+	if root.URLBase != "" {
+		if base, err = url.Parse(root.URLBase); err != nil {
+			return err
+		}
+	}
+	for _, st := range upnpServiceTypes {
+		ctrl, ok := root.Device.find(st)
+		if !ok {
+			continue
+		}
+		ref, err := url.Parse(ctrl)
+		if err != nil {
+			return err
+		}
+		c.controlURL = base.ResolveReference(ref).String()
+		c.serviceType = st
+
+		host, _, err := net.SplitHostPort(base.Host)
+		if err != nil {
+			host = base.Host
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			c.localIP, _ = LocalIP(ip) //nolint:errcheck
+		}
+		return nil
+	}
+	return errors.New("upnp: gateway has no WAN connection service")
+}
+
+// ExternalIP implements Mapper.
+func (c *UPnP) ExternalIP(ctx context.Context) (net.IP, error) {
+	var out struct {
+		IP string `xml:"Body>GetExternalIPAddressResponse>NewExternalIPAddress"`
+	}
+	if err := c.soap(ctx, "GetExternalIPAddress", "", &out); err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(strings.TrimSpace(out.IP))
+	if ip == nil {
+		return nil, fmt.Errorf("upnp: invalid external address %q", out.IP)
+	}
+	return ip, nil
+}
+
+// AddMapping implements Mapper.
+func (c *UPnP) AddMapping(ctx context.Context, internalPort, externalPort uint16, lease time.Duration) (uint16, error) {
+	if c.localIP == nil {
+		return 0, errors.New("upnp: unknown local address")
+	}
+	if externalPort == 0 {
+		externalPort = internalPort
+	}
+	args := "<NewRemoteHost></NewRemoteHost>" +
+		"<NewExternalPort>" + strconv.Itoa(int(externalPort)) + "</NewExternalPort>" +
+		"<NewProtocol>TCP</NewProtocol>" +
+		"<NewInternalPort>" + strconv.Itoa(int(internalPort)) + "</NewInternalPort>" +
+		"<NewInternalClient>" + c.localIP.String() + "</NewInternalClient>" +
+		"<NewEnabled>1</NewEnabled>" +
+		"<NewPortMappingDescription>" + upnpDescription + "</NewPortMappingDescription>" +
+		"<NewLeaseDuration>" + strconv.Itoa(int(lease/time.Second)) + "</NewLeaseDuration>"
+	if err := c.soap(ctx, "AddPortMapping", args, nil); err != nil {
+		return 0, err
+	}
+	return externalPort, nil
+}
+
+// DeleteMapping implements Mapper.
+func (c *UPnP) DeleteMapping(ctx context.Context, _, externalPort uint16) error {
+	args := "<NewRemoteHost></NewRemoteHost>" +
+		"<NewExternalPort>" + strconv.Itoa(int(externalPort)) + "</NewExternalPort>" +
+		"<NewProtocol>TCP</NewProtocol>"
+	return c.soap(ctx, "DeletePortMapping", args, nil)
+}
+
+func (c *UPnP) soap(ctx context.Context, action, args string, out interface{}) error {
+	body := `<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:` + action + ` xmlns:u="` + c.serviceType + `">` + args + `</u:` + action + `></s:Body></s:Envelope>`
+
+	req, err := http.NewRequest(http.MethodPost, c.controlURL, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+c.serviceType+"#"+action+`"`)
+
+	resp, err := c.httpC.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upnp: %s failed with status %d", action, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return xml.Unmarshal(data, out)
+}
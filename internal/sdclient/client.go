@@ -0,0 +1,138 @@
+// Package sdclient implements a client of the service discovery, which lists the services, such as proxy servers,
+// which visors advertise publicly on the network.
+package sdclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/geo"
+)
+
+var log = logging.MustGetLogger("sdclient")
+
+// Types of the services.
+const (
+	ServiceTypeProxy = "proxy" // socksproxy servers.
+	ServiceTypeVPN   = "vpn"
+)
+
+// Error is the object returned to the client when there's an error.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// ServiceAddr is the address of a service, the public key of its visor and the port of its app. It is encoded as
+// '<pk>:<port>'.
+type ServiceAddr struct {
+	PK   cipher.PubKey
+	Port uint16
+}
+
+// String implements fmt.Stringer
+func (a ServiceAddr) String() string {
+	return fmt.Sprintf("%s:%d", a.PK, a.Port)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (a ServiceAddr) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (a *ServiceAddr) UnmarshalText(text []byte) error {
+	i := strings.LastIndexByte(string(text), ':')
+	if i < 0 {
+		return fmt.Errorf("invalid service address '%s': expected '<pk>:<port>'", text)
+	}
+	if err := a.PK.UnmarshalText(text[:i]); err != nil {
+		return fmt.Errorf("invalid service address '%s': %v", text, err)
+	}
+	port, err := strconv.ParseUint(string(text[i+1:]), 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid service address '%s': %v", text, err)
+	}
+	a.Port = uint16(port)
+	return nil
+}
+
+// Service is a service which a visor advertises.
+type Service struct {
+	Addr    ServiceAddr   `json:"address"`
+	Type    string        `json:"type"`
+	Geo     *geo.Location `json:"geo,omitempty"`
+	Country string        `json:"country,omitempty"` // ISO 3166-1 alpha-2 code, if known.
+}
+
+// APIClient implements service discovery API client.
+type APIClient interface {
+	Services(ctx context.Context, serviceType string) ([]Service, error)
+}
+
+// httpClient implements APIClient for the service discovery API.
+type httpClient struct {
+	addr   string
+	client http.Client
+}
+
+// NewHTTP creates a new client of the service discovery at addr.
+func NewHTTP(addr string) APIClient {
+	return &httpClient{addr: strings.TrimSuffix(addr, "/")}
+}
+
+// Services returns the services of the type.
+func (c *httpClient) Services(ctx context.Context, serviceType string) ([]Service, error) {
+	uri := c.addr + "/api/services?" + url.Values{"type": {serviceType}}.Encode()
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if resp != nil {
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				log.WithError(err).Warn("Failed to close response body")
+			}
+		}()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status: %d, error: %v", resp.StatusCode, extractError(resp.Body))
+	}
+
+	var services []Service
+	if err := json.NewDecoder(resp.Body).Decode(&services); err != nil {
+		return nil, fmt.Errorf("invalid response: %v", err)
+	}
+	return services, nil
+}
+
+// extractError returns the decoded error message from Body.
+func extractError(r io.Reader) error {
+	var apiError Error
+
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, &apiError); err != nil {
+		return errors.New(string(body))
+	}
+
+	return errors.New(apiError.Error)
+}
@@ -0,0 +1,50 @@
+package sdclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAddr(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	addr := ServiceAddr{PK: pk, Port: 3}
+	b, err := json.Marshal(addr)
+	require.NoError(t, err)
+	assert.Equal(t, `"`+pk.Hex()+`:3"`, string(b))
+
+	var got ServiceAddr
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, addr, got)
+
+	for _, s := range []string{`"` + pk.Hex() + `"`, `"` + pk.Hex() + `:port"`, `"pk:3"`, `"` + pk.Hex() + `:65536"`} {
+		assert.Error(t, json.Unmarshal([]byte(s), &got), s)
+	}
+}
+
+func TestHTTPClient_Services(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/services" || r.URL.Query().Get("type") != ServiceTypeProxy {
+			w.WriteHeader(http.StatusNotFound)
+			require.NoError(t, json.NewEncoder(w).Encode(Error{Error: "not found"}))
+			return
+		}
+		_, err := w.Write([]byte(`[{"address":"` + pk.Hex() + `:3","type":"proxy","country":"DE"}]`))
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	services, err := NewHTTP(srv.URL+"/").Services(context.Background(), ServiceTypeProxy)
+	require.NoError(t, err)
+	assert.Equal(t, []Service{{Addr: ServiceAddr{PK: pk, Port: 3}, Type: ServiceTypeProxy, Country: "DE"}}, services)
+
+	_, err = NewHTTP(srv.URL).Services(context.Background(), ServiceTypeVPN)
+	assert.EqualError(t, err, "status: 404, error: not found")
+}
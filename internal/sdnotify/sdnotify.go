@@ -0,0 +1,69 @@
+// Package sdnotify implements the systemd service notification protocol (sd_notify), which lets services of
+// 'Type=notify' report their readiness and keep the systemd watchdog satisfied.
+package sdnotify
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// States sent to systemd.
+const (
+	Ready     = "READY=1"
+	Reloading = "RELOADING=1"
+	Stopping  = "STOPPING=1"
+	Watchdog  = "WATCHDOG=1"
+)
+
+// Environment variables set by systemd.
+const (
+	SocketEnv       = "NOTIFY_SOCKET"
+	WatchdogUSecEnv = "WATCHDOG_USEC"
+	WatchdogPIDEnv  = "WATCHDOG_PID"
+)
+
+// Notify sends state to the notification socket of $NOTIFY_SOCKET. It returns false without an error if the
+// service is not run by systemd with notifications enabled.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv(SocketEnv)
+	if socket == "" {
+		return false, nil
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:] // abstract socket.
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close() //nolint:errcheck
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Status returns the state which sets the free-form status of the service shown by 'systemctl status'.
+func Status(status string) string {
+	return "STATUS=" + status
+}
+
+// WatchdogInterval returns the watchdog timeout of the service, or 0 if the watchdog is disabled or
+// is not meant for this process. Watchdog pings should be sent at half the interval.
+func WatchdogInterval() (time.Duration, error) {
+	usec := os.Getenv(WatchdogUSecEnv)
+	if usec == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, errors.New("invalid $" + WatchdogUSecEnv + ": " + usec)
+	}
+	if pid := os.Getenv(WatchdogPIDEnv); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, nil
+	}
+	return time.Duration(n) * time.Microsecond, nil
+}
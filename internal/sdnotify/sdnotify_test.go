@@ -0,0 +1,71 @@
+package sdnotify
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotify(t *testing.T) {
+	defer func() { require.NoError(t, os.Unsetenv(SocketEnv)) }()
+
+	require.NoError(t, os.Unsetenv(SocketEnv))
+	sent, err := Notify(Ready)
+	require.NoError(t, err)
+	assert.False(t, sent)
+
+	dir, err := ioutil.TempDir("", "sdnotify")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, conn.Close()) }()
+
+	require.NoError(t, os.Setenv(SocketEnv, path))
+	sent, err = Notify(Status("serving"))
+	require.NoError(t, err)
+	assert.True(t, sent)
+
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "STATUS=serving", string(buf[:n]))
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	defer func() {
+		require.NoError(t, os.Unsetenv(WatchdogUSecEnv))
+		require.NoError(t, os.Unsetenv(WatchdogPIDEnv))
+	}()
+
+	cases := []struct {
+		usec, pid string
+		want      time.Duration
+		wantErr   bool
+	}{
+		{usec: "", want: 0},
+		{usec: "30000000", want: 30 * time.Second},
+		{usec: "30000000", pid: strconv.Itoa(os.Getpid()), want: 30 * time.Second},
+		{usec: "30000000", pid: "1", want: 0},
+		{usec: "abc", wantErr: true},
+	}
+	for _, c := range cases {
+		require.NoError(t, os.Setenv(WatchdogUSecEnv, c.usec))
+		require.NoError(t, os.Setenv(WatchdogPIDEnv, c.pid))
+		got, err := WatchdogInterval()
+		if c.wantErr {
+			assert.Error(t, err, c.usec)
+			continue
+		}
+		require.NoError(t, err, c.usec)
+		assert.Equal(t, c.want, got, c.usec)
+	}
+}
@@ -9,6 +9,7 @@ const (
 	DefaultTpDiscAddr      = "http://transport.discovery.skywire.skycoin.com"
 	DefaultDmsgDiscAddr    = "http://dmsg.discovery.skywire.skycoin.com"
 	DefaultRouteFinderAddr = "http://routefinder.skywire.skycoin.com"
+	DefaultServiceDiscAddr = "http://service.discovery.skywire.skycoin.com"
 	DefaultSetupPK         = "026c5a07de617c5c488195b76e8671bf9e7ee654d0633933e202af9e111ffa358d"
 )
 
@@ -17,6 +18,7 @@ const (
 	TestTpDiscAddr      = "http://transport.discovery.skywire.cc"
 	TestDmsgDiscAddr    = "http://dmsg.discovery.skywire.cc"
 	TestRouteFinderAddr = "http://routefinder.skywire.cc"
+	TestServiceDiscAddr = "http://service.discovery.skywire.cc"
 )
 
 // Common app constants.
@@ -29,6 +31,8 @@ const (
 	DmsgSetupPort      = uint16(36)  // Listening port of a setup node.
 	DmsgAwaitSetupPort = uint16(136) // Listening port of a visor node for setup operations.
 	DmsgTransportPort  = uint16(45)  // Listening port of a visor node for incoming transports.
+	DmsgHTTPPort       = uint16(80)  // Listening port of a visor node for HTTP API requests.
+	DmsgRPCPort        = uint16(46)  // Listening port of a visor node for RPC requests of hypervisors.
 )
 
 // Default dmsgpty constants.
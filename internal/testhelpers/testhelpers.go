@@ -27,3 +27,15 @@ func NoErrorN(t *testing.T, errs ...error) {
 		require.NoError(t, err)
 	}
 }
+
+// Eventually polls the condition every tick until it holds, failing the test if it does not within waitFor. Unlike
+// require.Eventually, the condition is checked synchronously, so it never outlives the call.
+func Eventually(t *testing.T, condition func() bool, waitFor, tick time.Duration) {
+	deadline := time.Now().Add(waitFor)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition never satisfied")
+		}
+		time.Sleep(tick)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
@@ -26,7 +27,7 @@ type Error struct {
 
 // APIClient implements messaging discovery API client.
 type APIClient interface {
-	UpdateNodeUptime(context.Context) error
+	UpdateNodeUptime(ctx context.Context, rewardAddress string) error
 }
 
 // httpClient implements Client for uptime tracker API.
@@ -61,9 +62,13 @@ func (c *httpClient) Get(ctx context.Context, path string) (*http.Response, erro
 	return c.client.Do(req.WithContext(ctx))
 }
 
-// UpdateNodeUptime updates node uptime.
-func (c *httpClient) UpdateNodeUptime(ctx context.Context) error {
-	resp, err := c.Get(ctx, "/update")
+// UpdateNodeUptime updates node uptime. The reward address of the node is reported if not empty.
+func (c *httpClient) UpdateNodeUptime(ctx context.Context, rewardAddress string) error {
+	path := "/update"
+	if rewardAddress != "" {
+		path += "?" + url.Values{"reward_address": {rewardAddress}}.Encode()
+	}
+	resp, err := c.Get(ctx, path)
 	if resp != nil {
 		defer func() {
 			if err := resp.Body.Close(); err != nil {
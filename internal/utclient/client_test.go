@@ -67,10 +67,15 @@ func TestUpdateNodeUptime(t *testing.T) {
 	c, err := NewHTTP(srv.URL, testPubKey, testSecKey)
 	require.NoError(t, err)
 
-	err = c.UpdateNodeUptime(context.TODO())
+	err = c.UpdateNodeUptime(context.TODO(), "")
 	require.NoError(t, err)
 
 	assert.Equal(t, "/update", <-urlCh)
+
+	err = c.UpdateNodeUptime(context.TODO(), "2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/update?reward_address=2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv", <-urlCh)
 }
 
 func authHandler(next http.Handler) http.Handler {
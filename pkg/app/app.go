@@ -16,6 +16,7 @@ import (
 
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 
+	"github.com/SkycoinProject/skywire-mainnet/pkg/buildinfo"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 )
 
@@ -35,7 +36,7 @@ var (
 type Config struct {
 	AppName         string `json:"app-name"`
 	AppVersion      string `json:"app-version"`
-	ProtocolVersion string `json:"protocol-version"`
+	ProtocolVersion string `json:"protocol-version"` // defaults to the version the app is built with.
 }
 
 // App represents client side in app's client-server communication
@@ -59,16 +60,23 @@ func Command(config *Config, appsPath string, args []string) (net.Conn, *exec.Cm
 		return nil, nil, fmt.Errorf("failed to open piped connection: %s", err)
 	}
 
-	binaryPath := filepath.Join(appsPath, fmt.Sprintf("%s.v%s", config.AppName, config.AppVersion))
-	cmd := exec.Command(binaryPath, args...) // nolint:gosec
+	cmd := exec.Command(BinaryPath(appsPath, config.AppName, config.AppVersion), args...) // nolint:gosec
 	cmd.ExtraFiles = []*os.File{clientConn.inFile, clientConn.outFile}
 
 	return srvConn, cmd, nil
 }
 
+// BinaryPath returns the path of the binary of the given app version in appsPath.
+func BinaryPath(appsPath, name, version string) string {
+	return filepath.Join(appsPath, fmt.Sprintf("%s.v%s", name, version))
+}
+
 // SetupFromPipe connects to a pipe, starts protocol loop and performs
 // initialization request with the Server.
 func SetupFromPipe(config *Config, inFD, outFD uintptr) (*App, error) {
+	if config.ProtocolVersion == "" {
+		config.ProtocolVersion = buildinfo.AppProtocol()
+	}
 	pipeConn, err := NewPipeConn(inFD, outFD)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open pipe: %s", err)
@@ -96,6 +104,10 @@ func SetupFromPipe(config *Config, inFD, outFD uintptr) (*App, error) {
 
 // New creates a new App directly from a `net.Conn` implementation.
 func New(conn net.Conn, conf *Config) (*App, error) {
+	if conf.ProtocolVersion == "" {
+		conf.ProtocolVersion = buildinfo.AppProtocol()
+	}
+
 	app := &App{
 		config:     *conf,
 		proto:      NewProtocol(conn),
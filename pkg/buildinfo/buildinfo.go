@@ -0,0 +1,127 @@
+// Package buildinfo embeds the compatibility versions of skywire binaries, so that they may be read from binaries
+// without running them, such as by visors before launching apps.
+package buildinfo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+)
+
+// AppProtocolVersion is the version of the protocol between visors and apps.
+const AppProtocolVersion = skyenv.AppProtocolVersion
+
+// appProtocolPrefix precedes the app protocol version embedded in binaries, which is terminated by a NUL byte.
+const appProtocolPrefix = "\x00skywire:app-protocol="
+
+// appProtocolMarker embeds AppProtocolVersion in binaries which link this package and call AppProtocol.
+const appProtocolMarker = appProtocolPrefix + AppProtocolVersion + "\x00"
+
+// maxVersionLen bounds the length of embedded versions.
+const maxVersionLen = 32
+
+// ErrNoAppProtocol occurs when a binary does not embed an app protocol version, such as if it was built before
+// versions were embedded.
+var ErrNoAppProtocol = errors.New("binary does not embed an app protocol version")
+
+// AppProtocol returns the app protocol version the binary is built with. Apps call it on setup, which also
+// ensures that the version is embedded in their binaries.
+func AppProtocol() string {
+	return strings.TrimSuffix(strings.TrimPrefix(appProtocolMarker, appProtocolPrefix), "\x00")
+}
+
+// ReadAppProtocol reads the app protocol version embedded in the binary at path.
+func ReadAppProtocol(path string) (string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck
+	return readAppProtocol(bufio.NewReader(f))
+}
+
+func readAppProtocol(r io.Reader) (string, error) {
+	prefix := []byte(appProtocolPrefix)
+	overlap := len(prefix) + maxVersionLen
+	buf := make([]byte, 0, 64*1024+overlap)
+	chunk := make([]byte, 64*1024)
+	for {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		eof := err == io.EOF
+		if err != nil && !eof {
+			return "", err
+		}
+
+		// The prefix itself may also be embedded as a string, so only versions which parse are accepted.
+		for off := 0; ; {
+			i := bytes.Index(buf[off:], prefix)
+			if i < 0 {
+				break
+			}
+			off += i + len(prefix)
+			end := bytes.IndexByte(buf[off:], 0)
+			if end < 0 {
+				if !eof && len(buf)-off < maxVersionLen {
+					break // may be completed by the next chunk, which is read after the overlap.
+				}
+				continue
+			}
+			if v := string(buf[off : off+end]); end <= maxVersionLen && validVersion(v) {
+				return v, nil
+			}
+		}
+		if eof {
+			return "", ErrNoAppProtocol
+		}
+		if len(buf) > overlap {
+			buf = append(buf[:0], buf[len(buf)-overlap:]...)
+		}
+	}
+}
+
+// CompatibleAppProtocol returns an error if apps built with the app protocol version v may not be launched by
+// binaries built with AppProtocolVersion. Versions are compatible if their major versions are equal, and for major
+// version 0, if their minor versions are also equal.
+func CompatibleAppProtocol(v string) error {
+	major, minor, err := parseVersion(v)
+	if err != nil {
+		return err
+	}
+	wantMajor, wantMinor, err := parseVersion(AppProtocolVersion)
+	if err != nil {
+		return err
+	}
+	if major != wantMajor || major == 0 && minor != wantMinor {
+		return fmt.Errorf("app protocol version %s is incompatible with supported version %s", v, AppProtocolVersion)
+	}
+	return nil
+}
+
+func validVersion(v string) bool {
+	_, _, err := parseVersion(v)
+	return err == nil
+}
+
+// parseVersion parses the major and minor versions of the version v, which is formatted as 'major.minor.patch'.
+func parseVersion(v string) (major, minor int, err error) {
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return 0, 0, fmt.Errorf("invalid version '%s'", v)
+	}
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		if nums[i], err = strconv.Atoi(p); err != nil || nums[i] < 0 {
+			return 0, 0, fmt.Errorf("invalid version '%s'", v)
+		}
+	}
+	return nums[0], nums[1], nil
+}
@@ -0,0 +1,54 @@
+package buildinfo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAppProtocol(t *testing.T) {
+	assert.Equal(t, AppProtocolVersion, AppProtocol())
+
+	dir, err := ioutil.TempDir("", "buildinfo")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	junk := strings.Repeat("\x7fELF junk", 10000)
+	cases := map[string]struct {
+		data string
+		want string
+		err  error
+	}{
+		"embedded":       {data: junk + appProtocolMarker + junk, want: AppProtocolVersion},
+		"after prefix":   {data: junk + appProtocolPrefix + "\x00" + junk + appProtocolPrefix + "1.2.3\x00", want: "1.2.3"},
+		"chunk boundary": {data: strings.Repeat("x", 64*1024-5) + appProtocolPrefix + "0.4.0\x00", want: "0.4.0"},
+		"not embedded":   {data: junk, err: ErrNoAppProtocol},
+		"truncated":      {data: junk + appProtocolPrefix + "0.0", err: ErrNoAppProtocol},
+	}
+	for name, c := range cases {
+		v, err := readAppProtocol(bytes.NewReader([]byte(c.data)))
+		assert.Equal(t, c.err, err, name)
+		assert.Equal(t, c.want, v, name)
+	}
+
+	path := filepath.Join(dir, "app.v1.0")
+	require.NoError(t, ioutil.WriteFile(path, []byte(junk+appProtocolMarker), 0600))
+	v, err := ReadAppProtocol(path)
+	require.NoError(t, err)
+	assert.Equal(t, AppProtocolVersion, v)
+}
+
+func TestCompatibleAppProtocol(t *testing.T) {
+	assert.NoError(t, CompatibleAppProtocol(AppProtocolVersion))
+	assert.NoError(t, CompatibleAppProtocol("0.0.9"))
+	assert.Error(t, CompatibleAppProtocol("0.1.0"))
+	assert.Error(t, CompatibleAppProtocol("1.0.0"))
+	assert.Error(t, CompatibleAppProtocol("0.0"))
+	assert.Error(t, CompatibleAppProtocol(""))
+}
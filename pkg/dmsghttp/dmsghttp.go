@@ -0,0 +1,61 @@
+// Package dmsghttp serves and requests HTTP over dmsg streams, so that the HTTP APIs of visors may be reached
+// even if the visors have no public IP address.
+package dmsghttp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// DialFunc dials a dmsg stream to the given remote and port.
+type DialFunc func(ctx context.Context, remote cipher.PubKey, port uint16) (net.Conn, error)
+
+// Serve serves HTTP requests received via the dmsg listener with the handler, until the listener is closed.
+func Serve(l net.Listener, h http.Handler) error {
+	return (&http.Server{Handler: h}).Serve(l)
+}
+
+// Transport returns an http.RoundTripper which sends requests over dmsg streams dialed with 'dial'.
+// The host of request URLs is the public key of the remote, and the port is the dmsg port (see URL).
+func Transport(dial DialFunc) http.RoundTripper {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+			pk, port, err := parseAddr(addr)
+			if err != nil {
+				return nil, err
+			}
+			return dial(ctx, pk, port)
+		},
+	}
+}
+
+// Client returns an http.Client which sends requests over dmsg streams dialed with 'dial'.
+func Client(dial DialFunc) *http.Client {
+	return &http.Client{Transport: Transport(dial)}
+}
+
+// URL returns the URL of the given path of the HTTP API served by the remote on the given dmsg port.
+func URL(remote cipher.PubKey, port uint16, path string) string {
+	return fmt.Sprintf("http://%s%s", net.JoinHostPort(remote.Hex(), strconv.Itoa(int(port))), path)
+}
+
+func parseAddr(addr string) (cipher.PubKey, uint16, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return cipher.PubKey{}, 0, err
+	}
+	var pk cipher.PubKey
+	if err := pk.Set(host); err != nil {
+		return cipher.PubKey{}, 0, fmt.Errorf("invalid public key '%s': %v", host, err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return cipher.PubKey{}, 0, fmt.Errorf("invalid dmsg port '%s'", portStr)
+	}
+	return pk, uint16(port), nil
+}
@@ -0,0 +1,53 @@
+package dmsghttp_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/dmsghttp"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/snettest"
+)
+
+func TestServe(t *testing.T) {
+	const port = 80
+
+	keys := snettest.GenKeyPairs(2)
+	nEnv := snettest.NewEnv(t, keys)
+	defer nEnv.Teardown()
+
+	l, err := nEnv.Nets[1].Listen(dmsg.Type, port)
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello")) //nolint:errcheck
+	})
+	errCh := make(chan error, 1)
+	go func() { errCh <- dmsghttp.Serve(l, mux) }()
+
+	c := dmsghttp.Client(func(ctx context.Context, remote cipher.PubKey, port uint16) (net.Conn, error) {
+		return nEnv.Nets[0].Dial(ctx, dmsg.Type, remote, port)
+	})
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get(dmsghttp.URL(keys[1].PK, port, "/hello"))
+		require.NoError(t, err, i)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "hello", string(body))
+	}
+
+	_, err = c.Get("http://invalid:80/hello")
+	assert.Error(t, err)
+
+	require.NoError(t, l.Close())
+	assert.Error(t, <-errCh)
+}
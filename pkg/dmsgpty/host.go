@@ -150,6 +150,11 @@ func NewHostFromDmsgClient(
 	}, nil
 }
 
+// Whitelist returns the whitelist of public keys allowed to request ptys.
+func (h *Host) Whitelist() ptycfg.Whitelist {
+	return h.ptyS.Auth()
+}
+
 // ServeRemoteRequests serves remote requests.
 func (h *Host) ServeRemoteRequests(ctx context.Context) {
 	go func() {
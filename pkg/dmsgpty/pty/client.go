@@ -75,6 +75,11 @@ func (c *Client) Start(name string, arg ...string) error {
 		c.log.WithError(err).Warn("failed to obtain terminal size")
 		size = nil
 	}
+	return c.StartWithSize(size, name, arg...)
+}
+
+// StartWithSize starts the pty with the given size, rather than the size of the local terminal.
+func (c *Client) StartWithSize(size *pty.Winsize, name string, arg ...string) error {
 	return c.call("Start", &CommandReq{Name: name, Arg: arg, Size: size}, empty)
 }
 
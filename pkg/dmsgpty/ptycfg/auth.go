@@ -56,7 +56,7 @@ func (w *jsonFileWhitelist) Add(pks ...cipher.PubKey) error {
 		for _, pk := range pks {
 			pkMap[pk] = true
 		}
-		return json.NewEncoder(f).Encode(pkMap)
+		return rewrite(f, pkMap)
 	}))
 }
 
@@ -65,7 +65,7 @@ func (w *jsonFileWhitelist) Remove(pks ...cipher.PubKey) error {
 		for _, pk := range pks {
 			delete(pkMap, pk)
 		}
-		return json.NewEncoder(f).Encode(pkMap)
+		return rewrite(f, pkMap)
 	}))
 }
 
@@ -98,6 +98,15 @@ func (w *jsonFileWhitelist) open(perm int, fn func(pkMap map[cipher.PubKey]bool,
 	return fn(pks, f)
 }
 
+// rewrite replaces the contents of the file, which is positioned at its start, with the map.
+func rewrite(f *os.File, pkMap map[cipher.PubKey]bool) error {
+	// Truncate, as the map may be shorter than the previous contents.
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	return json.NewEncoder(f).Encode(pkMap)
+}
+
 func jsonFileErr(err error) error {
 	if err != nil {
 		return fmt.Errorf("json file whitelist: %v", err)
@@ -0,0 +1,35 @@
+package ptycfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONFileWhitelist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dmsgpty_whitelist")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	w, err := NewJSONFileWhiteList(filepath.Join(dir, "whitelist.json"))
+	require.NoError(t, err)
+
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+	require.NoError(t, w.Add(pk1, pk2))
+	require.NoError(t, w.Remove(pk1))
+
+	all, err := w.All()
+	require.NoError(t, err)
+	assert.Equal(t, map[cipher.PubKey]bool{pk2: true}, all)
+
+	// The file holds only the remaining entries.
+	data, err := ioutil.ReadFile(filepath.Join(dir, "whitelist.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"`+pk2.Hex()+`":true}`+"\n", string(data))
+}
@@ -0,0 +1,339 @@
+package httputil
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // required by the WebSocket handshake.
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebSocket message types (opcodes of RFC 6455).
+const (
+	WebSocketText   = 0x1
+	WebSocketBinary = 0x2
+	webSocketClose  = 0x8
+	webSocketPing   = 0x9
+	webSocketPong   = 0xA
+)
+
+// WebSocket close codes.
+const (
+	WebSocketNormalClosure = 1000
+	WebSocketInternalError = 1011
+	WebSocketTryAgainLater = 1013
+)
+
+// MaxWebSocketMessage bounds the size of messages read from WebSockets.
+const MaxWebSocketMessage = 1 << 20
+
+// webSocketGUID is appended to the key of handshakes (RFC 6455, section 1.3).
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	// ErrNotWebSocket occurs when upgrading a request which is not a WebSocket handshake.
+	ErrNotWebSocket = errors.New("not a websocket handshake")
+
+	// ErrWebSocketClosed occurs when using a WebSocket which is closed.
+	ErrWebSocketClosed = errors.New("websocket is closed")
+
+	// ErrWebSocketOrigin occurs when upgrading a request of a browser from a page of another origin.
+	ErrWebSocketOrigin = errors.New("websocket origin does not match the host")
+)
+
+// WebSocket is a WebSocket connection. It is safe to write from multiple goroutines, but messages are read by a
+// single goroutine.
+type WebSocket struct {
+	conn   net.Conn
+	rw     *bufio.ReadWriter
+	client bool // clients mask the frames they write, servers the frames they read.
+
+	writeMx sync.Mutex
+	closed  bool
+}
+
+// CheckWebSocketOrigin checks that the 'Origin' header of the request, if any, matches its host. Browsers send
+// cookies with WebSocket handshakes of any page, so handshakes of other origins are rejected. Clients other than
+// browsers send no origin.
+func CheckWebSocketOrigin(r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || !strings.EqualFold(u.Host, r.Host) {
+		return ErrWebSocketOrigin
+	}
+	return nil
+}
+
+// UpgradeWebSocket completes the WebSocket handshake of the request. On failure, an error response is written.
+func UpgradeWebSocket(w http.ResponseWriter, r *http.Request) (*WebSocket, error) {
+	if err := CheckWebSocketOrigin(r); err != nil {
+		WriteJSON(w, r, http.StatusForbidden, err)
+		return nil, err
+	}
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" ||
+		r.Header.Get("Sec-WebSocket-Key") == "" {
+		WriteJSON(w, r, http.StatusBadRequest, ErrNotWebSocket)
+		return nil, ErrNotWebSocket
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		err := errors.New("connection does not support websockets")
+		WriteJSON(w, r, http.StatusInternalServerError, err)
+		return nil, err
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + webSocketGUID)) //nolint:gosec
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n"
+	if _, err := rw.WriteString(resp); err != nil {
+		_ = conn.Close() //nolint:errcheck
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		_ = conn.Close() //nolint:errcheck
+		return nil, err
+	}
+	// Deadlines set by the HTTP server no longer apply.
+	_ = conn.SetDeadline(time.Time{}) //nolint:errcheck
+	return &WebSocket{conn: conn, rw: rw}, nil
+}
+
+// DialWebSocket opens a WebSocket to the 'ws://' or 'http://' URL, such as to consume WebSocket endpoints in tools
+// and tests. The header is added to the handshake request.
+func DialWebSocket(url string, header http.Header) (*WebSocket, error) {
+	url = strings.Replace(url, "ws://", "http://", 1)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	addr := req.URL.Host
+	if req.URL.Port() == "" {
+		addr += ":80"
+	}
+	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(key))
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close() //nolint:errcheck
+		return nil, err
+	}
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	resp, err := http.ReadResponse(rw.Reader, req)
+	if err != nil {
+		_ = conn.Close() //nolint:errcheck
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		_ = conn.Close() //nolint:errcheck
+		return nil, fmt.Errorf("websocket handshake failed: %s", resp.Status)
+	}
+	return &WebSocket{conn: conn, rw: rw, client: true}, nil
+}
+
+func headerContains(h http.Header, key, token string) bool {
+	for _, v := range h[key] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteText writes a text message.
+func (ws *WebSocket) WriteText(p []byte) error {
+	return ws.WriteMessage(WebSocketText, p)
+}
+
+// WriteMessage writes a message of the given type (WebSocketText or WebSocketBinary).
+func (ws *WebSocket) WriteMessage(typ int, p []byte) error {
+	ws.writeMx.Lock()
+	defer ws.writeMx.Unlock()
+	if ws.closed {
+		return ErrWebSocketClosed
+	}
+	return ws.writeFrame(typ, p)
+}
+
+// writeFrame writes an unfragmented frame, masked if written by a client. The caller must hold writeMx.
+func (ws *WebSocket) writeFrame(opcode int, p []byte) error {
+	header := []byte{0x80 | byte(opcode), 0}
+	switch n := len(p); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header[1] = 127
+		header = append(header, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	if ws.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		header[1] |= 0x80
+		header = append(header, mask[:]...)
+		masked := make([]byte, len(p))
+		for i := range p {
+			masked[i] = p[i] ^ mask[i%4]
+		}
+		p = masked
+	}
+	if _, err := ws.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := ws.rw.Write(p); err != nil {
+		return err
+	}
+	return ws.rw.Flush()
+}
+
+// ReadMessage reads the next text or binary message. Pings are answered while reading, and io.EOF is returned
+// once the peer closes the WebSocket.
+func (ws *WebSocket) ReadMessage() (typ int, p []byte, err error) {
+	for {
+		fin, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch opcode {
+		case webSocketClose:
+			_ = ws.Close(WebSocketNormalClosure, "") //nolint:errcheck
+			return 0, nil, io.EOF
+		case webSocketPing:
+			ws.writeMx.Lock()
+			if !ws.closed {
+				err = ws.writeFrame(webSocketPong, payload)
+			}
+			ws.writeMx.Unlock()
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		case webSocketPong:
+			continue
+		case WebSocketText, WebSocketBinary:
+		default:
+			return 0, nil, fmt.Errorf("unexpected websocket opcode %d", opcode)
+		}
+
+		// Continuation frames follow until fin.
+		typ, p = opcode, payload
+		for !fin {
+			var cont []byte
+			if fin, opcode, cont, err = ws.readFrame(); err != nil {
+				return 0, nil, err
+			}
+			if opcode != 0 {
+				return 0, nil, fmt.Errorf("unexpected websocket opcode %d in fragmented message", opcode)
+			}
+			if p = append(p, cont...); len(p) > MaxWebSocketMessage {
+				return 0, nil, errors.New("websocket message is too large")
+			}
+		}
+		return typ, p, nil
+	}
+}
+
+func (ws *WebSocket) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.rw, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode = header[0]&0x80 != 0, int(header[0]&0x0F)
+	masked := header[1]&0x80 != 0
+	n := uint64(header[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(ws.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(ws.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > MaxWebSocketMessage {
+		return false, 0, nil, errors.New("websocket message is too large")
+	}
+	if masked == ws.client {
+		return false, 0, nil, errors.New("websocket frame is masked incorrectly")
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(ws.rw, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(ws.rw, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// Close sends a close message with the given code and reason, and closes the connection.
+func (ws *WebSocket) Close(code int, reason string) error {
+	ws.writeMx.Lock()
+	defer ws.writeMx.Unlock()
+	if ws.closed {
+		return nil
+	}
+	ws.closed = true
+	if len(reason) > 123 {
+		reason = reason[:123] // control frames are limited to 125 bytes.
+	}
+	p := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(p, uint16(code))
+	_ = ws.writeFrame(webSocketClose, append(p, reason...)) //nolint:errcheck
+	return ws.conn.Close()
+}
@@ -0,0 +1,85 @@
+package httputil
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocket(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := UpgradeWebSocket(w, r)
+		if err != nil {
+			return
+		}
+		// Echo messages until the client closes the websocket.
+		for {
+			typ, p, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := ws.WriteMessage(typ, p); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	ws, err := DialWebSocket(srv.URL, nil)
+	require.NoError(t, err)
+
+	large := bytes.Repeat([]byte("x"), 70000)
+	for _, msg := range [][]byte{[]byte("hello"), bytes.Repeat([]byte("y"), 300), large} {
+		require.NoError(t, ws.WriteText(msg))
+		typ, p, err := ws.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, WebSocketText, typ)
+		assert.Equal(t, msg, p)
+	}
+	require.NoError(t, ws.WriteMessage(WebSocketBinary, []byte{0, 1, 2}))
+	typ, p, err := ws.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, WebSocketBinary, typ)
+	assert.Equal(t, []byte{0, 1, 2}, p)
+
+	require.NoError(t, ws.Close(WebSocketNormalClosure, ""))
+	assert.Equal(t, ErrWebSocketClosed, ws.WriteText([]byte("closed")))
+
+	// Requests which are not handshakes are rejected.
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close() //nolint:errcheck
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Handshakes of browsers are only accepted from pages of the origin of the server.
+	_, err = DialWebSocket(srv.URL, http.Header{"Origin": {"http://attacker.example"}})
+	assert.EqualError(t, err, "websocket handshake failed: 403 Forbidden")
+	ws, err = DialWebSocket(srv.URL, http.Header{"Origin": {srv.URL}})
+	require.NoError(t, err)
+	require.NoError(t, ws.Close(WebSocketNormalClosure, ""))
+}
+
+func TestWebSocket_ReadClose(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := UpgradeWebSocket(w, r)
+		if err != nil {
+			return
+		}
+		_ = ws.WriteText([]byte("bye"))                 //nolint:errcheck
+		_ = ws.Close(WebSocketInternalError, "failure") //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	ws, err := DialWebSocket(srv.URL, nil)
+	require.NoError(t, err)
+	_, p, err := ws.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "bye", string(p))
+	_, _, err = ws.ReadMessage()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,445 @@
+package hypervisor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// AlertType is the type of an alert.
+type AlertType string
+
+// Types of alerts.
+const (
+	AlertVisorOffline AlertType = "visor_offline"
+	AlertVisorOnline  AlertType = "visor_online"
+	AlertAppCrashLoop AlertType = "app_crash_loop"
+	AlertTest         AlertType = "test"
+)
+
+// Defaults of AlertConfig.
+const (
+	DefaultAlertCheckInterval = 30 * time.Second
+	DefaultOfflineAfter       = 2 * time.Minute
+	DefaultCrashLoopCrashes   = 3
+	DefaultCrashLoopWindow    = 10 * time.Minute
+)
+
+// alertHistorySize is the number of alerts retained in the alert history.
+const alertHistorySize = 1000
+
+// notifyTimeout bounds the delivery of a notification.
+var notifyTimeout = 20 * time.Second
+
+// AlertConfig configures the alerts of visors which go offline, or whose apps crash repeatedly.
+type AlertConfig struct {
+	CheckInterval    visor.Duration `json:"check_interval,omitempty"`     // defaults to DefaultAlertCheckInterval.
+	OfflineAfter     visor.Duration `json:"offline_after,omitempty"`      // defaults to DefaultOfflineAfter.
+	CrashLoopCrashes int            `json:"crash_loop_crashes,omitempty"` // defaults to DefaultCrashLoopCrashes.
+	CrashLoopWindow  visor.Duration `json:"crash_loop_window,omitempty"`  // defaults to DefaultCrashLoopWindow.
+	Webhooks         []string       `json:"webhooks,omitempty"`           // URLs alerts are posted to, as JSON.
+	SMTP             *SMTPConfig    `json:"smtp,omitempty"`               // mails alerts, if set.
+}
+
+// SMTPConfig configures the mailing of alerts.
+type SMTPConfig struct {
+	Addr     string   `json:"address"` // host:port of the SMTP server.
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// FillDefaults fills the unset fields of the config with default values.
+func (c *AlertConfig) FillDefaults() {
+	if c.CheckInterval <= 0 {
+		c.CheckInterval = visor.Duration(DefaultAlertCheckInterval)
+	}
+	if c.OfflineAfter <= 0 {
+		c.OfflineAfter = visor.Duration(DefaultOfflineAfter)
+	}
+	if c.CrashLoopCrashes <= 0 {
+		c.CrashLoopCrashes = DefaultCrashLoopCrashes
+	}
+	if c.CrashLoopWindow <= 0 {
+		c.CrashLoopWindow = visor.Duration(DefaultCrashLoopWindow)
+	}
+}
+
+// Alert reports that a visor went offline or came back online, or that an app of a visor is crashing repeatedly.
+type Alert struct {
+	ID      uuid.UUID     `json:"id"`
+	Time    time.Time     `json:"time"`
+	Type    AlertType     `json:"type"`
+	PK      cipher.PubKey `json:"pk"`
+	Subject string        `json:"subject,omitempty"` // the app of app alerts.
+	Message string        `json:"message"`
+}
+
+func (a Alert) String() string {
+	return fmt.Sprintf("[%s] visor %s: %s", a.Type, a.PK, a.Message)
+}
+
+// Notifier delivers alerts.
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// WebhookNotifier posts alerts as JSON to a URL.
+type WebhookNotifier struct {
+	URL string
+}
+
+// Notify implements Notifier.
+func (n *WebhookNotifier) Notify(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s responded with status %d", n.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// SMTPNotifier mails alerts.
+type SMTPNotifier struct {
+	SMTPConfig
+}
+
+// Notify implements Notifier. The context is not honored by net/smtp.
+func (n *SMTPNotifier) Notify(_ context.Context, a Alert) error {
+	if len(n.To) == 0 {
+		return errors.New("no recipients of alert mails")
+	}
+	var auth smtp.Auth
+	if n.Username != "" {
+		host, _, err := net.SplitHostPort(n.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", n.Username, n.Password, host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: Skywire alert: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		n.From, strings.Join(n.To, ", "), a.Type, a.Time.Format(time.RFC1123Z), a)
+	return smtp.SendMail(n.Addr, auth, n.From, n.To, []byte(msg))
+}
+
+// VisorStatus is the state of a visor tracked by the alerts.
+type VisorStatus struct {
+	PK           cipher.PubKey `json:"pk"`
+	LastSeen     time.Time     `json:"last_seen"`
+	Online       bool          `json:"online"`
+	CrashLooping []string      `json:"crash_looping,omitempty"` // apps which are crashing repeatedly.
+}
+
+// alerter tracks the last time each visor was seen and the crashes of their apps, and fires alerts on changes.
+type alerter struct {
+	conf      AlertConfig
+	notifiers []Notifier
+	lastSeen  map[cipher.PubKey]time.Time
+	offline   map[cipher.PubKey]bool
+	looping   map[cipher.PubKey]map[string]bool
+	history   []Alert // oldest first.
+	mx        sync.Mutex
+}
+
+func newAlerter(conf *AlertConfig) *alerter {
+	a := &alerter{
+		lastSeen: make(map[cipher.PubKey]time.Time),
+		offline:  make(map[cipher.PubKey]bool),
+		looping:  make(map[cipher.PubKey]map[string]bool),
+	}
+	if conf != nil {
+		a.conf = *conf
+		for _, url := range conf.Webhooks {
+			a.notifiers = append(a.notifiers, &WebhookNotifier{URL: url})
+		}
+		if conf.SMTP != nil {
+			a.notifiers = append(a.notifiers, &SMTPNotifier{SMTPConfig: *conf.SMTP})
+		}
+	}
+	a.conf.FillDefaults()
+	return a
+}
+
+// track starts tracking the visor, which is reported offline if it is not seen in time.
+func (a *alerter) track(pk cipher.PubKey, now time.Time) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	if _, ok := a.lastSeen[pk]; !ok {
+		a.lastSeen[pk] = now
+	}
+}
+
+// tracked returns the tracked visors.
+func (a *alerter) tracked() []cipher.PubKey {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	pks := make([]cipher.PubKey, 0, len(a.lastSeen))
+	for pk := range a.lastSeen {
+		pks = append(pks, pk)
+	}
+	return pks
+}
+
+// update records the result of a check of the visor, with the app crashes within the crash loop window if the
+// visor is online, and returns the alerts to fire.
+func (a *alerter) update(pk cipher.PubKey, now time.Time, online bool, crashes []visor.Event) []Alert {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	var alerts []Alert
+	seen, tracked := a.lastSeen[pk]
+	if !tracked {
+		seen = now
+		a.lastSeen[pk] = now
+	}
+	if !online {
+		if down := now.Sub(seen); !a.offline[pk] && down >= time.Duration(a.conf.OfflineAfter) {
+			a.offline[pk] = true
+			alerts = append(alerts, newAlert(now, AlertVisorOffline, pk, "", "visor has not been seen for %s",
+				down.Round(time.Second)))
+		}
+		return a.record(alerts)
+	}
+
+	a.lastSeen[pk] = now
+	if a.offline[pk] {
+		delete(a.offline, pk)
+		alerts = append(alerts, newAlert(now, AlertVisorOnline, pk, "", "visor is back online after %s",
+			now.Sub(seen).Round(time.Second)))
+	}
+
+	counts := make(map[string]int)
+	for _, e := range crashes {
+		counts[e.Subject]++
+	}
+	looping := a.looping[pk]
+	if looping == nil {
+		looping = make(map[string]bool)
+		a.looping[pk] = looping
+	}
+	for app := range looping {
+		if counts[app] < a.conf.CrashLoopCrashes {
+			delete(looping, app)
+		}
+	}
+	apps := make([]string, 0, len(counts))
+	for app := range counts {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+	for _, app := range apps {
+		if n := counts[app]; n >= a.conf.CrashLoopCrashes && !looping[app] {
+			looping[app] = true
+			alerts = append(alerts, newAlert(now, AlertAppCrashLoop, pk, app, "app %s crashed %d times within %s",
+				app, n, time.Duration(a.conf.CrashLoopWindow)))
+		}
+	}
+	return a.record(alerts)
+}
+
+// record adds the alerts to the history, and returns them.
+func (a *alerter) record(alerts []Alert) []Alert {
+	a.history = append(a.history, alerts...)
+	if excess := len(a.history) - alertHistorySize; excess > 0 {
+		a.history = append([]Alert(nil), a.history[excess:]...)
+	}
+	return alerts
+}
+
+func newAlert(now time.Time, t AlertType, pk cipher.PubKey, subject, format string, args ...interface{}) Alert {
+	return Alert{ID: uuid.New(), Time: now, Type: t, PK: pk, Subject: subject, Message: fmt.Sprintf(format, args...)}
+}
+
+// notify delivers the alert with all notifiers, returning the errors of failed deliveries.
+func (a *alerter) notify(ctx context.Context, alert Alert) []error {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	var (
+		errs []error
+		mx   sync.Mutex
+		wg   sync.WaitGroup
+	)
+	for _, n := range a.notifiers {
+		wg.Add(1)
+		go func(n Notifier) {
+			defer wg.Done()
+			if err := n.Notify(ctx, alert); err != nil {
+				mx.Lock()
+				errs = append(errs, err)
+				mx.Unlock()
+			}
+		}(n)
+	}
+	wg.Wait()
+	return errs
+}
+
+// alerts returns the alerts of the history selected by the filters, oldest first.
+func (a *alerter) alerts(pk *cipher.PubKey, t AlertType, since time.Time) []Alert {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	alerts := make([]Alert, 0)
+	for _, alert := range a.history {
+		if (pk == nil || alert.PK == *pk) && (t == "" || alert.Type == t) && !alert.Time.Before(since) {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// statuses returns the states of the tracked visors.
+func (a *alerter) statuses() []VisorStatus {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	statuses := make([]VisorStatus, 0, len(a.lastSeen))
+	for pk, seen := range a.lastSeen {
+		s := VisorStatus{PK: pk, LastSeen: seen, Online: !a.offline[pk]}
+		for app := range a.looping[pk] {
+			s.CrashLooping = append(s.CrashLooping, app)
+		}
+		sort.Strings(s.CrashLooping)
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].PK.Hex() < statuses[j].PK.Hex() })
+	return statuses
+}
+
+// RunAlerts checks the connected visors, and the visors of the dmsg config, at the check interval of the alert
+// config until ctx is done, firing alerts when they go offline or their apps crash repeatedly. Alerts are only
+// recorded in the alert history if no notifiers are configured.
+func (m *Node) RunAlerts(ctx context.Context) {
+	if m.c.Dmsg != nil {
+		for _, pk := range m.c.Dmsg.Visors {
+			m.alerts.track(pk, time.Now())
+		}
+	}
+	ticker := time.NewTicker(time.Duration(m.alerts.conf.CheckInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkAlerts(ctx, time.Now())
+		}
+	}
+}
+
+// checkAlerts checks the connected and tracked visors, and delivers the alerts to fire.
+func (m *Node) checkAlerts(ctx context.Context, now time.Time) {
+	pks := m.selectNodes(&BulkRequest{All: true})
+	for _, pk := range m.alerts.tracked() {
+		if _, _, ok := m.client(pk); !ok {
+			pks = append(pks, pk)
+		}
+	}
+	window := visor.EventFilter{
+		From:  now.Add(-time.Duration(m.alerts.conf.CrashLoopWindow)),
+		Types: []visor.EventType{visor.EventAppCrashed},
+	}
+	results := m.applyBulk(pks, func(rpc visor.RPCClient) (interface{}, error) {
+		if _, err := rpc.Uptime(); err != nil {
+			return nil, err
+		}
+		// Visors which do not record events are only checked for being online.
+		crashes, err := rpc.Events(window)
+		if err != nil {
+			return []visor.Event(nil), nil
+		}
+		return crashes, nil
+	})
+
+	for _, res := range results {
+		crashes, _ := res.Result.([]visor.Event) //nolint:errcheck
+		for _, alert := range m.alerts.update(res.PK, now, res.OK, crashes) {
+			log.Warn(alert)
+			go func(alert Alert) {
+				for _, err := range m.alerts.notify(ctx, alert) {
+					log.WithError(err).Warnf("Failed to deliver alert %s", alert.ID)
+				}
+			}(alert)
+		}
+	}
+}
+
+// provides the alert history, filtered by the 'pk', 'type' and 'since' queries.
+func (m *Node) getAlerts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var pk *cipher.PubKey
+		if q := r.URL.Query().Get("pk"); q != "" {
+			pk = new(cipher.PubKey)
+			if err := pk.Set(q); err != nil {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid 'pk': %v", err))
+				return
+			}
+		}
+		var since time.Time
+		if q := r.URL.Query().Get("since"); q != "" {
+			var err error
+			if since, err = time.Parse(time.RFC3339Nano, q); err != nil {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid 'since': %v", err))
+				return
+			}
+		}
+		t := AlertType(r.URL.Query().Get("type"))
+		httputil.WriteJSON(w, r, http.StatusOK, m.alerts.alerts(pk, t, since))
+	}
+}
+
+// provides the last time each tracked visor was seen, and whether it is online.
+func (m *Node) getVisorStatuses() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		httputil.WriteJSON(w, r, http.StatusOK, m.alerts.statuses())
+	}
+}
+
+// TestAlertResp reports the delivery of a test alert.
+type TestAlertResp struct {
+	Notifiers int      `json:"notifiers"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// delivers a test alert with the configured notifiers, reporting delivery errors.
+func (m *Node) postTestAlert() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		alert := newAlert(time.Now(), AlertTest, m.c.PK, "", "test alert of the hypervisor")
+		errs := m.alerts.notify(r.Context(), alert)
+		resp := TestAlertResp{Notifiers: len(m.alerts.notifiers)}
+		for _, err := range errs {
+			resp.Errors = append(resp.Errors, err.Error())
+		}
+		status := http.StatusOK
+		if len(errs) > 0 {
+			status = http.StatusBadGateway
+		}
+		httputil.WriteJSON(w, r, status, resp)
+	}
+}
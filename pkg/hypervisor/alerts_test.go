@@ -0,0 +1,110 @@
+package hypervisor
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func TestAlerter_update(t *testing.T) {
+	a := newAlerter(&AlertConfig{
+		OfflineAfter:     visor.Duration(time.Minute),
+		CrashLoopCrashes: 2,
+	})
+	pk, _ := cipher.GenerateKeyPair()
+	start := time.Now()
+	crash := visor.Event{Type: visor.EventAppCrashed, Subject: "skychat"}
+
+	assert.Empty(t, a.update(pk, start, true, nil))
+
+	// Visors are reported offline once, after not being seen for OfflineAfter.
+	assert.Empty(t, a.update(pk, start.Add(30*time.Second), false, nil))
+	alerts := a.update(pk, start.Add(time.Minute), false, nil)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertVisorOffline, alerts[0].Type)
+	assert.Empty(t, a.update(pk, start.Add(2*time.Minute), false, nil))
+
+	alerts = a.update(pk, start.Add(3*time.Minute), true, []visor.Event{crash, crash})
+	require.Len(t, alerts, 2)
+	assert.Equal(t, AlertVisorOnline, alerts[0].Type)
+	assert.Equal(t, AlertAppCrashLoop, alerts[1].Type)
+	assert.Equal(t, "skychat", alerts[1].Subject)
+
+	// Crash loops are reported again only once the app stopped crashing.
+	assert.Empty(t, a.update(pk, start.Add(4*time.Minute), true, []visor.Event{crash, crash, crash}))
+	assert.Equal(t, []string{"skychat"}, a.statuses()[0].CrashLooping)
+	assert.Empty(t, a.update(pk, start.Add(5*time.Minute), true, []visor.Event{crash}))
+	assert.Len(t, a.update(pk, start.Add(6*time.Minute), true, []visor.Event{crash, crash}), 1)
+
+	assert.Len(t, a.alerts(nil, "", time.Time{}), 4)
+	assert.Len(t, a.alerts(&pk, AlertAppCrashLoop, time.Time{}), 2)
+	assert.Len(t, a.alerts(nil, "", start.Add(5*time.Minute)), 1)
+}
+
+func TestNode_checkAlerts(t *testing.T) {
+	received := make(chan Alert, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- alert
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "hypervisor_alerts")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	config.Alerts = &AlertConfig{OfflineAfter: visor.Duration(time.Minute), Webhooks: []string{srv.URL}}
+	m, err := NewNode(config)
+	require.NoError(t, err)
+	require.NoError(t, m.AddMockData(MockConfig{Nodes: 2}))
+
+	// The visor is tracked, but not connected.
+	offlinePK, _ := cipher.GenerateKeyPair()
+	now := time.Now()
+	m.alerts.track(offlinePK, now)
+
+	m.checkAlerts(context.Background(), now)
+	m.checkAlerts(context.Background(), now.Add(time.Minute))
+
+	select {
+	case alert := <-received:
+		assert.Equal(t, AlertVisorOffline, alert.Type)
+		assert.Equal(t, offlinePK, alert.PK)
+	case <-time.After(5 * time.Second):
+		t.Fatal("alert was not delivered")
+	}
+
+	rec := httptest.NewRecorder()
+	m.getVisorStatuses()(rec, httptest.NewRequest(http.MethodGet, "/api/alerts/visors", nil))
+	var statuses []VisorStatus
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&statuses))
+	require.Len(t, statuses, 3)
+	for _, s := range statuses {
+		assert.Equal(t, s.PK != offlinePK, s.Online)
+	}
+
+	rec = httptest.NewRecorder()
+	m.getAlerts()(rec, httptest.NewRequest(http.MethodGet, "/api/alerts?type=visor_offline", nil))
+	var alerts []Alert
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&alerts))
+	require.Len(t, alerts, 1)
+	assert.Equal(t, offlinePK, alerts[0].PK)
+}
@@ -0,0 +1,92 @@
+package hypervisor
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// provides the log files of an app, within the directory in which it runs on the visor.
+func (m *Node) getAppLogFiles() http.HandlerFunc {
+	return m.withCtx(m.appCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		files, err := ctx.RPC.AppLogFiles(ctx.App.Name)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, files)
+	})
+}
+
+// downloads the log file of an app given by the 'file' query as an attachment, or else the logs of the app within
+// the RFC3339 times of the 'from' and 'to' queries. The log is read from the visor in chunks, as it is written.
+func (m *Node) downloadAppLog() http.HandlerFunc {
+	return m.withCtx(m.appCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		in := visor.AppLogIn{App: ctx.App.Name, File: r.URL.Query().Get("file")}
+		for q, t := range map[string]*time.Time{"from": &in.From, "to": &in.To} {
+			v := r.URL.Query().Get(q)
+			if v == "" {
+				continue
+			}
+			if in.File != "" {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("'%s' query is invalid with 'file'", q))
+				return
+			}
+			var err error
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid '%s' query: %v", q, err))
+				return
+			}
+		}
+
+		out, err := ctx.RPC.AppLog(in)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if err.Error() == visor.ErrAppLogNotFound.Error() {
+				status = http.StatusNotFound
+			}
+			httputil.WriteJSON(w, r, status, err)
+			return
+		}
+
+		name := ctx.App.Name + ".log"
+		if in.File != "" {
+			name = ctx.App.Name + "-" + path.Base(in.File)
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+		// Files may grow while they are read, so only the size of the file when the download started is sent.
+		size := out.Size
+		if size >= 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		}
+		w.WriteHeader(http.StatusOK)
+
+		var sent int64
+		for {
+			data := out.Data
+			if size >= 0 && sent+int64(len(data)) > size {
+				data = data[:size-sent]
+			}
+			if _, err := w.Write(data); err != nil {
+				log.WithError(err).Warn("Failed to write app log")
+				return
+			}
+			sent += int64(len(data))
+			if out.Next < 0 || size >= 0 && sent >= size {
+				return
+			}
+			in.Offset = out.Next
+			if out, err = ctx.RPC.AppLog(in); err != nil {
+				log.WithError(err).Warnf("Failed to read app log of %s", ctx.PK)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package hypervisor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func TestNode_appLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_applogs")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	m, err := NewNode(config)
+	require.NoError(t, err)
+	require.NoError(t, m.AddMockData(MockConfig{Nodes: 1, Seed: 1}))
+	pk := m.selectNodes(&BulkRequest{All: true})[0]
+	apps, err := m.nodes[pk].Client.Apps()
+	require.NoError(t, err)
+	require.NotEmpty(t, apps)
+	uri := "/api/nodes/" + pk.Hex() + "/apps/" + apps[0].Name + "/logs/"
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, uri+path, nil))
+		return w
+	}
+
+	w := get("files")
+	require.Equal(t, http.StatusOK, w.Code)
+	var files []visor.AppLogFile
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &files))
+	require.Len(t, files, 1)
+
+	// Files are downloaded whole, as attachments.
+	w = get("download?file=" + files[0].Name)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strconv.FormatInt(files[0].Size, 10), w.Header().Get("Content-Length"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Len(t, w.Body.Bytes(), int(files[0].Size))
+	all := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	require.Len(t, all, 10)
+
+	// Time ranges are downloaded from the log store.
+	from, err := time.Parse(time.RFC3339Nano, strings.Trim(strings.Fields(all[2])[0], "[]"))
+	require.NoError(t, err)
+	w = get("download?from=" + from.Add(-time.Second).Format(time.RFC3339) + "&to=" +
+		from.Add(2*time.Minute-time.Second).Format(time.RFC3339))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	assert.Equal(t, all[2]+"\n"+all[3]+"\n", w.Body.String())
+
+	for path, code := range map[string]int{
+		"download?file=missing.log": http.StatusNotFound,
+		"download?from=yesterday":   http.StatusBadRequest,
+		"download?file=" + files[0].Name + "&to=" + from.Format(time.RFC3339): http.StatusBadRequest,
+	} {
+		assert.Equal(t, code, get(path).Code, path)
+	}
+}
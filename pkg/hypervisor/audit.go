@@ -0,0 +1,233 @@
+package hypervisor
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"go.etcd.io/bbolt"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+)
+
+const boltAuditBucketName = "audit_log"
+
+// Bounds of the number of audit entries provided per request.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+const auditKey = ctxKey("audit")
+
+// AuditEntry records a mutating call of the hypervisor API.
+type AuditEntry struct {
+	ID         uint64         `json:"id"`
+	Time       time.Time      `json:"time"`
+	User       string         `json:"user,omitempty"`  // empty if not authenticated, or authentication is disabled.
+	Token      string         `json:"token,omitempty"` // name of the API token the call was authorized with, if any.
+	RemoteAddr string         `json:"remote_addr"`
+	Method     string         `json:"method"`
+	Route      string         `json:"route"` // such as "/api/nodes/{pk}/apps/{app}".
+	Path       string         `json:"path"`
+	Visor      *cipher.PubKey `json:"visor,omitempty"`
+	Status     int            `json:"status"`
+	Success    bool           `json:"success"`
+}
+
+// AuditFilter selects audit entries. Zero fields select all entries.
+type AuditFilter struct {
+	User    string
+	Visor   *cipher.PubKey
+	Since   time.Time
+	Until   time.Time
+	Success *bool
+	Limit   int // of entries, newest first.
+}
+
+func (f *AuditFilter) match(e *AuditEntry) bool {
+	switch {
+	case f.User != "" && e.User != f.User:
+		return false
+	case f.Visor != nil && (e.Visor == nil || *e.Visor != *f.Visor):
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && e.Time.After(f.Until):
+		return false
+	case f.Success != nil && e.Success != *f.Success:
+		return false
+	default:
+		return true
+	}
+}
+
+// AuditLog is an append-only log of the mutating calls of the hypervisor API.
+type AuditLog interface {
+	Append(entry *AuditEntry) error
+	Entries(filter AuditFilter) ([]AuditEntry, error)
+}
+
+// BoltAuditLog implements AuditLog, storing entries in a bbolt database.
+type BoltAuditLog struct {
+	*bbolt.DB
+}
+
+// NewBoltAuditLog creates a new BoltAuditLog in the database, such as the database of a BoltUserStore.
+func NewBoltAuditLog(db *bbolt.DB) (*BoltAuditLog, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(boltAuditBucketName))
+		return err
+	})
+	return &BoltAuditLog{DB: db}, err
+}
+
+// Append appends the entry, setting its ID.
+func (l *BoltAuditLog) Append(entry *AuditEntry) error {
+	return l.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(boltAuditBucketName))
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		entry.ID = id
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, id)
+		return b.Put(key, data)
+	})
+}
+
+// Entries obtains the entries selected by the filter, newest first.
+func (l *BoltAuditLog) Entries(filter AuditFilter) ([]AuditEntry, error) {
+	entries := make([]AuditEntry, 0)
+	err := l.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte(boltAuditBucketName)).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e AuditEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			if !filter.Since.IsZero() && e.Time.Before(filter.Since) {
+				return nil
+			}
+			if !filter.match(&e) {
+				continue
+			}
+			if entries = append(entries, e); filter.Limit > 0 && len(entries) >= filter.Limit {
+				return nil
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// auditActor identifies who makes an audited call, once authorized.
+type auditActor struct {
+	user  string
+	token string
+}
+
+// setAuditActor records the user, and the name of the API token if any, who makes the audited call of r.
+func setAuditActor(r *http.Request, user, token string) {
+	if actor, ok := r.Context().Value(auditKey).(*auditActor); ok {
+		actor.user, actor.token = user, token
+	}
+}
+
+// audit is an http middleware which records mutating calls in the audit log, once handled.
+func (m *Node) audit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		actor := new(auditActor)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), auditKey, actor)))
+
+		entry := AuditEntry{
+			Time:       time.Now(),
+			User:       actor.user,
+			Token:      actor.token,
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     ww.Status(),
+		}
+		if entry.Status == 0 {
+			entry.Status = http.StatusOK
+		}
+		entry.Success = entry.Status < 400
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			entry.Route = rctx.RoutePattern()
+			var pk cipher.PubKey
+			if err := pk.Set(rctx.URLParam("pk")); err == nil {
+				entry.Visor = &pk
+			}
+		}
+		if err := m.auditLog.Append(&entry); err != nil {
+			log.WithError(err).Errorf("Failed to audit %s %s", entry.Method, entry.Path)
+		}
+	})
+}
+
+// provides the audit log, newest first, filtered by the 'user', 'pk', 'since', 'until' and 'success' queries, and
+// limited by the 'limit' query.
+func (m *Node) getAudit() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		filter := AuditFilter{User: q.Get("user"), Limit: defaultAuditLimit}
+		if v := q.Get("pk"); v != "" {
+			filter.Visor = new(cipher.PubKey)
+			if err := filter.Visor.Set(v); err != nil {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid 'pk': %v", err))
+				return
+			}
+		}
+		for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+			if v := q.Get(name); v != "" {
+				var err error
+				if *t, err = time.Parse(time.RFC3339Nano, v); err != nil {
+					httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid '%s': %v", name, err))
+					return
+				}
+			}
+		}
+		if v := q.Get("success"); v != "" {
+			success, err := strconv.ParseBool(v)
+			if err != nil {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid 'success': %v", err))
+				return
+			}
+			filter.Success = &success
+		}
+		if v := q.Get("limit"); v != "" {
+			limit, err := strconv.Atoi(v)
+			if err != nil || limit <= 0 || limit > maxAuditLimit {
+				httputil.WriteJSON(w, r, http.StatusBadRequest,
+					fmt.Errorf("invalid 'limit': expected 1 to %d", maxAuditLimit))
+				return
+			}
+			filter.Limit = limit
+		}
+		entries, err := m.auditLog.Entries(filter)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, entries)
+	}
+}
@@ -0,0 +1,96 @@
+package hypervisor
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_audit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_audit")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	m, err := NewNode(config)
+	require.NoError(t, err)
+	require.NoError(t, m.AddMockData(MockConfig{Nodes: 1, EnableAuth: true}))
+	pk := m.selectNodes(&BulkRequest{All: true})[0]
+
+	srv := httptest.NewTLSServer(m)
+	defer srv.Close()
+	m.c.Cookies.Domain = srv.Listener.Addr().String()
+	client := srv.Client()
+	client.Jar, err = cookiejar.New(&cookiejar.Options{})
+	require.NoError(t, err)
+
+	do := func(method, uri string, body io.Reader) *http.Response {
+		req, err := http.NewRequest(method, srv.URL+uri, body)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+	call := func(method, uri, body string) int {
+		resp := do(method, uri, strings.NewReader(body))
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode
+	}
+	entries := func(query string) []AuditEntry {
+		resp := do(http.MethodGet, "/api/audit"+query, nil)
+		defer func() { require.NoError(t, resp.Body.Close()) }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var entries []AuditEntry
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+		return entries
+	}
+
+	account := `{"username":"admin","password":"Secure1234"}`
+	require.Equal(t, http.StatusUnauthorized, call(http.MethodPost, "/api/login", account))
+	require.Equal(t, http.StatusOK, call(http.MethodPost, "/api/create-account", account))
+	require.Equal(t, http.StatusOK, call(http.MethodPost, "/api/login", account))
+	require.Equal(t, http.StatusOK, call(http.MethodPut, "/api/nodes/"+pk.Hex()+"/tags", `["eu"]`))
+	require.Equal(t, http.StatusOK, call(http.MethodGet, "/api/nodes", ""))
+
+	// Reads are not audited, and entries are provided newest first.
+	all := entries("")
+	require.Len(t, all, 4)
+	e := all[0]
+	assert.Equal(t, "admin", e.User)
+	assert.Equal(t, http.MethodPut, e.Method)
+	assert.Equal(t, "/api/nodes/{pk}/tags", e.Route)
+	assert.Equal(t, &pk, e.Visor)
+	assert.Equal(t, http.StatusOK, e.Status)
+	assert.True(t, e.Success)
+	assert.Equal(t, "admin", all[1].User)
+	assert.Equal(t, "/api/login", all[1].Route)
+
+	failed := entries("?success=false")
+	require.Len(t, failed, 1)
+	assert.Equal(t, "", failed[0].User)
+	assert.Equal(t, http.StatusUnauthorized, failed[0].Status)
+
+	assert.Len(t, entries("?pk="+pk.Hex()), 1)
+	assert.Len(t, entries("?user=admin&limit=1"), 1)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodGet, "/api/audit?limit=0", ""))
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodGet, "/api/audit?since=yesterday", ""))
+
+	// Users without the admin role may not read the audit log.
+	viewer := `{"username":"viewer","password":"Secure1234","role":"read-only"}`
+	require.Equal(t, http.StatusOK, call(http.MethodPost, "/api/users", viewer))
+	client.Jar, err = cookiejar.New(&cookiejar.Options{})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, call(http.MethodPost, "/api/login", `{"username":"viewer","password":"Secure1234"}`))
+	assert.Equal(t, http.StatusForbidden, call(http.MethodGet, "/api/audit", ""))
+}
@@ -0,0 +1,255 @@
+package hypervisor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+var (
+	// bulkConcurrency is the number of nodes a bulk action is applied to concurrently.
+	bulkConcurrency = 16
+
+	// bulkTimeout bounds the duration of a bulk action on each node, within the timeout of requests.
+	bulkTimeout = 25 * time.Second
+)
+
+// Errors associated with bulk actions.
+var (
+	ErrNoBulkSelector = errors.New("select nodes with 'pks' or 'tags', or all nodes with 'all'")
+	ErrNotConnected   = errors.New("node is not connected")
+	ErrBulkTimeout    = errors.New("timed out")
+)
+
+// BulkRequest applies an action to the selected nodes. Nodes are selected by public key and/or tag: nodes must
+// be in PKs, if any, and have all of Tags, if any.
+type BulkRequest struct {
+	Action string          `json:"action"`
+	Params json.RawMessage `json:"params,omitempty"`
+	PKs    []cipher.PubKey `json:"pks,omitempty"`
+	Tags   []string        `json:"tags,omitempty"`
+	All    bool            `json:"all,omitempty"` // selects all nodes, if there are no PKs or Tags.
+}
+
+// BulkResult is the result of a bulk action on a node.
+type BulkResult struct {
+	PK     cipher.PubKey `json:"pk"`
+	OK     bool          `json:"ok"`
+	Error  string        `json:"error,omitempty"`
+	Result interface{}   `json:"result,omitempty"`
+}
+
+// BulkResponse reports the results of a bulk action, per node.
+type BulkResponse struct {
+	Action    string       `json:"action"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// bulkFunc applies an action to a node.
+type bulkFunc func(rpc visor.RPCClient) (interface{}, error)
+
+// bulkActions parse the params of bulk actions, by action.
+var bulkActions = map[string]func(params json.RawMessage) (bulkFunc, error){
+	"start_app": func(params json.RawMessage) (bulkFunc, error) {
+		app, err := bulkAppParam(params)
+		return func(rpc visor.RPCClient) (interface{}, error) {
+			return nil, rpc.StartApp(app)
+		}, err
+	},
+	"stop_app": func(params json.RawMessage) (bulkFunc, error) {
+		app, err := bulkAppParam(params)
+		return func(rpc visor.RPCClient) (interface{}, error) {
+			return nil, rpc.StopApp(app)
+		}, err
+	},
+	"restart_app": func(params json.RawMessage) (bulkFunc, error) {
+		app, err := bulkAppParam(params)
+		return func(rpc visor.RPCClient) (interface{}, error) {
+			// Apps which are not running are started.
+			if err := rpc.StopApp(app); err != nil && err.Error() != visor.ErrAppNotRunning.Error() {
+				return nil, err
+			}
+			return nil, rpc.StartApp(app)
+		}, err
+	},
+	"add_transport": func(params json.RawMessage) (bulkFunc, error) {
+		var p struct {
+			Remote cipher.PubKey `json:"remote_pk"`
+			TpType string        `json:"transport_type"`
+			Public bool          `json:"public"`
+			Labels []string      `json:"labels"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Remote.Null() || p.TpType == "" {
+			return nil, errors.New("'remote_pk' and 'transport_type' params are required")
+		}
+		return func(rpc visor.RPCClient) (interface{}, error) {
+			return rpc.AddTransport(p.Remote, p.TpType, p.Public, 20*time.Second, p.Labels)
+		}, nil
+	},
+	"set_log_level": func(params json.RawMessage) (bulkFunc, error) {
+		var p visor.SetLogLevelIn
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return func(rpc visor.RPCClient) (interface{}, error) {
+			return nil, rpc.SetLogLevel(p.Module, p.Level)
+		}, nil
+	},
+	"reload": func(json.RawMessage) (bulkFunc, error) {
+		return func(rpc visor.RPCClient) (interface{}, error) {
+			return rpc.Reload()
+		}, nil
+	},
+	"update": func(params json.RawMessage) (bulkFunc, error) {
+		var in visor.UpdateIn
+		if err := json.Unmarshal(params, &in); err != nil {
+			return nil, err
+		}
+		return func(rpc visor.RPCClient) (interface{}, error) {
+			return rpc.Update(in)
+		}, nil
+	},
+}
+
+func bulkAppParam(params json.RawMessage) (string, error) {
+	var p struct {
+		App string `json:"app"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return "", err
+	}
+	if p.App == "" {
+		return "", errors.New("'app' param is required")
+	}
+	return p.App, nil
+}
+
+// BulkActions returns the names of the actions which can be applied in bulk.
+func BulkActions() []string {
+	actions := make([]string, 0, len(bulkActions))
+	for action := range bulkActions {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// selectNodes returns the public keys of the nodes selected by the request, which may include nodes which are not
+// connected if selected by public key.
+func (m *Node) selectNodes(req *BulkRequest) []cipher.PubKey {
+	pks := req.PKs
+	if len(pks) == 0 {
+		m.mu.RLock()
+		for pk := range m.nodes {
+			pks = append(pks, pk)
+		}
+		m.mu.RUnlock()
+	}
+	allTags := m.tags.AllTags()
+	selected := make([]cipher.PubKey, 0, len(pks))
+	seen := make(map[cipher.PubKey]bool)
+	for _, pk := range pks {
+		if !seen[pk] && hasTags(allTags[pk], req.Tags) {
+			selected = append(selected, pk)
+		}
+		seen[pk] = true
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Hex() < selected[j].Hex() })
+	return selected
+}
+
+// applyBulk applies f to the nodes concurrently, reporting the result of each node.
+func (m *Node) applyBulk(pks []cipher.PubKey, f bulkFunc) []BulkResult {
+	results := make([]BulkResult, len(pks))
+	sem := make(chan struct{}, bulkConcurrency)
+	var wg sync.WaitGroup
+	for i, pk := range pks {
+		results[i].PK = pk
+		_, client, ok := m.client(pk)
+		if !ok {
+			results[i].Error = ErrNotConnected.Error()
+			continue
+		}
+		wg.Add(1)
+		go func(res *BulkResult, client visor.RPCClient) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			type result struct {
+				v   interface{}
+				err error
+			}
+			resCh := make(chan result, 1)
+			go func() {
+				v, err := f(client)
+				resCh <- result{v, err}
+			}()
+			select {
+			case r := <-resCh:
+				if r.err != nil {
+					res.Error = r.err.Error()
+				} else {
+					res.OK, res.Result = true, r.v
+				}
+			case <-time.After(bulkTimeout):
+				res.Error = ErrBulkTimeout.Error()
+			}
+		}(&results[i], client)
+	}
+	wg.Wait()
+	return results
+}
+
+// applies an action to the nodes selected by a BulkRequest.
+func (m *Node) postBulk() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req BulkRequest
+		if err := httputil.ReadJSON(r, &req); err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadBody)
+			return
+		}
+		parse, ok := bulkActions[req.Action]
+		if !ok {
+			httputil.WriteJSON(w, r, http.StatusBadRequest,
+				fmt.Errorf("unknown action '%s', expected one of %v", req.Action, BulkActions()))
+			return
+		}
+		if len(req.PKs) == 0 && len(req.Tags) == 0 && !req.All {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrNoBulkSelector)
+			return
+		}
+		if len(req.Params) == 0 {
+			req.Params = json.RawMessage("{}")
+		}
+		f, err := parse(req.Params)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid params of '%s': %v", req.Action, err))
+			return
+		}
+
+		resp := BulkResponse{Action: req.Action, Results: m.applyBulk(m.selectNodes(&req), f)}
+		for _, res := range resp.Results {
+			if res.OK {
+				resp.Succeeded++
+			} else {
+				resp.Failed++
+			}
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, resp)
+	}
+}
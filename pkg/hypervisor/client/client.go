@@ -0,0 +1,503 @@
+// Package client implements a client of the hypervisor API, as documented by the OpenAPI document of the hypervisor
+// at '/api/openapi.json'.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/sdclient"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/hypervisor"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// totalCountHeader holds the number of items of a list which match the filters, before pagination.
+const totalCountHeader = "X-Total-Count"
+
+// Error is an error response of the hypervisor.
+type Error struct {
+	Status  int    `json:"-"`
+	Message string `json:"error"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("hypervisor: %s (%d)", e.Message, e.Status)
+}
+
+// ListOptions paginates and sorts a list. Lists are sorted by Sort, or in descending order if it is prefixed by '-'.
+type ListOptions struct {
+	Offset int
+	Limit  int // all items if zero.
+	Sort   string
+}
+
+// values returns a copy of the query with the options.
+func (o ListOptions) values(query url.Values) url.Values {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Sort != "" {
+		q.Set("sort", o.Sort)
+	}
+	return q
+}
+
+// Client is a client of the hypervisor API.
+type Client struct {
+	addr  string
+	token string
+	httpC *http.Client
+}
+
+// NewHTTP creates a client of the hypervisor at addr, such as 'https://localhost:8000'. Requests are authorized with
+// the API token if set, or with the session of Login otherwise. If httpC is nil, a client with a cookie jar is used.
+func NewHTTP(addr, token string, httpC *http.Client) *Client {
+	if httpC == nil {
+		jar, _ := cookiejar.New(nil) //nolint:errcheck // never fails.
+		httpC = &http.Client{Jar: jar}
+	}
+	return &Client{addr: strings.TrimSuffix(addr, "/"), token: token, httpC: httpC}
+}
+
+// do sends the request of the method and path, with the query and the JSON of body if not nil, and decodes the
+// response to out if not nil, or copies it to out if it is an io.Writer. Error responses are returned as *Error.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values,
+	body, out interface{}) (http.Header, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+	uri := c.addr + path
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, uri, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.httpC.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &Error{Status: resp.StatusCode}
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil || json.Unmarshal(b, apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(b))
+		}
+		return resp.Header, apiErr
+	}
+	if w, ok := out.(io.Writer); ok {
+		_, err := io.Copy(w, resp.Body)
+		return resp.Header, err
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.Header, fmt.Errorf("hypervisor: invalid response: %v", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// list gets a paginated list, returning the total count of items which match the filters of the query.
+func (c *Client) list(ctx context.Context, path string, query url.Values, opts ListOptions,
+	out interface{}) (int, error) {
+	h, err := c.do(ctx, http.MethodGet, path, opts.values(query), nil, out)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(h.Get(totalCountHeader))
+}
+
+func nodePath(pk cipher.PubKey, elems ...string) string {
+	path := "/api/nodes/" + pk.Hex()
+	for _, e := range elems {
+		path += "/" + url.PathEscape(e)
+	}
+	return path
+}
+
+// CreateAccount creates the initial admin account of the hypervisor.
+func (c *Client) CreateAccount(ctx context.Context, username, password string) error {
+	_, err := c.do(ctx, http.MethodPost, "/api/create-account", nil,
+		hypervisor.AccountRequest{Username: username, Password: password}, nil)
+	return err
+}
+
+// Login logs in, so that the session authorizes the requests of the client. The code is required if the user has
+// two-factor authentication enabled.
+func (c *Client) Login(ctx context.Context, username, password, code string) error {
+	_, err := c.do(ctx, http.MethodPost, "/api/login", nil,
+		hypervisor.LoginRequest{Username: username, Password: password, Code: code}, nil)
+	return err
+}
+
+// Logout logs out of the session.
+func (c *Client) Logout(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodPost, "/api/logout", nil, nil, nil)
+	return err
+}
+
+// User returns the info of the user.
+func (c *Client) User(ctx context.Context) (*hypervisor.UserInfoResp, error) {
+	var out hypervisor.UserInfoResp
+	_, err := c.do(ctx, http.MethodGet, "/api/user", nil, nil, &out)
+	return &out, err
+}
+
+// CreateToken creates an API token of the user.
+func (c *Client) CreateToken(ctx context.Context, req hypervisor.TokenRequest) (*hypervisor.NewTokenResp, error) {
+	var out hypervisor.NewTokenResp
+	_, err := c.do(ctx, http.MethodPost, "/api/tokens", nil, req, &out)
+	return &out, err
+}
+
+// RevokeToken revokes an API token of the user.
+func (c *Client) RevokeToken(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/api/tokens/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
+
+// Nodes returns the summaries of the visors, filtered by the 'online', 'version' and 'tag' queries, and the total
+// count of visors which match the filters.
+func (c *Client) Nodes(ctx context.Context, query url.Values, opts ListOptions) ([]hypervisor.SummaryResp, int, error) {
+	var out []hypervisor.SummaryResp
+	total, err := c.list(ctx, "/api/nodes", query, opts, &out)
+	return out, total, err
+}
+
+// Node returns the summary of a visor.
+func (c *Client) Node(ctx context.Context, pk cipher.PubKey) (*hypervisor.SummaryResp, error) {
+	var out hypervisor.SummaryResp
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk), nil, nil, &out)
+	return &out, err
+}
+
+// Health returns the health of a visor.
+func (c *Client) Health(ctx context.Context, pk cipher.PubKey) (*hypervisor.VisorHealth, error) {
+	var out hypervisor.VisorHealth
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "health"), nil, nil, &out)
+	return &out, err
+}
+
+// Uptime returns the uptime of a visor.
+func (c *Client) Uptime(ctx context.Context, pk cipher.PubKey) (time.Duration, error) {
+	var secs float64
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "uptime"), nil, nil, &secs)
+	return time.Duration(secs * float64(time.Second)), err
+}
+
+// Events returns the events of a visor, filtered by the 'from', 'to' and 'type' queries.
+func (c *Client) Events(ctx context.Context, pk cipher.PubKey, query url.Values) ([]visor.Event, error) {
+	var out []visor.Event
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "events"), query, nil, &out)
+	return out, err
+}
+
+// Exec executes a command on a visor, returning its output.
+func (c *Client) Exec(ctx context.Context, pk cipher.PubKey, command string) (string, error) {
+	var out hypervisor.ExecResp
+	_, err := c.do(ctx, http.MethodPost, "/api/exec/"+pk.Hex(), nil, hypervisor.ExecRequest{Command: command}, &out)
+	return out.Output, err
+}
+
+// Tags returns the tags of a visor.
+func (c *Client) Tags(ctx context.Context, pk cipher.PubKey) ([]string, error) {
+	var out []string
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "tags"), nil, nil, &out)
+	return out, err
+}
+
+// SetTags sets the tags of a visor, returning the tags as stored.
+func (c *Client) SetTags(ctx context.Context, pk cipher.PubKey, tags []string) ([]string, error) {
+	var out []string
+	_, err := c.do(ctx, http.MethodPut, nodePath(pk, "tags"), nil, tags, &out)
+	return out, err
+}
+
+// Bulk applies an action to many visors.
+func (c *Client) Bulk(ctx context.Context, req hypervisor.BulkRequest) (*hypervisor.BulkResponse, error) {
+	var out hypervisor.BulkResponse
+	_, err := c.do(ctx, http.MethodPost, "/api/bulk", nil, req, &out)
+	return &out, err
+}
+
+// Apps returns the apps of a visor, filtered by the 'running' query, and the total count of apps which match the
+// filters.
+func (c *Client) Apps(ctx context.Context, pk cipher.PubKey, query url.Values,
+	opts ListOptions) ([]*visor.AppState, int, error) {
+	var out []*visor.AppState
+	total, err := c.list(ctx, nodePath(pk, "apps"), query, opts, &out)
+	return out, total, err
+}
+
+// App returns an app of a visor.
+func (c *Client) App(ctx context.Context, pk cipher.PubKey, app string) (*visor.AppState, error) {
+	var out visor.AppState
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "apps", app), nil, nil, &out)
+	return &out, err
+}
+
+// UpdateApp starts, stops or sets the autostart of an app of a visor.
+func (c *Client) UpdateApp(ctx context.Context, pk cipher.PubKey, app string,
+	req hypervisor.AppRequest) (*visor.AppState, error) {
+	var out visor.AppState
+	_, err := c.do(ctx, http.MethodPut, nodePath(pk, "apps", app), nil, req, &out)
+	return &out, err
+}
+
+// AppLogs returns the logs of an app of a visor since the time.
+func (c *Client) AppLogs(ctx context.Context, pk cipher.PubKey, app string,
+	since time.Time) (*hypervisor.LogsRes, error) {
+	var out hypervisor.LogsRes
+	q := url.Values{"since": {since.Format(time.RFC3339Nano)}}
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "apps", app, "logs"), q, nil, &out)
+	return &out, err
+}
+
+// AppLogFiles returns the log files of an app of a visor.
+func (c *Client) AppLogFiles(ctx context.Context, pk cipher.PubKey, app string) ([]visor.AppLogFile, error) {
+	var out []visor.AppLogFile
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "apps", app, "logs", "files"), nil, nil, &out)
+	return out, err
+}
+
+// DownloadAppLog copies the log file of an app of a visor given by the 'file' query to w, or else the logs of the
+// app within the RFC3339 times of the 'from' and 'to' queries.
+func (c *Client) DownloadAppLog(ctx context.Context, pk cipher.PubKey, app string, query url.Values,
+	w io.Writer) error {
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "apps", app, "logs", "download"), query, nil, w)
+	return err
+}
+
+// Proxies returns the proxy servers advertised on the network, filtered by the 'type' and 'country' queries, and the
+// total count of servers which match the filters.
+func (c *Client) Proxies(ctx context.Context, query url.Values, opts ListOptions) ([]sdclient.Service, int, error) {
+	var out []sdclient.Service
+	total, err := c.list(ctx, "/api/proxies", query, opts, &out)
+	return out, total, err
+}
+
+// SetProxyServer sets the proxy server of the socksproxy client app of a visor.
+func (c *Client) SetProxyServer(ctx context.Context, pk, server cipher.PubKey) (*hypervisor.ProxyServerResp, error) {
+	var out hypervisor.ProxyServerResp
+	_, err := c.do(ctx, http.MethodPut, nodePath(pk, "proxy-server"), nil, hypervisor.ProxyServerRequest{PK: server}, &out)
+	return &out, err
+}
+
+// TransportTypes returns the transport types of a visor.
+func (c *Client) TransportTypes(ctx context.Context, pk cipher.PubKey) ([]string, error) {
+	var out []string
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "transport-types"), nil, nil, &out)
+	return out, err
+}
+
+// Transports returns the transports of a visor, filtered by the 'type', 'pk' and 'label' queries, and the total
+// count of transports which match the filters.
+func (c *Client) Transports(ctx context.Context, pk cipher.PubKey, query url.Values,
+	opts ListOptions) ([]*visor.TransportSummary, int, error) {
+	var out []*visor.TransportSummary
+	total, err := c.list(ctx, nodePath(pk, "transports"), query, opts, &out)
+	return out, total, err
+}
+
+// Transport returns a transport of a visor.
+func (c *Client) Transport(ctx context.Context, pk cipher.PubKey, tid uuid.UUID) (*visor.TransportSummary, error) {
+	var out visor.TransportSummary
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "transports", tid.String()), nil, nil, &out)
+	return &out, err
+}
+
+// AddTransport adds a transport to a visor.
+func (c *Client) AddTransport(ctx context.Context, pk cipher.PubKey,
+	req hypervisor.TransportRequest) (*visor.TransportSummary, error) {
+	var out visor.TransportSummary
+	_, err := c.do(ctx, http.MethodPost, nodePath(pk, "transports"), nil, req, &out)
+	return &out, err
+}
+
+// RemoveTransport removes a transport of a visor.
+func (c *Client) RemoveTransport(ctx context.Context, pk cipher.PubKey, tid uuid.UUID) error {
+	_, err := c.do(ctx, http.MethodDelete, nodePath(pk, "transports", tid.String()), nil, nil, nil)
+	return err
+}
+
+// SetTransportLabels sets the labels of a transport of a visor.
+func (c *Client) SetTransportLabels(ctx context.Context, pk cipher.PubKey, tid uuid.UUID,
+	labels []string) (*visor.TransportSummary, error) {
+	var out visor.TransportSummary
+	_, err := c.do(ctx, http.MethodPut, nodePath(pk, "transports", tid.String(), "labels"), nil, labels, &out)
+	return &out, err
+}
+
+// TransportStats returns the statistics of a transport of a visor.
+func (c *Client) TransportStats(ctx context.Context, pk cipher.PubKey, tid uuid.UUID) (*visor.TransportStats, error) {
+	var out visor.TransportStats
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "transports", tid.String(), "stats"), nil, nil, &out)
+	return &out, err
+}
+
+// Routes returns the routing rules of a visor with their summaries, filtered by the 'type' query, and the total
+// count of rules which match the filters.
+func (c *Client) Routes(ctx context.Context, pk cipher.PubKey, query url.Values,
+	opts ListOptions) ([]hypervisor.RoutingRuleResp, int, error) {
+	var out []hypervisor.RoutingRuleResp
+	q := opts.values(query)
+	q.Set("summary", "true")
+	total, err := c.list(ctx, nodePath(pk, "routes"), q, ListOptions{}, &out)
+	return out, total, err
+}
+
+// Route returns a routing rule of a visor, with its summary.
+func (c *Client) Route(ctx context.Context, pk cipher.PubKey,
+	rid routing.RouteID) (*hypervisor.RoutingRuleResp, error) {
+	var out hypervisor.RoutingRuleResp
+	q := url.Values{"summary": {"true"}}
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "routes", fmt.Sprint(rid)), q, nil, &out)
+	return &out, err
+}
+
+// AddRoute adds a routing rule to a visor.
+func (c *Client) AddRoute(ctx context.Context, pk cipher.PubKey,
+	rule routing.RuleSummary) (*hypervisor.RoutingRuleResp, error) {
+	var out hypervisor.RoutingRuleResp
+	_, err := c.do(ctx, http.MethodPost, nodePath(pk, "routes"), nil, rule, &out)
+	return &out, err
+}
+
+// UpdateRoute replaces a routing rule of a visor.
+func (c *Client) UpdateRoute(ctx context.Context, pk cipher.PubKey, rid routing.RouteID,
+	rule routing.RuleSummary) (*hypervisor.RoutingRuleResp, error) {
+	var out hypervisor.RoutingRuleResp
+	_, err := c.do(ctx, http.MethodPut, nodePath(pk, "routes", fmt.Sprint(rid)), nil, rule, &out)
+	return &out, err
+}
+
+// RemoveRoute removes a routing rule of a visor.
+func (c *Client) RemoveRoute(ctx context.Context, pk cipher.PubKey, rid routing.RouteID) error {
+	_, err := c.do(ctx, http.MethodDelete, nodePath(pk, "routes", fmt.Sprint(rid)), nil, nil, nil)
+	return err
+}
+
+// FindRoutes looks up routes from a visor to the destination via the route finder.
+func (c *Client) FindRoutes(ctx context.Context, pk cipher.PubKey,
+	in visor.FindRoutesIn) (*visor.FindRoutesOut, error) {
+	var out visor.FindRoutesOut
+	q := url.Values{"dst": {in.Dst.Hex()}}
+	if in.MinHops > 0 {
+		q.Set("min_hops", fmt.Sprint(in.MinHops))
+	}
+	if in.MaxHops > 0 {
+		q.Set("max_hops", fmt.Sprint(in.MaxHops))
+	}
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "routes", "find"), q, nil, &out)
+	return &out, err
+}
+
+// Loops returns the loops of a visor.
+func (c *Client) Loops(ctx context.Context, pk cipher.PubKey) ([]hypervisor.LoopResp, error) {
+	var out []hypervisor.LoopResp
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "loops"), nil, nil, &out)
+	return out, err
+}
+
+// Config returns the config of a visor, with secrets redacted.
+func (c *Client) Config(ctx context.Context, pk cipher.PubKey) (json.RawMessage, error) {
+	var out json.RawMessage
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "config"), nil, nil, &out)
+	return out, err
+}
+
+// DiffConfig previews the changes of a JSON merge patch of the config of a visor.
+func (c *Client) DiffConfig(ctx context.Context, pk cipher.PubKey, patch json.RawMessage) (*visor.ConfigDiff, error) {
+	var out visor.ConfigDiff
+	_, err := c.do(ctx, http.MethodPost, nodePath(pk, "config", "diff"), nil, patch, &out)
+	return &out, err
+}
+
+// PatchConfig applies a JSON merge patch to the config of a visor, which restarts if restart is set and the changes
+// require it.
+func (c *Client) PatchConfig(ctx context.Context, pk cipher.PubKey, patch json.RawMessage,
+	restart bool) (*visor.UpdateConfigOut, error) {
+	var out visor.UpdateConfigOut
+	q := url.Values{"restart": {strconv.FormatBool(restart)}}
+	_, err := c.do(ctx, http.MethodPatch, nodePath(pk, "config"), q, patch, &out)
+	return &out, err
+}
+
+// UpdateStatus returns the status of the last update of a visor.
+func (c *Client) UpdateStatus(ctx context.Context, pk cipher.PubKey) (*visor.UpdateStatus, error) {
+	var out visor.UpdateStatus
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "update"), nil, nil, &out)
+	return &out, err
+}
+
+// Update starts an update of a visor to the latest release, or only checks for one if checkOnly is set.
+func (c *Client) Update(ctx context.Context, pk cipher.PubKey, checkOnly bool) (*visor.UpdateStatus, error) {
+	var out visor.UpdateStatus
+	q := url.Values{"check_only": {strconv.FormatBool(checkOnly)}}
+	_, err := c.do(ctx, http.MethodPost, nodePath(pk, "update"), q, nil, &out)
+	return &out, err
+}
+
+// Alerts returns the recent alerts, filtered by the 'pk', 'type' and 'since' queries.
+func (c *Client) Alerts(ctx context.Context, query url.Values) ([]hypervisor.Alert, error) {
+	var out []hypervisor.Alert
+	_, err := c.do(ctx, http.MethodGet, "/api/alerts", query, nil, &out)
+	return out, err
+}
+
+// History returns the metrics history of a visor, within the 'from' and 'to' queries.
+func (c *Client) History(ctx context.Context, pk cipher.PubKey, query url.Values) ([]hypervisor.MetricsPoint, error) {
+	var out []hypervisor.MetricsPoint
+	_, err := c.do(ctx, http.MethodGet, nodePath(pk, "history"), query, nil, &out)
+	return out, err
+}
+
+// Audit returns the audit log, newest first, filtered by the 'user', 'pk', 'since', 'until', 'success' and 'limit'
+// queries.
+func (c *Client) Audit(ctx context.Context, query url.Values) ([]hypervisor.AuditEntry, error) {
+	var out []hypervisor.AuditEntry
+	_, err := c.do(ctx, http.MethodGet, "/api/audit", query, nil, &out)
+	return out, err
+}
+
+// OpenAPI returns the OpenAPI document of the API.
+func (c *Client) OpenAPI(ctx context.Context) (*hypervisor.OpenAPIDoc, error) {
+	var out hypervisor.OpenAPIDoc
+	_, err := c.do(ctx, http.MethodGet, "/api/openapi.json", nil, nil, &out)
+	return &out, err
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/hypervisor"
+)
+
+func TestClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_client")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := hypervisor.GenerateWorkDirConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	m, err := hypervisor.NewNode(config)
+	require.NoError(t, err)
+	require.NoError(t, m.AddMockData(hypervisor.MockConfig{
+		Nodes: 3, MaxTpsPerNode: 2, MaxRoutesPerNode: 2, EnableAuth: true,
+	}))
+
+	srv := httptest.NewTLSServer(m)
+	defer srv.Close()
+	httpC := srv.Client()
+	httpC.Jar, err = cookiejar.New(nil)
+	require.NoError(t, err)
+	c := NewHTTP(srv.URL, "", httpC)
+	ctx := context.Background()
+
+	require.NoError(t, c.CreateAccount(ctx, "admin", "Secure1234"))
+	_, _, err = c.Nodes(ctx, nil, ListOptions{})
+	require.IsType(t, &Error{}, err)
+	assert.Equal(t, http.StatusUnauthorized, err.(*Error).Status)
+
+	require.NoError(t, c.Login(ctx, "admin", "Secure1234", ""))
+	user, err := c.User(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", user.Username)
+
+	nodes, total, err := c.Nodes(ctx, nil, ListOptions{Limit: 2, Sort: "pk"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, nodes, 2)
+	assert.True(t, nodes[0].PubKey.Hex() < nodes[1].PubKey.Hex())
+
+	pk := nodes[0].PubKey
+	node, err := c.Node(ctx, pk)
+	require.NoError(t, err)
+	assert.Equal(t, pk, node.PubKey)
+
+	apps, total, err := c.Apps(ctx, pk, nil, ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, len(apps), total)
+	require.NotEmpty(t, apps)
+	status := 0
+	app, err := c.UpdateApp(ctx, pk, apps[0].Name, hypervisor.AppRequest{Status: &status})
+	require.NoError(t, err)
+	assert.Equal(t, apps[0].Name, app.Name)
+	files, err := c.AppLogFiles(ctx, pk, app.Name)
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	var buf bytes.Buffer
+	require.NoError(t, c.DownloadAppLog(ctx, pk, app.Name, url.Values{"file": {files[0].Name}}, &buf))
+	assert.Equal(t, files[0].Size, int64(buf.Len()))
+
+	tps, total, err := c.Transports(ctx, pk, nil, ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, len(tps), total)
+	if len(tps) > 0 {
+		tp, err := c.Transport(ctx, pk, tps[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, tps[0].ID, tp.ID)
+	}
+
+	routes, total, err := c.Routes(ctx, pk, nil, ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, len(routes), total)
+	for _, rt := range routes {
+		assert.NotNil(t, rt.Summary)
+	}
+
+	doc, err := c.OpenAPI(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, doc.Paths, "/api/nodes/{pk}")
+
+	unknown, _ := cipher.GenerateKeyPair()
+	_, err = c.Node(ctx, unknown)
+	require.IsType(t, &Error{}, err)
+	assert.Equal(t, http.StatusNotFound, err.(*Error).Status)
+	assert.NotEmpty(t, err.(*Error).Message)
+
+	require.NoError(t, c.Logout(ctx))
+	_, err = c.User(ctx)
+	require.IsType(t, &Error{}, err)
+}
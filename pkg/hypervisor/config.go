@@ -10,6 +10,7 @@ import (
 
 	"github.com/SkycoinProject/dmsg/cipher"
 
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/util/pathutil"
 )
 
@@ -41,6 +42,28 @@ type Config struct {
 	EnableAuth bool            `json:"enable_auth"` // Whether to enable user management.
 	Cookies    CookieConfig    `json:"cookies"`     // Configures cookies (for session management).
 	Interfaces InterfaceConfig `json:"interfaces"`  // Configures exposed interfaces.
+
+	// Dmsg connects to visors which serve their RPC over dmsg, if set.
+	Dmsg *DmsgConfig `json:"dmsg,omitempty"`
+
+	// TLS serves the HTTP interface over HTTPS, if set.
+	TLS *TLSConfig `json:"tls,omitempty"`
+
+	// Alerts configures the alerts of visors which go offline, or whose apps crash repeatedly.
+	Alerts *AlertConfig `json:"alerts,omitempty"`
+
+	// History configures the sampling of the metrics of visors over time, and their retention.
+	History *HistoryConfig `json:"history,omitempty"`
+
+	// Map configures how visors are placed on the map.
+	Map *MapConfig `json:"map,omitempty"`
+
+	// ServiceDisc is the address of the service discovery, which lists the proxy servers advertised on the network.
+	ServiceDisc string `json:"service_discovery,omitempty"`
+
+	// RateLimit configures the rate limiting of the HTTP API, and lockouts after failures to authenticate. Defaults
+	// apply if unset.
+	RateLimit *RateLimitConfig `json:"rate_limit,omitempty"`
 }
 
 func makeConfig() Config {
@@ -84,6 +107,7 @@ func GenerateLocalConfig() Config {
 func (c *Config) FillDefaults() {
 	c.Cookies.FillDefaults()
 	c.Interfaces.FillDefaults()
+	c.ServiceDisc = skyenv.DefaultServiceDiscAddr
 }
 
 // Parse parses the file in path, and decodes to the config.
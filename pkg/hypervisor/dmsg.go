@@ -0,0 +1,115 @@
+package hypervisor
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/noise"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// dmsgRedialInterval is the delay between attempts to connect to visors over dmsg.
+var dmsgRedialInterval = 10 * time.Second
+
+// DmsgConfig configures the management of visors over dmsg. Visors serve their RPC over dmsg if the 'dmsg_rpc'
+// field of their config is set, and accept the hypervisor if its public key is in their 'hypervisors' field.
+type DmsgConfig struct {
+	Discovery string          `json:"discovery"`
+	Port      uint16          `json:"port,omitempty"`     // RPC port of the visors, defaults to skyenv.DmsgRPCPort.
+	PtyPort   uint16          `json:"pty_port,omitempty"` // dmsgpty port of the visors, defaults to skyenv.DefaultDmsgPtyPort.
+	Visors    []cipher.PubKey `json:"visors"`             // visors to connect to.
+}
+
+// DmsgDialer dials visors over dmsg, such as a *dmsg.Client.
+type DmsgDialer interface {
+	Dial(ctx context.Context, remote cipher.PubKey, port uint16) (*dmsg.Transport, error)
+}
+
+// ServeDmsg connects to the visors of the dmsg config with dialer, and reconnects to them when disconnected, until
+// ctx is done.
+func (m *Node) ServeDmsg(ctx context.Context, dialer DmsgDialer) {
+	if m.c.Dmsg == nil {
+		return
+	}
+	port := m.c.Dmsg.Port
+	if port == 0 {
+		port = skyenv.DmsgRPCPort
+	}
+	ptyPort := m.c.Dmsg.PtyPort
+	if ptyPort == 0 {
+		ptyPort = skyenv.DefaultDmsgPtyPort
+	}
+	m.mu.Lock()
+	m.ptyDial = func(ctx context.Context, pk cipher.PubKey) (io.ReadWriteCloser, error) {
+		return dialer.Dial(ctx, pk, ptyPort)
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, pk := range m.c.Dmsg.Visors {
+		wg.Add(1)
+		go func(pk cipher.PubKey) {
+			defer wg.Done()
+			m.serveDmsgVisor(ctx, dialer, pk, port)
+		}(pk)
+	}
+	wg.Wait()
+}
+
+func (m *Node) serveDmsgVisor(ctx context.Context, dialer DmsgDialer, pk cipher.PubKey, port uint16) {
+	for {
+		if tp, err := dialer.Dial(ctx, pk, port); err != nil {
+			log.WithError(err).Warnf("Failed to connect to visor %s over dmsg", pk)
+		} else {
+			log.Infof("Connected to visor %s over dmsg", pk)
+			conn := &watchedConn{Conn: tp, closed: make(chan struct{})}
+			client := visor.NewRPCClient(rpc.NewClient(conn), visor.RPCPrefix)
+			m.mu.Lock()
+			m.nodes[pk] = appNodeConn{Addr: &noise.Addr{PK: pk, Addr: tp.RemoteAddr()}, Client: client}
+			m.mu.Unlock()
+			go m.syncTags(pk, client)
+
+			select {
+			case <-conn.closed:
+				log.Warnf("Disconnected from visor %s over dmsg", pk)
+			case <-ctx.Done():
+				_ = tp.Close() //nolint:errcheck
+			}
+			m.mu.Lock()
+			if c, ok := m.nodes[pk]; ok && c.Client == client {
+				delete(m.nodes, pk)
+			}
+			m.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(dmsgRedialInterval):
+		}
+	}
+}
+
+// watchedConn closes the closed channel once reading from the connection fails, which the RPC client does
+// continuously, so that disconnections are noticed.
+type watchedConn struct {
+	net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (c *watchedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err != nil {
+		c.once.Do(func() { close(c.closed) })
+	}
+	return n, err
+}
@@ -0,0 +1,83 @@
+package hypervisor
+
+import (
+	"context"
+	"net/rpc"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/disc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/nettest"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+type uptimeRPC struct{}
+
+func (uptimeRPC) Uptime(_ *struct{}, out *float64) error {
+	*out = 42
+	return nil
+}
+
+func TestNode_ServeDmsg(t *testing.T) {
+	dc := disc.NewMock()
+	srvPK, srvSK := cipher.GenerateKeyPair()
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+	srv, err := dmsg.NewServer(srvPK, srvSK, "", l, dc)
+	require.NoError(t, err)
+	go func() { _ = srv.Serve() }() //nolint:errcheck
+	defer func() { require.NoError(t, srv.Close()) }()
+
+	// The visor serves its RPC over dmsg.
+	visorPK, visorSK := cipher.GenerateKeyPair()
+	visorC := dmsg.NewClient(visorPK, visorSK, dc)
+	require.NoError(t, visorC.InitiateServerConnections(context.TODO(), 1))
+	visorL, err := visorC.Listen(skyenv.DmsgRPCPort)
+	require.NoError(t, err)
+	rpcSvr := rpc.NewServer()
+	require.NoError(t, rpcSvr.RegisterName(visor.RPCPrefix, uptimeRPC{}))
+	go rpcSvr.Accept(visorL)
+
+	config := makeConfig()
+	config.Dmsg = &DmsgConfig{Visors: []cipher.PubKey{visorPK}}
+	m := &Node{c: config, nodes: make(map[cipher.PubKey]appNodeConn), mu: new(sync.RWMutex)}
+	hvC := dmsg.NewClient(config.PK, config.SK, dc)
+	require.NoError(t, hvC.InitiateServerConnections(context.TODO(), 1))
+	defer func() { require.NoError(t, hvC.Close()) }()
+
+	dmsgRedialInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.ServeDmsg(ctx, hvC)
+		close(done)
+	}()
+
+	var client visor.RPCClient
+	testhelpers.Eventually(t, func() bool {
+		var ok bool
+		_, client, ok = m.client(visorPK)
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+	uptime, err := client.Uptime()
+	require.NoError(t, err)
+	assert.Equal(t, float64(42), uptime)
+
+	// The visor is removed once it disconnects.
+	require.NoError(t, visorC.Close())
+	testhelpers.Eventually(t, func() bool {
+		_, _, ok := m.client(visorPK)
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
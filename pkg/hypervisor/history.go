@@ -0,0 +1,481 @@
+package hypervisor
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+	"go.etcd.io/bbolt"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// Defaults of HistoryConfig.
+const (
+	DefaultHistoryInterval        = time.Minute
+	DefaultHistoryRetention       = 7 * 24 * time.Hour
+	DefaultHistoryHourlyRetention = 90 * 24 * time.Hour
+)
+
+// defaultHistoryRange is the range of history provided if not queried.
+const defaultHistoryRange = 24 * time.Hour
+
+const (
+	boltHistoryBucketName       = "visor_history"
+	boltHourlyHistoryBucketName = "visor_history_hourly"
+)
+
+// HistoryResolution is the resolution of metrics history.
+type HistoryResolution string
+
+// Resolutions of metrics history.
+const (
+	ResolutionRaw  HistoryResolution = "raw"  // samples, as collected.
+	ResolutionHour HistoryResolution = "hour" // hourly averages of samples.
+)
+
+// ErrUnknownResolution occurs when history is requested with an unknown resolution.
+var ErrUnknownResolution = errors.New("unknown resolution, expected 'raw' or 'hour'")
+
+// HistoryConfig configures the sampling of the metrics of visors, and how long samples are retained. Samples are
+// averaged per hour, and hourly averages are retained longer than samples.
+type HistoryConfig struct {
+	Interval        visor.Duration `json:"interval,omitempty"`         // defaults to DefaultHistoryInterval.
+	Retention       visor.Duration `json:"retention,omitempty"`        // defaults to DefaultHistoryRetention.
+	HourlyRetention visor.Duration `json:"hourly_retention,omitempty"` // defaults to DefaultHistoryHourlyRetention.
+}
+
+// FillDefaults fills the unset fields of the config with default values.
+func (c *HistoryConfig) FillDefaults() {
+	if c.Interval <= 0 {
+		c.Interval = visor.Duration(DefaultHistoryInterval)
+	}
+	if c.Retention <= 0 {
+		c.Retention = visor.Duration(DefaultHistoryRetention)
+	}
+	if c.HourlyRetention <= 0 {
+		c.HourlyRetention = visor.Duration(DefaultHistoryHourlyRetention)
+	}
+}
+
+// MetricsPoint holds metrics of a visor, as sampled at Time, or averaged over the hour starting at Time.
+type MetricsPoint struct {
+	Time       time.Time          `json:"time"`
+	Samples    int                `json:"samples"`
+	Online     float64            `json:"online"` // fraction of samples in which the visor was online.
+	Transports float64            `json:"transports"`
+	SentRate   float64            `json:"sent_bytes_per_second"`
+	RecvRate   float64            `json:"recv_bytes_per_second"`
+	Apps       map[string]float64 `json:"apps,omitempty"` // fraction of samples in which each app was running.
+}
+
+// merge adds the samples of p to the average of the point.
+func (mp *MetricsPoint) merge(p MetricsPoint) {
+	n, w := float64(mp.Samples), float64(p.Samples)
+	avg := func(a, b float64) float64 { return (a*n + b*w) / (n + w) }
+	mp.Online = avg(mp.Online, p.Online)
+	mp.Transports = avg(mp.Transports, p.Transports)
+	mp.SentRate = avg(mp.SentRate, p.SentRate)
+	mp.RecvRate = avg(mp.RecvRate, p.RecvRate)
+	apps := make(map[string]float64, len(mp.Apps))
+	for app, running := range mp.Apps {
+		apps[app] = avg(running, p.Apps[app])
+	}
+	for app, running := range p.Apps {
+		if _, ok := mp.Apps[app]; !ok {
+			apps[app] = avg(0, running)
+		}
+	}
+	mp.Apps = apps
+	mp.Samples += p.Samples
+}
+
+// HistoryStore stores the metrics history of visors.
+type HistoryStore interface {
+	AddSample(pk cipher.PubKey, sample MetricsPoint) error
+	Points(pk cipher.PubKey, res HistoryResolution, from, to time.Time) ([]MetricsPoint, error)
+	Visors() ([]cipher.PubKey, error)
+	Prune(res HistoryResolution, before time.Time) error
+}
+
+// BoltHistoryStore implements HistoryStore, storing history in a bbolt database.
+type BoltHistoryStore struct {
+	*bbolt.DB
+}
+
+// NewBoltHistoryStore creates a new BoltHistoryStore in the database, such as the database of a BoltUserStore.
+func NewBoltHistoryStore(db *bbolt.DB) (*BoltHistoryStore, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range []string{boltHistoryBucketName, boltHourlyHistoryBucketName} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return &BoltHistoryStore{DB: db}, err
+}
+
+func historyBucketName(res HistoryResolution) ([]byte, error) {
+	switch res {
+	case ResolutionRaw:
+		return []byte(boltHistoryBucketName), nil
+	case ResolutionHour:
+		return []byte(boltHourlyHistoryBucketName), nil
+	default:
+		return nil, ErrUnknownResolution
+	}
+}
+
+func historyKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+// AddSample adds the sample to the history of the visor, and to the average of its hour.
+func (s *BoltHistoryStore) AddSample(pk cipher.PubKey, sample MetricsPoint) error {
+	return s.Update(func(tx *bbolt.Tx) error {
+		raw, err := tx.Bucket([]byte(boltHistoryBucketName)).CreateBucketIfNotExists(pk[:])
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(sample)
+		if err != nil {
+			return err
+		}
+		if err := raw.Put(historyKey(sample.Time), data); err != nil {
+			return err
+		}
+
+		hourly, err := tx.Bucket([]byte(boltHourlyHistoryBucketName)).CreateBucketIfNotExists(pk[:])
+		if err != nil {
+			return err
+		}
+		avg := MetricsPoint{Time: sample.Time.Truncate(time.Hour)}
+		if data := hourly.Get(historyKey(avg.Time)); data != nil {
+			if err := json.Unmarshal(data, &avg); err != nil {
+				return err
+			}
+		}
+		avg.merge(sample)
+		if data, err = json.Marshal(avg); err != nil {
+			return err
+		}
+		return hourly.Put(historyKey(avg.Time), data)
+	})
+}
+
+// Points obtains the points of the visor from the time range, in order.
+func (s *BoltHistoryStore) Points(pk cipher.PubKey, res HistoryResolution, from, to time.Time) ([]MetricsPoint, error) {
+	name, err := historyBucketName(res)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]MetricsPoint, 0)
+	err = s.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(name).Bucket(pk[:])
+		if b == nil {
+			return nil
+		}
+		end := historyKey(to)
+		c := b.Cursor()
+		for k, v := c.Seek(historyKey(from)); k != nil && string(k) <= string(end); k, v = c.Next() {
+			var p MetricsPoint
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+			points = append(points, p)
+		}
+		return nil
+	})
+	return points, err
+}
+
+// Visors obtains the public keys of the visors with history.
+func (s *BoltHistoryStore) Visors() ([]cipher.PubKey, error) {
+	var pks []cipher.PubKey
+	err := s.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(boltHistoryBucketName)).ForEach(func(k, _ []byte) error {
+			var pk cipher.PubKey
+			copy(pk[:], k)
+			pks = append(pks, pk)
+			return nil
+		})
+	})
+	return pks, err
+}
+
+// Prune removes the points from before the time, and the history of visors without remaining points.
+func (s *BoltHistoryStore) Prune(res HistoryResolution, before time.Time) error {
+	name, err := historyBucketName(res)
+	if err != nil {
+		return err
+	}
+	return s.Update(func(tx *bbolt.Tx) error {
+		root := tx.Bucket(name)
+		var empty [][]byte
+		err := root.ForEach(func(pk, _ []byte) error {
+			b := root.Bucket(pk)
+			var old [][]byte
+			c := b.Cursor()
+			end := historyKey(before)
+			for k, _ := c.First(); k != nil && string(k) < string(end); k, _ = c.Next() {
+				old = append(old, k)
+			}
+			for _, k := range old {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			if k, _ := b.Cursor().First(); k == nil {
+				empty = append(empty, pk)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, pk := range empty {
+			if err := root.DeleteBucket(pk); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// historian samples the metrics of visors.
+type historian struct {
+	conf  HistoryConfig
+	store HistoryStore
+
+	// Traffic of the transports of each visor as of the last sample, to derive bandwidth.
+	traffic   map[cipher.PubKey]transportTraffic
+	trafficMu sync.Mutex
+}
+
+type transportTraffic struct {
+	time time.Time
+	logs map[uuid.UUID]transport.LogEntry
+}
+
+func newHistorian(conf *HistoryConfig, store HistoryStore) *historian {
+	h := &historian{store: store, traffic: make(map[cipher.PubKey]transportTraffic)}
+	if conf != nil {
+		h.conf = *conf
+	}
+	h.conf.FillDefaults()
+	return h
+}
+
+// sample returns the sample of a visor with the summary and transports, deriving bandwidth from the traffic of the
+// transports since the last sample.
+func (h *historian) sample(pk cipher.PubKey, now time.Time, summary *visor.Summary,
+	tps []*visor.TransportSummary) MetricsPoint {
+
+	p := MetricsPoint{
+		Time:       now,
+		Samples:    1,
+		Online:     1,
+		Transports: float64(len(tps)),
+		Apps:       make(map[string]float64, len(summary.Apps)),
+	}
+	for _, app := range summary.Apps {
+		if app.Status == visor.AppStatusRunning {
+			p.Apps[app.Name] = 1
+		} else {
+			p.Apps[app.Name] = 0
+		}
+	}
+
+	current := transportTraffic{time: now, logs: make(map[uuid.UUID]transport.LogEntry, len(tps))}
+	for _, tp := range tps {
+		if tp.Log != nil {
+			current.logs[tp.ID] = *tp.Log
+		}
+	}
+	h.trafficMu.Lock()
+	last, ok := h.traffic[pk]
+	h.traffic[pk] = current
+	h.trafficMu.Unlock()
+	if elapsed := now.Sub(last.time).Seconds(); ok && elapsed > 0 {
+		var sent, recv uint64
+		for id, entry := range current.logs {
+			// Traffic of transports established since the last sample is counted in full.
+			prev := last.logs[id]
+			if entry.SentBytes >= prev.SentBytes && entry.RecvBytes >= prev.RecvBytes {
+				sent += entry.SentBytes - prev.SentBytes
+				recv += entry.RecvBytes - prev.RecvBytes
+			}
+		}
+		p.SentRate, p.RecvRate = float64(sent)/elapsed, float64(recv)/elapsed
+	}
+	return p
+}
+
+// offline returns the sample of a visor which is not connected.
+func (h *historian) offline(pk cipher.PubKey, now time.Time) MetricsPoint {
+	h.trafficMu.Lock()
+	delete(h.traffic, pk)
+	h.trafficMu.Unlock()
+	return MetricsPoint{Time: now, Samples: 1}
+}
+
+// RunHistory samples the metrics of visors, until ctx is done.
+func (m *Node) RunHistory(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(m.history.conf.Interval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.sampleHistory(time.Now())
+		}
+	}
+}
+
+// sampleHistory samples the metrics of connected visors, and of visors with history which are not connected, and
+// prunes history which is no longer retained.
+func (m *Node) sampleHistory(now time.Time) {
+	pks := m.selectNodes(&BulkRequest{All: true})
+	known, err := m.history.store.Visors()
+	if err != nil {
+		log.WithError(err).Warn("Failed to obtain visors with history")
+	}
+	for _, pk := range known {
+		if _, _, ok := m.client(pk); !ok {
+			pks = append(pks, pk)
+		}
+	}
+
+	type result struct {
+		summary *visor.Summary
+		tps     []*visor.TransportSummary
+	}
+	results := m.applyBulk(pks, func(rpc visor.RPCClient) (interface{}, error) {
+		summary, err := rpc.Summary()
+		if err != nil {
+			return nil, err
+		}
+		tps, err := rpc.Transports(nil, nil, true)
+		if err != nil {
+			return nil, err
+		}
+		return result{summary: summary, tps: tps}, nil
+	})
+
+	for _, res := range results {
+		sample := m.history.offline(res.PK, now)
+		if r, ok := res.Result.(result); res.OK && ok {
+			sample = m.history.sample(res.PK, now, r.summary, r.tps)
+		}
+		if err := m.history.store.AddSample(res.PK, sample); err != nil {
+			log.WithError(err).Warnf("Failed to record history of visor %s", res.PK)
+		}
+	}
+
+	if err := m.history.store.Prune(ResolutionRaw, now.Add(-time.Duration(m.history.conf.Retention))); err != nil {
+		log.WithError(err).Warn("Failed to prune history")
+	}
+	hourly := now.Add(-time.Duration(m.history.conf.HourlyRetention))
+	if err := m.history.store.Prune(ResolutionHour, hourly); err != nil {
+		log.WithError(err).Warn("Failed to prune hourly history")
+	}
+}
+
+// historyQuery parses the 'from', 'to' and 'resolution' queries of history requests. The range defaults to the last
+// defaultHistoryRange, and the resolution to hourly averages for ranges beyond the retention of samples.
+func (m *Node) historyQuery(r *http.Request) (res HistoryResolution, from, to time.Time, err error) {
+	q := r.URL.Query()
+	to = time.Now()
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			return "", from, to, fmt.Errorf("invalid 'to': %v", err)
+		}
+	}
+	from = to.Add(-defaultHistoryRange)
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			return "", from, to, fmt.Errorf("invalid 'from': %v", err)
+		}
+	}
+	if from.After(to) {
+		return "", from, to, errors.New("'from' is after 'to'")
+	}
+	switch res = HistoryResolution(q.Get("resolution")); res {
+	case "":
+		res = ResolutionRaw
+		if from.Before(time.Now().Add(-time.Duration(m.history.conf.Retention))) {
+			res = ResolutionHour
+		}
+	case ResolutionRaw, ResolutionHour:
+	default:
+		return "", from, to, ErrUnknownResolution
+	}
+	return res, from, to, nil
+}
+
+// provides the metrics history of a node, which need not be connected, within the 'from' and 'to' queries.
+func (m *Node) getHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pk, err := pkFromParam(r, "pk")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		res, from, to, err := m.historyQuery(r)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		points, err := m.history.store.Points(pk, res, from, to)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, points)
+	}
+}
+
+// provides the metrics history of all nodes with history, or of those with all tags of the 'tag' queries, by
+// public key.
+func (m *Node) getAllHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, from, to, err := m.historyQuery(r)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		pks, err := m.history.store.Visors()
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		want := r.URL.Query()["tag"]
+		allTags := m.tags.AllTags()
+		byPK := make(map[string][]MetricsPoint, len(pks))
+		for _, pk := range pks {
+			if !hasTags(allTags[pk], want) {
+				continue
+			}
+			points, err := m.history.store.Points(pk, res, from, to)
+			if err != nil {
+				httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			byPK[pk.Hex()] = points
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, byPK)
+	}
+}
@@ -0,0 +1,158 @@
+package hypervisor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func TestBoltHistoryStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_history")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	users, err := NewBoltUserStore(filepath.Join(dir, "users.db"))
+	require.NoError(t, err)
+	s, err := NewBoltHistoryStore(users.DB)
+	require.NoError(t, err)
+
+	pk, _ := cipher.GenerateKeyPair()
+	hour := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+	samples := []MetricsPoint{
+		{Time: hour.Add(time.Minute), Samples: 1, Online: 1, Transports: 4, Apps: map[string]float64{"skychat": 1}},
+		{Time: hour.Add(2 * time.Minute), Samples: 1},
+		{Time: hour.Add(time.Hour), Samples: 1, Online: 1, Transports: 2},
+	}
+	for _, sample := range samples {
+		require.NoError(t, s.AddSample(pk, sample))
+	}
+
+	points, err := s.Points(pk, ResolutionRaw, hour, hour.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.True(t, samples[0].Time.Equal(points[0].Time))
+
+	points, err = s.Points(pk, ResolutionHour, hour, hour.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.True(t, hour.Equal(points[0].Time))
+	assert.Equal(t, 2, points[0].Samples)
+	assert.Equal(t, 0.5, points[0].Online)
+	assert.Equal(t, 2.0, points[0].Transports)
+	assert.Equal(t, map[string]float64{"skychat": 0.5}, points[0].Apps)
+
+	pks, err := s.Visors()
+	require.NoError(t, err)
+	assert.Equal(t, []cipher.PubKey{pk}, pks)
+
+	require.NoError(t, s.Prune(ResolutionRaw, hour.Add(time.Hour)))
+	points, err = s.Points(pk, ResolutionRaw, hour, hour.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, points, 1)
+
+	// Visors without remaining samples no longer have history.
+	require.NoError(t, s.Prune(ResolutionRaw, hour.Add(2*time.Hour)))
+	pks, err = s.Visors()
+	require.NoError(t, err)
+	assert.Empty(t, pks)
+	points, err = s.Points(pk, ResolutionHour, hour, hour.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, points, 2)
+
+	_, err = s.Points(pk, "minute", hour, hour)
+	assert.Equal(t, ErrUnknownResolution, err)
+}
+
+func TestHistorian_sample(t *testing.T) {
+	h := newHistorian(nil, nil)
+	pk, _ := cipher.GenerateKeyPair()
+	id1, id2 := uuid.New(), uuid.New()
+	summary := &visor.Summary{Apps: []*visor.AppState{
+		{Name: "skychat", Status: visor.AppStatusRunning},
+		{Name: "skysocks", Status: visor.AppStatusStopped},
+	}}
+
+	now := time.Now()
+	p := h.sample(pk, now, summary, []*visor.TransportSummary{
+		{ID: id1, Log: &transport.LogEntry{SentBytes: 1000, RecvBytes: 100}},
+	})
+	assert.Equal(t, MetricsPoint{
+		Time:       now,
+		Samples:    1,
+		Online:     1,
+		Transports: 1,
+		Apps:       map[string]float64{"skychat": 1, "skysocks": 0},
+	}, p)
+
+	// Bandwidth is derived from the traffic since the last sample, including of new transports.
+	p = h.sample(pk, now.Add(10*time.Second), summary, []*visor.TransportSummary{
+		{ID: id1, Log: &transport.LogEntry{SentBytes: 2000, RecvBytes: 100}},
+		{ID: id2, Log: &transport.LogEntry{SentBytes: 1000, RecvBytes: 500}},
+	})
+	assert.Equal(t, 200.0, p.SentRate)
+	assert.Equal(t, 50.0, p.RecvRate)
+
+	assert.Equal(t, MetricsPoint{Time: now, Samples: 1}, h.offline(pk, now))
+}
+
+func TestNode_sampleHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_history")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	m, err := NewNode(config)
+	require.NoError(t, err)
+	require.NoError(t, m.AddMockData(MockConfig{Nodes: 2, MaxTpsPerNode: 2}))
+	pks := m.selectNodes(&BulkRequest{All: true})
+
+	now := time.Now()
+	m.sampleHistory(now.Add(-time.Minute))
+	m.mu.Lock()
+	delete(m.nodes, pks[0])
+	m.mu.Unlock()
+	m.sampleHistory(now)
+
+	get := func(uri string, out interface{}) int {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, uri, nil))
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(out))
+		}
+		return w.Code
+	}
+
+	// Visors which are no longer connected are sampled as offline.
+	var points []MetricsPoint
+	require.Equal(t, http.StatusOK, get("/api/nodes/"+pks[0].Hex()+"/history", &points))
+	require.Len(t, points, 2)
+	assert.Equal(t, 1.0, points[0].Online)
+	assert.Equal(t, 0.0, points[1].Online)
+
+	var byPK map[string][]MetricsPoint
+	require.Equal(t, http.StatusOK, get("/api/history?resolution=hour", &byPK))
+	assert.Len(t, byPK, 2)
+	samples := 0
+	for _, p := range byPK[pks[1].Hex()] {
+		samples += p.Samples
+	}
+	assert.Equal(t, 2, samples)
+
+	from := now.Add(-time.Hour).Format(time.RFC3339)
+	assert.Equal(t, http.StatusBadRequest, get("/api/history?to="+from+"&from="+now.Format(time.RFC3339), nil))
+	assert.Equal(t, http.StatusBadRequest, get("/api/history?resolution=minute", nil))
+}
@@ -2,14 +2,17 @@
 package hypervisor
 
 import (
+	"bytes"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"net"
 	"net/http"
 	"net/rpc"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,6 +26,7 @@ import (
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
 )
 
@@ -38,10 +42,21 @@ type appNodeConn struct {
 
 // Node manages AppNodes.
 type Node struct {
-	c     Config
-	nodes map[cipher.PubKey]appNodeConn // connected remote nodes.
-	users *UserManager
-	mu    *sync.RWMutex
+	c        Config
+	nodes    map[cipher.PubKey]appNodeConn // connected remote nodes.
+	users    *UserManager
+	tags     TagStore
+	alerts   *alerter
+	history  *historian
+	auditLog AuditLog
+	status   *statusHub
+	geo      *geoLocator
+	proxies  *proxyDirectory
+	mock     *mockScenario // nil unless mock data is added.
+	ptyDial  ptyDialFunc   // nil unless visors are managed over dmsg.
+	mu       *sync.RWMutex
+
+	pendingTags map[cipher.PubKey]visor.SharedState // tags set while nodes were disconnected.
 }
 
 // NewNode creates a new Node.
@@ -50,13 +65,40 @@ func NewNode(config Config) (*Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	singleUserDB := NewSingleUserStore("admin", boltUserDB)
+	tokenDB, err := NewBoltTokenStore(boltUserDB.DB)
+	if err != nil {
+		return nil, err
+	}
+	tagDB, err := NewBoltTagStore(boltUserDB.DB)
+	if err != nil {
+		return nil, err
+	}
+	historyDB, err := NewBoltHistoryStore(boltUserDB.DB)
+	if err != nil {
+		return nil, err
+	}
+	auditDB, err := NewBoltAuditLog(boltUserDB.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	users := NewUserManager(boltUserDB, tokenDB, config.Cookies)
+	users.limiter = newRateLimiter(config.RateLimit)
 
 	return &Node{
-		c:     config,
-		nodes: make(map[cipher.PubKey]appNodeConn),
-		users: NewUserManager(singleUserDB, config.Cookies),
-		mu:    new(sync.RWMutex),
+		c:        config,
+		nodes:    make(map[cipher.PubKey]appNodeConn),
+		users:    users,
+		tags:     tagDB,
+		alerts:   newAlerter(config.Alerts),
+		history:  newHistorian(config.History, historyDB),
+		auditLog: auditDB,
+		status:   newStatusHub(),
+		geo:      newGeoLocator(config.Map),
+		proxies:  newProxyDirectory(config.ServiceDisc),
+		mu:       new(sync.RWMutex),
+
+		pendingTags: make(map[cipher.PubKey]visor.SharedState),
 	}, nil
 }
 
@@ -68,12 +110,11 @@ func (m *Node) ServeRPC(lis net.Listener) error {
 			return err
 		}
 		addr := conn.RemoteAddr().(*noise.Addr)
+		client := visor.NewRPCClient(rpc.NewClient(conn), visor.RPCPrefix)
 		m.mu.Lock()
-		m.nodes[addr.PK] = appNodeConn{
-			Addr:   addr,
-			Client: visor.NewRPCClient(rpc.NewClient(conn), visor.RPCPrefix),
-		}
+		m.nodes[addr.PK] = appNodeConn{Addr: addr, Client: client}
 		m.mu.Unlock()
+		go m.syncTags(addr.PK, client)
 	}
 }
 
@@ -82,42 +123,87 @@ type mockAddr string
 func (mockAddr) Network() string  { return "mock" }
 func (a mockAddr) String() string { return string(a) }
 
-// MockConfig configures how mock data is to be added.
+// MockConfig configures how mock data is to be added. It may be parsed from a scenario file with ParseMockConfig.
 type MockConfig struct {
-	Nodes            int
-	MaxTpsPerNode    int
-	MaxRoutesPerNode int
-	EnableAuth       bool
+	Nodes            int  `json:"nodes"`
+	MaxTpsPerNode    int  `json:"max_transports_per_node"`
+	MaxRoutesPerNode int  `json:"max_routes_per_node"`
+	EnableAuth       bool `json:"enable_auth"`
+
+	// Seed derives the mock data, which is reproducible from the same seed. The seed is random if zero.
+	Seed int64 `json:"seed,omitempty"`
+
+	OfflineNodes    int       `json:"offline_nodes,omitempty"`     // nodes which are offline at first.
+	FailingAppNodes int       `json:"failing_app_nodes,omitempty"` // online nodes with an app which crashes every step.
+	Churn           MockChurn `json:"churn"`
 }
 
 // AddMockData adds mock data to Node.
 func (m *Node) AddMockData(config MockConfig) error {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	log.Infof("Generating mock data with seed %d.", seed)
+	r := rand.New(rand.NewSource(seed))
+	mock := newMockScenario(r, config.Churn)
 	for i := 0; i < config.Nodes; i++ {
 		pk, client, err := visor.NewMockRPCClient(r, config.MaxTpsPerNode, config.MaxRoutesPerNode)
 		if err != nil {
 			return err
 		}
-		m.mu.Lock()
-		m.nodes[pk] = appNodeConn{
-			Addr: &noise.Addr{
-				PK:   pk,
-				Addr: mockAddr(fmt.Sprintf("0.0.0.0:%d", i)),
+		v := &mockNode{
+			conn: appNodeConn{
+				Addr: &noise.Addr{
+					PK:   pk,
+					Addr: mockAddr(fmt.Sprintf("0.0.0.0:%d", i)),
+				},
+				Client: client,
 			},
-			Client: client,
+			offline: offlineRPCClient(),
+			online:  i >= config.OfflineNodes,
+		}
+		if v.online && i < config.OfflineNodes+config.FailingAppNodes {
+			apps, err := client.Apps()
+			if err != nil {
+				return err
+			}
+			v.failingApp = apps[0].Name
+			v.crash(time.Now())
 		}
+		mock.nodes = append(mock.nodes, v)
+		m.mu.Lock()
+		m.nodes[pk] = v.current()
 		m.mu.Unlock()
 	}
+	m.mu.Lock()
+	m.mock = mock
+	m.mu.Unlock()
 	m.c.EnableAuth = config.EnableAuth
 	return nil
 }
 
 // ServeHTTP implements http.Handler
 func (m *Node) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	m.router().ServeHTTP(w, req)
+}
+
+// router routes the API of the hypervisor. Routes are documented by apiDocs.
+func (m *Node) router() chi.Router {
 	r := chi.NewRouter()
 	r.Use(middleware.Timeout(time.Second * 30))
 	r.Use(middleware.Logger)
+	r.Group(func(r chi.Router) {
+		if m.c.EnableAuth {
+			r.Use(m.users.Authorize)
+		}
+		r.Use(RequireScope(TokenScopeRead))
+		r.Get("/metrics", m.getMetrics())
+	})
 	r.Route("/api", func(r chi.Router) {
+		r.Use(m.audit)
+		r.Use(m.rateLimit)
+		r.Get("/openapi.json", m.getOpenAPI())
 		if m.c.EnableAuth {
 			r.Group(func(r chi.Router) {
 				r.Post("/create-account", m.users.CreateAccount())
@@ -129,31 +215,91 @@ func (m *Node) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			if m.c.EnableAuth {
 				r.Use(m.users.Authorize)
 			}
-			r.Get("/user", m.users.UserInfo())
-			r.Post("/change-password", m.users.ChangePassword())
-			r.Post("/exec/{pk}", m.exec())
-			r.Get("/nodes", m.getNodes())
-			r.Get("/nodes/{pk}/health", m.getHealth())
-			r.Get("/nodes/{pk}/uptime", m.getUptime())
-			r.Get("/nodes/{pk}", m.getNode())
-			r.Get("/nodes/{pk}/apps", m.getApps())
-			r.Get("/nodes/{pk}/apps/{app}", m.getApp())
-			r.Put("/nodes/{pk}/apps/{app}", m.putApp())
-			r.Get("/nodes/{pk}/apps/{app}/logs", m.appLogsSince())
-			r.Get("/nodes/{pk}/transport-types", m.getTransportTypes())
-			r.Get("/nodes/{pk}/transports", m.getTransports())
-			r.Post("/nodes/{pk}/transports", m.postTransport())
-			r.Get("/nodes/{pk}/transports/{tid}", m.getTransport())
-			r.Delete("/nodes/{pk}/transports/{tid}", m.deleteTransport())
-			r.Get("/nodes/{pk}/routes", m.getRoutes())
-			r.Post("/nodes/{pk}/routes", m.postRoute())
-			r.Get("/nodes/{pk}/routes/{rid}", m.getRoute())
-			r.Put("/nodes/{pk}/routes/{rid}", m.putRoute())
-			r.Delete("/nodes/{pk}/routes/{rid}", m.deleteRoute())
-			r.Get("/nodes/{pk}/loops", m.getLoops())
+			operator := m.users.RequireRole(RoleOperator)
+			admin := m.users.RequireRole(RoleAdmin)
+
+			// Routes which only read state, allowed with read tokens.
+			r.Group(func(r chi.Router) {
+				r.Use(RequireScope(TokenScopeRead))
+				r.Get("/user", m.users.UserInfo())
+				r.Group(func(r chi.Router) {
+					r.Use(RequireSession)
+					r.Get("/tokens", m.users.Tokens())
+					r.With(admin).Get("/users", m.users.Users())
+				})
+				r.Get("/nodes", m.getNodes())
+				r.Get("/tags", m.getAllTags())
+				r.Get("/nodes/{pk}/tags", m.getTags())
+				r.Get("/alerts", m.getAlerts())
+				r.Get("/alerts/visors", m.getVisorStatuses())
+				r.Get("/status/stream", m.getStatusStream())
+				r.Get("/map", m.getMap())
+				r.Get("/proxies", m.getProxies())
+				r.With(admin).Get("/audit", m.getAudit())
+				r.Get("/history", m.getAllHistory())
+				r.Get("/nodes/{pk}/history", m.getHistory())
+				r.Get("/nodes/{pk}/health", m.getHealth())
+				r.Get("/nodes/{pk}/hypervisors", m.getHypervisors())
+				r.Get("/nodes/{pk}/uptime", m.getUptime())
+				r.Get("/nodes/{pk}/events", m.getEvents())
+				r.Get("/nodes/{pk}/trusted-visors", m.getTrustedVisors())
+				r.Get("/nodes/{pk}", m.getNode())
+				r.Get("/nodes/{pk}/config", m.getConfig())
+				r.Get("/nodes/{pk}/update", m.getUpdate())
+				r.Get("/nodes/{pk}/apps", m.getApps())
+				r.Get("/nodes/{pk}/apps/{app}", m.getApp())
+				r.Get("/nodes/{pk}/apps/{app}/logs", m.appLogsSince())
+				r.Get("/nodes/{pk}/apps/{app}/logs/files", m.getAppLogFiles())
+				r.Get("/nodes/{pk}/apps/{app}/logs/download", m.downloadAppLog())
+				r.Get("/nodes/{pk}/transport-types", m.getTransportTypes())
+				r.Get("/nodes/{pk}/transports", m.getTransports())
+				r.Get("/nodes/{pk}/transports/{tid}", m.getTransport())
+				r.Get("/nodes/{pk}/transports/{tid}/stats", m.getTransportStats())
+				r.Get("/nodes/{pk}/routes", m.getRoutes())
+				r.Get("/nodes/{pk}/routes/find", m.getFindRoutes())
+				r.Get("/nodes/{pk}/routes/{rid}", m.getRoute())
+				r.Get("/nodes/{pk}/loops", m.getLoops())
+			})
+
+			// Routes which change state or hold connections open, which require write tokens.
+			r.Group(func(r chi.Router) {
+				r.Use(RequireScope(TokenScopeWrite))
+				r.Group(func(r chi.Router) {
+					r.Use(RequireSession)
+					r.Post("/change-password", m.users.ChangePassword())
+					r.Post("/2fa/enroll", m.users.EnrollTOTP())
+					r.Post("/2fa/confirm", m.users.ConfirmTOTP())
+					r.Post("/2fa/recovery-codes", m.users.NewRecoveryCodes())
+					r.Post("/2fa/disable", m.users.DisableTOTP())
+					r.Post("/tokens", m.users.CreateToken())
+					r.Delete("/tokens/{id}", m.users.RevokeToken())
+					r.With(admin).Post("/users", m.users.AddUser())
+					r.With(admin).Put("/users/{username}", m.users.UpdateUser())
+					r.With(admin).Delete("/users/{username}", m.users.RemoveUser())
+				})
+				r.With(admin).Post("/exec/{pk}", m.exec())
+				r.With(operator).Put("/nodes/{pk}/tags", m.putTags())
+				r.With(operator).Post("/bulk", m.postBulk())
+				r.With(admin).Post("/alerts/test", m.postTestAlert())
+				r.With(operator).Post("/nodes/{pk}/trusted-visors", m.postTrustedVisors())
+				r.With(operator).Delete("/nodes/{pk}/trusted-visors/{trusted}", m.deleteTrustedVisor())
+				r.With(operator).Post("/nodes/{pk}/config/diff", m.postConfigDiff())
+				r.With(admin).Patch("/nodes/{pk}/config", m.patchConfig())
+				r.With(admin).Post("/nodes/{pk}/update", m.postUpdate())
+				r.With(operator).Put("/nodes/{pk}/apps/{app}", m.putApp())
+				r.With(operator).Put("/nodes/{pk}/proxy-server", m.putProxyServer())
+				r.Get("/nodes/{pk}/logs/stream", m.streamLogs())
+				r.With(admin, RequireSession).Get("/nodes/{pk}/pty", m.getPty())
+				r.With(operator).Post("/nodes/{pk}/transports", m.postTransport())
+				r.With(operator).Delete("/nodes/{pk}/transports/{tid}", m.deleteTransport())
+				r.With(operator).Put("/nodes/{pk}/transports/{tid}/labels", m.putTransportLabels())
+				r.With(operator).Post("/nodes/{pk}/routes", m.postRoute())
+				r.With(operator).Put("/nodes/{pk}/routes/{rid}", m.putRoute())
+				r.With(operator).Delete("/nodes/{pk}/routes/{rid}", m.deleteRoute())
+			})
 		})
 	})
-	r.ServeHTTP(w, req)
+	return r
 }
 
 // VisorHealth represents a node's health report attached to hypervisor to visor request status
@@ -205,12 +351,97 @@ func (m *Node) getUptime() http.HandlerFunc {
 	})
 }
 
+// returns the events of the node, optionally filtered by the 'from' and 'to' RFC3339 times and the 'type' of events
+func (m *Node) getEvents() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		var filter visor.EventFilter
+		for key, t := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+			v := r.URL.Query().Get(key)
+			if v == "" {
+				continue
+			}
+			parsed, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid '%s': %v", key, err))
+				return
+			}
+			*t = parsed
+		}
+		for _, t := range r.URL.Query()["type"] {
+			filter.Types = append(filter.Types, visor.EventType(t))
+		}
+		events, err := ctx.RPC.Events(filter)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, events)
+	})
+}
+
+// returns the public keys of the trusted visors of the node
+func (m *Node) getTrustedVisors() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		pks, err := ctx.RPC.TrustedVisors()
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, pks)
+	})
+}
+
+// TrustedVisorsRequest is the request body of trusting visors.
+type TrustedVisorsRequest struct {
+	PubKeys []cipher.PubKey `json:"public_keys"`
+}
+
+// trusts the visors of the public keys of the request body
+func (m *Node) postTrustedVisors() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		var reqBody TrustedVisorsRequest
+		if err := httputil.ReadJSON(r, &reqBody); err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := ctx.RPC.AddTrustedVisors(reqBody.PubKeys...); err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, true)
+	})
+}
+
+// no longer trusts a visor
+func (m *Node) deleteTrustedVisor() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		pk, err := pkFromParam(r, "trusted")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := ctx.RPC.RemoveTrustedVisors(pk); err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, true)
+	})
+}
+
+// ExecRequest is the request body of executing a command on a node.
+type ExecRequest struct {
+	Command string `json:"command"`
+}
+
+// ExecResp is the output of a command executed on a node.
+type ExecResp struct {
+	Output string `json:"output"`
+}
+
 // executes a command and returns its output
 func (m *Node) exec() http.HandlerFunc {
 	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
-		var reqBody struct {
-			Command string `json:"command"`
-		}
+		var reqBody ExecRequest
 		if err := httputil.ReadJSON(r, &reqBody); err != nil {
 			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
 			return
@@ -222,38 +453,85 @@ func (m *Node) exec() http.HandlerFunc {
 			return
 		}
 
-		output := struct {
-			Output string `json:"output"`
-		}{string(out)}
-		httputil.WriteJSON(w, r, http.StatusOK, output)
+		httputil.WriteJSON(w, r, http.StatusOK, ExecResp{Output: string(out)})
+	})
+}
+
+// provides the status of the connection of a node to each of its hypervisors.
+func (m *Node) getHypervisors() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		statuses, err := ctx.RPC.Hypervisors()
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, statuses)
 	})
 }
 
-type summaryResp struct {
-	TCPAddr string `json:"tcp_addr"`
-	Online  bool   `json:"online"`
+// SummaryResp is the summary of a node.
+type SummaryResp struct {
+	TCPAddr string   `json:"tcp_addr"`
+	Online  bool     `json:"online"`
+	Tags    []string `json:"tags,omitempty"`
 	*visor.Summary
 }
 
-// provides summary of all nodes.
+// provides summary of all nodes, or of the nodes with all tags of the 'tag' queries. Nodes may be filtered by the
+// 'online' query and the 'version' queries, sorted by the 'sort' query, and paginated by the 'offset' and 'limit'
+// queries.
 func (m *Node) getNodes() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var summaries []summaryResp
+		lq, err := parseListQuery(r, "pk", "tcp_addr", "online", "version", "transports")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		online, err := boolFromQuery(r, "online")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		versions := strSliceFromQuery(r, "version", nil)
+
+		var summaries []SummaryResp
+		want := r.URL.Query()["tag"]
+		allTags := m.tags.AllTags()
 		m.mu.RLock()
 		for pk, c := range m.nodes {
+			tags := allTags[pk]
+			if !hasTags(tags, want) {
+				continue
+			}
 			summary, err := c.Client.Summary()
 			if err != nil {
 				log.Printf("failed to obtain summary from AppNode with pk %s. Error: %v", pk, err)
 				summary = &visor.Summary{PubKey: pk}
 			}
-			summaries = append(summaries, summaryResp{
+			if online != nil && *online != (err == nil) {
+				continue
+			}
+			if len(versions) > 0 && !hasTags(versions, []string{summary.NodeVersion}) {
+				continue
+			}
+			summaries = append(summaries, SummaryResp{
 				TCPAddr: c.Addr.Addr.String(),
 				Online:  err == nil,
+				Tags:    m.sharedTags(pk, tags, summary.SharedState),
 				Summary: summary,
 			})
 		}
 		m.mu.RUnlock()
-		httputil.WriteJSON(w, r, http.StatusOK, summaries)
+		lo, hi := lq.page(w, summaries, sortKeys{
+			"pk": func(i, j int) bool {
+				return bytes.Compare(summaries[i].PubKey[:], summaries[j].PubKey[:]) < 0
+			},
+			"tcp_addr":   func(i, j int) bool { return summaries[i].TCPAddr < summaries[j].TCPAddr },
+			"online":     func(i, j int) bool { return !summaries[i].Online && summaries[j].Online },
+			"version":    func(i, j int) bool { return summaries[i].NodeVersion < summaries[j].NodeVersion },
+			"transports": func(i, j int) bool { return len(summaries[i].Transports) < len(summaries[j].Transports) },
+		})
+		httputil.WriteJSON(w, r, http.StatusOK, summaries[lo:hi])
 	}
 }
 
@@ -265,22 +543,49 @@ func (m *Node) getNode() http.HandlerFunc {
 			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
 			return
 		}
-		httputil.WriteJSON(w, r, http.StatusOK, summaryResp{
+		m.mu.RLock()
+		tags := m.sharedTags(ctx.PK, m.tags.Tags(ctx.PK), summary.SharedState)
+		m.mu.RUnlock()
+		httputil.WriteJSON(w, r, http.StatusOK, SummaryResp{
 			TCPAddr: ctx.Addr.Addr.String(),
+			Tags:    tags,
 			Summary: summary,
 		})
 	})
 }
 
 // returns app summaries of a given node of pk
+// provides the apps of a node, which may be filtered by the 'running' query, sorted by the 'sort' query, and
+// paginated by the 'offset' and 'limit' queries.
 func (m *Node) getApps() http.HandlerFunc {
 	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
-		apps, err := ctx.RPC.Apps()
+		lq, err := parseListQuery(r, "name", "port", "status")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		running, err := boolFromQuery(r, "running")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		all, err := ctx.RPC.Apps()
 		if err != nil {
 			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
 			return
 		}
-		httputil.WriteJSON(w, r, http.StatusOK, apps)
+		apps := all[:0]
+		for _, app := range all {
+			if running == nil || *running == (app.Status == visor.AppStatusRunning) {
+				apps = append(apps, app)
+			}
+		}
+		lo, hi := lq.page(w, apps, sortKeys{
+			"name":   func(i, j int) bool { return apps[i].Name < apps[j].Name },
+			"port":   func(i, j int) bool { return apps[i].Port < apps[j].Port },
+			"status": func(i, j int) bool { return apps[i].Status < apps[j].Status },
+		})
+		httputil.WriteJSON(w, r, http.StatusOK, apps[lo:hi])
 	})
 }
 
@@ -291,12 +596,15 @@ func (m *Node) getApp() http.HandlerFunc {
 	})
 }
 
+// AppRequest is the request body of updating an app. Unset fields are left unchanged.
+type AppRequest struct {
+	Autostart *bool `json:"autostart,omitempty"`
+	Status    *int  `json:"status,omitempty"` // 1 to start the app, 0 to stop it.
+}
+
 func (m *Node) putApp() http.HandlerFunc {
 	return m.withCtx(m.appCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
-		var reqBody struct {
-			Autostart *bool `json:"autostart,omitempty"`
-			Status    *int  `json:"status,omitempty"`
-		}
+		var reqBody AppRequest
 		if err := httputil.ReadJSON(r, &reqBody); err != nil {
 			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
 			return
@@ -364,6 +672,62 @@ func (m *Node) appLogsSince() http.HandlerFunc {
 	})
 }
 
+// streamLogs streams the logs of a node, or of the app given by the 'app' query, over a WebSocket. The last
+// 'lines' lines (visor.DefaultTailLines by default) are sent first, followed by new lines as they are logged,
+// one line per text message.
+func (m *Node) streamLogs() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		in := visor.TailLogsIn{App: r.URL.Query().Get("app")}
+		if v := r.URL.Query().Get("lines"); v != "" {
+			lines, err := strconv.Atoi(v)
+			if err != nil || lines < 0 {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid 'lines' query: %s", v))
+				return
+			}
+			in.Lines = lines
+		}
+
+		ws, err := httputil.UpgradeWebSocket(w, r)
+		if err != nil {
+			log.WithError(err).Warn("Failed to upgrade log stream to websocket")
+			return
+		}
+
+		// Messages of the client are discarded, and reading fails once the client is gone.
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if _, _, err := ws.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-done:
+				_ = ws.Close(httputil.WebSocketNormalClosure, "") //nolint:errcheck
+				return
+			default:
+			}
+			out, err := ctx.RPC.TailLogs(in)
+			if err != nil {
+				_ = ws.Close(httputil.WebSocketInternalError, err.Error()) //nolint:errcheck
+				return
+			}
+			for _, line := range out.Lines {
+				if err := ws.WriteText([]byte(line)); err != nil {
+					_ = ws.Close(httputil.WebSocketNormalClosure, "") //nolint:errcheck
+					return
+				}
+			}
+			// Following the first lines, all new lines are sent.
+			in.After, in.Lines = out.Next, math.MaxInt32
+		}
+	})
+}
+
 func (m *Node) getTransportTypes() http.HandlerFunc {
 	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
 		types, err := ctx.RPC.TransportTypes()
@@ -375,14 +739,20 @@ func (m *Node) getTransportTypes() http.HandlerFunc {
 	})
 }
 
+// provides the transports of a node, which may be filtered by the 'type', 'pk' and 'label' queries, sorted by the
+// 'sort' query, and paginated by the 'offset' and 'limit' queries.
 func (m *Node) getTransports() http.HandlerFunc {
 	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		lq, err := parseListQuery(r, "id", "remote", "type", "sent", "recv")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
 		var (
 			qTypes []string
 			qPKs   []cipher.PubKey
 			qLogs  bool
 		)
-		var err error
 		qTypes = strSliceFromQuery(r, "type", nil)
 		if qPKs, err = pkSliceFromQuery(r, "pk", nil); err != nil {
 			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
@@ -392,27 +762,57 @@ func (m *Node) getTransports() http.HandlerFunc {
 			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
 			return
 		}
-		transports, err := ctx.RPC.Transports(qTypes, qPKs, qLogs)
+		all, err := ctx.RPC.Transports(qTypes, qPKs, qLogs)
 		if err != nil {
 			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
 			return
 		}
-		httputil.WriteJSON(w, r, http.StatusOK, transports)
+		labels := strSliceFromQuery(r, "label", nil)
+		transports := all[:0]
+		for _, tp := range all {
+			if hasTags(tp.Labels, labels) {
+				transports = append(transports, tp)
+			}
+		}
+		bytesOf := func(tp *visor.TransportSummary, sent bool) uint64 {
+			switch {
+			case tp.Log == nil:
+				return 0
+			case sent:
+				return tp.Log.SentBytes
+			default:
+				return tp.Log.RecvBytes
+			}
+		}
+		lo, hi := lq.page(w, transports, sortKeys{
+			"id": func(i, j int) bool { return bytes.Compare(transports[i].ID[:], transports[j].ID[:]) < 0 },
+			"remote": func(i, j int) bool {
+				return bytes.Compare(transports[i].Remote[:], transports[j].Remote[:]) < 0
+			},
+			"type": func(i, j int) bool { return transports[i].Type < transports[j].Type },
+			"sent": func(i, j int) bool { return bytesOf(transports[i], true) < bytesOf(transports[j], true) },
+			"recv": func(i, j int) bool { return bytesOf(transports[i], false) < bytesOf(transports[j], false) },
+		})
+		httputil.WriteJSON(w, r, http.StatusOK, transports[lo:hi])
 	})
 }
 
+// TransportRequest is the request body of adding a transport.
+type TransportRequest struct {
+	Remote cipher.PubKey `json:"remote_pk"`
+	TpType string        `json:"transport_type"`
+	Public bool          `json:"public"`
+	Labels []string      `json:"labels"`
+}
+
 func (m *Node) postTransport() http.HandlerFunc {
 	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
-		var reqBody struct {
-			Remote cipher.PubKey `json:"remote_pk"`
-			TpType string        `json:"transport_type"`
-			Public bool          `json:"public"`
-		}
+		var reqBody TransportRequest
 		if err := httputil.ReadJSON(r, &reqBody); err != nil {
 			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
 			return
 		}
-		summary, err := ctx.RPC.AddTransport(reqBody.Remote, reqBody.TpType, reqBody.Public, 30*time.Second)
+		summary, err := ctx.RPC.AddTransport(reqBody.Remote, reqBody.TpType, reqBody.Public, 30*time.Second, reqBody.Labels)
 		if err != nil {
 			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
 			return
@@ -437,14 +837,46 @@ func (m *Node) deleteTransport() http.HandlerFunc {
 	})
 }
 
-type routingRuleResp struct {
+func (m *Node) putTransportLabels() http.HandlerFunc {
+	return m.withCtx(m.tpCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		var labels []string
+		if err := httputil.ReadJSON(r, &labels); err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if _, err := transport.NormalizeLabels(labels); err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		summary, err := ctx.RPC.SetTransportLabels(ctx.Tp.ID, labels)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, summary)
+	})
+}
+
+func (m *Node) getTransportStats() http.HandlerFunc {
+	return m.withCtx(m.tpCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		stats, err := ctx.RPC.TransportStats(ctx.Tp.ID)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, stats)
+	})
+}
+
+// RoutingRuleResp is a routing rule of a node, with the summary of the rule if requested.
+type RoutingRuleResp struct {
 	Key     routing.RouteID      `json:"key"`
 	Rule    string               `json:"rule"`
 	Summary *routing.RuleSummary `json:"rule_summary,omitempty"`
 }
 
-func makeRoutingRuleResp(key routing.RouteID, rule routing.Rule, summary bool) routingRuleResp {
-	resp := routingRuleResp{
+func makeRoutingRuleResp(key routing.RouteID, rule routing.Rule, summary bool) RoutingRuleResp {
+	resp := RoutingRuleResp{
 		Key:  key,
 		Rule: hex.EncodeToString(rule),
 	}
@@ -454,21 +886,43 @@ func makeRoutingRuleResp(key routing.RouteID, rule routing.Rule, summary bool) r
 	return resp
 }
 
+// provides the routing rules of a node, which may be filtered by the 'type' queries, sorted by the 'sort' query, and
+// paginated by the 'offset' and 'limit' queries.
 func (m *Node) getRoutes() http.HandlerFunc {
 	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		lq, err := parseListQuery(r, "key", "type")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
 		qSummary, err := httputil.BoolFromQuery(r, "summary", false)
 		if err != nil {
 			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
 			return
 		}
-		rules, err := ctx.RPC.RoutingRules()
+		all, err := ctx.RPC.RoutingRules()
 		if err != nil {
 			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
 			return
 		}
-		resp := make([]routingRuleResp, len(rules))
-		for i, rule := range rules {
-			resp[i] = makeRoutingRuleResp(rule.Key, rule.Value, qSummary)
+		types := strSliceFromQuery(r, "type", nil)
+		rules := all[:0]
+		for _, rule := range all {
+			match := len(types) == 0
+			for _, t := range types {
+				match = match || strings.EqualFold(t, rule.Value.Type().String())
+			}
+			if match {
+				rules = append(rules, rule)
+			}
+		}
+		lo, hi := lq.page(w, rules, sortKeys{
+			"key":  func(i, j int) bool { return rules[i].Key < rules[j].Key },
+			"type": func(i, j int) bool { return rules[i].Value.Type() < rules[j].Value.Type() },
+		})
+		resp := make([]RoutingRuleResp, 0, hi-lo)
+		for _, rule := range rules[lo:hi] {
+			resp = append(resp, makeRoutingRuleResp(rule.Key, rule.Value, qSummary))
 		}
 		httputil.WriteJSON(w, r, http.StatusOK, resp)
 	})
@@ -541,16 +995,48 @@ func (m *Node) deleteRoute() http.HandlerFunc {
 	})
 }
 
-type loopResp struct {
+// looks up routes between the node and the 'dst' node via the route finder, without setting them up.
+func (m *Node) getFindRoutes() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		in := visor.FindRoutesIn{MaxHops: visor.DefaultMaxHops}
+		if err := in.Dst.Set(r.URL.Query().Get("dst")); err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid 'dst': %v", err))
+			return
+		}
+		for key, v := range map[string]*uint16{"min_hops": &in.MinHops, "max_hops": &in.MaxHops} {
+			if q := r.URL.Query().Get(key); q != "" {
+				n, err := strconv.ParseUint(q, 10, 16)
+				if err != nil {
+					httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid '%s': %s", key, q))
+					return
+				}
+				*v = uint16(n)
+			}
+		}
+		if in.MinHops > in.MaxHops {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, errors.New("'min_hops' exceeds 'max_hops'"))
+			return
+		}
+		out, err := ctx.RPC.FindRoutes(in)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadGateway, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, out)
+	})
+}
+
+// LoopResp is a loop of a node.
+type LoopResp struct {
 	routing.RuleAppFields
 	FwdRule routing.RuleForwardFields `json:"resp"`
 }
 
-func makeLoopResp(info visor.LoopInfo) loopResp {
+func makeLoopResp(info visor.LoopInfo) LoopResp {
 	if len(info.FwdRule) == 0 || len(info.AppRule) == 0 {
-		return loopResp{}
+		return LoopResp{}
 	}
-	return loopResp{
+	return LoopResp{
 		RuleAppFields: *info.AppRule.Summary().AppFields,
 		FwdRule:       *info.FwdRule.Summary().ForwardFields,
 	}
@@ -563,7 +1049,7 @@ func (m *Node) getLoops() http.HandlerFunc {
 			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
 			return
 		}
-		resp := make([]loopResp, len(loops))
+		resp := make([]LoopResp, len(loops))
 		for i, l := range loops {
 			resp[i] = makeLoopResp(l)
 		}
@@ -13,9 +13,12 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
 )
 
 func TestMain(m *testing.M) {
@@ -388,6 +391,620 @@ func TestNewNode(t *testing.T) {
 			},
 		})
 	})
+
+	t.Run("api_tokens", func(t *testing.T) {
+		addr, client, stop := startNode(defaultMockConfig())
+		defer stop()
+
+		pk, _ := cipher.GenerateKeyPair()
+		var token struct {
+			Token string `json:"token"`
+			APIToken
+		}
+		bearer := func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+token.Token)
+		}
+		errorIs := func(want error) func(t *testing.T, r *http.Response) {
+			return func(t *testing.T, r *http.Response) {
+				body, err := decodeErrorBody(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, want.Error(), body.Error)
+			}
+		}
+
+		testCases(t, addr, client, []TestCase{
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/create-account",
+				ReqBody:    strings.NewReader(`{"username":"admin","password":"Secure1234"}`),
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/login",
+				ReqBody:    strings.NewReader(`{"username":"admin","password":"Secure1234"}`),
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/tokens",
+				ReqBody:    strings.NewReader(`{"name":"monitoring","expires_in":"720h"}`),
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&token))
+					assert.True(t, strings.HasPrefix(token.Token, tokenPrefix))
+					assert.Equal(t, []string{TokenScopeRead}, token.Scopes)
+					assert.False(t, token.Expiry.IsZero())
+				},
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/tokens",
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					body, err := ioutil.ReadAll(r.Body)
+					require.NoError(t, err)
+					assert.Contains(t, string(body), token.ID.String())
+					assert.NotContains(t, string(body), token.Token)
+				},
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes",
+				ReqMod:     bearer,
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/change-password",
+				ReqMod:     bearer,
+				RespStatus: http.StatusForbidden,
+				RespBody:   errorIs(ErrTokenScope),
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/tokens",
+				ReqMod:     bearer,
+				RespStatus: http.StatusForbidden,
+				RespBody:   errorIs(ErrTokenForbidden),
+			},
+			{
+				// Routes which hold connections open require the write scope, whatever their method.
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes/" + pk.Hex() + "/logs/stream",
+				ReqMod:     bearer,
+				RespStatus: http.StatusForbidden,
+				RespBody:   errorIs(ErrTokenScope),
+			},
+			{
+				ReqMethod: http.MethodGet,
+				ReqURI:    "/api/nodes",
+				ReqMod: func(req *http.Request) {
+					req.Header.Set("Authorization", "Bearer "+tokenPrefix+"invalid")
+				},
+				RespStatus: http.StatusUnauthorized,
+				RespBody:   errorIs(ErrBadToken),
+			},
+		})
+
+		// The token is revoked with the session.
+		testCases(t, addr, client, []TestCase{
+			{
+				ReqMethod:  http.MethodDelete,
+				ReqURI:     "/api/tokens/" + token.ID.String(),
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodDelete,
+				ReqURI:     "/api/tokens/" + token.ID.String(),
+				RespStatus: http.StatusNotFound,
+				RespBody:   errorIs(ErrTokenNotFound),
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes",
+				ReqMod:     bearer,
+				RespStatus: http.StatusUnauthorized,
+				RespBody:   errorIs(ErrBadToken),
+			},
+		})
+	})
+
+	t.Run("user_roles", func(t *testing.T) {
+		addr, client, stop := startNode(defaultMockConfig())
+		defer stop()
+
+		jar, err := cookiejar.New(&cookiejar.Options{})
+		require.NoError(t, err)
+		viewer := &http.Client{Transport: client.Transport, Jar: jar}
+		errorIs := func(want error) func(t *testing.T, r *http.Response) {
+			return func(t *testing.T, r *http.Response) {
+				body, err := decodeErrorBody(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, want.Error(), body.Error)
+			}
+		}
+
+		testCases(t, addr, client, []TestCase{
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/create-account",
+				ReqBody:    strings.NewReader(`{"username":"admin","password":"Secure1234"}`),
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/login",
+				ReqBody:    strings.NewReader(`{"username":"admin","password":"Secure1234"}`),
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/users",
+				ReqBody:    strings.NewReader(`{"username":"viewer","password":"Secure1234","role":"root"}`),
+				RespStatus: http.StatusBadRequest,
+				RespBody:   errorIs(ErrBadRole),
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/users",
+				ReqBody:    strings.NewReader(`{"username":"viewer","password":"Secure1234","role":"read-only"}`),
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/users",
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					var users []UserSummary
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&users))
+					assert.ElementsMatch(t, []UserSummary{
+						{Username: "admin", Role: RoleAdmin},
+						{Username: "viewer", Role: RoleReadOnly},
+					}, users)
+				},
+			},
+		})
+		testCases(t, addr, viewer, []TestCase{
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/login",
+				ReqBody:    strings.NewReader(`{"username":"viewer","password":"Secure1234"}`),
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes",
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/nodes/invalid/transports",
+				RespStatus: http.StatusForbidden,
+				RespBody:   errorIs(ErrRoleForbidden),
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/exec/invalid",
+				RespStatus: http.StatusForbidden,
+				RespBody:   errorIs(ErrRoleForbidden),
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/users",
+				RespStatus: http.StatusForbidden,
+				RespBody:   errorIs(ErrRoleForbidden),
+			},
+		})
+		testCases(t, addr, client, []TestCase{
+			{
+				ReqMethod:  http.MethodPut,
+				ReqURI:     "/api/users/viewer",
+				ReqBody:    strings.NewReader(`{"role":"operator"}`),
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodPut,
+				ReqURI:     "/api/users/admin",
+				ReqBody:    strings.NewReader(`{"role":"operator"}`),
+				RespStatus: http.StatusForbidden,
+				RespBody:   errorIs(ErrModifySelf),
+			},
+		})
+		testCases(t, addr, viewer, []TestCase{
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/nodes/invalid/transports",
+				RespStatus: http.StatusBadRequest, // passes the role check.
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/exec/invalid",
+				RespStatus: http.StatusForbidden,
+				RespBody:   errorIs(ErrRoleForbidden),
+			},
+		})
+		testCases(t, addr, client, []TestCase{
+			{
+				ReqMethod:  http.MethodDelete,
+				ReqURI:     "/api/users/admin",
+				RespStatus: http.StatusForbidden,
+				RespBody:   errorIs(ErrModifySelf),
+			},
+			{
+				ReqMethod:  http.MethodDelete,
+				ReqURI:     "/api/users/viewer",
+				RespStatus: http.StatusOK,
+			},
+		})
+		testCases(t, addr, viewer, []TestCase{
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes",
+				RespStatus: http.StatusUnauthorized,
+			},
+		})
+	})
+
+	t.Run("visor_tags", func(t *testing.T) {
+		mock := defaultMockConfig()
+		mock.EnableAuth = false
+		addr, client, stop := startNode(mock)
+		defer stop()
+
+		var nodes []SummaryResp
+		listNodes := func(query string, want int) TestCase {
+			return TestCase{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes" + query,
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					nodes = nil
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&nodes))
+					assert.Len(t, nodes, want, query)
+				},
+			}
+		}
+		testCases(t, addr, client, []TestCase{listNodes("", mock.Nodes)})
+		pk1, pk2 := nodes[0].PubKey.String(), nodes[1].PubKey.String()
+
+		testCases(t, addr, client, []TestCase{
+			{
+				ReqMethod:  http.MethodPut,
+				ReqURI:     "/api/nodes/" + pk1 + "/tags",
+				ReqBody:    strings.NewReader(`["site:berlin","rpi","rpi"]`),
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodPut,
+				ReqURI:     "/api/nodes/" + pk2 + "/tags",
+				ReqBody:    strings.NewReader(`["site:berlin"]`),
+				RespStatus: http.StatusOK,
+			},
+			{
+				ReqMethod:  http.MethodPut,
+				ReqURI:     "/api/nodes/" + pk2 + "/tags",
+				ReqBody:    strings.NewReader(`["has space"]`),
+				RespStatus: http.StatusBadRequest,
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes/" + pk1 + "/tags",
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					var tags []string
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&tags))
+					assert.Equal(t, []string{"rpi", "site:berlin"}, tags)
+				},
+			},
+			listNodes("?tag=site:berlin", 2),
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes/" + pk1 + "/hypervisors",
+				RespStatus: http.StatusOK,
+			},
+			listNodes("?tag=site:berlin&tag=rpi", 1),
+			listNodes("?tag=unknown", 0),
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/tags",
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					var byTag map[string][]string
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&byTag))
+					assert.ElementsMatch(t, []string{pk1, pk2}, byTag["site:berlin"])
+					assert.Equal(t, []string{pk1}, byTag["rpi"])
+				},
+			},
+		})
+	})
+
+	t.Run("bulk_actions", func(t *testing.T) {
+		mock := defaultMockConfig()
+		mock.EnableAuth = false
+		addr, client, stop := startNode(mock)
+		defer stop()
+
+		var nodes []SummaryResp
+		testCases(t, addr, client, []TestCase{{
+			ReqMethod:  http.MethodGet,
+			ReqURI:     "/api/nodes",
+			RespStatus: http.StatusOK,
+			RespBody: func(t *testing.T, r *http.Response) {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&nodes))
+			},
+		}})
+		pk := nodes[0].PubKey.String()
+		offline := "02b72766f0ebade8e06d6969b5aeedaff8bf8efd7867f362bb4a63135ab6009775"
+
+		bulk := func(body string, status int, check func(t *testing.T, resp BulkResponse)) TestCase {
+			tc := TestCase{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/bulk",
+				ReqBody:    strings.NewReader(body),
+				RespStatus: status,
+			}
+			if check != nil {
+				tc.RespBody = func(t *testing.T, r *http.Response) {
+					var resp BulkResponse
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&resp))
+					check(t, resp)
+				}
+			}
+			return tc
+		}
+		testCases(t, addr, client, []TestCase{
+			{
+				ReqMethod:  http.MethodPut,
+				ReqURI:     "/api/nodes/" + pk + "/tags",
+				ReqBody:    strings.NewReader(`["canary"]`),
+				RespStatus: http.StatusOK,
+			},
+			bulk(`{"action":"restart_app","params":{"app":"foo"},"all":true}`, http.StatusOK,
+				func(t *testing.T, resp BulkResponse) {
+					assert.Equal(t, mock.Nodes, resp.Succeeded)
+					assert.Len(t, resp.Results, mock.Nodes)
+				}),
+			bulk(`{"action":"restart_app","params":{"app":"foo"},"tags":["canary"]}`, http.StatusOK,
+				func(t *testing.T, resp BulkResponse) {
+					require.Len(t, resp.Results, 1)
+					assert.Equal(t, pk, resp.Results[0].PK.String())
+				}),
+			bulk(`{"action":"stop_app","params":{"app":"foo"},"pks":["`+pk+`","`+offline+`"]}`, http.StatusOK,
+				func(t *testing.T, resp BulkResponse) {
+					assert.Equal(t, 1, resp.Succeeded)
+					assert.Equal(t, 1, resp.Failed)
+					for _, res := range resp.Results {
+						if res.PK.String() == offline {
+							assert.Equal(t, ErrNotConnected.Error(), res.Error)
+						}
+					}
+				}),
+			bulk(`{"action":"set_log_level","params":{"level":"debug"},"pks":["`+pk+`"]}`, http.StatusOK,
+				func(t *testing.T, resp BulkResponse) {
+					require.Len(t, resp.Results, 1)
+					assert.False(t, resp.Results[0].OK)
+					assert.NotEmpty(t, resp.Results[0].Error)
+				}),
+			bulk(`{"action":"update","params":{"check_only":true},"pks":["`+pk+`"]}`, http.StatusOK,
+				func(t *testing.T, resp BulkResponse) {
+					require.Len(t, resp.Results, 1)
+					assert.True(t, resp.Results[0].OK)
+				}),
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes/" + pk + "/update",
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					var status visor.UpdateStatus
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+					assert.Equal(t, visor.UpdateUpToDate, status.State)
+				},
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/nodes/" + pk + "/update?check_only=maybe",
+				RespStatus: http.StatusBadRequest,
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     "/api/nodes/" + pk + "/update",
+				RespStatus: http.StatusAccepted,
+			},
+			bulk(`{"action":"restart_app","params":{},"all":true}`, http.StatusBadRequest, nil),
+			bulk(`{"action":"restart_app","params":{"app":"foo"}}`, http.StatusBadRequest, nil),
+			bulk(`{"action":"format_disk","all":true}`, http.StatusBadRequest, nil),
+		})
+	})
+
+	t.Run("aggregated_metrics", func(t *testing.T) {
+		mock := defaultMockConfig()
+		mock.EnableAuth = false
+		addr, client, stop := startNode(mock)
+		defer stop()
+
+		testCases(t, addr, client, []TestCase{{
+			ReqMethod:  http.MethodGet,
+			ReqURI:     "/metrics",
+			RespStatus: http.StatusOK,
+			RespBody: func(t *testing.T, r *http.Response) {
+				body, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, 1, strings.Count(string(body), "# TYPE skywire_visor_transports gauge"))
+				assert.Equal(t, mock.Nodes, strings.Count(string(body), "skywire_visor_transports{visor=\""))
+				assert.Equal(t, mock.Nodes, strings.Count(string(body), visorUpMetric+"{visor=\""))
+				assert.NotRegexp(t, visorUpMetric+`{.*} 0\n`, string(body))
+			},
+		}})
+	})
+
+	t.Run("config_editing", func(t *testing.T) {
+		mock := defaultMockConfig()
+		mock.EnableAuth = false
+		addr, client, stop := startNode(mock)
+		defer stop()
+
+		var nodes []SummaryResp
+		testCases(t, addr, client, []TestCase{{
+			ReqMethod:  http.MethodGet,
+			ReqURI:     "/api/nodes",
+			RespStatus: http.StatusOK,
+			RespBody: func(t *testing.T, r *http.Response) {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&nodes))
+			},
+		}})
+		confURI := "/api/nodes/" + nodes[0].PubKey.String() + "/config"
+
+		testCases(t, addr, client, []TestCase{
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     confURI,
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					var conf struct {
+						Node struct {
+							SK string `json:"static_secret_key"`
+						} `json:"node"`
+					}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&conf))
+					assert.Equal(t, visor.RedactedValue, conf.Node.SK)
+				},
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     confURI + "/diff",
+				ReqBody:    strings.NewReader(`{"log_level":"debug"}`),
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					var diff visor.ConfigDiff
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&diff))
+					require.Len(t, diff.Changes, 1)
+					assert.Equal(t, "log_level", diff.Changes[0].Field)
+					assert.Equal(t, []string{"log_level"}, diff.Reloadable)
+				},
+			},
+			{
+				ReqMethod:  http.MethodPost,
+				ReqURI:     confURI + "/diff",
+				ReqBody:    strings.NewReader(`"debug"`),
+				RespStatus: http.StatusBadRequest,
+			},
+			{
+				ReqMethod:  http.MethodPatch,
+				ReqURI:     confURI + "?restart=true",
+				ReqBody:    strings.NewReader(`{"log_level":"debug","apps_path":"./bin"}`),
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					var out visor.UpdateConfigOut
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&out))
+					assert.Equal(t, []string{"apps_path"}, out.RestartRequired)
+					assert.True(t, out.Restarting)
+				},
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     confURI,
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					var conf struct {
+						LogLevel string `json:"log_level"`
+					}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&conf))
+					assert.Equal(t, "debug", conf.LogLevel)
+				},
+			},
+		})
+	})
+
+	t.Run("transport_management", func(t *testing.T) {
+		mock := defaultMockConfig()
+		mock.EnableAuth = false
+		addr, client, stop := startNode(mock)
+		defer stop()
+
+		var nodes []SummaryResp
+		testCases(t, addr, client, []TestCase{{
+			ReqMethod:  http.MethodGet,
+			ReqURI:     "/api/nodes",
+			RespStatus: http.StatusOK,
+			RespBody: func(t *testing.T, r *http.Response) {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&nodes))
+			},
+		}})
+		pk := nodes[0].PubKey.String()
+		remote := "02b72766f0ebade8e06d6969b5aeedaff8bf8efd7867f362bb4a63135ab6009775"
+
+		var tp visor.TransportSummary
+		testCases(t, addr, client, []TestCase{{
+			ReqMethod:  http.MethodPost,
+			ReqURI:     "/api/nodes/" + pk + "/transports",
+			ReqBody:    strings.NewReader(`{"remote_pk":"` + remote + `","transport_type":"dmsg"}`),
+			RespStatus: http.StatusOK,
+			RespBody: func(t *testing.T, r *http.Response) {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&tp))
+				assert.Equal(t, remote, tp.Remote.String())
+			},
+		}})
+		tpURI := "/api/nodes/" + pk + "/transports/" + tp.ID.String()
+
+		testCases(t, addr, client, []TestCase{
+			{
+				ReqMethod:  http.MethodPut,
+				ReqURI:     tpURI + "/labels",
+				ReqBody:    strings.NewReader(`["backbone"]`),
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					var summary visor.TransportSummary
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&summary))
+					assert.Equal(t, []string{"backbone"}, summary.Labels)
+				},
+			},
+			{
+				ReqMethod:  http.MethodPut,
+				ReqURI:     tpURI + "/labels",
+				ReqBody:    strings.NewReader(`["has space"]`),
+				RespStatus: http.StatusBadRequest,
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     tpURI + "/stats",
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					var stats visor.TransportStats
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&stats))
+					assert.Equal(t, tp.ID, stats.ID)
+				},
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes/" + pk + "/routes/find?dst=" + remote,
+				RespStatus: http.StatusOK,
+				RespBody: func(t *testing.T, r *http.Response) {
+					var routes visor.FindRoutesOut
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&routes))
+					require.Len(t, routes.Forward, 1)
+					require.Len(t, routes.Forward[0], 1)
+					assert.Equal(t, tp.ID, routes.Forward[0][0].Transport)
+					assert.Len(t, routes.Reverse, 1)
+				},
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes/" + pk + "/routes/find?dst=" + remote + "&min_hops=3&max_hops=2",
+				RespStatus: http.StatusBadRequest,
+			},
+			{
+				ReqMethod:  http.MethodGet,
+				ReqURI:     "/api/nodes/" + pk + "/routes/find",
+				RespStatus: http.StatusBadRequest,
+			},
+			{
+				ReqMethod:  http.MethodDelete,
+				ReqURI:     tpURI,
+				RespStatus: http.StatusOK,
+			},
+		})
+	})
 }
 
 type ErrorBody struct {
@@ -0,0 +1,286 @@
+package hypervisor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/geo"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+const (
+	// geoIPTimeout bounds the geo-IP lookups of a request of the map.
+	geoIPTimeout = 5 * time.Second
+
+	// geoIPRetry is the time after which failed geo-IP lookups of an address are retried.
+	geoIPRetry = time.Hour
+
+	// mapClusterCell is the size in pixels of the cells of the map within which visors are clustered.
+	mapClusterCell = 64
+
+	// maxMapZoom is the maximum zoom level of the map.
+	maxMapZoom = 22
+
+	// maxMercatorLat is the latitude of the edges of the web mercator map.
+	maxMercatorLat = 85.05112878
+)
+
+// Sources of the locations of visors.
+const (
+	LocationReported = "reported" // self-reported by the visor.
+	LocationGeoIP    = "geo_ip"   // geo-IP lookup of the address of the connection of the visor.
+)
+
+// MapConfig configures the map of visors.
+type MapConfig struct {
+	// GeoIP places visors which report no location by geo-IP lookups of the addresses of their connections. The
+	// addresses are sent to the geo-IP service, so it is disabled by default.
+	GeoIP bool `json:"geo_ip,omitempty"`
+
+	// GeoIPURL is the geo-IP service, in which '{ip}' is replaced by the address. Defaults to geo.DefaultIPLookupURL.
+	GeoIPURL string `json:"geo_ip_url,omitempty"`
+}
+
+// FillDefaults fills the unset fields of the config with default values.
+func (c *MapConfig) FillDefaults() {
+	if c.GeoIPURL == "" {
+		c.GeoIPURL = geo.DefaultIPLookupURL
+	}
+}
+
+// MapVisor is a visor placed on the map.
+type MapVisor struct {
+	PK     cipher.PubKey `json:"pk"`
+	Online bool          `json:"online"`
+	Lat    float64       `json:"lat"`
+	Lon    float64       `json:"lon"`
+	Region string        `json:"region,omitempty"`
+	Source string        `json:"source"` // LocationReported or LocationGeoIP.
+}
+
+// MapCluster is a group of visors which are close to each other at the zoom level of the map.
+type MapCluster struct {
+	Lat    float64         `json:"lat"` // average of the visors.
+	Lon    float64         `json:"lon"`
+	Count  int             `json:"count"`
+	Online int             `json:"online"` // number of online visors.
+	Visors []cipher.PubKey `json:"visors"`
+}
+
+// MapResp is the map of the visors.
+type MapResp struct {
+	Visors   []MapVisor      `json:"visors"`             // visors which are not clustered.
+	Clusters []MapCluster    `json:"clusters,omitempty"` // only if clustering.
+	Unplaced []cipher.PubKey `json:"unplaced,omitempty"` // visors of unknown location.
+}
+
+// geoIPResult is the result of a geo-IP lookup of an address.
+type geoIPResult struct {
+	loc *geo.Location // nil if the lookup failed.
+	at  time.Time
+}
+
+// geoLocator places visors on the map, and remembers where visors were last placed so that offline visors may be
+// placed too.
+type geoLocator struct {
+	conf   MapConfig
+	lookup func(ctx context.Context, url string, ip net.IP) (*geo.Location, error)
+	last   map[cipher.PubKey]MapVisor
+	byIP   map[string]geoIPResult
+	mx     sync.Mutex
+}
+
+func newGeoLocator(conf *MapConfig) *geoLocator {
+	g := &geoLocator{
+		lookup: geo.LookupIP,
+		last:   make(map[cipher.PubKey]MapVisor),
+		byIP:   make(map[string]geoIPResult),
+	}
+	if conf != nil {
+		g.conf = *conf
+	}
+	g.conf.FillDefaults()
+	return g
+}
+
+// lookupIP returns the location of the address, looking it up if it is not cached.
+func (g *geoLocator) lookupIP(ctx context.Context, ip net.IP, now time.Time) *geo.Location {
+	g.mx.Lock()
+	res, ok := g.byIP[ip.String()]
+	g.mx.Unlock()
+	if ok && (res.loc != nil || now.Sub(res.at) < geoIPRetry) {
+		return res.loc
+	}
+	loc, err := g.lookup(ctx, g.conf.GeoIPURL, ip)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to locate %s", ip)
+		loc = nil
+	}
+	g.mx.Lock()
+	g.byIP[ip.String()] = geoIPResult{loc: loc, at: now}
+	g.mx.Unlock()
+	return loc
+}
+
+// locate places the visor by its reported location, or the geo-IP location of its address if it reports no
+// coordinates. Visors which can not be placed are placed where they were last placed, if ever.
+func (g *geoLocator) locate(ctx context.Context, v MapVisor, reported *geo.Location, ip net.IP,
+	now time.Time) (MapVisor, bool) {
+	switch {
+	case reported != nil && (reported.Lat != 0 || reported.Lon != 0):
+		v.Lat, v.Lon, v.Region, v.Source = reported.Lat, reported.Lon, reported.Region, LocationReported
+	case g.conf.GeoIP && geo.Locatable(ip):
+		loc := g.lookupIP(ctx, ip, now)
+		if loc == nil {
+			return g.lastPlace(v)
+		}
+		v.Lat, v.Lon, v.Region, v.Source = loc.Lat, loc.Lon, loc.Region, LocationGeoIP
+		if reported != nil && reported.Region != "" {
+			v.Region = reported.Region
+		}
+	default:
+		return g.lastPlace(v)
+	}
+	g.mx.Lock()
+	g.last[v.PK] = v
+	g.mx.Unlock()
+	return v, true
+}
+
+func (g *geoLocator) lastPlace(v MapVisor) (MapVisor, bool) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	last, ok := g.last[v.PK]
+	if !ok {
+		return v, false
+	}
+	last.Online = v.Online
+	return last, true
+}
+
+// connIP returns the IP address of the connection of a visor, or nil if it has none, such as over dmsg.
+func connIP(addr net.Addr) net.IP {
+	if addr == nil {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+// provides the locations of the visors, or of those with all tags of the 'tag' queries, with whether they are
+// online. Visors are clustered within cells of the map at the zoom level of the 'zoom' query, if set.
+func (m *Node) getMap() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zoom := -1
+		if v := r.URL.Query().Get("zoom"); v != "" {
+			z, err := strconv.Atoi(v)
+			if err != nil || z < 0 || z > maxMapZoom {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid 'zoom' query: %s", v))
+				return
+			}
+			zoom = z
+		}
+		pks := m.selectNodes(&BulkRequest{All: true, Tags: strSliceFromQuery(r, "tag", nil)})
+		results := m.applyBulk(pks, func(rpc visor.RPCClient) (interface{}, error) {
+			return rpc.Summary()
+		})
+
+		ctx, cancel := context.WithTimeout(r.Context(), geoIPTimeout)
+		defer cancel()
+		now := time.Now()
+		visors := make([]MapVisor, len(results))
+		placed := make([]bool, len(results))
+		sem := make(chan struct{}, bulkConcurrency)
+		var wg sync.WaitGroup
+		for i, res := range results {
+			var reported *geo.Location
+			if summary, ok := res.Result.(*visor.Summary); ok && res.OK {
+				reported = summary.Location
+			}
+			var ip net.IP
+			if addr, _, ok := m.client(res.PK); ok && addr != nil {
+				ip = connIP(addr.Addr)
+			}
+			wg.Add(1)
+			go func(i int, v MapVisor) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				visors[i], placed[i] = m.geo.locate(ctx, v, reported, ip, now)
+			}(i, MapVisor{PK: res.PK, Online: res.OK})
+		}
+		wg.Wait()
+
+		resp := MapResp{Visors: make([]MapVisor, 0, len(visors))}
+		for i, v := range visors {
+			if placed[i] {
+				resp.Visors = append(resp.Visors, v)
+			} else {
+				resp.Unplaced = append(resp.Unplaced, v.PK)
+			}
+		}
+		if zoom >= 0 {
+			resp.Visors, resp.Clusters = clusterVisors(resp.Visors, zoom)
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, resp)
+	}
+}
+
+// clusterVisors groups the visors within the same cells of the map at the zoom level, returning the visors which are
+// alone in their cells and the clusters.
+func clusterVisors(visors []MapVisor, zoom int) ([]MapVisor, []MapCluster) {
+	type cell struct{ x, y int64 }
+	var cells []cell
+	byCell := make(map[cell][]MapVisor)
+	for _, v := range visors {
+		x, y := mapPixel(v.Lat, v.Lon, zoom)
+		c := cell{int64(x / mapClusterCell), int64(y / mapClusterCell)}
+		if _, ok := byCell[c]; !ok {
+			cells = append(cells, c)
+		}
+		byCell[c] = append(byCell[c], v)
+	}
+
+	singles := make([]MapVisor, 0, len(visors))
+	var clusters []MapCluster
+	for _, c := range cells {
+		vs := byCell[c]
+		if len(vs) == 1 {
+			singles = append(singles, vs[0])
+			continue
+		}
+		cl := MapCluster{Count: len(vs), Visors: make([]cipher.PubKey, len(vs))}
+		for i, v := range vs {
+			cl.Lat += v.Lat / float64(len(vs))
+			cl.Lon += v.Lon / float64(len(vs))
+			cl.Visors[i] = v.PK
+			if v.Online {
+				cl.Online++
+			}
+		}
+		clusters = append(clusters, cl)
+	}
+	return singles, clusters
+}
+
+// mapPixel projects the coordinates to the pixels of the web mercator map at the zoom level.
+func mapPixel(lat, lon float64, zoom int) (x, y float64) {
+	size := 256 * math.Exp2(float64(zoom))
+	lat = math.Max(-maxMercatorLat, math.Min(maxMercatorLat, lat))
+	sin := math.Sin(lat * math.Pi / 180)
+	x = (lon + 180) / 360 * size
+	y = (0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)) * size
+	return x, y
+}
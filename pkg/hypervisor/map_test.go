@@ -0,0 +1,141 @@
+package hypervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/geo"
+)
+
+func TestGeoLocator_locate(t *testing.T) {
+	var lookups int
+	g := newGeoLocator(&MapConfig{GeoIP: true})
+	g.lookup = func(_ context.Context, url string, ip net.IP) (*geo.Location, error) {
+		assert.Equal(t, geo.DefaultIPLookupURL, url)
+		lookups++
+		if ip.Equal(net.IPv4(203, 0, 113, 1)) {
+			return &geo.Location{Lat: 52.52, Lon: 13.4, Region: "DE/Berlin"}, nil
+		}
+		return nil, errors.New("unknown address")
+	}
+	ctx, now := context.TODO(), time.Now()
+	pk, _ := cipher.GenerateKeyPair()
+	v := MapVisor{PK: pk, Online: true}
+	ip := net.IPv4(203, 0, 113, 1)
+
+	// Reported coordinates take precedence over geo-IP.
+	got, ok := g.locate(ctx, v, &geo.Location{Lat: 1, Lon: 2}, ip, now)
+	require.True(t, ok)
+	assert.Equal(t, MapVisor{PK: pk, Online: true, Lat: 1, Lon: 2, Source: LocationReported}, got)
+	assert.Equal(t, 0, lookups)
+
+	// Visors reporting no coordinates are located by geo-IP, which is cached.
+	for i := 0; i < 2; i++ {
+		got, ok = g.locate(ctx, v, &geo.Location{Region: "Home"}, ip, now)
+		require.True(t, ok)
+		assert.Equal(t, MapVisor{PK: pk, Online: true, Lat: 52.52, Lon: 13.4, Region: "Home", Source: LocationGeoIP}, got)
+	}
+	assert.Equal(t, 1, lookups)
+
+	// Visors which can not be located are placed where they were last placed.
+	got, ok = g.locate(ctx, MapVisor{PK: pk}, nil, nil, now)
+	require.True(t, ok)
+	assert.Equal(t, MapVisor{PK: pk, Lat: 52.52, Lon: 13.4, Region: "Home", Source: LocationGeoIP}, got)
+
+	// Failed lookups are retried later.
+	other, _ := cipher.GenerateKeyPair()
+	for _, at := range []time.Time{now, now.Add(time.Minute), now.Add(geoIPRetry)} {
+		_, ok = g.locate(ctx, MapVisor{PK: other}, nil, net.IPv4(198, 51, 100, 1), at)
+		assert.False(t, ok)
+	}
+	assert.Equal(t, 3, lookups)
+
+	// Private addresses, and addresses of visors without geo-IP, are not looked up.
+	_, ok = g.locate(ctx, MapVisor{PK: other}, nil, net.IPv4(10, 0, 0, 1), now)
+	assert.False(t, ok)
+	_, ok = newGeoLocator(nil).locate(ctx, MapVisor{PK: other}, nil, ip, now)
+	assert.False(t, ok)
+	assert.Equal(t, 3, lookups)
+}
+
+func TestClusterVisors(t *testing.T) {
+	pks := make([]cipher.PubKey, 3)
+	for i := range pks {
+		pks[i], _ = cipher.GenerateKeyPair()
+	}
+	visors := []MapVisor{
+		{PK: pks[0], Online: true, Lat: 52.52, Lon: 13.4},    // Berlin.
+		{PK: pks[1], Online: false, Lat: 52.39, Lon: 13.06},  // Potsdam.
+		{PK: pks[2], Online: true, Lat: -33.87, Lon: 151.21}, // Sydney.
+	}
+
+	singles, clusters := clusterVisors(visors, 2)
+	assert.Equal(t, []MapVisor{visors[2]}, singles)
+	require.Len(t, clusters, 1)
+	assert.Equal(t, 2, clusters[0].Count)
+	assert.Equal(t, 1, clusters[0].Online)
+	assert.Equal(t, []cipher.PubKey{pks[0], pks[1]}, clusters[0].Visors)
+	assert.InDelta(t, 52.455, clusters[0].Lat, 1e-9)
+	assert.InDelta(t, 13.23, clusters[0].Lon, 1e-9)
+
+	singles, clusters = clusterVisors(visors, 12)
+	assert.Equal(t, visors, singles)
+	assert.Empty(t, clusters)
+}
+
+func TestNode_getMap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_map")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	m, err := NewNode(config)
+	require.NoError(t, err)
+	require.NoError(t, m.AddMockData(MockConfig{Nodes: 5, OfflineNodes: 1, Seed: 42}))
+
+	get := func(query string) (int, MapResp) {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/map"+query, nil))
+		var resp MapResp
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w.Code, resp
+	}
+
+	// Mock visors report their locations, except for the offline one, which was never placed.
+	code, resp := get("")
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, resp.Visors, 4)
+	assert.Len(t, resp.Unplaced, 1)
+	for _, v := range resp.Visors {
+		assert.True(t, v.Online)
+		assert.Equal(t, LocationReported, v.Source)
+	}
+
+	// Clustering keeps every placed visor.
+	code, resp = get("?zoom=0")
+	require.Equal(t, http.StatusOK, code)
+	n := len(resp.Visors)
+	for _, c := range resp.Clusters {
+		n += c.Count
+	}
+	assert.Equal(t, 4, n)
+
+	code, _ = get("?zoom=23")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
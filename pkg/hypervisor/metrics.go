@@ -0,0 +1,88 @@
+package hypervisor
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// visorLabel is added to the metrics of visors, with the public key of the visor as value.
+const visorLabel = "visor"
+
+// visorUpMetric reports whether the metrics of each connected visor were collected.
+const visorUpMetric = "skywire_hypervisor_visor_up"
+
+// provides the metrics of all connected nodes, labeled by node, in a Prometheus exposition format.
+func (m *Node) getMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results := m.applyBulk(m.selectNodes(&BulkRequest{All: true}), func(rpc visor.RPCClient) (interface{}, error) {
+			return rpc.Metrics()
+		})
+		families := aggregateMetrics(results)
+
+		format := expfmt.Negotiate(r.Header)
+		w.Header().Set("Content-Type", string(format))
+		enc := expfmt.NewEncoder(w, format)
+		for _, mf := range families {
+			if err := enc.Encode(mf); err != nil {
+				log.WithError(err).Warn("Failed to encode metrics")
+				return
+			}
+		}
+	}
+}
+
+// aggregateMetrics merges the metrics of the results of rpc.Metrics calls, labeling them by node. Nodes whose
+// metrics could not be collected are reported by visorUpMetric.
+func aggregateMetrics(results []BulkResult) []*dto.MetricFamily {
+	upName, upHelp, gauge := visorUpMetric, "Whether the metrics of the visor were collected.", dto.MetricType_GAUGE
+	up := &dto.MetricFamily{Name: &upName, Help: &upHelp, Type: &gauge}
+	byName := map[string]*dto.MetricFamily{upName: up}
+
+	for _, res := range results {
+		label, value := visorLabel, res.PK.Hex()
+		pair := &dto.LabelPair{Name: &label, Value: &value}
+
+		upValue := 0.0
+		if raw, ok := res.Result.([]byte); res.OK && ok {
+			parsed, err := new(expfmt.TextParser).TextToMetricFamilies(bytes.NewReader(raw))
+			if err != nil {
+				log.WithError(err).Warnf("Failed to parse metrics of node %s", res.PK)
+			} else {
+				upValue = 1
+				mergeMetrics(byName, parsed, pair)
+			}
+		}
+		up.Metric = append(up.Metric, &dto.Metric{Label: []*dto.LabelPair{pair}, Gauge: &dto.Gauge{Value: &upValue}})
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(byName))
+	for _, mf := range byName {
+		families = append(families, mf)
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].GetName() < families[j].GetName() })
+	return families
+}
+
+// mergeMetrics adds the metrics of a node, labeled with pair, to the families by name.
+func mergeMetrics(byName, parsed map[string]*dto.MetricFamily, pair *dto.LabelPair) {
+	for name, mf := range parsed {
+		for _, metric := range mf.Metric {
+			metric.Label = append(metric.Label, pair)
+		}
+		existing, ok := byName[name]
+		switch {
+		case !ok:
+			byName[name] = mf
+		case existing.GetType() == mf.GetType():
+			existing.Metric = append(existing.Metric, mf.Metric...)
+		default:
+			log.Warnf("Dropped metric %s of node %s: type differs from other nodes", name, pair.GetValue())
+		}
+	}
+}
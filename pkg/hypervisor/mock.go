@@ -0,0 +1,135 @@
+package hypervisor
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/rpc"
+	"path/filepath"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// DefaultMockChurnInterval is the default interval between the steps of mock mode.
+const DefaultMockChurnInterval = 30 * time.Second
+
+// MockChurn configures how mock nodes change at every step of mock mode.
+type MockChurn struct {
+	Interval   visor.Duration `json:"interval,omitempty"`   // between steps, defaults to DefaultMockChurnInterval.
+	Disconnect float64        `json:"disconnect,omitempty"` // chance of each online node to go offline per step.
+	Reconnect  float64        `json:"reconnect,omitempty"`  // chance of each offline node to come back online per step.
+}
+
+// ParseMockConfig parses the scenario file in path, such as:
+//
+//	{
+//	  "nodes": 20,
+//	  "max_transports_per_node": 10,
+//	  "max_routes_per_node": 30,
+//	  "seed": 42,
+//	  "offline_nodes": 3,
+//	  "failing_app_nodes": 2,
+//	  "churn": {"interval": "1m", "disconnect": 0.05, "reconnect": 0.5}
+//	}
+func ParseMockConfig(path string) (MockConfig, error) {
+	var config MockConfig
+	data, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return config, err
+	}
+	err = json.Unmarshal(data, &config)
+	return config, err
+}
+
+// mockNode is a node of mock mode.
+type mockNode struct {
+	conn       appNodeConn
+	offline    visor.RPCClient // used while the node is offline.
+	online     bool
+	failingApp string // app which crashes every step, if any.
+}
+
+// current returns the connection of the node, whose calls fail while the node is offline.
+func (v *mockNode) current() appNodeConn {
+	if v.online {
+		return v.conn
+	}
+	return appNodeConn{Addr: v.conn.Addr, Client: v.offline}
+}
+
+// offlineRPCClient returns a RPCClient whose calls fail, as with visors which lost their connection.
+func offlineRPCClient() visor.RPCClient {
+	conn, _ := net.Pipe()
+	catch(conn.Close())
+	return visor.NewRPCClient(rpc.NewClient(conn), visor.RPCPrefix)
+}
+
+// mockScenario changes the nodes of mock mode over time.
+type mockScenario struct {
+	r     *rand.Rand
+	churn MockChurn
+	nodes []*mockNode
+}
+
+func newMockScenario(r *rand.Rand, churn MockChurn) *mockScenario {
+	if churn.Interval <= 0 {
+		churn.Interval = visor.Duration(DefaultMockChurnInterval)
+	}
+	return &mockScenario{r: r, churn: churn}
+}
+
+// step changes the nodes as of now: nodes go offline or come back online by chance, and failing apps crash.
+func (s *mockScenario) step(now time.Time) {
+	for _, v := range s.nodes {
+		switch {
+		case v.online && s.r.Float64() < s.churn.Disconnect:
+			v.online = false
+		case !v.online && s.r.Float64() < s.churn.Reconnect:
+			v.online = true
+		}
+		if v.online && v.failingApp != "" {
+			v.crash(now)
+		}
+	}
+}
+
+func (v *mockNode) crash(now time.Time) {
+	if err := v.conn.Client.(visor.MockRPCClient).CrashApp(v.failingApp, now); err != nil {
+		log.WithError(err).Warnf("Failed to crash app %s of mock node %s", v.failingApp, v.conn.Addr.PK)
+	}
+}
+
+// RunMock steps the nodes of mock mode until the context is done.
+func (m *Node) RunMock(ctx context.Context) {
+	m.mu.RLock()
+	mock := m.mock
+	m.mu.RUnlock()
+	if mock == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(mock.churn.Interval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.stepMock(now)
+		}
+	}
+}
+
+func (m *Node) stepMock(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.mock == nil {
+		return
+	}
+	m.mock.step(now)
+	for _, v := range m.mock.nodes {
+		m.nodes[v.conn.Addr.PK] = v.current()
+	}
+}
@@ -0,0 +1,83 @@
+package hypervisor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func TestNode_AddMockData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_mock")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	scenario := filepath.Join(dir, "scenario.json")
+	require.NoError(t, ioutil.WriteFile(scenario, []byte(`{
+		"nodes": 4,
+		"max_transports_per_node": 5,
+		"max_routes_per_node": 5,
+		"seed": 42,
+		"offline_nodes": 1,
+		"failing_app_nodes": 1,
+		"churn": {"interval": "1m", "reconnect": 1}
+	}`), 0600))
+	mockConfig, err := ParseMockConfig(scenario)
+	require.NoError(t, err)
+	assert.Equal(t, visor.Duration(time.Minute), mockConfig.Churn.Interval)
+
+	newMockNode := func(name string) *Node {
+		config := makeConfig()
+		config.DBPath = filepath.Join(dir, name)
+		m, err := NewNode(config)
+		require.NoError(t, err)
+		require.NoError(t, m.AddMockData(mockConfig))
+		return m
+	}
+	summaries := func(m *Node) map[cipher.PubKey][]*visor.TransportSummary {
+		tps := make(map[cipher.PubKey][]*visor.TransportSummary)
+		for _, v := range m.mock.nodes {
+			if summary, err := v.conn.Client.Summary(); assert.NoError(t, err) {
+				tps[summary.PubKey] = summary.Transports
+			}
+		}
+		return tps
+	}
+
+	// Mock data is reproducible from the seed.
+	m := newMockNode("users1.db")
+	assert.Equal(t, summaries(m), summaries(newMockNode("users2.db")))
+
+	online := func() (n int) {
+		for _, pk := range m.selectNodes(&BulkRequest{All: true}) {
+			if _, err := m.nodes[pk].Client.Summary(); err == nil {
+				n++
+			}
+		}
+		return n
+	}
+	crashes := func() int {
+		events, err := m.mock.nodes[1].conn.Client.Events(visor.EventFilter{Types: []visor.EventType{visor.EventAppCrashed}})
+		require.NoError(t, err)
+		return len(events)
+	}
+	require.Len(t, m.mock.nodes, 4)
+	assert.Equal(t, 3, online())
+	assert.Equal(t, 1, crashes())
+
+	m.stepMock(time.Now())
+	assert.Equal(t, 4, online())
+	assert.Equal(t, 2, crashes())
+
+	m.mock.churn = MockChurn{Disconnect: 1}
+	m.stepMock(time.Now())
+	assert.Equal(t, 0, online())
+	assert.Equal(t, 2, crashes())
+}
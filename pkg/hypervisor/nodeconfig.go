@@ -0,0 +1,87 @@
+package hypervisor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+)
+
+// maxConfigPatchSize bounds the size of config patches.
+const maxConfigPatchSize = 1 << 20
+
+// readConfigPatch reads a JSON merge patch of a visor config from the body of the request.
+func readConfigPatch(r *http.Request) ([]byte, bool) {
+	patch, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, maxConfigPatchSize))
+	if err != nil {
+		return nil, false
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &obj); err != nil || obj == nil {
+		return nil, false
+	}
+	return patch, true
+}
+
+// provides the config the node runs with, with secret fields redacted.
+func (m *Node) getConfig() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		conf, err := ctx.RPC.EffectiveConfig()
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, conf)
+	})
+}
+
+// previews the changes of a JSON merge patch of the config file of the node.
+func (m *Node) postConfigDiff() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		patch, ok := readConfigPatch(r)
+		if !ok {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadBody)
+			return
+		}
+		diff, err := ctx.RPC.DiffConfig(patch)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, diff)
+	})
+}
+
+// applies a JSON merge patch to the config file of the node, which is then reloaded, and restarted if the 'restart'
+// query is set and changes require a restart. Patches which result in configs with problems are rejected with the
+// diff.
+func (m *Node) patchConfig() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		restart, err := httputil.BoolFromQuery(r, "restart", false)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		patch, ok := readConfigPatch(r)
+		if !ok {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadBody)
+			return
+		}
+		diff, err := ctx.RPC.DiffConfig(patch)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if len(diff.Problems) > 0 {
+			httputil.WriteJSON(w, r, http.StatusUnprocessableEntity, diff)
+			return
+		}
+		out, err := ctx.RPC.UpdateConfig(patch, restart)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, out)
+	})
+}
@@ -0,0 +1,49 @@
+package hypervisor
+
+import (
+	"net/http"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// provides the progress and result of the last update of the node.
+func (m *Node) getUpdate() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		status, err := ctx.RPC.UpdateStatus()
+		if err != nil {
+			httputil.WriteJSON(w, r, updateErrorStatus(err), err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, status)
+	})
+}
+
+// starts an update of the node to the latest release, or only checks for one if the 'check_only' query is set.
+// The progress of the update is provided by getUpdate.
+func (m *Node) postUpdate() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		checkOnly, err := httputil.BoolFromQuery(r, "check_only", false)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		status, err := ctx.RPC.Update(visor.UpdateIn{CheckOnly: checkOnly})
+		if err != nil {
+			httputil.WriteJSON(w, r, updateErrorStatus(err), err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusAccepted, status)
+	})
+}
+
+func updateErrorStatus(err error) int {
+	switch err.Error() {
+	case visor.ErrUpdatesNotConfigured.Error():
+		return http.StatusNotImplemented
+	case visor.ErrUpdateInProgress.Error():
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
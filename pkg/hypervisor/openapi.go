@@ -0,0 +1,588 @@
+package hypervisor
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/go-chi/chi"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/sdclient"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// openAPIVersion is the version of the OpenAPI specification of the documents of the API.
+const openAPIVersion = "3.0.3"
+
+// apiDoc documents an operation of the API.
+type apiDoc struct {
+	id        string            // operationId, which names the operation in generated clients.
+	summary   string            // one line.
+	role      string            // role required by the operation, if any.
+	query     map[string]string // descriptions of the queries, by name.
+	body      interface{}       // value of the type of the request body, nil if none.
+	resp      interface{}       // value of the type of the response body or WebSocket messages, nil if not JSON.
+	status    int               // status of success, http.StatusOK if zero.
+	websocket bool              // the operation upgrades to a WebSocket.
+	text      bool              // the response is plain text.
+	public    bool              // the operation requires no authorization.
+}
+
+// listQueries are the queries of paginated list operations.
+var listQueries = map[string]string{
+	"offset": "number of items to skip",
+	"limit":  "maximum number of items, all if zero",
+	"sort":   "sort key, in descending order if prefixed by '-'",
+}
+
+// withList returns the queries with the queries of paginated lists.
+func withList(query map[string]string) map[string]string {
+	out := make(map[string]string, len(query)+len(listQueries))
+	for k, v := range listQueries {
+		out[k] = v
+	}
+	for k, v := range query {
+		out[k] = v
+	}
+	return out
+}
+
+// pathParams describes the path parameters of the routes.
+var pathParams = map[string]string{
+	"pk":       "public key of the visor",
+	"app":      "name of the app",
+	"tid":      "transport ID",
+	"rid":      "route ID",
+	"id":       "ID of the API token",
+	"username": "name of the user",
+	"trusted":  "public key of the trusted visor",
+}
+
+// apiDocs documents the routes of the API, by method and pattern. Each route of Node.router must be documented, with
+// the role which guards it.
+var apiDocs = map[string]apiDoc{
+	"GET /metrics": {id: "getMetrics", summary: "Prometheus metrics of the hypervisor and its visors",
+		text: true},
+
+	"GET /api/openapi.json": {id: "getOpenAPI", summary: "OpenAPI document of the API",
+		resp: map[string]interface{}{}, public: true},
+	"POST /api/create-account": {id: "createAccount", summary: "Create the initial admin account",
+		body: AccountRequest{}, resp: true, public: true},
+	"POST /api/login": {id: "login", summary: "Log in, setting the session cookie",
+		body: LoginRequest{}, resp: true, public: true},
+	"POST /api/logout": {id: "logout", summary: "Log out of the session",
+		resp: true, public: true},
+
+	"GET /api/user": {id: "getUser", summary: "Info of the user and their sessions",
+		resp: UserInfoResp{}},
+	"POST /api/change-password": {id: "changePassword", summary: "Change the password of the user",
+		body: ChangePasswordRequest{}, resp: true},
+	"POST /api/2fa/enroll": {id: "enrollTOTP", summary: "Generate a TOTP secret to be confirmed",
+		resp: TOTPEnrollmentResp{}},
+	"POST /api/2fa/confirm": {id: "confirmTOTP", summary: "Enable two-factor authentication with the enrolled secret",
+		body: SecondFactorRequest{}, resp: RecoveryCodesResp{}},
+	"POST /api/2fa/recovery-codes": {id: "newRecoveryCodes", summary: "Replace the recovery codes of the user",
+		body: SecondFactorRequest{}, resp: RecoveryCodesResp{}},
+	"POST /api/2fa/disable": {id: "disableTOTP", summary: "Disable two-factor authentication",
+		body: SecondFactorRequest{}, resp: true},
+	"GET /api/tokens": {id: "getTokens", summary: "API tokens of the user",
+		resp: []APIToken{}},
+	"POST /api/tokens": {id: "createToken", summary: "Create an API token",
+		body: TokenRequest{}, resp: NewTokenResp{}},
+	"DELETE /api/tokens/{id}": {id: "revokeToken", summary: "Revoke an API token",
+		resp: true},
+	"GET /api/users": {id: "getUsers", summary: "Users of the hypervisor",
+		role: RoleAdmin, resp: []UserSummary{}},
+	"POST /api/users": {id: "addUser", summary: "Add a user with a role",
+		role: RoleAdmin, body: AddUserRequest{}, resp: UserSummary{}},
+	"PUT /api/users/{username}": {id: "updateUser", summary: "Update a user",
+		role: RoleAdmin, body: UpdateUserRequest{}, resp: UserSummary{}},
+	"DELETE /api/users/{username}": {id: "removeUser", summary: "Remove a user",
+		role: RoleAdmin, resp: true},
+
+	"POST /api/exec/{pk}": {id: "exec", summary: "Execute a command on a visor",
+		role: RoleAdmin, body: ExecRequest{}, resp: ExecResp{}},
+	"GET /api/nodes": {id: "getNodes", summary: "Summaries of the visors",
+		resp: []SummaryResp{},
+		query: withList(map[string]string{
+			"online":  "only visors which are online if true, or offline if false",
+			"version": "only visors of the versions, repeatable",
+			"tag":     "only visors with all the tags, repeatable",
+		})},
+	"GET /api/tags": {id: "getAllTags", summary: "Visors by tag",
+		resp: map[string][]cipher.PubKey{}},
+	"GET /api/nodes/{pk}/tags": {id: "getTags", summary: "Tags of a visor",
+		resp: []string{}},
+	"PUT /api/nodes/{pk}/tags": {id: "putTags", summary: "Set the tags of a visor",
+		role: RoleOperator, body: []string{}, resp: []string{}},
+	"POST /api/bulk": {id: "bulk", summary: "Apply an action to many visors",
+		role: RoleOperator, body: BulkRequest{}, resp: BulkResponse{}},
+	"GET /api/alerts": {id: "getAlerts", summary: "Recent alerts",
+		resp: []Alert{},
+		query: map[string]string{
+			"pk":    "only alerts of the visor",
+			"type":  "only alerts of the type",
+			"since": "only alerts since the RFC3339 time",
+		}},
+	"GET /api/alerts/visors": {id: "getVisorStatuses", summary: "Last time each visor was seen",
+		resp: []VisorStatus{}},
+	"GET /api/status/stream": {id: "streamStatus", summary: "Stream the status deltas of the visors",
+		websocket: true, resp: StatusDelta{},
+		query: map[string]string{
+			"pk":   "only deltas of the visor, repeatable",
+			"type": "only deltas of the type, repeatable",
+		}},
+	"GET /api/map": {id: "getMap", summary: "Locations of the visors, for rendering a map",
+		resp: MapResp{},
+		query: map[string]string{
+			"zoom": "clusters visors which are close at the web mercator zoom level, from 0 to 22",
+			"tag":  "only visors with all the tags, repeatable",
+		}},
+	"GET /api/proxies": {id: "getProxies", summary: "Proxy servers advertised on the network by the service discovery",
+		resp: []sdclient.Service{},
+		query: withList(map[string]string{
+			"type":    "'proxy' for socksproxy servers, the default, or 'vpn'",
+			"country": "only servers in the country, as an ISO 3166-1 alpha-2 code, repeatable",
+		})},
+	"POST /api/alerts/test": {id: "testAlert", summary: "Deliver a test alert",
+		role: RoleAdmin, resp: TestAlertResp{}},
+	"GET /api/audit": {id: "getAudit", summary: "Audit log of mutating API calls, newest first",
+		role: RoleAdmin, resp: []AuditEntry{},
+		query: map[string]string{
+			"user":    "only calls of the user",
+			"pk":      "only calls on the visor",
+			"since":   "only calls since the RFC3339 time",
+			"until":   "only calls until the RFC3339 time",
+			"success": "only successful calls if true, or failed calls if false",
+			"limit":   "maximum number of entries",
+		}},
+	"GET /api/history": {id: "getAllHistory", summary: "Metrics history of the visors, by public key",
+		resp: map[string][]MetricsPoint{},
+		query: withHistory(map[string]string{
+			"tag": "only visors with all the tags, repeatable",
+		})},
+	"GET /api/nodes/{pk}/history": {id: "getHistory", summary: "Metrics history of a visor",
+		resp: []MetricsPoint{}, query: withHistory(nil)},
+
+	"GET /api/nodes/{pk}": {id: "getNode", summary: "Summary of a visor",
+		resp: SummaryResp{}},
+	"GET /api/nodes/{pk}/health": {id: "getHealth", summary: "Health of a visor",
+		resp: VisorHealth{}},
+	"GET /api/nodes/{pk}/hypervisors": {id: "getHypervisors", summary: "Hypervisors of a visor",
+		resp: []visor.HypervisorStatus{}},
+	"GET /api/nodes/{pk}/uptime": {id: "getUptime", summary: "Uptime of a visor, in seconds",
+		resp: float64(0)},
+	"GET /api/nodes/{pk}/events": {id: "getEvents", summary: "Events of a visor",
+		resp: []visor.Event{},
+		query: map[string]string{
+			"from": "only events since the RFC3339 time",
+			"to":   "only events until the RFC3339 time",
+			"type": "only events of the types, repeatable",
+		}},
+	"GET /api/nodes/{pk}/trusted-visors": {id: "getTrustedVisors", summary: "Visors trusted by a visor",
+		resp: []cipher.PubKey{}},
+	"POST /api/nodes/{pk}/trusted-visors": {id: "trustVisors", summary: "Trust visors",
+		role: RoleOperator, body: TrustedVisorsRequest{}, resp: true},
+	"DELETE /api/nodes/{pk}/trusted-visors/{trusted}": {id: "untrustVisor", summary: "Stop trusting a visor",
+		role: RoleOperator, resp: true},
+	"GET /api/nodes/{pk}/config": {id: "getConfig", summary: "Config of a visor, with secrets redacted",
+		resp: json.RawMessage{}},
+	"POST /api/nodes/{pk}/config/diff": {id: "diffConfig",
+		summary: "Preview a JSON merge patch of the config of a visor", role: RoleOperator,
+		body: map[string]interface{}{}, resp: visor.ConfigDiff{}},
+	"PATCH /api/nodes/{pk}/config": {id: "patchConfig", summary: "Apply a JSON merge patch to the config of a visor",
+		role: RoleAdmin, body: map[string]interface{}{}, resp: visor.UpdateConfigOut{},
+		query: map[string]string{
+			"restart": "restart the visor if changes require it",
+		}},
+	"GET /api/nodes/{pk}/update": {id: "getUpdate", summary: "Status of the last update of a visor",
+		resp: visor.UpdateStatus{}},
+	"POST /api/nodes/{pk}/update": {id: "update", summary: "Update a visor to the latest release",
+		role: RoleAdmin, resp: visor.UpdateStatus{}, status: http.StatusAccepted,
+		query: map[string]string{
+			"check_only": "only check for a release",
+		}},
+
+	"GET /api/nodes/{pk}/apps": {id: "getApps", summary: "Apps of a visor",
+		resp: []visor.AppState{},
+		query: withList(map[string]string{
+			"running": "only running apps if true, or stopped apps if false",
+		})},
+	"GET /api/nodes/{pk}/apps/{app}": {id: "getApp", summary: "An app of a visor",
+		resp: visor.AppState{}},
+	"PUT /api/nodes/{pk}/apps/{app}": {id: "putApp", summary: "Start, stop or set the autostart of an app",
+		role: RoleOperator, body: AppRequest{}, resp: visor.AppState{}},
+	"PUT /api/nodes/{pk}/proxy-server": {id: "putProxyServer", role: RoleOperator, body: ProxyServerRequest{},
+		resp: ProxyServerResp{}, summary: "Set the proxy server of the socksproxy client app, restarting it if it runs"},
+	"GET /api/nodes/{pk}/apps/{app}/logs": {id: "getAppLogs", summary: "Logs of an app",
+		resp: LogsRes{}, query: map[string]string{"since": "only logs since the RFC3339 time"}},
+	"GET /api/nodes/{pk}/apps/{app}/logs/files": {id: "getAppLogFiles", summary: "Log files of an app",
+		resp: []visor.AppLogFile{}},
+	"GET /api/nodes/{pk}/apps/{app}/logs/download": {id: "downloadAppLog",
+		summary: "Download a log file of an app, or its logs within a time range, as an attachment", text: true,
+		query: map[string]string{
+			"file": "name of the log file, the logs of the log store if unset",
+			"from": "only logs of the log store since the RFC3339 time",
+			"to":   "only logs of the log store before the RFC3339 time",
+		}},
+	"GET /api/nodes/{pk}/logs/stream": {id: "streamLogs", summary: "Stream the logs of a visor, one line per message",
+		websocket: true,
+		query: map[string]string{
+			"app":   "logs of the app rather than of the visor",
+			"lines": "number of past lines to send first",
+		}},
+	"GET /api/nodes/{pk}/pty": {id: "openPty", role: RoleAdmin, websocket: true,
+		summary: "Open a terminal running '" + ptyCmd + "' on the dmsgpty host of a visor",
+		query: map[string]string{
+			"rows": "initial rows of the terminal",
+			"cols": "initial columns of the terminal",
+		}},
+
+	"GET /api/nodes/{pk}/transport-types": {id: "getTransportTypes", summary: "Transport types of a visor",
+		resp: []string{}},
+	"GET /api/nodes/{pk}/transports": {id: "getTransports", summary: "Transports of a visor",
+		resp: []visor.TransportSummary{},
+		query: withList(map[string]string{
+			"type":  "only transports of the types, repeatable",
+			"pk":    "only transports to the visors, repeatable",
+			"label": "only transports with the label",
+			"logs":  "include the logs of the transports, true by default",
+		})},
+	"POST /api/nodes/{pk}/transports": {id: "addTransport", summary: "Add a transport",
+		role: RoleOperator, body: TransportRequest{}, resp: visor.TransportSummary{}},
+	"GET /api/nodes/{pk}/transports/{tid}": {id: "getTransport", summary: "A transport of a visor",
+		resp: visor.TransportSummary{}},
+	"DELETE /api/nodes/{pk}/transports/{tid}": {id: "removeTransport", summary: "Remove a transport",
+		role: RoleOperator, resp: true},
+	"PUT /api/nodes/{pk}/transports/{tid}/labels": {id: "putTransportLabels", summary: "Set the labels of a transport",
+		role: RoleOperator, body: []string{}, resp: visor.TransportSummary{}},
+	"GET /api/nodes/{pk}/transports/{tid}/stats": {id: "getTransportStats", summary: "Statistics of a transport",
+		resp: visor.TransportStats{}},
+
+	"GET /api/nodes/{pk}/routes": {id: "getRoutes", summary: "Routing rules of a visor",
+		resp: []RoutingRuleResp{},
+		query: withList(map[string]string{
+			"type":    "only rules of the type",
+			"summary": "include the summaries of the rules",
+		})},
+	"POST /api/nodes/{pk}/routes": {id: "addRoute", summary: "Add a routing rule",
+		role: RoleOperator, body: routing.RuleSummary{}, resp: RoutingRuleResp{}},
+	"GET /api/nodes/{pk}/routes/find": {id: "findRoutes", summary: "Look up routes to a visor via the route finder",
+		resp: visor.FindRoutesOut{},
+		query: map[string]string{
+			"dst":      "public key of the destination visor",
+			"min_hops": "minimum hops of the routes",
+			"max_hops": "maximum hops of the routes",
+		}},
+	"GET /api/nodes/{pk}/routes/{rid}": {id: "getRoute", summary: "A routing rule of a visor",
+		resp: RoutingRuleResp{}, query: map[string]string{"summary": "include the summary of the rule"}},
+	"PUT /api/nodes/{pk}/routes/{rid}": {id: "putRoute", summary: "Replace a routing rule",
+		role: RoleOperator, body: routing.RuleSummary{}, resp: RoutingRuleResp{}},
+	"DELETE /api/nodes/{pk}/routes/{rid}": {id: "removeRoute", summary: "Remove a routing rule",
+		role: RoleOperator, resp: true},
+	"GET /api/nodes/{pk}/loops": {id: "getLoops", summary: "Loops of a visor",
+		resp: []LoopResp{}},
+}
+
+// withHistory returns the queries with the queries of metrics history.
+func withHistory(query map[string]string) map[string]string {
+	out := map[string]string{
+		"from":       "start of the history, as an RFC3339 time",
+		"to":         "end of the history, as an RFC3339 time, now by default",
+		"resolution": "'" + string(ResolutionRaw) + "' or '" + string(ResolutionHour) + "'",
+	}
+	for k, v := range query {
+		out[k] = v
+	}
+	return out
+}
+
+// OpenAPIDoc is an OpenAPI document.
+type OpenAPIDoc struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       OpenAPIInfo                            `json:"info"`
+	Paths      map[string]map[string]OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                      `json:"components"`
+	Security   []map[string][]string                  `json:"security,omitempty"`
+}
+
+// OpenAPIInfo is the info of an OpenAPI document.
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIOperation is an operation of an OpenAPI document.
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIBody               `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	Security    *[]map[string][]string     `json:"security,omitempty"` // empty for public operations.
+	Role        string                     `json:"x-role,omitempty"`   // role required by the operation.
+}
+
+// OpenAPIParameter is a path or query parameter of an operation.
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIBody is a request body of an operation.
+type OpenAPIBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response of an operation.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType is the content of a body of a media type.
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIComponents holds the schemas referenced by operations.
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema        `json:"schemas"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenAPISecurityScheme is a scheme of authorization.
+type OpenAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// OpenAPISchema is a JSON schema of a value.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+}
+
+// errorSchema is the schema of error responses.
+var errorSchema = &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{"error": {Type: "string"}}}
+
+var routeParamRegexp = regexp.MustCompile(`{([^}]+)}`)
+
+// OpenAPI documents the routes of the hypervisor as an OpenAPI document.
+func (m *Node) OpenAPI() (*OpenAPIDoc, error) {
+	doc := &OpenAPIDoc{
+		OpenAPI: openAPIVersion,
+		Info:    OpenAPIInfo{Title: "Skywire Hypervisor API", Version: visor.Version},
+		Paths:   make(map[string]map[string]OpenAPIOperation),
+	}
+	schemas := newSchemaGen()
+	if m.c.EnableAuth {
+		doc.Components.SecuritySchemes = map[string]OpenAPISecurityScheme{
+			"session": {Type: "apiKey", In: "cookie", Name: sessionCookieName},
+			"token":   {Type: "http", Scheme: "bearer"},
+		}
+		doc.Security = []map[string][]string{{"session": {}}, {"token": {}}}
+	}
+	err := chi.Walk(m.router(), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(strings.Replace(route, "/*/", "/", -1), "/")
+		d, ok := apiDocs[method+" "+route]
+		if !ok {
+			return fmt.Errorf("route '%s %s' is not documented", method, route)
+		}
+		op := OpenAPIOperation{
+			OperationID: d.id,
+			Summary:     d.summary,
+			Role:        d.role,
+			Responses:   map[string]OpenAPIResponse{"default": jsonResponse("error", errorSchema)},
+		}
+		if d.public && m.c.EnableAuth {
+			op.Security = &[]map[string][]string{}
+		}
+		for _, match := range routeParamRegexp.FindAllStringSubmatch(route, -1) {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{Name: match[1], In: "path", Required: true,
+				Description: pathParams[match[1]], Schema: &OpenAPISchema{Type: "string"}})
+		}
+		names := make([]string, 0, len(d.query))
+		for name := range d.query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{Name: name, In: "query",
+				Description: d.query[name], Schema: &OpenAPISchema{Type: "string"}})
+		}
+		if d.body != nil {
+			op.RequestBody = &OpenAPIBody{Required: true, Content: map[string]OpenAPIMediaType{
+				"application/json": {Schema: schemas.schema(reflect.TypeOf(d.body))},
+			}}
+		}
+		switch status := d.status; {
+		case d.websocket && d.resp != nil:
+			op.Responses["101"] = jsonResponse("upgraded to a WebSocket of JSON messages",
+				schemas.schema(reflect.TypeOf(d.resp)))
+		case d.websocket:
+			op.Responses["101"] = OpenAPIResponse{Description: "upgraded to a WebSocket"}
+		case d.text:
+			op.Responses["200"] = OpenAPIResponse{Description: "success", Content: map[string]OpenAPIMediaType{
+				"text/plain": {Schema: &OpenAPISchema{Type: "string"}},
+			}}
+		default:
+			if status == 0 {
+				status = http.StatusOK
+			}
+			op.Responses[fmt.Sprint(status)] = jsonResponse("success", schemas.schema(reflect.TypeOf(d.resp)))
+		}
+		if doc.Paths[route] == nil {
+			doc.Paths[route] = make(map[string]OpenAPIOperation)
+		}
+		doc.Paths[route][strings.ToLower(method)] = op
+		return nil
+	})
+	doc.Components.Schemas = schemas.defs
+	return doc, err
+}
+
+func jsonResponse(description string, schema *OpenAPISchema) OpenAPIResponse {
+	return OpenAPIResponse{Description: description, Content: map[string]OpenAPIMediaType{
+		"application/json": {Schema: schema},
+	}}
+}
+
+// getOpenAPI provides the OpenAPI document of the API.
+func (m *Node) getOpenAPI() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := m.OpenAPI()
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, doc)
+	}
+}
+
+// schemaGen generates the JSON schemas of Go types, as encoded by encoding/json. Named struct types are defined
+// once, as components.
+type schemaGen struct {
+	defs map[string]*OpenAPISchema
+}
+
+func newSchemaGen() *schemaGen {
+	return &schemaGen{defs: make(map[string]*OpenAPISchema)}
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// knownSchemas are the schemas of types with custom JSON encodings.
+var knownSchemas = map[reflect.Type]OpenAPISchema{
+	reflect.TypeOf(time.Time{}):       {Type: "string", Format: "date-time"},
+	reflect.TypeOf(visor.Duration(0)): {Type: "string", Format: "duration"},
+}
+
+func (g *schemaGen) schema(t reflect.Type) *OpenAPISchema {
+	if t == nil {
+		return &OpenAPISchema{}
+	}
+	if s, ok := knownSchemas[t]; ok {
+		return &s
+	}
+	if t.Kind() == reflect.Ptr {
+		s := g.schema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	}
+	switch {
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &OpenAPISchema{Type: "string"}
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		return &OpenAPISchema{} // any value.
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := path.Base(t.PkgPath()) + "." + t.Name()
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = &OpenAPISchema{} // defined before its fields, which may refer to it.
+			*g.defs[name] = *g.structSchema(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	return &OpenAPISchema{}
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) *OpenAPISchema {
+	s := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			// Fields of embedded structs are promoted.
+			for k, v := range g.structSchema(ft).Properties {
+				if _, ok := s.Properties[k]; !ok {
+					s.Properties[k] = v
+				}
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schema(f.Type)
+	}
+	return s
+}
@@ -0,0 +1,93 @@
+package hypervisor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_OpenAPI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_openapi")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	m, err := NewNode(config)
+	require.NoError(t, err)
+
+	// Every route is documented, and every documented route exists.
+	doc, err := m.OpenAPI()
+	require.NoError(t, err)
+	for key := range apiDocs {
+		parts := strings.SplitN(key, " ", 2)
+		_, ok := doc.Paths[parts[1]][strings.ToLower(parts[0])]
+		assert.True(t, ok, "stale documentation of '%s'", key)
+	}
+
+	// The document is served without authorization, and its references resolve.
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var served map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &served))
+	schemas := served["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	assert.Contains(t, schemas, "hypervisor.SummaryResp")
+	var refs func(v interface{})
+	refs = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				assert.Contains(t, schemas, strings.TrimPrefix(ref, "#/components/schemas/"))
+			}
+			for _, e := range v {
+				refs(e)
+			}
+		case []interface{}:
+			for _, e := range v {
+				refs(e)
+			}
+		}
+	}
+	refs(served)
+
+	// The documented roles guard the routes.
+	var cookies []*http.Cookie
+	do := func(method, uri, body string) int {
+		req := httptest.NewRequest(method, uri, strings.NewReader(body))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, req)
+		if c := w.Result().Cookies(); len(c) > 0 {
+			cookies = c
+		}
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/api/create-account",
+		`{"username":"admin","password":"Secure1234"}`))
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/api/login", `{"username":"admin","password":"Secure1234"}`))
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/api/users",
+		`{"username":"viewer","password":"Secure1234","role":"`+RoleReadOnly+`"}`))
+	cookies = nil
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/api/login", `{"username":"viewer","password":"Secure1234"}`))
+
+	pk := m.c.PK.Hex()
+	for key, d := range apiDocs {
+		if d.role == "" {
+			continue
+		}
+		parts := strings.SplitN(key, " ", 2)
+		uri := routeParamRegexp.ReplaceAllStringFunc(parts[1], func(string) string { return pk })
+		assert.Equal(t, http.StatusForbidden, do(parts[0], uri, ""), key)
+	}
+}
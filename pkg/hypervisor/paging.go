@@ -0,0 +1,84 @@
+package hypervisor
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// totalCountHeader holds the number of items of a list endpoint which match the filters, before pagination.
+const totalCountHeader = "X-Total-Count"
+
+// sortKeys holds the less functions of a list, by the names of the sort keys of the list.
+type sortKeys map[string]func(i, j int) bool
+
+// listQuery holds the 'offset', 'limit' and 'sort' queries of list endpoints. Lists are sorted by the sort key,
+// or in descending order if the key is prefixed by '-'.
+type listQuery struct {
+	offset int
+	limit  int // all items if zero.
+	sort   string
+	desc   bool
+}
+
+// parseListQuery parses the list queries of the request, sorting by one of the keys, or by the first key if the
+// 'sort' query is unset.
+func parseListQuery(r *http.Request, keys ...string) (listQuery, error) {
+	q := r.URL.Query()
+	lq := listQuery{sort: keys[0]}
+	for name, v := range map[string]*int{"offset": &lq.offset, "limit": &lq.limit} {
+		if s := q.Get(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				return lq, fmt.Errorf("invalid '%s': expected a non-negative integer", name)
+			}
+			*v = n
+		}
+	}
+	if s := q.Get("sort"); s != "" {
+		lq.sort, lq.desc = strings.TrimPrefix(s, "-"), strings.HasPrefix(s, "-")
+	}
+	for _, key := range keys {
+		if lq.sort == key {
+			return lq, nil
+		}
+	}
+	return lq, fmt.Errorf("invalid 'sort': expected one of '%s'", strings.Join(keys, "', '"))
+}
+
+// boolFromQuery parses the optional boolean query of the key, nil if unset.
+func boolFromQuery(r *http.Request, key string) (*bool, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s': %v", key, err)
+	}
+	return &b, nil
+}
+
+// page sorts the list, a slice whose items are compared by the sort keys, and returns the bounds of the page of the
+// list. It sets the total count of items.
+func (lq listQuery) page(w http.ResponseWriter, list interface{}, keys sortKeys) (lo, hi int) {
+	less := keys[lq.sort]
+	if lq.desc {
+		sort.SliceStable(list, func(i, j int) bool { return less(j, i) })
+	} else {
+		sort.SliceStable(list, less)
+	}
+	n := reflect.ValueOf(list).Len()
+	w.Header().Set(totalCountHeader, strconv.Itoa(n))
+	lo, hi = lq.offset, n
+	if lo > n {
+		lo = n
+	}
+	if lq.limit > 0 && lo+lq.limit < n {
+		hi = lo + lq.limit
+	}
+	return lo, hi
+}
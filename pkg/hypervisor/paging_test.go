@@ -0,0 +1,101 @@
+package hypervisor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func TestNode_pagination(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_paging")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	m, err := NewNode(config)
+	require.NoError(t, err)
+	require.NoError(t, m.AddMockData(MockConfig{Nodes: 5, MaxTpsPerNode: 10, MaxRoutesPerNode: 10, Seed: 1,
+		OfflineNodes: 1}))
+
+	get := func(uri string, out interface{}) (int, int) {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, uri, nil))
+		if w.Code != http.StatusOK {
+			return w.Code, 0
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(out))
+		total, err := strconv.Atoi(w.Header().Get(totalCountHeader))
+		require.NoError(t, err)
+		return w.Code, total
+	}
+
+	var nodes []SummaryResp
+	_, total := get("/api/nodes?limit=2", &nodes)
+	assert.Equal(t, 5, total)
+	require.Len(t, nodes, 2)
+	assert.True(t, bytes.Compare(nodes[0].PubKey[:], nodes[1].PubKey[:]) < 0)
+	_, total = get("/api/nodes?offset=4&limit=2&sort=-pk", &nodes)
+	assert.Equal(t, 5, total)
+	require.Len(t, nodes, 1)
+	_, total = get("/api/nodes?offset=10", &nodes)
+	assert.Equal(t, 5, total)
+	assert.Empty(t, nodes)
+
+	_, total = get("/api/nodes?online=false", &nodes)
+	assert.Equal(t, 1, total)
+	_, total = get("/api/nodes?version="+visor.Version+"&sort=-transports", &nodes)
+	assert.Equal(t, 4, total)
+	assert.True(t, sort.SliceIsSorted(nodes, func(i, j int) bool {
+		return len(nodes[i].Transports) > len(nodes[j].Transports)
+	}))
+
+	code, _ := get("/api/nodes?sort=uptime", &nodes)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = get("/api/nodes?limit=-1", &nodes)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	var pk string
+	for _, node := range nodes {
+		if node.RoutesCount > 0 {
+			pk = node.PubKey.Hex()
+			break
+		}
+	}
+	require.NotEmpty(t, pk)
+	var tps []*visor.TransportSummary
+	_, all := get("/api/nodes/"+pk+"/transports", &tps)
+	require.NotZero(t, all)
+	assert.Len(t, tps, all)
+	_, total = get("/api/nodes/"+pk+"/transports?limit=1&sort=-type", &tps)
+	assert.Equal(t, all, total)
+	assert.Len(t, tps, 1)
+
+	var rules []RoutingRuleResp
+	_, all = get("/api/nodes/"+pk+"/routes", &rules)
+	require.NotZero(t, all)
+	_, total = get("/api/nodes/"+pk+"/routes?type=app&summary=true", &rules)
+	assert.Equal(t, all/2, total)
+	for _, rule := range rules {
+		assert.Equal(t, routing.RuleApp, rule.Summary.Type)
+	}
+
+	var apps []*visor.AppState
+	_, total = get("/api/nodes/"+pk+"/apps?sort=-name&running=false", &apps)
+	assert.Equal(t, 2, total)
+	require.Len(t, apps, 2)
+	assert.True(t, apps[0].Name > apps[1].Name)
+}
@@ -0,0 +1,247 @@
+package hypervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/sdclient"
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+const (
+	// proxiesTimeout bounds the requests to the service discovery.
+	proxiesTimeout = 10 * time.Second
+
+	// proxiesTTL is the time for which the proxy servers listed by the service discovery are cached.
+	proxiesTTL = time.Minute
+
+	// proxyServerFlag is the flag of the socksproxy client app which sets the proxy server.
+	proxyServerFlag = "-srv"
+)
+
+// ErrNoProxyClient occurs when setting the proxy server of a visor whose config has no socksproxy client app.
+var ErrNoProxyClient = errors.New("visor has no " + skyenv.SkyproxyClientName + " app")
+
+// cachedServices are the services of a type listed by the service discovery.
+type cachedServices struct {
+	services []sdclient.Service
+	at       time.Time
+}
+
+// proxyDirectory lists the proxy servers advertised on the network, caching the lists of the service discovery.
+type proxyDirectory struct {
+	sd     sdclient.APIClient
+	byType map[string]cachedServices
+	mx     sync.Mutex
+}
+
+func newProxyDirectory(addr string) *proxyDirectory {
+	if addr == "" {
+		addr = skyenv.DefaultServiceDiscAddr
+	}
+	return &proxyDirectory{
+		sd:     sdclient.NewHTTP(addr),
+		byType: make(map[string]cachedServices),
+	}
+}
+
+// services returns the services of the type. If the service discovery fails, the last list is returned if any.
+func (d *proxyDirectory) services(ctx context.Context, serviceType string, now time.Time) ([]sdclient.Service, error) {
+	d.mx.Lock()
+	cached, ok := d.byType[serviceType]
+	d.mx.Unlock()
+	if ok && now.Sub(cached.at) < proxiesTTL {
+		return cached.services, nil
+	}
+
+	services, err := d.sd.Services(ctx, serviceType)
+	if err != nil {
+		if ok {
+			log.WithError(err).Warnf("Failed to list %s services, using the list of %s", serviceType, cached.at)
+			return cached.services, nil
+		}
+		return nil, err
+	}
+	d.mx.Lock()
+	d.byType[serviceType] = cachedServices{services: services, at: now}
+	d.mx.Unlock()
+	return services, nil
+}
+
+// provides the proxy servers of the type of the 'type' query, 'proxy' by default or 'vpn', which are advertised on
+// the network, filtered by the 'country' query, sorted by the 'sort' query, and paginated by the 'offset' and
+// 'limit' queries.
+func (m *Node) getProxies() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lq, err := parseListQuery(r, "pk", "country")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		serviceType := r.URL.Query().Get("type")
+		switch serviceType {
+		case "":
+			serviceType = sdclient.ServiceTypeProxy
+		case sdclient.ServiceTypeProxy, sdclient.ServiceTypeVPN:
+		default:
+			httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid 'type' query: %s", serviceType))
+			return
+		}
+		countries := strSliceFromQuery(r, "country", nil)
+
+		ctx, cancel := context.WithTimeout(r.Context(), proxiesTimeout)
+		defer cancel()
+		all, err := m.proxies.services(ctx, serviceType, time.Now())
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadGateway, err)
+			return
+		}
+		services := make([]sdclient.Service, 0, len(all))
+		for _, s := range all {
+			if countries == nil || containsFold(countries, s.Country) {
+				services = append(services, s)
+			}
+		}
+		lo, hi := lq.page(w, services, sortKeys{
+			"pk":      func(i, j int) bool { return services[i].Addr.PK.Hex() < services[j].Addr.PK.Hex() },
+			"country": func(i, j int) bool { return services[i].Country < services[j].Country },
+		})
+		httputil.WriteJSON(w, r, http.StatusOK, services[lo:hi])
+	}
+}
+
+func containsFold(ss []string, s string) bool {
+	for _, e := range ss {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProxyServerRequest sets the proxy server of the socksproxy client app of a visor.
+type ProxyServerRequest struct {
+	PK cipher.PubKey `json:"pk"` // public key of the visor of the proxy server.
+}
+
+// ProxyServerResp is the result of setting the proxy server of a visor.
+type ProxyServerResp struct {
+	Config    *visor.UpdateConfigOut `json:"config"`
+	Restarted bool                   `json:"restarted"` // whether the running app was restarted to connect.
+}
+
+// sets the proxy server of the socksproxy client app in the config of the node, and restarts the app if it runs so
+// that it connects to the server.
+func (m *Node) putProxyServer() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		var req ProxyServerRequest
+		if err := httputil.ReadJSON(r, &req); err != nil || req.PK.Null() {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadBody)
+			return
+		}
+		conf, err := ctx.RPC.EffectiveConfig()
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		patch, err := proxyServerPatch(conf, req.PK)
+		if err == ErrNoProxyClient {
+			httputil.WriteJSON(w, r, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		var resp ProxyServerResp
+		if resp.Config, err = ctx.RPC.UpdateConfig(patch, false); err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Running apps keep their previous config until they are restarted.
+		apps, err := ctx.RPC.Apps()
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		for _, app := range apps {
+			if app.Name != skyenv.SkyproxyClientName || app.Status != visor.AppStatusRunning {
+				continue
+			}
+			if err := ctx.RPC.StopApp(app.Name); err != nil {
+				httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			if err := ctx.RPC.StartApp(app.Name); err != nil {
+				httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			resp.Restarted = true
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, resp)
+	})
+}
+
+// proxyServerPatch returns a config patch which sets the proxy server of the socksproxy client app of the config.
+// Apps are patched as a whole, so the patch holds every app of the config.
+func proxyServerPatch(conf []byte, pk cipher.PubKey) ([]byte, error) {
+	var c struct {
+		Apps []map[string]interface{} `json:"apps"`
+	}
+	if err := json.Unmarshal(conf, &c); err != nil {
+		return nil, err
+	}
+	found := false
+	for _, app := range c.Apps {
+		if app["app"] != skyenv.SkyproxyClientName {
+			continue
+		}
+		found = true
+		var args []interface{}
+		if a, ok := app["args"].([]interface{}); ok {
+			args = a
+		}
+		app["args"] = setFlag(args, proxyServerFlag, pk.Hex())
+	}
+	if !found {
+		return nil, ErrNoProxyClient
+	}
+	return json.Marshal(map[string]interface{}{"apps": c.Apps})
+}
+
+// setFlag sets the value of the flag in the args, in either the '-flag value' or the '-flag=value' form, appending
+// the flag if it is unset.
+func setFlag(args []interface{}, flag, value string) []interface{} {
+	out := make([]interface{}, 0, len(args)+2)
+	set := false
+	for i := 0; i < len(args); i++ {
+		arg, _ := args[i].(string)
+		switch {
+		case arg == flag || arg == "-"+flag:
+			i++ // skips the value.
+		case strings.HasPrefix(arg, flag+"=") || strings.HasPrefix(arg, "-"+flag+"="):
+		default:
+			out = append(out, args[i])
+			continue
+		}
+		if !set {
+			out = append(out, flag, value)
+			set = true
+		}
+	}
+	if !set {
+		out = append(out, flag, value)
+	}
+	return out
+}
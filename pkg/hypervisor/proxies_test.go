@@ -0,0 +1,141 @@
+package hypervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/sdclient"
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+)
+
+// stubServiceDisc lists the services, or fails with err if set.
+type stubServiceDisc struct {
+	services []sdclient.Service
+	err      error
+	calls    int
+}
+
+func (sd *stubServiceDisc) Services(_ context.Context, serviceType string) ([]sdclient.Service, error) {
+	sd.calls++
+	if sd.err != nil {
+		return nil, sd.err
+	}
+	var out []sdclient.Service
+	for _, s := range sd.services {
+		if s.Type == serviceType {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func TestProxyDirectory_services(t *testing.T) {
+	sd := &stubServiceDisc{services: []sdclient.Service{{Type: sdclient.ServiceTypeProxy}}}
+	d := newProxyDirectory("")
+	d.sd = sd
+	now := time.Now()
+
+	_, err := d.services(context.Background(), sdclient.ServiceTypeProxy, now)
+	require.NoError(t, err)
+	services, err := d.services(context.Background(), sdclient.ServiceTypeProxy, now.Add(proxiesTTL/2))
+	require.NoError(t, err)
+	assert.Len(t, services, 1)
+	assert.Equal(t, 1, sd.calls)
+
+	// Failures of the service discovery fall back to the last list.
+	sd.err = errors.New("unavailable")
+	services, err = d.services(context.Background(), sdclient.ServiceTypeProxy, now.Add(proxiesTTL))
+	require.NoError(t, err)
+	assert.Len(t, services, 1)
+	assert.Equal(t, 2, sd.calls)
+	_, err = d.services(context.Background(), sdclient.ServiceTypeVPN, now)
+	assert.Equal(t, sd.err, err)
+}
+
+func TestSetFlag(t *testing.T) {
+	args := func(s ...interface{}) []interface{} { return s }
+	for _, tc := range []struct {
+		in, out []interface{}
+	}{
+		{in: nil, out: args("-srv", "pk")},
+		{in: args("-addr", ":1080"), out: args("-addr", ":1080", "-srv", "pk")},
+		{in: args("-srv", "old", "-addr", ":1080"), out: args("-srv", "pk", "-addr", ":1080")},
+		{in: args("--srv=old", "-srv", "older"), out: args("-srv", "pk")},
+	} {
+		assert.Equal(t, tc.out, setFlag(tc.in, "-srv", "pk"), tc.in)
+	}
+}
+
+func TestNode_proxies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_proxies")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	m, err := NewNode(config)
+	require.NoError(t, err)
+	require.NoError(t, m.AddMockData(MockConfig{Nodes: 1, Seed: 1}))
+	pk := m.selectNodes(&BulkRequest{All: true})[0]
+
+	var servers [3]cipher.PubKey
+	for i := range servers {
+		servers[i], _ = cipher.GenerateKeyPair()
+	}
+	m.proxies.sd = &stubServiceDisc{services: []sdclient.Service{
+		{Addr: sdclient.ServiceAddr{PK: servers[0], Port: 3}, Type: sdclient.ServiceTypeProxy, Country: "DE"},
+		{Addr: sdclient.ServiceAddr{PK: servers[1], Port: 3}, Type: sdclient.ServiceTypeProxy, Country: "AU"},
+		{Addr: sdclient.ServiceAddr{PK: servers[2], Port: 44}, Type: sdclient.ServiceTypeVPN, Country: "DE"},
+	}}
+
+	do := func(method, uri, body string, v interface{}) int {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(method, uri, strings.NewReader(body)))
+		if w.Code == http.StatusOK && v != nil {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), v))
+		}
+		return w.Code
+	}
+
+	var services []sdclient.Service
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/api/proxies?sort=country", "", &services))
+	require.Len(t, services, 2)
+	assert.Equal(t, "AU", services[0].Country)
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/api/proxies?type=vpn&country=de", "", &services))
+	require.Len(t, services, 1)
+	assert.Equal(t, servers[2], services[0].Addr.PK)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/api/proxies?type=tor", "", nil))
+
+	// Visors need a socksproxy client app to pick a proxy server.
+	uri := "/api/nodes/" + pk.Hex() + "/proxy-server"
+	body := `{"pk":"` + servers[0].Hex() + `"}`
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, uri, body, nil))
+	client := m.nodes[pk].Client
+	_, err = client.UpdateConfig([]byte(`{"apps":[{"app":"`+skyenv.SkyproxyClientName+`","port":13,
+		"args":["-srv","`+servers[1].Hex()+`","-addr",":1080"]}]}`), false)
+	require.NoError(t, err)
+
+	var resp ProxyServerResp
+	require.Equal(t, http.StatusOK, do(http.MethodPut, uri, body, &resp))
+	require.NotEmpty(t, resp.Config.Changes)
+	assert.Equal(t, "apps[0].args[1]", resp.Config.Changes[0].Field)
+	conf, err := client.EffectiveConfig()
+	require.NoError(t, err)
+	assert.Contains(t, string(conf), servers[0].Hex())
+	assert.NotContains(t, string(conf), servers[1].Hex())
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, uri, `{}`, nil))
+}
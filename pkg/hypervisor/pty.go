@@ -0,0 +1,188 @@
+package hypervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/creack/pty"
+
+	ptyc "github.com/SkycoinProject/skywire-mainnet/pkg/dmsgpty/pty"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+const (
+	// ptyCmd is the command of browser terminals.
+	ptyCmd = "/bin/bash"
+
+	// ptyDialTimeout bounds dialing the dmsgpty host of a visor.
+	ptyDialTimeout = 20 * time.Second
+
+	// ptyReadSize is the maximum size of the messages of pty output.
+	ptyReadSize = 4096
+)
+
+// Errors associated with browser terminals.
+var (
+	ErrPtyUnavailable    = errors.New("remote terminals require the hypervisor to manage visors over dmsg")
+	ErrPtyNotWhitelisted = errors.New("the hypervisor is not in the dmsgpty whitelist of the visor")
+)
+
+// ptyDialFunc dials the dmsgpty host of a visor.
+type ptyDialFunc func(ctx context.Context, pk cipher.PubKey) (io.ReadWriteCloser, error)
+
+// ptySize is the control message of browser terminals, which resizes the pty.
+type ptySize struct {
+	Rows uint16 `json:"rows"`
+	Cols uint16 `json:"cols"`
+}
+
+// getPty opens a terminal running ptyCmd to the dmsgpty host of a node over a WebSocket. The visor must whitelist the
+// public key of the hypervisor. The initial size is given by the 'rows' and 'cols' queries.
+//
+// Binary messages of the client are written to the pty, and text messages are JSON objects of the 'rows' and 'cols'
+// which resize the pty. The output of the pty is sent as binary messages.
+func (m *Node) getPty() http.HandlerFunc {
+	return m.withCtx(m.nodeCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		// The pty is started before upgrading, so the origin is checked first.
+		if err := httputil.CheckWebSocketOrigin(r); err != nil {
+			httputil.WriteJSON(w, r, http.StatusForbidden, err)
+			return
+		}
+		q := r.URL.Query()
+		var size *pty.Winsize
+		if q.Get("rows") != "" || q.Get("cols") != "" {
+			rows, err1 := strconv.ParseUint(q.Get("rows"), 10, 16)
+			cols, err2 := strconv.ParseUint(q.Get("cols"), 10, 16)
+			if err1 != nil || err2 != nil {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, errors.New("invalid 'rows' and 'cols' queries"))
+				return
+			}
+			size = &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)}
+		}
+
+		whitelist, err := ctx.RPC.PtyWhitelist()
+		if err != nil {
+			status := http.StatusInternalServerError
+			if err.Error() == visor.ErrDmsgPtyDisabled.Error() {
+				status = http.StatusNotFound
+			}
+			httputil.WriteJSON(w, r, status, err)
+			return
+		}
+		if !containsPK(whitelist, m.c.PK) {
+			httputil.WriteJSON(w, r, http.StatusForbidden, ErrPtyNotWhitelisted)
+			return
+		}
+
+		m.mu.RLock()
+		dial := m.ptyDial
+		m.mu.RUnlock()
+		if dial == nil {
+			httputil.WriteJSON(w, r, http.StatusServiceUnavailable, ErrPtyUnavailable)
+			return
+		}
+		dialCtx, cancel := context.WithTimeout(r.Context(), ptyDialTimeout)
+		conn, err := dial(dialCtx, ctx.PK)
+		cancel()
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadGateway, err)
+			return
+		}
+
+		// The session outlives the timeout of requests, so the pty is not bound to the request context.
+		ptyCtx, cancel := context.WithCancel(context.Background())
+		ptyC := ptyc.NewPtyClient(ptyCtx, log, conn)
+		if err := ptyC.StartWithSize(size, ptyCmd); err != nil {
+			cancel()
+			_ = conn.Close() //nolint:errcheck
+			httputil.WriteJSON(w, r, http.StatusBadGateway, err)
+			return
+		}
+		// Stopping the pty waits for pending reads of the host, so the connection is closed instead, which stops the
+		// pty once the host notices.
+		var closeOnce sync.Once
+		closePty := func() {
+			closeOnce.Do(func() {
+				cancel()
+				_ = conn.Close() //nolint:errcheck
+			})
+		}
+		defer closePty()
+
+		ws, err := httputil.UpgradeWebSocket(w, r)
+		if err != nil {
+			log.WithError(err).Warn("Failed to upgrade terminal to websocket")
+			return
+		}
+		l := log.WithField("visor", ctx.PK).WithField("cmd", ptyCmd)
+		if user, ok := r.Context().Value(userKey).(User); ok {
+			l = l.WithField("user", user.Name)
+		}
+		l.Info("Opened remote terminal")
+		defer l.Info("Closed remote terminal")
+
+		// Input of the client is forwarded until the client or the pty is gone. The pty is closed once the client is
+		// gone, which ends reading from the pty.
+		done := make(chan struct{})
+		go func() {
+			defer closePty()
+			defer close(done)
+			for {
+				typ, p, err := ws.ReadMessage()
+				if err != nil {
+					return
+				}
+				switch typ {
+				case httputil.WebSocketBinary:
+					_, err = ptyC.Write(p)
+				case httputil.WebSocketText:
+					var s ptySize
+					if err := json.Unmarshal(p, &s); err != nil {
+						l.WithError(err).Warn("Invalid terminal control message")
+						continue
+					}
+					err = ptyC.SetPtySize(&pty.Winsize{Rows: s.Rows, Cols: s.Cols})
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+
+		b := make([]byte, ptyReadSize)
+		for {
+			n, err := ptyC.Read(b)
+			if err != nil {
+				code, reason := httputil.WebSocketNormalClosure, ""
+				if err != io.EOF && ptyCtx.Err() == nil {
+					code, reason = httputil.WebSocketInternalError, err.Error()
+				}
+				_ = ws.Close(code, reason) //nolint:errcheck
+				break
+			}
+			if err := ws.WriteMessage(httputil.WebSocketBinary, b[:n]); err != nil {
+				_ = ws.Close(httputil.WebSocketNormalClosure, "") //nolint:errcheck
+				break
+			}
+		}
+		closePty()
+		<-done
+	})
+}
+
+func containsPK(pks []cipher.PubKey, pk cipher.PubKey) bool {
+	for _, p := range pks {
+		if p == pk {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package hypervisor
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/creack/pty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ptyc "github.com/SkycoinProject/skywire-mainnet/pkg/dmsgpty/pty"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+)
+
+// echoGateway is a pty gateway whose pty echoes its input.
+type echoGateway struct {
+	cmd   chan *ptyc.CommandReq
+	sizes chan *pty.Winsize
+	out   chan []byte
+}
+
+func (g *echoGateway) Start(req *ptyc.CommandReq, _ *struct{}) error {
+	g.cmd <- req
+	return nil
+}
+
+func (g *echoGateway) Stop(_, _ *struct{}) error { return nil }
+
+func (g *echoGateway) Read(_ *int, respB *[]byte) error {
+	b, ok := <-g.out
+	if !ok {
+		return io.EOF
+	}
+	*respB = b
+	return nil
+}
+
+func (g *echoGateway) Write(wb *[]byte, n *int) error {
+	g.out <- *wb
+	*n = len(*wb)
+	return nil
+}
+
+func (g *echoGateway) SetPtySize(size *pty.Winsize, _ *struct{}) error {
+	g.sizes <- size
+	return nil
+}
+
+func TestNode_getPty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_pty")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.EnableAuth = false
+	config.DBPath = filepath.Join(dir, "users.db")
+	m, err := NewNode(config)
+	require.NoError(t, err)
+	require.NoError(t, m.AddMockData(MockConfig{Nodes: 1, MaxTpsPerNode: 1, MaxRoutesPerNode: 1}))
+	pk := m.selectNodes(&BulkRequest{All: true})[0]
+	uri := "/api/nodes/" + pk.Hex() + "/pty?rows=24&cols=80"
+
+	get := func(origin string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, uri, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		m.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusForbidden, get(""))
+	require.NoError(t, m.nodes[pk].Client.AddPtyWhitelist(m.c.PK))
+	assert.Equal(t, http.StatusServiceUnavailable, get(""))
+	assert.Equal(t, http.StatusServiceUnavailable, get("http://example.com"))
+	assert.Equal(t, http.StatusForbidden, get("https://attacker.example"))
+
+	g := &echoGateway{cmd: make(chan *ptyc.CommandReq, 1), sizes: make(chan *pty.Winsize, 1), out: make(chan []byte)}
+	rpcS := rpc.NewServer()
+	require.NoError(t, rpcS.RegisterName(ptyc.GatewayName, g))
+	served := make(chan struct{})
+	m.ptyDial = func(_ context.Context, dialed cipher.PubKey) (io.ReadWriteCloser, error) {
+		assert.Equal(t, pk, dialed)
+		conn, hostConn := net.Pipe()
+		go func() {
+			rpcS.ServeConn(hostConn)
+			close(served)
+		}()
+		return conn, nil
+	}
+
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+	ws, err := httputil.DialWebSocket(srv.URL+uri+"&cmd=/bin/sh&arg=-l", nil)
+	require.NoError(t, err)
+	req := <-g.cmd
+	assert.Equal(t, ptyCmd, req.Name)
+	assert.Empty(t, req.Arg)
+	assert.Equal(t, &pty.Winsize{Rows: 24, Cols: 80}, req.Size)
+
+	require.NoError(t, ws.WriteMessage(httputil.WebSocketBinary, []byte("echo\n")))
+	typ, p, err := ws.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, httputil.WebSocketBinary, typ)
+	assert.Equal(t, "echo\n", string(p))
+
+	require.NoError(t, ws.WriteText([]byte(`{"rows":30,"cols":100}`)))
+	assert.Equal(t, &pty.Winsize{Rows: 30, Cols: 100}, <-g.sizes)
+
+	// The connection to the host is closed once the client is gone, and the host stops serving it once its pending
+	// read ends.
+	require.NoError(t, ws.Close(httputil.WebSocketNormalClosure, ""))
+	close(g.out)
+	<-served
+}
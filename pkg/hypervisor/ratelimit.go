@@ -0,0 +1,243 @@
+package hypervisor
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+// Default values of RateLimitConfig.
+const (
+	DefaultRequestsPerMinute = 600
+	DefaultLoginsPerMinute   = 10
+	DefaultMaxAuthFailures   = 5
+	DefaultLockout           = time.Minute
+	DefaultMaxLockout        = time.Hour
+)
+
+// Errors associated with rate limiting.
+var (
+	ErrRateLimited = errors.New("too many requests, try again later")
+	ErrLockedOut   = errors.New("too many failed authentication attempts, try again later")
+)
+
+// RateLimitConfig configures the rate limiting of the HTTP API per client address, and the lockout of client addresses
+// and usernames after repeated failures to authenticate. Lockouts double with each further failure.
+type RateLimitConfig struct {
+	RequestsPerMinute int            `json:"requests_per_minute,omitempty"` // defaults to DefaultRequestsPerMinute.
+	LoginsPerMinute   int            `json:"logins_per_minute,omitempty"`   // defaults to DefaultLoginsPerMinute.
+	MaxAuthFailures   int            `json:"max_auth_failures,omitempty"`   // defaults to DefaultMaxAuthFailures.
+	Lockout           visor.Duration `json:"lockout,omitempty"`             // defaults to DefaultLockout.
+	MaxLockout        visor.Duration `json:"max_lockout,omitempty"`         // defaults to DefaultMaxLockout.
+
+	// TrustProxy identifies clients by the last address of the 'X-Forwarded-For' header, which is appended by the
+	// reverse proxy the hypervisor is served behind.
+	TrustProxy bool `json:"trust_proxy,omitempty"`
+}
+
+// FillDefaults fills the unset fields of the config with default values.
+func (c *RateLimitConfig) FillDefaults() {
+	if c.RequestsPerMinute <= 0 {
+		c.RequestsPerMinute = DefaultRequestsPerMinute
+	}
+	if c.LoginsPerMinute <= 0 {
+		c.LoginsPerMinute = DefaultLoginsPerMinute
+	}
+	if c.MaxAuthFailures <= 0 {
+		c.MaxAuthFailures = DefaultMaxAuthFailures
+	}
+	if c.Lockout <= 0 {
+		c.Lockout = visor.Duration(DefaultLockout)
+	}
+	if c.MaxLockout <= 0 {
+		c.MaxLockout = visor.Duration(DefaultMaxLockout)
+	}
+	if c.MaxLockout < c.Lockout {
+		c.MaxLockout = c.Lockout
+	}
+}
+
+// rateBucket is a token bucket which holds up to a minute worth of requests.
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// authFailures records the consecutive failures to authenticate of a client address or username.
+type authFailures struct {
+	count int
+	last  time.Time
+	until time.Time // locked out until.
+}
+
+// rateLimiter limits the rate of requests, and locks out clients which repeatedly fail to authenticate.
+type rateLimiter struct {
+	conf      RateLimitConfig
+	now       func() time.Time
+	buckets   map[string]*rateBucket
+	failures  map[string]*authFailures
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+func newRateLimiter(conf *RateLimitConfig) *rateLimiter {
+	l := &rateLimiter{
+		now:      time.Now,
+		buckets:  make(map[string]*rateBucket),
+		failures: make(map[string]*authFailures),
+	}
+	if conf != nil {
+		l.conf = *conf
+	}
+	l.conf.FillDefaults()
+	return l
+}
+
+// allow takes a request of the key from its bucket, refilled at perMinute, returning how long to wait otherwise.
+func (l *rateLimiter) allow(key string, perMinute int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	rate := float64(perMinute) / float64(time.Minute)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &rateBucket{tokens: float64(perMinute), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(perMinute), b.tokens+float64(now.Sub(b.last))*rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rate)
+	}
+	b.tokens--
+	return true, 0
+}
+
+// lockedOut returns how long the longest lockout of the keys lasts, if any.
+func (l *rateLimiter) lockedOut(keys ...string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var d time.Duration
+	for _, key := range keys {
+		if f, ok := l.failures[key]; ok && f.until.Sub(now) > d {
+			d = f.until.Sub(now)
+		}
+	}
+	return d
+}
+
+// fail records a failure to authenticate of the keys, locking out the keys with too many consecutive failures. It
+// returns the most consecutive failures of the keys. Failures are forgotten after the longest lockout.
+func (l *rateLimiter) fail(keys ...string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	most := 0
+	for _, key := range keys {
+		f, ok := l.failures[key]
+		if !ok || now.Sub(f.last) > time.Duration(l.conf.MaxLockout) {
+			f = new(authFailures)
+			l.failures[key] = f
+		}
+		f.count++
+		f.last = now
+		if excess := f.count - l.conf.MaxAuthFailures; excess >= 0 {
+			lockout := time.Duration(l.conf.MaxLockout)
+			if excess < 32 && time.Duration(l.conf.Lockout)<<uint(excess) < lockout {
+				lockout = time.Duration(l.conf.Lockout) << uint(excess)
+			}
+			f.until = now.Add(lockout)
+		}
+		if f.count > most {
+			most = f.count
+		}
+	}
+	return most
+}
+
+// succeed forgets the failures to authenticate of the keys.
+func (l *rateLimiter) succeed(keys ...string) {
+	l.mu.Lock()
+	for _, key := range keys {
+		delete(l.failures, key)
+	}
+	l.mu.Unlock()
+}
+
+// sweep removes full buckets, and failures which are forgotten, once a minute. l.mu should be locked.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+	for key, f := range l.failures {
+		if now.Sub(f.last) > time.Duration(l.conf.MaxLockout) && now.After(f.until) {
+			delete(l.failures, key)
+		}
+	}
+}
+
+// clientIP returns the address of the client of the request. Behind a trusted proxy, this is the last address of
+// 'X-Forwarded-For', as the addresses before it are set by the client.
+func (l *rateLimiter) clientIP(r *http.Request) string {
+	if fwd := r.Header["X-Forwarded-For"]; l.conf.TrustProxy && len(fwd) > 0 {
+		addrs := strings.Split(fwd[len(fwd)-1], ",")
+		if ip := strings.TrimSpace(addrs[len(addrs)-1]); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ipKey(ip string) string         { return "ip:" + ip }
+func usernameKey(name string) string { return "user:" + name }
+
+// writeTooManyRequests responds with the error, and when to retry.
+func writeTooManyRequests(w http.ResponseWriter, r *http.Request, retry time.Duration, err error) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+	httputil.WriteJSON(w, r, http.StatusTooManyRequests, err)
+}
+
+// rateLimit is an http middleware which limits the rate of requests per client address, and the rate of logins and
+// account creations further.
+func (m *Node) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := m.users.limiter
+		ip := l.clientIP(r)
+		if ok, retry := l.allow(ipKey(ip), l.conf.RequestsPerMinute); !ok {
+			log.WithField("ip", ip).WithField("path", r.URL.Path).Warn("Rate limited request.")
+			writeTooManyRequests(w, r, retry, ErrRateLimited)
+			return
+		}
+		if r.Method == http.MethodPost && (r.URL.Path == "/api/login" || r.URL.Path == "/api/create-account") {
+			if ok, retry := l.allow("login:"+ip, l.conf.LoginsPerMinute); !ok {
+				log.WithField("ip", ip).WithField("path", r.URL.Path).Warn("Rate limited login.")
+				writeTooManyRequests(w, r, retry, ErrRateLimited)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
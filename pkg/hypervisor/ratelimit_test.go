@@ -0,0 +1,115 @@
+package hypervisor
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(&RateLimitConfig{MaxAuthFailures: 2, Lockout: visor.Duration(time.Minute)})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.allow("a", 3)
+		require.True(t, ok)
+	}
+	ok, retry := l.allow("a", 3)
+	assert.False(t, ok)
+	assert.Equal(t, 20*time.Second, retry)
+	ok, _ = l.allow("b", 3)
+	assert.True(t, ok)
+	now = now.Add(20 * time.Second)
+	ok, _ = l.allow("a", 3)
+	assert.True(t, ok)
+
+	// Lockouts double with each failure after the allowed failures, up to the longest lockout.
+	assert.Equal(t, 1, l.fail("ip", "user"))
+	assert.Zero(t, l.lockedOut("ip", "user"))
+	assert.Equal(t, 2, l.fail("ip", "user"))
+	assert.Equal(t, time.Minute, l.lockedOut("ip"))
+	assert.Equal(t, 3, l.fail("user"))
+	assert.Equal(t, 2*time.Minute, l.lockedOut("ip", "user"))
+	for i := 0; i < 10; i++ {
+		l.fail("user")
+	}
+	assert.Equal(t, DefaultMaxLockout, l.lockedOut("user"))
+
+	l.succeed("user")
+	assert.Zero(t, l.lockedOut("user"))
+	assert.Equal(t, time.Minute, l.lockedOut("ip"))
+
+	// Failures are forgotten after the longest lockout.
+	now = now.Add(DefaultMaxLockout + time.Second)
+	assert.Zero(t, l.lockedOut("ip"))
+	assert.Equal(t, 1, l.fail("ip"))
+}
+
+func TestRateLimiter_clientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Add("X-Forwarded-For", "1.1.1.1, 2.2.2.2")
+	req.Header.Add("X-Forwarded-For", "3.3.3.3, 4.4.4.4")
+
+	assert.Equal(t, "10.0.0.1", newRateLimiter(&RateLimitConfig{}).clientIP(req))
+	// The client sets any addresses before the one appended by the proxy.
+	assert.Equal(t, "4.4.4.4", newRateLimiter(&RateLimitConfig{TrustProxy: true}).clientIP(req))
+}
+
+func TestNode_rateLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_ratelimit")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	config.RateLimit = &RateLimitConfig{RequestsPerMinute: 8, LoginsPerMinute: 5, MaxAuthFailures: 2}
+	m, err := NewNode(config)
+	require.NoError(t, err)
+	require.NoError(t, m.AddMockData(MockConfig{EnableAuth: true}))
+
+	post := func(uri, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodPost, uri, strings.NewReader(body)))
+		return w
+	}
+	account := `{"username":"admin","password":"Secure1234"}`
+	require.Equal(t, http.StatusOK, post("/api/create-account", account).Code)
+	wrong := `{"username":"admin","password":"Wrong1234"}`
+	require.Equal(t, http.StatusUnauthorized, post("/api/login", wrong).Code)
+	require.Equal(t, http.StatusUnauthorized, post("/api/login", wrong).Code)
+
+	// Locked out clients may not login, even with the right password.
+	w := post("/api/login", account)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), ErrLockedOut.Error())
+
+	// Clients are locked out of the use of API tokens too.
+	req := httptest.NewRequest(http.MethodGet, "/api/nodes", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// Logins are limited further than other requests.
+	require.Equal(t, http.StatusTooManyRequests, post("/api/login", account).Code)
+	w = post("/api/login", account)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), ErrRateLimited.Error())
+	assert.Equal(t, http.StatusBadRequest, post("/api/logout", "").Code)
+	w = post("/api/logout", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), ErrRateLimited.Error())
+}
@@ -0,0 +1,307 @@
+package hypervisor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+const (
+	// statusCheckInterval is the interval at which visors are checked for being online, while their events are not
+	// followed.
+	statusCheckInterval = 5 * time.Second
+
+	// statusBufferSize is the number of deltas buffered for each client of the status stream. Clients which fall
+	// further behind are disconnected.
+	statusBufferSize = 64
+)
+
+// StatusDeltaType is the type of a status delta.
+type StatusDeltaType string
+
+// Types of status deltas.
+const (
+	StatusVisorOnline  StatusDeltaType = "visor_online"
+	StatusVisorOffline StatusDeltaType = "visor_offline"
+	StatusApp          StatusDeltaType = "app"
+	StatusTransport    StatusDeltaType = "transport"
+
+	// StatusResync reports that events of the visor were missed, so that its status should be fetched again.
+	StatusResync StatusDeltaType = "resync"
+)
+
+// StatusDelta is a change in the status of a visor, pushed by the status stream.
+type StatusDelta struct {
+	Type      StatusDeltaType         `json:"type"`
+	PK        cipher.PubKey           `json:"pk"`
+	Time      time.Time               `json:"time"`
+	Event     *visor.Event            `json:"event,omitempty"`     // event of the visor, for app and transport deltas.
+	App       *visor.AppState         `json:"app,omitempty"`       // app after the event, for app deltas.
+	Transport *visor.TransportSummary `json:"transport,omitempty"` // transport after the event, unless removed.
+}
+
+// statusHub tracks whether visors are online, and publishes status deltas to the clients of the status stream.
+type statusHub struct {
+	online map[cipher.PubKey]bool
+	subs   map[chan StatusDelta]struct{}
+	mx     sync.Mutex
+}
+
+func newStatusHub() *statusHub {
+	return &statusHub{
+		online: make(map[cipher.PubKey]bool),
+		subs:   make(map[chan StatusDelta]struct{}),
+	}
+}
+
+// subscribe returns the channel of upcoming deltas, the deltas of the visors as of now, and a func which
+// unsubscribes. The channel is closed if the subscriber falls behind.
+func (h *statusHub) subscribe(now time.Time) (<-chan StatusDelta, []StatusDelta, func()) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	current := make([]StatusDelta, 0, len(h.online))
+	for pk, online := range h.online {
+		current = append(current, onlineDelta(pk, online, now))
+	}
+	ch := make(chan StatusDelta, statusBufferSize)
+	h.subs[ch] = struct{}{}
+	return ch, current, func() {
+		h.mx.Lock()
+		defer h.mx.Unlock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *statusHub) publish(d StatusDelta) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.publishLocked(d)
+}
+
+func (h *statusHub) publishLocked(d StatusDelta) {
+	for ch := range h.subs {
+		select {
+		case ch <- d:
+		default:
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// setOnline records whether the visor is online, publishing a delta if it changed.
+func (h *statusHub) setOnline(pk cipher.PubKey, online bool, now time.Time) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if was, ok := h.online[pk]; ok && was == online {
+		return
+	}
+	h.online[pk] = online
+	h.publishLocked(onlineDelta(pk, online, now))
+}
+
+func onlineDelta(pk cipher.PubKey, online bool, now time.Time) StatusDelta {
+	if online {
+		return StatusDelta{Type: StatusVisorOnline, PK: pk, Time: now}
+	}
+	return StatusDelta{Type: StatusVisorOffline, PK: pk, Time: now}
+}
+
+// statusWatch is the watch of a visor over a connection.
+type statusWatch struct {
+	client visor.RPCClient
+	cancel context.CancelFunc
+}
+
+// RunStatus watches the connected visors until ctx is done, publishing whether they are online and the events of
+// their apps and transports to the status stream.
+func (m *Node) RunStatus(ctx context.Context) {
+	watches := make(map[cipher.PubKey]statusWatch)
+	defer func() {
+		for _, w := range watches {
+			w.cancel()
+		}
+	}()
+	ticker := time.NewTicker(statusCheckInterval)
+	defer ticker.Stop()
+	for {
+		m.watchStatus(ctx, watches)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchStatus starts watching the visors which are connected anew, and stops watching those which are gone.
+func (m *Node) watchStatus(ctx context.Context, watches map[cipher.PubKey]statusWatch) {
+	m.mu.RLock()
+	conns := make(map[cipher.PubKey]visor.RPCClient, len(m.nodes))
+	for pk, c := range m.nodes {
+		conns[pk] = c.Client
+	}
+	m.mu.RUnlock()
+
+	for pk, w := range watches {
+		if client, ok := conns[pk]; !ok || client != w.client {
+			w.cancel()
+			delete(watches, pk)
+			if !ok {
+				m.status.setOnline(pk, false, time.Now())
+			}
+		}
+	}
+	for pk, client := range conns {
+		if _, ok := watches[pk]; !ok {
+			wCtx, cancel := context.WithCancel(ctx)
+			watches[pk] = statusWatch{client: client, cancel: cancel}
+			go m.watchVisor(wCtx, pk, client)
+		}
+	}
+}
+
+// watchVisor checks whether the visor is online, and follows its events while it is, until ctx is done.
+func (m *Node) watchVisor(ctx context.Context, pk cipher.PubKey, client visor.RPCClient) {
+	for {
+		_, err := client.Uptime()
+		if ctx.Err() != nil {
+			return
+		}
+		m.status.setOnline(pk, err == nil, time.Now())
+		if err == nil {
+			m.followEvents(ctx, pk, client)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(statusCheckInterval):
+		}
+	}
+}
+
+// followEvents publishes the app and transport events of the visor until ctx is done or subscribing fails, such as
+// when the visor is gone or does not publish events.
+func (m *Node) followEvents(ctx context.Context, pk cipher.PubKey, client visor.RPCClient) {
+	in := visor.SubscribeIn{Kinds: []string{visor.EventKindApp, visor.EventKindTransport}}
+	for {
+		out, err := client.Subscribe(in)
+		if err != nil || ctx.Err() != nil {
+			return
+		}
+		in.After = out.Next
+		if out.Missed > 0 {
+			m.status.publish(StatusDelta{Type: StatusResync, PK: pk, Time: time.Now()})
+		}
+		for _, e := range out.Events {
+			m.status.publish(eventDelta(pk, client, e))
+		}
+	}
+}
+
+// eventDelta returns the delta of an event of the visor, with the state of its app or transport after the event.
+func eventDelta(pk cipher.PubKey, client visor.RPCClient, e visor.StreamEvent) StatusDelta {
+	d := StatusDelta{PK: pk, Time: e.Time, Event: &e.Event}
+	if e.Kind == visor.EventKindApp {
+		d.Type = StatusApp
+		if apps, err := client.Apps(); err == nil {
+			for _, app := range apps {
+				if app.Name == e.Subject {
+					d.App = app
+				}
+			}
+		}
+		return d
+	}
+	d.Type = StatusTransport
+	if tid, err := uuid.Parse(e.Subject); err == nil && e.Type != visor.EventTransportRemoved {
+		if tp, err := client.Transport(tid); err == nil {
+			d.Transport = tp
+		}
+	}
+	return d
+}
+
+// streams the status deltas of the visors over a WebSocket as JSON messages, starting with whether each visor is
+// online. Deltas may be filtered by the 'pk' and 'type' queries. Clients which fall behind are disconnected with the
+// 'try again later' close code, and should fetch the visors again before reconnecting.
+func (m *Node) getStatusStream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pks, err := pkSliceFromQuery(r, "pk", nil)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		types := strSliceFromQuery(r, "type", nil)
+		match := func(d StatusDelta) bool {
+			return (len(pks) == 0 || containsPK(pks, d.PK)) &&
+				(len(types) == 0 || hasTags(types, []string{string(d.Type)}))
+		}
+
+		ws, err := httputil.UpgradeWebSocket(w, r)
+		if err != nil {
+			log.WithError(err).Warn("Failed to upgrade status stream to websocket")
+			return
+		}
+		ch, current, unsubscribe := m.status.subscribe(time.Now())
+		defer unsubscribe()
+
+		// Messages of the client are discarded, and reading fails once the client is gone.
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if _, _, err := ws.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		send := func(d StatusDelta) bool {
+			if !match(d) {
+				return true
+			}
+			b, err := json.Marshal(d)
+			if err == nil {
+				err = ws.WriteText(b)
+			}
+			if err != nil {
+				_ = ws.Close(httputil.WebSocketNormalClosure, "") //nolint:errcheck
+				return false
+			}
+			return true
+		}
+		for _, d := range current {
+			if !send(d) {
+				return
+			}
+		}
+		for {
+			select {
+			case <-done:
+				_ = ws.Close(httputil.WebSocketNormalClosure, "") //nolint:errcheck
+				return
+			case d, ok := <-ch:
+				if !ok {
+					_ = ws.Close(httputil.WebSocketTryAgainLater, "status stream fell behind") //nolint:errcheck
+					return
+				}
+				if !send(d) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package hypervisor
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func TestStatusHub(t *testing.T) {
+	h := newStatusHub()
+	pk, _ := cipher.GenerateKeyPair()
+	now := time.Now()
+	h.setOnline(pk, true, now)
+
+	ch, current, unsubscribe := h.subscribe(now)
+	assert.Equal(t, []StatusDelta{{Type: StatusVisorOnline, PK: pk, Time: now}}, current)
+
+	// Only changes are published.
+	h.setOnline(pk, true, now)
+	h.setOnline(pk, false, now)
+	assert.Equal(t, StatusDelta{Type: StatusVisorOffline, PK: pk, Time: now}, <-ch)
+
+	// Subscribers which fall behind are dropped.
+	for i := 0; i <= statusBufferSize; i++ {
+		h.publish(StatusDelta{Type: StatusResync, PK: pk, Time: now})
+	}
+	for i := 0; i < statusBufferSize; i++ {
+		<-ch
+	}
+	_, ok := <-ch
+	assert.False(t, ok)
+	unsubscribe()
+}
+
+func TestNode_getStatusStream(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_status")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	m, err := NewNode(config)
+	require.NoError(t, err)
+	require.NoError(t, m.AddMockData(MockConfig{Nodes: 2, Churn: MockChurn{Disconnect: 1}}))
+	pks := m.selectNodes(&BulkRequest{All: true})
+
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+	dial := func(query string) <-chan StatusDelta {
+		ws, err := httputil.DialWebSocket(srv.URL+"/api/status/stream"+query, nil)
+		require.NoError(t, err)
+		deltas := make(chan StatusDelta, statusBufferSize)
+		go func() {
+			defer close(deltas)
+			defer func() { _ = ws.Close(httputil.WebSocketNormalClosure, "") }() //nolint:errcheck
+			for {
+				_, p, err := ws.ReadMessage()
+				if err != nil {
+					return
+				}
+				var d StatusDelta
+				if assert.NoError(t, json.Unmarshal(p, &d)) {
+					deltas <- d
+				}
+			}
+		}()
+		return deltas
+	}
+	next := func(deltas <-chan StatusDelta) StatusDelta {
+		select {
+		case d := <-deltas:
+			return d
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no status delta")
+			return StatusDelta{}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watches := make(map[cipher.PubKey]statusWatch)
+	all := dial("")
+	m.watchStatus(ctx, watches)
+	online := map[cipher.PubKey]bool{}
+	for len(online) < len(pks) {
+		d := next(all)
+		assert.Equal(t, StatusVisorOnline, d.Type)
+		online[d.PK] = true
+	}
+
+	// Events of transports are pushed with the transport. Events published before the visor is followed are not
+	// pushed, so transports are added until one is.
+	tps := dial("?type=transport&pk=" + pks[0].Hex())
+	remote, _ := cipher.GenerateKeyPair()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	var d StatusDelta
+	for d.Type == "" {
+		select {
+		case d = <-tps:
+		case <-ticker.C:
+			_, err := m.nodes[pks[0]].Client.AddTransport(remote, "native", true, 0, nil)
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no transport delta")
+		}
+	}
+	assert.Equal(t, StatusTransport, d.Type)
+	assert.Equal(t, pks[0], d.PK)
+	require.NotNil(t, d.Event)
+	assert.Equal(t, visor.EventTransportAdded, d.Event.Type)
+	require.NotNil(t, d.Transport)
+	assert.Equal(t, remote, d.Transport.Remote)
+
+	// Visors going offline are pushed once their connection changes.
+	m.stepMock(time.Now())
+	m.watchStatus(ctx, watches)
+	offline := map[cipher.PubKey]bool{}
+	for len(offline) < len(pks) {
+		if d := next(all); d.Type == StatusVisorOffline {
+			offline[d.PK] = true
+		}
+	}
+}
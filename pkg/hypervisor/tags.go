@@ -0,0 +1,235 @@
+package hypervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"go.etcd.io/bbolt"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+const (
+	boltTagBucketName = "visor_tags"
+	maxTagsPerVisor   = 32
+)
+
+var tagRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.:/-]{1,64}$`)
+
+// ValidateTag checks that the tag is 1 to 64 letters, digits, or any of '_.:/-', such as 'site:berlin'.
+func ValidateTag(tag string) error {
+	if !tagRegexp.MatchString(tag) {
+		return fmt.Errorf("invalid tag '%s': expected 1 to 64 letters, digits, or any of '_.:/-'", tag)
+	}
+	return nil
+}
+
+// TagStore stores the tags of visors, which group visors by site, hardware or purpose.
+type TagStore interface {
+	Tags(pk cipher.PubKey) []string
+	SetTags(pk cipher.PubKey, tags []string)
+	AllTags() map[cipher.PubKey][]string
+}
+
+// BoltTagStore implements TagStore, storing tags in a bbolt database.
+type BoltTagStore struct {
+	*bbolt.DB
+}
+
+// NewBoltTagStore creates a new BoltTagStore in the database, such as the database of a BoltUserStore.
+func NewBoltTagStore(db *bbolt.DB) (*BoltTagStore, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(boltTagBucketName))
+		return err
+	})
+	return &BoltTagStore{DB: db}, err
+}
+
+// Tags obtains the tags of the visor.
+func (s *BoltTagStore) Tags(pk cipher.PubKey) []string {
+	tags := make([]string, 0)
+	catch(s.View(func(tx *bbolt.Tx) error {
+		if raw := tx.Bucket([]byte(boltTagBucketName)).Get(pk[:]); raw != nil {
+			return json.Unmarshal(raw, &tags)
+		}
+		return nil
+	}))
+	return tags
+}
+
+// SetTags replaces the tags of the visor, or removes them if tags is empty.
+func (s *BoltTagStore) SetTags(pk cipher.PubKey, tags []string) {
+	catch(s.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(boltTagBucketName))
+		if len(tags) == 0 {
+			return b.Delete(pk[:])
+		}
+		raw, err := json.Marshal(tags)
+		if err != nil {
+			return err
+		}
+		return b.Put(pk[:], raw)
+	}))
+}
+
+// AllTags obtains the tags of all tagged visors.
+func (s *BoltTagStore) AllTags() map[cipher.PubKey][]string {
+	all := make(map[cipher.PubKey][]string)
+	catch(s.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(boltTagBucketName)).ForEach(func(k, raw []byte) error {
+			var pk cipher.PubKey
+			copy(pk[:], k)
+			var tags []string
+			if err := json.Unmarshal(raw, &tags); err != nil {
+				return err
+			}
+			all[pk] = tags
+			return nil
+		})
+	}))
+	return all
+}
+
+// hasTags returns whether have contains all of want.
+func hasTags(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			found = found || h == w
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// provides the tags of a node, which need not be connected.
+func (m *Node) getTags() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pk, err := pkFromParam(r, "pk")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, m.tags.Tags(pk))
+	}
+}
+
+// replaces the tags of a node, which need not be connected.
+func (m *Node) putTags() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pk, err := pkFromParam(r, "pk")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		var tags []string
+		if err := httputil.ReadJSON(r, &tags); err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadBody)
+			return
+		}
+		unique := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			if err := ValidateTag(tag); err != nil {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+				return
+			}
+			if !hasTags(unique, []string{tag}) {
+				unique = append(unique, tag)
+			}
+		}
+		if len(unique) > maxTagsPerVisor {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("nodes may have up to %d tags", maxTagsPerVisor))
+			return
+		}
+		sort.Strings(unique)
+		httputil.WriteJSON(w, r, http.StatusOK, m.setTags(pk, visor.SharedState{Tags: unique, UpdatedAt: time.Now()}))
+	}
+}
+
+// setTags sets the tags of the node, and stores them in the state shared by the hypervisors of the node if connected.
+// Otherwise, the tags are stored in the shared state once the node connects. It returns the resulting tags, which are
+// those of another hypervisor if it set the shared state more recently.
+func (m *Node) setTags(pk cipher.PubKey, state visor.SharedState) []string {
+	m.tags.SetTags(pk, state.Tags)
+	if _, client, ok := m.client(pk); ok {
+		if shared, err := client.SetSharedState(state); err == nil {
+			m.tags.SetTags(pk, shared.Tags)
+			return shared.Tags
+		}
+	}
+	m.mu.Lock()
+	m.pendingTags[pk] = state
+	m.mu.Unlock()
+	return state.Tags
+}
+
+// syncTags reconciles the tags of a newly connected node with the state shared by its hypervisors, so that tags set
+// through any hypervisor are seen by all of them.
+func (m *Node) syncTags(pk cipher.PubKey, client visor.RPCClient) {
+	m.mu.Lock()
+	pending, ok := m.pendingTags[pk]
+	delete(m.pendingTags, pk)
+	m.mu.Unlock()
+
+	if !ok {
+		shared, err := client.SharedState()
+		if err != nil {
+			log.WithError(err).Warnf("Failed to obtain shared state of visor %s", pk)
+			return
+		}
+		if !shared.UpdatedAt.IsZero() {
+			m.tags.SetTags(pk, shared.Tags)
+			return
+		}
+		// Nodes without shared state, such as nodes upgraded from older versions, adopt the tags of this hypervisor.
+		if pending.Tags = m.tags.Tags(pk); len(pending.Tags) == 0 {
+			return
+		}
+		pending.UpdatedAt = time.Now()
+	}
+	shared, err := client.SetSharedState(pending)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to sync tags of visor %s", pk)
+		m.mu.Lock()
+		if _, ok := m.pendingTags[pk]; !ok {
+			m.pendingTags[pk] = pending
+		}
+		m.mu.Unlock()
+		return
+	}
+	m.tags.SetTags(pk, shared.Tags)
+}
+
+// sharedTags returns the tags of the shared state of the node, if any and unless tags set through this hypervisor
+// are pending, or the stored tags otherwise. Stored tags are updated to the shared state. m.mu must be locked.
+func (m *Node) sharedTags(pk cipher.PubKey, stored []string, state *visor.SharedState) []string {
+	if _, ok := m.pendingTags[pk]; ok || state == nil {
+		return stored
+	}
+	if !reflect.DeepEqual(stored, state.Tags) && !(len(stored) == 0 && len(state.Tags) == 0) {
+		m.tags.SetTags(pk, state.Tags)
+	}
+	return state.Tags
+}
+
+// provides the public keys of the nodes of each tag.
+func (m *Node) getAllTags() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		byTag := make(map[string][]cipher.PubKey)
+		for pk, tags := range m.tags.AllTags() {
+			for _, tag := range tags {
+				byTag[tag] = append(byTag[tag], pk)
+			}
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, byTag)
+	}
+}
@@ -0,0 +1,75 @@
+package hypervisor
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/noise"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func TestNode_syncTags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_tags")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	newNode := func(name string) *Node {
+		config := makeConfig()
+		config.DBPath = filepath.Join(dir, name+".db")
+		m, err := NewNode(config)
+		require.NoError(t, err)
+		return m
+	}
+	a, b := newNode("a"), newNode("b")
+
+	pk, client, err := visor.NewMockRPCClient(rand.New(rand.NewSource(1)), 1, 1)
+	require.NoError(t, err)
+	connect := func(m *Node) {
+		m.mu.Lock()
+		m.nodes[pk] = appNodeConn{Addr: &noise.Addr{PK: pk, Addr: mockAddr("mock")}, Client: client}
+		m.mu.Unlock()
+		m.syncTags(pk, client)
+	}
+	disconnect := func(m *Node) {
+		m.mu.Lock()
+		delete(m.nodes, pk)
+		m.mu.Unlock()
+	}
+
+	// Visors without shared state adopt the tags of the hypervisor.
+	b.tags.SetTags(pk, []string{"legacy"})
+	connect(b)
+	connect(a)
+	assert.Equal(t, []string{"legacy"}, a.tags.Tags(pk))
+
+	// Tags set through one hypervisor are seen by the others.
+	assert.Equal(t, []string{"site:berlin"}, a.setTags(pk, visor.SharedState{
+		Tags:      []string{"site:berlin"},
+		UpdatedAt: time.Now(),
+	}))
+	summary, err := client.Summary()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"site:berlin"}, b.sharedTags(pk, b.tags.Tags(pk), summary.SharedState))
+	assert.Equal(t, []string{"site:berlin"}, b.tags.Tags(pk))
+
+	// Tags set while disconnected are synced on reconnection, unless set more recently through another hypervisor.
+	disconnect(b)
+	b.setTags(pk, visor.SharedState{Tags: []string{"stale"}, UpdatedAt: time.Now().Add(-time.Hour)})
+	assert.Equal(t, []string{"stale"}, b.tags.Tags(pk))
+	connect(b)
+	assert.Equal(t, []string{"site:berlin"}, b.tags.Tags(pk))
+
+	disconnect(b)
+	b.setTags(pk, visor.SharedState{Tags: []string{"rpi"}, UpdatedAt: time.Now()})
+	connect(b)
+	state, err := client.SharedState()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rpi"}, state.Tags)
+}
@@ -0,0 +1,97 @@
+package hypervisor
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// DefaultACMEHTTPAddr is the address HTTP-01 challenges are served at, which ACME servers connect to on port 80.
+const DefaultACMEHTTPAddr = ":80"
+
+// TLSConfig configures the hypervisor to serve its HTTP interface over HTTPS, with the certificate of the files or
+// a certificate obtained automatically via ACME.
+type TLSConfig struct {
+	CertFile string      `json:"cert_file,omitempty"` // PEM certificate chain.
+	KeyFile  string      `json:"key_file,omitempty"`  // PEM private key of the certificate.
+	ACME     *ACMEConfig `json:"acme,omitempty"`      // obtains and renews certificates, instead of the files.
+}
+
+// ACMEConfig configures obtaining certificates from an ACME certificate authority, such as Let's Encrypt.
+// Domains are validated with TLS-ALPN-01 challenges on the HTTPS address, and with HTTP-01 challenges on HTTPAddr.
+type ACMEConfig struct {
+	Domains      []string `json:"domains"`
+	Email        string   `json:"email,omitempty"`         // contact for expiry notices of the certificate authority.
+	DirectoryURL string   `json:"directory_url,omitempty"` // defaults to the production directory of Let's Encrypt.
+	CacheDir     string   `json:"cache_dir,omitempty"`     // defaults to 'certs' in the directory of db_path.
+
+	// HTTPAddr serves HTTP-01 challenges, and redirects other requests to HTTPS. Defaults to DefaultACMEHTTPAddr,
+	// not served if "-", which leaves TLS-ALPN-01 challenges only.
+	HTTPAddr string `json:"http_address,omitempty"`
+}
+
+// Validate checks the TLS config.
+func (c *TLSConfig) Validate() error {
+	if c.ACME == nil {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return errors.New("tls requires either 'cert_file' and 'key_file', or 'acme'")
+		}
+		return nil
+	}
+	if len(c.ACME.Domains) == 0 {
+		return errors.New("acme requires 'domains'")
+	}
+	return nil
+}
+
+func (c *ACMEConfig) manager(dbPath string) *autocert.Manager {
+	cacheDir := c.CacheDir
+	if cacheDir == "" {
+		cacheDir = filepath.Join(filepath.Dir(dbPath), "certs")
+	}
+	mgr := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cacheDir),
+		HostPolicy: autocert.HostWhitelist(c.Domains...),
+		Email:      c.Email,
+	}
+	if c.DirectoryURL != "" {
+		mgr.Client = &acme.Client{DirectoryURL: c.DirectoryURL}
+	}
+	return mgr
+}
+
+// ListenAndServe serves the HTTP interface of the hypervisor at the HTTP address of the interfaces config, over
+// HTTPS if the TLS config is set. ACME certificates are obtained on the first handshake, and renewed before they expire.
+func (m *Node) ListenAndServe() error {
+	addr := m.c.Interfaces.HTTPAddr
+	if m.c.TLS == nil {
+		return http.ListenAndServe(addr, m)
+	}
+	if err := m.c.TLS.Validate(); err != nil {
+		return err
+	}
+	srv := &http.Server{Addr: addr, Handler: m}
+	if m.c.TLS.ACME == nil {
+		return srv.ListenAndServeTLS(m.c.TLS.CertFile, m.c.TLS.KeyFile)
+	}
+
+	mgr := m.c.TLS.ACME.manager(m.c.DBPath)
+	srv.TLSConfig = mgr.TLSConfig()
+	if httpAddr := m.c.TLS.ACME.HTTPAddr; httpAddr != "-" {
+		if httpAddr == "" {
+			httpAddr = DefaultACMEHTTPAddr
+		}
+		handler := mgr.HTTPHandler(nil)
+		go func() {
+			log.Infof("serving ACME challenges on '%s'", httpAddr)
+			if err := http.ListenAndServe(httpAddr, handler); err != nil {
+				log.WithError(err).Error("Failed to serve ACME challenges")
+			}
+		}()
+	}
+	return srv.ListenAndServeTLS("", "")
+}
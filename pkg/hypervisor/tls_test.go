@@ -0,0 +1,46 @@
+package hypervisor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func TestTLSConfig_Validate(t *testing.T) {
+	cases := []struct {
+		conf TLSConfig
+		ok   bool
+	}{
+		{TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}, true},
+		{TLSConfig{CertFile: "cert.pem"}, false},
+		{TLSConfig{ACME: &ACMEConfig{Domains: []string{"example.com"}}}, true},
+		{TLSConfig{ACME: &ACMEConfig{}}, false},
+		{TLSConfig{ACME: &ACMEConfig{Domains: []string{"example.com"}, HTTPAddr: "-"}}, true},
+	}
+	for i, c := range cases {
+		err := c.conf.Validate()
+		assert.Equal(t, c.ok, err == nil, "case %d: %v", i, err)
+	}
+}
+
+func TestACMEConfig_manager(t *testing.T) {
+	conf := ACMEConfig{Domains: []string{"example.com"}, DirectoryURL: "https://acme.example.com/directory"}
+	mgr := conf.manager(filepath.Join("data", "users.db"))
+
+	assert.Equal(t, autocert.DirCache(filepath.Join("data", "certs")), mgr.Cache)
+	require.NotNil(t, mgr.Client)
+	assert.Equal(t, conf.DirectoryURL, mgr.Client.DirectoryURL)
+	assert.NoError(t, mgr.HostPolicy(nil, "example.com"))
+	assert.Error(t, mgr.HostPolicy(nil, "other.com"))
+
+	// Requests which are not challenges are redirected to HTTPS.
+	rec := httptest.NewRecorder()
+	mgr.HTTPHandler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/api/nodes", nil))
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "https://example.com/api/nodes", rec.Header().Get("Location"))
+}
@@ -0,0 +1,317 @@
+package hypervisor
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"go.etcd.io/bbolt"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+)
+
+const (
+	boltTokenBucketName = "api_tokens"
+	tokenPrefix         = "swm_"
+	tokenSecretLen      = 32
+)
+
+// Scopes of API tokens.
+const (
+	TokenScopeRead  = "read"  // allows the routes which only read the state of the hypervisor and visors.
+	TokenScopeWrite = "write" // allows all routes, including the routes which change state or open sessions.
+)
+
+// Errors associated with API tokens.
+var (
+	ErrBadToken       = errors.New("API token is either non-existent, expired, revoked, or ill-formatted")
+	ErrTokenScope     = errors.New("API token is not in scope of the request")
+	ErrTokenForbidden = errors.New("request requires a session, and is not allowed with API tokens")
+	ErrTokenNotFound  = errors.New("API token is either revoked or not found")
+)
+
+const tokenKey = ctxKey("token")
+
+func init() {
+	gob.Register(APIToken{})
+}
+
+// APIToken is a long-lived token which authorizes requests of a user, as an alternative to sessions for
+// automation. Only the hash of the token is stored.
+type APIToken struct {
+	ID      uuid.UUID     `json:"id"`
+	Name    string        `json:"name"`
+	User    string        `json:"username"`
+	Scopes  []string      `json:"scopes"`
+	Created time.Time     `json:"created"`
+	Expiry  time.Time     `json:"expiry,omitempty"` // never expires if zero.
+	Hash    cipher.SHA256 `json:"-"`
+}
+
+// HasScope returns whether the token has the scope. The write scope includes the read scope.
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == TokenScopeWrite {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired returns whether the token is expired.
+func (t *APIToken) Expired() bool {
+	return !t.Expiry.IsZero() && time.Now().After(t.Expiry)
+}
+
+// Encode encodes the token to bytes.
+func (t *APIToken) Encode() []byte {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(t); err != nil {
+		catch(err, "unexpected token encode error:")
+	}
+	return buf.Bytes()
+}
+
+// DecodeAPIToken decodes the token from bytes.
+func DecodeAPIToken(raw []byte) APIToken {
+	var t APIToken
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&t); err != nil {
+		catch(err, "unexpected decode token error:")
+	}
+	return t
+}
+
+// newTokenSecret returns a new random token, and its hash.
+func newTokenSecret() (string, cipher.SHA256) {
+	secret := tokenPrefix + hex.EncodeToString(cipher.RandByte(tokenSecretLen))
+	return secret, cipher.SumSHA256([]byte(secret))
+}
+
+// TokenStore stores API tokens.
+type TokenStore interface {
+	Token(hash cipher.SHA256) (APIToken, bool)
+	Tokens(user string) []APIToken
+	AddToken(token APIToken) bool
+	RemoveToken(user string, id uuid.UUID) bool
+}
+
+// BoltTokenStore implements TokenStore, storing tokens in a bbolt database.
+type BoltTokenStore struct {
+	*bbolt.DB
+}
+
+// NewBoltTokenStore creates a new BoltTokenStore in the database, such as the database of a BoltUserStore.
+func NewBoltTokenStore(db *bbolt.DB) (*BoltTokenStore, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(boltTokenBucketName))
+		return err
+	})
+	return &BoltTokenStore{DB: db}, err
+}
+
+// Token obtains the token of the hash. Returns true if the token exists.
+func (s *BoltTokenStore) Token(hash cipher.SHA256) (token APIToken, ok bool) {
+	catch(s.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket([]byte(boltTokenBucketName)).Get(hash[:])
+		if raw == nil {
+			return nil
+		}
+		token, ok = DecodeAPIToken(raw), true
+		return nil
+	}))
+	return token, ok
+}
+
+// Tokens obtains the tokens of the user.
+func (s *BoltTokenStore) Tokens(user string) []APIToken {
+	tokens := make([]APIToken, 0)
+	catch(s.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(boltTokenBucketName)).ForEach(func(_, raw []byte) error {
+			if t := DecodeAPIToken(raw); t.User == user {
+				tokens = append(tokens, t)
+			}
+			return nil
+		})
+	}))
+	return tokens
+}
+
+// AddToken adds a new token; ok is true when successful.
+func (s *BoltTokenStore) AddToken(token APIToken) (ok bool) {
+	catch(s.Update(func(tx *bbolt.Tx) error {
+		tokens := tx.Bucket([]byte(boltTokenBucketName))
+		if tokens.Get(token.Hash[:]) != nil {
+			return nil
+		}
+		ok = true
+		return tokens.Put(token.Hash[:], token.Encode())
+	}))
+	return ok
+}
+
+// RemoveToken removes the token of the user with the ID. Returns true if the token existed.
+func (s *BoltTokenStore) RemoveToken(user string, id uuid.UUID) (ok bool) {
+	catch(s.Update(func(tx *bbolt.Tx) error {
+		tokens := tx.Bucket([]byte(boltTokenBucketName))
+		var key []byte
+		err := tokens.ForEach(func(k, raw []byte) error {
+			if t := DecodeAPIToken(raw); t.User == user && t.ID == id {
+				key = k
+			}
+			return nil
+		})
+		if err != nil || key == nil {
+			return err
+		}
+		ok = true
+		return tokens.Delete(key)
+	}))
+	return ok
+}
+
+// bearerToken returns the token of the 'Authorization: Bearer' header of the request, if any.
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if len(h) < len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(h[len(prefix):]), true
+}
+
+// authorizeToken authorizes the request with the API token, returning the status and error on failure. The scope of
+// the token is checked by RequireScope.
+func (s *UserManager) authorizeToken(r *http.Request, secret string) (context.Context, int, error) {
+	token, ok := s.tokens.Token(cipher.SumSHA256([]byte(secret)))
+	if !ok || token.Expired() {
+		return nil, http.StatusUnauthorized, ErrBadToken
+	}
+	user, ok := s.db.User(token.User)
+	if !ok {
+		return nil, http.StatusUnauthorized, ErrBadToken
+	}
+	setAuditActor(r, user.Name, token.Name)
+	ctx := context.WithValue(r.Context(), userKey, user)
+	return context.WithValue(ctx, tokenKey, token), 0, nil
+}
+
+// RequireScope returns an http middleware which rejects requests authorized with API tokens without the scope. Each
+// route requires a scope explicitly, as routes of any method may change state, such as routes upgrading to
+// WebSockets. Requests authorized with sessions, or without authorization, pass.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token, ok := r.Context().Value(tokenKey).(APIToken); ok && !token.HasScope(scope) {
+				httputil.WriteJSON(w, r, http.StatusForbidden, ErrTokenScope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession is an http middleware which rejects requests authorized with API tokens, such as requests which
+// manage credentials.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(tokenKey).(APIToken); ok {
+			httputil.WriteJSON(w, r, http.StatusForbidden, ErrTokenForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Tokens returns a HandlerFunc for listing the API tokens of the user.
+func (s *UserManager) Tokens() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(userKey).(User)
+		httputil.WriteJSON(w, r, http.StatusOK, s.tokens.Tokens(user.Name))
+	}
+}
+
+// TokenRequest is the request body of creating an API token.
+type TokenRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`     // read only if empty.
+	ExpiresIn string   `json:"expires_in"` // duration such as '720h', never expires if empty.
+}
+
+// NewTokenResp is a created API token, with the secret of the token.
+type NewTokenResp struct {
+	Token string `json:"token"`
+	APIToken
+}
+
+// CreateToken returns a HandlerFunc for creating API tokens. The token is only included in the response.
+func (s *UserManager) CreateToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(userKey).(User)
+		var rb TokenRequest
+		if err := httputil.ReadJSON(r, &rb); err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadBody)
+			return
+		}
+		if len(rb.Scopes) == 0 {
+			rb.Scopes = []string{TokenScopeRead}
+		}
+		for _, scope := range rb.Scopes {
+			if scope != TokenScopeRead && scope != TokenScopeWrite {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid scope '%s'", scope))
+				return
+			}
+		}
+
+		secret, hash := newTokenSecret()
+		token := APIToken{
+			ID:      uuid.New(),
+			Name:    rb.Name,
+			User:    user.Name,
+			Scopes:  rb.Scopes,
+			Created: time.Now().UTC(),
+			Hash:    hash,
+		}
+		if rb.ExpiresIn != "" {
+			d, err := time.ParseDuration(rb.ExpiresIn)
+			if err != nil || d <= 0 {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, fmt.Errorf("invalid 'expires_in': %s", rb.ExpiresIn))
+				return
+			}
+			token.Expiry = token.Created.Add(d)
+		}
+		if ok := s.tokens.AddToken(token); !ok {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, errors.New("failed to create API token"))
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, NewTokenResp{
+			Token:    secret,
+			APIToken: token,
+		})
+	}
+}
+
+// RevokeToken returns a HandlerFunc for revoking an API token of the user.
+func (s *UserManager) RevokeToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(userKey).(User)
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if ok := s.tokens.RemoveToken(user.Name, id); !ok {
+			httputil.WriteJSON(w, r, http.StatusNotFound, ErrTokenNotFound)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, true)
+	}
+}
@@ -0,0 +1,257 @@
+package hypervisor
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+)
+
+// Parameters of TOTP codes, as defined by RFC 6238 and expected by authenticator apps.
+const (
+	totpIssuer    = "Skywire Hypervisor"
+	totpSecretLen = 20
+	totpPeriod    = 30 * time.Second
+	totpDigits    = 6
+	totpSkew      = 1 // of periods, either side of the current period.
+)
+
+// Parameters of recovery codes, which authenticate once each in place of TOTP codes.
+const (
+	recoveryCodeCount = 10
+	recoveryCodeLen   = 5 // bytes, hex encoded with a dash in the middle.
+)
+
+// Errors associated with two-factor authentication.
+var (
+	ErrTOTPRequired    = errors.New("two-factor authentication 'code' is required")
+	ErrBadTOTP         = errors.New("incorrect two-factor authentication code")
+	ErrTOTPEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTOTPNotEnrolled = errors.New("two-factor authentication is not enrolled")
+)
+
+// totpSecretEncoding encodes TOTP secrets, as expected by authenticator apps.
+var totpSecretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpCode returns the HOTP code of the secret at the counter, as of RFC 4226.
+func totpCode(secret []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:]) //nolint:errcheck
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0xf
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, code%uint32(math.Pow10(totpDigits)))
+}
+
+// totpCounter returns the TOTP counter of the time.
+func totpCounter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(totpPeriod/time.Second))
+}
+
+// totpURI returns the otpauth URI of the secret of the user, for authenticator apps.
+func totpURI(username string, secret []byte) string {
+	q := url.Values{}
+	q.Set("secret", totpSecretEncoding.EncodeToString(secret))
+	q.Set("issuer", totpIssuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(int(totpPeriod/time.Second)))
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + totpIssuer + ":" + username,
+		RawQuery: q.Encode(),
+	}).String()
+}
+
+// TOTPEnabled returns whether the user authenticates with a TOTP code, in addition to the password.
+func (u *User) TOTPEnabled() bool {
+	return len(u.TOTPSecret) > 0
+}
+
+// verifyTOTP verifies the code against the secret at now, rejecting codes of counters which were already used.
+func (u *User) verifyTOTP(secret []byte, code string, now time.Time) bool {
+	counter := totpCounter(now)
+	for c := counter - totpSkew; c <= counter+totpSkew; c++ {
+		if c > u.TOTPCounter && hmac.Equal([]byte(totpCode(secret, c)), []byte(code)) {
+			u.TOTPCounter = c
+			return true
+		}
+	}
+	return false
+}
+
+// VerifySecondFactor verifies the TOTP code or recovery code of the user at now. Recovery codes are used up.
+func (u *User) VerifySecondFactor(code string, now time.Time) bool {
+	code = strings.TrimSpace(code)
+	if u.verifyTOTP(u.TOTPSecret, code, now) {
+		return true
+	}
+	hash := cipher.SumSHA256([]byte(strings.ToLower(code)))
+	for i, h := range u.RecoveryCodes {
+		if h == hash {
+			u.RecoveryCodes = append(u.RecoveryCodes[:i:i], u.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// newRecoveryCodes sets new recovery codes of the user, returning the codes.
+func (u *User) newRecoveryCodes() []string {
+	codes := make([]string, recoveryCodeCount)
+	u.RecoveryCodes = make([]cipher.SHA256, recoveryCodeCount)
+	for i := range codes {
+		code := hex.EncodeToString(cipher.RandByte(recoveryCodeLen))
+		codes[i] = code[:len(code)/2] + "-" + code[len(code)/2:]
+		u.RecoveryCodes[i] = cipher.SumSHA256([]byte(codes[i]))
+	}
+	return codes
+}
+
+// disableTOTP removes the second factor of the user.
+func (u *User) disableTOTP() {
+	u.TOTPSecret, u.PendingTOTPSecret, u.RecoveryCodes, u.TOTPCounter = nil, nil, nil, 0
+}
+
+// TOTPEnrollmentResp is a TOTP secret to be confirmed, with its otpauth URI for authenticator apps.
+type TOTPEnrollmentResp struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// SecondFactorRequest is the request body of confirming TOTP secrets and of actions which require the second factor.
+type SecondFactorRequest struct {
+	Password string `json:"password,omitempty"` // required to disable two-factor authentication.
+	Code     string `json:"code"`               // TOTP or recovery code.
+}
+
+// RecoveryCodesResp holds new recovery codes, which are only included in the response.
+type RecoveryCodesResp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnrollTOTP returns a HandlerFunc which generates a TOTP secret for the user, to be confirmed with ConfirmTOTP.
+func (s *UserManager) EnrollTOTP() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(userKey).(User)
+		if user.TOTPEnabled() {
+			httputil.WriteJSON(w, r, http.StatusConflict, ErrTOTPEnabled)
+			return
+		}
+		user.PendingTOTPSecret = cipher.RandByte(totpSecretLen)
+		if ok := s.db.SetUser(user); !ok {
+			httputil.WriteJSON(w, r, http.StatusForbidden, ErrUserNotFound)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, TOTPEnrollmentResp{
+			Secret: totpSecretEncoding.EncodeToString(user.PendingTOTPSecret),
+			URI:    totpURI(user.Name, user.PendingTOTPSecret),
+		})
+	}
+}
+
+// ConfirmTOTP returns a HandlerFunc which enables the enrolled TOTP secret of the user once a code of the secret is
+// provided, and responds with recovery codes.
+func (s *UserManager) ConfirmTOTP() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(userKey).(User)
+		var rb SecondFactorRequest
+		if err := httputil.ReadJSON(r, &rb); err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadBody)
+			return
+		}
+		if user.TOTPEnabled() {
+			httputil.WriteJSON(w, r, http.StatusConflict, ErrTOTPEnabled)
+			return
+		}
+		if len(user.PendingTOTPSecret) == 0 {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrTOTPNotEnrolled)
+			return
+		}
+		if !user.verifyTOTP(user.PendingTOTPSecret, strings.TrimSpace(rb.Code), time.Now()) {
+			httputil.WriteJSON(w, r, http.StatusUnauthorized, ErrBadTOTP)
+			return
+		}
+		user.TOTPSecret, user.PendingTOTPSecret = user.PendingTOTPSecret, nil
+		codes := user.newRecoveryCodes()
+		if ok := s.db.SetUser(user); !ok {
+			httputil.WriteJSON(w, r, http.StatusForbidden, ErrUserNotFound)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, RecoveryCodesResp{codes})
+	}
+}
+
+// NewRecoveryCodes returns a HandlerFunc which replaces the recovery codes of the user, once authenticated with the
+// second factor.
+func (s *UserManager) NewRecoveryCodes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.secondFactor(w, r, false)
+		if !ok {
+			return
+		}
+		codes := user.newRecoveryCodes()
+		if ok := s.db.SetUser(user); !ok {
+			httputil.WriteJSON(w, r, http.StatusForbidden, ErrUserNotFound)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, RecoveryCodesResp{codes})
+	}
+}
+
+// DisableTOTP returns a HandlerFunc which disables two-factor authentication of the user, once authenticated with
+// the password and the second factor.
+func (s *UserManager) DisableTOTP() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.secondFactor(w, r, true)
+		if !ok {
+			return
+		}
+		user.disableTOTP()
+		if ok := s.db.SetUser(user); !ok {
+			httputil.WriteJSON(w, r, http.StatusForbidden, ErrUserNotFound)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, true)
+	}
+}
+
+// secondFactor authenticates the user of the request with the 'code' of the request body, and the 'password' too if
+// withPassword is set. It responds with the error otherwise.
+func (s *UserManager) secondFactor(w http.ResponseWriter, r *http.Request, withPassword bool) (User, bool) {
+	user := r.Context().Value(userKey).(User)
+	var rb SecondFactorRequest
+	if err := httputil.ReadJSON(r, &rb); err != nil {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadBody)
+		return user, false
+	}
+	if !user.TOTPEnabled() {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, ErrTOTPNotEnabled)
+		return user, false
+	}
+	if withPassword && !user.VerifyPassword(rb.Password) {
+		httputil.WriteJSON(w, r, http.StatusUnauthorized, ErrBadLogin)
+		return user, false
+	}
+	if !user.VerifySecondFactor(rb.Code, time.Now()) {
+		httputil.WriteJSON(w, r, http.StatusUnauthorized, ErrBadTOTP)
+		return user, false
+	}
+	return user, true
+}
@@ -0,0 +1,116 @@
+package hypervisor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// Test vectors of RFC 6238, truncated to 6 digits.
+	secret := []byte("12345678901234567890")
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, code := range cases {
+		assert.Equal(t, code, totpCode(secret, totpCounter(time.Unix(unix, 0))), unix)
+	}
+}
+
+func TestUserManager_TOTP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hypervisor_totp")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	config := makeConfig()
+	config.DBPath = filepath.Join(dir, "users.db")
+	m, err := NewNode(config)
+	require.NoError(t, err)
+
+	var cookies []*http.Cookie
+	post := func(uri, body string, out interface{}) (int, string) {
+		req := httptest.NewRequest(http.MethodPost, uri, strings.NewReader(body))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, req)
+		if c := w.Result().Cookies(); len(c) > 0 {
+			cookies = c
+		}
+		if out != nil && w.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(out))
+		}
+		return w.Code, w.Body.String()
+	}
+	login := func(code string) (int, string) {
+		cookies = nil
+		return post("/api/login", `{"username":"admin","password":"Secure1234","code":"`+code+`"}`, nil)
+	}
+
+	account := `{"username":"admin","password":"Secure1234"}`
+	status, _ := post("/api/create-account", account, nil)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = login("")
+	require.Equal(t, http.StatusOK, status)
+
+	var enrollment struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}
+	status, _ = post("/api/2fa/enroll", "", &enrollment)
+	require.Equal(t, http.StatusOK, status)
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/"))
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+	secret, err := totpSecretEncoding.DecodeString(enrollment.Secret)
+	require.NoError(t, err)
+
+	counter := totpCounter(time.Now())
+	status, _ = post("/api/2fa/confirm", `{"code":"000000x"}`, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	var recovery struct {
+		Codes []string `json:"recovery_codes"`
+	}
+	status, _ = post("/api/2fa/confirm", `{"code":"`+totpCode(secret, counter)+`"}`, &recovery)
+	require.Equal(t, http.StatusOK, status)
+	assert.Len(t, recovery.Codes, recoveryCodeCount)
+	status, _ = post("/api/2fa/enroll", "", nil)
+	assert.Equal(t, http.StatusConflict, status)
+
+	status, body := login("")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Contains(t, body, ErrTOTPRequired.Error())
+
+	// Codes may not be reused.
+	status, body = login(totpCode(secret, counter))
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Contains(t, body, ErrBadTOTP.Error())
+	status, _ = login(totpCode(secret, counter+1))
+	assert.Equal(t, http.StatusOK, status)
+
+	status, _ = login(recovery.Codes[0])
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = login(recovery.Codes[0])
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = login(recovery.Codes[1])
+	require.Equal(t, http.StatusOK, status)
+	status, _ = post("/api/2fa/disable", `{"password":"Wrong1234","code":"`+recovery.Codes[2]+`"}`, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = post("/api/2fa/disable", `{"password":"Secure1234","code":"`+recovery.Codes[2]+`"}`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = login("")
+	assert.Equal(t, http.StatusOK, status)
+}
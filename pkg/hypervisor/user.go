@@ -18,6 +18,24 @@ const (
 	passwordSaltLen    = 16
 )
 
+// InitialUser is the name of the account which may be created without authorization, to bootstrap the hypervisor.
+// It is an admin, and other accounts are managed by admins.
+const InitialUser = "admin"
+
+// Roles of users, from least to most privileged.
+const (
+	RoleReadOnly = "read-only" // may view nodes.
+	RoleOperator = "operator"  // may also manage nodes, their apps, transports and routes.
+	RoleAdmin    = "admin"     // may also execute commands on nodes, and manage users.
+)
+
+var roleRanks = map[string]int{RoleReadOnly: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// ValidRole returns whether the role is one of the roles of users.
+func ValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
 func init() {
 	gob.Register(User{})
 }
@@ -27,6 +45,25 @@ type User struct {
 	Name   string
 	PwSalt []byte
 	PwHash cipher.SHA256
+	Role   string // RoleAdmin if empty, for users created before roles.
+
+	TOTPSecret        []byte          // two-factor authentication is enabled if set.
+	PendingTOTPSecret []byte          // enrolled, until confirmed with a code.
+	TOTPCounter       uint64          // of the last used TOTP code, which may not be reused.
+	RecoveryCodes     []cipher.SHA256 // hashes of unused recovery codes.
+}
+
+// UserRole returns the role of the user.
+func (u *User) UserRole() string {
+	if u.Role == "" {
+		return RoleAdmin
+	}
+	return u.Role
+}
+
+// HasRole returns whether the user has the role, or a more privileged role.
+func (u *User) HasRole(role string) bool {
+	return roleRanks[u.UserRole()] >= roleRanks[role]
 }
 
 // SetName checks the provided name, and sets the name if format is valid.
@@ -74,6 +111,7 @@ func DecodeUser(raw []byte) User {
 // UserStore stores users.
 type UserStore interface {
 	User(name string) (User, bool)
+	Users() []User
 	AddUser(user User) bool
 	SetUser(user User) bool
 	RemoveUser(name string)
@@ -116,6 +154,18 @@ func (s *BoltUserStore) User(name string) (user User, ok bool) {
 	return user, ok
 }
 
+// Users obtains all users.
+func (s *BoltUserStore) Users() []User {
+	users := make([]User, 0)
+	catch(s.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(boltUserBucketName)).ForEach(func(_, raw []byte) error {
+			users = append(users, DecodeUser(raw))
+			return nil
+		})
+	}))
+	return users
+}
+
 // AddUser adds a new user; ok is true when successful.
 func (s *BoltUserStore) AddUser(user User) (ok bool) {
 	catch(s.Update(func(tx *bbolt.Tx) error {
@@ -173,6 +223,15 @@ func (s *SingleUserStore) User(name string) (User, bool) {
 	return User{}, false
 }
 
+// Users gets the single user, if it exists.
+func (s *SingleUserStore) Users() []User {
+	users := make([]User, 0, 1)
+	if user, ok := s.UserStore.User(s.username); ok {
+		users = append(users, user)
+	}
+	return users
+}
+
 // AddUser adds a new user.
 func (s *SingleUserStore) AddUser(user User) bool {
 	if s.allowName(user.Name) {
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-chi/chi"
 	"github.com/google/uuid"
 	"github.com/gorilla/securecookie"
 
@@ -27,6 +28,9 @@ var (
 	ErrBadPasswordFormat = errors.New("format of 'password' is not accepted")
 	ErrUserNotCreated    = errors.New("failed to create new user: username is either already taken, or unaccepted")
 	ErrUserNotFound      = errors.New("user is either deleted or not found")
+	ErrBadRole           = errors.New("role is not one of 'admin', 'operator' or 'read-only'")
+	ErrRoleForbidden     = errors.New("role of user does not permit the request")
+	ErrModifySelf        = errors.New("users cannot remove their own account or change their own role")
 )
 
 // for use with context.Context
@@ -48,22 +52,33 @@ type Session struct {
 type UserManager struct {
 	c        CookieConfig
 	db       UserStore
+	tokens   TokenStore
 	sessions map[uuid.UUID]Session
 	crypto   *securecookie.SecureCookie
+	limiter  *rateLimiter
 	mu       *sync.RWMutex
 }
 
 // NewUserManager creates a new UserManager.
-func NewUserManager(users UserStore, config CookieConfig) *UserManager {
+func NewUserManager(users UserStore, tokens TokenStore, config CookieConfig) *UserManager {
 	return &UserManager{
 		db:       users,
+		tokens:   tokens,
 		c:        config,
 		sessions: make(map[uuid.UUID]Session),
 		crypto:   securecookie.New(config.HashKey, config.BlockKey),
+		limiter:  newRateLimiter(nil),
 		mu:       new(sync.RWMutex),
 	}
 }
 
+// LoginRequest is the request body of logins.
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Code     string `json:"code,omitempty"` // TOTP or recovery code, if two-factor authentication is enabled.
+}
+
 // Login returns a HandlerFunc for login operations.
 func (s *UserManager) Login() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -71,23 +86,50 @@ func (s *UserManager) Login() http.HandlerFunc {
 			httputil.WriteJSON(w, r, http.StatusForbidden, ErrNotLoggedOut)
 			return
 		}
-		var rb struct {
-			Username string `json:"username"`
-			Password string `json:"password"`
-		}
+		var rb LoginRequest
 		if err := httputil.ReadJSON(r, &rb); err != nil {
 			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadBody)
 			return
 		}
+		ip := s.limiter.clientIP(r)
+		keys := []string{ipKey(ip), usernameKey(rb.Username)}
+		if retry := s.limiter.lockedOut(keys...); retry > 0 {
+			log.WithField("ip", ip).WithField("username", rb.Username).Warn("Rejected login while locked out.")
+			writeTooManyRequests(w, r, retry, ErrLockedOut)
+			return
+		}
 		user, ok := s.db.User(rb.Username)
 		if !ok || !user.VerifyPassword(rb.Password) {
+			failures := s.limiter.fail(keys...)
+			log.WithField("ip", ip).WithField("username", rb.Username).WithField("failures", failures).
+				Warn("Failed login.")
 			httputil.WriteJSON(w, r, http.StatusUnauthorized, ErrBadLogin)
 			return
 		}
+		if user.TOTPEnabled() {
+			if rb.Code == "" {
+				httputil.WriteJSON(w, r, http.StatusUnauthorized, ErrTOTPRequired)
+				return
+			}
+			if !user.VerifySecondFactor(rb.Code, time.Now()) {
+				failures := s.limiter.fail(keys...)
+				log.WithField("ip", ip).WithField("username", rb.Username).WithField("failures", failures).
+					Warn("Failed two-factor authentication of login.")
+				httputil.WriteJSON(w, r, http.StatusUnauthorized, ErrBadTOTP)
+				return
+			}
+			if ok := s.db.SetUser(user); !ok {
+				httputil.WriteJSON(w, r, http.StatusForbidden, ErrUserNotFound)
+				return
+			}
+		}
+		s.limiter.succeed(usernameKey(rb.Username))
+		log.WithField("ip", ip).WithField("username", rb.Username).Info("Logged in.")
 		s.newSession(w, Session{
 			User:   rb.Username,
 			Expiry: time.Now().Add(s.c.ExpiresDuration),
 		})
+		setAuditActor(r, rb.Username, "")
 		// http.SetCookie()
 		httputil.WriteJSON(w, r, http.StatusOK, ok)
 	}
@@ -104,14 +146,34 @@ func (s *UserManager) Logout() http.HandlerFunc {
 	}
 }
 
-// Authorize is an http middleware for authorizing requests.
+// Authorize is an http middleware for authorizing requests with a session cookie, or with an API token in the
+// 'Authorization: Bearer' header.
 func (s *UserManager) Authorize(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if secret, ok := bearerToken(r); ok {
+			ip := s.limiter.clientIP(r)
+			if retry := s.limiter.lockedOut(ipKey(ip)); retry > 0 {
+				writeTooManyRequests(w, r, retry, ErrLockedOut)
+				return
+			}
+			ctx, status, err := s.authorizeToken(r, secret)
+			if err != nil {
+				if status == http.StatusUnauthorized {
+					failures := s.limiter.fail(ipKey(ip))
+					log.WithField("ip", ip).WithField("failures", failures).Warn("Failed authorization with API token.")
+				}
+				httputil.WriteJSON(w, r, status, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
 		user, session, ok := s.session(r)
 		if !ok {
 			httputil.WriteJSON(w, r, http.StatusUnauthorized, ErrBadSession)
 			return
 		}
+		setAuditActor(r, user.Name, "")
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, userKey, user)
 		ctx = context.WithValue(ctx, sessionKey, session)
@@ -119,16 +181,33 @@ func (s *UserManager) Authorize(next http.Handler) http.Handler {
 	})
 }
 
+// RequireRole returns an http middleware which rejects requests of users without the role, or a more privileged
+// role. Requests pass if authorization is disabled.
+func (s *UserManager) RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, ok := r.Context().Value(userKey).(User); ok && !user.HasRole(role) {
+				httputil.WriteJSON(w, r, http.StatusForbidden, ErrRoleForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ChangePasswordRequest is the request body of changing the password of the user.
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
 // ChangePassword returns a HandlerFunc for changing the user's password.
 func (s *UserManager) ChangePassword() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var (
 			user = r.Context().Value(userKey).(User)
 		)
-		var rb struct {
-			OldPassword string `json:"old_password"`
-			NewPassword string `json:"new_password"`
-		}
+		var rb ChangePasswordRequest
 		if err := httputil.ReadJSON(r, &rb); err != nil {
 			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
 			return
@@ -150,18 +229,77 @@ func (s *UserManager) ChangePassword() http.HandlerFunc {
 	}
 }
 
-// CreateAccount returns a HandlerFunc for account creation.
+// AccountRequest is the request body of creating the initial account.
+type AccountRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// CreateAccount returns a HandlerFunc for creation of the InitialUser account.
 func (s *UserManager) CreateAccount() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var rb struct {
-			Username string `json:"username"`
-			Password string `json:"password"`
-		}
+		var rb AccountRequest
 		if err := httputil.ReadJSON(r, &rb); err != nil {
 			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
 			return
 		}
-		var user User
+		user := User{Role: RoleAdmin}
+		if ok := user.SetName(rb.Username); !ok {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadUsernameFormat)
+			return
+		}
+		if ok := user.SetPassword(rb.Password); !ok {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadPasswordFormat)
+			return
+		}
+		if user.Name != InitialUser || !s.db.AddUser(user) {
+			httputil.WriteJSON(w, r, http.StatusForbidden, ErrUserNotCreated)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, true)
+	}
+}
+
+// UserSummary describes a user.
+type UserSummary struct {
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	TwoFactor bool   `json:"two_factor"`
+}
+
+// Users returns a HandlerFunc for listing users.
+func (s *UserManager) Users() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		users := s.db.Users()
+		summaries := make([]UserSummary, 0, len(users))
+		for _, user := range users {
+			summaries = append(summaries, UserSummary{Username: user.Name, Role: user.UserRole(),
+				TwoFactor: user.TOTPEnabled()})
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, summaries)
+	}
+}
+
+// AddUserRequest is the request body of adding a user.
+type AddUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+// AddUser returns a HandlerFunc for adding users with a role.
+func (s *UserManager) AddUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rb AddUserRequest
+		if err := httputil.ReadJSON(r, &rb); err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadBody)
+			return
+		}
+		if !ValidRole(rb.Role) {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadRole)
+			return
+		}
+		user := User{Role: rb.Role}
 		if ok := user.SetName(rb.Username); !ok {
 			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadUsernameFormat)
 			return
@@ -174,16 +312,101 @@ func (s *UserManager) CreateAccount() http.HandlerFunc {
 			httputil.WriteJSON(w, r, http.StatusForbidden, ErrUserNotCreated)
 			return
 		}
+		httputil.WriteJSON(w, r, http.StatusOK, UserSummary{Username: user.Name, Role: user.Role})
+	}
+}
+
+// UpdateUserRequest is the request body of updating a user. Unset fields are left unchanged.
+type UpdateUserRequest struct {
+	Role             string `json:"role,omitempty"`
+	Password         string `json:"password,omitempty"`
+	DisableTwoFactor bool   `json:"disable_two_factor,omitempty"` // for users who lost their second factor.
+}
+
+// UpdateUser returns a HandlerFunc for changing the role of a user, or resetting their password or two-factor
+// authentication. Sessions of the user end if their password is reset.
+func (s *UserManager) UpdateUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		self := r.Context().Value(userKey).(User)
+		var rb UpdateUserRequest
+		if err := httputil.ReadJSON(r, &rb); err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadBody)
+			return
+		}
+		user, ok := s.db.User(chi.URLParam(r, "username"))
+		if !ok {
+			httputil.WriteJSON(w, r, http.StatusNotFound, ErrUserNotFound)
+			return
+		}
+		if rb.Role != "" {
+			if !ValidRole(rb.Role) {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadRole)
+				return
+			}
+			if user.Name == self.Name && rb.Role != self.UserRole() {
+				httputil.WriteJSON(w, r, http.StatusForbidden, ErrModifySelf)
+				return
+			}
+			user.Role = rb.Role
+		}
+		if rb.Password != "" {
+			if ok := user.SetPassword(rb.Password); !ok {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, ErrBadPasswordFormat)
+				return
+			}
+		}
+		if rb.DisableTwoFactor {
+			user.disableTOTP()
+		}
+		if ok := s.db.SetUser(user); !ok {
+			httputil.WriteJSON(w, r, http.StatusNotFound, ErrUserNotFound)
+			return
+		}
+		if rb.Password != "" {
+			s.delAllSessionsOfUser(user.Name)
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, UserSummary{Username: user.Name, Role: user.UserRole(),
+			TwoFactor: user.TOTPEnabled()})
+	}
+}
+
+// RemoveUser returns a HandlerFunc for removing users, along with their sessions and API tokens.
+func (s *UserManager) RemoveUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		self := r.Context().Value(userKey).(User)
+		name := chi.URLParam(r, "username")
+		if name == self.Name {
+			httputil.WriteJSON(w, r, http.StatusForbidden, ErrModifySelf)
+			return
+		}
+		if _, ok := s.db.User(name); !ok {
+			httputil.WriteJSON(w, r, http.StatusNotFound, ErrUserNotFound)
+			return
+		}
+		s.db.RemoveUser(name)
+		s.delAllSessionsOfUser(name)
+		for _, token := range s.tokens.Tokens(name) {
+			s.tokens.RemoveToken(name, token.ID)
+		}
 		httputil.WriteJSON(w, r, http.StatusOK, true)
 	}
 }
 
+// UserInfoResp is the info of the user, and of their sessions.
+type UserInfoResp struct {
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	TwoFactor bool      `json:"two_factor"`
+	Current   Session   `json:"current_session"`
+	Sessions  []Session `json:"other_sessions"`
+}
+
 // UserInfo returns a HandlerFunc for obtaining user info.
 func (s *UserManager) UserInfo() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var (
-			user    = r.Context().Value(userKey).(User)
-			session = r.Context().Value(sessionKey).(Session)
+			user       = r.Context().Value(userKey).(User)
+			session, _ = r.Context().Value(sessionKey).(Session) // zero if authorized with an API token.
 		)
 		var otherSessions []Session
 		s.mu.RLock()
@@ -193,14 +416,12 @@ func (s *UserManager) UserInfo() http.HandlerFunc {
 			}
 		}
 		s.mu.RUnlock()
-		httputil.WriteJSON(w, r, http.StatusOK, struct {
-			Username string    `json:"username"`
-			Current  Session   `json:"current_session"`
-			Sessions []Session `json:"other_sessions"`
-		}{
-			Username: user.Name,
-			Current:  session,
-			Sessions: otherSessions,
+		httputil.WriteJSON(w, r, http.StatusOK, UserInfoResp{
+			Username:  user.Name,
+			Role:      user.UserRole(),
+			TwoFactor: user.TOTPEnabled(),
+			Current:   session,
+			Sessions:  otherSessions,
 		})
 	}
 }
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Dial failure reasons recorded by DialMetrics.
+const (
+	DialTimeout  = "timeout"
+	DialRefused  = "refused"
+	DialNoRoute  = "no_route"
+	DialCanceled = "canceled"
+	DialOther    = "other"
+)
+
+// DialMetrics records metrics of outbound dials, labeled by network type.
+// Like TransportMetrics, it is not registered automatically.
+type DialMetrics struct {
+	Attempts *prometheus.CounterVec
+	Failures *prometheus.CounterVec
+	Latency  *prometheus.HistogramVec
+}
+
+// NewDialMetrics constructs new DialMetrics.
+func NewDialMetrics(service string) *DialMetrics {
+	return &DialMetrics{
+		Attempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: service + "_dial_attempts_total",
+			Help: "The total number of dial attempts",
+		}, []string{"type"}),
+		Failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: service + "_dial_failures_total",
+			Help: "The total number of failed dials, by reason",
+		}, []string{"type", "reason"}),
+		Latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    service + "_dial_duration_seconds",
+			Help:    "The duration of successful dials",
+			Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"type"}),
+	}
+}
+
+func (m *DialMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.Attempts, m.Failures, m.Latency}
+}
+
+// Describe implements prometheus.Collector.
+func (m *DialMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *DialMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DmsgSessionStats is a snapshot of the statistics of a session with a dmsg server.
+type DmsgSessionStats struct {
+	Server     string
+	Connected  bool
+	Streams    int64
+	BytesSent  uint64
+	BytesRecv  uint64
+	Reconnects uint64
+	RTT        float64 // in seconds.
+}
+
+// DmsgMetrics exposes statistics of dmsg sessions, labeled by server public key.
+// Statistics are obtained from the source on collection. Like TransportMetrics, it is not registered automatically.
+type DmsgMetrics struct {
+	source func() []DmsgSessionStats
+
+	connected  *prometheus.Desc
+	streams    *prometheus.Desc
+	bytesSent  *prometheus.Desc
+	bytesRecv  *prometheus.Desc
+	reconnects *prometheus.Desc
+	rtt        *prometheus.Desc
+}
+
+// NewDmsgMetrics constructs new DmsgMetrics.
+func NewDmsgMetrics(service string, source func() []DmsgSessionStats) *DmsgMetrics {
+	labels := []string{"server"}
+	return &DmsgMetrics{
+		source:     source,
+		connected:  prometheus.NewDesc(service+"_dmsg_session_connected", "Whether the session with the dmsg server is established", labels, nil),
+		streams:    prometheus.NewDesc(service+"_dmsg_session_streams", "The number of open dmsg streams via the server", labels, nil),
+		bytesSent:  prometheus.NewDesc(service+"_dmsg_session_sent_bytes_total", "The total number of bytes sent over dmsg streams via the server", labels, nil),
+		bytesRecv:  prometheus.NewDesc(service+"_dmsg_session_received_bytes_total", "The total number of bytes received over dmsg streams via the server", labels, nil),
+		reconnects: prometheus.NewDesc(service+"_dmsg_session_reconnects_total", "The total number of times the session with the server was re-established", labels, nil),
+		rtt:        prometheus.NewDesc(service+"_dmsg_server_rtt_seconds", "The TCP connect round trip time to the dmsg server", labels, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *DmsgMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{m.connected, m.streams, m.bytesSent, m.bytesRecv, m.reconnects, m.rtt} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *DmsgMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range m.source() {
+		connected := 0.0
+		if s.Connected {
+			connected = 1
+		}
+		ch <- prometheus.MustNewConstMetric(m.connected, prometheus.GaugeValue, connected, s.Server)
+		ch <- prometheus.MustNewConstMetric(m.streams, prometheus.GaugeValue, float64(s.Streams), s.Server)
+		ch <- prometheus.MustNewConstMetric(m.bytesSent, prometheus.CounterValue, float64(s.BytesSent), s.Server)
+		ch <- prometheus.MustNewConstMetric(m.bytesRecv, prometheus.CounterValue, float64(s.BytesRecv), s.Server)
+		ch <- prometheus.MustNewConstMetric(m.reconnects, prometheus.CounterValue, float64(s.Reconnects), s.Server)
+		ch <- prometheus.MustNewConstMetric(m.rtt, prometheus.GaugeValue, s.RTT, s.Server)
+	}
+}
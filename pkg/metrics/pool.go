@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolMetrics records metrics of a connection pool, labeled by network type.
+// Like TransportMetrics, it is not registered automatically.
+type PoolMetrics struct {
+	Hits      *prometheus.CounterVec
+	Misses    *prometheus.CounterVec
+	Evictions *prometheus.CounterVec
+	Idle      *prometheus.GaugeVec
+}
+
+// NewPoolMetrics constructs new PoolMetrics.
+func NewPoolMetrics(service string) *PoolMetrics {
+	return &PoolMetrics{
+		Hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: service + "_pool_hits_total",
+			Help: "The total number of dials served by reusing a pooled connection",
+		}, []string{"type"}),
+		Misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: service + "_pool_misses_total",
+			Help: "The total number of dials which required a new connection",
+		}, []string{"type"}),
+		Evictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: service + "_pool_evictions_total",
+			Help: "The total number of pooled connections closed due to being idle, unhealthy or in excess",
+		}, []string{"type"}),
+		Idle: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: service + "_pool_idle_connections",
+			Help: "The number of idle pooled connections",
+		}, []string{"type"}),
+	}
+}
+
+func (m *PoolMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.Hits, m.Misses, m.Evictions, m.Idle}
+}
+
+// Describe implements prometheus.Collector.
+func (m *PoolMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *PoolMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
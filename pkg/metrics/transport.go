@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Handshake results recorded by TransportMetrics.
+const (
+	HandshakeSuccess = "success"
+	HandshakeFailure = "failure"
+)
+
+// TransportMetrics records transport metrics, labeled by transport type.
+// Unlike the other metrics of this package, it is not registered automatically as
+// it implements prometheus.Collector and should be registered by the caller.
+type TransportMetrics struct {
+	Transports *prometheus.GaugeVec
+	Handshakes *prometheus.CounterVec
+	Reconnects *prometheus.CounterVec
+	BytesSent  *prometheus.CounterVec
+	BytesRecv  *prometheus.CounterVec
+}
+
+// NewTransportMetrics constructs new TransportMetrics.
+func NewTransportMetrics(service string) *TransportMetrics {
+	return &TransportMetrics{
+		Transports: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: service + "_transports",
+			Help: "The number of transports",
+		}, []string{"type"}),
+		Handshakes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: service + "_transport_handshakes_total",
+			Help: "The total number of transport settlement handshakes",
+		}, []string{"type", "result"}),
+		Reconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: service + "_transport_reconnects_total",
+			Help: "The total number of attempts to redial underlying transport connections",
+		}, []string{"type"}),
+		BytesSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: service + "_transport_sent_bytes_total",
+			Help: "The total number of payload bytes sent over transports",
+		}, []string{"type"}),
+		BytesRecv: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: service + "_transport_received_bytes_total",
+			Help: "The total number of payload bytes received over transports",
+		}, []string{"type"}),
+	}
+}
+
+func (m *TransportMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.Transports, m.Handshakes, m.Reconnects, m.BytesSent, m.BytesRecv}
+}
+
+// Describe implements prometheus.Collector.
+func (m *TransportMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *TransportMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
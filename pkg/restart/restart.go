@@ -0,0 +1,54 @@
+// Package restart re-executes the running process with its original arguments and environment.
+package restart
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrNoExecutable occurs when the path of the running executable cannot be determined.
+var ErrNoExecutable = errors.New("cannot determine path of the executable")
+
+// Context is the information required to restart the process.
+// It should be captured at startup, before the working directory or environment are changed.
+type Context struct {
+	path string
+	args []string
+	env  []string
+}
+
+// CaptureContext captures the executable, arguments and environment of the running process.
+func CaptureContext() (*Context, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrNoExecutable, err)
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrNoExecutable, err)
+	}
+	return &Context{
+		path: path,
+		args: append([]string(nil), os.Args...),
+		env:  os.Environ(),
+	}, nil
+}
+
+// Path returns the path of the executable.
+func (c *Context) Path() string {
+	return c.path
+}
+
+// Args returns the arguments the process was started with, including the program name.
+func (c *Context) Args() []string {
+	return append([]string(nil), c.args...)
+}
+
+// Exec replaces the running process with a new instance of the executable, keeping the PID, so that service
+// managers keep supervising it. It only returns if the new instance cannot be executed.
+// Resources such as listeners should be closed beforehand, as the new instance binds them again.
+func (c *Context) Exec() error {
+	return syscall.Exec(c.path, c.args, c.env) // nolint:gosec
+}
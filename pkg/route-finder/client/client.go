@@ -27,6 +27,10 @@ type GetRoutesRequest struct {
 	DstPK   cipher.PubKey `json:"dst_pk,omitempty"`
 	MinHops uint16        `json:"min_hops,omitempty"`
 	MaxHops uint16        `json:"max_hops,omitempty"`
+
+	// TransportWeights assigns costs to hops over transports of given types (key: transport type).
+	// Hops over transports of types which are not included cost 1.
+	TransportWeights map[string]float64 `json:"transport_weights,omitempty"`
 }
 
 // GetRoutesResponse encodes the json body of /routes response
@@ -57,10 +61,13 @@ type apiClient struct {
 	addr       string
 	client     http.Client
 	apiTimeout time.Duration
+	weights    map[string]float64
 }
 
 // NewHTTP constructs new Client that communicates over http.
-func NewHTTP(addr string, apiTimeout time.Duration) Client {
+// 'transportWeights' are sent with route queries so that found routes reflect the cost of transport types
+// (see GetRoutesRequest.TransportWeights), it may be nil.
+func NewHTTP(addr string, apiTimeout time.Duration, transportWeights map[string]float64) Client {
 	if apiTimeout == 0 {
 		apiTimeout = defaultContextTimeout
 	}
@@ -69,6 +76,7 @@ func NewHTTP(addr string, apiTimeout time.Duration) Client {
 		addr:       sanitizedAddr(addr),
 		client:     http.Client{},
 		apiTimeout: apiTimeout,
+		weights:    transportWeights,
 	}
 }
 
@@ -80,6 +88,8 @@ func (c *apiClient) PairedRoutes(source, destiny cipher.PubKey, minHops, maxHops
 		DstPK:   destiny,
 		MinHops: minHops,
 		MaxHops: maxHops,
+
+		TransportWeights: c.weights,
 	}
 	marshaledBody, err := json.Marshal(requestBody)
 	if err != nil {
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIClient_PairedRoutes_TransportWeights(t *testing.T) {
+	weights := map[string]float64{"stcp": 1, "dmsg": 3}
+
+	var req GetRoutesRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.NoError(t, json.NewEncoder(w).Encode(GetRoutesResponse{}))
+	}))
+	defer srv.Close()
+
+	src, _ := cipher.GenerateKeyPair()
+	dst, _ := cipher.GenerateKeyPair()
+
+	c := NewHTTP(srv.URL, 0, weights)
+	_, _, err := c.PairedRoutes(src, dst, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, src, req.SrcPK)
+	assert.Equal(t, dst, req.DstPK)
+	assert.Equal(t, weights, req.TransportWeights)
+}
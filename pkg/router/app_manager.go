@@ -10,11 +10,10 @@ import (
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/buildinfo"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 )
 
-const supportedProtocolVersion = "0.0.1"
-
 type appCallbacks struct {
 	CreateLoop func(ctx context.Context, conn *app.Protocol, raddr routing.Addr) (laddr routing.Addr, err error)
 	CloseLoop  func(ctx context.Context, conn *app.Protocol, loop routing.Loop) error
@@ -64,8 +63,8 @@ func (am *appManager) initApp(payload []byte) error {
 		return fmt.Errorf("invalid INIT payload: %v", err)
 	}
 
-	if config.ProtocolVersion != supportedProtocolVersion {
-		return errors.New("unsupported protocol version")
+	if err := buildinfo.CompatibleAppProtocol(config.ProtocolVersion); err != nil {
+		return fmt.Errorf("app %s.v%s: %v", config.AppName, config.AppVersion, err)
 	}
 
 	if am.appConf.AppName != config.AppName {
@@ -37,7 +37,8 @@ func TestAppManagerInit(t *testing.T) {
 		conf *app.Config
 		err  string
 	}{
-		{&app.Config{AppName: "foo", AppVersion: "0.0.1", ProtocolVersion: "0.0.2"}, "unsupported protocol version"},
+		{&app.Config{AppName: "foo", AppVersion: "0.0.1", ProtocolVersion: "0.1.0"},
+			"app foo.v0.0.1: app protocol version 0.1.0 is incompatible with supported version 0.0.1"},
 		{&app.Config{AppName: "foo", AppVersion: "0.0.2", ProtocolVersion: "0.0.1"}, "unexpected app version"},
 		{&app.Config{AppName: "bar", AppVersion: "0.0.1", ProtocolVersion: "0.0.1"}, "unexpected app"},
 	}
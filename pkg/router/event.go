@@ -0,0 +1,104 @@
+package router
+
+import (
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// EventType represents the type of a route event.
+type EventType string
+
+// Route event types.
+const (
+	// EventLoopCreated occurs when a loop of a local app is established.
+	EventLoopCreated EventType = "loop_created"
+	// EventLoopClosed occurs when a loop of a local app is closed, locally or by the remote.
+	EventLoopClosed EventType = "loop_closed"
+	// EventLoopSetupFailed occurs when a loop requested by a local app cannot be established.
+	EventLoopSetupFailed EventType = "loop_setup_failed"
+)
+
+// ObserverBufferSize is the capacity of the channels returned by Router.Observe.
+// Events are dropped for observers that fail to keep up.
+const ObserverBufferSize = 64
+
+// Event is a structured notification about a change in the loops of the Router.
+type Event struct {
+	Type EventType    `json:"type"`
+	Loop routing.Loop `json:"loop"`
+	Time time.Time    `json:"time"`
+	// Error is the reason of EventLoopSetupFailed events.
+	Error string `json:"error,omitempty"`
+}
+
+// eventHub fans out events to observers.
+type eventHub struct {
+	obs    map[chan Event]struct{}
+	closed bool
+	mx     sync.Mutex
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{obs: make(map[chan Event]struct{})}
+}
+
+func (h *eventHub) observe() (<-chan Event, func()) {
+	ch := make(chan Event, ObserverBufferSize)
+
+	h.mx.Lock()
+	if h.closed {
+		close(ch)
+	} else {
+		h.obs[ch] = struct{}{}
+	}
+	h.mx.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mx.Lock()
+			if _, ok := h.obs[ch]; ok {
+				delete(h.obs, ch)
+				close(ch)
+			}
+			h.mx.Unlock()
+		})
+	}
+}
+
+func (h *eventHub) publish(t EventType, loop routing.Loop) {
+	h.send(Event{Type: t, Loop: loop, Time: time.Now()})
+}
+
+func (h *eventHub) publishFailure(loop routing.Loop, err error) {
+	h.send(Event{Type: EventLoopSetupFailed, Loop: loop, Time: time.Now(), Error: err.Error()})
+}
+
+func (h *eventHub) send(e Event) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	for ch := range h.obs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func (h *eventHub) close() {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.closed = true
+	for ch := range h.obs {
+		delete(h.obs, ch)
+		close(ch)
+	}
+}
+
+// Observe returns a channel which receives loop events, and a function to stop observing.
+// The channel is closed when the Router is closed.
+func (r *Router) Observe() (<-chan Event, func()) {
+	return r.events.observe()
+}
@@ -52,3 +52,13 @@ func (ll *loopList) dropAll() []routing.Addr {
 	ll.Unlock()
 	return r
 }
+
+func (ll *loopList) addrs() []routing.Addr {
+	ll.Lock()
+	r := make([]routing.Addr, 0, len(ll.loops))
+	for addr := range ll.loops {
+		r = append(r, addr)
+	}
+	ll.Unlock()
+	return r
+}
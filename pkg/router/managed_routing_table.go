@@ -84,6 +84,21 @@ func (rt *managedRoutingTable) Cleanup() error {
 	return nil
 }
 
+// LastActivity returns the time of the last activity of the rule, and whether its activity is tracked.
+func (rt *managedRoutingTable) LastActivity(routeID routing.RouteID) (time.Time, bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	t, ok := rt.activity[routeID]
+	return t, ok
+}
+
+// Touch records activity of the rule, so that it is kept alive for its keep-alive duration.
+func (rt *managedRoutingTable) Touch(routeID routing.RouteID) {
+	rt.mu.Lock()
+	rt.activity[routeID] = time.Now()
+	rt.mu.Unlock()
+}
+
 // ruleIsExpired checks whether rule's keep alive timeout is exceeded.
 // NOTE: for internal use, is NOT thread-safe, object lock should be acquired outside
 func (rt *managedRoutingTable) ruleIsTimedOut(routeID routing.RouteID, rule routing.Rule) bool {
@@ -41,3 +41,26 @@ func TestManagedRoutingTableCleanup(t *testing.T) {
 	require.Error(t, err)
 	assert.Nil(t, rule)
 }
+
+func TestManagedRoutingTableTouch(t *testing.T) {
+	rt := manageRoutingTable(routing.InMemoryRoutingTable())
+
+	// Rules added to the underlying table are not tracked, so they are cleaned up unless they are touched.
+	id, err := rt.Table.AddRule(routing.ForwardRule(1*time.Hour, 3, uuid.New(), 1))
+	require.NoError(t, err)
+	id2, err := rt.Table.AddRule(routing.ForwardRule(1*time.Hour, 3, uuid.New(), 2))
+	require.NoError(t, err)
+	_, ok := rt.LastActivity(id)
+	assert.False(t, ok)
+
+	before := time.Now()
+	rt.Touch(id)
+	last, ok := rt.LastActivity(id)
+	require.True(t, ok)
+	assert.False(t, last.Before(before))
+
+	require.NoError(t, rt.Cleanup())
+	assert.Equal(t, 1, rt.Count())
+	_, err = rt.Table.Rule(id2)
+	assert.Error(t, err)
+}
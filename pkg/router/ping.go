@@ -0,0 +1,185 @@
+package router
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// EchoPort is the port on which routers echo the packets of loops back to their source, so that the latency and
+// loss of the routes to visors can be measured with Ping.
+const EchoPort = routing.Port(7)
+
+// pingHeaderSize is the size of the sequence number which starts the payloads of ping packets.
+const pingHeaderSize = 4
+
+// PingConfig configures Ping.
+type PingConfig struct {
+	Count    int           // number of packets to send.
+	Size     int           // size of the payload of the packets, at least 4 bytes and at most the MTU of the loop's transport.
+	Interval time.Duration // delay between sending packets.
+	Timeout  time.Duration // time to wait for the reply of the last packet.
+}
+
+// PingReply is the outcome of sending a ping packet.
+type PingReply struct {
+	Seq  int           `json:"seq"`
+	RTT  time.Duration `json:"rtt"`  // round-trip time, 0 if the packet is lost.
+	Lost bool          `json:"lost"` // whether no reply was received in time.
+}
+
+// serveEcho serves the echo of EchoPort as an app of the router itself, until the router is closed.
+func (r *Router) serveEcho() error {
+	rConn, eConn := net.Pipe()
+	echo := app.NewProtocol(eConn)
+	go func() {
+		err := echo.Serve(func(frame app.Frame, payload []byte) (interface{}, error) {
+			if frame != app.FrameSend {
+				return nil, nil // loops are accepted from anyone, and there is nothing to clean up on close.
+			}
+			var p app.Packet
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return nil, err
+			}
+			return nil, echo.Send(app.FrameSend, &p, nil)
+		})
+		if err != nil {
+			r.Logger.WithError(err).Warn("Echo stopped")
+		}
+	}()
+	return r.ServeApp(rConn, EchoPort, &app.Config{AppName: "echo"})
+}
+
+// Ping sets up a loop to the EchoPort of the remote visor, and sends packets over it to measure the round-trip time
+// and loss of its routes. The loop is closed afterwards.
+func (r *Router) Ping(ctx context.Context, remote cipher.PubKey, conf PingConfig) ([]PingReply, error) {
+	if conf.Size < pingHeaderSize {
+		conf.Size = pingHeaderSize
+	}
+	r.wg.Add(1)
+	defer r.wg.Done()
+
+	rConn, pConn := net.Pipe()
+	proto := app.NewProtocol(pConn)
+	defer func() { _ = proto.Close() }() //nolint:errcheck
+	go func() {
+		if err := r.serveAppProto(app.NewProtocol(rConn), &app.Config{AppName: "ping"}); err != nil {
+			r.Logger.WithError(err).Warn("Ping stopped")
+		}
+	}()
+
+	confirmed := make(chan struct{})
+	var confirmOnce sync.Once
+	type reply struct {
+		seq int
+		at  time.Time
+	}
+	replies := make(chan reply, conf.Count)
+	go proto.Serve(func(frame app.Frame, payload []byte) (interface{}, error) { //nolint:errcheck
+		switch frame {
+		case app.FrameConfirmLoop:
+			confirmOnce.Do(func() { close(confirmed) })
+		case app.FrameSend:
+			var p app.Packet
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return nil, err
+			}
+			if len(p.Payload) < pingHeaderSize {
+				return nil, errors.New("invalid ping reply")
+			}
+			select {
+			case replies <- reply{seq: int(binary.BigEndian.Uint32(p.Payload)), at: time.Now()}:
+			default: // more replies than packets sent.
+			}
+		}
+		return nil, nil
+	})
+
+	raddr := routing.Addr{PubKey: remote, Port: EchoPort}
+	var laddr routing.Addr
+	if err := proto.Send(app.FrameCreateLoop, &raddr, &laddr); err != nil {
+		return nil, fmt.Errorf("failed to set up loop: %v", err)
+	}
+	loop := routing.Loop{Local: laddr, Remote: raddr}
+	defer func() {
+		if err := proto.Send(app.FrameClose, &loop, nil); err != nil {
+			r.Logger.WithError(err).Warnf("Failed to close ping loop %s", loop)
+		}
+	}()
+	select {
+	case <-confirmed:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	// Payloads which exceed the MTU would be split, and only the first part would start with the sequence number.
+	if l, err := r.pm.GetLoop(laddr.Port, raddr); err == nil {
+		if tp := r.tm.Transport(l.trID); tp != nil && conf.Size > int(tp.MTU()) {
+			conf.Size = int(tp.MTU())
+		}
+	}
+
+	sent := make([]time.Time, conf.Count)
+	out := make([]PingReply, conf.Count)
+	for seq := range out {
+		out[seq] = PingReply{Seq: seq, Lost: true}
+	}
+	received := 0
+	receive := func(rep reply) {
+		if rep.seq < 0 || rep.seq >= conf.Count || !out[rep.seq].Lost {
+			return
+		}
+		out[rep.seq].RTT = rep.at.Sub(sent[rep.seq])
+		out[rep.seq].Lost = false
+		received++
+	}
+
+	for seq := 0; seq < conf.Count; seq++ {
+		payload := make([]byte, conf.Size)
+		binary.BigEndian.PutUint32(payload, uint32(seq))
+		sent[seq] = time.Now()
+		if err := proto.Send(app.FrameSend, &app.Packet{Loop: loop, Payload: payload}, nil); err != nil {
+			r.Logger.WithError(err).Warnf("Failed to send ping packet %d", seq)
+		}
+		if seq == conf.Count-1 {
+			break
+		}
+		next := time.NewTimer(conf.Interval)
+	wait:
+		for {
+			select {
+			case rep := <-replies:
+				receive(rep)
+			case <-next.C:
+				break wait
+			case <-ctx.Done():
+				next.Stop()
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	timeout := time.NewTimer(conf.Timeout)
+	defer timeout.Stop()
+collect:
+	for received < conf.Count {
+		select {
+		case rep := <-replies:
+			receive(rep)
+		case <-timeout.C:
+			break collect
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/snettest"
+)
+
+// Ensure that packets of loops to EchoPort are sent back over the reverse route of the loop.
+func TestRouter_serveEcho(t *testing.T) {
+	keys := snettest.GenKeyPairs(2)
+
+	nEnv := snettest.NewEnv(t, keys)
+	defer nEnv.Teardown()
+	rEnv := NewTestEnv(t, nEnv.Nets)
+	defer rEnv.Teardown()
+
+	r0, err := New(nEnv.Nets[0], rEnv.GenRouterConfig(0))
+	require.NoError(t, err)
+	r1, err := New(nEnv.Nets[1], rEnv.GenRouterConfig(1))
+	require.NoError(t, err)
+
+	tp, err := rEnv.TpMngrs[1].SaveTransport(context.TODO(), keys[0].PK, dmsg.Type)
+	require.NoError(t, err)
+
+	echoErr := make(chan error, 1)
+	go func() { echoErr <- r0.serveEcho() }()
+	defer func() {
+		for _, conn := range r0.pm.AppConns() {
+			assert.NoError(t, conn.Close())
+		}
+		assert.NoError(t, <-echoErr)
+	}()
+	testhelpers.Eventually(t, func() bool {
+		_, err := r0.pm.Get(EchoPort)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	// Set up the loop of r0 with the remote port 9 of r1, which replies with route ID 42.
+	raddr := routing.Addr{PubKey: keys[1].PK, Port: 9}
+	require.NoError(t, r0.pm.SetLoop(EchoPort, raddr, &loop{trID: tp.Entry.ID, routeID: 42}))
+	appRule := routing.AppRule(time.Hour, 0, 42, keys[1].PK, EchoPort, raddr.Port)
+	appRtID, err := r0.rm.rt.AddRule(appRule)
+	require.NoError(t, err)
+
+	payload := []byte{0, 0, 0, 1, 'p', 'i', 'n', 'g'}
+	require.NoError(t, r0.handlePacket(context.TODO(), routing.MakePacket(appRtID, payload)))
+
+	packet, err := r1.tm.ReadPacket()
+	require.NoError(t, err)
+	assert.Equal(t, routing.RouteID(42), packet.RouteID())
+	assert.Equal(t, payload, packet.Payload())
+}
@@ -62,6 +62,17 @@ func (pm *portManager) AppPorts(appConn *app.Protocol) []routing.Port {
 	return res
 }
 
+// Loops returns the loops of all ports.
+func (pm *portManager) Loops() []routing.Loop {
+	var loops []routing.Loop
+	for port, bind := range pm.ports.all() {
+		for _, raddr := range bind.loops.addrs() {
+			loops = append(loops, routing.Loop{Local: routing.Addr{Port: port}, Remote: raddr})
+		}
+	}
+	return loops
+}
+
 func (pm *portManager) Close(port routing.Port) []routing.Addr {
 	if pm == nil {
 		return nil
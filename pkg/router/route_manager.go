@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
@@ -44,6 +45,9 @@ type routeManager struct {
 	sl     *snet.Listener // Listens for setup node requests.
 	rt     *managedRoutingTable
 	done   chan struct{}
+
+	setupPK cipher.PubKey // setup node which was last dialed successfully.
+	setupMx sync.Mutex
 }
 
 // newRouteManager creates a new route manager.
@@ -161,18 +165,28 @@ func (rm *routeManager) rtGarbageCollectLoop() {
 	}
 }
 
-func (rm *routeManager) dialSetupConn(_ context.Context) (*snet.Conn, error) {
+func (rm *routeManager) dialSetupConn(ctx context.Context) (*snet.Conn, error) {
 	for _, sPK := range rm.conf.SetupPKs {
-		conn, err := rm.n.Dial(snet.DmsgType, sPK, skyenv.DmsgSetupPort)
+		conn, err := rm.n.Dial(ctx, snet.DmsgType, sPK, skyenv.DmsgSetupPort)
 		if err != nil {
 			rm.Logger.WithError(err).Warnf("failed to dial to setup node: setupPK(%s)", sPK)
 			continue
 		}
+		rm.setupMx.Lock()
+		rm.setupPK = sPK
+		rm.setupMx.Unlock()
 		return conn, nil
 	}
 	return nil, errors.New("failed to dial to a setup node")
 }
 
+// setupNode returns the setup node which was last dialed successfully.
+func (rm *routeManager) setupNode() (cipher.PubKey, bool) {
+	rm.setupMx.Lock()
+	defer rm.setupMx.Unlock()
+	return rm.setupPK, !rm.setupPK.Null()
+}
+
 // GetRule gets routing rule.
 func (rm *routeManager) GetRule(routeID routing.RouteID) (routing.Rule, error) {
 	rule, err := rm.rt.Rule(routeID)
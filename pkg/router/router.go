@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
@@ -34,6 +36,9 @@ const (
 
 var log = logging.MustGetLogger("router")
 
+// ErrDraining occurs when a loop is requested while the Router is draining.
+var ErrDraining = errors.New("router is draining")
+
 // Config configures Router.
 type Config struct {
 	Logger                 *logging.Logger
@@ -70,6 +75,10 @@ type Router struct {
 	pm *portManager
 	rm *routeManager
 
+	events *eventHub
+
+	draining int32 // atomic, non-zero once Drain is called.
+
 	wg sync.WaitGroup
 	mx sync.Mutex
 }
@@ -85,6 +94,7 @@ func New(n *snet.Network, config *Config) (*Router, error) {
 		pm:          newPortManager(10),
 		conf:        config,
 		staticPorts: make(map[routing.Port]struct{}),
+		events:      newEventHub(),
 	}
 
 	// Prepare route manager.
@@ -128,6 +138,12 @@ func (r *Router) Serve(ctx context.Context) error {
 		r.wg.Done()
 	}()
 
+	go func() {
+		if err := r.serveEcho(); err != nil {
+			r.Logger.WithError(err).Warnf("Failed to serve echo on port %d", EchoPort)
+		}
+	}()
+
 	r.tm.Serve(ctx)
 	return nil
 }
@@ -160,6 +176,20 @@ func (r *Router) ServeApp(conn net.Conn, port routing.Port, appConf *app.Config)
 	r.staticPorts[port] = struct{}{}
 	r.mx.Unlock()
 
+	err := r.serveAppProto(appProto, appConf)
+
+	r.mx.Lock()
+	delete(r.staticPorts, port)
+	r.mx.Unlock()
+
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// serveAppProto handles the frames of an app until its connection is closed, and closes its loops afterwards.
+func (r *Router) serveAppProto(appProto *app.Protocol, appConf *app.Config) error {
 	callbacks := &appCallbacks{
 		CreateLoop: r.requestLoop,
 		CloseLoop:  r.closeLoop,
@@ -175,14 +205,6 @@ func (r *Router) ServeApp(conn net.Conn, port routing.Port, appConf *app.Config)
 			}
 		}
 	}
-
-	r.mx.Lock()
-	delete(r.staticPorts, port)
-	r.mx.Unlock()
-
-	if err == io.EOF {
-		return nil
-	}
 	return err
 }
 
@@ -203,18 +225,74 @@ func (r *Router) Close() error {
 		r.Logger.WithError(err).Warnf("closing route_manager returned error")
 	}
 	r.wg.Wait()
+	r.events.close()
 
 	return r.tm.Close()
 }
 
+// Drain stops the Router from creating new loops, other than those initiated by trusted visors (see
+// transport.Manager.SetTrustedVisors), and closes the existing loops: apps are notified that their loops are
+// closed, and the rules of the loops are removed locally and (via setup nodes) on remote nodes.
+// Loops with trusted visors are closed last, so that they are kept for as long as the context allows.
+// It returns once all loops are closed, or with the context's error if the context is done first.
+func (r *Router) Drain(ctx context.Context) error {
+	atomic.StoreInt32(&r.draining, 1)
+
+	loops := r.pm.Loops()
+	sort.SliceStable(loops, func(i, j int) bool {
+		return !r.isTrusted(loops[i].Remote.PubKey) && r.isTrusted(loops[j].Remote.PubKey)
+	})
+	r.Logger.Infof("Draining: closing %d loops", len(loops))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, loop := range loops {
+			if ctx.Err() != nil {
+				return
+			}
+			b, err := r.pm.Get(loop.Local.Port)
+			if err != nil {
+				continue
+			}
+			loop.Local.PubKey = r.conf.PubKey
+			if err := b.conn.Send(app.FrameClose, loop, nil); err != nil {
+				r.Logger.Warnf("Failed to notify App about closed loop %s: %s", loop, err)
+			}
+			if err := r.closeLoop(ctx, b.conn, loop); err != nil {
+				r.Logger.Warnf("Failed to close loop %s: %s", loop, err)
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+		return ctx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Router) isDraining() bool {
+	return atomic.LoadInt32(&r.draining) != 0
+}
+
+func (r *Router) isTrusted(pk cipher.PubKey) bool {
+	return r.tm != nil && r.tm.IsTrusted(pk)
+}
+
 func (r *Router) forwardPacket(ctx context.Context, payload []byte, rule routing.Rule) error {
 	tp := r.tm.Transport(rule.TransportID())
 	if tp == nil {
 		return errors.New("unknown transport")
 	}
-	if err := tp.WritePacket(ctx, rule.RouteID(), payload); err != nil {
+	if !r.tm.Quotas().AllowRelay(tp.Remote()) {
+		return transport.ErrQuotaExceeded
+	}
+	if err := writePacket(ctx, tp, rule.RouteID(), payload); err != nil {
 		return err
 	}
+	r.tm.Quotas().AddRelay(uint64(len(payload)))
 	r.Logger.Infof("Forwarded packet via Transport %s using rule %d", rule.TransportID(), rule.RouteID())
 	return nil
 }
@@ -255,7 +333,28 @@ func (r *Router) forwardAppPacket(ctx context.Context, appConn *app.Protocol, pa
 	}
 
 	r.Logger.Infof("Forwarded App packet from LocalPort %d using route ID %d", packet.Loop.Local.Port, l.routeID)
-	return tr.WritePacket(ctx, l.routeID, packet.Payload)
+	return writePacket(ctx, tr, l.routeID, packet.Payload)
+}
+
+// writePacket writes the payload over the transport, split into packets which do not exceed the MTU of the
+// transport. Loops carry byte streams, so the remote app reads the payload as if it was sent in a single packet.
+func writePacket(ctx context.Context, tp *transport.ManagedTransport, rtID routing.RouteID, payload []byte) error {
+	for {
+		n := len(payload)
+		if mtu := int(tp.MTU()); n > mtu {
+			n = mtu
+		}
+		if err := tp.WritePacket(ctx, rtID, payload[:n]); err != nil {
+			// The MTU negotiated when the transport is redialed may be lower than the one the payload was split by.
+			if n > int(tp.MTU()) {
+				continue
+			}
+			return err
+		}
+		if payload = payload[n:]; len(payload) == 0 {
+			return nil
+		}
+	}
 }
 
 func (r *Router) forwardLocalAppPacket(packet *app.Packet) error {
@@ -275,6 +374,9 @@ func (r *Router) forwardLocalAppPacket(packet *app.Packet) error {
 }
 
 func (r *Router) requestLoop(ctx context.Context, appConn *app.Protocol, raddr routing.Addr) (routing.Addr, error) {
+	if r.isDraining() {
+		return routing.Addr{}, ErrDraining
+	}
 	lport := r.pm.Alloc(appConn)
 	if err := r.pm.SetLoop(lport, raddr, &loop{}); err != nil {
 		return routing.Addr{}, err
@@ -286,12 +388,15 @@ func (r *Router) requestLoop(ctx context.Context, appConn *app.Protocol, raddr r
 			return routing.Addr{}, fmt.Errorf("confirm: %s", err)
 		}
 		r.Logger.Infof("Created local loop on port %d", laddr.Port)
+		r.events.publish(EventLoopCreated, routing.Loop{Local: laddr, Remote: raddr})
 		return laddr, nil
 	}
 
 	forwardRoute, reverseRoute, err := r.fetchBestRoutes(laddr.PubKey, raddr.PubKey)
 	if err != nil {
-		return routing.Addr{}, fmt.Errorf("route finder: %s", err)
+		err = fmt.Errorf("route finder: %s", err)
+		r.events.publishFailure(routing.Loop{Local: laddr, Remote: raddr}, err)
+		return routing.Addr{}, err
 	}
 
 	ld := routing.LoopDescriptor{
@@ -306,6 +411,7 @@ func (r *Router) requestLoop(ctx context.Context, appConn *app.Protocol, raddr r
 
 	sConn, err := r.rm.dialSetupConn(ctx)
 	if err != nil {
+		r.events.publishFailure(routing.Loop{Local: laddr, Remote: raddr}, err)
 		return routing.Addr{}, err
 	}
 	defer func() {
@@ -314,7 +420,9 @@ func (r *Router) requestLoop(ctx context.Context, appConn *app.Protocol, raddr r
 		}
 	}()
 	if err := setup.CreateLoop(ctx, setup.NewSetupProtocol(sConn), ld); err != nil {
-		return routing.Addr{}, fmt.Errorf("route setup: %s", err)
+		err = fmt.Errorf("route setup: %s", err)
+		r.events.publishFailure(routing.Loop{Local: laddr, Remote: raddr}, err)
+		return routing.Addr{}, err
 	}
 
 	r.Logger.Infof("Created new loop to %s on port %d", raddr, laddr.Port)
@@ -336,6 +444,9 @@ func (r *Router) confirmLocalLoop(laddr, raddr routing.Addr) error {
 }
 
 func (r *Router) confirmLoop(l routing.Loop, rule routing.Rule) error {
+	if r.isDraining() && !r.isTrusted(l.Remote.PubKey) {
+		return ErrDraining
+	}
 	b, err := r.pm.Get(l.Local.Port)
 	if err != nil {
 		return err
@@ -349,6 +460,7 @@ func (r *Router) confirmLoop(l routing.Loop, rule routing.Rule) error {
 	if err = b.conn.Send(app.FrameConfirmLoop, addrs, nil); err != nil {
 		r.Logger.Warnf("Failed to notify App about new loop: %s", err)
 	}
+	r.events.publish(EventLoopCreated, routing.Loop{Local: addrs[0], Remote: l.Remote})
 
 	return nil
 }
@@ -357,6 +469,7 @@ func (r *Router) closeLoop(ctx context.Context, appConn *app.Protocol, loop rout
 	if err := r.destroyLoop(loop); err != nil {
 		r.Logger.Warnf("Failed to remove loop: %s", err)
 	}
+	r.events.publish(EventLoopClosed, loop)
 
 	sConn, err := r.rm.dialSetupConn(ctx)
 	if err != nil {
@@ -383,6 +496,7 @@ func (r *Router) loopClosed(loop routing.Loop) error {
 	if err := r.destroyLoop(loop); err != nil {
 		r.Logger.Warnf("Failed to remove loop: %s", err)
 	}
+	r.events.publish(EventLoopClosed, loop)
 
 	if err := b.conn.Send(app.FrameClose, loop, nil); err != nil {
 		return err
@@ -429,6 +543,22 @@ fetchRoutesAgain:
 	return fwdRoutes[0], revRoutes[0], nil
 }
 
+// SetupNode returns the setup node which was last used to set up routes, if any.
+func (r *Router) SetupNode() (cipher.PubKey, bool) {
+	return r.rm.setupNode()
+}
+
+// RuleActivity returns the time of the last activity of the rule, and whether the router tracks its activity.
+func (r *Router) RuleActivity(routeID routing.RouteID) (time.Time, bool) {
+	return r.rm.rt.LastActivity(routeID)
+}
+
+// TouchRule records activity of the rule, so that rules which are added to the routing table by hand rather than by
+// the setup node are kept alive for their keep-alive duration rather than cleaned up.
+func (r *Router) TouchRule(routeID routing.RouteID) {
+	r.rm.rt.Touch(routeID)
+}
+
 // SetupIsTrusted checks if setup node is trusted.
 func (r *Router) SetupIsTrusted(sPK cipher.PubKey) bool {
 	return r.rm.conf.SetupIsTrusted(sPK)
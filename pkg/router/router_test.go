@@ -159,6 +159,36 @@ func TestRouter_Serve(t *testing.T) {
 	//})
 }
 
+// Ensure that packets exceeding the MTU of a transport are split before they are forwarded over it.
+func TestRouter_forwardPacket_MTU(t *testing.T) {
+	keys := snettest.GenKeyPairs(2)
+
+	nEnv := snettest.NewEnv(t, keys)
+	defer nEnv.Teardown()
+	rEnv := NewTestEnv(t, nEnv.Nets)
+	defer rEnv.Teardown()
+	rEnv.TpMngrConfs[0].MTU = 8
+
+	r0, err := New(nEnv.Nets[0], rEnv.GenRouterConfig(0))
+	require.NoError(t, err)
+
+	tp1, err := rEnv.TpMngrs[1].SaveTransport(context.TODO(), keys[0].PK, dmsg.Type)
+	require.NoError(t, err)
+
+	fwdRule := routing.ForwardRule(1*time.Hour, routing.RouteID(5), tp1.Entry.ID, routing.RouteID(0))
+	fwdRtID, err := r0.rm.rt.AddRule(fwdRule)
+	require.NoError(t, err)
+
+	require.NoError(t, r0.handlePacket(context.TODO(), routing.MakePacket(fwdRtID, []byte("This is a test!"))))
+
+	for _, exp := range []string{"This is ", "a test!"} {
+		recvPacket, err := rEnv.TpMngrs[1].ReadPacket()
+		require.NoError(t, err)
+		assert.Equal(t, routing.RouteID(5), recvPacket.RouteID())
+		assert.Equal(t, exp, string(recvPacket.Payload()))
+	}
+}
+
 type TestEnv struct {
 	TpD transport.DiscoveryClient
 
@@ -0,0 +1,210 @@
+package snet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AvailabilityInterval is the interval at which the availability of networks is checked.
+const AvailabilityInterval = 15 * time.Second
+
+// EventBufferSize is the capacity of the channels returned by Network.Observe.
+// Events are dropped for observers that fail to keep up.
+const EventBufferSize = 16
+
+// NetworkEvent notifies that a network has become available or unavailable.
+type NetworkEvent struct {
+	Network   string    `json:"network"`
+	Available bool      `json:"available"`
+	Reason    string    `json:"reason,omitempty"` // why the network is unavailable.
+	Time      time.Time `json:"time"`
+}
+
+// AvailabilityChecker may be implemented by the clients of registered network drivers to report their availability.
+// Driver clients which do not implement it are always considered available.
+type AvailabilityChecker interface {
+	CheckAvailability(ctx context.Context) error
+}
+
+// availability tracks the availability of networks and fans out changes to observers.
+type availability struct {
+	state  map[string]error // nil error: available.
+	obs    map[chan NetworkEvent]struct{}
+	closed bool
+	mx     sync.Mutex
+}
+
+func newAvailability() *availability {
+	return &availability{
+		state: make(map[string]error),
+		obs:   make(map[chan NetworkEvent]struct{}),
+	}
+}
+
+// set records the availability of a network, notifying observers if it changed.
+func (a *availability) set(network string, err error) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	prev, ok := a.state[network]
+	a.state[network] = err
+	if ok && (prev == nil) == (err == nil) {
+		return
+	}
+	e := NetworkEvent{Network: network, Available: err == nil, Time: time.Now()}
+	if err != nil {
+		e.Reason = err.Error()
+	}
+	for ch := range a.obs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func (a *availability) observe() (<-chan NetworkEvent, func()) {
+	ch := make(chan NetworkEvent, EventBufferSize)
+
+	a.mx.Lock()
+	if a.closed {
+		close(ch)
+	} else {
+		a.obs[ch] = struct{}{}
+	}
+	a.mx.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			a.mx.Lock()
+			if _, ok := a.obs[ch]; ok {
+				delete(a.obs, ch)
+				close(ch)
+			}
+			a.mx.Unlock()
+		})
+	}
+}
+
+func (a *availability) close() {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	a.closed = true
+	for ch := range a.obs {
+		delete(a.obs, ch)
+		close(ch)
+	}
+}
+
+// Observe returns a channel which receives events when networks become available or unavailable,
+// and a function to stop observing.
+func (n *Network) Observe() (<-chan NetworkEvent, func()) {
+	return n.avail.observe()
+}
+
+// Availability returns the availability of the transport networks, keyed by network type.
+// Values are empty for available networks, and describe the reason otherwise.
+// Networks which have not yet been checked are reported as available.
+func (n *Network) Availability() map[string]string {
+	n.avail.mx.Lock()
+	defer n.avail.mx.Unlock()
+	out := make(map[string]string, len(n.conf.TpNetworks))
+	for _, network := range n.conf.TpNetworks {
+		out[network] = ""
+		if err := n.avail.state[network]; err != nil {
+			out[network] = err.Error()
+		}
+	}
+	return out
+}
+
+// IsAvailable returns whether the given network is available.
+func (n *Network) IsAvailable(network string) bool {
+	n.avail.mx.Lock()
+	defer n.avail.mx.Unlock()
+	return n.avail.state[network] == nil
+}
+
+// monitorAvailability periodically checks the availability of the transport networks until the Network is closed.
+func (n *Network) monitorAvailability(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-ticker.C:
+			n.checkAvailability()
+		}
+	}
+}
+
+func (n *Network) checkAvailability() {
+	ctx, cancel := context.WithTimeout(context.Background(), AvailabilityInterval)
+	defer cancel()
+	go func() {
+		select {
+		case <-n.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for _, network := range n.conf.TpNetworks {
+		n.avail.set(network, n.networkErr(ctx, network))
+	}
+}
+
+// networkErr returns the reason a network is unavailable, or nil if it is available.
+func (n *Network) networkErr(ctx context.Context, network string) error {
+	switch network {
+	case DmsgType:
+		if n.dmsgC == nil {
+			return errors.New("dmsg is not initialized")
+		}
+		if n.dmsgDisc == nil {
+			return nil
+		}
+		// The dmsg client keeps its discovery entry updated with the servers it has sessions with.
+		entry, err := n.dmsgDisc.Entry(ctx, n.conf.PubKey)
+		if err != nil {
+			return fmt.Errorf("failed to obtain discovery entry: %v", err)
+		}
+		if entry.Client != nil {
+			n.dmsgStats.setConnected(entry.Client.DelegatedServers)
+		}
+		if entry.Client == nil || len(entry.Client.DelegatedServers) == 0 {
+			return errors.New("no sessions with dmsg servers")
+		}
+		return nil
+	case STcpType:
+		if n.stcpC == nil {
+			return errors.New("stcp is not initialized")
+		}
+		if n.conf.STCPLocalAddr == "" {
+			return nil
+		}
+		if err := n.stcpC.ServeErr(); err == nil {
+			return nil
+		}
+		// Attempt to listen again, as the address may have become available.
+		if err := n.serveSTCP(); err != nil {
+			return fmt.Errorf("failed to listen: %v", err)
+		}
+		return nil
+	default:
+		c, ok := n.client(network)
+		if !ok {
+			return ErrUnknownNetwork
+		}
+		if ac, ok := c.(AvailabilityChecker); ok {
+			return ac.CheckAvailability(ctx)
+		}
+		return nil
+	}
+}
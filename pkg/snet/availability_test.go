@@ -0,0 +1,97 @@
+package snet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
+)
+
+// flakyClient is a pipeClient which reports availability as configured.
+type flakyClient struct {
+	*pipeClient
+	err error
+	mx  sync.Mutex
+}
+
+func (c *flakyClient) CheckAvailability(context.Context) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.err
+}
+
+func (c *flakyClient) setErr(err error) {
+	c.mx.Lock()
+	c.err = err
+	c.mx.Unlock()
+}
+
+func TestNetwork_Observe(t *testing.T) {
+	const flakyType = "flaky"
+
+	fc := &flakyClient{pipeClient: &pipeClient{pn: &pipeNet{listeners: make(map[dmsg.Addr]*pipeListener)}}}
+	Register(flakyType, func(context.Context, Config, json.RawMessage) (NetworkClient, error) {
+		return fc, nil
+	})
+
+	n := NewRaw(Config{TpNetworks: []string{flakyType}}, nil, nil)
+	require.NoError(t, n.initDrivers(context.TODO()))
+	n.checkAvailability()
+	assert.True(t, n.IsAvailable(flakyType))
+
+	events, stop := n.Observe()
+	defer stop()
+
+	// TEST: Changes of availability are published.
+	fc.setErr(errors.New("offline"))
+	n.checkAvailability()
+	e := <-events
+	assert.Equal(t, flakyType, e.Network)
+	assert.False(t, e.Available)
+	assert.Equal(t, "offline", e.Reason)
+	assert.Equal(t, map[string]string{flakyType: "offline"}, n.Availability())
+
+	// TEST: Unchanged availability is not published.
+	n.checkAvailability()
+	fc.setErr(nil)
+	n.checkAvailability()
+	e = <-events
+	assert.True(t, e.Available)
+	assert.True(t, n.IsAvailable(flakyType))
+
+	// TEST: Observers are closed with the network.
+	require.NoError(t, n.Close())
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestNetwork_Availability_STCP(t *testing.T) {
+	// Occupy the stcp address.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+
+	pk, sk := cipher.GenerateKeyPair()
+	stcpC := stcp.NewClient(nil, pk, sk, stcp.NewTable(nil))
+	n := NewRaw(Config{PubKey: pk, SecKey: sk, TpNetworks: []string{STcpType}, STCPLocalAddr: addr}, nil, stcpC)
+	defer func() { require.NoError(t, n.Close()) }()
+
+	// TEST: stcp is unavailable while it fails to listen.
+	n.checkAvailability()
+	assert.False(t, n.IsAvailable(STcpType))
+
+	// TEST: Listening is retried.
+	require.NoError(t, l.Close())
+	n.checkAvailability()
+	assert.True(t, n.IsAvailable(STcpType))
+	assert.NoError(t, stcpC.ServeErr())
+}
@@ -0,0 +1,225 @@
+package snet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/disc"
+)
+
+// DefaultDmsgDiscCacheTTL is the default duration for which cached dmsg discovery responses are used when
+// discovery is unavailable.
+const DefaultDmsgDiscCacheTTL = 24 * time.Hour
+
+type cachedEntry struct {
+	Entry   *disc.Entry `json:"entry"`
+	Fetched time.Time   `json:"fetched"`
+}
+
+type discCacheFile struct {
+	Servers        []*disc.Entry                 `json:"servers"`
+	ServersFetched time.Time                     `json:"servers_fetched"`
+	Entries        map[cipher.PubKey]cachedEntry `json:"entries"`
+}
+
+// pendingEntry is an entry which failed to be published, and is published once discovery is available.
+type pendingEntry struct {
+	entry *disc.Entry
+	sk    cipher.SecKey // empty if the entry is to be created (rather than updated).
+}
+
+// discCache wraps a discovery client, caching the responses of successful queries in memory and on disk.
+// When discovery is unavailable, cached responses which are younger than the TTL are returned instead, so that
+// dmsg sessions can be (re-)established during discovery outages, including after a restart.
+// Entries which fail to be published are published by refresh once discovery is available again.
+type discCache struct {
+	disc.APIClient
+
+	path    string // if empty, the cache is not persisted.
+	ttl     time.Duration
+	data    discCacheFile
+	pending map[cipher.PubKey]pendingEntry
+	mx      sync.Mutex
+}
+
+func newDiscCache(dc disc.APIClient, path string, ttl time.Duration) (*discCache, error) {
+	if ttl <= 0 {
+		ttl = DefaultDmsgDiscCacheTTL
+	}
+	c := &discCache{
+		APIClient: dc,
+		path:      path,
+		ttl:       ttl,
+		data:      discCacheFile{Entries: make(map[cipher.PubKey]cachedEntry)},
+		pending:   make(map[cipher.PubKey]pendingEntry),
+	}
+	if path == "" {
+		return c, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return c, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return c, fmt.Errorf("read: %s", err)
+	}
+	var f discCacheFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return c, fmt.Errorf("json: %s", err)
+	}
+	if f.Entries == nil {
+		f.Entries = make(map[cipher.PubKey]cachedEntry)
+	}
+	c.data = f
+	return c, nil
+}
+
+// Entry obtains the entry from discovery, falling back to the cached entry if discovery is unavailable.
+func (c *discCache) Entry(ctx context.Context, pk cipher.PubKey) (*disc.Entry, error) {
+	entry, err := c.APIClient.Entry(ctx, pk)
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if err == nil {
+		c.data.Entries[pk] = cachedEntry{Entry: entry, Fetched: time.Now()}
+		c.save()
+		return entry, nil
+	}
+	if !isDiscUnavailable(err) {
+		return nil, err
+	}
+	cached, ok := c.data.Entries[pk]
+	if !ok || time.Since(cached.Fetched) > c.ttl {
+		return nil, err
+	}
+	e := *cached.Entry
+	return &e, nil
+}
+
+// AvailableServers obtains the servers from discovery, falling back to the cached servers if discovery is
+// unavailable.
+func (c *discCache) AvailableServers(ctx context.Context) ([]*disc.Entry, error) {
+	entries, err := c.APIClient.AvailableServers(ctx)
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if err == nil && len(entries) > 0 {
+		c.data.Servers, c.data.ServersFetched = entries, time.Now()
+		c.save()
+		return entries, nil
+	}
+	if (err != nil && !isDiscUnavailable(err)) || time.Since(c.data.ServersFetched) > c.ttl {
+		return entries, err
+	}
+	out := make([]*disc.Entry, len(c.data.Servers))
+	for i, e := range c.data.Servers {
+		cp := *e
+		out[i] = &cp
+	}
+	return out, nil
+}
+
+// SetEntry creates the entry, to be retried by refresh if discovery is unavailable.
+func (c *discCache) SetEntry(ctx context.Context, entry *disc.Entry) error {
+	err := c.APIClient.SetEntry(ctx, entry)
+	c.setPending(entry, cipher.SecKey{}, err)
+	return err
+}
+
+// UpdateEntry updates the entry, to be retried by refresh if discovery is unavailable.
+func (c *discCache) UpdateEntry(ctx context.Context, sk cipher.SecKey, entry *disc.Entry) error {
+	err := c.APIClient.UpdateEntry(ctx, sk, entry)
+	c.setPending(entry, sk, err)
+	return err
+}
+
+func (c *discCache) setPending(entry *disc.Entry, sk cipher.SecKey, err error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if err != nil && isDiscUnavailable(err) {
+		c.pending[entry.Static] = pendingEntry{entry: entry, sk: sk}
+	} else {
+		delete(c.pending, entry.Static)
+	}
+}
+
+// refresh publishes the entries which failed to be published while discovery was unavailable.
+func (c *discCache) refresh(ctx context.Context) error {
+	c.mx.Lock()
+	pending := make([]pendingEntry, 0, len(c.pending))
+	for _, p := range c.pending {
+		pending = append(pending, p)
+	}
+	c.mx.Unlock()
+
+	for _, p := range pending {
+		if err := c.publish(ctx, p); err != nil {
+			return fmt.Errorf("failed to publish entry of %s: %v", p.entry.Static, err)
+		}
+	}
+	return nil
+}
+
+func (c *discCache) publish(ctx context.Context, p pendingEntry) error {
+	if p.sk.Null() {
+		return c.SetEntry(ctx, p.entry)
+	}
+	// The sequence of the entry may have changed, so the entry is updated from its latest version.
+	entry, err := c.APIClient.Entry(ctx, p.entry.Static)
+	if err != nil {
+		return err
+	}
+	if entry.Client != nil && p.entry.Client != nil {
+		entry.Client.DelegatedServers = p.entry.Client.DelegatedServers
+	}
+	return c.UpdateEntry(ctx, p.sk, entry)
+}
+
+// save prunes expired entries and persists the cache, the caller should hold the lock.
+func (c *discCache) save() {
+	if c.path == "" {
+		return
+	}
+	for pk, e := range c.data.Entries {
+		if time.Since(e.Fetched) > c.ttl {
+			delete(c.data.Entries, pk)
+		}
+	}
+	data, err := json.Marshal(c.data)
+	if err != nil {
+		log.WithError(err).Warn("Failed to encode dmsg discovery cache")
+		return
+	}
+	tmp := c.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		log.WithError(err).Warn("Failed to write dmsg discovery cache")
+		return
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		log.WithError(err).Warn("Failed to write dmsg discovery cache")
+	}
+}
+
+// isDiscUnavailable returns whether an error returned by the discovery client is due to discovery being
+// unreachable or failing, rather than a response to the request (such as the entry not being found).
+func isDiscUnavailable(err error) bool {
+	if _, ok := err.(disc.EntryValidationError); ok {
+		return false
+	}
+	switch err {
+	case disc.ErrKeyNotFound, disc.ErrUnauthorized, disc.ErrBadInput:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,112 @@
+package snet
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/disc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outageDisc is a discovery client which may be made unavailable.
+type outageDisc struct {
+	disc.APIClient
+	down bool
+}
+
+var errDiscDown = errors.New("connection refused")
+
+func (d *outageDisc) Entry(ctx context.Context, pk cipher.PubKey) (*disc.Entry, error) {
+	if d.down {
+		return nil, errDiscDown
+	}
+	return d.APIClient.Entry(ctx, pk)
+}
+
+func (d *outageDisc) SetEntry(ctx context.Context, e *disc.Entry) error {
+	if d.down {
+		return errDiscDown
+	}
+	return d.APIClient.SetEntry(ctx, e)
+}
+
+func (d *outageDisc) UpdateEntry(ctx context.Context, sk cipher.SecKey, e *disc.Entry) error {
+	if d.down {
+		return errDiscDown
+	}
+	return d.APIClient.UpdateEntry(ctx, sk, e)
+}
+
+func (d *outageDisc) AvailableServers(ctx context.Context) ([]*disc.Entry, error) {
+	if d.down {
+		return nil, errDiscDown
+	}
+	return d.APIClient.AvailableServers(ctx)
+}
+
+func TestDiscCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "disc_cache")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "cache.json")
+
+	ctx := context.TODO()
+	dc := &outageDisc{APIClient: disc.NewMock()}
+	srvPK, _ := cipher.GenerateKeyPair()
+	cPK, cSK := cipher.GenerateKeyPair()
+	require.NoError(t, dc.SetEntry(ctx, disc.NewServerEntry(srvPK, 0, "127.0.0.1:8080", 10)))
+	cEntry := disc.NewClientEntry(cPK, 0, []cipher.PubKey{srvPK})
+	require.NoError(t, cEntry.Sign(cSK))
+	require.NoError(t, dc.SetEntry(ctx, cEntry))
+
+	c, err := newDiscCache(dc, path, 0)
+	require.NoError(t, err)
+	_, err = c.AvailableServers(ctx)
+	require.NoError(t, err)
+	_, err = c.Entry(ctx, cPK)
+	require.NoError(t, err)
+
+	// Definitive responses are not masked by the cache.
+	assert.False(t, isDiscUnavailable(disc.ErrKeyNotFound))
+	assert.False(t, isDiscUnavailable(disc.ErrValidationWrongSequence))
+	assert.True(t, isDiscUnavailable(errDiscDown))
+
+	// After a restart, cached responses are used during an outage.
+	dc.down = true
+	c, err = newDiscCache(dc, path, 0)
+	require.NoError(t, err)
+	servers, err := c.AvailableServers(ctx)
+	require.NoError(t, err)
+	require.Len(t, servers, 1)
+	assert.Equal(t, srvPK, servers[0].Static)
+	entry, err := c.Entry(ctx, cPK)
+	require.NoError(t, err)
+	assert.Equal(t, []cipher.PubKey{srvPK}, entry.Client.DelegatedServers)
+	unknownPK, _ := cipher.GenerateKeyPair()
+	_, err = c.Entry(ctx, unknownPK)
+	assert.Equal(t, errDiscDown, err)
+
+	// Updates made during the outage are published once discovery is available again.
+	entry.Client.DelegatedServers = nil
+	assert.Error(t, c.UpdateEntry(ctx, cSK, entry))
+	assert.Error(t, c.refresh(ctx))
+	dc.down = false
+	require.NoError(t, c.refresh(ctx))
+	entry, err = dc.Entry(ctx, cPK)
+	require.NoError(t, err)
+	assert.Empty(t, entry.Client.DelegatedServers)
+	assert.Empty(t, c.pending)
+
+	// Expired responses are not used.
+	dc.down = true
+	c, err = newDiscCache(dc, path, 1)
+	require.NoError(t, err)
+	_, err = c.AvailableServers(ctx)
+	assert.Equal(t, errDiscDown, err)
+}
@@ -0,0 +1,223 @@
+package snet
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/disc"
+)
+
+// Dmsg server selection defaults.
+const (
+	DmsgServerCheckInterval = time.Minute
+	dmsgServerProbeTimeout  = 5 * time.Second
+	dmsgServerMaxExtra      = 1 // max number of sessions established in addition to DmsgMinSrvs on failover.
+)
+
+// DmsgServer describes a dmsg server advertised by discovery.
+type DmsgServer struct {
+	PK        cipher.PubKey `json:"pk"`
+	Address   string        `json:"address"`
+	RTT       time.Duration `json:"rtt"`             // time taken to establish a TCP connection, 0 if unreachable.
+	Error     string        `json:"error,omitempty"` // why the server is unreachable.
+	Connected bool          `json:"connected"`       // whether a session with the server is established.
+}
+
+func (s DmsgServer) reachable() bool { return s.Error == "" }
+
+// serverSelector wraps a discovery client so that the dmsg client connects to the dmsg servers with the lowest
+// round trip time first. RTTs are measured via TCP connects to the advertised server addresses.
+type serverSelector struct {
+	disc.APIClient
+
+	servers map[cipher.PubKey]DmsgServer
+	mx      sync.Mutex
+}
+
+func newServerSelector(dc disc.APIClient) *serverSelector {
+	return &serverSelector{APIClient: dc, servers: make(map[cipher.PubKey]DmsgServer)}
+}
+
+// AvailableServers returns the available servers ordered by RTT, with unreachable servers last.
+func (s *serverSelector) AvailableServers(ctx context.Context) ([]*disc.Entry, error) {
+	entries, err := s.APIClient.AvailableServers(ctx)
+	if err != nil || len(entries) == 0 {
+		return entries, err
+	}
+	s.measure(ctx, entries)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return lessServer(s.servers[entries[i].Static], s.servers[entries[j].Static])
+	})
+	return entries, nil
+}
+
+// measure probes the RTT of the given servers concurrently.
+func (s *serverSelector) measure(ctx context.Context, entries []*disc.Entry) {
+	ctx, cancel := context.WithTimeout(ctx, dmsgServerProbeTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, entry := range entries {
+		if entry.Server == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(pk cipher.PubKey, addr string) {
+			defer wg.Done()
+			srv := DmsgServer{PK: pk, Address: addr}
+			if rtt, err := probeRTT(ctx, addr); err != nil {
+				srv.Error = err.Error()
+			} else {
+				srv.RTT = rtt
+			}
+			s.mx.Lock()
+			srv.Connected = s.servers[pk].Connected
+			s.servers[pk] = srv
+			s.mx.Unlock()
+		}(entry.Static, entry.Server.Address)
+	}
+	wg.Wait()
+}
+
+// setConnected records the servers with which sessions are established.
+func (s *serverSelector) setConnected(pks []cipher.PubKey) {
+	connected := make(map[cipher.PubKey]bool, len(pks))
+	for _, pk := range pks {
+		connected[pk] = true
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for pk, srv := range s.servers {
+		srv.Connected = connected[pk]
+		s.servers[pk] = srv
+	}
+}
+
+// list returns the known servers ordered by RTT.
+func (s *serverSelector) list() []DmsgServer {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	out := make([]DmsgServer, 0, len(s.servers))
+	for _, srv := range s.servers {
+		out = append(out, srv)
+	}
+	sort.Slice(out, func(i, j int) bool { return lessServer(out[i], out[j]) })
+	return out
+}
+
+func lessServer(a, b DmsgServer) bool {
+	if a.reachable() != b.reachable() {
+		return a.reachable()
+	}
+	return a.RTT < b.RTT
+}
+
+func probeRTT(ctx context.Context, addr string) (time.Duration, error) {
+	var d net.Dialer
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	_ = conn.Close() //nolint:errcheck
+	return rtt, nil
+}
+
+// isDegraded returns whether the best connected server is unreachable, or significantly slower than the best
+// available server.
+func isDegraded(servers []DmsgServer) bool {
+	if len(servers) == 0 || !servers[0].reachable() {
+		return false // no better alternative.
+	}
+	best := servers[0]
+	for _, srv := range servers {
+		if !srv.Connected {
+			continue
+		}
+		// servers are ordered, so this is the best connected server.
+		if !srv.reachable() {
+			return true
+		}
+		return srv.RTT > 2*best.RTT+50*time.Millisecond
+	}
+	return false
+}
+
+// DmsgServers returns the dmsg servers advertised by discovery, ordered by RTT.
+// It returns nil if dmsg server selection is not enabled (such as when the Network is created with NewRaw).
+func (n *Network) DmsgServers() []DmsgServer {
+	if n.dmsgSel == nil {
+		return nil
+	}
+	return n.dmsgSel.list()
+}
+
+// selectDmsgServers periodically measures the RTT of dmsg servers, establishes sessions with the best servers if
+// there are fewer than DmsgMinSrvs, and establishes a session with the best server if the current sessions are
+// degraded.
+// The vendored dmsg client does not support closing individual sessions, so degraded sessions are kept until
+// they fail (after which dmsg keeps attempting to reconnect), and the number of additional sessions is limited.
+func (n *Network) selectDmsgServers(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := n.checkDmsgServers(); err != nil {
+			log.WithError(err).Warn("Failed to select dmsg servers")
+		}
+		select {
+		case <-n.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n *Network) checkDmsgServers() error {
+	ctx, cancel := context.WithTimeout(context.Background(), dmsgServerProbeTimeout*2)
+	defer cancel()
+
+	if n.dmsgCache != nil {
+		if err := n.dmsgCache.refresh(ctx); err != nil {
+			log.WithError(err).Warn("Failed to refresh dmsg discovery cache")
+		}
+	}
+	entry, err := n.dmsgDisc.Entry(ctx, n.conf.PubKey)
+	if err != nil {
+		return fmt.Errorf("failed to obtain discovery entry: %v", err)
+	}
+	var connected []cipher.PubKey
+	if entry.Client != nil {
+		connected = entry.Client.DelegatedServers
+	}
+	if _, err := n.dmsgSel.AvailableServers(ctx); err != nil {
+		return fmt.Errorf("failed to obtain servers: %v", err)
+	}
+	n.dmsgSel.setConnected(connected)
+	n.dmsgStats.setConnected(connected)
+
+	// The dmsg client only reconnects to the servers it lost sessions with, which may no longer be available.
+	if len(connected) < n.conf.DmsgMinSrvs {
+		if err := n.dmsgC.InitiateServerConnections(ctx, n.conf.DmsgMinSrvs); err != nil {
+			return fmt.Errorf("failed to restore %d sessions (have %d): %v", n.conf.DmsgMinSrvs, len(connected), err)
+		}
+		return nil
+	}
+	if !isDegraded(n.dmsgSel.list()) || len(connected) >= n.conf.DmsgMinSrvs+dmsgServerMaxExtra {
+		return nil
+	}
+	// Servers are connected to in order of RTT, so this establishes a session with the best unconnected server.
+	if err := n.dmsgC.InitiateServerConnections(ctx, len(connected)+1); err != nil {
+		return fmt.Errorf("failover failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package snet
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/disc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerSelector(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	// An address which refuses connections.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddr := closed.Addr().String()
+	require.NoError(t, closed.Close())
+
+	dc := disc.NewMock()
+	upPK, _ := cipher.GenerateKeyPair()
+	downPK, _ := cipher.GenerateKeyPair()
+	require.NoError(t, dc.SetEntry(context.TODO(), disc.NewServerEntry(downPK, 0, closedAddr, 10)))
+	require.NoError(t, dc.SetEntry(context.TODO(), disc.NewServerEntry(upPK, 0, l.Addr().String(), 10)))
+
+	sel := newServerSelector(dc)
+	entries, err := sel.AvailableServers(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, upPK, entries[0].Static)
+	assert.Equal(t, downPK, entries[1].Static)
+
+	sel.setConnected([]cipher.PubKey{downPK})
+	servers := sel.list()
+	require.Len(t, servers, 2)
+	assert.True(t, servers[0].reachable())
+	assert.False(t, servers[1].reachable())
+	assert.True(t, servers[1].Connected)
+	assert.True(t, isDegraded(servers))
+}
+
+func TestIsDegraded(t *testing.T) {
+	pk := func() cipher.PubKey { pk, _ := cipher.GenerateKeyPair(); return pk }
+	fast := DmsgServer{PK: pk(), RTT: 10 * time.Millisecond}
+	slow := DmsgServer{PK: pk(), RTT: 500 * time.Millisecond}
+	down := DmsgServer{PK: pk(), Error: "refused"}
+
+	connected := func(s DmsgServer) DmsgServer { s.Connected = true; return s }
+
+	assert.False(t, isDegraded(nil))
+	assert.False(t, isDegraded([]DmsgServer{connected(fast), slow}))
+	assert.True(t, isDegraded([]DmsgServer{fast, connected(slow)}))
+	assert.True(t, isDegraded([]DmsgServer{fast, connected(down)}))
+	assert.False(t, isDegraded([]DmsgServer{connected(down)}))
+}
@@ -0,0 +1,182 @@
+package snet
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/noise"
+)
+
+// ErrDmsgDeadline occurs when setting deadlines on dmsg streams, which is not supported.
+var ErrDmsgDeadline = errors.New("deadlines are not supported by dmsg streams")
+
+// DmsgSession summarizes the session with a dmsg server.
+type DmsgSession struct {
+	Server     cipher.PubKey `json:"server_pk"`
+	Address    string        `json:"address,omitempty"`
+	Connected  bool          `json:"connected"`
+	Streams    int64         `json:"streams"` // open dmsg streams (transports) via the server.
+	BytesSent  uint64        `json:"bytes_sent"`
+	BytesRecv  uint64        `json:"bytes_received"`
+	Reconnects uint64        `json:"reconnects"` // number of times the session was re-established.
+	RTT        time.Duration `json:"rtt"`        // see DmsgServer.
+}
+
+type sessionCounters struct {
+	streams    int64
+	sent       uint64
+	recv       uint64
+	reconnects uint64
+	connected  bool // protected by dmsgStats.mx.
+	dropped    bool // whether the session was connected and then dropped, protected by dmsgStats.mx.
+}
+
+// dmsgStats records statistics of dmsg streams, keyed by the server they are relayed through.
+type dmsgStats struct {
+	sessions map[cipher.PubKey]*sessionCounters
+	mx       sync.Mutex
+}
+
+func newDmsgStats() *dmsgStats {
+	return &dmsgStats{sessions: make(map[cipher.PubKey]*sessionCounters)}
+}
+
+func (s *dmsgStats) counters(srvPK cipher.PubKey) *sessionCounters {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	c, ok := s.sessions[srvPK]
+	if !ok {
+		c = new(sessionCounters)
+		s.sessions[srvPK] = c
+	}
+	return c
+}
+
+// setConnected records the servers with which sessions are established, counting reconnects.
+func (s *dmsgStats) setConnected(pks []cipher.PubKey) {
+	connected := make(map[cipher.PubKey]bool, len(pks))
+	for _, pk := range pks {
+		connected[pk] = true
+		s.counters(pk)
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for pk, c := range s.sessions {
+		switch {
+		case connected[pk] && c.dropped:
+			c.dropped = false
+			atomic.AddUint64(&c.reconnects, 1)
+		case !connected[pk] && c.connected:
+			c.dropped = true
+		}
+		c.connected = connected[pk]
+	}
+}
+
+// wrap records the statistics of a dmsg stream.
+func (s *dmsgStats) wrap(conn net.Conn) net.Conn {
+	tp, ok := conn.(*dmsg.Transport)
+	if !ok {
+		return conn
+	}
+	// The transport embeds the noise connection with the dmsg server.
+	var srvPK cipher.PubKey
+	if addr, ok := tp.Conn.RemoteAddr().(*noise.Addr); ok {
+		srvPK = addr.PK
+	}
+	c := s.counters(srvPK)
+	atomic.AddInt64(&c.streams, 1)
+	return &dmsgStatsConn{Transport: tp, c: c}
+}
+
+func (s *dmsgStats) list() []DmsgSession {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	out := make([]DmsgSession, 0, len(s.sessions))
+	for pk, c := range s.sessions {
+		out = append(out, DmsgSession{
+			Server:     pk,
+			Connected:  c.connected,
+			Streams:    atomic.LoadInt64(&c.streams),
+			BytesSent:  atomic.LoadUint64(&c.sent),
+			BytesRecv:  atomic.LoadUint64(&c.recv),
+			Reconnects: atomic.LoadUint64(&c.reconnects),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Server.Hex() < out[j].Server.Hex() })
+	return out
+}
+
+// dmsgStatsConn is a dmsg stream which records statistics.
+type dmsgStatsConn struct {
+	*dmsg.Transport
+	c    *sessionCounters
+	once sync.Once
+}
+
+func (c *dmsgStatsConn) Read(b []byte) (int, error) {
+	n, err := c.Transport.Read(b)
+	atomic.AddUint64(&c.c.recv, uint64(n))
+	return n, err
+}
+
+func (c *dmsgStatsConn) Write(b []byte) (int, error) {
+	n, err := c.Transport.Write(b)
+	atomic.AddUint64(&c.c.sent, uint64(n))
+	return n, err
+}
+
+func (c *dmsgStatsConn) Close() error {
+	c.once.Do(func() { atomic.AddInt64(&c.c.streams, -1) })
+	return c.Transport.Close()
+}
+
+// SetDeadline is not supported, as dmsg.Transport would set the deadline of the session with the dmsg server
+// (which is shared by all streams), rather than of the stream.
+func (c *dmsgStatsConn) SetDeadline(time.Time) error { return ErrDmsgDeadline }
+
+// SetReadDeadline is not supported, see SetDeadline.
+func (c *dmsgStatsConn) SetReadDeadline(time.Time) error { return ErrDmsgDeadline }
+
+// SetWriteDeadline is not supported, see SetDeadline.
+func (c *dmsgStatsConn) SetWriteDeadline(time.Time) error { return ErrDmsgDeadline }
+
+// dmsgStatsListener records the statistics of accepted dmsg streams.
+type dmsgStatsListener struct {
+	net.Listener
+	s *dmsgStats
+}
+
+func (l *dmsgStatsListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return l.s.wrap(conn), nil
+}
+
+// DmsgSessions returns statistics of the sessions with dmsg servers.
+// Byte counts only include dmsg streams established via the Network.
+func (n *Network) DmsgSessions() []DmsgSession {
+	sessions := n.dmsgStats.list()
+	if n.dmsgSel == nil {
+		return sessions
+	}
+	servers := make(map[cipher.PubKey]DmsgServer)
+	for _, srv := range n.dmsgSel.list() {
+		servers[srv.PK] = srv
+	}
+	for i, s := range sessions {
+		if srv, ok := servers[s.Server]; ok {
+			sessions[i].Address = srv.Address
+			sessions[i].RTT = srv.RTT
+		}
+	}
+	return sessions
+}
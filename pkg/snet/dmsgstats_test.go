@@ -0,0 +1,32 @@
+package snet
+
+import (
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDmsgStats_SetConnected(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+
+	s := newDmsgStats()
+	s.setConnected([]cipher.PubKey{pk1, pk2})
+	s.setConnected([]cipher.PubKey{pk1})
+	s.setConnected([]cipher.PubKey{pk1})
+	s.setConnected([]cipher.PubKey{pk1, pk2})
+
+	sessions := s.list()
+	require.Len(t, sessions, 2)
+	for _, session := range sessions {
+		assert.True(t, session.Connected)
+		switch session.Server {
+		case pk1:
+			assert.Zero(t, session.Reconnects)
+		case pk2:
+			assert.Equal(t, uint64(1), session.Reconnects)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package snet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// NetworkClient is a driver of a network type which is not built into snet.
+// Addresses of connections and listeners should be in the '<pk>:<port>' form (as dmsg.Addr).
+type NetworkClient interface {
+	Dial(ctx context.Context, pk cipher.PubKey, port uint16) (net.Conn, error)
+	Listen(port uint16) (net.Listener, error)
+	Close() error
+}
+
+// NetworkFactory creates a NetworkClient.
+// 'opts' are the driver-specific options of Config.Options (may be nil).
+type NetworkFactory func(ctx context.Context, conf Config, opts json.RawMessage) (NetworkClient, error)
+
+var (
+	driversMx sync.RWMutex
+	drivers   = make(map[string]NetworkFactory)
+)
+
+// Register makes a network driver available under the given network type.
+// Networks of registered types are created on Network.Init if they are included in Config.TpNetworks.
+// Register panics if the type is built-in, already registered, or if the factory is nil.
+func Register(network string, factory NetworkFactory) {
+	driversMx.Lock()
+	defer driversMx.Unlock()
+
+	if factory == nil {
+		panic("snet: Register factory is nil")
+	}
+	if network == DmsgType || network == STcpType {
+		panic(fmt.Sprintf("snet: Register of built-in network '%s'", network))
+	}
+	if _, dup := drivers[network]; dup {
+		panic(fmt.Sprintf("snet: Register called twice for network '%s'", network))
+	}
+	drivers[network] = factory
+}
+
+// Drivers returns a sorted list of the registered network types.
+func Drivers() []string {
+	driversMx.RLock()
+	defer driversMx.RUnlock()
+
+	out := make([]string, 0, len(drivers))
+	for network := range drivers {
+		out = append(out, network)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func driver(network string) (NetworkFactory, bool) {
+	driversMx.RLock()
+	defer driversMx.RUnlock()
+	f, ok := drivers[network]
+	return f, ok
+}
+
+// initDrivers creates the clients of registered networks which are included in TpNetworks.
+func (n *Network) initDrivers(ctx context.Context) error {
+	for _, network := range n.conf.TpNetworks {
+		if network == DmsgType || network == STcpType {
+			continue
+		}
+		factory, ok := driver(network)
+		if !ok {
+			return fmt.Errorf("%v: '%s'", ErrUnknownNetwork, network)
+		}
+		c, err := factory(ctx, n.conf, n.conf.Options[network])
+		if err != nil {
+			return fmt.Errorf("failed to initiate '%s': %v", network, err)
+		}
+		n.clientsMx.Lock()
+		n.clients[network] = c
+		n.clientsMx.Unlock()
+	}
+	return nil
+}
+
+func (n *Network) client(network string) (NetworkClient, bool) {
+	n.clientsMx.RLock()
+	defer n.clientsMx.RUnlock()
+	c, ok := n.clients[network]
+	return c, ok
+}
@@ -0,0 +1,127 @@
+package snet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pipeNet is an in-memory network driver.
+type pipeNet struct {
+	listeners map[dmsg.Addr]*pipeListener
+	mx        sync.Mutex
+}
+
+type pipeClient struct {
+	pn *pipeNet
+	pk cipher.PubKey
+}
+
+func (c *pipeClient) Dial(_ context.Context, pk cipher.PubKey, port uint16) (net.Conn, error) {
+	c.pn.mx.Lock()
+	lis, ok := c.pn.listeners[dmsg.Addr{PK: pk, Port: port}]
+	c.pn.mx.Unlock()
+	if !ok {
+		return nil, errors.New("not listening")
+	}
+	c1, c2 := net.Pipe()
+	lAddr, rAddr := dmsg.Addr{PK: c.pk, Port: 1}, lis.addr
+	lis.accept <- &pipeConn{Conn: c2, lAddr: rAddr, rAddr: lAddr}
+	return &pipeConn{Conn: c1, lAddr: lAddr, rAddr: rAddr}, nil
+}
+
+func (c *pipeClient) Listen(port uint16) (net.Listener, error) {
+	lis := &pipeListener{addr: dmsg.Addr{PK: c.pk, Port: port}, accept: make(chan net.Conn, 1)}
+	c.pn.mx.Lock()
+	c.pn.listeners[lis.addr] = lis
+	c.pn.mx.Unlock()
+	return lis, nil
+}
+
+func (c *pipeClient) Close() error { return nil }
+
+type pipeListener struct {
+	addr   dmsg.Addr
+	accept chan net.Conn
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) { return <-l.accept, nil }
+func (l *pipeListener) Close() error              { return nil }
+func (l *pipeListener) Addr() net.Addr            { return l.addr }
+
+type pipeConn struct {
+	net.Conn
+	lAddr, rAddr dmsg.Addr
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return c.lAddr }
+func (c *pipeConn) RemoteAddr() net.Addr { return c.rAddr }
+
+func TestRegister(t *testing.T) {
+	const pipeType = "pipe"
+
+	pn := &pipeNet{listeners: make(map[dmsg.Addr]*pipeListener)}
+	var gotOpts json.RawMessage
+	Register(pipeType, func(_ context.Context, conf Config, opts json.RawMessage) (NetworkClient, error) {
+		gotOpts = opts
+		return &pipeClient{pn: pn, pk: conf.PubKey}, nil
+	})
+	assert.Contains(t, Drivers(), pipeType)
+	assert.Panics(t, func() {
+		Register(pipeType, func(context.Context, Config, json.RawMessage) (NetworkClient, error) { return nil, nil })
+	})
+	assert.Panics(t, func() {
+		Register(DmsgType, func(context.Context, Config, json.RawMessage) (NetworkClient, error) { return nil, nil })
+	})
+
+	nets := make([]*Network, 2)
+	for i := range nets {
+		pk, sk := cipher.GenerateKeyPair()
+		nets[i] = NewRaw(Config{
+			PubKey:     pk,
+			SecKey:     sk,
+			TpNetworks: []string{pipeType},
+			Options:    map[string]json.RawMessage{pipeType: json.RawMessage(`{"mtu":1500}`)},
+		}, nil, nil)
+		require.NoError(t, nets[i].initDrivers(context.TODO()))
+	}
+	assert.JSONEq(t, `{"mtu":1500}`, string(gotOpts))
+
+	lis, err := nets[1].Listen(pipeType, 10)
+	require.NoError(t, err)
+	assert.Equal(t, pipeType, lis.Network())
+
+	errCh := make(chan error, 1)
+	go func() {
+		conn, err := lis.AcceptConn()
+		if err == nil {
+			_, err = io.Copy(conn, io.LimitReader(conn, 5))
+		}
+		errCh <- err
+	}()
+
+	conn, err := nets[0].Dial(context.TODO(), pipeType, nets[1].LocalPK(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, nets[1].LocalPK(), conn.RemotePK())
+	assert.Equal(t, pipeType, conn.Network())
+
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	b := make([]byte, 5)
+	_, err = io.ReadFull(conn, b)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+	require.NoError(t, <-errCh)
+
+	_, err = nets[0].Dial(context.TODO(), "unregistered", nets[1].LocalPK(), 10)
+	assert.Equal(t, ErrUnknownNetwork, err)
+}
@@ -0,0 +1,62 @@
+package snet
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/disc"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/metrics"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
+)
+
+// observeDial records the outcome of a dial in the configured metrics.
+func (n *Network) observeDial(network string, start time.Time, err error) {
+	m := n.conf.Metrics
+	if m == nil {
+		return
+	}
+	m.Attempts.WithLabelValues(network).Inc()
+	if err != nil {
+		m.Failures.WithLabelValues(network, dialFailureReason(err)).Inc()
+		return
+	}
+	m.Latency.WithLabelValues(network).Observe(time.Since(start).Seconds())
+}
+
+// dialFailureReason classifies a dial error into one of the reasons of metrics.DialMetrics.
+func dialFailureReason(err error) string {
+	switch err {
+	case context.DeadlineExceeded:
+		return metrics.DialTimeout
+	case context.Canceled:
+		return metrics.DialCanceled
+	case dmsg.ErrRequestRejected, dmsg.ErrPortNotListening:
+		return metrics.DialRefused
+	case dmsg.ErrNoSrv, stcp.ErrNoTableEntry:
+		return metrics.DialNoRoute
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return metrics.DialTimeout
+	}
+	if opErr, ok := err.(*net.OpError); ok {
+		if sysErr, ok := opErr.Err.(*os.SyscallError); ok {
+			switch sysErr.Err {
+			case syscall.ECONNREFUSED:
+				return metrics.DialRefused
+			case syscall.EHOSTUNREACH, syscall.ENETUNREACH:
+				return metrics.DialNoRoute
+			}
+		}
+	}
+	// dmsg does not preserve errors of discovery lookups.
+	if strings.Contains(err.Error(), disc.ErrKeyNotFound.Error()) {
+		return metrics.DialNoRoute
+	}
+	return metrics.DialOther
+}
@@ -0,0 +1,83 @@
+package snet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/disc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/metrics"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
+)
+
+func TestDialFailureReason(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{err: context.DeadlineExceeded, want: metrics.DialTimeout},
+		{err: context.Canceled, want: metrics.DialCanceled},
+		{err: dmsg.ErrRequestRejected, want: metrics.DialRefused},
+		{err: dmsg.ErrNoSrv, want: metrics.DialNoRoute},
+		{err: stcp.ErrNoTableEntry, want: metrics.DialNoRoute},
+		{err: fmt.Errorf("get entry failure: %s", disc.ErrKeyNotFound), want: metrics.DialNoRoute},
+		{err: errors.New("unexpected"), want: metrics.DialOther},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, dialFailureReason(tc.err), tc.err.Error())
+	}
+}
+
+func TestNetwork_Dial_Metrics(t *testing.T) {
+	// Obtain an address which refuses connections.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	lPK, lSK := cipher.GenerateKeyPair()
+	rPK, _ := cipher.GenerateKeyPair()
+	noEntryPK, _ := cipher.GenerateKeyPair()
+	stcpC := stcp.NewClient(nil, lPK, lSK, stcp.NewTable(map[cipher.PubKey]string{rPK: addr}))
+	defer func() { require.NoError(t, stcpC.Close()) }()
+
+	met := metrics.NewDialMetrics("test")
+	n := NewRaw(Config{PubKey: lPK, SecKey: lSK, Metrics: met}, nil, stcpC)
+
+	_, err = n.Dial(context.TODO(), STcpType, rPK, 1)
+	require.Error(t, err)
+	_, err = n.Dial(context.TODO(), STcpType, noEntryPK, 1)
+	require.Error(t, err)
+
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(met))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	failures := make(map[string]float64)
+	var attempts float64
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			switch f.GetName() {
+			case "test_dial_attempts_total":
+				attempts += m.GetCounter().GetValue()
+			case "test_dial_failures_total":
+				for _, l := range m.GetLabel() {
+					if l.GetName() == "reason" {
+						failures[l.GetValue()] += m.GetCounter().GetValue()
+					}
+				}
+			}
+		}
+	}
+	assert.Equal(t, float64(2), attempts)
+	assert.Equal(t, map[string]float64{metrics.DialRefused: 1, metrics.DialNoRoute: 1}, failures)
+}
@@ -2,12 +2,15 @@ package snet
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/SkycoinProject/skywire-mainnet/pkg/metrics"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
 
 	"github.com/SkycoinProject/skycoin/src/util/logging"
@@ -26,34 +29,67 @@ const (
 var (
 	// ErrUnknownNetwork occurs on attempt to dial an unknown network type.
 	ErrUnknownNetwork = errors.New("unknown network type")
+
+	// ErrNetworkDisabled occurs on attempt to dial or listen on a disabled network.
+	ErrNetworkDisabled = errors.New("network is disabled")
 )
 
+var log = logging.MustGetLogger("snet")
+
 // Config represents a network configuration.
 type Config struct {
 	PubKey     cipher.PubKey
 	SecKey     cipher.SecKey
-	TpNetworks []string // networks to be used with transports
+	TpNetworks []string // networks to be used with transports, in order of preference for outbound dials
+
+	DisabledNetworks []string // networks which are not initiated, and may not be dialed or listened on
 
 	DmsgDiscAddr string
-	DmsgMinSrvs  int
+	DmsgMinSrvs  int // min number of concurrent dmsg server sessions, which are re-established if lost.
+
+	DmsgDiscCacheFile string        // persists dmsg discovery responses for use during outages, optional.
+	DmsgDiscCacheTTL  time.Duration // max age of cached dmsg discovery responses, defaults to DefaultDmsgDiscCacheTTL.
 
-	STCPLocalAddr string // if empty, don't listen.
-	STCPTable     map[cipher.PubKey]string
+	STCPLocalAddr   string // if empty, don't listen.
+	STCPTable       map[cipher.PubKey]string
+	STCPPortMapping bool         // attempt NAT-PMP/UPnP port mapping for the stcp listener.
+	STCPMultiplex   bool         // multiplex stcp connections with the same remote over a single TCP connection.
+	STCPTLS         bool         // wrap stcp connections in TLS with certificates derived from the visor's keys.
+	STCPNetSim      *stcp.NetSim // simulate network conditions on stcp connections (testing only).
+
+	Options map[string]json.RawMessage // options of registered network drivers, keyed by network type.
+	Metrics *metrics.DialMetrics       // optional.
 }
 
 // Network represents a network between nodes in Skywire.
 type Network struct {
-	conf  Config
-	dmsgC *dmsg.Client
-	stcpC *stcp.Client
+	conf      Config
+	dmsgC     *dmsg.Client
+	dmsgDisc  disc.APIClient  // used to check the availability of dmsg, optional.
+	dmsgSel   *serverSelector // orders dmsg servers by RTT, optional.
+	dmsgCache *discCache      // caches dmsg discovery responses, optional.
+	dmsgStats *dmsgStats
+	stcpC     *stcp.Client
+
+	clients   map[string]NetworkClient // clients of registered network drivers.
+	clientsMx sync.RWMutex
+
+	avail *availability
+	done  chan struct{}
+	once  sync.Once
 }
 
 // New creates a network from a config.
 func New(conf Config) *Network {
+	dmsgCache, err := newDiscCache(disc.NewHTTP(conf.DmsgDiscAddr), conf.DmsgDiscCacheFile, conf.DmsgDiscCacheTTL)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load dmsg discovery cache")
+	}
+	dmsgSel := newServerSelector(dmsgCache)
 	dmsgC := dmsg.NewClient(
 		conf.PubKey,
 		conf.SecKey,
-		disc.NewHTTP(conf.DmsgDiscAddr),
+		dmsgSel,
 		dmsg.SetLogger(logging.MustGetLogger("snet.dmsgC")))
 
 	stcpC := stcp.NewClient(
@@ -61,36 +97,102 @@ func New(conf Config) *Network {
 		conf.PubKey,
 		conf.SecKey,
 		stcp.NewTable(conf.STCPTable))
+	stcpC.SetMultiplexing(conf.STCPMultiplex)
 
-	return NewRaw(conf, dmsgC, stcpC)
+	n := NewRaw(conf, dmsgC, stcpC)
+	n.dmsgDisc, n.dmsgSel, n.dmsgCache = dmsgSel, dmsgSel, dmsgCache
+	return n
 }
 
 // NewRaw creates a network from a config and a dmsg client.
 func NewRaw(conf Config, dmsgC *dmsg.Client, stcpC *stcp.Client) *Network {
-	return &Network{
-		conf:  conf,
-		dmsgC: dmsgC,
-		stcpC: stcpC,
+	n := &Network{
+		conf:    conf,
+		dmsgC:   dmsgC,
+		stcpC:   stcpC,
+		clients: make(map[string]NetworkClient),
+		avail:   newAvailability(),
+		done:    make(chan struct{}),
+
+		dmsgStats: newDmsgStats(),
 	}
+
+	// Disabled networks are never used for transports.
+	if len(conf.DisabledNetworks) > 0 {
+		tpNetworks := make([]string, 0, len(conf.TpNetworks))
+		for _, network := range conf.TpNetworks {
+			if !n.IsDisabled(network) {
+				tpNetworks = append(tpNetworks, network)
+			}
+		}
+		n.conf.TpNetworks = tpNetworks
+	}
+	return n
 }
 
 // Init initiates server connections.
 func (n *Network) Init(ctx context.Context) error {
-	if err := n.dmsgC.InitiateServerConnections(ctx, n.conf.DmsgMinSrvs); err != nil {
-		return fmt.Errorf("failed to initiate 'dmsg': %v", err)
+	if !n.IsDisabled(DmsgType) {
+		if err := n.dmsgC.InitiateServerConnections(ctx, n.conf.DmsgMinSrvs); err != nil {
+			return fmt.Errorf("failed to initiate 'dmsg': %v", err)
+		}
+		if n.dmsgSel != nil {
+			go n.selectDmsgServers(DmsgServerCheckInterval)
+		}
+	}
+	if n.conf.STCPTLS {
+		tlsConf, err := stcp.TLSConfig(n.conf.PubKey, n.conf.SecKey)
+		if err != nil {
+			return fmt.Errorf("failed to create 'stcp' TLS config: %v", err)
+		}
+		n.stcpC.SetTLS(tlsConf)
 	}
-	if n.conf.STCPLocalAddr != "" {
-		if err := n.stcpC.Serve(n.conf.STCPLocalAddr); err != nil {
-			return fmt.Errorf("failed to initiate 'stcp': %v", err)
+	if sim := n.conf.STCPNetSim; sim != nil {
+		if err := sim.Validate(); err != nil {
+			return fmt.Errorf("invalid 'stcp' network simulation: %v", err)
+		}
+		n.stcpC.SetNetSim(sim)
+	}
+	if n.IsDisabled(STcpType) {
+		log.Info("'stcp' is disabled")
+	} else if n.conf.STCPLocalAddr != "" {
+		// A failure to listen is reported as stcp being unavailable, and is retried.
+		if err := n.serveSTCP(); err != nil {
+			log.WithError(err).Warn("Failed to initiate 'stcp', retrying")
 		}
 	} else {
-		fmt.Println("No config found for stcp")
+		log.Info("No config found for stcp")
+	}
+	if err := n.initDrivers(ctx); err != nil {
+		return err
+	}
+
+	n.checkAvailability()
+	go n.monitorAvailability(AvailabilityInterval)
+	return nil
+}
+
+// serveSTCP listens on the configured stcp address, and maps the port if configured.
+// The external address obtained by port mapping is published to transport discovery (see STCPEndpoints).
+func (n *Network) serveSTCP() error {
+	if err := n.stcpC.Serve(n.conf.STCPLocalAddr); err != nil {
+		return err
+	}
+	if n.conf.STCPPortMapping {
+		if err := n.stcpC.MapPort(nil); err != nil {
+			return fmt.Errorf("failed to map port: %v", err)
+		}
 	}
 	return nil
 }
 
 // Close closes underlying connections.
 func (n *Network) Close() error {
+	n.once.Do(func() {
+		close(n.done)
+		n.avail.close()
+	})
+
 	wg := new(sync.WaitGroup)
 	wg.Add(2)
 
@@ -106,6 +208,15 @@ func (n *Network) Close() error {
 		wg.Done()
 	}()
 
+	var driverErr error
+	n.clientsMx.Lock()
+	for network, c := range n.clients {
+		if err := c.Close(); err != nil && driverErr == nil {
+			driverErr = fmt.Errorf("failed to close '%s': %v", network, err)
+		}
+	}
+	n.clientsMx.Unlock()
+
 	wg.Wait()
 
 	if dmsgErr != nil {
@@ -114,7 +225,7 @@ func (n *Network) Close() error {
 	if stcpErr != nil {
 		return stcpErr
 	}
-	return nil
+	return driverErr
 }
 
 // LocalPK returns local public key.
@@ -123,25 +234,64 @@ func (n *Network) LocalPK() cipher.PubKey { return n.conf.PubKey }
 // LocalSK returns local secure key.
 func (n *Network) LocalSK() cipher.SecKey { return n.conf.SecKey }
 
-// TransportNetworks returns network types that are used for transports.
+// TransportNetworks returns network types that are used for transports, in order of preference.
 func (n *Network) TransportNetworks() []string { return n.conf.TpNetworks }
 
+// IsDisabled returns whether the given network is disabled.
+func (n *Network) IsDisabled(network string) bool {
+	for _, disabled := range n.conf.DisabledNetworks {
+		if disabled == network {
+			return true
+		}
+	}
+	return false
+}
+
+// PreferredNetwork returns the most preferred transport network, or an empty string if there are none.
+func (n *Network) PreferredNetwork() string {
+	if len(n.conf.TpNetworks) == 0 {
+		return ""
+	}
+	return n.conf.TpNetworks[0]
+}
+
 // Dmsg returns underlying dmsg client.
 func (n *Network) Dmsg() *dmsg.Client { return n.dmsgC }
 
+// STCPEndpoints returns the addresses the stcp listener may be reached at, including the external address obtained
+// by port mapping (see stcp.Client.Endpoints). Nil is returned if stcp is disabled.
+func (n *Network) STCPEndpoints() []string {
+	if n.stcpC == nil || n.IsDisabled(STcpType) {
+		return nil
+	}
+	return n.stcpC.Endpoints()
+}
+
 // STcp returns the underlying stcp.Client.
 func (n *Network) STcp() *stcp.Client { return n.stcpC }
 
 // Dial dials a node by its public key and returns a connection.
-func (n *Network) Dial(network string, pk cipher.PubKey, port uint16) (*Conn, error) {
-	ctx := context.Background()
+// The context cancels the dial, but does not affect the returned connection.
+func (n *Network) Dial(ctx context.Context, network string, pk cipher.PubKey, port uint16) (*Conn, error) {
+	if n.IsDisabled(network) {
+		return nil, ErrNetworkDisabled
+	}
+	start := time.Now()
+	conn, err := n.dial(ctx, network, pk, port)
+	if err != ErrUnknownNetwork {
+		n.observeDial(network, start, err)
+	}
+	return conn, err
+}
+
+func (n *Network) dial(ctx context.Context, network string, pk cipher.PubKey, port uint16) (*Conn, error) {
 	switch network {
 	case DmsgType:
 		conn, err := n.dmsgC.Dial(ctx, pk, port)
 		if err != nil {
 			return nil, err
 		}
-		return makeConn(conn, network), nil
+		return makeConn(n.dmsgStats.wrap(conn), network), nil
 	case STcpType:
 		conn, err := n.stcpC.Dial(ctx, pk, port)
 		if err != nil {
@@ -149,19 +299,30 @@ func (n *Network) Dial(network string, pk cipher.PubKey, port uint16) (*Conn, er
 		}
 		return makeConn(conn, network), nil
 	default:
-		return nil, ErrUnknownNetwork
+		c, ok := n.client(network)
+		if !ok {
+			return nil, ErrUnknownNetwork
+		}
+		conn, err := c.Dial(ctx, pk, port)
+		if err != nil {
+			return nil, err
+		}
+		return makeConn(conn, network), nil
 	}
 }
 
 // Listen listens on the specified port.
 func (n *Network) Listen(network string, port uint16) (*Listener, error) {
+	if n.IsDisabled(network) {
+		return nil, ErrNetworkDisabled
+	}
 	switch network {
 	case DmsgType:
 		lis, err := n.dmsgC.Listen(port)
 		if err != nil {
 			return nil, err
 		}
-		return makeListener(lis, network), nil
+		return makeListener(&dmsgStatsListener{Listener: lis, s: n.dmsgStats}, network), nil
 	case STcpType:
 		lis, err := n.stcpC.Listen(port)
 		if err != nil {
@@ -169,7 +330,15 @@ func (n *Network) Listen(network string, port uint16) (*Listener, error) {
 		}
 		return makeListener(lis, network), nil
 	default:
-		return nil, ErrUnknownNetwork
+		c, ok := n.client(network)
+		if !ok {
+			return nil, ErrUnknownNetwork
+		}
+		lis, err := c.Listen(port)
+		if err != nil {
+			return nil, err
+		}
+		return makeListener(lis, network), nil
 	}
 }
 
@@ -235,6 +404,13 @@ func (c Conn) RemotePort() uint16 { return c.rPort }
 // Network returns network of connection.
 func (c Conn) Network() string { return c.network }
 
+// WithConn returns a copy of the connection which performs I/O over the given net.Conn.
+// This is used to layer encryption over an established connection.
+func (c Conn) WithConn(conn net.Conn) *Conn {
+	c.Conn = conn
+	return &c
+}
+
 func disassembleAddr(addr net.Addr) (pk cipher.PubKey, port uint16) {
 	strs := strings.Split(addr.String(), ":")
 	if len(strs) != 2 {
@@ -1,6 +1,7 @@
 package snet
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -19,3 +20,21 @@ func TestDisassembleAddr(t *testing.T) {
 	require.Equal(t, pk, gotPK)
 	require.Equal(t, port, gotPort)
 }
+
+func TestNetwork_DisabledNetworks(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	n := NewRaw(Config{
+		PubKey:           pk,
+		SecKey:           sk,
+		TpNetworks:       []string{DmsgType, STcpType},
+		DisabledNetworks: []string{DmsgType},
+	}, nil, nil)
+
+	require.Equal(t, []string{STcpType}, n.TransportNetworks())
+	require.Equal(t, STcpType, n.PreferredNetwork())
+
+	_, err := n.Dial(context.TODO(), DmsgType, pk, 1)
+	require.Equal(t, ErrNetworkDisabled, err)
+	_, err = n.Listen(DmsgType, 1)
+	require.Equal(t, ErrNetworkDisabled, err)
+}
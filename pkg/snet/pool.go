@@ -0,0 +1,274 @@
+package snet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/metrics"
+)
+
+// Pool defaults.
+const (
+	DefaultPoolMaxIdle     = 2
+	DefaultPoolIdleTimeout = time.Minute
+)
+
+// ErrPoolClosed occurs when dialing via a closed Pool.
+var ErrPoolClosed = errors.New("connection pool is closed")
+
+// PoolConfig configures a Pool.
+type PoolConfig struct {
+	MaxIdle     int                  // maximum idle connections per remote, defaults to DefaultPoolMaxIdle.
+	IdleTimeout time.Duration        // idle connections are closed after this duration, defaults to DefaultPoolIdleTimeout.
+	Metrics     *metrics.PoolMetrics // optional.
+}
+
+type poolKey struct {
+	network string
+	pk      cipher.PubKey
+	port    uint16
+}
+
+type idleConn struct {
+	conn  *Conn
+	since time.Time
+}
+
+// Pool reuses connections dialed via a Network.
+// Connections are returned to the Pool with Put once the caller is done with them, and are handed out
+// again by Dial to the same network, remote and port. This is only suitable for protocols which support
+// multiple requests over a single connection.
+// Connections which have been closed or have failed are never reused.
+type Pool struct {
+	n    *Network
+	conf PoolConfig
+
+	idle map[poolKey][]idleConn
+	mx   sync.Mutex
+
+	done chan struct{}
+	once sync.Once
+}
+
+// NewPool creates a Pool which dials via the given Network.
+func NewPool(n *Network, conf PoolConfig) *Pool {
+	if conf.MaxIdle <= 0 {
+		conf.MaxIdle = DefaultPoolMaxIdle
+	}
+	if conf.IdleTimeout <= 0 {
+		conf.IdleTimeout = DefaultPoolIdleTimeout
+	}
+	p := &Pool{
+		n:    n,
+		conf: conf,
+		idle: make(map[poolKey][]idleConn),
+		done: make(chan struct{}),
+	}
+	go p.evictLoop()
+	return p
+}
+
+// Dial returns an idle healthy connection to the remote if one exists, or otherwise dials a new one.
+func (p *Pool) Dial(ctx context.Context, network string, pk cipher.PubKey, port uint16) (*Conn, error) {
+	key := poolKey{network: network, pk: pk, port: port}
+
+	p.mx.Lock()
+	if p.isClosed() {
+		p.mx.Unlock()
+		return nil, ErrPoolClosed
+	}
+	for len(p.idle[key]) > 0 {
+		conns := p.idle[key]
+		ic := conns[len(conns)-1]
+		p.setIdle(key, conns[:len(conns)-1])
+
+		if !healthy(ic.conn) {
+			p.evict(ic.conn)
+			continue
+		}
+		p.mx.Unlock()
+		p.count(network, true)
+		return ic.conn, nil
+	}
+	p.mx.Unlock()
+
+	p.count(network, false)
+	conn, err := p.n.Dial(ctx, network, pk, port)
+	if err != nil {
+		return nil, err
+	}
+	return conn.WithConn(&poolConn{Conn: conn.Conn}), nil
+}
+
+// Put returns a connection obtained from Dial to the Pool.
+// The connection is closed instead if it is unhealthy, or if the Pool is full or closed.
+func (p *Pool) Put(conn *Conn) {
+	key := poolKey{network: conn.Network(), pk: conn.RemotePK(), port: conn.RemotePort()}
+
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	if p.isClosed() || !healthy(conn) || len(p.idle[key]) >= p.conf.MaxIdle {
+		p.evict(conn)
+		return
+	}
+	p.setIdle(key, append(p.idle[key], idleConn{conn: conn, since: time.Now()}))
+}
+
+// Idle returns the number of idle connections in the Pool.
+func (p *Pool) Idle() int {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	n := 0
+	for _, conns := range p.idle {
+		n += len(conns)
+	}
+	return n
+}
+
+// Close closes the Pool and all idle connections.
+func (p *Pool) Close() error {
+	p.once.Do(func() {
+		p.mx.Lock()
+		defer p.mx.Unlock()
+		close(p.done)
+		for key, conns := range p.idle {
+			for _, ic := range conns {
+				p.evict(ic.conn)
+			}
+			p.setIdle(key, nil)
+		}
+	})
+	return nil
+}
+
+func (p *Pool) isClosed() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Pool) evictLoop() {
+	ticker := time.NewTicker(p.conf.IdleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.evictIdle(now)
+		}
+	}
+}
+
+// evictIdle closes connections which have been idle for longer than IdleTimeout, or are unhealthy.
+func (p *Pool) evictIdle(now time.Time) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	for key, conns := range p.idle {
+		kept := conns[:0]
+		for _, ic := range conns {
+			if now.Sub(ic.since) >= p.conf.IdleTimeout || !healthy(ic.conn) {
+				p.evict(ic.conn)
+				continue
+			}
+			kept = append(kept, ic)
+		}
+		p.setIdle(key, kept)
+	}
+}
+
+// setIdle sets the idle connections of a key and updates the idle metric.
+// WARNING: Not thread safe, p.mx should be locked.
+func (p *Pool) setIdle(key poolKey, conns []idleConn) {
+	diff := len(conns) - len(p.idle[key])
+	if len(conns) == 0 {
+		delete(p.idle, key)
+	} else {
+		p.idle[key] = conns
+	}
+	if p.conf.Metrics != nil && diff != 0 {
+		p.conf.Metrics.Idle.WithLabelValues(key.network).Add(float64(diff))
+	}
+}
+
+func (p *Pool) evict(conn *Conn) {
+	_ = conn.Close() //nolint:errcheck
+	if p.conf.Metrics != nil {
+		p.conf.Metrics.Evictions.WithLabelValues(conn.Network()).Inc()
+	}
+}
+
+func (p *Pool) count(network string, hit bool) {
+	if p.conf.Metrics == nil {
+		return
+	}
+	if hit {
+		p.conf.Metrics.Hits.WithLabelValues(network).Inc()
+	} else {
+		p.conf.Metrics.Misses.WithLabelValues(network).Inc()
+	}
+}
+
+// poolConn records whether the connection has failed or been closed.
+type poolConn struct {
+	net.Conn
+	failed bool
+	mx     sync.Mutex
+}
+
+func (c *poolConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.fail(err)
+	return n, err
+}
+
+func (c *poolConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.fail(err)
+	return n, err
+}
+
+func (c *poolConn) Close() error {
+	c.fail(io.ErrClosedPipe)
+	return c.Conn.Close()
+}
+
+func (c *poolConn) fail(err error) {
+	if err == nil {
+		return
+	}
+	c.mx.Lock()
+	c.failed = true
+	c.mx.Unlock()
+}
+
+// healthy returns whether a connection dialed by a Pool may be reused.
+func healthy(conn *Conn) bool {
+	pc, ok := conn.Conn.(*poolConn)
+	if !ok {
+		return false
+	}
+	pc.mx.Lock()
+	failed := pc.failed
+	pc.mx.Unlock()
+	if failed {
+		return false
+	}
+	// Underlying connections may report being closed by the remote (such as dmsg transports).
+	if c, ok := pc.Conn.(interface{ IsClosed() bool }); ok && c.IsClosed() {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package snet_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/metrics"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/snettest"
+)
+
+// gatherValues returns the values of the metrics of a collector, keyed by name.
+func gatherValues(t *testing.T, c prometheus.Collector) map[string]float64 {
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(c))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			values[f.GetName()] += m.GetGauge().GetValue() + m.GetCounter().GetValue()
+		}
+	}
+	return values
+}
+
+func TestPool(t *testing.T) {
+	keys := snettest.GenKeyPairs(2)
+	nEnv := snettest.NewEnv(t, keys)
+	defer nEnv.Teardown()
+
+	const port = 20
+	lis, err := nEnv.Nets[1].Listen(dmsg.Type, port)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, lis.Close()) }()
+	go func() {
+		for {
+			if _, err := lis.AcceptConn(); err != nil {
+				return
+			}
+		}
+	}()
+
+	met := metrics.NewPoolMetrics("test")
+	pool := snet.NewPool(nEnv.Nets[0], snet.PoolConfig{MaxIdle: 1, IdleTimeout: 200 * time.Millisecond, Metrics: met})
+	defer func() { require.NoError(t, pool.Close()) }()
+
+	// TEST: A returned connection is reused.
+	conn1, err := pool.Dial(context.TODO(), dmsg.Type, keys[1].PK, port)
+	require.NoError(t, err)
+	pool.Put(conn1)
+	assert.Equal(t, 1, pool.Idle())
+
+	conn2, err := pool.Dial(context.TODO(), dmsg.Type, keys[1].PK, port)
+	require.NoError(t, err)
+	assert.True(t, conn1 == conn2)
+	assert.Equal(t, 0, pool.Idle())
+	values := gatherValues(t, met)
+	assert.Equal(t, float64(1), values["test_pool_hits_total"])
+	assert.Equal(t, float64(1), values["test_pool_misses_total"])
+
+	// TEST: Closed connections are not reused.
+	require.NoError(t, conn2.Close())
+	pool.Put(conn2)
+	assert.Equal(t, 0, pool.Idle())
+
+	// TEST: Connections in excess of MaxIdle are closed.
+	conn3, err := pool.Dial(context.TODO(), dmsg.Type, keys[1].PK, port)
+	require.NoError(t, err)
+	conn4, err := pool.Dial(context.TODO(), dmsg.Type, keys[1].PK, port)
+	require.NoError(t, err)
+	pool.Put(conn3)
+	pool.Put(conn4)
+	assert.Equal(t, 1, pool.Idle())
+	assert.Equal(t, float64(1), gatherValues(t, met)["test_pool_idle_connections"])
+
+	// TEST: Idle connections are evicted.
+	testhelpers.Eventually(t, func() bool { return pool.Idle() == 0 }, 2*time.Second, 20*time.Millisecond)
+	values = gatherValues(t, met)
+	assert.Equal(t, float64(0), values["test_pool_idle_connections"])
+	assert.Equal(t, float64(3), values["test_pool_evictions_total"])
+
+	// TEST: Dialing via a closed pool fails.
+	require.NoError(t, pool.Close())
+	_, err = pool.Dial(context.TODO(), dmsg.Type, keys[1].PK, port)
+	assert.Equal(t, snet.ErrPoolClosed, err)
+}
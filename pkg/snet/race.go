@@ -0,0 +1,116 @@
+package snet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
+)
+
+// DefaultRaceHeadStart is the default head start given to each network over the next preferred network in DialRace.
+const DefaultRaceHeadStart = 250 * time.Millisecond
+
+type raceResult struct {
+	network string
+	conn    *Conn
+	err     error
+}
+
+// DialRace dials the remote over multiple networks concurrently and returns the first established connection
+// ("happy eyeballs"). Networks are given in order of preference, and each is given a head start over the next
+// preferred network: the next network is dialed once the head start elapses or the previous dial fails.
+// If no networks are given, the transport networks of the Network with a known path to the remote are used.
+// A headStart of 0 defaults to DefaultRaceHeadStart.
+func (n *Network) DialRace(ctx context.Context, pk cipher.PubKey, port uint16, headStart time.Duration, networks ...string) (*Conn, error) {
+	if len(networks) == 0 {
+		networks = n.raceNetworks(pk)
+	}
+	if len(networks) == 0 {
+		return nil, ErrUnknownNetwork
+	}
+	if headStart <= 0 {
+		headStart = DefaultRaceHeadStart
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan raceResult, len(networks))
+	dial := func(network string) {
+		conn, err := n.Dial(ctx, network, pk, port)
+		results <- raceResult{network: network, conn: conn, err: err}
+	}
+
+	go dial(networks[0])
+	next, pending := 1, 1
+
+	timer := time.NewTimer(headStart)
+	defer timer.Stop()
+
+	var errs []string
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if next < len(networks) {
+				go dial(networks[next])
+				next++
+				pending++
+				timer.Reset(headStart)
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				// Close connections of dials which succeed after the winner.
+				go closeRaceLosers(results, pending)
+				return res.conn, nil
+			}
+			errs = append(errs, fmt.Sprintf("%s: %v", res.network, res.err))
+
+			// Dial the next network immediately, as the previous dial failed.
+			if next < len(networks) {
+				go dial(networks[next])
+				next++
+				pending++
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(headStart)
+			}
+		}
+	}
+	return nil, errors.New("all dials failed: " + strings.Join(errs, "; "))
+}
+
+func closeRaceLosers(results <-chan raceResult, pending int) {
+	for i := 0; i < pending; i++ {
+		if res := <-results; res.err == nil {
+			_ = res.conn.Close() //nolint:errcheck
+		}
+	}
+}
+
+// raceNetworks returns the transport networks which have a known path to the remote.
+// stcp is skipped if the remote is not in the PK table.
+func (n *Network) raceNetworks(pk cipher.PubKey) []string {
+	var networks []string
+	for _, network := range n.TransportNetworks() {
+		if network == STcpType && (n.stcpC == nil || !hasAddr(n.stcpC.Table(), pk)) {
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func hasAddr(t stcp.PKTable, pk cipher.PubKey) bool {
+	_, ok := t.Addr(pk)
+	return ok
+}
@@ -0,0 +1,107 @@
+package snet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// raceClient is a pipeClient with a configurable dial delay and failure.
+type raceClient struct {
+	*pipeClient
+	delay time.Duration
+	err   error
+}
+
+func (c *raceClient) Dial(ctx context.Context, pk cipher.PubKey, port uint16) (net.Conn, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(c.delay):
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.pipeClient.Dial(ctx, pk, port)
+}
+
+func TestNetwork_DialRace(t *testing.T) {
+	const (
+		slowType = "race-slow"
+		fastType = "race-fast"
+		failType = "race-fail"
+		port     = 10
+	)
+	register := func(network string, delay time.Duration, err error) {
+		pn := &pipeNet{listeners: make(map[dmsg.Addr]*pipeListener)}
+		Register(network, func(_ context.Context, conf Config, _ json.RawMessage) (NetworkClient, error) {
+			return &raceClient{pipeClient: &pipeClient{pn: pn, pk: conf.PubKey}, delay: delay, err: err}, nil
+		})
+	}
+	register(slowType, time.Second, nil)
+	register(fastType, 0, nil)
+	register(failType, 0, errors.New("unreachable"))
+
+	networks := []string{slowType, fastType, failType}
+	nets := make([]*Network, 2)
+	for i := range nets {
+		pk, sk := cipher.GenerateKeyPair()
+		nets[i] = NewRaw(Config{PubKey: pk, SecKey: sk, TpNetworks: networks}, nil, nil)
+		require.NoError(t, nets[i].initDrivers(context.TODO()))
+	}
+	for _, network := range networks {
+		lis, err := nets[1].Listen(network, port)
+		require.NoError(t, err)
+		go func() {
+			for {
+				if _, err := lis.AcceptConn(); err != nil {
+					return
+				}
+			}
+		}()
+	}
+	rPK := nets[1].LocalPK()
+
+	// TEST: The preferred network wins if it connects within its head start.
+	conn, err := nets[0].DialRace(context.TODO(), rPK, port, time.Second, fastType, slowType)
+	require.NoError(t, err)
+	assert.Equal(t, fastType, conn.Network())
+
+	// TEST: The next network is dialed once the head start elapses.
+	start := time.Now()
+	conn, err = nets[0].DialRace(context.TODO(), rPK, port, 50*time.Millisecond, slowType, fastType)
+	require.NoError(t, err)
+	assert.Equal(t, fastType, conn.Network())
+	assert.True(t, time.Since(start) < time.Second)
+
+	// TEST: The next network is dialed immediately if the preferred network fails.
+	start = time.Now()
+	conn, err = nets[0].DialRace(context.TODO(), rPK, port, 10*time.Second, failType, fastType)
+	require.NoError(t, err)
+	assert.Equal(t, fastType, conn.Network())
+	assert.True(t, time.Since(start) < time.Second)
+
+	// TEST: Dialing fails if all networks fail.
+	_, err = nets[0].DialRace(context.TODO(), rPK, port, 0, failType)
+	assert.Error(t, err)
+
+	// TEST: Dialing fails once the context is canceled.
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	_, err = nets[0].DialRace(ctx, rPK, port, 0, slowType)
+	assert.Error(t, err)
+}
+
+func TestNetwork_raceNetworks(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	n := NewRaw(Config{TpNetworks: []string{DmsgType, STcpType}}, nil, nil)
+	assert.Equal(t, []string{DmsgType}, n.raceNetworks(pk))
+}
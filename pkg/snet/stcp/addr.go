@@ -0,0 +1,84 @@
+package stcp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrUnbracketedIPv6 occurs when an IPv6 address with a port is not enclosed in brackets, which is ambiguous.
+var ErrUnbracketedIPv6 = errors.New("IPv6 addresses should be enclosed in brackets (such as '[::1]:7777')")
+
+// ParseAddr validates a tcp address of the form 'host:port' and returns it in canonical form.
+// IPv6 addresses are required to be bracketed (such as '[2001:db8::1]:7777'), and IP addresses are
+// formatted in their shortest form, so that equivalent addresses compare equal.
+func ParseAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		if strings.Count(addr, ":") > 1 && !strings.HasPrefix(addr, "[") {
+			return "", ErrUnbracketedIPv6
+		}
+		return "", err
+	}
+	if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+		return "", fmt.Errorf("invalid port '%s'", port)
+	}
+	if host == "" {
+		return "", errors.New("missing host")
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		host = ip.String()
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// canonicalAddr returns the canonical form of an address, or the address as-is if it is invalid.
+func canonicalAddr(addr string) string {
+	if c, err := ParseAddr(addr); err == nil {
+		return c
+	}
+	return addr
+}
+
+// Endpoints returns the addresses the stcp listener may be reached at: the external address obtained via port
+// mapping, followed by the listening address. If listening on all interfaces, the global unicast addresses
+// (IPv4 and IPv6) of the local interfaces are returned instead of the listening address.
+// IPv6 endpoints are of particular interest, as they are usually reachable without port mapping.
+func (c *Client) Endpoints() []string {
+	var endpoints []string
+	if ext := c.ExternalAddr(); ext != "" {
+		endpoints = append(endpoints, ext)
+	}
+	lTCP := c.listener()
+	if lTCP == nil {
+		return endpoints
+	}
+	lAddr, ok := lTCP.Addr().(*net.TCPAddr)
+	if !ok {
+		return endpoints
+	}
+	port := strconv.Itoa(lAddr.Port)
+	if !lAddr.IP.IsUnspecified() {
+		return append(endpoints, net.JoinHostPort(lAddr.IP.String(), port))
+	}
+
+	ifAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		c.log.Warnf("failed to obtain interface addresses: %v", err)
+		return endpoints
+	}
+	for _, a := range ifAddrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		// A listener bound to an unspecified IPv4 address is not reachable via IPv6.
+		if lAddr.IP.To4() != nil && ipNet.IP.To4() == nil {
+			continue
+		}
+		endpoints = append(endpoints, net.JoinHostPort(ipNet.IP.String(), port))
+	}
+	return endpoints
+}
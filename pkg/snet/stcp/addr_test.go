@@ -0,0 +1,86 @@
+package stcp
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddr(t *testing.T) {
+	cases := []struct {
+		addr string
+		want string
+		err  bool
+	}{
+		{addr: "127.0.0.1:7777", want: "127.0.0.1:7777"},
+		{addr: "localhost:7777", want: "localhost:7777"},
+		{addr: "[::1]:7777", want: "[::1]:7777"},
+		{addr: "[2001:0db8:0000:0000:0000:0000:0000:0001]:7777", want: "[2001:db8::1]:7777"},
+		{addr: "[::ffff:127.0.0.1]:7777", want: "127.0.0.1:7777"},
+		{addr: "2001:db8::1:7777", err: true},
+		{addr: "127.0.0.1", err: true},
+		{addr: "127.0.0.1:0", err: true},
+		{addr: "127.0.0.1:70000", err: true},
+		{addr: ":7777", err: true},
+	}
+	for _, tc := range cases {
+		got, err := ParseAddr(tc.addr)
+		if tc.err {
+			assert.Error(t, err, tc.addr)
+			continue
+		}
+		require.NoError(t, err, tc.addr)
+		assert.Equal(t, tc.want, got)
+	}
+	_, err := ParseAddr("2001:db8::1:7777")
+	assert.Equal(t, ErrUnbracketedIPv6, err)
+
+	// Equivalent addresses resolve to the same entry.
+	pk, _ := cipher.GenerateKeyPair()
+	table := NewTable(map[cipher.PubKey]string{pk: "[2001:db8:0::1]:7777"})
+	got, ok := table.PubKey("[2001:db8::1]:7777")
+	assert.True(t, ok)
+	assert.Equal(t, pk, got)
+}
+
+func TestClient_IPv6(t *testing.T) {
+	probe, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 is not supported: %v", err)
+	}
+	require.NoError(t, probe.Close())
+
+	const port = 10
+	rPK, rSK := cipher.GenerateKeyPair()
+	lPK, lSK := cipher.GenerateKeyPair()
+
+	// A listener on all interfaces accepts both IPv4 and IPv6 connections.
+	rC := NewClient(nil, rPK, rSK, NewTable(nil))
+	defer func() { require.NoError(t, rC.Close()) }()
+	require.NoError(t, rC.Serve(":0"))
+	lis, err := rC.Listen(port)
+	require.NoError(t, err)
+	go func() {
+		for {
+			if _, err := lis.Accept(); err != nil {
+				return
+			}
+		}
+	}()
+	rPort := rC.lTCP.Addr().(*net.TCPAddr).Port
+
+	for _, host := range []string{"::1", "127.0.0.1"} {
+		addr := net.JoinHostPort(host, strconv.Itoa(rPort))
+		lC := NewClient(nil, lPK, lSK, NewTable(map[cipher.PubKey]string{rPK: addr}))
+		conn, err := lC.Dial(context.Background(), rPK, port)
+		require.NoError(t, err, addr)
+		assert.Equal(t, rPK, conn.rAddr.PK)
+		require.NoError(t, conn.Close())
+		require.NoError(t, lC.Close())
+	}
+}
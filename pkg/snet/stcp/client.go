@@ -2,8 +2,8 @@ package stcp
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
-	"fmt"
 	"io"
 	"net"
 	"sync"
@@ -12,6 +12,7 @@ import (
 	"github.com/SkycoinProject/dmsg"
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/hashicorp/yamux"
 )
 
 // Conn wraps an underlying net.Conn and modifies various methods to integrate better with the 'network' package.
@@ -126,9 +127,20 @@ type Client struct {
 	t   PKTable
 	p   *Porter
 
-	lTCP net.Listener
-	lMap map[uint16]*Listener // key: lPort
-	mx   sync.Mutex
+	lTCP     net.Listener
+	serveErr error                // error which stopped serving, if any
+	lMap     map[uint16]*Listener // key: lPort
+	mx       sync.Mutex
+
+	extAddr string // external address obtained via port mapping
+
+	mux      bool                             // whether multiplexing is enabled
+	sessions map[cipher.PubKey]*yamux.Session // multiplexed sessions, key: remote PK
+	muxDials map[cipher.PubKey]*muxDial       // pending dials of multiplexed sessions, key: remote PK
+	noMux    map[cipher.PubKey]time.Time      // remotes which rejected multiplexing, value: time of rejection
+
+	tls *tls.Config // if set, connections are wrapped in TLS
+	sim *NetSim     // if set, simulated network conditions are applied to connections
 
 	done chan struct{}
 	once sync.Once
@@ -140,19 +152,26 @@ func NewClient(log *logging.Logger, pk cipher.PubKey, sk cipher.SecKey, t PKTabl
 		log = logging.MustGetLogger("stcp")
 	}
 	return &Client{
-		log:  log,
-		lPK:  pk,
-		lSK:  sk,
-		t:    t,
-		p:    newPorter(PorterMinEphemeral),
-		lMap: make(map[uint16]*Listener),
-		done: make(chan struct{}),
+		log:      log,
+		lPK:      pk,
+		lSK:      sk,
+		t:        t,
+		p:        newPorter(PorterMinEphemeral),
+		lMap:     make(map[uint16]*Listener),
+		sessions: make(map[cipher.PubKey]*yamux.Session),
+		muxDials: make(map[cipher.PubKey]*muxDial),
+		noMux:    make(map[cipher.PubKey]time.Time),
+		done:     make(chan struct{}),
 	}
 }
 
 // Serve serves the listening portion of the client.
+// Serve may be called again once serving has stopped due to an error (see ServeErr).
 func (c *Client) Serve(tcpAddr string) error {
-	if c.lTCP != nil {
+	c.mx.Lock()
+	serving := c.lTCP != nil && c.serveErr == nil
+	c.mx.Unlock()
+	if serving {
 		return errors.New("already listening")
 	}
 
@@ -160,15 +179,23 @@ func (c *Client) Serve(tcpAddr string) error {
 	if err != nil {
 		return err
 	}
-	c.lTCP = lTCP
+	if conf := c.tlsConfig(); conf != nil {
+		lTCP = tls.NewListener(lTCP, conf)
+	}
+	c.mx.Lock()
+	c.lTCP, c.serveErr = lTCP, nil
+	c.mx.Unlock()
 	c.log.Infof("listening on tcp addr: %v", lTCP.Addr())
 
 	go func() {
 		for {
-			if err := c.acceptTCPConn(); err != nil {
+			if err := c.acceptTCPConn(lTCP); err != nil {
 				c.log.Warnf("failed to accept incoming connection: %v", err)
 				if !IsHandshakeError(err) {
 					c.log.Warnf("stopped serving stcp")
+					c.mx.Lock()
+					c.serveErr = err
+					c.mx.Unlock()
 					return
 				}
 			}
@@ -178,19 +205,44 @@ func (c *Client) Serve(tcpAddr string) error {
 	return nil
 }
 
-func (c *Client) acceptTCPConn() error {
+// ErrNotServing is returned by ServeErr if Serve has not been called successfully.
+var ErrNotServing = errors.New("stcp is not listening")
+
+// ServeErr returns nil if the client is serving, or otherwise the reason it is not.
+func (c *Client) ServeErr() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.lTCP == nil {
+		return ErrNotServing
+	}
+	return c.serveErr
+}
+
+// listener returns the tcp listener, or nil if Serve has not been called successfully.
+func (c *Client) listener() net.Listener {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.lTCP
+}
+
+func (c *Client) acceptTCPConn(lTCP net.Listener) error {
 	if c.isClosed() {
 		return io.ErrClosedPipe
 	}
 
-	tcpConn, err := c.lTCP.Accept()
+	tcpConn, err := lTCP.Accept()
 	if err != nil {
 		return err
 	}
 	var lis *Listener
+	var isMux bool
 	hs := ResponderHandshake(func(f2 Frame2) error {
 		c.mx.Lock()
 		defer c.mx.Unlock()
+		if f2.DstAddr.Port == MuxPort && c.mux {
+			isMux = true
+			return nil
+		}
 		var ok bool
 		if lis, ok = c.lMap[f2.DstAddr.Port]; !ok {
 			return errors.New("not listening on given port")
@@ -201,7 +253,15 @@ func (c *Client) acceptTCPConn() error {
 	if err != nil {
 		return err
 	}
-	return lis.Introduce(conn)
+	if isMux {
+		sess, err := yamux.Server(conn.Conn, yamux.DefaultConfig())
+		if err != nil {
+			return err
+		}
+		c.addMuxSession(conn.rAddr.PK, sess)
+		return nil
+	}
+	return lis.Introduce(c.simulate(conn))
 }
 
 // Dial dials a new stcp.Conn to specified remote public key and port.
@@ -210,21 +270,101 @@ func (c *Client) Dial(ctx context.Context, rPK cipher.PubKey, rPort uint16) (*Co
 		return nil, io.ErrClosedPipe
 	}
 
-	tcpAddr, ok := c.t.Addr(rPK)
-	if !ok {
-		return nil, fmt.Errorf("pk table: entry of %s does not exist", rPK)
+	if c.multiplexing() {
+		conn, err := c.dialMux(ctx, rPK, rPort)
+		if err != errMuxUnsupported {
+			return conn, err
+		}
 	}
-	conn, err := net.Dial("tcp", tcpAddr)
+
+	conn, err := c.dialTCP(ctx, rPK)
 	if err != nil {
 		return nil, err
 	}
 
 	lPort, freePort, err := c.p.ReserveEphemeral(ctx)
 	if err != nil {
+		_ = conn.Close() //nolint:errcheck
 		return nil, err
 	}
 	hs := InitiatorHandshake(c.lSK, dmsg.Addr{PK: c.lPK, Port: lPort}, dmsg.Addr{PK: rPK, Port: rPort})
-	return newConn(conn, time.Now().Add(HandshakeTimeout), hs, freePort)
+	stop := closeOnDone(ctx, conn)
+	stcpConn, err := newConn(conn, handshakeDeadline(ctx), hs, freePort)
+	if ctxErr := stop(); ctxErr != nil {
+		if err == nil {
+			_ = stcpConn.Close() //nolint:errcheck
+		}
+		return nil, ctxErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.simulate(stcpConn), nil
+}
+
+func (c *Client) dialTCP(ctx context.Context, rPK cipher.PubKey) (net.Conn, error) {
+	tcpAddr, ok := c.t.Addr(rPK)
+	if !ok {
+		return nil, ErrNoTableEntry
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", tcpAddr)
+	if err != nil {
+		return nil, err
+	}
+	conf := c.tlsConfig()
+	if conf == nil {
+		return conn, nil
+	}
+
+	tlsConn := tls.Client(conn, tlsConfigForRemote(conf, rPK))
+	stop := closeOnDone(ctx, tlsConn)
+	if err := tlsConn.SetDeadline(handshakeDeadline(ctx)); err != nil {
+		_ = tlsConn.Close() //nolint:errcheck
+		return nil, err
+	}
+	err = tlsConn.Handshake()
+	if ctxErr := stop(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err == nil {
+		err = tlsConn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		_ = tlsConn.Close() //nolint:errcheck
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// handshakeDeadline returns the deadline of a handshake, which is HandshakeTimeout from now
+// unless the context's deadline is sooner.
+func handshakeDeadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(HandshakeTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
+
+// closeOnDone closes conn if the context is done before the returned stop function is called,
+// which interrupts blocking I/O on conn. Stop returns the context's error if conn was closed.
+func closeOnDone(ctx context.Context, conn io.Closer) (stop func() error) {
+	done := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close() //nolint:errcheck
+			errCh <- ctx.Err()
+		case <-done:
+			errCh <- nil
+		}
+	}()
+	return func() error {
+		close(done)
+		return <-errCh
+	}
 }
 
 // Listen creates a new listener for stcp.
@@ -248,6 +388,11 @@ func (c *Client) Listen(lPort uint16) (*Listener, error) {
 	return lis, nil
 }
 
+// Table returns the PKTable of the Client, which may be modified at runtime.
+func (c *Client) Table() PKTable {
+	return c.t
+}
+
 // Close closes the Client.
 func (c *Client) Close() error {
 	if c == nil {
@@ -266,6 +411,10 @@ func (c *Client) Close() error {
 		for _, lis := range c.lMap {
 			_ = lis.Close() // nolint:errcheck
 		}
+
+		for _, sess := range c.sessions {
+			_ = sess.Close() // nolint:errcheck
+		}
 	})
 	return nil
 }
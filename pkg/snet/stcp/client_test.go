@@ -1,12 +1,14 @@
 package stcp
 
 import (
+	"context"
 	"net"
 	"testing"
 	"time"
 
 	"github.com/SkycoinProject/dmsg"
 	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/nettest"
 )
@@ -52,3 +54,32 @@ func prepareConns(t *testing.T) (*Conn, *Conn, func()) {
 
 	return a, b, closeFunc
 }
+
+func TestClient_Dial_Context(t *testing.T) {
+	// A remote which accepts TCP connections, but never responds to the handshake.
+	lTCP, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, lTCP.Close()) }()
+	go func() {
+		for {
+			conn, err := lTCP.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }() // nolint:errcheck
+		}
+	}()
+
+	rPK, _ := cipher.GenerateKeyPair()
+	lPK, lSK := cipher.GenerateKeyPair()
+	c := NewClient(nil, lPK, lSK, NewTable(map[cipher.PubKey]string{rPK: lTCP.Addr().String()}))
+	defer func() { require.NoError(t, c.Close()) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = c.Dial(ctx, rPK, 1)
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < HandshakeTimeout)
+}
@@ -9,8 +9,6 @@ import (
 	"net"
 	"time"
 
-	cipher2 "github.com/SkycoinProject/skycoin/src/cipher"
-
 	"github.com/SkycoinProject/dmsg"
 	"github.com/SkycoinProject/dmsg/cipher"
 )
@@ -21,6 +19,9 @@ const (
 
 	// HandshakeNonceSize is the size of the nonce for the handshake.
 	HandshakeNonceSize = 16
+
+	// maxFrameSize is the maximum size of a single handshake frame.
+	maxFrameSize = 4096
 )
 
 // HandshakeError occurs when the handshake fails.
@@ -141,7 +142,6 @@ func (f2 *Frame2) Sign(srcSK cipher.SecKey) error {
 		return err
 	}
 	f2.Sig = sig
-	fmt.Println("SIGN! len(b.Bytes)", len(b.Bytes()), cipher2.SumSHA256(b.Bytes()).Hex())
 	return nil
 }
 
@@ -154,16 +154,10 @@ func (f2 Frame2) Verify(nonce [HandshakeNonceSize]byte) error {
 	sig := f2.Sig
 	f2.Sig = cipher.Sig{}
 
-	//cipher2.PubKeyFromSig(cipher2.Sig(sig))
-
 	var b bytes.Buffer
 	if err := json.NewEncoder(&b).Encode(f2); err != nil {
 		return err
 	}
-	hash := cipher.SumSHA256(b.Bytes())
-	rPK, err := cipher2.PubKeyFromSig(cipher2.Sig(sig), cipher2.SHA256(hash))
-	fmt.Println("VERIFY! len(b.Bytes)", len(b.Bytes()), cipher2.SHA256(hash).Hex(), "recovered:", rPK, err, "expected:", f2.SrcAddr.PK)
-
 	return cipher.VerifyPubKeySignedPayload(f2.SrcAddr.PK, sig, b.Bytes())
 }
 
@@ -179,7 +173,7 @@ func writeFrame1(w io.Writer, nonce [HandshakeNonceSize]byte) error {
 
 func readFrame1(r io.Reader) (Frame1, error) {
 	var f1 Frame1
-	err := json.NewDecoder(r).Decode(&f1)
+	err := readJSONLine(r, &f1)
 	return f1, err
 }
 
@@ -189,7 +183,7 @@ func writeFrame2(w io.Writer, f2 Frame2) error {
 
 func readFrame2(r io.Reader) (Frame2, error) {
 	var f2 Frame2
-	err := json.NewDecoder(r).Decode(&f2)
+	err := readJSONLine(r, &f2)
 	return f2, err
 }
 
@@ -204,6 +198,29 @@ func writeFrame3(w io.Writer, err error) error {
 
 func readFrame3(r io.Reader) (Frame3, error) {
 	var f3 Frame3
-	err := json.NewDecoder(r).Decode(&f3)
+	err := readJSONLine(r, &f3)
 	return f3, err
 }
+
+// readJSONLine reads a single newline-terminated JSON object from r.
+// Reading is done byte by byte so that no data beyond the frame is consumed from the connection
+// (which is what json.Decoder would do).
+func readJSONLine(r io.Reader, v interface{}) error {
+	var (
+		line []byte
+		b    [1]byte
+	)
+	for {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return err
+		}
+		if b[0] == '\n' {
+			break
+		}
+		if len(line) >= maxFrameSize {
+			return errors.New("handshake frame is too large")
+		}
+		line = append(line, b[0])
+	}
+	return json.Unmarshal(line, v)
+}
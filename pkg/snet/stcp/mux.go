@@ -0,0 +1,252 @@
+package stcp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/hashicorp/yamux"
+)
+
+const (
+	// MuxPort is the destination port of handshakes which establish multiplexed sessions.
+	// As port 0 can never be listened on, visors which do not support multiplexing reject such handshakes.
+	MuxPort = uint16(0)
+
+	// MuxRetryInterval is the duration to wait before reattempting to establish a multiplexed session
+	// with a remote which does not support multiplexing.
+	MuxRetryInterval = 10 * time.Minute
+
+	muxStreamAccepted = byte(1)
+	muxStreamRejected = byte(0)
+)
+
+var errMuxUnsupported = errors.New("remote does not support multiplexing")
+
+// SetMultiplexing enables or disables multiplexing of connections.
+// When enabled, all connections with a given remote share a single TCP connection (and handshake) where possible.
+// Remotes which do not support multiplexing are dialed with a TCP connection per stcp connection.
+func (c *Client) SetMultiplexing(enable bool) {
+	c.mx.Lock()
+	c.mux = enable
+	c.mx.Unlock()
+}
+
+func (c *Client) multiplexing() bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.mux
+}
+
+// Sessions returns the number of multiplexed sessions.
+func (c *Client) Sessions() int {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return len(c.sessions)
+}
+
+// dialMux dials a stream over the multiplexed session with the remote, establishing the session if needed.
+// errMuxUnsupported is returned if the remote does not support multiplexing.
+func (c *Client) dialMux(ctx context.Context, rPK cipher.PubKey, rPort uint16) (*Conn, error) {
+	sess, err := c.muxSession(ctx, rPK)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := sess.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	lPort, freePort, err := c.p.ReserveEphemeral(ctx)
+	if err != nil {
+		_ = stream.Close() //nolint:errcheck
+		return nil, err
+	}
+	if err := initiateMuxStream(stream, handshakeDeadline(ctx), lPort, rPort); err != nil {
+		_ = stream.Close() //nolint:errcheck
+		freePort()
+		return nil, err
+	}
+	return c.simulate(&Conn{
+		Conn:     stream,
+		lAddr:    dmsg.Addr{PK: c.lPK, Port: lPort},
+		rAddr:    dmsg.Addr{PK: rPK, Port: rPort},
+		freePort: freePort,
+	}), nil
+}
+
+// muxDial is a pending dial of a multiplexed session, which concurrent dials to the same remote wait for.
+type muxDial struct {
+	done chan struct{}
+	sess *yamux.Session
+	err  error
+}
+
+// muxSession returns the multiplexed session with the remote, establishing it if it does not exist. Only one
+// session is established at a time per remote, so that concurrent dials share the session.
+func (c *Client) muxSession(ctx context.Context, rPK cipher.PubKey) (*yamux.Session, error) {
+	c.mx.Lock()
+	if sess, ok := c.sessions[rPK]; ok {
+		c.mx.Unlock()
+		return sess, nil
+	}
+	if failedAt, failed := c.noMux[rPK]; failed && time.Since(failedAt) < MuxRetryInterval {
+		c.mx.Unlock()
+		return nil, errMuxUnsupported
+	}
+	if d, ok := c.muxDials[rPK]; ok {
+		c.mx.Unlock()
+		select {
+		case <-d.done:
+			return d.sess, d.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	d := &muxDial{done: make(chan struct{})}
+	c.muxDials[rPK] = d
+	c.mx.Unlock()
+
+	d.sess, d.err = c.dialMuxSession(ctx, rPK)
+	c.mx.Lock()
+	delete(c.muxDials, rPK)
+	c.mx.Unlock()
+	close(d.done)
+	return d.sess, d.err
+}
+
+// dialMuxSession establishes a multiplexed session with the remote.
+func (c *Client) dialMuxSession(ctx context.Context, rPK cipher.PubKey) (*yamux.Session, error) {
+	conn, err := c.dialTCP(ctx, rPK)
+	if err != nil {
+		return nil, err
+	}
+	hs := InitiatorHandshake(c.lSK, dmsg.Addr{PK: c.lPK, Port: MuxPort}, dmsg.Addr{PK: rPK, Port: MuxPort})
+	stop := closeOnDone(ctx, conn)
+	_, _, err = hs(conn, handshakeDeadline(ctx))
+	if ctxErr := stop(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		_ = conn.Close() //nolint:errcheck
+		if IsHandshakeError(err) {
+			c.log.Infof("falling back to non-multiplexed connections with %s: %v", rPK, err)
+			c.mx.Lock()
+			c.noMux[rPK] = time.Now()
+			c.mx.Unlock()
+			return nil, errMuxUnsupported
+		}
+		return nil, err
+	}
+	sess, err := yamux.Client(conn, yamux.DefaultConfig())
+	if err != nil {
+		_ = conn.Close() //nolint:errcheck
+		return nil, err
+	}
+	c.addMuxSession(rPK, sess)
+	return sess, nil
+}
+
+// addMuxSession registers the session and serves the streams opened by the remote over it.
+func (c *Client) addMuxSession(rPK cipher.PubKey, sess *yamux.Session) {
+	c.mx.Lock()
+	c.sessions[rPK] = sess
+	delete(c.noMux, rPK)
+	c.mx.Unlock()
+
+	go func() {
+		defer func() {
+			c.mx.Lock()
+			if c.sessions[rPK] == sess {
+				delete(c.sessions, rPK)
+			}
+			c.mx.Unlock()
+			_ = sess.Close() //nolint:errcheck
+		}()
+		for {
+			stream, err := sess.Accept()
+			if err != nil {
+				if !c.isClosed() && !sess.IsClosed() {
+					c.log.Warnf("multiplexed session with %s closed: %v", rPK, err)
+				}
+				return
+			}
+			go c.acceptMuxStream(rPK, stream)
+		}
+	}()
+}
+
+func (c *Client) acceptMuxStream(rPK cipher.PubKey, stream net.Conn) {
+	var lis *Listener
+	lPort, rPort, err := respondMuxStream(stream, func(lPort uint16) error {
+		c.mx.Lock()
+		defer c.mx.Unlock()
+		var ok bool
+		if lis, ok = c.lMap[lPort]; !ok {
+			return errors.New("not listening on given port")
+		}
+		return nil
+	})
+	if err != nil {
+		c.log.Warnf("failed to accept multiplexed stream from %s: %v", rPK, err)
+		_ = stream.Close() //nolint:errcheck
+		return
+	}
+	conn := &Conn{
+		Conn:  stream,
+		lAddr: dmsg.Addr{PK: c.lPK, Port: lPort},
+		rAddr: dmsg.Addr{PK: rPK, Port: rPort},
+	}
+	if err := lis.Introduce(c.simulate(conn)); err != nil {
+		_ = stream.Close() //nolint:errcheck
+	}
+}
+
+// initiateMuxStream sends the stream header (source and destination ports) and awaits acceptance.
+func initiateMuxStream(stream net.Conn, deadline time.Time, lPort, rPort uint16) error {
+	if err := stream.SetDeadline(deadline); err != nil {
+		return err
+	}
+	hdr := make([]byte, 4)
+	binary.BigEndian.PutUint16(hdr[0:], lPort)
+	binary.BigEndian.PutUint16(hdr[2:], rPort)
+	if _, err := stream.Write(hdr); err != nil {
+		return err
+	}
+	resp := make([]byte, 1)
+	if _, err := io.ReadFull(stream, resp); err != nil {
+		return err
+	}
+	if resp[0] != muxStreamAccepted {
+		return HandshakeError("multiplexed stream rejected: not listening on given port")
+	}
+	return stream.SetDeadline(time.Time{})
+}
+
+// respondMuxStream reads the stream header and accepts the stream if check succeeds.
+func respondMuxStream(stream net.Conn, check func(lPort uint16) error) (lPort, rPort uint16, err error) {
+	if err = stream.SetDeadline(time.Now().Add(HandshakeTimeout)); err != nil {
+		return
+	}
+	hdr := make([]byte, 4)
+	if _, err = io.ReadFull(stream, hdr); err != nil {
+		return
+	}
+	rPort = binary.BigEndian.Uint16(hdr[0:])
+	lPort = binary.BigEndian.Uint16(hdr[2:])
+
+	if err = check(lPort); err != nil {
+		_, _ = stream.Write([]byte{muxStreamRejected}) //nolint:errcheck
+		return
+	}
+	if _, err = stream.Write([]byte{muxStreamAccepted}); err != nil {
+		return
+	}
+	err = stream.SetDeadline(time.Time{})
+	return
+}
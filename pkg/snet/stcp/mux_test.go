@@ -0,0 +1,127 @@
+package stcp
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Multiplexing(t *testing.T) {
+	run := func(t *testing.T, respMux bool, expSessions int) {
+		aPK, aSK := cipher.GenerateKeyPair()
+		bPK, bSK := cipher.GenerateKeyPair()
+
+		b := NewClient(nil, bPK, bSK, NewTable(nil))
+		b.SetMultiplexing(respMux)
+		require.NoError(t, b.Serve("127.0.0.1:0"))
+		defer func() { require.NoError(t, b.Close()) }()
+
+		a := NewClient(nil, aPK, aSK, NewTable(map[cipher.PubKey]string{bPK: b.lTCP.Addr().String()}))
+		a.SetMultiplexing(true)
+		defer func() { require.NoError(t, a.Close()) }()
+
+		for _, port := range []uint16{10, 11} {
+			lis, err := b.Listen(port)
+			require.NoError(t, err)
+
+			go func() {
+				conn, err := lis.Accept()
+				if err != nil {
+					return
+				}
+				_, _ = io.Copy(conn, conn) //nolint:errcheck
+			}()
+
+			conn, err := a.Dial(context.TODO(), bPK, port)
+			require.NoError(t, err)
+			assert.Equal(t, aPK, conn.lAddr.PK)
+			assert.Equal(t, port, conn.rAddr.Port)
+
+			_, err = conn.Write([]byte("ping"))
+			require.NoError(t, err)
+			buf := make([]byte, 4)
+			_, err = io.ReadFull(conn, buf)
+			require.NoError(t, err)
+			assert.Equal(t, "ping", string(buf))
+		}
+		assert.Equal(t, expSessions, a.Sessions())
+
+		// Dialing a port which is not listened on should fail.
+		_, err := a.Dial(context.TODO(), bPK, 12)
+		require.Error(t, err)
+	}
+
+	t.Run("multiplexed", func(t *testing.T) { run(t, true, 1) })
+	t.Run("fallback", func(t *testing.T) { run(t, false, 0) })
+}
+
+// Concurrent dials to the same remote should share a single multiplexed session.
+func TestClient_Multiplexing_concurrentDials(t *testing.T) {
+	aPK, aSK := cipher.GenerateKeyPair()
+	bPK, bSK := cipher.GenerateKeyPair()
+
+	b := NewClient(nil, bPK, bSK, NewTable(nil))
+	b.SetMultiplexing(true)
+	require.NoError(t, b.Serve("127.0.0.1:0"))
+	defer func() { require.NoError(t, b.Close()) }()
+	lis, err := b.Listen(10)
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _, _ = io.Copy(conn, conn) }() //nolint:errcheck
+		}
+	}()
+
+	// TCP connections to b are counted by a proxy.
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, proxy.Close()) }()
+	var tcpConns int32
+	go func() {
+		for {
+			conn, err := proxy.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&tcpConns, 1)
+			bConn, err := net.Dial("tcp", b.lTCP.Addr().String())
+			if err != nil {
+				_ = conn.Close() //nolint:errcheck
+				continue
+			}
+			go func() { _, _ = io.Copy(bConn, conn) }() //nolint:errcheck
+			go func() { _, _ = io.Copy(conn, bConn) }() //nolint:errcheck
+		}
+	}()
+
+	a := NewClient(nil, aPK, aSK, NewTable(map[cipher.PubKey]string{bPK: proxy.Addr().String()}))
+	a.SetMultiplexing(true)
+	defer func() { require.NoError(t, a.Close()) }()
+
+	const dials = 10
+	var wg sync.WaitGroup
+	wg.Add(dials)
+	for i := 0; i < dials; i++ {
+		go func() {
+			defer wg.Done()
+			conn, err := a.Dial(context.TODO(), bPK, 10)
+			if assert.NoError(t, err) {
+				assert.NoError(t, conn.Close())
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, a.Sessions())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tcpConns))
+}
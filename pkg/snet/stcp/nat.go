@@ -0,0 +1,120 @@
+package stcp
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/portmap"
+)
+
+// PortMappingRetry is the delay between attempts to discover a NAT gateway.
+const PortMappingRetry = time.Minute
+
+// discoverMapper finds the port mapper of the NAT gateway. It is a variable so that it may be replaced in tests.
+var discoverMapper = portmap.Discover
+
+// ExternalAddr returns the external address of the stcp listener as learned via port mapping.
+// An empty string is returned if no mapping is established.
+func (c *Client) ExternalAddr() string {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.extAddr
+}
+
+func (c *Client) setExternalAddr(addr string) {
+	c.mx.Lock()
+	c.extAddr = addr
+	c.mx.Unlock()
+}
+
+// MapPort attempts to map the port of the stcp listener on the NAT gateway (via NAT-PMP or UPnP).
+// The mapping is renewed at half the lease duration and is removed when the Client is closed.
+// onAddr is called (if non-nil) every time the external address changes.
+// Serve should be called beforehand.
+func (c *Client) MapPort(onAddr func(addr string)) error {
+	lTCP := c.listener()
+	if lTCP == nil {
+		return ErrNotServing
+	}
+	lPort := uint16(lTCP.Addr().(*net.TCPAddr).Port)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-c.done
+		cancel()
+	}()
+
+	go func() {
+		var (
+			m     portmap.Mapper
+			ePort = lPort
+			err   error
+		)
+		for {
+			if m == nil {
+				if m, err = discoverMapper(ctx); err != nil {
+					c.log.Warnf("port mapping: %v: retrying in %v", err, PortMappingRetry)
+					if !c.sleep(PortMappingRetry) {
+						return
+					}
+					continue
+				}
+				c.log.Infof("port mapping: found %s gateway", m.Type())
+			}
+
+			if ePort, err = c.renewMapping(ctx, m, lPort, ePort, onAddr); err != nil {
+				c.log.Warnf("port mapping: %s: %v", m.Type(), err)
+				m, ePort = nil, lPort
+				if !c.sleep(PortMappingRetry) {
+					return
+				}
+				continue
+			}
+			if !c.sleep(portmap.DefaultLease / 2) {
+				break
+			}
+		}
+
+		delCtx, delCancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer delCancel()
+		if err := m.DeleteMapping(delCtx, lPort, ePort); err != nil {
+			c.log.Warnf("port mapping: failed to delete mapping: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (c *Client) renewMapping(ctx context.Context, m portmap.Mapper, lPort, ePort uint16, onAddr func(string)) (uint16, error) {
+	ePort, err := m.AddMapping(ctx, lPort, ePort, portmap.DefaultLease)
+	if err != nil {
+		return 0, err
+	}
+	ip, err := m.ExternalIP(ctx)
+	if err != nil {
+		return 0, err
+	}
+	addr := net.JoinHostPort(ip.String(), strconv.Itoa(int(ePort)))
+	if addr != c.ExternalAddr() {
+		c.log.Infof("port mapping: external address is %s", addr)
+		c.setExternalAddr(addr)
+		if onAddr != nil {
+			onAddr(addr)
+		}
+	}
+	return ePort, nil
+}
+
+// sleep returns false if the Client is closed before d elapses.
+func (c *Client) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-c.done:
+		return false
+	case <-t.C:
+		return true
+	}
+}
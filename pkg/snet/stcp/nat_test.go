@@ -0,0 +1,145 @@
+package stcp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/portmap"
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+)
+
+// fakeIGD is a NAT gateway which maps internal ports to external ports with an offset.
+type fakeIGD struct {
+	mx       sync.Mutex
+	ip       net.IP
+	offset   uint16
+	err      error             // returned by AddMapping if set.
+	mappings map[uint16]uint16 // external port to internal port.
+	leases   []time.Duration
+}
+
+func newFakeIGD(ip string, offset uint16) *fakeIGD {
+	return &fakeIGD{ip: net.ParseIP(ip), offset: offset, mappings: make(map[uint16]uint16)}
+}
+
+func (g *fakeIGD) Type() string { return "fake" }
+
+func (g *fakeIGD) ExternalIP(context.Context) (net.IP, error) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	return g.ip, nil
+}
+
+func (g *fakeIGD) AddMapping(_ context.Context, internalPort, externalPort uint16, lease time.Duration) (uint16, error) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	if g.err != nil {
+		return 0, g.err
+	}
+	// The requested external port is granted if it is already mapped to the internal port.
+	if p, ok := g.mappings[externalPort]; !ok || p != internalPort {
+		externalPort = internalPort + g.offset
+	}
+	g.mappings[externalPort] = internalPort
+	g.leases = append(g.leases, lease)
+	return externalPort, nil
+}
+
+func (g *fakeIGD) DeleteMapping(_ context.Context, _, externalPort uint16) error {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	delete(g.mappings, externalPort)
+	return nil
+}
+
+func (g *fakeIGD) set(ip string, offset uint16, err error) {
+	g.mx.Lock()
+	g.ip, g.offset, g.err = net.ParseIP(ip), offset, err
+	g.mx.Unlock()
+}
+
+func (g *fakeIGD) mapped() map[uint16]uint16 {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	out := make(map[uint16]uint16, len(g.mappings))
+	for e, i := range g.mappings {
+		out[e] = i
+	}
+	return out
+}
+
+func TestClient_renewMapping(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	c := NewClient(nil, pk, sk, NewTable(nil))
+	defer func() { require.NoError(t, c.Close()) }()
+
+	igd := newFakeIGD("203.0.113.1", 1000)
+	var addrs []string
+	onAddr := func(addr string) { addrs = append(addrs, addr) }
+
+	// The external address is learned on the first mapping.
+	ePort, err := c.renewMapping(context.TODO(), igd, 7777, 7777, onAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(8777), ePort)
+	assert.Equal(t, "203.0.113.1:8777", c.ExternalAddr())
+
+	// Renewals keep the external port, and do not report an unchanged address.
+	ePort, err = c.renewMapping(context.TODO(), igd, 7777, ePort, onAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(8777), ePort)
+	assert.Equal(t, []string{"203.0.113.1:8777"}, addrs)
+	assert.Equal(t, []time.Duration{portmap.DefaultLease, portmap.DefaultLease}, igd.leases)
+
+	// A change of the external IP is reported.
+	igd.set("203.0.113.2", 1000, nil)
+	_, err = c.renewMapping(context.TODO(), igd, 7777, ePort, onAddr)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"203.0.113.1:8777", "203.0.113.2:8777"}, addrs)
+	assert.Equal(t, "203.0.113.2:8777", c.ExternalAddr())
+
+	// Failures are returned, and keep the last external address.
+	igd.set("203.0.113.2", 1000, errors.New("mapping refused"))
+	_, err = c.renewMapping(context.TODO(), igd, 7777, ePort, onAddr)
+	assert.Error(t, err)
+	assert.Equal(t, "203.0.113.2:8777", c.ExternalAddr())
+}
+
+func TestClient_MapPort(t *testing.T) {
+	igd := newFakeIGD("203.0.113.1", 0)
+	orig := discoverMapper
+	discoverMapper = func(context.Context) (portmap.Mapper, error) { return igd, nil }
+	defer func() { discoverMapper = orig }()
+
+	pk, sk := cipher.GenerateKeyPair()
+	c := NewClient(nil, pk, sk, NewTable(nil))
+	assert.Equal(t, ErrNotServing, c.MapPort(nil))
+
+	require.NoError(t, c.Serve("127.0.0.1:0"))
+	lPort := uint16(c.lTCP.Addr().(*net.TCPAddr).Port)
+	extAddr := net.JoinHostPort("203.0.113.1", strconv.Itoa(int(lPort)))
+
+	addrCh := make(chan string, 1)
+	require.NoError(t, c.MapPort(func(addr string) { addrCh <- addr }))
+	select {
+	case addr := <-addrCh:
+		assert.Equal(t, extAddr, addr)
+	case <-time.After(5 * time.Second):
+		t.Fatal("external address was not reported")
+	}
+	assert.Equal(t, extAddr, c.ExternalAddr())
+	assert.Equal(t, extAddr, c.Endpoints()[0])
+	assert.Equal(t, map[uint16]uint16{lPort: lPort}, igd.mapped())
+
+	// The mapping is removed once the client is closed.
+	require.NoError(t, c.Close())
+	testhelpers.Eventually(t, func() bool { return len(igd.mapped()) == 0 }, 5*time.Second, 10*time.Millisecond)
+}
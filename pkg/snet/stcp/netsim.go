@@ -0,0 +1,166 @@
+package stcp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// netSimQueueSize is the maximum number of writes which may be delayed at once per connection.
+const netSimQueueSize = 1024
+
+// NetSim configures simulated network conditions which are applied to stcp connections.
+// It is intended for local testing of flow control and app behavior, and should not be enabled in production.
+//
+// Conditions are applied to writes, after the stcp handshake. Each write is treated as a packet, so
+// Loss drops whole writes. As dropped writes corrupt streams which rely on every byte arriving (such as
+// encrypted transports), Loss should only be used with transports which do not use encryption, or which
+// use forward error correction (see transport.FECConfig) to repair most losses.
+type NetSim struct {
+	Latency time.Duration // delay added to each write.
+	Jitter  time.Duration // maximum random deviation from Latency (writes are never reordered).
+	Loss    float64       // probability of a write being dropped, in the range [0, 1].
+}
+
+// Validate returns an error if the NetSim is invalid.
+func (s *NetSim) Validate() error {
+	if s.Latency < 0 || s.Jitter < 0 {
+		return errors.New("simulated latency and jitter must not be negative")
+	}
+	if s.Loss < 0 || s.Loss > 1 {
+		return fmt.Errorf("simulated loss %v is not in the range [0, 1]", s.Loss)
+	}
+	return nil
+}
+
+// SetNetSim sets the simulated network conditions which are applied to connections established afterwards.
+// A nil NetSim disables simulation.
+func (c *Client) SetNetSim(sim *NetSim) {
+	if sim != nil {
+		c.log.Warnf("simulating network conditions: latency(%s) jitter(%s) loss(%v)", sim.Latency, sim.Jitter, sim.Loss)
+	}
+	c.mx.Lock()
+	c.sim = sim
+	c.mx.Unlock()
+}
+
+// simulate wraps the underlying connection of conn to apply the simulated network conditions, if set.
+func (c *Client) simulate(conn *Conn) *Conn {
+	c.mx.Lock()
+	sim := c.sim
+	c.mx.Unlock()
+
+	if sim != nil {
+		conn.Conn = newSimConn(conn.Conn, *sim)
+	}
+	return conn
+}
+
+type simPacket struct {
+	b  []byte
+	at time.Time
+}
+
+// simConn delays and drops writes to the underlying connection.
+type simConn struct {
+	net.Conn
+	sim  NetSim
+	rand *rand.Rand // protected by mx.
+
+	queue chan simPacket
+	last  time.Time // delivery time of the last queued write, protected by mx.
+	err   error     // error of a delayed write, protected by mx.
+	mx    sync.Mutex
+
+	done chan struct{}
+	once sync.Once
+}
+
+func newSimConn(conn net.Conn, sim NetSim) *simConn {
+	sc := &simConn{
+		Conn:  conn,
+		sim:   sim,
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())), // nolint:gosec
+		queue: make(chan simPacket, netSimQueueSize),
+		done:  make(chan struct{}),
+	}
+	go sc.deliver()
+	return sc
+}
+
+func (sc *simConn) deliver() {
+	for {
+		var p simPacket
+		select {
+		case <-sc.done:
+			return
+		case p = <-sc.queue:
+		}
+
+		timer := time.NewTimer(time.Until(p.at))
+		select {
+		case <-sc.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := sc.Conn.Write(p.b); err != nil {
+			sc.mx.Lock()
+			sc.err = err
+			sc.mx.Unlock()
+		}
+	}
+}
+
+// Write queues b for delayed delivery, or drops it.
+// Write blocks if too many writes are already delayed.
+func (sc *simConn) Write(b []byte) (int, error) {
+	p, drop, err := sc.schedule(b)
+	if err != nil {
+		return 0, err
+	}
+	if drop {
+		return len(b), nil
+	}
+	select {
+	case <-sc.done:
+		return 0, io.ErrClosedPipe
+	case sc.queue <- p:
+		return len(b), nil
+	}
+}
+
+func (sc *simConn) schedule(b []byte) (p simPacket, drop bool, err error) {
+	sc.mx.Lock()
+	defer sc.mx.Unlock()
+
+	if sc.err != nil {
+		return simPacket{}, false, sc.err
+	}
+	if sc.sim.Loss > 0 && sc.rand.Float64() < sc.sim.Loss {
+		return simPacket{}, true, nil
+	}
+
+	delay := sc.sim.Latency
+	if sc.sim.Jitter > 0 {
+		delay += time.Duration(sc.rand.Int63n(int64(2*sc.sim.Jitter))) - sc.sim.Jitter
+	}
+	at := time.Now().Add(delay)
+	if at.Before(sc.last) {
+		at = sc.last
+	}
+	sc.last = at
+
+	return simPacket{b: append([]byte(nil), b...), at: at}, false, nil
+}
+
+// Close closes the underlying connection. Writes which are still delayed are dropped.
+func (sc *simConn) Close() error {
+	sc.once.Do(func() { close(sc.done) })
+	return sc.Conn.Close()
+}
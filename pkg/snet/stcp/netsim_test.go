@@ -0,0 +1,61 @@
+package stcp
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimConn(t *testing.T) {
+	// TEST: Writes are delayed by latency, and are not reordered by jitter.
+	t.Run("Latency", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		sc := newSimConn(c1, NetSim{Latency: 50 * time.Millisecond, Jitter: 20 * time.Millisecond})
+		defer func() {
+			assert.NoError(t, sc.Close())
+			assert.NoError(t, c2.Close())
+		}()
+
+		start := time.Now()
+		for i := 0; i < 20; i++ {
+			_, err := sc.Write([]byte{byte(i)})
+			require.NoError(t, err)
+		}
+
+		b := make([]byte, 20)
+		_, err := io.ReadFull(c2, b)
+		require.NoError(t, err)
+		assert.True(t, time.Since(start) >= 30*time.Millisecond)
+		for i := range b {
+			assert.Equal(t, byte(i), b[i])
+		}
+	})
+
+	// TEST: All writes are dropped with a loss of 1.
+	t.Run("Loss", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		sc := newSimConn(c1, NetSim{Loss: 1})
+		defer func() {
+			assert.NoError(t, sc.Close())
+			assert.NoError(t, c2.Close())
+		}()
+
+		n, err := sc.Write([]byte("dropped"))
+		require.NoError(t, err)
+		assert.Equal(t, 7, n)
+
+		require.NoError(t, c2.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+		_, err = c2.Read(make([]byte, 7))
+		assert.Error(t, err)
+	})
+}
+
+func TestNetSim_Validate(t *testing.T) {
+	assert.NoError(t, (&NetSim{Latency: time.Millisecond, Loss: 0.1}).Validate())
+	assert.Error(t, (&NetSim{Latency: -time.Millisecond}).Validate())
+	assert.Error(t, (&NetSim{Loss: 1.5}).Validate())
+}
@@ -2,42 +2,66 @@ package stcp
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 )
 
+// ErrNoTableEntry occurs when dialing a remote which has no entry in the PKTable.
+var ErrNoTableEntry = errors.New("pk table: entry of remote does not exist")
+
 // PKTable associates public keys to tcp addresses.
+// Addresses are stored in the canonical form returned by ParseAddr.
 type PKTable interface {
 	Addr(pk cipher.PubKey) (string, bool)
 	PubKey(addr string) (cipher.PubKey, bool)
 	Count() int
+
+	// Entries returns a copy of all entries.
+	Entries() map[cipher.PubKey]string
+	// Add adds or replaces the entry of the given public key.
+	Add(pk cipher.PubKey, addr string)
+	// Remove removes the entry of the given public key.
+	Remove(pk cipher.PubKey)
+	// Reset replaces all entries.
+	Reset(entries map[cipher.PubKey]string)
 }
 
 type memoryTable struct {
 	entries map[cipher.PubKey]string
 	reverse map[string]cipher.PubKey
+	mu      sync.RWMutex
 }
 
 // NewTable instantiates a memory implementation of PKTable.
 func NewTable(entries map[cipher.PubKey]string) PKTable {
-	reverse := make(map[string]cipher.PubKey, len(entries))
-	for pk, addr := range entries {
-		reverse[addr] = pk
-	}
-	return &memoryTable{
-		entries: entries,
-		reverse: reverse,
-	}
+	mt := new(memoryTable)
+	mt.Reset(entries)
+	return mt
 }
 
 // NewTableFromFile is similar to NewTable, but grabs predefined values
 // from a file specified in 'path'.
 func NewTableFromFile(path string) (PKTable, error) {
+	entries, err := ReadTableFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(entries), nil
+}
+
+// ReadTableFile reads PKTable entries from a file specified in 'path'.
+// Each line of the file should contain a public key and an address, separated by whitespace.
+func ReadTableFile(path string) (map[cipher.PubKey]string, error) {
 	path, err := filepath.Abs(path)
 	if err != nil {
 		return nil, err
@@ -58,6 +82,9 @@ func NewTableFromFile(path string) (PKTable, error) {
 	)
 	for s.Scan() {
 		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
 		if len(fields) != 2 {
 			return nil, errors.New("pk file is invalid: each line should have two fields")
 		}
@@ -65,24 +92,130 @@ func NewTableFromFile(path string) (PKTable, error) {
 		if err := pk.UnmarshalText([]byte(fields[0])); err != nil {
 			return nil, fmt.Errorf("pk file is invalid: each line should have two fields: %v", err)
 		}
-		entries[pk] = fields[1]
+		addr, err := ParseAddr(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("pk file is invalid: address of %s: %v", pk, err)
+		}
+		entries[pk] = addr
+	}
+	return entries, s.Err()
+}
+
+// WriteTableFile writes PKTable entries to a file specified in 'path', in the format read by ReadTableFile.
+func WriteTableFile(path string, entries map[cipher.PubKey]string) error {
+	lines := make([]string, 0, len(entries))
+	for pk, addr := range entries {
+		lines = append(lines, pk.String()+" "+addr+"\n")
+	}
+	sort.Strings(lines)
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strings.Join(lines, "")), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// WatchTableFile polls the file specified in 'path' for modifications, and calls 'reload' with the entries
+// of the file whenever it changes, until the context is canceled.
+// Files which fail to be read are skipped, so that a partially written file does not clear the table.
+func WatchTableFile(ctx context.Context, path string, interval time.Duration, reload func(map[cipher.PubKey]string)) error {
+	modTime := func() time.Time {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+	last := modTime()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		mod := modTime()
+		if mod.IsZero() || mod.Equal(last) {
+			continue
+		}
+		entries, err := ReadTableFile(path)
+		if err != nil {
+			continue
+		}
+		last = mod
+		reload(entries)
 	}
-	return NewTable(entries), nil
 }
 
 // Addr obtains the address associated with the given public key.
 func (mt *memoryTable) Addr(pk cipher.PubKey) (string, bool) {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
 	addr, ok := mt.entries[pk]
 	return addr, ok
 }
 
-// PubKey obtains the public key associated with the given public key.
+// PubKey obtains the public key associated with the given address.
 func (mt *memoryTable) PubKey(addr string) (cipher.PubKey, bool) {
-	pk, ok := mt.reverse[addr]
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	pk, ok := mt.reverse[canonicalAddr(addr)]
 	return pk, ok
 }
 
 // Count returns the number of entries within the PKTable implementation.
 func (mt *memoryTable) Count() int {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
 	return len(mt.entries)
 }
+
+// Entries returns a copy of all entries.
+func (mt *memoryTable) Entries() map[cipher.PubKey]string {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	entries := make(map[cipher.PubKey]string, len(mt.entries))
+	for pk, addr := range mt.entries {
+		entries[pk] = addr
+	}
+	return entries
+}
+
+// Add adds or replaces the entry of the given public key.
+func (mt *memoryTable) Add(pk cipher.PubKey, addr string) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	if old, ok := mt.entries[pk]; ok {
+		delete(mt.reverse, old)
+	}
+	addr = canonicalAddr(addr)
+	mt.entries[pk] = addr
+	mt.reverse[addr] = pk
+}
+
+// Remove removes the entry of the given public key.
+func (mt *memoryTable) Remove(pk cipher.PubKey) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	if addr, ok := mt.entries[pk]; ok {
+		delete(mt.reverse, addr)
+		delete(mt.entries, pk)
+	}
+}
+
+// Reset replaces all entries.
+func (mt *memoryTable) Reset(entries map[cipher.PubKey]string) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mt.entries = make(map[cipher.PubKey]string, len(entries))
+	mt.reverse = make(map[string]cipher.PubKey, len(entries))
+	for pk, addr := range entries {
+		addr = canonicalAddr(addr)
+		mt.entries[pk] = addr
+		mt.reverse[addr] = pk
+	}
+}
@@ -0,0 +1,67 @@
+package stcp
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+)
+
+func TestMemoryTable(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+
+	table := NewTable(map[cipher.PubKey]string{pk1: "127.0.0.1:7777"})
+	table.Add(pk1, "127.0.0.1:8888")
+	table.Add(pk2, "127.0.0.1:9999")
+
+	_, ok := table.PubKey("127.0.0.1:7777")
+	assert.False(t, ok)
+	pk, ok := table.PubKey("127.0.0.1:8888")
+	assert.True(t, ok)
+	assert.Equal(t, pk1, pk)
+	assert.Equal(t, 2, table.Count())
+
+	table.Remove(pk1)
+	_, ok = table.Addr(pk1)
+	assert.False(t, ok)
+	assert.Equal(t, map[cipher.PubKey]string{pk2: "127.0.0.1:9999"}, table.Entries())
+}
+
+func TestWatchTableFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pktable")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "pktable")
+
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+	require.NoError(t, WriteTableFile(path, map[cipher.PubKey]string{pk1: "127.0.0.1:7777"}))
+
+	table, err := NewTableFromFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, table.Count())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = WatchTableFile(ctx, path, 10*time.Millisecond, table.Reset) // nolint:errcheck
+	}()
+
+	// Ensure the modification time differs on file systems with coarse timestamps.
+	time.Sleep(20 * time.Millisecond)
+	updated := map[cipher.PubKey]string{pk1: "127.0.0.1:7777", pk2: "127.0.0.1:8888"}
+	require.NoError(t, WriteTableFile(path, updated))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+
+	testhelpers.Eventually(t, func() bool { return table.Count() == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, updated, table.Entries())
+}
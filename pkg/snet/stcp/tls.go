@@ -0,0 +1,128 @@
+package stcp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// tlsCertValidity is the validity period of generated TLS certificates.
+const tlsCertValidity = 10 * 365 * 24 * time.Hour
+
+// TLSConfig creates a TLS configuration for stcp connections from the visor's key pair.
+//
+// As visor keys (secp256k1) are not supported by TLS, a self-signed certificate is generated with an ephemeral
+// P-256 key. The certificate is bound to the visor by its common name (the visor's public key) and a signature of
+// the certificate's public key by the visor's secret key (stored as the organizational unit).
+// Peers verify this binding instead of relying on certificate authorities.
+func TLSConfig(pk cipher.PubKey, sk cipher.SecKey) (*tls.Config, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	spki, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := cipher.SignPayload(spki, sk)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:         pk.Hex(),
+			OrganizationalUnit: []string{sig.Hex()},
+		},
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(tlsCertValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		ClientAuth:   tls.RequireAnyClientCert,
+		MinVersion:   tls.VersionTLS12,
+		// Certificates are verified against visor public keys by VerifyPeerCertificate.
+		InsecureSkipVerify:    true, // nolint:gosec
+		VerifyPeerCertificate: verifyPeerCertificate(nil),
+	}, nil
+}
+
+// tlsConfigForRemote returns a copy of the TLS configuration which only accepts the certificate of the given remote.
+func tlsConfigForRemote(conf *tls.Config, rPK cipher.PubKey) *tls.Config {
+	conf = conf.Clone()
+	conf.VerifyPeerCertificate = verifyPeerCertificate(&rPK)
+	return conf
+}
+
+// verifyPeerCertificate verifies that the peer's certificate is bound to the visor of the expected public key.
+// If expected is nil, any visor is accepted (the stcp handshake authenticates the remote later on).
+func verifyPeerCertificate(expected *cipher.PubKey) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("no peer certificate")
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+			return fmt.Errorf("invalid certificate signature: %v", err)
+		}
+
+		var pk cipher.PubKey
+		if err := pk.Set(cert.Subject.CommonName); err != nil {
+			return fmt.Errorf("invalid certificate common name: %v", err)
+		}
+		if expected != nil && pk != *expected {
+			return fmt.Errorf("certificate of unexpected visor: %s", pk)
+		}
+		if len(cert.Subject.OrganizationalUnit) != 1 {
+			return errors.New("certificate is not signed by visor")
+		}
+		var sig cipher.Sig
+		if err := sig.UnmarshalText([]byte(cert.Subject.OrganizationalUnit[0])); err != nil {
+			return fmt.Errorf("invalid visor signature: %v", err)
+		}
+		if err := cipher.VerifyPubKeySignedPayload(pk, sig, cert.RawSubjectPublicKeyInfo); err != nil {
+			return fmt.Errorf("invalid visor signature: %v", err)
+		}
+		return nil
+	}
+}
+
+// SetTLS wraps all stcp connections of the client in TLS, using the given configuration (typically from TLSConfig).
+// As TLS connections cannot be distinguished from plain ones, remotes should also enable TLS.
+// This should be called before Serve.
+func (c *Client) SetTLS(conf *tls.Config) {
+	c.mx.Lock()
+	c.tls = conf
+	c.mx.Unlock()
+}
+
+func (c *Client) tlsConfig() *tls.Config {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.tls
+}
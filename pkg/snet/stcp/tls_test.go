@@ -0,0 +1,63 @@
+package stcp
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_TLS(t *testing.T) {
+	newClient := func(t *testing.T, table map[cipher.PubKey]string) (*Client, cipher.PubKey) {
+		pk, sk := cipher.GenerateKeyPair()
+		conf, err := TLSConfig(pk, sk)
+		require.NoError(t, err)
+		c := NewClient(nil, pk, sk, NewTable(table))
+		c.SetTLS(conf)
+		return c, pk
+	}
+
+	b, bPK := newClient(t, nil)
+	require.NoError(t, b.Serve("127.0.0.1:0"))
+	defer func() { require.NoError(t, b.Close()) }()
+	bAddr := b.lTCP.Addr().String()
+
+	lis, err := b.Listen(10)
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _, _ = io.Copy(conn, conn) }() //nolint:errcheck
+		}
+	}()
+
+	t.Run("echo", func(t *testing.T) {
+		a, _ := newClient(t, map[cipher.PubKey]string{bPK: bAddr})
+		defer func() { require.NoError(t, a.Close()) }()
+
+		conn, err := a.Dial(context.TODO(), bPK, 10)
+		require.NoError(t, err)
+		_, err = conn.Write([]byte("ping"))
+		require.NoError(t, err)
+		buf := make([]byte, 4)
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(buf))
+		require.NoError(t, conn.Close())
+	})
+
+	t.Run("unexpected_certificate", func(t *testing.T) {
+		otherPK, _ := cipher.GenerateKeyPair()
+		a, _ := newClient(t, map[cipher.PubKey]string{otherPK: bAddr})
+		defer func() { require.NoError(t, a.Close()) }()
+
+		_, err := a.Dial(context.TODO(), otherPK, 10)
+		require.Error(t, err)
+	})
+}
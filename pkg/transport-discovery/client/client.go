@@ -10,6 +10,8 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
@@ -21,6 +23,9 @@ import (
 
 var log = logging.MustGetLogger("transport-discovery")
 
+// totalCountHeader holds the total number of entries matching a query.
+const totalCountHeader = "X-Total-Count"
+
 // Error is the object returned to the client when there's an error.
 type Error struct {
 	Error string `json:"error"`
@@ -160,6 +165,59 @@ func (c *apiClient) GetTransportsByEdge(ctx context.Context, pk cipher.PubKey) (
 	return entries, nil
 }
 
+// QueryTransportsByEdge returns a page of the Transports registered for the edge which match the query,
+// along with the total number of matching Transports.
+// Filters are sent as query parameters ('type', 'since', 'offset' and 'limit') and the total is read
+// from the 'X-Total-Count' header. Servers which do not support these are handled by filtering locally.
+func (c *apiClient) QueryTransportsByEdge(ctx context.Context, pk cipher.PubKey, q transport.EdgeQuery) ([]*transport.EntryWithStatus, int, error) {
+	v := url.Values{}
+	if q.Type != "" {
+		v.Set("type", q.Type)
+	}
+	if !q.SeenSince.IsZero() {
+		v.Set("since", strconv.FormatInt(q.SeenSince.Unix(), 10))
+	}
+	if q.Offset > 0 {
+		v.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	path := fmt.Sprintf("/transports/edge:%s", pk)
+	if len(v) > 0 {
+		path += "?" + v.Encode()
+	}
+
+	resp, err := c.Get(ctx, path)
+	if resp != nil {
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				log.WithError(err).Warn("Failed to close HTTP response body")
+			}
+		}()
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("status: %d, error: %v", resp.StatusCode, extractError(resp.Body))
+	}
+
+	var entries []*transport.EntryWithStatus
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, 0, fmt.Errorf("json: %s", err)
+	}
+
+	total, err := strconv.Atoi(resp.Header.Get(totalCountHeader))
+	if err != nil {
+		// Server does not support queries.
+		entries, total = q.Apply(entries)
+	}
+
+	return entries, total, nil
+}
+
 // DeleteTransport deletes given transport by it's ID. A visor can only delete transports if he is one of it's edges.
 func (c *apiClient) DeleteTransport(ctx context.Context, id uuid.UUID) error {
 	resp, err := c.Delete(ctx, fmt.Sprintf("/transports/id:%s", id.String()))
@@ -210,6 +268,52 @@ func (c *apiClient) UpdateStatuses(ctx context.Context, statuses ...*transport.S
 	return entries, nil
 }
 
+// UpdateEndpoints publishes the addresses which the stcp listener of the visor may be reached at.
+func (c *apiClient) UpdateEndpoints(ctx context.Context, endpoints *transport.SignedEndpoints) error {
+	resp, err := c.Post(ctx, "/endpoints", endpoints)
+	if resp != nil {
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				log.WithError(err).Warn("Failed to close HTTP response body")
+			}
+		}()
+	}
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("status: %d, error: %v", resp.StatusCode, extractError(resp.Body))
+	}
+
+	return nil
+}
+
+// UploadStats uploads signed bandwidth and uptime statistics of transports.
+func (c *apiClient) UploadStats(ctx context.Context, stats ...*transport.SignedStats) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	resp, err := c.Post(ctx, "/stats", stats)
+	if resp != nil {
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				log.WithError(err).Warn("Failed to close HTTP response body")
+			}
+		}()
+	}
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("status: %d, error: %v", resp.StatusCode, extractError(resp.Body))
+	}
+
+	return nil
+}
+
 // extractError returns the decoded error message from Body.
 func extractError(r io.Reader) error {
 	var apiError Error
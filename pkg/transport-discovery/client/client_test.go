@@ -218,6 +218,47 @@ func TestGetTransportsByEdge(t *testing.T) {
 	assert.True(t, entries[0].IsUp)
 }
 
+func TestQueryTransportsByEdge(t *testing.T) {
+	dmsgEntry := &transport.EntryWithStatus{Entry: newTestEntry(), IsUp: true}
+	stcpEntry := &transport.EntryWithStatus{Entry: newTestEntry(), IsUp: true}
+	stcpEntry.Entry.Type = "stcp"
+	query := transport.EdgeQuery{Type: "stcp", Offset: 1, Limit: 10}
+
+	t.Run("server_supports_queries", func(t *testing.T) {
+		srv := httptest.NewServer(authHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, fmt.Sprintf("/transports/edge:%s", testPubKey), r.URL.Path)
+			assert.Equal(t, "stcp", r.URL.Query().Get("type"))
+			assert.Equal(t, "1", r.URL.Query().Get("offset"))
+			assert.Equal(t, "10", r.URL.Query().Get("limit"))
+			w.Header().Set("X-Total-Count", "2")
+			require.NoError(t, json.NewEncoder(w).Encode([]*transport.EntryWithStatus{stcpEntry}))
+		})))
+		defer srv.Close()
+
+		c, err := NewHTTP(srv.URL, testPubKey, testSecKey)
+		require.NoError(t, err)
+		entries, total, err := c.QueryTransportsByEdge(context.Background(), testPubKey, query)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, 2, total)
+	})
+
+	t.Run("legacy_server", func(t *testing.T) {
+		srv := httptest.NewServer(authHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewEncoder(w).Encode([]*transport.EntryWithStatus{dmsgEntry, stcpEntry}))
+		})))
+		defer srv.Close()
+
+		c, err := NewHTTP(srv.URL, testPubKey, testSecKey)
+		require.NoError(t, err)
+		entries, total, err := c.QueryTransportsByEdge(context.Background(), testPubKey, transport.EdgeQuery{Type: "stcp"})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, stcpEntry.Entry, entries[0].Entry)
+	})
+}
+
 func TestUpdateStatuses(t *testing.T) {
 	entry := &transport.EntryWithStatus{Entry: newTestEntry(), IsUp: true}
 	srv := httptest.NewServer(authHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -239,6 +280,29 @@ func TestUpdateStatuses(t *testing.T) {
 	assert.Equal(t, entry.Entry, entries[0].Entry)
 }
 
+func TestUpdateEndpoints(t *testing.T) {
+	endpoints, err := transport.NewSignedEndpoints(transport.Endpoints{
+		Edge:      testPubKey,
+		Addresses: []string{"203.0.113.1:7777"},
+		Timestamp: 1,
+	}, testSecKey)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(authHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/endpoints", r.URL.String())
+		var se transport.SignedEndpoints
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&se))
+		assert.NoError(t, se.Verify())
+		assert.Equal(t, endpoints.Addresses, se.Addresses)
+		w.WriteHeader(http.StatusCreated)
+	})))
+	defer srv.Close()
+
+	c, err := NewHTTP(srv.URL, testPubKey, testSecKey)
+	require.NoError(t, err)
+	require.NoError(t, c.UpdateEndpoints(context.Background(), endpoints))
+}
+
 func authHandler(t *testing.T, next http.Handler) http.Handler {
 	m := http.NewServeMux()
 	m.Handle("/security/nonces/", http.HandlerFunc(
@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+)
+
+// RateWindows are the windows of time over which the bandwidth rates of transports are averaged.
+var RateWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// ErrNoBandwidthStats occurs when a transport is neither managed nor recorded in the log store.
+var ErrNoBandwidthStats = errors.New("transport has no bandwidth statistics")
+
+// BandwidthRate is the average bandwidth of a transport in bytes per second over a window of time.
+type BandwidthRate struct {
+	Window   time.Duration `json:"window"`
+	SentRate float64       `json:"sent_rate"`
+	RecvRate float64       `json:"recv_rate"`
+}
+
+// BandwidthStats are the bytes sent and received over a transport, with the recent rates and the number of times its
+// underlying connection was re-established.
+type BandwidthStats struct {
+	ID         uuid.UUID       `json:"t_id"`
+	Remote     cipher.PubKey   `json:"remote_pk,omitempty"` // unknown unless managed.
+	Type       string          `json:"type,omitempty"`      // unknown unless managed.
+	SentBytes  uint64          `json:"sent"`
+	RecvBytes  uint64          `json:"recv"`
+	Rates      []BandwidthRate `json:"rates,omitempty"` // for each of RateWindows, only if managed.
+	Reconnects uint32          `json:"reconnects"`
+	Managed    bool            `json:"managed"` // false if the statistics are only recorded in the log store.
+}
+
+// rateSample are the bytes sent and received over a transport at a time.
+type rateSample struct {
+	at         time.Time
+	sent, recv uint64
+}
+
+// rateHistory holds the samples of the bytes of a transport within the longest of RateWindows.
+type rateHistory struct {
+	samples []rateSample // oldest first.
+	mx      sync.Mutex
+}
+
+// add adds the sample, dropping the samples which are no longer needed to average over the longest window.
+func (h *rateHistory) add(s rateSample) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.samples = append(h.samples, s)
+	maxWindow := RateWindows[len(RateWindows)-1]
+	drop := 0
+	for drop+1 < len(h.samples) && s.at.Sub(h.samples[drop+1].at) >= maxWindow {
+		drop++
+	}
+	if drop > 0 {
+		h.samples = append(h.samples[:0], h.samples[drop:]...)
+	}
+}
+
+// rates averages the bandwidth up to the current sample over each of RateWindows. Windows which are longer than the
+// history are averaged over the history.
+func (h *rateHistory) rates(now rateSample) []BandwidthRate {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	rates := make([]BandwidthRate, len(RateWindows))
+	for i, window := range RateWindows {
+		rates[i].Window = window
+		for _, s := range h.samples {
+			if now.at.Sub(s.at) > window {
+				continue
+			}
+			if secs := now.at.Sub(s.at).Seconds(); secs > 0 && now.sent >= s.sent && now.recv >= s.recv {
+				rates[i].SentRate = float64(now.sent-s.sent) / secs
+				rates[i].RecvRate = float64(now.recv-s.recv) / secs
+			}
+			break
+		}
+	}
+	return rates
+}
+
+// sampleRates records the current bytes of the transport for its bandwidth rates.
+func (mt *ManagedTransport) sampleRates(now time.Time) {
+	mt.rates.add(mt.rateSample(now))
+}
+
+func (mt *ManagedTransport) rateSample(now time.Time) rateSample {
+	return rateSample{
+		at:   now,
+		sent: atomic.LoadUint64(&mt.LogEntry.SentBytes),
+		recv: atomic.LoadUint64(&mt.LogEntry.RecvBytes),
+	}
+}
+
+// BandwidthStats returns the bandwidth statistics of the transport.
+func (mt *ManagedTransport) BandwidthStats() BandwidthStats {
+	now := mt.rateSample(time.Now())
+	mt.connMx.Lock()
+	reconnects := mt.reconnects
+	mt.connMx.Unlock()
+	return BandwidthStats{
+		ID:         mt.Entry.ID,
+		Remote:     mt.rPK,
+		Type:       mt.netName,
+		SentBytes:  now.sent,
+		RecvBytes:  now.recv,
+		Rates:      mt.rates.rates(now),
+		Reconnects: reconnects,
+		Managed:    true,
+	}
+}
+
+// BandwidthStats returns the bandwidth statistics of the transport of the ID. Transports which are no longer managed
+// have the totals recorded in the log store.
+func (tm *Manager) BandwidthStats(id uuid.UUID) (*BandwidthStats, error) {
+	if tp := tm.Transport(id); tp != nil {
+		stats := tp.BandwidthStats()
+		return &stats, nil
+	}
+	if tm.conf.LogStore == nil {
+		return nil, ErrNoBandwidthStats
+	}
+	entry, err := tm.conf.LogStore.Entry(id)
+	if err != nil || entry == nil {
+		return nil, ErrNoBandwidthStats
+	}
+	return &BandwidthStats{
+		ID:        id,
+		SentBytes: atomic.LoadUint64(&entry.SentBytes),
+		RecvBytes: atomic.LoadUint64(&entry.RecvBytes),
+	}, nil
+}
+
+// AllBandwidthStats returns the bandwidth statistics of the managed transports, sorted by ID.
+func (tm *Manager) AllBandwidthStats() []BandwidthStats {
+	var stats []BandwidthStats
+	tm.WalkTransports(func(tp *ManagedTransport) bool {
+		stats = append(stats, tp.BandwidthStats())
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ID.String() < stats[j].ID.String() })
+	return stats
+}
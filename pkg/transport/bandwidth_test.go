@@ -0,0 +1,42 @@
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateHistory(t *testing.T) {
+	start := time.Now()
+	sample := func(secs int, sent, recv uint64) rateSample {
+		return rateSample{at: start.Add(time.Duration(secs) * time.Second), sent: sent, recv: recv}
+	}
+
+	var h rateHistory
+	for _, r := range h.rates(sample(0, 0, 0)) {
+		assert.Zero(t, r.SentRate)
+		assert.Zero(t, r.RecvRate)
+	}
+
+	// 1000 bytes/s sent for 20 minutes, 100 bytes/s received for the last 5 minutes.
+	for secs := 0; secs <= 20*60; secs += 3 {
+		recv := uint64(0)
+		if secs > 15*60 {
+			recv = uint64(secs-15*60) * 100
+		}
+		h.add(sample(secs, uint64(secs)*1000, recv))
+	}
+	assert.True(t, h.samples[0].at.After(start.Add(4*time.Minute)), "samples beyond the longest window are dropped")
+
+	rates := h.rates(sample(20*60+3, (20*60+3)*1000, (5*60+3)*100))
+	require.Len(t, rates, len(RateWindows))
+	for i, r := range rates {
+		assert.Equal(t, RateWindows[i], r.Window)
+		assert.InDelta(t, 1000, r.SentRate, 0.1)
+	}
+	assert.InDelta(t, 100, rates[0].RecvRate, 0.1)
+	assert.InDelta(t, 100, rates[1].RecvRate, 0.1)
+	assert.InDelta(t, 100.0/3, rates[2].RecvRate, 0.5)
+}
@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
+)
+
+// Cipher suites which may be negotiated to encrypt transports.
+const (
+	// CipherSuiteNone does not encrypt the transport. This is suitable for networks which are already encrypted (such as dmsg).
+	CipherSuiteNone = "none"
+	// CipherSuiteNoiseKK encrypts the transport with the Noise KK pattern, secp256k1, ChaChaPoly and SHA256.
+	CipherSuiteNoiseKK = "Noise_KK_secp256k1_ChaChaPoly_SHA256"
+)
+
+// noiseOverhead is the number of bytes added to each noise frame (sequence number and AEAD tag).
+const noiseOverhead = 4 + 16
+
+// maxNoiseMTU is the largest MTU of a noise-encrypted transport, as noise frames are limited to math.MaxUint16 bytes.
+const maxNoiseMTU = math.MaxUint16 - noiseOverhead - routing.PacketHeaderSize
+
+// SupportedCipherSuites returns all cipher suites supported by this implementation.
+func SupportedCipherSuites() []string {
+	return []string{CipherSuiteNoiseKK, CipherSuiteNone}
+}
+
+// DefaultCipherSuites returns the default cipher suites of the given network, in order of preference.
+// stcp transports are always encrypted, so they are not established with visors which do not support negotiation.
+func DefaultCipherSuites(network string) []string {
+	if network == snet.STcpType {
+		return []string{CipherSuiteNoiseKK}
+	}
+	return []string{CipherSuiteNone, CipherSuiteNoiseKK}
+}
+
+// ValidateCipherSuites returns an error if any of the given cipher suites is not supported.
+func ValidateCipherSuites(suites []string) error {
+	for _, s := range suites {
+		if !containsCipherSuite(SupportedCipherSuites(), s) {
+			return fmt.Errorf("unsupported cipher suite '%s'", s)
+		}
+	}
+	return nil
+}
+
+func containsCipherSuite(suites []string, suite string) bool {
+	for _, s := range suites {
+		if s == suite {
+			return true
+		}
+	}
+	return false
+}
+
+// selectCipherSuite returns the first of the remote's suites (in the remote's order of preference) which is also supported locally.
+func selectCipherSuite(local, remote []string) (string, bool) {
+	for _, s := range remote {
+		if containsCipherSuite(local, s) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// requiresEncryption returns true if the suites prefer CipherSuiteNoiseKK over CipherSuiteNone.
+// CipherSuiteNone is then never accepted from a responder, as an on-path attacker could have downgraded the negotiation.
+func requiresEncryption(suites []string) bool {
+	for _, s := range suites {
+		switch s {
+		case CipherSuiteNoiseKK:
+			return true
+		case CipherSuiteNone:
+			return false
+		}
+	}
+	return false
+}
+
+// capMTU caps the MTU to what can be carried by the given cipher suite.
+func capMTU(mtu uint16, suite string) uint16 {
+	if suite == CipherSuiteNoiseKK && mtu > maxNoiseMTU {
+		return maxNoiseMTU
+	}
+	return mtu
+}
+
+// wrapCipherSuite wraps the connection with the given cipher suite.
+// The prologue binds the settlement negotiation to the noise handshake, so that it fails if the negotiation was tampered with.
+func wrapCipherSuite(conn *snet.Conn, suite string, sk cipher.SecKey, init bool, prologue []byte) (*snet.Conn, error) {
+	switch suite {
+	case CipherSuiteNone:
+		return conn, nil
+	case CipherSuiteNoiseKK:
+		nConn, err := wrapNoiseKK(conn, conn.LocalPK(), sk, conn.RemotePK(), init, prologue)
+		if err != nil {
+			return nil, fmt.Errorf("noise handshake failed: %v", err)
+		}
+		return conn.WithConn(nConn), nil
+	default:
+		return nil, fmt.Errorf("unsupported cipher suite '%s'", suite)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -16,18 +17,69 @@ type DiscoveryClient interface {
 	RegisterTransports(ctx context.Context, entries ...*SignedEntry) error
 	GetTransportByID(ctx context.Context, id uuid.UUID) (*EntryWithStatus, error)
 	GetTransportsByEdge(ctx context.Context, pk cipher.PubKey) ([]*EntryWithStatus, error)
+	QueryTransportsByEdge(ctx context.Context, pk cipher.PubKey, q EdgeQuery) ([]*EntryWithStatus, int, error)
 	DeleteTransport(ctx context.Context, id uuid.UUID) error
 	UpdateStatuses(ctx context.Context, statuses ...*Status) ([]*EntryWithStatus, error)
+	UpdateEndpoints(ctx context.Context, endpoints *SignedEndpoints) error
+	UploadStats(ctx context.Context, stats ...*SignedStats) error
+}
+
+// EdgeQuery filters and paginates the transports of an edge obtained from transport discovery.
+type EdgeQuery struct {
+	Type      string    // only return transports of this type (all types if empty).
+	SeenSince time.Time // only return transports seen at or after this time (no limit if zero).
+	Offset    int       // number of matching entries to skip.
+	Limit     int       // maximum number of entries to return (no limit if 0).
+}
+
+// Match returns true if the entry passes the query's filters.
+func (q EdgeQuery) Match(e *EntryWithStatus) bool {
+	if q.Type != "" && e.Entry.Type != q.Type {
+		return false
+	}
+	if !q.SeenSince.IsZero() && e.LastSeen().Before(q.SeenSince.Truncate(time.Second)) {
+		return false
+	}
+	return true
+}
+
+// Apply filters and paginates the given entries.
+// It returns the requested page (ordered by transport ID) and the total number of matching entries.
+func (q EdgeQuery) Apply(entries []*EntryWithStatus) ([]*EntryWithStatus, int) {
+	res := make([]*EntryWithStatus, 0, len(entries))
+	for _, e := range entries {
+		if q.Match(e) {
+			res = append(res, e)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Entry.ID.String() < res[j].Entry.ID.String()
+	})
+	total := len(res)
+	if q.Offset > 0 {
+		if q.Offset >= len(res) {
+			return []*EntryWithStatus{}, total
+		}
+		res = res[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(res) {
+		res = res[:q.Limit]
+	}
+	return res, total
 }
 
 type mockDiscoveryClient struct {
 	sync.Mutex
-	entries map[uuid.UUID]EntryWithStatus
+	entries   map[uuid.UUID]EntryWithStatus
+	endpoints map[cipher.PubKey]SignedEndpoints
 }
 
 // NewDiscoveryMock construct a new mock transport discovery client.
 func NewDiscoveryMock() DiscoveryClient {
-	return &mockDiscoveryClient{entries: map[uuid.UUID]EntryWithStatus{}}
+	return &mockDiscoveryClient{
+		entries:   map[uuid.UUID]EntryWithStatus{},
+		endpoints: map[cipher.PubKey]SignedEndpoints{},
+	}
 }
 
 func (td *mockDiscoveryClient) RegisterTransports(ctx context.Context, entries ...*SignedEntry) error {
@@ -60,6 +112,7 @@ func (td *mockDiscoveryClient) GetTransportByID(ctx context.Context, id uuid.UUI
 		Entry:      entry.Entry,
 		IsUp:       entry.IsUp,
 		Registered: entry.Registered,
+		Updated:    entry.Updated,
 		Statuses:   entry.Statuses,
 	}, nil
 }
@@ -83,6 +136,15 @@ func (td *mockDiscoveryClient) GetTransportsByEdge(ctx context.Context, pk ciphe
 	return res, nil
 }
 
+func (td *mockDiscoveryClient) QueryTransportsByEdge(ctx context.Context, pk cipher.PubKey, q EdgeQuery) ([]*EntryWithStatus, int, error) {
+	entries, err := td.GetTransportsByEdge(ctx, pk)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries, total := q.Apply(entries)
+	return entries, total, nil
+}
+
 // NOTE that mock implementation doesn't checks whether the transport to be deleted is valid or not, this is, that
 // it can be deleted by the visor who called DeleteTransport
 func (td *mockDiscoveryClient) DeleteTransport(ctx context.Context, id uuid.UUID) error {
@@ -108,9 +170,31 @@ func (td *mockDiscoveryClient) UpdateStatuses(ctx context.Context, statuses ...*
 
 		td.Lock()
 		entry.IsUp = status.IsUp
+		entry.Updated = time.Now().Unix()
 		td.entries[status.ID] = *entry
 		td.Unlock()
 	}
 
 	return res, nil
 }
+
+func (td *mockDiscoveryClient) UpdateEndpoints(ctx context.Context, endpoints *SignedEndpoints) error {
+	if err := endpoints.Verify(); err != nil {
+		return err
+	}
+
+	td.Lock()
+	td.endpoints[endpoints.Edge] = *endpoints
+	td.Unlock()
+	return nil
+}
+
+// NOTE that mock implementation only verifies the uploaded stats, and discards them.
+func (td *mockDiscoveryClient) UploadStats(ctx context.Context, stats ...*SignedStats) error {
+	for _, s := range stats {
+		if err := s.Verify(); err != nil {
+			return fmt.Errorf("invalid stats of transport %s: %v", s.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+const (
+	// endpointsPollInterval is the interval at which the external stcp address is checked for changes.
+	endpointsPollInterval = 30 * time.Second
+
+	// endpointsPublishTimeout is the timeout of a single publish of stcp endpoints.
+	endpointsPublishTimeout = 30 * time.Second
+)
+
+// Endpoints are the addresses which the stcp listener of a visor may be reached at.
+type Endpoints struct {
+	Edge      cipher.PubKey `json:"edge"`
+	Addresses []string      `json:"addresses"`
+	Timestamp int64         `json:"timestamp"`
+}
+
+// ToBinary returns the binary representation of Endpoints which is signed.
+func (e *Endpoints) ToBinary() []byte {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(e.Timestamp))
+
+	b := make([]byte, 0, len(e.Edge)+len(ts))
+	b = append(b, e.Edge[:]...)
+	b = append(b, ts[:]...)
+	for _, addr := range e.Addresses {
+		b = append(b, '\n')
+		b = append(b, addr...)
+	}
+	return b
+}
+
+// SignedEndpoints are Endpoints signed by the edge.
+type SignedEndpoints struct {
+	Endpoints
+	Sig cipher.Sig `json:"sig"`
+}
+
+// NewSignedEndpoints signs the endpoints with the edge's secret key.
+func NewSignedEndpoints(e Endpoints, sk cipher.SecKey) (*SignedEndpoints, error) {
+	sig, err := cipher.SignPayload(e.ToBinary(), sk)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedEndpoints{Endpoints: e, Sig: sig}, nil
+}
+
+// Verify verifies the signature of the edge.
+func (se *SignedEndpoints) Verify() error {
+	if se.Sig.Null() {
+		return errors.New("endpoints are not signed")
+	}
+	return cipher.VerifyPubKeySignedPayload(se.Edge, se.Sig, se.ToBinary())
+}
+
+// publishEndpoints publishes the stcp endpoints to transport discovery whenever they change,
+// until the context is canceled or the Manager is closed.
+func (tm *Manager) publishEndpoints(ctx context.Context) {
+	ticker := time.NewTicker(endpointsPollInterval)
+	defer ticker.Stop()
+
+	var published string
+	for {
+		if addrs := tm.n.STCPEndpoints(); len(addrs) > 0 && strings.Join(addrs, " ") != published {
+			if err := tm.uploadEndpoints(ctx, addrs); err != nil {
+				tm.Logger.WithError(err).Warn("Failed to publish stcp endpoints")
+			} else {
+				published = strings.Join(addrs, " ")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tm.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (tm *Manager) uploadEndpoints(ctx context.Context, addrs []string) error {
+	se, err := NewSignedEndpoints(Endpoints{
+		Edge:      tm.n.LocalPK(),
+		Addresses: addrs,
+		Timestamp: time.Now().Unix(),
+	}, tm.n.LocalSK())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, endpointsPublishTimeout)
+	defer cancel()
+	return tm.conf.DiscoveryClient.UpdateEndpoints(ctx, se)
+}
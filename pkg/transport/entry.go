@@ -3,6 +3,7 @@ package transport
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/google/uuid"
@@ -162,9 +163,18 @@ type EntryWithStatus struct {
 	Entry      *Entry  `json:"entry"`
 	IsUp       bool    `json:"is_up"`
 	Registered int64   `json:"registered"`
+	Updated    int64   `json:"updated,omitempty"` // last time the status was updated (last seen).
 	Statuses   [2]bool `json:"statuses"`
 }
 
+// LastSeen returns the last time the transport was reported on.
+func (e *EntryWithStatus) LastSeen() time.Time {
+	if e.Updated != 0 {
+		return time.Unix(e.Updated, 0)
+	}
+	return time.Unix(e.Registered, 0)
+}
+
 // String implements stringer
 func (e *EntryWithStatus) String() string {
 	res := "entry:\n"
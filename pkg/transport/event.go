@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+)
+
+// EventType represents the type of a transport event.
+type EventType string
+
+// Transport event types.
+const (
+	// EventEstablished occurs when an underlying connection of a transport is established.
+	EventEstablished EventType = "established"
+	// EventClosed occurs when an underlying connection of a transport is closed.
+	EventClosed EventType = "closed"
+	// EventReconnecting occurs when a transport attempts to redial it's underlying connection.
+	EventReconnecting EventType = "reconnecting"
+	// EventHandshakeFailed occurs when the settlement handshake of a transport fails.
+	EventHandshakeFailed EventType = "handshake_failed"
+)
+
+// ObserverBufferSize is the capacity of the channels returned by Manager.Observe.
+// Events are dropped for observers that fail to keep up.
+const ObserverBufferSize = 64
+
+// Event is a structured notification about a change in the state of a transport.
+type Event struct {
+	Type     EventType     `json:"type"`
+	TpID     uuid.UUID     `json:"tp_id"`
+	RemotePK cipher.PubKey `json:"remote_pk"`
+	TpType   string        `json:"tp_type"`
+	Reason   string        `json:"reason,omitempty"`
+	Time     time.Time     `json:"time"`
+}
+
+// eventHub fans out events to observers.
+type eventHub struct {
+	obs    map[chan Event]struct{}
+	closed bool
+	mx     sync.Mutex
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{obs: make(map[chan Event]struct{})}
+}
+
+func (h *eventHub) observe() (<-chan Event, func()) {
+	ch := make(chan Event, ObserverBufferSize)
+
+	h.mx.Lock()
+	if h.closed {
+		close(ch)
+	} else {
+		h.obs[ch] = struct{}{}
+	}
+	h.mx.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mx.Lock()
+			if _, ok := h.obs[ch]; ok {
+				delete(h.obs, ch)
+				close(ch)
+			}
+			h.mx.Unlock()
+		})
+	}
+}
+
+func (h *eventHub) publish(e Event) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	for ch := range h.obs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func (h *eventHub) close() {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.closed = true
+	for ch := range h.obs {
+		delete(h.obs, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,300 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/fec"
+)
+
+// FECConfig configures forward error correction of transports, which repairs writes lost by lossy networks.
+// Every DataShards writes to the underlying connection are followed by ParityShards parity writes, so that up to
+// ParityShards lost writes of each group can be reconstructed. FEC is disabled if either is zero.
+// As parity is only sent once a group is complete, lost writes of an incomplete group are repaired once more is written.
+type FECConfig struct {
+	DataShards   uint8 `json:"data_shards"`
+	ParityShards uint8 `json:"parity_shards"`
+}
+
+// Enabled returns whether forward error correction is enabled.
+func (c FECConfig) Enabled() bool {
+	return c.DataShards > 0 && c.ParityShards > 0
+}
+
+// Validate returns an error if the config is enabled, but the number of shards is not supported.
+func (c FECConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	_, err := fec.New(int(c.DataShards), int(c.ParityShards))
+	return err
+}
+
+func (c FECConfig) encode() []byte {
+	return []byte{c.DataShards, c.ParityShards}
+}
+
+func readFECConfig(r io.Reader) (FECConfig, error) {
+	b := make([]byte, 2)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return FECConfig{}, fmt.Errorf("failed to read fec config: %v", err)
+	}
+	return FECConfig{DataShards: b[0], ParityShards: b[1]}, nil
+}
+
+// negotiateFEC returns the parameters of the initiator, if both edges enable forward error correction.
+func negotiateFEC(local FECConfig, remote *FECConfig) FECConfig {
+	if remote == nil || !local.Enabled() || !remote.Enabled() {
+		return FECConfig{}
+	}
+	return *remote
+}
+
+// fecCounters count the shards lost by the network, which are accumulated over the underlying connections of a transport.
+type fecCounters struct {
+	repaired uint64 // data shards which were lost, but reconstructed from parity.
+	lost     uint64 // data shards which were lost and could not be reconstructed.
+}
+
+const (
+	fecHeaderSize   = 4 + 1 + 4 // group, index and body size.
+	fecLenSize      = 4         // prefix of data shards, holding the size of the written data.
+	fecMaxShardSize = 1 << 20
+)
+
+// fecConn adds forward error correction to a connection which may lose whole writes, but does not reorder them.
+// Each write is sent as one data shard, in a frame of its own (group, index, size and body).
+// Data shards are delivered as soon as they are received in order. Missing data shards are reconstructed once
+// enough shards of the group are received, or are skipped (and counted as lost) once a later group is received.
+type fecConn struct {
+	net.Conn
+	codec    *fec.Codec
+	counters *fecCounters
+
+	wMx     sync.Mutex
+	wGroup  uint32
+	wShards [][]byte // data shards of the current group which were written.
+
+	rMx     sync.Mutex
+	rBuf    bytes.Buffer
+	rGroup  uint32
+	rShards [][]byte // shards of the current group which were received (nil if missing).
+	rNext   int      // index of the next data shard to deliver.
+}
+
+func newFECConn(conn net.Conn, conf FECConfig, counters *fecCounters) (*fecConn, error) {
+	codec, err := fec.New(int(conf.DataShards), int(conf.ParityShards))
+	if err != nil {
+		return nil, err
+	}
+	if counters == nil {
+		counters = new(fecCounters)
+	}
+	return &fecConn{
+		Conn:     conn,
+		codec:    codec,
+		counters: counters,
+		rShards:  make([][]byte, codec.DataShards()+codec.ParityShards()),
+	}, nil
+}
+
+// Write sends p as a single data shard, followed by the parity shards if p completes a group.
+func (fc *fecConn) Write(p []byte) (int, error) {
+	if len(p)+fecLenSize > fecMaxShardSize {
+		return 0, fmt.Errorf("write of %d bytes exceeds fec shard size", len(p))
+	}
+
+	fc.wMx.Lock()
+	defer fc.wMx.Unlock()
+
+	shard := make([]byte, fecLenSize+len(p))
+	binary.BigEndian.PutUint32(shard, uint32(len(p)))
+	copy(shard[fecLenSize:], p)
+	if err := fc.writeShard(len(fc.wShards), shard); err != nil {
+		return 0, err
+	}
+	fc.wShards = append(fc.wShards, shard)
+	if len(fc.wShards) < fc.codec.DataShards() {
+		return len(p), nil
+	}
+
+	shards := padShards(fc.wShards, fc.codec.DataShards()+fc.codec.ParityShards())
+	if err := fc.codec.Encode(shards); err != nil {
+		return 0, err
+	}
+	for i := fc.codec.DataShards(); i < len(shards); i++ {
+		if err := fc.writeShard(i, shards[i]); err != nil {
+			return 0, err
+		}
+	}
+	fc.wGroup++
+	fc.wShards = fc.wShards[:0]
+	return len(p), nil
+}
+
+func (fc *fecConn) writeShard(index int, body []byte) error {
+	frame := make([]byte, fecHeaderSize, fecHeaderSize+len(body))
+	binary.BigEndian.PutUint32(frame[0:], fc.wGroup)
+	frame[4] = byte(index)
+	binary.BigEndian.PutUint32(frame[5:], uint32(len(body)))
+	_, err := fc.Conn.Write(append(frame, body...))
+	return err
+}
+
+// padShards pads the data shards with zeros to the size of the largest one, and allocates the parity shards.
+func padShards(data [][]byte, total int) [][]byte {
+	var size int
+	for _, s := range data {
+		if len(s) > size {
+			size = len(s)
+		}
+	}
+	shards := make([][]byte, total)
+	for i := range shards {
+		shards[i] = make([]byte, size)
+		if i < len(data) {
+			copy(shards[i], data[i])
+		}
+	}
+	return shards
+}
+
+// Read reads the data of delivered data shards.
+func (fc *fecConn) Read(p []byte) (int, error) {
+	fc.rMx.Lock()
+	defer fc.rMx.Unlock()
+
+	for fc.rBuf.Len() == 0 {
+		if err := fc.readShard(); err != nil {
+			return 0, err
+		}
+	}
+	return fc.rBuf.Read(p)
+}
+
+func (fc *fecConn) readShard() error {
+	h := make([]byte, fecHeaderSize)
+	if _, err := io.ReadFull(fc.Conn, h); err != nil {
+		return err
+	}
+	group, index, size := binary.BigEndian.Uint32(h[0:]), int(h[4]), binary.BigEndian.Uint32(h[5:])
+	if index >= len(fc.rShards) || size > fecMaxShardSize {
+		return errors.New("invalid fec frame")
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(fc.Conn, body); err != nil {
+		return err
+	}
+
+	switch {
+	case group < fc.rGroup:
+		return nil // parity of a delivered group.
+	case group > fc.rGroup:
+		if err := fc.finishGroup(); err != nil {
+			return err
+		}
+		lostGroups := uint64(group - fc.rGroup)
+		atomic.AddUint64(&fc.counters.lost, lostGroups*uint64(fc.codec.DataShards()))
+		fc.rGroup = group
+	}
+
+	fc.rShards[index] = body
+	if fc.rShards[fc.rNext] == nil {
+		if err := fc.reconstruct(); err != nil {
+			return err
+		}
+	}
+	return fc.deliver()
+}
+
+// reconstruct reconstructs the missing shards of the current group, if enough shards were received.
+func (fc *fecConn) reconstruct() error {
+	var received, size int
+	for i, s := range fc.rShards {
+		if s == nil {
+			continue
+		}
+		received++
+		if i >= fc.codec.DataShards() {
+			size = len(s)
+		}
+	}
+	if received < fc.codec.DataShards() || size == 0 {
+		return nil
+	}
+
+	shards := make([][]byte, len(fc.rShards))
+	for i, s := range fc.rShards {
+		switch {
+		case s == nil:
+		case len(s) > size:
+			return errors.New("fec shard is larger than parity")
+		default:
+			shards[i] = make([]byte, size)
+			copy(shards[i], s)
+		}
+	}
+	if err := fc.codec.Reconstruct(shards); err != nil {
+		return err
+	}
+	for i := fc.rNext; i < fc.codec.DataShards(); i++ {
+		if fc.rShards[i] == nil {
+			atomic.AddUint64(&fc.counters.repaired, 1)
+		}
+	}
+	copy(fc.rShards, shards)
+	return nil
+}
+
+// deliver writes the data of the data shards which are next in order to the read buffer.
+// The next group is started once all data shards of the current group are delivered.
+func (fc *fecConn) deliver() error {
+	for fc.rNext < fc.codec.DataShards() && fc.rShards[fc.rNext] != nil {
+		if err := fc.deliverShard(fc.rShards[fc.rNext]); err != nil {
+			return err
+		}
+		fc.rNext++
+	}
+	if fc.rNext == fc.codec.DataShards() {
+		fc.nextGroup()
+	}
+	return nil
+}
+
+// finishGroup delivers the remaining data shards of the current group, and counts the ones missing as lost.
+func (fc *fecConn) finishGroup() error {
+	for ; fc.rNext < fc.codec.DataShards(); fc.rNext++ {
+		s := fc.rShards[fc.rNext]
+		if s == nil {
+			atomic.AddUint64(&fc.counters.lost, 1)
+			continue
+		}
+		if err := fc.deliverShard(s); err != nil {
+			return err
+		}
+	}
+	fc.nextGroup()
+	return nil
+}
+
+func (fc *fecConn) nextGroup() {
+	fc.rGroup++
+	fc.rNext = 0
+	for i := range fc.rShards {
+		fc.rShards[i] = nil
+	}
+}
+
+func (fc *fecConn) deliverShard(s []byte) error {
+	if len(s) < fecLenSize || int(binary.BigEndian.Uint32(s)) > len(s)-fecLenSize {
+		return errors.New("invalid fec data shard")
+	}
+	fc.rBuf.Write(s[fecLenSize : fecLenSize+binary.BigEndian.Uint32(s)])
+	return nil
+}
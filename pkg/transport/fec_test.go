@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lossyConn drops the writes of the given indexes.
+type lossyConn struct {
+	net.Conn
+	drop   map[int]bool
+	writes int
+}
+
+func (c *lossyConn) Write(b []byte) (int, error) {
+	i := c.writes
+	c.writes++
+	if c.drop[i] {
+		return len(b), nil
+	}
+	return c.Conn.Write(b)
+}
+
+func TestFECConn(t *testing.T) {
+	conf := FECConfig{DataShards: 4, ParityShards: 2}
+	const frames = 6 // writes per group: data shards, then parity shards.
+
+	c0, c1 := net.Pipe()
+	defer func() {
+		assert.NoError(t, c0.Close())
+		assert.NoError(t, c1.Close())
+	}()
+
+	lossy := &lossyConn{Conn: c0, drop: map[int]bool{
+		0*frames + 1: true, // group 0: 1 lost, repaired.
+		1*frames + 0: true, // group 1: 2 lost, repaired.
+		1*frames + 2: true,
+		2*frames + 0: true, // group 2: 3 lost, not repaired.
+		2*frames + 1: true,
+		2*frames + 3: true,
+	}}
+	w, err := newFECConn(lossy, conf, nil)
+	require.NoError(t, err)
+	r, err := newFECConn(c1, conf, nil)
+	require.NoError(t, err)
+
+	var exp []string
+	go func() {
+		for i := 0; i < 13; i++ {
+			if _, err := w.Write([]byte(fmt.Sprintf("packet %02d", i))); err != nil {
+				return
+			}
+		}
+	}()
+	for i := 0; i < 13; i++ {
+		if i == 8 || i == 9 || i == 11 {
+			continue // lost.
+		}
+		exp = append(exp, fmt.Sprintf("packet %02d", i))
+	}
+
+	for _, e := range exp {
+		b := make([]byte, len(e))
+		_, err := io.ReadFull(r, b)
+		require.NoError(t, err)
+		assert.Equal(t, e, string(b))
+	}
+	assert.Equal(t, uint64(3), r.counters.repaired)
+	assert.Equal(t, uint64(3), r.counters.lost)
+}
+
+func TestFECConfig_Validate(t *testing.T) {
+	assert.NoError(t, FECConfig{}.Validate())
+	assert.NoError(t, FECConfig{DataShards: 10, ParityShards: 4}.Validate())
+	assert.Error(t, FECConfig{DataShards: 200, ParityShards: 200}.Validate())
+}
@@ -2,10 +2,12 @@ package transport
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 
@@ -41,49 +43,158 @@ func compareEntries(expected, received *Entry) error {
 	return nil
 }
 
-func receiveAndVerifyEntry(r io.Reader, expected *Entry, remotePK cipher.PubKey) (*SignedEntry, error) {
-	var recvSE SignedEntry
-	if err := json.NewDecoder(r).Decode(&recvSE); err != nil {
-		return nil, fmt.Errorf("failed to read entry: %s", err)
+// DefaultMTU is the default maximum payload size of packets sent over a transport.
+const DefaultMTU = math.MaxUint16
+
+// Settlement responses.
+const (
+	settlementRejected       byte = 0
+	settlementAccepted       byte = 1 // legacy response, without MTU.
+	settlementAcceptedMTU    byte = 2 // followed by the responder's MTU (2 bytes, big endian).
+	settlementAcceptedCipher byte = 3 // followed by the responder's MTU and the selected cipher suite (1 byte length, then name).
+	settlementAcceptedResume byte = 4 // followed by the same as settlementAcceptedCipher, then the responder's ResumeState.
+	settlementAcceptedFEC    byte = 5 // followed by the same as settlementAcceptedCipher, a resume flag (1 byte, then the responder's ResumeState if 1), then the FECConfig.
+)
+
+// settlementRequest is sent by the initiator of the settlement handshake.
+// MTU, CipherSuites, Nonce, Resume and FEC are omitted by legacy visors.
+type settlementRequest struct {
+	SignedEntry
+	MTU          uint16           `json:"mtu,omitempty"`
+	CipherSuites []string         `json:"cipher_suites,omitempty"` // in order of preference.
+	Nonce        *settlementNonce `json:"nonce,omitempty"`
+	Resume       *ResumeState     `json:"resume,omitempty"`
+	FEC          *FECConfig       `json:"fec,omitempty"`
+}
+
+// settlementOffer returns the binary representation of the MTU, cipher suites and FEC config offered by the initiator.
+// It is signed with the nonce of the settlement request, so that an on-path attacker can not downgrade the offer.
+func settlementOffer(mtu uint16, suites []string, fec *FECConfig) []byte {
+	b := make([]byte, 2, 2+len(suites)*(len(CipherSuiteNoiseKK)+1))
+	binary.BigEndian.PutUint16(b, mtu)
+	for _, s := range suites {
+		lb := make([]byte, binary.MaxVarintLen64)
+		b = append(b, lb[:binary.PutUvarint(lb, uint64(len(s)))]...)
+		b = append(b, s...)
+	}
+	if fec != nil {
+		b = append(b, fec.encode()...)
+	}
+	return b
+}
+
+// settlementPrologue returns the noise prologue of an encrypted transport, which covers the whole negotiation:
+// the entry, the initiator's offer, and the responder's MTU, selected cipher suite and FEC config.
+func settlementPrologue(entry *Entry, offer []byte, mtu uint16, suite string, fec FECConfig) []byte {
+	b := entry.ToBinary()
+	b = append(b, offer...)
+	b = append(b, byte(mtu>>8), byte(mtu))
+	b = append(b, suite...)
+	return append(b, fec.encode()...)
+}
+
+// negotiateMTU returns the lesser of the two MTUs, treating zero as DefaultMTU.
+func negotiateMTU(local, remote uint16) uint16 {
+	if local == 0 {
+		local = DefaultMTU
+	}
+	if remote == 0 || remote > local {
+		return local
+	}
+	return remote
+}
+
+func receiveAndVerifyEntry(r io.Reader, expected *Entry, remotePK cipher.PubKey) (*SignedEntry, *settlementRequest, error) {
+	var req settlementRequest
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return nil, nil, fmt.Errorf("failed to read entry: %s", err)
+	}
+	recvSE := req.SignedEntry
+	if recvSE.Entry == nil {
+		return nil, nil, errors.New("received empty entry")
 	}
 	if err := compareEntries(expected, recvSE.Entry); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	sig, ok := recvSE.Signature(remotePK)
 	if !ok {
-		return nil, errors.New("invalid remote signature")
+		return nil, nil, errors.New("invalid remote signature")
 	}
 	if err := cipher.VerifyPubKeySignedPayload(remotePK, sig, recvSE.Entry.ToBinary()); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return &recvSE, nil
+	return &recvSE, &req, nil
+}
+
+// SettlementConfig configures a settlement handshake.
+type SettlementConfig struct {
+	MTU          uint16   // maximum payload size supported locally (DefaultMTU if 0).
+	CipherSuites []string // allowed cipher suites in order of preference (DefaultCipherSuites of the network if empty).
+
+	// Nonces rejects replayed settlement requests when responding (optional).
+	Nonces *NonceWindow
+
+	// RequireNonce rejects settlement requests of legacy initiators, which do not contain nonces.
+	// Otherwise they are accepted with a warning, as they can not be checked for replays.
+	RequireNonce bool
+
+	// Resume is the local resume state, sent to the remote (optional, resumption is disabled if nil).
+	Resume *ResumeState
+
+	// FEC is offered to the remote when initiating, and accepted from the remote when responding (disabled if zero).
+	FEC FECConfig
+
+	fecCounters *fecCounters // accumulates the counters of forward error correction (optional).
+}
+
+// SettlementResult is the outcome of a successful settlement handshake.
+type SettlementResult struct {
+	Conn        *snet.Conn // connection wrapped with the negotiated cipher suite.
+	MTU         uint16
+	CipherSuite string
+	Resume      *ResumeState // resume state of the remote, nil if either edge does not support resumption.
+	FEC         FECConfig    // negotiated forward error correction, zero if disabled.
 }
 
 // SettlementHS represents a settlement handshake.
 // This is the handshake responsible for registering a transport to transport discovery.
-type SettlementHS func(ctx context.Context, dc DiscoveryClient, conn *snet.Conn, sk cipher.SecKey) error
+// It also negotiates the MTU and cipher suite of the transport, and exchanges the states used to resume it.
+type SettlementHS func(ctx context.Context, dc DiscoveryClient, conn *snet.Conn, sk cipher.SecKey) (SettlementResult, error)
 
 // Do performs the settlement handshake.
-func (hs SettlementHS) Do(ctx context.Context, dc DiscoveryClient, conn *snet.Conn, sk cipher.SecKey) (err error) {
+func (hs SettlementHS) Do(ctx context.Context, dc DiscoveryClient, conn *snet.Conn, sk cipher.SecKey) (res SettlementResult, err error) {
 	done := make(chan struct{})
 	go func() {
-		err = hs(ctx, dc, conn, sk)
+		res, err = hs(ctx, dc, conn, sk)
 		close(done)
 	}()
 	select {
 	case <-done:
-		return err
+		return res, err
 	case <-ctx.Done():
-		return ctx.Err()
+		return SettlementResult{}, ctx.Err()
 	}
 }
 
 // MakeSettlementHS creates a settlement handshake.
 // `init` determines whether the local side is initiating or responding.
-func MakeSettlementHS(init bool) SettlementHS {
+// Remotes which do not support MTU negotiation are assumed to support the local MTU, and remotes which
+// do not support cipher suite negotiation are only accepted if CipherSuiteNone is allowed.
+// Initiators which prefer CipherSuiteNoiseKK over CipherSuiteNone never accept an unencrypted transport.
+func MakeSettlementHS(init bool, conf SettlementConfig) SettlementHS {
+	mtu := negotiateMTU(conf.MTU, 0)
+
+	suitesOf := func(conn *snet.Conn) []string {
+		if len(conf.CipherSuites) == 0 {
+			return DefaultCipherSuites(conn.Network())
+		}
+		return conf.CipherSuites
+	}
+
 	// initiating logic.
-	initHS := func(ctx context.Context, dc DiscoveryClient, conn *snet.Conn, sk cipher.SecKey) (err error) {
+	initHS := func(ctx context.Context, dc DiscoveryClient, conn *snet.Conn, sk cipher.SecKey) (_ SettlementResult, err error) {
 		entry := makeEntryFromTpConn(conn)
+		suites := suitesOf(conn)
 
 		defer func() {
 			if _, err := dc.UpdateStatuses(ctx, &Status{ID: entry.ID, IsUp: err == nil}); err != nil {
@@ -94,34 +205,127 @@ func MakeSettlementHS(init bool) SettlementHS {
 		// create signed entry and send it to responding visor node.
 		se, ok := NewSignedEntry(&entry, conn.LocalPK(), sk)
 		if !ok {
-			return errors.New("failed to sign entry")
+			return SettlementResult{}, errors.New("failed to sign entry")
+		}
+		var offerFEC *FECConfig
+		if conf.FEC.Enabled() {
+			offerFEC = &conf.FEC
+		}
+		offer := settlementOffer(mtu, suites, offerFEC)
+		nonce, err := newSettlementNonce(&entry, offer, sk)
+		if err != nil {
+			return SettlementResult{}, fmt.Errorf("failed to sign nonce: %v", err)
 		}
-		if err := json.NewEncoder(conn).Encode(se); err != nil {
-			return fmt.Errorf("failed to write entry: %v", err)
+		req := settlementRequest{SignedEntry: *se, MTU: mtu, CipherSuites: suites, Nonce: nonce, Resume: conf.Resume, FEC: offerFEC}
+		if err := json.NewEncoder(conn).Encode(req); err != nil {
+			return SettlementResult{}, fmt.Errorf("failed to write entry: %v", err)
 		}
 
 		// await okay signal.
 		accepted := make([]byte, 1)
 		if _, err := io.ReadFull(conn, accepted); err != nil {
-			return fmt.Errorf("failed to read response: %v", err)
+			return SettlementResult{}, fmt.Errorf("failed to read response: %v", err)
 		}
-		if accepted[0] == 0 {
-			return fmt.Errorf("transport settlement rejected by remote")
+		res := SettlementResult{MTU: mtu, CipherSuite: CipherSuiteNone}
+		var remoteMTU uint16
+		switch accepted[0] {
+		case settlementRejected:
+			return SettlementResult{}, fmt.Errorf("transport settlement rejected by remote")
+		case settlementAcceptedMTU, settlementAcceptedCipher, settlementAcceptedResume, settlementAcceptedFEC:
+			b := make([]byte, 2)
+			if _, err := io.ReadFull(conn, b); err != nil {
+				return SettlementResult{}, fmt.Errorf("failed to read remote MTU: %v", err)
+			}
+			remoteMTU = binary.BigEndian.Uint16(b)
+			res.MTU = negotiateMTU(mtu, remoteMTU)
 		}
-		return nil
+		if accepted[0] == settlementAcceptedCipher || accepted[0] == settlementAcceptedResume || accepted[0] == settlementAcceptedFEC {
+			if res.CipherSuite, err = readCipherSuite(conn); err != nil {
+				return SettlementResult{}, err
+			}
+		}
+		resumed := accepted[0] == settlementAcceptedResume
+		if accepted[0] == settlementAcceptedFEC {
+			flag := make([]byte, 1)
+			if _, err := io.ReadFull(conn, flag); err != nil {
+				return SettlementResult{}, fmt.Errorf("failed to read resume flag: %v", err)
+			}
+			resumed = flag[0] == 1
+		}
+		if resumed {
+			if res.Resume, err = readResumeState(conn); err != nil {
+				return SettlementResult{}, err
+			}
+		}
+		if accepted[0] == settlementAcceptedFEC {
+			if res.FEC, err = readFECConfig(conn); err != nil {
+				return SettlementResult{}, err
+			}
+			if res.FEC.Enabled() && (offerFEC == nil || res.FEC != *offerFEC) {
+				return SettlementResult{}, fmt.Errorf("remote selected unexpected fec config %v", res.FEC)
+			}
+		}
+		if res.CipherSuite == CipherSuiteNone && requiresEncryption(suites) {
+			return SettlementResult{}, errors.New("remote did not negotiate an encrypted transport")
+		}
+		if !containsCipherSuite(suites, res.CipherSuite) {
+			return SettlementResult{}, fmt.Errorf("remote selected disallowed cipher suite '%s'", res.CipherSuite)
+		}
+
+		res.MTU = capMTU(res.MTU, res.CipherSuite)
+		if res.FEC.Enabled() {
+			fc, err := newFECConn(conn, res.FEC, conf.fecCounters)
+			if err != nil {
+				return SettlementResult{}, err
+			}
+			conn = conn.WithConn(fc)
+		}
+		prologue := settlementPrologue(&entry, offer, remoteMTU, res.CipherSuite, res.FEC)
+		if res.Conn, err = wrapCipherSuite(conn, res.CipherSuite, sk, true, prologue); err != nil {
+			return SettlementResult{}, err
+		}
+		return res, nil
 	}
 
 	// responding logic.
-	respHS := func(ctx context.Context, dc DiscoveryClient, conn *snet.Conn, sk cipher.SecKey) error {
+	respHS := func(ctx context.Context, dc DiscoveryClient, conn *snet.Conn, sk cipher.SecKey) (SettlementResult, error) {
 		entry := makeEntryFromTpConn(conn)
 
 		// receive, verify and sign entry.
-		recvSE, err := receiveAndVerifyEntry(conn, &entry, conn.RemotePK())
+		recvSE, req, err := receiveAndVerifyEntry(conn, &entry, conn.RemotePK())
 		if err != nil {
-			return err
+			return SettlementResult{}, err
+		}
+
+		if err := checkNonce(conf.Nonces, conf.RequireNonce, req, conn.RemotePK()); err != nil {
+			if _, err := conn.Write([]byte{settlementRejected}); err != nil {
+				log.WithError(err).Warn("Failed to reject transport settlement")
+			}
+			return SettlementResult{}, err
 		}
+
+		// legacy initiators do not send cipher suites, and do not encrypt transports.
+		remoteSuites := req.CipherSuites
+		if len(remoteSuites) == 0 {
+			remoteSuites = []string{CipherSuiteNone}
+		}
+		suite, ok := selectCipherSuite(suitesOf(conn), remoteSuites)
+		if !ok {
+			if _, err := conn.Write([]byte{settlementRejected}); err != nil {
+				log.WithError(err).Warn("Failed to reject transport settlement")
+			}
+			return SettlementResult{}, fmt.Errorf("no common cipher suite with remote: remote supports %v", remoteSuites)
+		}
+		fecConf := negotiateFEC(conf.FEC, req.FEC)
+		if err := fecConf.Validate(); err != nil {
+			if _, err := conn.Write([]byte{settlementRejected}); err != nil {
+				log.WithError(err).Warn("Failed to reject transport settlement")
+			}
+			return SettlementResult{}, fmt.Errorf("invalid fec config of remote: %v", err)
+		}
+
 		if ok := recvSE.Sign(conn.LocalPK(), sk); !ok {
-			return errors.New("failed to sign received entry")
+			return SettlementResult{}, errors.New("failed to sign received entry")
 		}
 		entry = *recvSE.Entry
 
@@ -130,11 +334,57 @@ func MakeSettlementHS(init bool) SettlementHS {
 			log.WithError(err).Error("Failed to register transports")
 		}
 
-		// inform initiating visor node.
-		if _, err := conn.Write([]byte{1}); err != nil {
-			return fmt.Errorf("failed to accept transport settlement: write failed: %v", err)
+		// inform initiating visor node (legacy initiators may not support MTU or cipher suite negotiation).
+		resumed := len(req.CipherSuites) != 0 && req.Resume != nil && conf.Resume != nil
+		var resp []byte
+		switch {
+		case len(req.CipherSuites) != 0 && req.FEC != nil:
+			resp = append([]byte{settlementAcceptedFEC, 0, 0, byte(len(suite))}, suite...)
+			binary.BigEndian.PutUint16(resp[1:], mtu)
+			if resumed {
+				resp = append(resp, 1)
+				resp = append(resp, conf.Resume.encode()...)
+			} else {
+				resp = append(resp, 0)
+			}
+			resp = append(resp, fecConf.encode()...)
+		case resumed:
+			resp = append([]byte{settlementAcceptedResume, 0, 0, byte(len(suite))}, suite...)
+			binary.BigEndian.PutUint16(resp[1:], mtu)
+			resp = append(resp, conf.Resume.encode()...)
+		case len(req.CipherSuites) != 0:
+			resp = append([]byte{settlementAcceptedCipher, 0, 0, byte(len(suite))}, suite...)
+			binary.BigEndian.PutUint16(resp[1:], mtu)
+		case req.MTU != 0:
+			resp = []byte{settlementAcceptedMTU, 0, 0}
+			binary.BigEndian.PutUint16(resp[1:], mtu)
+		default:
+			resp = []byte{settlementAccepted}
 		}
-		return nil
+		if _, err := conn.Write(resp); err != nil {
+			return SettlementResult{}, fmt.Errorf("failed to accept transport settlement: write failed: %v", err)
+		}
+
+		res := SettlementResult{
+			MTU:         capMTU(negotiateMTU(mtu, req.MTU), suite),
+			CipherSuite: suite,
+			FEC:         fecConf,
+		}
+		if resumed {
+			res.Resume = req.Resume
+		}
+		if fecConf.Enabled() {
+			fc, err := newFECConn(conn, fecConf, conf.fecCounters)
+			if err != nil {
+				return SettlementResult{}, err
+			}
+			conn = conn.WithConn(fc)
+		}
+		prologue := settlementPrologue(&entry, settlementOffer(req.MTU, req.CipherSuites, req.FEC), mtu, suite, fecConf)
+		if res.Conn, err = wrapCipherSuite(conn, suite, sk, false, prologue); err != nil {
+			return SettlementResult{}, err
+		}
+		return res, nil
 	}
 
 	if init {
@@ -142,3 +392,37 @@ func MakeSettlementHS(init bool) SettlementHS {
 	}
 	return respHS
 }
+
+// checkNonce verifies the signature of the nonce (and so of the offer) of a settlement request, if present,
+// and rejects replayed settlement requests if a NonceWindow is set.
+func checkNonce(w *NonceWindow, requireNonce bool, req *settlementRequest, remotePK cipher.PubKey) error {
+	if req.Nonce == nil {
+		if w == nil {
+			return nil
+		}
+		if requireNonce {
+			return errors.New("settlement request has no nonce")
+		}
+		log.WithField("remote_pk", remotePK).Warn("Accepting settlement request without nonce from legacy visor")
+		return nil
+	}
+	if err := req.Nonce.verify(req.Entry, settlementOffer(req.MTU, req.CipherSuites, req.FEC), remotePK); err != nil {
+		return fmt.Errorf("invalid nonce signature: %v", err)
+	}
+	if w == nil {
+		return nil
+	}
+	return w.Check(remotePK, req.Nonce.Nonce, req.Nonce.Timestamp)
+}
+
+func readCipherSuite(r io.Reader) (string, error) {
+	n := make([]byte, 1)
+	if _, err := io.ReadFull(r, n); err != nil {
+		return "", fmt.Errorf("failed to read cipher suite: %v", err)
+	}
+	b := make([]byte, n[0])
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", fmt.Errorf("failed to read cipher suite: %v", err)
+	}
+	return string(b), nil
+}
@@ -2,6 +2,11 @@ package transport_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
 	"testing"
 
 	"github.com/SkycoinProject/dmsg"
@@ -12,6 +17,25 @@ import (
 	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
 )
 
+// tamperConn rewrites the first write to the underlying connection, as an on-path attacker would.
+type tamperConn struct {
+	net.Conn
+	once   sync.Once
+	tamper func(b []byte) []byte
+}
+
+func (c *tamperConn) Write(b []byte) (int, error) {
+	first := false
+	c.once.Do(func() { first = true })
+	if !first {
+		return c.Conn.Write(b)
+	}
+	if _, err := c.Conn.Write(c.tamper(b)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
 func TestSettlementHS(t *testing.T) {
 	tpDisc := transport.NewDiscoveryMock()
 
@@ -32,15 +56,244 @@ func TestSettlementHS(t *testing.T) {
 				errCh1 <- err
 				return
 			}
-			errCh1 <- transport.MakeSettlementHS(false).Do(context.TODO(), tpDisc, conn1, keys[1].SK)
+			res, err := transport.MakeSettlementHS(false, transport.SettlementConfig{MTU: 1500}).
+				Do(context.TODO(), tpDisc, conn1, keys[1].SK)
+			if err == nil && res.MTU != 1200 {
+				err = fmt.Errorf("responder negotiated unexpected mtu: %d", res.MTU)
+			}
+			errCh1 <- err
 		}()
 		defer func() {
 			require.NoError(t, <-errCh1)
 		}()
 
-		conn0, err := nEnv.Nets[0].Dial(dmsg.Type, keys[1].PK, skyenv.DmsgTransportPort)
+		conn0, err := nEnv.Nets[0].Dial(context.TODO(), dmsg.Type, keys[1].PK, skyenv.DmsgTransportPort)
+		require.NoError(t, err)
+		res, err := transport.MakeSettlementHS(true, transport.SettlementConfig{MTU: 1200}).
+			Do(context.TODO(), tpDisc, conn0, keys[0].SK)
+		require.NoError(t, err)
+		require.Equal(t, uint16(1200), res.MTU)
+		require.Equal(t, transport.CipherSuiteNone, res.CipherSuite)
+	})
+
+	// TEST: Negotiate an encrypted transport and exchange data over it.
+	t.Run("CipherSuite", func(t *testing.T) {
+		lis1, err := nEnv.Nets[1].Listen(dmsg.Type, skyenv.DmsgTransportPort+1)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, lis1.Close()) }()
+
+		respConf := transport.SettlementConfig{CipherSuites: []string{transport.CipherSuiteNoiseKK, transport.CipherSuiteNone}}
+		errCh1 := make(chan error, 1)
+		go func() {
+			defer close(errCh1)
+			conn1, err := lis1.AcceptConn()
+			if err != nil {
+				errCh1 <- err
+				return
+			}
+			res, err := transport.MakeSettlementHS(false, respConf).Do(context.TODO(), tpDisc, conn1, keys[1].SK)
+			if err != nil {
+				errCh1 <- err
+				return
+			}
+			_, err = io.Copy(res.Conn, io.LimitReader(res.Conn, 5)) // echo
+			errCh1 <- err
+		}()
+
+		conn0, err := nEnv.Nets[0].Dial(context.TODO(), dmsg.Type, keys[1].PK, skyenv.DmsgTransportPort+1)
+		require.NoError(t, err)
+		initConf := transport.SettlementConfig{CipherSuites: []string{transport.CipherSuiteNoiseKK}}
+		res, err := transport.MakeSettlementHS(true, initConf).Do(context.TODO(), tpDisc, conn0, keys[0].SK)
+		require.NoError(t, err)
+		require.Equal(t, transport.CipherSuiteNoiseKK, res.CipherSuite)
+		require.True(t, res.MTU < transport.DefaultMTU)
+
+		_, err = res.Conn.Write([]byte("hello"))
+		require.NoError(t, err)
+		b := make([]byte, 5)
+		_, err = io.ReadFull(res.Conn, b)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(b))
+		require.NoError(t, <-errCh1)
+	})
+
+	// TEST: Settlement is rejected when there is no common cipher suite.
+	t.Run("NoCommonCipherSuite", func(t *testing.T) {
+		lis1, err := nEnv.Nets[1].Listen(dmsg.Type, skyenv.DmsgTransportPort+2)
 		require.NoError(t, err)
-		require.NoError(t, transport.MakeSettlementHS(true).Do(context.TODO(), tpDisc, conn0, keys[0].SK), "fucked up")
+		defer func() { require.NoError(t, lis1.Close()) }()
+
+		errCh1 := make(chan error, 1)
+		go func() {
+			defer close(errCh1)
+			conn1, err := lis1.AcceptConn()
+			if err != nil {
+				errCh1 <- err
+				return
+			}
+			respConf := transport.SettlementConfig{CipherSuites: []string{transport.CipherSuiteNone}}
+			_, err = transport.MakeSettlementHS(false, respConf).Do(context.TODO(), tpDisc, conn1, keys[1].SK)
+			errCh1 <- err
+		}()
+
+		conn0, err := nEnv.Nets[0].Dial(context.TODO(), dmsg.Type, keys[1].PK, skyenv.DmsgTransportPort+2)
+		require.NoError(t, err)
+		initConf := transport.SettlementConfig{CipherSuites: []string{transport.CipherSuiteNoiseKK}}
+		_, err = transport.MakeSettlementHS(true, initConf).Do(context.TODO(), tpDisc, conn0, keys[0].SK)
+		require.Error(t, err)
+		require.Error(t, <-errCh1)
+	})
+
+	// TEST: Resume states are exchanged only if both edges support resumption.
+	t.Run("Resume", func(t *testing.T) {
+		lis1, err := nEnv.Nets[1].Listen(dmsg.Type, skyenv.DmsgTransportPort+3)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, lis1.Close()) }()
+
+		initState := transport.ResumeState{Session: 1, Peer: 2, Recv: 10}
+		respState := transport.ResumeState{Session: 2, Peer: 1, Recv: 20}
+
+		for _, respResume := range []*transport.ResumeState{&respState, nil} {
+			resCh1 := make(chan transport.SettlementResult, 1)
+			errCh1 := make(chan error, 1)
+			go func(respConf transport.SettlementConfig) {
+				conn1, err := lis1.AcceptConn()
+				if err != nil {
+					errCh1 <- err
+					return
+				}
+				res, err := transport.MakeSettlementHS(false, respConf).Do(context.TODO(), tpDisc, conn1, keys[1].SK)
+				resCh1 <- res
+				errCh1 <- err
+			}(transport.SettlementConfig{Resume: respResume})
+
+			conn0, err := nEnv.Nets[0].Dial(context.TODO(), dmsg.Type, keys[1].PK, skyenv.DmsgTransportPort+3)
+			require.NoError(t, err)
+			initConf := transport.SettlementConfig{Resume: &initState}
+			res0, err := transport.MakeSettlementHS(true, initConf).Do(context.TODO(), tpDisc, conn0, keys[0].SK)
+			require.NoError(t, err)
+			res1 := <-resCh1
+			require.NoError(t, <-errCh1)
+
+			if respResume == nil {
+				require.Nil(t, res0.Resume)
+				require.Nil(t, res1.Resume)
+				continue
+			}
+			require.Equal(t, &respState, res0.Resume)
+			require.Equal(t, &initState, res1.Resume)
+		}
+	})
+
+	// TEST: Forward error correction is negotiated only if both edges enable it, and the transport carries data.
+	t.Run("FEC", func(t *testing.T) {
+		lis1, err := nEnv.Nets[1].Listen(dmsg.Type, skyenv.DmsgTransportPort+7)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, lis1.Close()) }()
+
+		initFEC := transport.FECConfig{DataShards: 4, ParityShards: 2}
+		for _, respFEC := range []transport.FECConfig{initFEC, {}} {
+			resCh1 := make(chan transport.SettlementResult, 1)
+			errCh1 := make(chan error, 1)
+			go func(respConf transport.SettlementConfig) {
+				conn1, err := lis1.AcceptConn()
+				if err != nil {
+					errCh1 <- err
+					return
+				}
+				res, err := transport.MakeSettlementHS(false, respConf).Do(context.TODO(), tpDisc, conn1, keys[1].SK)
+				resCh1 <- res
+				if err == nil {
+					_, err = io.Copy(res.Conn, io.LimitReader(res.Conn, 5)) // echo
+				}
+				errCh1 <- err
+			}(transport.SettlementConfig{FEC: respFEC})
+
+			conn0, err := nEnv.Nets[0].Dial(context.TODO(), dmsg.Type, keys[1].PK, skyenv.DmsgTransportPort+7)
+			require.NoError(t, err)
+			initConf := transport.SettlementConfig{CipherSuites: []string{transport.CipherSuiteNoiseKK}, FEC: initFEC}
+			res0, err := transport.MakeSettlementHS(true, initConf).Do(context.TODO(), tpDisc, conn0, keys[0].SK)
+			require.NoError(t, err)
+			require.Equal(t, respFEC, res0.FEC)
+			require.Equal(t, respFEC, (<-resCh1).FEC)
+
+			_, err = res0.Conn.Write([]byte("hello"))
+			require.NoError(t, err)
+			b := make([]byte, 5)
+			_, err = io.ReadFull(res0.Conn, b)
+			require.NoError(t, err)
+			require.Equal(t, "hello", string(b))
+			require.NoError(t, <-errCh1)
+		}
+	})
+
+	// downgrade performs a settlement over the given port, where the initiator's first write is rewritten by
+	// tamperInit and the responder's first write is rewritten by tamperResp (if non-nil).
+	// Both edges allow CipherSuiteNone, but prefer CipherSuiteNoiseKK.
+	downgrade := func(port uint16, tamperInit, tamperResp func([]byte) []byte) (initErr, respErr error) {
+		lis1, err := nEnv.Nets[1].Listen(dmsg.Type, port)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, lis1.Close()) }()
+
+		conf := transport.SettlementConfig{CipherSuites: []string{transport.CipherSuiteNoiseKK, transport.CipherSuiteNone}}
+		errCh1 := make(chan error, 1)
+		go func() {
+			defer close(errCh1)
+			conn1, err := lis1.AcceptConn()
+			if err != nil {
+				errCh1 <- err
+				return
+			}
+			defer func() { _ = conn1.Close() }() // nolint:errcheck
+			if tamperResp != nil {
+				conn1 = conn1.WithConn(&tamperConn{Conn: conn1.Conn, tamper: tamperResp})
+			}
+			_, err = transport.MakeSettlementHS(false, conf).Do(context.TODO(), tpDisc, conn1, keys[1].SK)
+			errCh1 <- err
+		}()
+
+		conn0, err := nEnv.Nets[0].Dial(context.TODO(), dmsg.Type, keys[1].PK, port)
+		require.NoError(t, err)
+		if tamperInit != nil {
+			conn0 = conn0.WithConn(&tamperConn{Conn: conn0.Conn, tamper: tamperInit})
+		}
+		_, initErr = transport.MakeSettlementHS(true, conf).Do(context.TODO(), tpDisc, conn0, keys[0].SK)
+		require.NoError(t, conn0.Close())
+		return initErr, <-errCh1
+	}
+
+	// TEST: Stripping CipherSuiteNoiseKK from the offer of the initiator is detected by the responder.
+	t.Run("DowngradedOffer", func(t *testing.T) {
+		initErr, respErr := downgrade(skyenv.DmsgTransportPort+4, func(b []byte) []byte {
+			var req map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(b, &req))
+			req["cipher_suites"] = json.RawMessage(`["none"]`)
+			b, err := json.Marshal(req)
+			require.NoError(t, err)
+			return append(b, '\n')
+		}, nil)
+		require.Error(t, initErr)
+		require.Error(t, respErr)
+	})
+
+	// TEST: Rewriting the response to the legacy response (which implies CipherSuiteNone) is refused by the initiator.
+	t.Run("DowngradedResponse", func(t *testing.T) {
+		initErr, respErr := downgrade(skyenv.DmsgTransportPort+5, nil, func([]byte) []byte {
+			return []byte{1}
+		})
+		require.Error(t, initErr)
+		require.Error(t, respErr)
+	})
+
+	// TEST: Rewriting the negotiated MTU fails the noise handshake, as the negotiation is its prologue.
+	t.Run("TamperedResponse", func(t *testing.T) {
+		initErr, respErr := downgrade(skyenv.DmsgTransportPort+6, nil, func(b []byte) []byte {
+			b = append([]byte(nil), b...)
+			b[1], b[2] = 0x04, 0x00
+			return b
+		})
+		require.Error(t, initErr)
+		require.Error(t, respErr)
 	})
 }
 
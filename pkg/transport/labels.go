@@ -0,0 +1,125 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidLabel occurs when a transport label is empty or contains whitespace or commas.
+var ErrInvalidLabel = errors.New("transport labels must be non-empty and contain no whitespace or commas")
+
+// LabelStore stores user-defined labels of transports.
+type LabelStore interface {
+	Labels(id uuid.UUID) ([]string, error)
+	SetLabels(id uuid.UUID, labels []string) error
+}
+
+// NormalizeLabels validates, de-duplicates and sorts the given labels.
+func NormalizeLabels(labels []string) ([]string, error) {
+	set := make(map[string]struct{}, len(labels))
+	out := make([]string, 0, len(labels))
+	for _, l := range labels {
+		if l == "" || strings.ContainsAny(l, " \t\r\n,") {
+			return nil, ErrInvalidLabel
+		}
+		if _, ok := set[l]; ok {
+			continue
+		}
+		set[l] = struct{}{}
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+type inMemoryTransportLabelStore struct {
+	labels map[uuid.UUID][]string
+	mu     sync.Mutex
+}
+
+// InMemoryTransportLabelStore implements in-memory LabelStore.
+func InMemoryTransportLabelStore() LabelStore {
+	return &inMemoryTransportLabelStore{labels: make(map[uuid.UUID][]string)}
+}
+
+func (s *inMemoryTransportLabelStore) Labels(id uuid.UUID) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.labels[id]...), nil
+}
+
+func (s *inMemoryTransportLabelStore) SetLabels(id uuid.UUID, labels []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(labels) == 0 {
+		delete(s.labels, id)
+		return nil
+	}
+	s.labels[id] = append([]string(nil), labels...)
+	return nil
+}
+
+type fileTransportLabelStore struct {
+	path   string
+	labels map[uuid.UUID][]string
+	mu     sync.Mutex
+}
+
+// FileTransportLabelStore implements LabelStore which persists all labels in a single JSON file.
+func FileTransportLabelStore(path string) (LabelStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	s := &fileTransportLabelStore{path: path, labels: make(map[uuid.UUID][]string)}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("read: %s", err)
+	}
+	if err := json.Unmarshal(data, &s.labels); err != nil {
+		return nil, fmt.Errorf("json: %s", err)
+	}
+	return s, nil
+}
+
+func (s *fileTransportLabelStore) Labels(id uuid.UUID) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.labels[id]...), nil
+}
+
+func (s *fileTransportLabelStore) SetLabels(id uuid.UUID, labels []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(labels) == 0 {
+		if _, ok := s.labels[id]; !ok {
+			return nil
+		}
+		delete(s.labels, id)
+	} else {
+		s.labels[id] = append([]string(nil), labels...)
+	}
+
+	data, err := json.MarshalIndent(s.labels, "", "\t")
+	if err != nil {
+		return fmt.Errorf("json: %s", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write: %s", err)
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,66 @@
+package transport_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+func testTransportLabelStore(t *testing.T, labelStore transport.LabelStore) {
+	t.Helper()
+
+	id1, id2 := uuid.New(), uuid.New()
+	require.NoError(t, labelStore.SetLabels(id1, []string{"backup-lte"}))
+	require.NoError(t, labelStore.SetLabels(id2, []string{"home-fiber", "primary"}))
+
+	labels, err := labelStore.Labels(id2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"home-fiber", "primary"}, labels)
+
+	require.NoError(t, labelStore.SetLabels(id1, nil))
+	labels, err = labelStore.Labels(id1)
+	require.NoError(t, err)
+	assert.Empty(t, labels)
+}
+
+func TestInMemoryTransportLabelStore(t *testing.T) {
+	testTransportLabelStore(t, transport.InMemoryTransportLabelStore())
+}
+
+func TestFileTransportLabelStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "label_store")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	path := filepath.Join(dir, "labels.json")
+
+	ls, err := transport.FileTransportLabelStore(path)
+	require.NoError(t, err)
+	testTransportLabelStore(t, ls)
+
+	// Labels should persist.
+	id := uuid.New()
+	require.NoError(t, ls.SetLabels(id, []string{"home-fiber"}))
+	ls, err = transport.FileTransportLabelStore(path)
+	require.NoError(t, err)
+	labels, err := ls.Labels(id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"home-fiber"}, labels)
+}
+
+func TestNormalizeLabels(t *testing.T) {
+	labels, err := transport.NormalizeLabels([]string{"b", "a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, labels)
+
+	_, err = transport.NormalizeLabels([]string{"home fiber"})
+	assert.Equal(t, transport.ErrInvalidLabel, err)
+}
@@ -0,0 +1,268 @@
+package transport
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
+)
+
+// Default values of MaintenanceConfig.
+const (
+	DefaultMaintenanceInterval = time.Minute
+	maintenanceDialTimeout     = 20 * time.Second
+	maintenanceCandidateLimit  = 100 // max number of entries obtained per seed.
+)
+
+// MaintenanceConfig configures the automatic transport maintenance policy.
+// The policy keeps at least MinTransports healthy transports, creating new ones to peers found via
+// transport discovery when needed, and prunes transports it created once there are more than MaxTransports.
+// Peers are preferred if they have public transports, were quick to establish transports to, and have more
+// transports which are up, in that order.
+type MaintenanceConfig struct {
+	MinTransports int
+	MaxTransports int             // if 0, transports are never pruned.
+	Type          string          // type of transports to create (defaults to the preferred network).
+	Seeds         []cipher.PubKey // visors used to discover candidate peers (defaults to ManagerConfig.DefaultNodes).
+	Interval      time.Duration   // defaults to DefaultMaintenanceInterval.
+}
+
+// maintainedTp records a transport created by the maintenance policy.
+type maintainedTp struct {
+	id      uuid.UUID
+	latency time.Duration // time taken to establish the transport.
+}
+
+// maintenanceCandidate is a remote visor which the maintenance policy may create a transport to.
+type maintenanceCandidate struct {
+	pk      cipher.PubKey
+	up      int           // number of transports of the visor which are up, as reported by seeds.
+	public  bool          // whether the visor has public transports.
+	latency time.Duration // time taken to establish a transport to the visor, 0 if never measured.
+}
+
+// rankCandidates sorts candidates best first: visors with public transports, then those which were quickest to
+// establish transports to (failed attempts count as maintenanceDialTimeout, and unmeasured visors are ranked after
+// measured ones), then those with more transports which are up.
+func rankCandidates(cands []maintenanceCandidate) {
+	latency := func(c maintenanceCandidate) time.Duration {
+		if c.latency == 0 {
+			return maintenanceDialTimeout - 1
+		}
+		return c.latency
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		a, b := cands[i], cands[j]
+		if a.public != b.public {
+			return a.public
+		}
+		if la, lb := latency(a), latency(b); la != lb {
+			return la < lb
+		}
+		if a.up != b.up {
+			return a.up > b.up
+		}
+		return a.pk.Hex() < b.pk.Hex()
+	})
+}
+
+// IsUp returns whether the transport currently has an established underlying connection.
+func (mt *ManagedTransport) IsUp() bool {
+	return mt.getConn() != nil
+}
+
+// Maintain runs the maintenance policy described by conf until the context is canceled or the Manager is closed.
+func (tm *Manager) Maintain(ctx context.Context, conf MaintenanceConfig) {
+	if conf.Interval <= 0 {
+		conf.Interval = DefaultMaintenanceInterval
+	}
+	if conf.Type == "" {
+		conf.Type = tm.n.PreferredNetwork()
+	}
+
+	owned := make(map[uuid.UUID]maintainedTp)
+	latencies := make(map[cipher.PubKey]time.Duration)
+
+	ticker := time.NewTicker(conf.Interval)
+	defer ticker.Stop()
+
+	// Maintenance is run as soon as the network of maintained transports becomes available again.
+	netEvents, stop := tm.n.Observe()
+	defer stop()
+
+	for {
+		tm.maintain(ctx, conf, owned, latencies)
+		if !tm.awaitMaintenance(ctx, conf.Type, ticker.C, netEvents) {
+			return
+		}
+	}
+}
+
+// awaitMaintenance blocks until maintenance is next due, and returns false if maintenance should stop.
+func (tm *Manager) awaitMaintenance(ctx context.Context, netType string, tick <-chan time.Time, netEvents <-chan snet.NetworkEvent) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-tm.done:
+			return false
+		case <-tick:
+			return true
+		case e, ok := <-netEvents:
+			if !ok {
+				return false
+			}
+			if e.Network == netType && e.Available {
+				return true
+			}
+		}
+	}
+}
+
+// maintain runs a single round of maintenance. Latencies of transports it attempts to create are recorded in latencies
+// to rank candidates in later rounds.
+func (tm *Manager) maintain(ctx context.Context, conf MaintenanceConfig, owned map[uuid.UUID]maintainedTp, latencies map[cipher.PubKey]time.Duration) {
+	var healthy, total int // healthy only counts public transports which are up.
+	remotes := make(map[cipher.PubKey]struct{})
+	tm.WalkTransports(func(tp *ManagedTransport) bool {
+		total++
+		if tp.Entry.Public && tp.IsUp() {
+			healthy++
+		}
+		remotes[tp.Remote()] = struct{}{}
+		return true
+	})
+	for id := range owned {
+		if tm.Transport(id) == nil {
+			delete(owned, id) // deleted elsewhere.
+		}
+	}
+
+	if healthy < conf.MinTransports && !tm.n.IsAvailable(conf.Type) {
+		tm.Logger.Infof("maintenance: network '%s' is unavailable, not creating transports", conf.Type)
+	} else if healthy < conf.MinTransports {
+		seeds := conf.Seeds
+		if len(seeds) == 0 {
+			seeds = tm.DefaultNodes()
+		}
+		for _, pk := range tm.maintenanceCandidates(ctx, seeds, remotes, latencies) {
+			if healthy >= conf.MinTransports || tm.isClosing() {
+				break
+			}
+			start := time.Now()
+			dialCtx, cancel := context.WithTimeout(ctx, maintenanceDialTimeout)
+			mTp, err := tm.SaveTransport(dialCtx, pk, conf.Type)
+			cancel()
+			if err != nil {
+				tm.Logger.Warnf("maintenance: failed to save transport to %s: %v", pk, err)
+				latencies[pk] = maintenanceDialTimeout
+				continue
+			}
+			if !mTp.IsUp() {
+				tm.Logger.Infof("maintenance: transport to %s is down, discarding", pk)
+				tm.DeleteTransport(mTp.Entry.ID)
+				latencies[pk] = maintenanceDialTimeout
+				continue
+			}
+			latencies[pk] = time.Since(start)
+			owned[mTp.Entry.ID] = maintainedTp{id: mTp.Entry.ID, latency: latencies[pk]}
+			if mTp.Entry.Public {
+				healthy++
+			}
+			total++
+			tm.Logger.Infof("maintenance: established transport %s to %s", mTp.Entry.ID, pk)
+		}
+		if healthy < conf.MinTransports {
+			tm.Logger.Warnf("maintenance: only %d/%d healthy public transports", healthy, conf.MinTransports)
+		}
+	}
+
+	if conf.MaxTransports > 0 && total > conf.MaxTransports {
+		for _, id := range tm.pruneOrder(owned) {
+			if total <= conf.MaxTransports {
+				break
+			}
+			tm.Logger.Infof("maintenance: pruning redundant transport %s", id)
+			tm.DeleteTransport(id)
+			delete(owned, id)
+			total--
+		}
+	}
+}
+
+// maintenanceCandidates returns the remote public keys of visors that may be connected to, best first (see
+// rankCandidates). Candidates are the seeds and the visors that seeds have transports with.
+func (tm *Manager) maintenanceCandidates(ctx context.Context, seeds []cipher.PubKey, exclude map[cipher.PubKey]struct{},
+	latencies map[cipher.PubKey]time.Duration) []cipher.PubKey {
+
+	cands := make(map[cipher.PubKey]*maintenanceCandidate)
+	candidate := func(pk cipher.PubKey) *maintenanceCandidate {
+		c, ok := cands[pk]
+		if !ok {
+			c = &maintenanceCandidate{pk: pk, latency: latencies[pk]}
+			cands[pk] = c
+		}
+		return c
+	}
+	for _, seed := range seeds {
+		candidate(seed)
+		entries, _, err := tm.conf.DiscoveryClient.QueryTransportsByEdge(ctx, seed, EdgeQuery{Limit: maintenanceCandidateLimit})
+		if err != nil {
+			tm.Logger.Warnf("maintenance: failed to obtain transports of %s: %v", seed, err)
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsUp {
+				continue
+			}
+			remote := candidate(e.Entry.RemoteEdge(seed))
+			remote.up++
+			if e.Entry.Public {
+				remote.public = true
+				candidate(seed).public = true
+			}
+		}
+	}
+
+	ranked := make([]maintenanceCandidate, 0, len(cands))
+	for pk, c := range cands {
+		if _, ok := exclude[pk]; ok || pk == tm.conf.PubKey {
+			continue
+		}
+		ranked = append(ranked, *c)
+	}
+	rankCandidates(ranked)
+	pks := make([]cipher.PubKey, len(ranked))
+	for i, c := range ranked {
+		pks[i] = c.pk
+	}
+	return pks
+}
+
+// pruneOrder sorts the transports created by the maintenance policy in the order they should be pruned:
+// transports that are down first, followed by the ones that took longest to establish.
+func (tm *Manager) pruneOrder(owned map[uuid.UUID]maintainedTp) []uuid.UUID {
+	tps := make([]maintainedTp, 0, len(owned))
+	up := make(map[uuid.UUID]bool, len(owned))
+	for id, mt := range owned {
+		tps = append(tps, mt)
+		if tp := tm.Transport(id); tp != nil {
+			up[id] = tp.IsUp()
+		}
+	}
+	sort.Slice(tps, func(i, j int) bool {
+		if up[tps[i].id] != up[tps[j].id] {
+			return !up[tps[i].id]
+		}
+		return tps[i].latency > tps[j].latency
+	})
+	ids := make([]uuid.UUID, len(tps))
+	for i, mt := range tps {
+		ids[i] = mt.id
+	}
+	return ids
+}
@@ -0,0 +1,72 @@
+package transport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankCandidates(t *testing.T) {
+	pks := make([]cipher.PubKey, 6)
+	for i := range pks {
+		pks[i], _ = cipher.GenerateKeyPair()
+	}
+
+	// Expected order.
+	exp := []maintenanceCandidate{
+		{pk: pks[0], public: true, latency: time.Second},
+		{pk: pks[1], public: true, up: 5},
+		{pk: pks[2], latency: 100 * time.Millisecond},
+		{pk: pks[3], latency: time.Second, up: 1},
+		{pk: pks[4], up: 3},
+		{pk: pks[5], up: 10, latency: maintenanceDialTimeout}, // failed.
+	}
+	cands := make([]maintenanceCandidate, len(exp))
+	for i, j := range []int{5, 3, 1, 4, 0, 2} {
+		cands[i] = exp[j]
+	}
+	rankCandidates(cands)
+	assert.Equal(t, exp, cands)
+}
+
+func TestManager_maintenanceCandidates(t *testing.T) {
+	keys := make([]cipher.PubKey, 6)
+	for i := range keys {
+		keys[i], _ = cipher.GenerateKeyPair()
+	}
+	local, seed, private, public, down, lone := keys[0], keys[1], keys[2], keys[3], keys[4], keys[5]
+
+	dc := NewDiscoveryMock()
+	for _, e := range []*Entry{
+		NewEntry(seed, private, "dmsg", false),
+		NewEntry(seed, public, "dmsg", true),
+		NewEntry(seed, public, "stcp", true),
+		NewEntry(seed, down, "dmsg", true),
+		NewEntry(seed, local, "dmsg", true),
+	} {
+		require.NoError(t, dc.RegisterTransports(context.TODO(), &SignedEntry{Entry: e}))
+	}
+	_, err := dc.UpdateStatuses(context.TODO(), &Status{ID: MakeTransportID(seed, down, "dmsg"), IsUp: false})
+	require.NoError(t, err)
+
+	tm := &Manager{
+		Logger: logging.MustGetLogger("transport_manager"),
+		conf:   &ManagerConfig{PubKey: local, DiscoveryClient: dc},
+	}
+
+	// Visors with public transports rank first, then those with more transports which are up.
+	// Seeds are not assumed to have transports which are up.
+	assert.Equal(t, []cipher.PubKey{public, seed, private, lone},
+		tm.maintenanceCandidates(context.TODO(), []cipher.PubKey{seed, lone}, nil, nil))
+
+	// Measured latencies take precedence over the number of transports which are up.
+	latencies := map[cipher.PubKey]time.Duration{seed: time.Second, public: maintenanceDialTimeout}
+	exclude := map[cipher.PubKey]struct{}{private: {}}
+	assert.Equal(t, []cipher.PubKey{seed, public},
+		tm.maintenanceCandidates(context.TODO(), []cipher.PubKey{seed}, exclude, latencies))
+}
@@ -0,0 +1,56 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/snettest"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+func TestManager_Maintain(t *testing.T) {
+	tpDisc := transport.NewDiscoveryMock()
+
+	keys := snettest.GenKeyPairs(2)
+	nEnv := snettest.NewEnv(t, keys)
+	defer nEnv.Teardown()
+
+	ms := make([]*transport.Manager, len(keys))
+	for i, key := range keys {
+		m, err := transport.NewManager(nEnv.Nets[i], &transport.ManagerConfig{
+			PubKey:          key.PK,
+			SecKey:          key.SK,
+			DiscoveryClient: tpDisc,
+			LogStore:        transport.InMemoryTransportLogStore(),
+		})
+		require.NoError(t, err)
+		go m.Serve(context.TODO())
+		ms[i] = m
+	}
+	defer func() {
+		for _, m := range ms {
+			require.NoError(t, m.Close())
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go ms[0].Maintain(ctx, transport.MaintenanceConfig{
+		MinTransports: 1,
+		Type:          "dmsg",
+		Seeds:         []cipher.PubKey{keys[1].PK},
+		Interval:      100 * time.Millisecond,
+	})
+
+	tpID := transport.MakeTransportID(keys[0].PK, keys[1].PK, "dmsg")
+	testhelpers.Eventually(t, func() bool {
+		tp := ms[0].Transport(tpID)
+		return tp != nil && tp.IsUp()
+	}, 5*time.Second, 50*time.Millisecond)
+}
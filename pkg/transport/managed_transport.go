@@ -11,6 +11,7 @@ import (
 
 	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
 
+	"github.com/SkycoinProject/skywire-mainnet/pkg/metrics"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
 
@@ -29,6 +30,12 @@ var (
 
 	// ErrConnAlreadyExists occurs when an underlying transport connection already exists.
 	ErrConnAlreadyExists = errors.New("underlying transport connection already exists")
+
+	// ErrPayloadTooLarge occurs when a packet's payload exceeds the MTU of the transport.
+	ErrPayloadTooLarge = errors.New("packet payload exceeds transport MTU")
+
+	// errConnReplaced occurs when reading from an underlying connection which is no longer used.
+	errConnReplaced = errors.New("underlying transport connection was replaced")
 )
 
 // ManagedTransport manages a direct line of communication between two visor nodes.
@@ -42,8 +49,37 @@ type ManagedTransport struct {
 	LogEntry   *LogEntry
 	logUpdates uint32
 
-	dc DiscoveryClient
-	ls LogStore
+	dc      DiscoveryClient
+	ls      LogStore
+	events  *eventHub                 // may be nil
+	metrics *metrics.TransportMetrics // may be nil
+	nonces  *NonceWindow              // may be nil
+	quotas  *Quotas                   // may be nil
+
+	requireNonce bool // rejects settlement requests without nonces.
+
+	localMTU     uint16   // MTU supported locally (DefaultMTU if 0).
+	cipherSuites []string // allowed cipher suites (DefaultCipherSuites if empty).
+	mtu          uint16   // MTU negotiated with the remote, protected by connMx.
+	cipherSuite  string   // cipher suite negotiated with the remote, protected by connMx.
+
+	fec         FECConfig   // forward error correction offered to, or accepted from the remote (disabled if zero).
+	fecCounters fecCounters // shards repaired and lost by forward error correction, accessed atomically.
+
+	uptime     time.Duration // total duration of previous underlying connections, protected by connMx.
+	upSince    time.Time     // time the current underlying connection was established, protected by connMx.
+	downSince  time.Time     // time the previous underlying connection failed, protected by connMx.
+	reconnects uint32        // underlying connections established after a previous one failed, protected by connMx.
+
+	rates rateHistory // recent bytes sent and received, for the bandwidth rates.
+
+	// Resumption of the packet streams across underlying connections (see ResumeState), protected by connMx.
+	session      uint64
+	peerSession  uint64
+	recvPkts     uint64
+	resumeBuf    *resumeBuffer // nil if resumption is disabled.
+	resumeWindow time.Duration
+	resumable    bool // whether the remote supports resumption.
 
 	n      *snet.Network
 	conn   *snet.Conn
@@ -68,6 +104,10 @@ func NewManagedTransport(n *snet.Network, dc DiscoveryClient, ls LogStore, rPK c
 		LogEntry: new(LogEntry),
 		connCh:   make(chan struct{}, 1),
 		done:     make(chan struct{}),
+
+		session:      newResumeSession(),
+		resumeBuf:    &resumeBuffer{max: DefaultResumeBufferSize},
+		resumeWindow: DefaultResumeWindow,
 	}
 	mt.wg.Add(2)
 	return mt
@@ -105,6 +145,8 @@ func (mt *ManagedTransport) Serve(readCh chan<- routing.Packet, done <-chan stru
 				mt.log.WithError(err).Warn("Failed to close connection")
 			}
 			mt.conn = nil
+			mt.uptime += time.Since(mt.upSince)
+			mt.emit(EventClosed, "transport closed")
 		}
 		mt.connMx.Unlock()
 	}()
@@ -121,12 +163,19 @@ func (mt *ManagedTransport) Serve(readCh chan<- routing.Packet, done <-chan stru
 				if err == ErrNotServing {
 					return
 				}
+				if err == errConnReplaced {
+					continue
+				}
 				mt.connMx.Lock()
-				mt.clearConn(ctx)
+				mt.clearConn(ctx, err)
 				mt.connMx.Unlock()
 				mt.log.Warnf("failed to read packet: %v", err)
 				continue
 			}
+			if mt.quotas != nil && !mt.quotas.Allow(mt.rPK) {
+				mt.log.Debugf("dropped packet: %v", ErrQuotaExceeded)
+				continue
+			}
 			select {
 			case <-done:
 				return
@@ -141,7 +190,8 @@ func (mt *ManagedTransport) Serve(readCh chan<- routing.Packet, done <-chan stru
 		case <-mt.done:
 			return
 
-		case <-logTicker.C:
+		case now := <-logTicker.C:
+			mt.sampleRates(now)
 			if mt.logMod() {
 				if err := mt.ls.Record(mt.Entry.ID, mt.LogEntry); err != nil {
 					mt.log.Warnf("Failed to record log entry: %s", err)
@@ -150,6 +200,7 @@ func (mt *ManagedTransport) Serve(readCh chan<- routing.Packet, done <-chan stru
 				// If there has not been any activity, ensure underlying 'write' tp is still up.
 				mt.connMx.Lock()
 				if mt.conn == nil {
+					mt.emit(EventReconnecting, "")
 					if err := mt.dial(ctx); err != nil {
 						mt.log.Warnf("failed to redial underlying connection: %v", err)
 					}
@@ -206,11 +257,13 @@ func (mt *ManagedTransport) Accept(ctx context.Context, conn *snet.Conn) error {
 
 	ctx, cancel := context.WithTimeout(ctx, time.Second*20)
 	defer cancel()
-	if err := MakeSettlementHS(false).Do(ctx, mt.dc, conn, mt.n.LocalSK()); err != nil {
+	res, err := MakeSettlementHS(false, mt.settlementConfig()).Do(ctx, mt.dc, conn, mt.n.LocalSK())
+	if err != nil {
+		mt.emit(EventHandshakeFailed, err.Error())
 		return fmt.Errorf("settlement handshake failed: %v", err)
 	}
 
-	return mt.setIfConnNil(ctx, conn)
+	return mt.setIfConnNil(ctx, res)
 }
 
 // Dial dials a new underlying connection.
@@ -229,18 +282,36 @@ func (mt *ManagedTransport) Dial(ctx context.Context) error {
 }
 
 func (mt *ManagedTransport) dial(ctx context.Context) error {
-	tp, err := mt.n.Dial(mt.netName, mt.rPK, skyenv.DmsgTransportPort)
+	// Closing the transport cancels pending dials.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-mt.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	tp, err := mt.n.Dial(ctx, mt.netName, mt.rPK, skyenv.DmsgTransportPort)
 	if err != nil {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, time.Second*20)
-	defer cancel()
-	if err := MakeSettlementHS(true).Do(ctx, mt.dc, tp, mt.n.LocalSK()); err != nil {
+	hsCtx, cancelHS := context.WithTimeout(ctx, time.Second*20)
+	defer cancelHS()
+	res, err := MakeSettlementHS(true, mt.settlementConfig()).Do(hsCtx, mt.dc, tp, mt.n.LocalSK())
+	if err != nil {
+		mt.emit(EventHandshakeFailed, err.Error())
 		return fmt.Errorf("settlement handshake failed: %v", err)
 	}
 
-	return mt.setIfConnNil(ctx, tp)
+	return mt.setIfConnNil(hsCtx, res)
+}
+
+func (mt *ManagedTransport) settlementConfig() SettlementConfig {
+	return SettlementConfig{MTU: mt.localMTU, CipherSuites: mt.cipherSuites, Nonces: mt.nonces, RequireNonce: mt.requireNonce, Resume: mt.resumeState(),
+		FEC: mt.fec, fecCounters: &mt.fecCounters}
 }
 
 func (mt *ManagedTransport) getConn() *snet.Conn {
@@ -252,9 +323,9 @@ func (mt *ManagedTransport) getConn() *snet.Conn {
 
 // sets conn if `mt.conn` is nil otherwise, closes the conn.
 // TODO: Add logging here.
-func (mt *ManagedTransport) setIfConnNil(ctx context.Context, conn *snet.Conn) error {
+func (mt *ManagedTransport) setIfConnNil(ctx context.Context, res SettlementResult) error {
 	if mt.conn != nil {
-		if err := conn.Close(); err != nil {
+		if err := res.Conn.Close(); err != nil {
 			log.WithError(err).Warn("Failed to close connection")
 		}
 		return ErrConnAlreadyExists
@@ -270,20 +341,35 @@ func (mt *ManagedTransport) setIfConnNil(ctx context.Context, conn *snet.Conn) e
 		break
 	}
 
-	mt.conn = conn
+	mt.conn = res.Conn
+	mt.upSince = time.Now()
+	if !mt.downSince.IsZero() {
+		mt.reconnects++
+	}
+	mt.mtu = res.MTU
+	mt.cipherSuite = res.CipherSuite
 	select {
 	case mt.connCh <- struct{}{}:
 	default:
 	}
+	mt.emit(EventEstablished, "")
+
+	if err := mt.resume(res.Resume); err != nil {
+		mt.clearConn(ctx, err)
+		return err
+	}
 	return nil
 }
 
-func (mt *ManagedTransport) clearConn(ctx context.Context) {
+func (mt *ManagedTransport) clearConn(ctx context.Context, reason error) {
 	if mt.conn != nil {
 		if err := mt.conn.Close(); err != nil {
 			log.WithError(err).Warn("Failed to close connection")
 		}
 		mt.conn = nil
+		mt.uptime += time.Since(mt.upSince)
+		mt.downSince = time.Now()
+		mt.emit(EventClosed, reason.Error())
 	}
 	if _, err := mt.dc.UpdateStatuses(ctx, &Status{ID: mt.Entry.ID, IsUp: false}); err != nil {
 		mt.log.Warnf("Failed to update transport status: %s", err)
@@ -299,18 +385,37 @@ func (mt *ManagedTransport) WritePacket(ctx context.Context, rtID routing.RouteI
 	if !mt.isServing() {
 		return ErrNotServing
 	}
+	if mt.quotas != nil && !mt.quotas.Allow(mt.rPK) {
+		return ErrQuotaExceeded
+	}
 
+	packet := routing.MakePacket(rtID, payload)
 	if mt.conn == nil {
 		if err := mt.dial(ctx); err != nil {
+			if len(payload) <= int(mt.mtu) && mt.bufferWhileDown(packet) {
+				mt.logSent(uint64(len(payload)))
+				return nil
+			}
 			return fmt.Errorf("failed to redial underlying connection: %v", err)
 		}
 	}
+	if len(payload) > int(mt.mtu) {
+		return fmt.Errorf("%v: payload(%d) mtu(%d)", ErrPayloadTooLarge, len(payload), mt.mtu)
+	}
 
-	n, err := mt.conn.Write(routing.MakePacket(rtID, payload))
+	n, err := mt.conn.Write(packet)
 	if err != nil {
-		mt.clearConn(ctx)
+		mt.clearConn(ctx, err)
+		// The remote discards partially received packets, so the packet is sent in full once resumed.
+		if mt.bufferWhileDown(packet) {
+			mt.logSent(uint64(len(payload)))
+			return nil
+		}
 		return err
 	}
+	if mt.resumeBuf != nil {
+		mt.resumeBuf.push(packet)
+	}
 	if n > routing.PacketHeaderSize {
 		mt.logSent(uint64(n - routing.PacketHeaderSize))
 	}
@@ -333,11 +438,23 @@ func (mt *ManagedTransport) readPacket() (packet routing.Packet, err error) {
 
 	h := make(routing.Packet, routing.PacketHeaderSize)
 	if _, err = io.ReadFull(conn, h); err != nil {
-		return nil, err
+		return nil, mt.readErr(conn, err)
 	}
 	p := make([]byte, h.Size())
 	if _, err = io.ReadFull(conn, p); err != nil {
-		return nil, err
+		return nil, mt.readErr(conn, err)
+	}
+
+	// Packets are only counted as received if the connection is current, as the count of received packets is
+	// exchanged when establishing a new connection (so that the remote retransmits the packets which were lost).
+	mt.connMx.Lock()
+	current := mt.conn == conn
+	if current {
+		mt.recvPkts++
+	}
+	mt.connMx.Unlock()
+	if !current {
+		return nil, errConnReplaced
 	}
 	packet = append(h, p...)
 	if n := len(packet); n > routing.PacketHeaderSize {
@@ -347,17 +464,67 @@ func (mt *ManagedTransport) readPacket() (packet routing.Packet, err error) {
 	return packet, nil
 }
 
+// readErr returns errConnReplaced if the failed connection is no longer used, so that the current one is kept.
+func (mt *ManagedTransport) readErr(conn *snet.Conn, err error) error {
+	if c := mt.getConn(); c != nil && c != conn {
+		return errConnReplaced
+	}
+	return err
+}
+
+func (mt *ManagedTransport) emit(t EventType, reason string) {
+	mt.recordEvent(t)
+	if mt.events == nil {
+		return
+	}
+	mt.events.publish(Event{
+		Type:     t,
+		TpID:     mt.Entry.ID,
+		RemotePK: mt.rPK,
+		TpType:   mt.netName,
+		Reason:   reason,
+		Time:     time.Now(),
+	})
+}
+
 /*
 	TRANSPORT LOGGING
 */
 
+// recordEvent records the metrics associated with the given event.
+func (mt *ManagedTransport) recordEvent(t EventType) {
+	if mt.metrics == nil {
+		return
+	}
+	switch t {
+	case EventEstablished:
+		mt.metrics.Handshakes.WithLabelValues(mt.netName, metrics.HandshakeSuccess).Inc()
+	case EventHandshakeFailed:
+		mt.metrics.Handshakes.WithLabelValues(mt.netName, metrics.HandshakeFailure).Inc()
+	case EventReconnecting:
+		mt.metrics.Reconnects.WithLabelValues(mt.netName).Inc()
+	}
+}
+
 func (mt *ManagedTransport) logSent(b uint64) {
 	mt.LogEntry.AddSent(b)
+	if mt.quotas != nil {
+		mt.quotas.Add(mt.rPK, b)
+	}
+	if mt.metrics != nil {
+		mt.metrics.BytesSent.WithLabelValues(mt.netName).Add(float64(b))
+	}
 	atomic.AddUint32(&mt.logUpdates, 1)
 }
 
 func (mt *ManagedTransport) logRecv(b uint64) {
 	mt.LogEntry.AddRecv(b)
+	if mt.quotas != nil {
+		mt.quotas.Add(mt.rPK, b)
+	}
+	if mt.metrics != nil {
+		mt.metrics.BytesRecv.WithLabelValues(mt.netName).Add(float64(b))
+	}
 	atomic.AddUint32(&mt.logUpdates, 1)
 }
 
@@ -369,6 +536,25 @@ func (mt *ManagedTransport) logMod() bool {
 	return false
 }
 
+// MTU returns the maximum payload size of packets negotiated with the remote.
+// Before the first underlying connection is established, the local MTU is returned.
+func (mt *ManagedTransport) MTU() uint16 {
+	mt.connMx.Lock()
+	defer mt.connMx.Unlock()
+	if mt.mtu == 0 {
+		return negotiateMTU(mt.localMTU, 0)
+	}
+	return mt.mtu
+}
+
+// CipherSuite returns the cipher suite negotiated with the remote.
+// An empty string is returned before the first underlying connection is established.
+func (mt *ManagedTransport) CipherSuite() string {
+	mt.connMx.Lock()
+	defer mt.connMx.Unlock()
+	return mt.cipherSuite
+}
+
 // Remote returns the remote public key.
 func (mt *ManagedTransport) Remote() cipher.PubKey { return mt.rPK }
 
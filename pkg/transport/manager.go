@@ -3,28 +3,48 @@ package transport
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
 
+	"github.com/SkycoinProject/skywire-mainnet/pkg/metrics"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/geo"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 	"github.com/google/uuid"
 )
 
+// ErrDraining occurs when a new transport is requested while the Manager is draining.
+var ErrDraining = errors.New("transport manager is draining")
+
 // ManagerConfig configures a Manager.
 type ManagerConfig struct {
-	PubKey          cipher.PubKey
-	SecKey          cipher.SecKey
-	DefaultNodes    []cipher.PubKey // Nodes to automatically connect to
-	DiscoveryClient DiscoveryClient
-	LogStore        LogStore
+	PubKey               cipher.PubKey
+	SecKey               cipher.SecKey
+	DefaultNodes         []cipher.PubKey // Nodes to automatically connect to
+	DiscoveryClient      DiscoveryClient
+	LogStore             LogStore
+	LabelStore           LabelStore                // defaults to InMemoryTransportLabelStore if nil.
+	MTU                  uint16                    // maximum payload size of packets, defaults to DefaultMTU if 0.
+	CipherSuites         []string                  // allowed cipher suites in order of preference, defaults to DefaultCipherSuites if empty.
+	FEC                  FECConfig                 // forward error correction of transports over lossy networks, disabled if zero.
+	Metrics              *metrics.TransportMetrics // optional.
+	PersistentTransports []PersistentTransport     // transports which are established on serve and kept alive.
+	StatsInterval        time.Duration             // interval of transport stats uploads to discovery, disabled if 0.
+	NonceFile            string                    // file persisting nonces of settlement requests, kept in memory if empty.
+	RequireNonces        bool                      // rejects settlement requests of legacy visors, which do not contain nonces.
+	ResumeBufferSize     int                       // bytes of sent packets retained for resumption, DefaultResumeBufferSize if 0, disabled if negative.
+	TrustedVisors        []cipher.PubKey           // remote visors whose transports are accepted while draining.
+	Quotas               []Quota                   // bandwidth quotas, which do not apply to trusted visors.
+	QuotaFile            string                    // file persisting the usage of quotas, kept in memory if empty.
 }
 
 // Manager manages Transports.
@@ -34,13 +54,22 @@ type Manager struct {
 	nets   map[string]struct{}
 	tps    map[uuid.UUID]*ManagedTransport
 	n      *snet.Network
+	events *eventHub
+	nonces *NonceWindow
+	quotas *Quotas
 
 	readCh    chan routing.Packet
+	confMx    sync.RWMutex // protects DefaultNodes, PersistentTransports, trusted, location and reward, which may be replaced at runtime.
+	trusted   map[cipher.PubKey]struct{}
+	location  *geo.Location // self-reported location included in stats uploads, may be nil.
+	reward    string        // reward address included in stats uploads, may be empty.
 	mx        sync.RWMutex
 	wg        sync.WaitGroup
 	serveOnce sync.Once // ensure we only serve once.
 	closeOnce sync.Once // ensure we only close once.
 	done      chan struct{}
+	ready     chan struct{}
+	draining  int32 // atomic, non-zero once Drain is called.
 }
 
 // NewManager creates a Manager with the provided configuration and transport factories.
@@ -50,15 +79,41 @@ func NewManager(n *snet.Network, config *ManagerConfig) (*Manager, error) {
 	for _, netType := range n.TransportNetworks() {
 		nets[netType] = struct{}{}
 	}
+	if err := ValidateCipherSuites(config.CipherSuites); err != nil {
+		return nil, err
+	}
+	if err := config.FEC.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fec config: %v", err)
+	}
+	if err := validatePersistentTransports(config.PersistentTransports); err != nil {
+		return nil, err
+	}
+	if config.LabelStore == nil {
+		config.LabelStore = InMemoryTransportLabelStore()
+	}
+	nonces, err := NewNonceWindow(config.NonceFile, DefaultNonceWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load nonce window: %v", err)
+	}
+	quotas, err := NewQuotas(config.QuotaFile, config.Quotas)
+	if err != nil {
+		return nil, fmt.Errorf("bandwidth quotas: %v", err)
+	}
 	tm := &Manager{
 		Logger: logging.MustGetLogger("tp_manager"),
 		conf:   config,
 		nets:   nets,
 		tps:    make(map[uuid.UUID]*ManagedTransport),
 		n:      n,
+		events: newEventHub(),
+		nonces: nonces,
+		quotas: quotas,
 		readCh: make(chan routing.Packet, 20),
 		done:   make(chan struct{}),
+		ready:  make(chan struct{}),
 	}
+	tm.SetTrustedVisors(config.TrustedVisors)
+	quotas.exempt = tm.IsTrusted
 	return tm, nil
 }
 
@@ -82,7 +137,16 @@ func (tm *Manager) serve(ctx context.Context) {
 		tm.Logger.Infof("listening on network: %s", netType)
 		listeners = append(listeners, lis)
 
-		tm.wg.Add(1)
+		// The WaitGroup is only added to while the Manager is not closing, so that it does not race with close.
+		tm.mx.Lock()
+		closing := tm.isClosing()
+		if !closing {
+			tm.wg.Add(1)
+		}
+		tm.mx.Unlock()
+		if closing {
+			break
+		}
 		go func() {
 			defer tm.wg.Done()
 			for {
@@ -104,7 +168,16 @@ func (tm *Manager) serve(ctx context.Context) {
 	}
 
 	tm.initTransports(ctx)
+	go tm.publishEndpoints(ctx)
+
 	tm.Logger.Info("transport manager is serving.")
+	close(tm.ready)
+
+	go tm.keepPersistentTransports(ctx)
+	go tm.saveQuotas(ctx)
+	if tm.conf.StatsInterval > 0 {
+		go tm.uploadStats(ctx, tm.conf.StatsInterval)
+	}
 
 	// closing logic
 	<-tm.done
@@ -123,6 +196,9 @@ func (tm *Manager) serve(ctx context.Context) {
 func (tm *Manager) initTransports(ctx context.Context) {
 	tm.mx.Lock()
 	defer tm.mx.Unlock()
+	if tm.isClosing() {
+		return
+	}
 
 	entries, err := tm.conf.DiscoveryClient.GetTransportsByEdge(ctx, tm.conf.PubKey)
 	if err != nil {
@@ -159,13 +235,22 @@ func (tm *Manager) acceptTransport(ctx context.Context, lis *snet.Listener) erro
 	tpID := tm.tpIDFromPK(conn.RemotePK(), conn.Network())
 
 	mTp, ok := tm.tps[tpID]
+	if !ok && tm.isDraining() && !tm.IsTrusted(conn.RemotePK()) {
+		_ = conn.Close() //nolint:errcheck
+		return ErrDraining
+	}
+	if !ok && !tm.quotas.Allow(conn.RemotePK()) {
+		_ = conn.Close() //nolint:errcheck
+		return ErrQuotaExceeded
+	}
 	if !ok {
-		mTp = NewManagedTransport(tm.n, tm.conf.DiscoveryClient, tm.conf.LogStore, conn.RemotePK(), lis.Network())
+		mTp = tm.newManagedTransport(conn.RemotePK(), lis.Network())
 		if err := mTp.Accept(ctx, conn); err != nil {
 			return err
 		}
 		go mTp.Serve(tm.readCh, tm.done)
 		tm.tps[tpID] = mTp
+		tm.updateTransportsMetric()
 
 	} else {
 		if err := mTp.Accept(ctx, conn); err != nil {
@@ -178,7 +263,11 @@ func (tm *Manager) acceptTransport(ctx context.Context, lis *snet.Listener) erro
 }
 
 // SaveTransport begins to attempt to establish data transports to the given 'remote' node.
+// If 'tpType' is empty, the preferred network is used.
 func (tm *Manager) SaveTransport(ctx context.Context, remote cipher.PubKey, tpType string) (*ManagedTransport, error) {
+	if tpType == "" {
+		tpType = tm.n.PreferredNetwork()
+	}
 	tm.mx.Lock()
 	defer tm.mx.Unlock()
 	if tm.isClosing() {
@@ -196,6 +285,9 @@ func (tm *Manager) SaveTransport(ctx context.Context, remote cipher.PubKey, tpTy
 
 func (tm *Manager) saveTransport(remote cipher.PubKey, netName string) (*ManagedTransport, error) {
 	if _, ok := tm.nets[netName]; !ok {
+		if tm.n.IsDisabled(netName) {
+			return nil, fmt.Errorf("transport type '%s' is disabled", netName)
+		}
 		return nil, errors.New("unknown transport type")
 	}
 
@@ -205,10 +297,17 @@ func (tm *Manager) saveTransport(remote cipher.PubKey, netName string) (*Managed
 	if ok {
 		return tp, nil
 	}
+	if tm.isDraining() {
+		return nil, ErrDraining
+	}
+	if !tm.quotas.Allow(remote) {
+		return nil, ErrQuotaExceeded
+	}
 
-	mTp := NewManagedTransport(tm.n, tm.conf.DiscoveryClient, tm.conf.LogStore, remote, netName)
+	mTp := tm.newManagedTransport(remote, netName)
 	go mTp.Serve(tm.readCh, tm.done)
 	tm.tps[tpID] = mTp
+	tm.updateTransportsMetric()
 
 	tm.Logger.Infof("saved transport: remote(%s) type(%s) tpID(%s)", remote, netName, tpID)
 	return mTp, nil
@@ -234,7 +333,50 @@ func (tm *Manager) DeleteTransport(id uuid.UUID) {
 		}
 		tm.Logger.Infof("Deregister transport %s from discovery", id)
 
+		if err := tm.conf.LabelStore.SetLabels(id, nil); err != nil {
+			tm.Logger.Warnf("Failed to remove labels of transport %s: %v", id, err)
+		}
+
 		delete(tm.tps, id)
+		tm.updateTransportsMetric()
+	}
+}
+
+// newManagedTransport creates a ManagedTransport which inherits the Manager's settings.
+func (tm *Manager) newManagedTransport(remote cipher.PubKey, netName string) *ManagedTransport {
+	mTp := NewManagedTransport(tm.n, tm.conf.DiscoveryClient, tm.conf.LogStore, remote, netName)
+	mTp.events = tm.events
+	mTp.metrics = tm.conf.Metrics
+	mTp.localMTU = tm.conf.MTU
+	mTp.cipherSuites = tm.conf.CipherSuites
+	mTp.fec = tm.conf.FEC
+	mTp.nonces = tm.nonces
+	mTp.requireNonce = tm.conf.RequireNonces
+	mTp.quotas = tm.quotas
+	switch size := tm.conf.ResumeBufferSize; {
+	case size < 0:
+		mTp.resumeBuf = nil
+	case size > 0:
+		mTp.resumeBuf.max = size
+	}
+	return mTp
+}
+
+// updateTransportsMetric records the number of transports of each type.
+// WARNING: Not thread safe, tm.mx should be locked.
+func (tm *Manager) updateTransportsMetric() {
+	if tm.conf.Metrics == nil {
+		return
+	}
+	counts := make(map[string]int, len(tm.nets))
+	for netName := range tm.nets {
+		counts[netName] = 0
+	}
+	for _, tp := range tm.tps {
+		counts[tp.Type()]++
+	}
+	for netName, n := range counts {
+		tm.conf.Metrics.Transports.WithLabelValues(netName).Set(float64(n))
 	}
 }
 
@@ -275,6 +417,92 @@ func (tm *Manager) WalkTransports(walk func(tp *ManagedTransport) bool) {
 	tm.mx.RUnlock()
 }
 
+// Labels returns the user-defined labels of the transport of given ID.
+func (tm *Manager) Labels(id uuid.UUID) []string {
+	labels, err := tm.conf.LabelStore.Labels(id)
+	if err != nil {
+		tm.Logger.Warnf("Failed to obtain labels of transport %s: %v", id, err)
+	}
+	return labels
+}
+
+// SetLabels replaces the user-defined labels of the transport of given ID.
+func (tm *Manager) SetLabels(id uuid.UUID, labels []string) error {
+	labels, err := NormalizeLabels(labels)
+	if err != nil {
+		return err
+	}
+	return tm.conf.LabelStore.SetLabels(id, labels)
+}
+
+// Observe returns a channel which receives transport events, and a function to stop observing.
+// Events are dropped if the channel's buffer is full. The channel is closed on Manager.Close.
+func (tm *Manager) Observe() (<-chan Event, func()) {
+	return tm.events.observe()
+}
+
+// DefaultNodes returns the nodes used to discover peers by transport maintenance.
+func (tm *Manager) DefaultNodes() []cipher.PubKey {
+	tm.confMx.RLock()
+	defer tm.confMx.RUnlock()
+	return tm.conf.DefaultNodes
+}
+
+// SetDefaultNodes replaces the nodes used to discover peers by transport maintenance.
+func (tm *Manager) SetDefaultNodes(pks []cipher.PubKey) {
+	tm.confMx.Lock()
+	tm.conf.DefaultNodes = append([]cipher.PubKey(nil), pks...)
+	tm.confMx.Unlock()
+}
+
+// SetTrustedVisors replaces the trusted visors. Transports initiated by trusted visors are still accepted while
+// the Manager is draining, and limits on transports must not apply to them.
+func (tm *Manager) SetTrustedVisors(pks []cipher.PubKey) {
+	trusted := make(map[cipher.PubKey]struct{}, len(pks))
+	for _, pk := range pks {
+		trusted[pk] = struct{}{}
+	}
+	tm.confMx.Lock()
+	tm.trusted = trusted
+	tm.confMx.Unlock()
+}
+
+// IsTrusted returns whether the remote visor is trusted (see SetTrustedVisors).
+func (tm *Manager) IsTrusted(pk cipher.PubKey) bool {
+	tm.confMx.RLock()
+	_, ok := tm.trusted[pk]
+	tm.confMx.RUnlock()
+	return ok
+}
+
+// SetLocation sets the self-reported location of the local visor, which is included in stats uploads.
+func (tm *Manager) SetLocation(l *geo.Location) {
+	tm.confMx.Lock()
+	tm.location = l
+	tm.confMx.Unlock()
+}
+
+// Location returns the self-reported location of the local visor, or nil if unknown.
+func (tm *Manager) Location() *geo.Location {
+	tm.confMx.RLock()
+	defer tm.confMx.RUnlock()
+	return tm.location
+}
+
+// SetRewardAddress sets the reward address of the local visor, which is included in stats uploads.
+func (tm *Manager) SetRewardAddress(addr string) {
+	tm.confMx.Lock()
+	tm.reward = addr
+	tm.confMx.Unlock()
+}
+
+// RewardAddress returns the reward address of the local visor, or an empty string if unset.
+func (tm *Manager) RewardAddress() string {
+	tm.confMx.RLock()
+	defer tm.confMx.RUnlock()
+	return tm.reward
+}
+
 // Local returns Manager.config.PubKey
 func (tm *Manager) Local() cipher.PubKey {
 	return tm.conf.PubKey
@@ -294,8 +522,6 @@ func (tm *Manager) close() {
 	}
 
 	tm.mx.Lock()
-	defer tm.mx.Unlock()
-
 	close(tm.done)
 
 	statuses := make([]*Status, 0, len(tm.tps))
@@ -307,9 +533,37 @@ func (tm *Manager) close() {
 	if _, err := tm.conf.DiscoveryClient.UpdateStatuses(context.Background(), statuses...); err != nil {
 		tm.Logger.Warnf("failed to update transport statuses: %v", err)
 	}
+	tm.mx.Unlock()
 
+	// Listening goroutines may be waiting for mx to accept transports, so it should not be held while waiting.
 	tm.wg.Wait()
 	close(tm.readCh)
+	tm.events.close()
+
+	if err := tm.quotas.Save(); err != nil {
+		tm.Logger.WithError(err).Warn("Failed to persist bandwidth quota usage")
+	}
+}
+
+// Drain stops the Manager from establishing new transports, other than those initiated by trusted visors.
+// Existing transports are kept until the Manager is closed.
+func (tm *Manager) Drain() {
+	atomic.StoreInt32(&tm.draining, 1)
+}
+
+func (tm *Manager) isDraining() bool {
+	return atomic.LoadInt32(&tm.draining) != 0
+}
+
+// Ready returns a channel which is closed once the Manager listens on all networks and has initialized the saved
+// transports.
+func (tm *Manager) Ready() <-chan struct{} {
+	return tm.ready
+}
+
+// IsClosed returns whether the Manager is closed.
+func (tm *Manager) IsClosed() bool {
+	return tm.isClosing()
 }
 
 func (tm *Manager) isClosing() bool {
@@ -10,12 +10,15 @@ import (
 
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 
+	"github.com/SkycoinProject/skywire-mainnet/pkg/metrics"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/snettest"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
 
 	"github.com/SkycoinProject/dmsg"
 	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -60,16 +63,21 @@ func TestNewManager(t *testing.T) {
 	// Prepare tp manager 1.
 	pk1, sk1 := keys[1].PK, keys[1].SK
 	ls1 := transport.InMemoryTransportLogStore()
+	met1 := metrics.NewTransportMetrics("test")
 	m2, err := transport.NewManager(nEnv.Nets[1], &transport.ManagerConfig{
 		PubKey:          pk1,
 		SecKey:          sk1,
 		DiscoveryClient: tpDisc,
 		LogStore:        ls1,
+		Metrics:         met1,
 	})
 	require.NoError(t, err)
 	go m2.Serve(context.TODO())
 	defer func() { require.NoError(t, m2.Close()) }()
 
+	events, stopObserving := m2.Observe()
+	defer stopObserving()
+
 	// Create data transport between manager 1 & manager 2.
 	tp2, err := m2.SaveTransport(context.TODO(), pk0, "dmsg")
 	require.NoError(t, err)
@@ -78,6 +86,19 @@ func TestNewManager(t *testing.T) {
 
 	fmt.Println("transports created")
 
+	// Ensure observers are notified of the established transport.
+	t.Run("check_observe", func(t *testing.T) {
+		select {
+		case e := <-events:
+			assert.Equal(t, transport.EventEstablished, e.Type)
+			assert.Equal(t, tp2.Entry.ID, e.TpID)
+			assert.Equal(t, pk0, e.RemotePK)
+			assert.Equal(t, "dmsg", e.TpType)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for transport event")
+		}
+	})
+
 	totalSent2 := 0
 	totalSent1 := 0
 
@@ -111,6 +132,25 @@ func TestNewManager(t *testing.T) {
 		}
 	})
 
+	// Ensure transport metrics are recorded.
+	t.Run("check_metrics", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		require.NoError(t, reg.Register(met1))
+		families, err := reg.Gather()
+		require.NoError(t, err)
+
+		values := make(map[string]float64)
+		for _, f := range families {
+			for _, m := range f.GetMetric() {
+				values[f.GetName()] += m.GetGauge().GetValue() + m.GetCounter().GetValue()
+			}
+		}
+		assert.Equal(t, float64(1), values["test_transports"])
+		assert.Equal(t, float64(1), values["test_transport_handshakes_total"])
+		assert.Equal(t, float64(totalSent2), values["test_transport_sent_bytes_total"])
+		assert.Equal(t, float64(totalSent1), values["test_transport_received_bytes_total"])
+	})
+
 	// Ensure tp log entries are of expected.
 	t.Run("check_tp_logs", func(t *testing.T) {
 
@@ -128,6 +168,19 @@ func TestNewManager(t *testing.T) {
 		assert.Equal(t, uint64(totalSent1), entry2.RecvBytes)
 	})
 
+	// Ensure the bandwidth statistics of managed transports are of expected.
+	t.Run("check_bandwidth_stats", func(t *testing.T) {
+		stats, err := m0.BandwidthStats(tp1.Entry.ID)
+		require.NoError(t, err)
+		assert.True(t, stats.Managed)
+		assert.Equal(t, pk1, stats.Remote)
+		assert.Equal(t, uint64(totalSent1), stats.SentBytes)
+		assert.Equal(t, uint64(totalSent2), stats.RecvBytes)
+		assert.Zero(t, stats.Reconnects)
+		require.Len(t, stats.Rates, len(transport.RateWindows))
+		assert.Equal(t, []transport.BandwidthStats{*stats}, m0.AllBandwidthStats())
+	})
+
 	// Ensure deleting a transport works as expected.
 	t.Run("check_delete_tp", func(t *testing.T) {
 
@@ -143,6 +196,33 @@ func TestNewManager(t *testing.T) {
 		m2.DeleteTransport(tp2.Entry.ID)
 		_, err = tpDisc.GetTransportByID(context.TODO(), tpID)
 		require.Contains(t, err.Error(), "not found")
+
+		// Deleted transports have the statistics of the log store.
+		stats, err := m2.BandwidthStats(tp2.Entry.ID)
+		require.NoError(t, err)
+		assert.False(t, stats.Managed)
+		assert.Equal(t, uint64(totalSent2), stats.SentBytes)
+		assert.Equal(t, uint64(totalSent1), stats.RecvBytes)
+
+		_, err = m2.BandwidthStats(uuid.New())
+		assert.Equal(t, transport.ErrNoBandwidthStats, err)
+	})
+
+	// Ensure a draining manager keeps existing transports, but does not establish new ones.
+	t.Run("check_drain", func(t *testing.T) {
+		m0.Drain()
+
+		tp, err := m0.SaveTransport(context.TODO(), pk1, "dmsg")
+		require.NoError(t, err)
+		assert.Equal(t, tp1.Entry.ID, tp.Entry.ID)
+
+		pk2, _ := cipher.GenerateKeyPair()
+		_, err = m0.SaveTransport(context.TODO(), pk2, "dmsg")
+		assert.Equal(t, transport.ErrDraining, err)
+
+		m0.SetTrustedVisors([]cipher.PubKey{pk1})
+		assert.True(t, m0.IsTrusted(pk1))
+		assert.False(t, m0.IsTrusted(pk2))
 	})
 }
 
@@ -0,0 +1,174 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	dmsgnoise "github.com/SkycoinProject/dmsg/noise"
+	"github.com/flynn/noise"
+)
+
+// noiseConn is a net.Conn encrypted with the Noise KK pattern.
+// Frames are the same as of dmsg/noise: a 2 byte length, followed by a 4 byte sequence number and the ciphertext.
+// Unlike dmsg/noise, the handshake takes a prologue, which binds the settlement negotiation to the noise session.
+type noiseConn struct {
+	net.Conn
+	enc, dec *noise.CipherState
+
+	rMx  sync.Mutex
+	rBuf bytes.Buffer
+	rSeq uint32
+
+	wMx  sync.Mutex
+	wSeq uint32
+}
+
+// wrapNoiseKK performs the noise handshake over conn, with the given prologue.
+// The handshake fails if the remote used a different prologue.
+func wrapNoiseKK(conn net.Conn, lPK cipher.PubKey, lSK cipher.SecKey, rPK cipher.PubKey, init bool, prologue []byte) (*noiseConn, error) {
+	hs, err := noise.NewHandshakeState(noise.Config{
+		CipherSuite:   noise.NewCipherSuite(dmsgnoise.Secp256k1{}, noise.CipherChaChaPoly, noise.HashSHA256),
+		Random:        rand.Reader,
+		Pattern:       noise.HandshakeKK,
+		Initiator:     init,
+		Prologue:      prologue,
+		StaticKeypair: noise.DHKey{Public: lPK[:], Private: lSK[:]},
+		PeerStatic:    rPK[:],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nc := &noiseConn{Conn: conn}
+	done := make(chan error, 1)
+	go func() {
+		if init {
+			done <- nc.initiatorHandshake(hs)
+		} else {
+			done <- nc.responderHandshake(hs)
+		}
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, err
+		}
+		return nc, nil
+	case <-time.After(dmsgnoise.AcceptHandshakeTimeout):
+		return nil, errors.New("timeout")
+	}
+}
+
+func (nc *noiseConn) initiatorHandshake(hs *noise.HandshakeState) error {
+	msg, _, _, err := hs.WriteMessage(nil, nil)
+	if err != nil {
+		return err
+	}
+	if err := nc.writeFrame(msg); err != nil {
+		return err
+	}
+	if msg, err = nc.readFrame(); err != nil {
+		return err
+	}
+	_, nc.enc, nc.dec, err = hs.ReadMessage(nil, msg)
+	return err
+}
+
+func (nc *noiseConn) responderHandshake(hs *noise.HandshakeState) error {
+	msg, err := nc.readFrame()
+	if err != nil {
+		return err
+	}
+	if _, _, _, err := hs.ReadMessage(nil, msg); err != nil {
+		return err
+	}
+	if msg, nc.dec, nc.enc, err = hs.WriteMessage(nil, nil); err != nil {
+		return err
+	}
+	return nc.writeFrame(msg)
+}
+
+func (nc *noiseConn) readFrame() ([]byte, error) {
+	h := make([]byte, 2)
+	if _, err := io.ReadFull(nc.Conn, h); err != nil {
+		return nil, err
+	}
+	b := make([]byte, binary.BigEndian.Uint16(h))
+	if _, err := io.ReadFull(nc.Conn, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (nc *noiseConn) writeFrame(b []byte) error {
+	if len(b) > math.MaxUint16 {
+		return fmt.Errorf("noise frame of %d bytes is too large", len(b))
+	}
+	frame := make([]byte, 2, 2+len(b))
+	binary.BigEndian.PutUint16(frame, uint16(len(b)))
+	_, err := nc.Conn.Write(append(frame, b...))
+	return err
+}
+
+// Read reads decrypted data. Frames must arrive in order, as the underlying connection is reliable.
+func (nc *noiseConn) Read(p []byte) (int, error) {
+	nc.rMx.Lock()
+	defer nc.rMx.Unlock()
+
+	if nc.rBuf.Len() > 0 {
+		return nc.rBuf.Read(p)
+	}
+
+	frame, err := nc.readFrame()
+	if err != nil {
+		return 0, err
+	}
+	if len(frame) < 4 {
+		return 0, errors.New("noise frame is too short")
+	}
+	seq := binary.BigEndian.Uint32(frame[:4])
+	if seq != nc.rSeq+1 {
+		return 0, fmt.Errorf("unexpected noise frame sequence %d (expected %d)", seq, nc.rSeq+1)
+	}
+	plaintext, err := nc.dec.Cipher().Decrypt(nil, uint64(seq), nil, frame[4:])
+	if err != nil {
+		return 0, err
+	}
+	nc.rSeq = seq
+
+	n := copy(p, plaintext)
+	nc.rBuf.Write(plaintext[n:])
+	return n, nil
+}
+
+// Write encrypts p as a single frame, or as several frames if p does not fit into one.
+func (nc *noiseConn) Write(p []byte) (int, error) {
+	nc.wMx.Lock()
+	defer nc.wMx.Unlock()
+
+	const maxPlaintext = math.MaxUint16 - noiseOverhead
+	for n := 0; n < len(p); {
+		chunk := p[n:]
+		if len(chunk) > maxPlaintext {
+			chunk = chunk[:maxPlaintext]
+		}
+		nc.wSeq++
+		frame := make([]byte, 4, noiseOverhead+len(chunk))
+		binary.BigEndian.PutUint32(frame, nc.wSeq)
+		if err := nc.writeFrame(nc.enc.Cipher().Encrypt(frame, uint64(nc.wSeq), nil, chunk)); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,103 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// persistentTransportsInterval is the interval between checks that persistent transports exist.
+const persistentTransportsInterval = 10 * time.Second
+
+// PersistentTransport is a transport which is established when the Manager serves, and is kept for the lifetime
+// of the Manager. It is re-created if deleted.
+type PersistentTransport struct {
+	PK    cipher.PubKey `json:"pk"`
+	Type  string        `json:"type"`
+	Label string        `json:"label,omitempty"`
+}
+
+// IsPersistent returns whether the given transport is declared as persistent.
+func (tm *Manager) IsPersistent(tp *ManagedTransport) bool {
+	for _, pt := range tm.persistentTransports() {
+		if pt.PK == tp.Remote() && pt.Type == tp.Type() {
+			return true
+		}
+	}
+	return false
+}
+
+func validatePersistentTransports(pts []PersistentTransport) error {
+	for _, pt := range pts {
+		if pt.Label == "" {
+			continue
+		}
+		if _, err := NormalizeLabels([]string{pt.Label}); err != nil {
+			return fmt.Errorf("persistent transport to %s: %v", pt.PK, err)
+		}
+	}
+	return nil
+}
+
+// SetPersistentTransports replaces the persistent transports, which are established on the next check.
+// Transports which are no longer declared as persistent are kept, but are no longer re-created if deleted.
+func (tm *Manager) SetPersistentTransports(pts []PersistentTransport) error {
+	if err := validatePersistentTransports(pts); err != nil {
+		return err
+	}
+	tm.confMx.Lock()
+	tm.conf.PersistentTransports = append([]PersistentTransport(nil), pts...)
+	tm.confMx.Unlock()
+	return nil
+}
+
+func (tm *Manager) persistentTransports() []PersistentTransport {
+	tm.confMx.RLock()
+	defer tm.confMx.RUnlock()
+	return tm.conf.PersistentTransports
+}
+
+// keepPersistentTransports ensures persistent transports exist until the context is canceled or the Manager is closed.
+// Redialing underlying connections is left to the ManagedTransports themselves.
+func (tm *Manager) keepPersistentTransports(ctx context.Context) {
+	ticker := time.NewTicker(persistentTransportsInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, pt := range tm.persistentTransports() {
+			if tm.isClosing() {
+				return
+			}
+			tm.savePersistentTransport(ctx, pt)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tm.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (tm *Manager) savePersistentTransport(ctx context.Context, pt PersistentTransport) {
+	if tm.Transport(MakeTransportID(tm.conf.PubKey, pt.PK, pt.Type)) != nil {
+		return
+	}
+	mTp, err := tm.SaveTransport(ctx, pt.PK, pt.Type)
+	if err != nil {
+		tm.Logger.Warnf("failed to save persistent transport: type(%s) remote(%s): %v", pt.Type, pt.PK, err)
+		return
+	}
+	tm.Logger.Infof("saved persistent transport: type(%s) remote(%s) tpID(%s)", pt.Type, pt.PK, mTp.Entry.ID)
+
+	if pt.Label == "" {
+		return
+	}
+	if err := tm.SetLabels(mTp.Entry.ID, append(tm.Labels(mTp.Entry.ID), pt.Label)); err != nil {
+		tm.Logger.Warnf("failed to label persistent transport %s: %v", mTp.Entry.ID, err)
+	}
+}
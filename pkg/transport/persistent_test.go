@@ -0,0 +1,71 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/snettest"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+func TestManager_PersistentTransports(t *testing.T) {
+	tpDisc := transport.NewDiscoveryMock()
+
+	keys := snettest.GenKeyPairs(2)
+	nEnv := snettest.NewEnv(t, keys)
+	defer nEnv.Teardown()
+
+	persistent := []transport.PersistentTransport{{PK: keys[1].PK, Type: "dmsg", Label: "uplink"}}
+
+	ms := make([]*transport.Manager, len(keys))
+	for i, key := range keys {
+		conf := &transport.ManagerConfig{
+			PubKey:          key.PK,
+			SecKey:          key.SK,
+			DiscoveryClient: tpDisc,
+			LogStore:        transport.InMemoryTransportLogStore(),
+		}
+		if i == 0 {
+			conf.PersistentTransports = persistent
+		}
+		m, err := transport.NewManager(nEnv.Nets[i], conf)
+		require.NoError(t, err)
+		go m.Serve(context.TODO())
+		ms[i] = m
+	}
+	defer func() {
+		for _, m := range ms {
+			require.NoError(t, m.Close())
+		}
+	}()
+
+	tpID := transport.MakeTransportID(keys[0].PK, keys[1].PK, "dmsg")
+	testhelpers.Eventually(t, func() bool {
+		tp := ms[0].Transport(tpID)
+		return tp != nil && tp.IsUp()
+	}, 5*time.Second, 50*time.Millisecond)
+
+	tp := ms[0].Transport(tpID)
+	assert.True(t, ms[0].IsPersistent(tp))
+	assert.Equal(t, []string{"uplink"}, ms[0].Labels(tpID))
+}
+
+func TestNewManager_InvalidPersistentTransportLabel(t *testing.T) {
+	keys := snettest.GenKeyPairs(2)
+	nEnv := snettest.NewEnv(t, keys)
+	defer nEnv.Teardown()
+
+	_, err := transport.NewManager(nEnv.Nets[0], &transport.ManagerConfig{
+		PubKey:               keys[0].PK,
+		SecKey:               keys[0].SK,
+		DiscoveryClient:      transport.NewDiscoveryMock(),
+		LogStore:             transport.InMemoryTransportLogStore(),
+		PersistentTransports: []transport.PersistentTransport{{PK: keys[1].PK, Type: "dmsg", Label: "home fiber"}},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,331 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// QuotaPeriod is the period over which the usage of a bandwidth quota is counted.
+type QuotaPeriod string
+
+const (
+	// QuotaMonthly quotas count usage from the start of the calendar month (in UTC).
+	QuotaMonthly QuotaPeriod = "monthly"
+	// QuotaRolling quotas count usage within a rolling window of time.
+	QuotaRolling QuotaPeriod = "rolling"
+)
+
+// DefaultQuotaWindow is the window of rolling quotas without one.
+const DefaultQuotaWindow = 30 * 24 * time.Hour
+
+const (
+	quotaBuckets      = 30          // number of buckets the usage of rolling quotas is counted in.
+	quotaSaveInterval = time.Minute // interval between saves of quota usage.
+)
+
+// ErrQuotaExceeded occurs when sending packets to, or establishing transports with, a remote whose bandwidth quota
+// is exceeded, or when relaying packets once the relay quota is exceeded.
+var ErrQuotaExceeded = errors.New("bandwidth quota exceeded")
+
+// Quota caps the bytes sent and received over transports with a remote visor, or the bytes relayed on behalf of
+// other visors (forwarded packets).
+type Quota struct {
+	Remote cipher.PubKey // remote visor of the quota, unless Relay is set.
+	Relay  bool          // caps relayed bytes instead of those exchanged with Remote.
+	Bytes  uint64        // maximum bytes per period.
+	Period QuotaPeriod   // QuotaMonthly if empty.
+	Window time.Duration // window of QuotaRolling quotas, DefaultQuotaWindow if 0.
+}
+
+// Validate checks that the quota is well-formed.
+func (q Quota) Validate() error {
+	if q.Relay && !q.Remote.Null() || !q.Relay && q.Remote.Null() {
+		return errors.New("quota must have either a remote or relay set")
+	}
+	if q.Bytes == 0 {
+		return errors.New("quota must have bytes set")
+	}
+	switch q.Period {
+	case "", QuotaMonthly, QuotaRolling:
+	default:
+		return fmt.Errorf("invalid quota period '%s'", q.Period)
+	}
+	if q.Window < 0 {
+		return errors.New("quota window must not be negative")
+	}
+	return nil
+}
+
+func (q Quota) key() string {
+	if q.Relay {
+		return "relay"
+	}
+	return q.Remote.Hex()
+}
+
+// QuotaUsage reports the usage of a bandwidth quota.
+type QuotaUsage struct {
+	Remote   cipher.PubKey `json:"remote"`
+	Relay    bool          `json:"relay"`
+	Period   QuotaPeriod   `json:"period"`
+	Since    time.Time     `json:"since"` // start of the counted usage.
+	Used     uint64        `json:"used"`
+	Limit    uint64        `json:"limit"`
+	Exceeded bool          `json:"exceeded"`
+}
+
+type quotaBucket struct {
+	Start time.Time `json:"start"`
+	Bytes uint64    `json:"bytes"`
+}
+
+type quotaState struct {
+	Quota
+	buckets []quotaBucket // in order of start.
+}
+
+// since returns the start of the usage counted at t.
+func (s *quotaState) since(t time.Time) time.Time {
+	if s.Period == QuotaRolling {
+		return t.Add(-s.window()).UTC()
+	}
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *quotaState) window() time.Duration {
+	if s.Window <= 0 {
+		return DefaultQuotaWindow
+	}
+	return s.Window
+}
+
+// bucketSize is the granularity of counted usage: days for monthly quotas, as months start on days.
+func (s *quotaState) bucketSize() time.Duration {
+	if s.Period != QuotaRolling {
+		return 24 * time.Hour
+	}
+	if size := s.window() / quotaBuckets; size > time.Minute {
+		return size
+	}
+	return time.Minute
+}
+
+func (s *quotaState) prune(t time.Time) {
+	since := s.since(t)
+	i := 0
+	for i < len(s.buckets) && s.buckets[i].Start.Before(since) {
+		i++
+	}
+	s.buckets = s.buckets[i:]
+}
+
+func (s *quotaState) add(t time.Time, n uint64) {
+	start := t.Truncate(s.bucketSize())
+	if last := len(s.buckets) - 1; last >= 0 && s.buckets[last].Start.Equal(start) {
+		s.buckets[last].Bytes += n
+		return
+	}
+	s.prune(t)
+	s.buckets = append(s.buckets, quotaBucket{Start: start, Bytes: n})
+}
+
+func (s *quotaState) used(t time.Time) uint64 {
+	s.prune(t)
+	var used uint64
+	for _, b := range s.buckets {
+		used += b.Bytes
+	}
+	return used
+}
+
+// Quotas counts the bandwidth used with remote visors and for relaying against bandwidth quotas.
+// Quotas do not apply to exempt remotes (trusted visors of the Manager).
+// If a path is set, usage is persisted so that it survives restarts.
+type Quotas struct {
+	path   string
+	remote map[cipher.PubKey]*quotaState
+	relay  *quotaState
+	exempt func(cipher.PubKey) bool
+	dirty  bool
+	mu     sync.Mutex
+}
+
+// NewQuotas creates Quotas enforcing the given quotas.
+// If path is not empty, previously counted usage is loaded from, and usage is persisted to, the file.
+func NewQuotas(path string, quotas []Quota) (*Quotas, error) {
+	qs := &Quotas{path: path, remote: make(map[cipher.PubKey]*quotaState)}
+	for _, q := range quotas {
+		if err := q.Validate(); err != nil {
+			return nil, err
+		}
+		s := &quotaState{Quota: q}
+		if _, ok := qs.remote[q.Remote]; ok || q.Relay && qs.relay != nil {
+			return nil, fmt.Errorf("duplicate quota for %s", q.key())
+		}
+		if q.Relay {
+			qs.relay = s
+		} else {
+			qs.remote[q.Remote] = s
+		}
+	}
+	if path == "" {
+		return qs, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return qs, nil
+		}
+		return nil, fmt.Errorf("read: %s", err)
+	}
+	var saved map[string][]quotaBucket
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("json: %s", err)
+	}
+	for _, s := range qs.states() {
+		s.buckets = saved[s.key()]
+	}
+	return qs, nil
+}
+
+// states returns the states of the quotas, in order of remote with the relay quota last.
+func (qs *Quotas) states() []*quotaState {
+	states := make([]*quotaState, 0, len(qs.remote)+1)
+	for _, s := range qs.remote {
+		states = append(states, s)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Remote.Hex() < states[j].Remote.Hex() })
+	if qs.relay != nil {
+		states = append(states, qs.relay)
+	}
+	return states
+}
+
+func (qs *Quotas) isExempt(pk cipher.PubKey) bool {
+	return qs.exempt != nil && qs.exempt(pk)
+}
+
+// Allow returns whether packets may be exchanged with the remote.
+func (qs *Quotas) Allow(remote cipher.PubKey) bool {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	s, ok := qs.remote[remote]
+	return !ok || qs.isExempt(remote) || s.used(time.Now()) < s.Bytes
+}
+
+// AllowRelay returns whether packets may be relayed to the next remote.
+func (qs *Quotas) AllowRelay(next cipher.PubKey) bool {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	return qs.relay == nil || qs.isExempt(next) || qs.relay.used(time.Now()) < qs.relay.Bytes
+}
+
+// Add counts bytes exchanged with the remote.
+func (qs *Quotas) Add(remote cipher.PubKey, n uint64) {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	if s, ok := qs.remote[remote]; ok {
+		s.add(time.Now(), n)
+		qs.dirty = true
+	}
+}
+
+// AddRelay counts relayed bytes.
+func (qs *Quotas) AddRelay(n uint64) {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	if qs.relay != nil {
+		qs.relay.add(time.Now(), n)
+		qs.dirty = true
+	}
+}
+
+// Usage returns the usage of each quota, in order of remote with the relay quota last.
+func (qs *Quotas) Usage() []QuotaUsage {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	now := time.Now()
+	usage := make([]QuotaUsage, 0, len(qs.remote)+1)
+	for _, s := range qs.states() {
+		period := s.Period
+		if period == "" {
+			period = QuotaMonthly
+		}
+		used := s.used(now)
+		usage = append(usage, QuotaUsage{
+			Remote:   s.Remote,
+			Relay:    s.Relay,
+			Period:   period,
+			Since:    s.since(now),
+			Used:     used,
+			Limit:    s.Bytes,
+			Exceeded: used >= s.Bytes && (s.Relay || !qs.isExempt(s.Remote)),
+		})
+	}
+	return usage
+}
+
+// Save persists the usage if it changed since it was last saved.
+func (qs *Quotas) Save() error {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	if qs.path == "" || !qs.dirty {
+		return nil
+	}
+	saved := make(map[string][]quotaBucket, len(qs.remote)+1)
+	for _, s := range qs.states() {
+		saved[s.key()] = s.buckets
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("json: %s", err)
+	}
+	tmp := qs.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write: %s", err)
+	}
+	if err := os.Rename(tmp, qs.path); err != nil {
+		return err
+	}
+	qs.dirty = false
+	return nil
+}
+
+// saveQuotas periodically persists the usage of quotas until the context is canceled or the Manager is closed.
+func (tm *Manager) saveQuotas(ctx context.Context) {
+	ticker := time.NewTicker(quotaSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tm.done:
+			return
+		case <-ticker.C:
+			if err := tm.quotas.Save(); err != nil {
+				tm.Logger.WithError(err).Warn("Failed to persist bandwidth quota usage")
+			}
+		}
+	}
+}
+
+// Quotas returns the bandwidth quotas of the Manager.
+func (tm *Manager) Quotas() *Quotas {
+	return tm.quotas
+}
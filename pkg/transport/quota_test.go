@@ -0,0 +1,66 @@
+package transport_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+func TestQuotas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_quotas")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "bandwidth_usage.json")
+
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+	quotas := []transport.Quota{
+		{Remote: pk1, Bytes: 100},
+		{Relay: true, Bytes: 50, Period: transport.QuotaRolling, Window: time.Hour},
+	}
+	qs, err := transport.NewQuotas(path, quotas)
+	require.NoError(t, err)
+
+	qs.Add(pk1, 60)
+	qs.Add(pk2, 1000) // no quota
+	qs.AddRelay(30)
+	assert.True(t, qs.Allow(pk1))
+	assert.True(t, qs.AllowRelay(pk2))
+
+	qs.Add(pk1, 40)
+	qs.AddRelay(20)
+	assert.False(t, qs.Allow(pk1))
+	assert.True(t, qs.Allow(pk2))
+	assert.False(t, qs.AllowRelay(pk2))
+
+	usage := qs.Usage()
+	require.Len(t, usage, 2)
+	assert.Equal(t, pk1, usage[0].Remote)
+	assert.Equal(t, transport.QuotaMonthly, usage[0].Period)
+	assert.Equal(t, uint64(100), usage[0].Used)
+	assert.True(t, usage[0].Exceeded)
+	assert.True(t, usage[1].Relay)
+	assert.Equal(t, uint64(50), usage[1].Used)
+
+	// Usage survives restarts.
+	require.NoError(t, qs.Save())
+	qs, err = transport.NewQuotas(path, quotas)
+	require.NoError(t, err)
+	for i, u := range qs.Usage() {
+		assert.Equal(t, usage[i].Used, u.Used)
+	}
+	assert.False(t, qs.Allow(pk1))
+
+	_, err = transport.NewQuotas("", []transport.Quota{{Remote: pk1, Relay: true, Bytes: 1}})
+	assert.Error(t, err)
+	_, err = transport.NewQuotas("", []transport.Quota{{Remote: pk1, Bytes: 1}, {Remote: pk1, Bytes: 2}})
+	assert.Error(t, err)
+}
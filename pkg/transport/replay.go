@@ -0,0 +1,167 @@
+package transport
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// DefaultNonceWindow is the default duration which settlement request nonces are remembered for.
+const DefaultNonceWindow = 10 * time.Minute
+
+var (
+	// ErrReplayedSettlement occurs when a settlement request with an already seen nonce is received.
+	ErrReplayedSettlement = errors.New("settlement request is replayed")
+
+	// ErrStaleSettlement occurs when the timestamp of a settlement request is outside of the nonce window.
+	ErrStaleSettlement = errors.New("settlement request timestamp is outside of nonce window")
+)
+
+// settlementNonce proves the freshness of a settlement request.
+// The signature covers the entry, the offer (MTU and cipher suites), the nonce and the timestamp,
+// so a nonce can not be moved to a captured request, and the offer can not be downgraded.
+type settlementNonce struct {
+	Nonce     uint64     `json:"nonce"`
+	Timestamp int64      `json:"timestamp"` // unix nanoseconds.
+	Sig       cipher.Sig `json:"sig"`
+}
+
+func newSettlementNonce(entry *Entry, offer []byte, sk cipher.SecKey) (*settlementNonce, error) {
+	n := &settlementNonce{
+		Nonce:     binary.BigEndian.Uint64(cipher.RandByte(8)),
+		Timestamp: time.Now().UnixNano(),
+	}
+	sig, err := cipher.SignPayload(n.payload(entry, offer), sk)
+	if err != nil {
+		return nil, err
+	}
+	n.Sig = sig
+	return n, nil
+}
+
+func (n *settlementNonce) payload(entry *Entry, offer []byte) []byte {
+	b := entry.ToBinary()
+	var nb [16]byte
+	binary.BigEndian.PutUint64(nb[:8], n.Nonce)
+	binary.BigEndian.PutUint64(nb[8:], uint64(n.Timestamp))
+	b = append(b, nb[:]...)
+	return append(b, offer...)
+}
+
+func (n *settlementNonce) verify(entry *Entry, offer []byte, pk cipher.PubKey) error {
+	return cipher.VerifyPubKeySignedPayload(pk, n.Sig, n.payload(entry, offer))
+}
+
+type nonceRecord struct {
+	PK        cipher.PubKey `json:"pk"`
+	Nonce     uint64        `json:"nonce"`
+	Timestamp int64         `json:"timestamp"`
+}
+
+type nonceKey struct {
+	pk    cipher.PubKey
+	nonce uint64
+}
+
+// NonceWindow remembers the nonces of settlement requests received within a sliding window of time,
+// so that captured settlement requests can not be replayed. Requests with timestamps outside of the
+// window are rejected, so nonces only need to be remembered for the duration of the window.
+// If a path is set, the window is persisted so that it survives restarts.
+type NonceWindow struct {
+	path   string
+	size   time.Duration
+	nonces map[nonceKey]int64 // timestamps of seen nonces.
+	mu     sync.Mutex
+}
+
+// NewNonceWindow creates a NonceWindow of the given size (DefaultNonceWindow if 0).
+// If path is not empty, previously seen nonces are loaded from, and newly seen nonces are persisted to, the file.
+func NewNonceWindow(path string, size time.Duration) (*NonceWindow, error) {
+	if size <= 0 {
+		size = DefaultNonceWindow
+	}
+	w := &NonceWindow{path: path, size: size, nonces: make(map[nonceKey]int64)}
+	if path == "" {
+		return w, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return w, nil
+		}
+		return nil, fmt.Errorf("read: %s", err)
+	}
+	var records []nonceRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("json: %s", err)
+	}
+	for _, r := range records {
+		w.nonces[nonceKey{pk: r.PK, nonce: r.Nonce}] = r.Timestamp
+	}
+	return w, nil
+}
+
+// Check records the nonce of the given remote, and returns an error if the nonce was already seen or
+// the timestamp is outside of the window.
+func (w *NonceWindow) Check(pk cipher.PubKey, nonce uint64, timestamp int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if ts := time.Unix(0, timestamp); ts.Before(now.Add(-w.size)) || ts.After(now.Add(w.size)) {
+		return ErrStaleSettlement
+	}
+	w.prune(now)
+
+	key := nonceKey{pk: pk, nonce: nonce}
+	if _, ok := w.nonces[key]; ok {
+		return ErrReplayedSettlement
+	}
+	w.nonces[key] = timestamp
+
+	if err := w.save(); err != nil {
+		log.WithError(err).Warn("Failed to persist settlement nonce window")
+	}
+	return nil
+}
+
+// prune removes nonces with timestamps which are outside of the window.
+func (w *NonceWindow) prune(now time.Time) {
+	min := now.Add(-w.size).UnixNano()
+	for key, ts := range w.nonces {
+		if ts < min {
+			delete(w.nonces, key)
+		}
+	}
+}
+
+func (w *NonceWindow) save() error {
+	if w.path == "" {
+		return nil
+	}
+	records := make([]nonceRecord, 0, len(w.nonces))
+	for key, ts := range w.nonces {
+		records = append(records, nonceRecord{PK: key.pk, Nonce: key.nonce, Timestamp: ts})
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("json: %s", err)
+	}
+	tmp := w.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write: %s", err)
+	}
+	return os.Rename(tmp, w.path)
+}
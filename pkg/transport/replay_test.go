@@ -0,0 +1,178 @@
+package transport_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/snettest"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+// recordConn records all data written to the underlying connection.
+type recordConn struct {
+	net.Conn
+	written bytes.Buffer
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	c.written.Write(b)
+	return c.Conn.Write(b)
+}
+
+func TestSettlementHS_Replay(t *testing.T) {
+	tpDisc := transport.NewDiscoveryMock()
+
+	keys := snettest.GenKeyPairs(2)
+	nEnv := snettest.NewEnv(t, keys)
+	defer nEnv.Teardown()
+
+	dir, err := ioutil.TempDir("", "nonces")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	nonceFile := filepath.Join(dir, "nonces.json")
+
+	nonces, err := transport.NewNonceWindow(nonceFile, 0)
+	require.NoError(t, err)
+
+	const port = skyenv.DmsgTransportPort + 10
+	lis1, err := nEnv.Nets[1].Listen(dmsg.Type, port)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, lis1.Close()) }()
+
+	respond := func(requireNonce bool) <-chan error {
+		errCh := make(chan error, 1)
+		go func() {
+			defer close(errCh)
+			conn1, err := lis1.AcceptConn()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			defer func() { _ = conn1.Close() }() // nolint:errcheck
+			_, err = transport.MakeSettlementHS(false, transport.SettlementConfig{Nonces: nonces, RequireNonce: requireNonce}).
+				Do(context.TODO(), tpDisc, conn1, keys[1].SK)
+			errCh <- err
+		}()
+		return errCh
+	}
+
+	// replay writes a recorded settlement request over a new connection and returns the first byte of the response.
+	// The connection is kept open until done is closed, if non-nil.
+	replay := func(port uint16, req []byte, done <-chan struct{}) byte {
+		conn, err := nEnv.Nets[0].Dial(context.TODO(), dmsg.Type, keys[1].PK, port)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, conn.Close()) }()
+
+		_, err = conn.Write(req)
+		require.NoError(t, err)
+		resp := make([]byte, 1)
+		_, err = io.ReadFull(conn, resp)
+		require.NoError(t, err)
+		if done != nil {
+			go io.Copy(ioutil.Discard, conn) // nolint:errcheck
+			<-done
+		}
+		return resp[0]
+	}
+
+	// Record a successful settlement request.
+	errCh := respond(false)
+	conn0, err := nEnv.Nets[0].Dial(context.TODO(), dmsg.Type, keys[1].PK, port)
+	require.NoError(t, err)
+	rec := &recordConn{Conn: conn0}
+	_, err = transport.MakeSettlementHS(true, transport.SettlementConfig{}).
+		Do(context.TODO(), tpDisc, conn0.WithConn(rec), keys[0].SK)
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+	require.NoError(t, conn0.Close())
+	recorded := rec.written.Bytes()
+
+	// TEST: Replaying the settlement request is rejected.
+	t.Run("SameWindow", func(t *testing.T) {
+		errCh := respond(false)
+		assert.Equal(t, byte(0), replay(port, recorded, nil))
+		assert.Equal(t, transport.ErrReplayedSettlement, <-errCh)
+	})
+
+	var req map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(recorded, &req))
+	delete(req, "nonce")
+	stripped, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	// TEST: Stripping the nonce from the settlement request does not bypass the check if nonces are required.
+	t.Run("StrippedNonce", func(t *testing.T) {
+		errCh := respond(true)
+		assert.Equal(t, byte(0), replay(port, stripped, nil))
+		assert.Error(t, <-errCh)
+	})
+
+	// TEST: Settlement requests of legacy visors, which do not contain nonces, are accepted unless nonces are required.
+	t.Run("LegacyInitiator", func(t *testing.T) {
+		errCh := respond(false)
+		done := make(chan struct{})
+		var err error
+		go func() {
+			err = <-errCh
+			close(done)
+		}()
+		assert.NotEqual(t, byte(0), replay(port, stripped, done))
+		assert.NoError(t, err)
+	})
+
+	// TEST: Replaying the settlement request against a fresh manager, which loads the persisted window, is rejected.
+	t.Run("FreshManager", func(t *testing.T) {
+		m, err := transport.NewManager(nEnv.Nets[1], &transport.ManagerConfig{
+			PubKey:          keys[1].PK,
+			SecKey:          keys[1].SK,
+			DiscoveryClient: transport.NewDiscoveryMock(),
+			LogStore:        transport.InMemoryTransportLogStore(),
+			NonceFile:       nonceFile,
+		})
+		require.NoError(t, err)
+		go m.Serve(context.TODO())
+		defer func() { require.NoError(t, m.Close()) }()
+
+		// Wait for the manager to listen.
+		testhelpers.Eventually(t, func() bool {
+			conn, err := nEnv.Nets[0].Dial(context.TODO(), dmsg.Type, keys[1].PK, skyenv.DmsgTransportPort)
+			if err != nil {
+				return false
+			}
+			_ = conn.Close() // nolint:errcheck
+			return true
+		}, 5*time.Second, 50*time.Millisecond)
+		assert.Equal(t, byte(0), replay(skyenv.DmsgTransportPort, recorded, nil))
+
+		tpID := transport.MakeTransportID(keys[0].PK, keys[1].PK, dmsg.Type)
+		assert.Nil(t, m.Transport(tpID))
+	})
+}
+
+func TestNonceWindow(t *testing.T) {
+	w, err := transport.NewNonceWindow("", time.Minute)
+	require.NoError(t, err)
+
+	keys := snettest.GenKeyPairs(2)
+	now := time.Now().UnixNano()
+
+	require.NoError(t, w.Check(keys[0].PK, 1, now))
+	assert.Equal(t, transport.ErrReplayedSettlement, w.Check(keys[0].PK, 1, now))
+	assert.NoError(t, w.Check(keys[1].PK, 1, now))
+	assert.Equal(t, transport.ErrStaleSettlement, w.Check(keys[0].PK, 2, now-int64(2*time.Minute)))
+	assert.Equal(t, transport.ErrStaleSettlement, w.Check(keys[0].PK, 2, now+int64(2*time.Minute)))
+}
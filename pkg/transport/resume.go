@@ -0,0 +1,167 @@
+package transport
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// Resumption defaults.
+const (
+	// DefaultResumeBufferSize is the default number of bytes of sent packets retained by a transport, to be
+	// retransmitted if the underlying connection fails before the remote receives them.
+	DefaultResumeBufferSize = 1 << 20
+
+	// DefaultResumeWindow is the duration after an underlying connection fails during which packets written to the
+	// transport are buffered (rather than failing), to be sent once the transport is resumed.
+	DefaultResumeWindow = 30 * time.Second
+)
+
+// resumeStateSize is the size of an encoded ResumeState.
+const resumeStateSize = 24
+
+// ResumeState describes the packet stream of a transport, as seen by one of its edges.
+// It is exchanged in the settlement handshake so that the packets which were lost when the previous underlying
+// connection failed are retransmitted over the new connection. As underlying connections are reliable and ordered,
+// lost packets are identified by the number of packets received by the remote.
+type ResumeState struct {
+	Session uint64 `json:"session"` // random ID of the local packet stream, chosen when the transport is created.
+	Peer    uint64 `json:"peer"`    // ID of the remote's packet stream, 0 if unknown.
+	Recv    uint64 `json:"recv"`    // number of packets received from the remote's packet stream.
+}
+
+// resumes returns whether the local packet stream continues the remote's, and vice versa.
+// Both edges reach the same result, as they compare the same pairs of IDs.
+func (s ResumeState) resumes(remote ResumeState) bool {
+	return s.Peer != 0 && s.Peer == remote.Session && remote.Peer == s.Session
+}
+
+func (s ResumeState) encode() []byte {
+	b := make([]byte, resumeStateSize)
+	binary.BigEndian.PutUint64(b[0:], s.Session)
+	binary.BigEndian.PutUint64(b[8:], s.Peer)
+	binary.BigEndian.PutUint64(b[16:], s.Recv)
+	return b
+}
+
+func readResumeState(r io.Reader) (*ResumeState, error) {
+	b := make([]byte, resumeStateSize)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, fmt.Errorf("failed to read resume state: %v", err)
+	}
+	return &ResumeState{
+		Session: binary.BigEndian.Uint64(b[0:]),
+		Peer:    binary.BigEndian.Uint64(b[8:]),
+		Recv:    binary.BigEndian.Uint64(b[16:]),
+	}, nil
+}
+
+func newResumeSession() uint64 {
+	for {
+		if id := binary.BigEndian.Uint64(cipher.RandByte(8)); id != 0 {
+			return id
+		}
+	}
+}
+
+// resumeBuffer retains the most recently sent packets of a transport.
+type resumeBuffer struct {
+	max  int              // max number of bytes retained.
+	pkts []routing.Packet // oldest first.
+	size int              // number of bytes retained.
+	next uint64           // number of packets sent, which is also the sequence of the next packet.
+}
+
+// push records a sent packet, dropping the oldest packets if the buffer is full.
+func (b *resumeBuffer) push(p routing.Packet) {
+	b.next++
+	b.pkts = append(b.pkts, p)
+	b.size += len(p)
+	for b.size > b.max && len(b.pkts) > 0 {
+		b.size -= len(b.pkts[0])
+		b.pkts[0] = nil
+		b.pkts = b.pkts[1:]
+	}
+}
+
+// resume returns the packets which have not been received by a remote which received 'recv' packets.
+// Packets which are no longer retained are lost, so the packets are renumbered to follow on from 'recv'.
+func (b *resumeBuffer) resume(recv uint64) (pkts []routing.Packet, lost uint64) {
+	first := b.next - uint64(len(b.pkts))
+	switch {
+	case recv >= b.next:
+		pkts = nil
+	case recv < first:
+		pkts, lost = b.pkts, first-recv
+	default:
+		pkts = b.pkts[recv-first:]
+	}
+	b.pkts = append([]routing.Packet(nil), pkts...)
+	b.size = 0
+	for _, p := range b.pkts {
+		b.size += len(p)
+	}
+	b.next = recv + uint64(len(b.pkts))
+	return b.pkts, lost
+}
+
+func (b *resumeBuffer) reset() {
+	b.pkts, b.size, b.next = nil, 0, 0
+}
+
+// resumeState returns the local resume state, or nil if resumption is disabled.
+// WARNING: Not thread safe, mt.connMx should be locked.
+func (mt *ManagedTransport) resumeState() *ResumeState {
+	if mt.resumeBuf == nil {
+		return nil
+	}
+	return &ResumeState{Session: mt.session, Peer: mt.peerSession, Recv: mt.recvPkts}
+}
+
+// resume retransmits the packets which were not received by the remote over the previous underlying connection,
+// or starts new packet streams if the remote's stream does not continue the previous one (such as after a restart).
+// WARNING: Not thread safe, mt.connMx should be locked and mt.conn should be set.
+func (mt *ManagedTransport) resume(remote *ResumeState) error {
+	if mt.resumeBuf == nil {
+		return nil
+	}
+	local := mt.resumeState()
+	mt.resumable = remote != nil
+	if remote == nil || !local.resumes(*remote) {
+		mt.resumeBuf.reset()
+		mt.recvPkts = 0
+		mt.peerSession = 0
+		if remote != nil {
+			mt.peerSession = remote.Session
+		}
+		return nil
+	}
+
+	pkts, lost := mt.resumeBuf.resume(remote.Recv)
+	if lost > 0 {
+		mt.log.Warnf("resumed transport: %d packets were lost", lost)
+	}
+	for _, p := range pkts {
+		if _, err := mt.conn.Write(p); err != nil {
+			return fmt.Errorf("failed to retransmit packets: %v", err)
+		}
+	}
+	mt.log.Infof("resumed transport: retransmitted %d packets", len(pkts))
+	return nil
+}
+
+// bufferWhileDown records a packet which could not be written as the underlying connection failed, to be sent once
+// the transport is resumed. It returns false if the transport may not be resumed.
+// WARNING: Not thread safe, mt.connMx should be locked.
+func (mt *ManagedTransport) bufferWhileDown(p routing.Packet) bool {
+	if !mt.resumable || time.Since(mt.downSince) > mt.resumeWindow {
+		return false
+	}
+	mt.resumeBuf.push(p)
+	return true
+}
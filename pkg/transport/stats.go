@@ -0,0 +1,148 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/geo"
+)
+
+// statsUploadTimeout is the timeout of a single upload of transport statistics.
+const statsUploadTimeout = 30 * time.Second
+
+// Stats are the bandwidth and uptime statistics of a transport, as observed by one of it's edges.
+// Values are cumulative since the transport was created locally, so lost uploads do not lose data.
+type Stats struct {
+	ID        uuid.UUID     `json:"t_id"`
+	Reporter  cipher.PubKey `json:"reporter"`
+	SentBytes uint64        `json:"sent"`
+	RecvBytes uint64        `json:"recv"`
+	Uptime    uint64        `json:"uptime"` // seconds the transport was up.
+	Timestamp int64         `json:"timestamp"`
+	Location  *geo.Location `json:"location,omitempty"`       // self-reported location of the reporter.
+	Reward    string        `json:"reward_address,omitempty"` // Skycoin address of the reporter's rewards.
+
+	// Packets lost by the network, which were repaired or not by forward error correction (see FECConfig).
+	FECRepaired uint64 `json:"fec_repaired,omitempty"`
+	FECLost     uint64 `json:"fec_lost,omitempty"`
+}
+
+// ToBinary returns the binary representation of Stats which is signed.
+func (s *Stats) ToBinary() []byte {
+	b := make([]byte, 0, len(s.ID)+len(s.Reporter)+32)
+	b = append(b, s.ID[:]...)
+	b = append(b, s.Reporter[:]...)
+	for _, v := range []uint64{s.SentBytes, s.RecvBytes, s.Uptime, uint64(s.Timestamp)} {
+		var vb [8]byte
+		binary.BigEndian.PutUint64(vb[:], v)
+		b = append(b, vb[:]...)
+	}
+	if s.Location != nil {
+		b = append(b, s.Location.ToBinary()...)
+	}
+	if s.Reward != "" {
+		b = append(b, s.Reward...)
+	}
+	if s.FECRepaired != 0 || s.FECLost != 0 {
+		var vb [16]byte
+		binary.BigEndian.PutUint64(vb[:8], s.FECRepaired)
+		binary.BigEndian.PutUint64(vb[8:], s.FECLost)
+		b = append(b, vb[:]...)
+	}
+	return b
+}
+
+// SignedStats are Stats signed by the reporter.
+type SignedStats struct {
+	Stats
+	Sig cipher.Sig `json:"sig"`
+}
+
+// NewSignedStats signs the stats with the reporter's secret key.
+func NewSignedStats(s Stats, sk cipher.SecKey) (*SignedStats, error) {
+	sig, err := cipher.SignPayload(s.ToBinary(), sk)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedStats{Stats: s, Sig: sig}, nil
+}
+
+// Verify verifies the signature of the reporter.
+func (ss *SignedStats) Verify() error {
+	if ss.Sig.Null() {
+		return errors.New("stats are not signed")
+	}
+	return cipher.VerifyPubKeySignedPayload(ss.Reporter, ss.Sig, ss.ToBinary())
+}
+
+// Uptime returns the total duration which the transport has been up.
+func (mt *ManagedTransport) Uptime() time.Duration {
+	mt.connMx.Lock()
+	defer mt.connMx.Unlock()
+	up := mt.uptime
+	if mt.conn != nil {
+		up += time.Since(mt.upSince)
+	}
+	return up
+}
+
+// Stats returns the current statistics of the transport.
+func (mt *ManagedTransport) Stats() Stats {
+	return Stats{
+		ID:        mt.Entry.ID,
+		Reporter:  mt.n.LocalPK(),
+		SentBytes: atomic.LoadUint64(&mt.LogEntry.SentBytes),
+		RecvBytes: atomic.LoadUint64(&mt.LogEntry.RecvBytes),
+		Uptime:    uint64(mt.Uptime() / time.Second),
+		Timestamp: time.Now().Unix(),
+
+		FECRepaired: atomic.LoadUint64(&mt.fecCounters.repaired),
+		FECLost:     atomic.LoadUint64(&mt.fecCounters.lost),
+	}
+}
+
+// uploadStats periodically signs and uploads the statistics of all transports to transport discovery,
+// until the context is canceled or the Manager is closed.
+func (tm *Manager) uploadStats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tm.done:
+			return
+		case <-ticker.C:
+		}
+
+		var stats []*SignedStats
+		location, reward := tm.Location(), tm.RewardAddress()
+		tm.WalkTransports(func(tp *ManagedTransport) bool {
+			s := tp.Stats()
+			s.Location, s.Reward = location, reward
+			ss, err := NewSignedStats(s, tm.conf.SecKey)
+			if err != nil {
+				tm.Logger.Warnf("Failed to sign stats of transport %s: %v", tp.Entry.ID, err)
+				return true
+			}
+			stats = append(stats, ss)
+			return true
+		})
+		if len(stats) == 0 {
+			continue
+		}
+
+		uploadCtx, cancel := context.WithTimeout(ctx, statsUploadTimeout)
+		if err := tm.conf.DiscoveryClient.UploadStats(uploadCtx, stats...); err != nil {
+			tm.Logger.Warnf("Failed to upload transport stats: %v", err)
+		}
+		cancel()
+	}
+}
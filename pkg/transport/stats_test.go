@@ -0,0 +1,51 @@
+package transport_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/geo"
+)
+
+func TestSignedStats(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	s := transport.Stats{
+		ID:        uuid.New(),
+		Reporter:  pk,
+		SentBytes: 100,
+		RecvBytes: 200,
+		Uptime:    60,
+		Timestamp: time.Now().Unix(),
+	}
+
+	ss, err := transport.NewSignedStats(s, sk)
+	require.NoError(t, err)
+	assert.NoError(t, ss.Verify())
+
+	ss.SentBytes++
+	assert.Error(t, ss.Verify())
+
+	assert.Error(t, (&transport.SignedStats{Stats: s}).Verify())
+
+	// The location of the reporter is signed.
+	s.Location = &geo.Location{Lat: 52.52, Lon: 13.40, Region: "DE/Berlin"}
+	ss, err = transport.NewSignedStats(s, sk)
+	require.NoError(t, err)
+	assert.NoError(t, ss.Verify())
+	ss.Location.Region = "DE/Hamburg"
+	assert.Error(t, ss.Verify())
+
+	// The counters of forward error correction are signed.
+	s.FECRepaired, s.FECLost = 3, 1
+	ss, err = transport.NewSignedStats(s, sk)
+	require.NoError(t, err)
+	assert.NoError(t, ss.Verify())
+	ss.FECLost = 0
+	assert.Error(t, ss.Verify())
+}
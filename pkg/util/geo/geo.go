@@ -0,0 +1,144 @@
+// Package geo describes the self-reported geographic locations of visors.
+package geo
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// DefaultLookupURL is the geo-IP service used to detect locations if no other is configured.
+const DefaultLookupURL = "https://ipapi.co/json/"
+
+// DefaultIPLookupURL is the geo-IP service used to locate other hosts if no other is configured. '{ip}' is replaced
+// by the address of the host.
+const DefaultIPLookupURL = "https://ipapi.co/{ip}/json/"
+
+// Location is a self-reported geographic location, as coordinates, a region name, or both.
+type Location struct {
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Region string  `json:"region,omitempty"`
+}
+
+// Validate checks that the coordinates are in range.
+func (l *Location) Validate() error {
+	if l.Lat < -90 || l.Lat > 90 {
+		return fmt.Errorf("latitude %v is out of range", l.Lat)
+	}
+	if l.Lon < -180 || l.Lon > 180 {
+		return fmt.Errorf("longitude %v is out of range", l.Lon)
+	}
+	return nil
+}
+
+// ToBinary returns the binary representation of the location which is signed.
+func (l *Location) ToBinary() []byte {
+	b := make([]byte, 16, 16+len(l.Region))
+	binary.BigEndian.PutUint64(b[:8], math.Float64bits(l.Lat))
+	binary.BigEndian.PutUint64(b[8:], math.Float64bits(l.Lon))
+	return append(b, l.Region...)
+}
+
+// lookupResponse holds the fields of the responses of common geo-IP services (ipapi.co and ip-api.com).
+type lookupResponse struct {
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Lat        float64 `json:"lat"`
+	Lon        float64 `json:"lon"`
+	Region     string  `json:"region"`
+	RegionName string  `json:"regionName"`
+	Country    string  `json:"country_code"`
+	CountryAlt string  `json:"countryCode"`
+	Error      bool    `json:"error"`
+	Reason     string  `json:"reason"`
+}
+
+// Lookup detects the location of the public IP address of the host with the geo-IP service at url.
+// The region is formatted as '<country code>/<region>'.
+func Lookup(ctx context.Context, url string) (*Location, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geo-IP lookup failed with status %d", resp.StatusCode)
+	}
+
+	var r lookupResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("invalid geo-IP response: %v", err)
+	}
+	if r.Error {
+		return nil, fmt.Errorf("geo-IP lookup failed: %s", r.Reason)
+	}
+	loc := &Location{Lat: r.Latitude, Lon: r.Longitude}
+	if r.Lat != 0 || r.Lon != 0 {
+		loc.Lat, loc.Lon = r.Lat, r.Lon
+	}
+	country, region := r.Country, r.Region
+	if r.CountryAlt != "" {
+		country = r.CountryAlt
+	}
+	if r.RegionName != "" {
+		region = r.RegionName // ip-api.com reports the code of the region as 'region'.
+	}
+	var parts []string
+	for _, s := range []string{country, region} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	loc.Region = strings.Join(parts, "/")
+	if loc.Lat == 0 && loc.Lon == 0 && loc.Region == "" {
+		return nil, errors.New("geo-IP response has no location")
+	}
+	return loc, loc.Validate()
+}
+
+// LookupIP detects the location of the IP address with the geo-IP service at url, in which '{ip}' is replaced by the
+// address.
+func LookupIP(ctx context.Context, url string, ip net.IP) (*Location, error) {
+	if !Locatable(ip) {
+		return nil, fmt.Errorf("address %s can not be located", ip)
+	}
+	return Lookup(ctx, strings.Replace(url, "{ip}", ip.String(), -1))
+}
+
+// privateNets are the address ranges which are not routed on the internet, so that geo-IP services can not locate
+// their addresses.
+var privateNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7"} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// Locatable reports whether the IP address is routed on the internet, so that geo-IP services may locate it.
+func Locatable(ip net.IP) bool {
+	if ip == nil || ip.IsUnspecified() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range privateNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package geo
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	responses := map[string]string{
+		"/ipapi":  `{"latitude": 52.52, "longitude": 13.4, "region": "Berlin", "country_code": "DE"}`,
+		"/ip-api": `{"lat": 52.52, "lon": 13.4, "region": "BE", "regionName": "Berlin", "countryCode": "DE"}`,
+		"/error":  `{"error": true, "reason": "RateLimited"}`,
+		"/empty":  `{}`,
+
+		"/203.0.113.1": `{"lat": 1, "lon": 2}`,
+	}
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, _ = w.Write([]byte(responses[r.URL.Path])) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	want := &Location{Lat: 52.52, Lon: 13.4, Region: "DE/Berlin"}
+	for _, path := range []string{"/ipapi", "/ip-api"} {
+		loc, err := Lookup(context.TODO(), srv.URL+path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, loc, path)
+	}
+	loc, err := LookupIP(context.TODO(), srv.URL+"/{ip}", net.IPv4(203, 0, 113, 1))
+	require.NoError(t, err)
+	assert.Equal(t, "/203.0.113.1", paths[len(paths)-1])
+	assert.Equal(t, &Location{Lat: 1, Lon: 2}, loc)
+	for _, ip := range []string{"127.0.0.1", "0.0.0.0", "10.1.2.3", "192.168.1.1", "fe80::1", "fd00::1"} {
+		assert.False(t, Locatable(net.ParseIP(ip)), ip)
+	}
+	assert.True(t, Locatable(net.ParseIP("2001:db8::1")))
+
+	_, err = Lookup(context.TODO(), srv.URL+"/error")
+	assert.EqualError(t, err, "geo-IP lookup failed: RateLimited")
+	_, err = Lookup(context.TODO(), srv.URL+"/empty")
+	assert.Error(t, err)
+
+	assert.Error(t, (&Location{Lat: 91}).Validate())
+	assert.Error(t, (&Location{Lon: -181}).Validate())
+	assert.NotEqual(t, (&Location{Lat: 1}).ToBinary(), (&Location{Lon: 1}).ToBinary())
+}
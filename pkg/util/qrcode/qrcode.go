@@ -0,0 +1,526 @@
+// Package qrcode encodes short byte strings, such as public keys, as QR codes for terminals and images.
+//
+// Only the byte mode, medium error correction and versions 1 to 9 are implemented, which fit up to 180 bytes.
+package qrcode
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// QuietZone is the width of the light border around the symbol, in modules, which scanners require.
+const QuietZone = 4
+
+// ErrTooLong occurs when encoding more bytes than the largest supported version fits.
+var ErrTooLong = errors.New("data too long for a QR code")
+
+// blockSpec describes the error correction blocks of a version, at medium error correction.
+type blockSpec struct {
+	ecPerBlock     int
+	blocks1, data1 int // blocks of the first group, and their data codewords.
+	blocks2, data2 int // blocks of the second group, and their data codewords.
+}
+
+// mediumBlocks holds the block specs of versions 1 to 9 at index version-1.
+var mediumBlocks = []blockSpec{
+	{10, 1, 16, 0, 0},
+	{16, 1, 28, 0, 0},
+	{26, 1, 44, 0, 0},
+	{18, 2, 32, 0, 0},
+	{24, 2, 43, 0, 0},
+	{16, 4, 27, 0, 0},
+	{18, 4, 31, 0, 0},
+	{22, 2, 38, 2, 39},
+	{22, 3, 36, 2, 37},
+}
+
+// alignmentPositions holds the rows and columns of the alignment patterns of versions 1 to 9 at index version-1.
+var alignmentPositions = [][]int{
+	nil,
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+}
+
+func (s blockSpec) dataCodewords() int {
+	return s.blocks1*s.data1 + s.blocks2*s.data2
+}
+
+// Code is an encoded QR code symbol.
+type Code struct {
+	Version int
+	Size    int      // width and height in modules, without the quiet zone.
+	Modules [][]bool // dark modules, indexed by row then column.
+
+	function [][]bool // modules of function patterns, which are not masked.
+}
+
+// Encode encodes data in byte mode, in the smallest version which fits it.
+func Encode(data []byte) (*Code, error) {
+	version := 0
+	for v, spec := range mediumBlocks {
+		// Mode indicator and 8-bit character count.
+		if 4+8+len(data)*8 <= spec.dataCodewords()*8 {
+			version = v + 1
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+	spec := mediumBlocks[version-1]
+
+	c := newCode(version)
+	c.drawFunctionPatterns()
+	c.drawCodewords(interleave(spec, encodeData(data, spec.dataCodewords())))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask) // masks are undone by applying them again.
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+	return c, nil
+}
+
+func newCode(version int) *Code {
+	size := 17 + 4*version
+	c := &Code{Version: version, Size: size, Modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range c.Modules {
+		c.Modules[i] = make([]bool, size)
+		c.function[i] = make([]bool, size)
+	}
+	return c
+}
+
+// String renders the code with half block characters, two rows of modules per line, including the quiet zone.
+// Dark modules are rendered as spaces for terminals with light text on a dark background, unless invert is set.
+func (c *Code) String(invert bool) string {
+	var b strings.Builder
+	for y := -QuietZone; y < c.Size+QuietZone; y += 2 {
+		for x := -QuietZone; x < c.Size+QuietZone; x++ {
+			top, bottom := c.light(x, y) != invert, c.light(x, y+1) != invert
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Image renders the code with scale pixels per module, including the quiet zone.
+func (c *Code) Image(scale int) image.Image {
+	width := (c.Size + 2*QuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, width, width))
+	for py := 0; py < width; py++ {
+		for px := 0; px < width; px++ {
+			if c.light(px/scale-QuietZone, py/scale-QuietZone) {
+				img.SetGray(px, py, color.Gray{Y: 0xff})
+			}
+		}
+	}
+	return img
+}
+
+// light returns whether the module at column x and row y is light, including the modules of the quiet zone.
+func (c *Code) light(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return true
+	}
+	return !c.Modules[y][x]
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.Modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+// drawFunctionPatterns draws the finder, timing and alignment patterns, and reserves the format and version areas.
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	pos := alignmentPositions[c.Version-1]
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			// Skip the corners of the finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignment(pos[i], pos[j])
+		}
+	}
+
+	c.drawFormatBits(0)
+	c.drawVersionBits()
+}
+
+// drawFinder draws a finder pattern and its separator around the center at column x and row y.
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= c.Size || yy >= c.Size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			c.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignment draws an alignment pattern around the center at column x and row y.
+func (c *Code) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// formatBits returns the 15 bits of format information of the mask at medium error correction.
+func formatBits(mask int) int {
+	data := mask // the bits of medium error correction are 00.
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawFormatBits draws both copies of the format information, and the dark module.
+func (c *Code) drawFormatBits(mask int) {
+	bits := formatBits(mask)
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.Size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(bits, i))
+	}
+	c.setFunction(8, c.Size-8, true)
+}
+
+// versionBits returns the 18 bits of version information of the version.
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// drawVersionBits draws both copies of the version information of versions 7 and up.
+func (c *Code) drawVersionBits() {
+	if c.Version < 7 {
+		return
+	}
+	bits := versionBits(c.Version)
+	for i := 0; i < 18; i++ {
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, bit(bits, i))
+		c.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords places the codewords in the modules which are not function patterns, in upwards and downwards
+// zigzags of two columns from the bottom right corner.
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern.
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				c.Modules[y][x] = bit(int(codewords[i>>3]), 7-(i&7))
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the modules which are not function patterns where the condition of the mask holds.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				c.Modules[y][x] = !c.Modules[y][x]
+			}
+		}
+	}
+}
+
+// finderLike is a run of modules which resembles the finder patterns, as penalized by masks.
+var finderLike = []bool{true, false, true, true, true, false, true, false, false, false, false}
+
+// penalty scores the modules with the rules which select the mask, the lower the better.
+func (c *Code) penalty() int {
+	p := 0
+	at := func(transpose bool, i, j int) bool {
+		if transpose {
+			return c.Modules[j][i]
+		}
+		return c.Modules[i][j]
+	}
+	for _, transpose := range []bool{false, true} {
+		for i := 0; i < c.Size; i++ {
+			// Runs of five or more modules of the same color.
+			run := 1
+			for j := 1; j < c.Size; j++ {
+				if at(transpose, i, j) == at(transpose, i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					p += run - 2
+				}
+				run = 1
+			}
+			if run >= 5 {
+				p += run - 2
+			}
+
+			// Patterns which resemble the finder patterns, preceded or followed by light modules.
+			for j := 0; j+len(finderLike) <= c.Size; j++ {
+				forward, backward := true, true
+				for k, dark := range finderLike {
+					if at(transpose, i, j+k) != dark {
+						forward = false
+					}
+					if at(transpose, i, j+len(finderLike)-1-k) != dark {
+						backward = false
+					}
+				}
+				if forward {
+					p += 40
+				}
+				if backward {
+					p += 40
+				}
+			}
+		}
+	}
+
+	// Blocks of 2x2 modules of the same color.
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Modules[y][x] {
+				dark++
+			}
+			if x > 0 && y > 0 {
+				m := c.Modules[y][x]
+				if m == c.Modules[y-1][x] && m == c.Modules[y][x-1] && m == c.Modules[y-1][x-1] {
+					p += 3
+				}
+			}
+		}
+	}
+
+	// Deviation of the proportion of dark modules from half, in steps of 5%.
+	total := c.Size * c.Size
+	p += abs(dark*20-total*10) / total * 10
+	return p
+}
+
+// encodeData returns the data codewords of data in byte mode, padded to capacity codewords.
+func encodeData(data []byte, capacity int) []byte {
+	var bb bitBuffer
+	bb.append(0x4, 4) // byte mode.
+	bb.append(len(data), 8)
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+	bb.append(0, min(4, capacity*8-len(bb))) // terminator.
+	bb.append(0, (8-len(bb)%8)%8)
+
+	codewords := make([]byte, 0, capacity)
+	for i := 0; i < len(bb); i += 8 {
+		var b byte
+		for _, set := range bb[i : i+8] {
+			b <<= 1
+			if set {
+				b |= 1
+			}
+		}
+		codewords = append(codewords, b)
+	}
+	for pad := byte(0xEC); len(codewords) < capacity; pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+	return codewords
+}
+
+// interleave splits the data codewords into the blocks of the spec, and interleaves them followed by their error
+// correction codewords.
+func interleave(spec blockSpec, data []byte) []byte {
+	var blocks, ecBlocks [][]byte
+	divisor := rsDivisor(spec.ecPerBlock)
+	for i := 0; i < spec.blocks1+spec.blocks2; i++ {
+		n := spec.data1
+		if i >= spec.blocks1 {
+			n = spec.data2
+		}
+		blocks = append(blocks, data[:n])
+		ecBlocks = append(ecBlocks, rsRemainder(data[:n], divisor))
+		data = data[n:]
+	}
+
+	var out []byte
+	for i := 0; i < max(spec.data1, spec.data2); i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < spec.ecPerBlock; i++ {
+		for _, ec := range ecBlocks {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+// rsDivisor returns the coefficients of the Reed-Solomon generator polynomial of the degree, from the highest
+// power, excluding its leading 1.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder returns the Reed-Solomon error correction codewords of data.
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, bit(v, i))
+	}
+}
+
+func bit(v, i int) bool {
+	return (v>>uint(i))&1 != 0
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,157 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// "HELLO WORLD" at version 1 with medium error correction.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	assert.Equal(t, want, rsRemainder(data, rsDivisor(10)))
+}
+
+func TestFormatBits(t *testing.T) {
+	assert.Equal(t, 0x5412, formatBits(0)) // 101010000010010
+	assert.Equal(t, 0x45F9, formatBits(4)) // 100010111111001
+	assert.Equal(t, 0x40CE, formatBits(5)) // 100000011001110
+	assert.Equal(t, 0x4AA0, formatBits(7)) // 100101010100000
+	assert.Equal(t, 0x5125, formatBits(1)) // 101000100100101
+}
+
+func TestVersionBits(t *testing.T) {
+	assert.Equal(t, 0x07C94, versionBits(7))
+	assert.Equal(t, 0x085BC, versionBits(8))
+	assert.Equal(t, 0x09A99, versionBits(9))
+}
+
+func TestEncode(t *testing.T) {
+	pk := "0276ad1c5e77d7945ad6343a3c36a8014f463653b3375b6e02ebeaa3a21d89e881"
+	tests := []struct {
+		data    string
+		version int
+	}{
+		{"skywire", 1},
+		{pk, 5},
+		{strings.Repeat(pk, 2), 8},
+		{strings.Repeat("a", 180), 9},
+	}
+	for _, tt := range tests {
+		c, err := Encode([]byte(tt.data))
+		require.NoError(t, err)
+		assert.Equal(t, tt.version, c.Version)
+		assert.Equal(t, 17+4*tt.version, c.Size)
+		assert.Equal(t, tt.data, string(decode(t, c)))
+	}
+
+	_, err := Encode(make([]byte, 181))
+	assert.Equal(t, ErrTooLong, err)
+}
+
+func TestCode_Image(t *testing.T) {
+	c, err := Encode([]byte("skywire"))
+	require.NoError(t, err)
+	img := c.Image(2)
+	assert.Equal(t, (21+2*QuietZone)*2, img.Bounds().Dx())
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	assert.Equal(t, (21+2*QuietZone+1)/2, strings.Count(c.String(false), "\n"))
+}
+
+// decode reads the data of the code back from its modules, by reversing the steps of Encode.
+func decode(t *testing.T, c *Code) []byte {
+	var bits int
+	for i := 0; i <= 5; i++ {
+		bits |= b2i(c.Modules[i][8]) << uint(i)
+	}
+	bits |= b2i(c.Modules[7][8]) << 6
+	bits |= b2i(c.Modules[8][8]) << 7
+	bits |= b2i(c.Modules[8][7]) << 8
+	for i := 9; i < 15; i++ {
+		bits |= b2i(c.Modules[8][14-i]) << uint(i)
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatBits(m) == bits {
+			mask = m
+		}
+	}
+	require.NotEqual(t, -1, mask, "invalid format bits")
+
+	// Read the codewords by unmasking a copy of the code, and placing the modules back into codewords.
+	spec := mediumBlocks[c.Version-1]
+	total := spec.dataCodewords() + (spec.blocks1+spec.blocks2)*spec.ecPerBlock
+	unmasked := *c
+	unmasked.Modules = make([][]bool, c.Size)
+	for i := range c.Modules {
+		unmasked.Modules[i] = append([]bool(nil), c.Modules[i]...)
+	}
+	unmasked.applyMask(mask)
+	var bb bitBuffer
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if x := right - j; !c.function[y][x] && len(bb) < total*8 {
+					bb = append(bb, unmasked.Modules[y][x])
+				}
+			}
+		}
+	}
+
+	// Deinterleave the data codewords, and check the error correction codewords of each block.
+	codewords := make([]byte, total)
+	for i := range codewords {
+		for k := 0; k < 8; k++ {
+			codewords[i] = codewords[i]<<1 | byte(b2i(bb[i*8+k]))
+		}
+	}
+	blocks := make([][]byte, spec.blocks1+spec.blocks2)
+	i := 0
+	for k := 0; k < max(spec.data1, spec.data2); k++ {
+		for b := range blocks {
+			if (b < spec.blocks1 && k < spec.data1) || (b >= spec.blocks1 && k < spec.data2) {
+				blocks[b] = append(blocks[b], codewords[i])
+				i++
+			}
+		}
+	}
+	var data []byte
+	for b, block := range blocks {
+		ec := make([]byte, spec.ecPerBlock)
+		for k := range ec {
+			ec[k] = codewords[i+k*len(blocks)+b]
+		}
+		require.Equal(t, rsRemainder(block, rsDivisor(spec.ecPerBlock)), ec)
+		data = append(data, block...)
+	}
+
+	require.Equal(t, byte(0x4), data[0]>>4, "byte mode")
+	n := int(data[0]&0xF)<<4 | int(data[1]>>4)
+	out := make([]byte, n)
+	for k := range out {
+		out[k] = data[1+k]<<4 | data[2+k]>>4
+	}
+	return out
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+// Package seal encrypts secrets at rest with a passphrase.
+package seal
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Iterations is the number of PBKDF2 iterations deriving the key of sealed secrets from the passphrase.
+const Iterations = 200000
+
+// Bounds of the iterations of the secrets which are opened, as the iterations are read from the sealed secret. Too
+// few iterations weaken the key, and too many stall opening the secret.
+const (
+	MinIterations = 100000
+	MaxIterations = 10 * Iterations
+)
+
+const (
+	scheme   = "pbkdf2-sha256-chacha20poly1305"
+	saltSize = 16
+)
+
+var (
+	// ErrWrongPassphrase occurs when a sealed secret is opened with the wrong passphrase, or is corrupted.
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	// ErrEmptyPassphrase occurs when sealing a secret with an empty passphrase.
+	ErrEmptyPassphrase = errors.New("empty passphrase")
+)
+
+// Seal encrypts the secret with a key derived from the passphrase.
+// The result is formatted as '<scheme>$<iterations>$<salt>$<nonce and ciphertext>', with values in base64.
+func Seal(secret, passphrase []byte) (string, error) {
+	if len(passphrase) == 0 {
+		return "", ErrEmptyPassphrase
+	}
+	salt := make([]byte, saltSize)
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	aead, err := chacha20poly1305.New(deriveKey(passphrase, salt, Iterations))
+	if err != nil {
+		return "", err
+	}
+	ciphertext := aead.Seal(nonce, nonce, secret, []byte(scheme))
+	return strings.Join([]string{
+		scheme,
+		strconv.Itoa(Iterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(ciphertext),
+	}, "$"), nil
+}
+
+// Open decrypts a secret sealed by Seal.
+func Open(sealed string, passphrase []byte) ([]byte, error) {
+	parts := strings.Split(sealed, "$")
+	if len(parts) != 4 || parts[0] != scheme {
+		return nil, fmt.Errorf("invalid sealed secret: unknown format")
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter < MinIterations || iter > MaxIterations {
+		return nil, fmt.Errorf("invalid sealed secret: iterations '%s' (must be within %d and %d)",
+			parts[1], MinIterations, MaxIterations)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid sealed secret: %v", err)
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid sealed secret: %v", err)
+	}
+	if len(ciphertext) < chacha20poly1305.NonceSize {
+		return nil, fmt.Errorf("invalid sealed secret: ciphertext too short")
+	}
+
+	aead, err := chacha20poly1305.New(deriveKey(passphrase, salt, iter))
+	if err != nil {
+		return nil, err
+	}
+	nonce, ciphertext := ciphertext[:chacha20poly1305.NonceSize], ciphertext[chacha20poly1305.NonceSize:]
+	secret, err := aead.Open(nil, nonce, ciphertext, []byte(scheme))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return secret, nil
+}
+
+// deriveKey derives a key of chacha20poly1305.KeySize bytes with PBKDF2-HMAC-SHA256 (RFC 8018), which only needs a
+// single block as the key is no longer than the hash.
+func deriveKey(passphrase, salt []byte, iter int) []byte {
+	prf := hmac.New(sha256.New, passphrase)
+	var block [4]byte
+	binary.BigEndian.PutUint32(block[:], 1)
+
+	prf.Write(salt)     //nolint:errcheck
+	prf.Write(block[:]) //nolint:errcheck
+	u := prf.Sum(nil)
+	key := append([]byte(nil), u...)
+	for i := 1; i < iter; i++ {
+		prf.Reset()
+		prf.Write(u) //nolint:errcheck
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key[:chacha20poly1305.KeySize]
+}
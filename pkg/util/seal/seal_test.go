@@ -0,0 +1,45 @@
+package seal
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeal(t *testing.T) {
+	secret := []byte("secret key")
+	sealed, err := Seal(secret, []byte("passphrase"))
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, string(secret))
+
+	opened, err := Open(sealed, []byte("passphrase"))
+	require.NoError(t, err)
+	assert.Equal(t, secret, opened)
+
+	_, err = Open(sealed, []byte("wrong"))
+	assert.Equal(t, ErrWrongPassphrase, err)
+
+	_, err = Open(strings.Replace(sealed, scheme, "other", 1), []byte("passphrase"))
+	assert.Error(t, err)
+
+	// Iterations out of bounds are rejected before deriving the key.
+	for _, iter := range []int{1, MinIterations - 1, MaxIterations + 1, 1 << 40} {
+		tampered := strings.Replace(sealed, "$"+strconv.Itoa(Iterations)+"$", "$"+strconv.Itoa(iter)+"$", 1)
+		_, err = Open(tampered, []byte("passphrase"))
+		assert.Error(t, err)
+		assert.NotEqual(t, ErrWrongPassphrase, err)
+	}
+
+	_, err = Seal(secret, nil)
+	assert.Equal(t, ErrEmptyPassphrase, err)
+}
+
+// Test vector of PBKDF2-HMAC-SHA256 from RFC 7914, section 11.
+func TestDeriveKey(t *testing.T) {
+	key := deriveKey([]byte("passwd"), []byte("salt"), 1)
+	assert.Equal(t, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc", hex.EncodeToString(key))
+}
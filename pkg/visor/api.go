@@ -0,0 +1,382 @@
+package visor
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// APIVersion is the version of the REST API of the visor, which prefixes its paths.
+const APIVersion = "v1"
+
+// ErrUnauthorized occurs when a REST API request does not carry the configured token.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// RESTAPIConfig configures the REST API of the visor.
+type RESTAPIConfig struct {
+	Address string `json:"address"`         // TCP address to serve the REST API on.
+	Token   string `json:"token,omitempty"` // if set, requests must carry it as 'Authorization: Bearer <token>'.
+}
+
+// RESTAPIHandler returns the REST API of the visor, which allows non-Go tooling to manage the visor with HTTP/JSON.
+// Paths are prefixed with '/api/<APIVersion>', and requests must carry the given token if it is not empty.
+func (node *Node) RESTAPIHandler(token string) http.Handler {
+	api := &restAPI{node: node, rpc: &RPC{node: node}}
+
+	r := chi.NewRouter()
+	r.Route("/api/"+APIVersion, func(r chi.Router) {
+		if token != "" {
+			r.Use(authorizeToken(token, node.recordAuthFailure))
+		}
+		r.Get("/health", api.getHealth)
+		r.Get("/uptime", api.getUptime)
+		r.Get("/summary", api.getSummary)
+		r.Get("/apps", api.getApps)
+		r.Get("/apps/{app}", api.getApp)
+		r.Put("/apps/{app}", api.putApp)
+		r.Get("/transports", api.getTransports)
+		r.Post("/transports", api.postTransport)
+		r.Get("/transports/{tid}", api.getTransport)
+		r.Delete("/transports/{tid}", api.deleteTransport)
+		r.Get("/routes", api.getRoutes)
+		r.Post("/routes", api.postRoute)
+		r.Get("/routes/{rid}", api.getRoute)
+		r.Put("/routes/{rid}", api.putRoute)
+		r.Delete("/routes/{rid}", api.deleteRoute)
+	})
+	return r
+}
+
+// ServeRESTAPI serves the REST API on the address of the 'rest_api' config, until the node is closed.
+func (node *Node) ServeRESTAPI() error {
+	if node.conf.RESTAPI == nil {
+		return errors.New("'rest_api' config field not defined")
+	}
+	l, err := net.Listen("tcp", node.conf.RESTAPI.Address)
+	if err != nil {
+		return err
+	}
+	node.httpMu.Lock()
+	node.apiListener = l
+	node.httpMu.Unlock()
+
+	node.logger.Infof("Serving REST API on %s", l.Addr())
+	return http.Serve(l, node.RESTAPIHandler(node.conf.RESTAPI.Token))
+}
+
+func authorizeToken(token string, failed func(r *http.Request)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				failed(r)
+				httputil.WriteJSON(w, r, http.StatusUnauthorized, ErrUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type restAPI struct {
+	node *Node
+	rpc  *RPC
+}
+
+func (api *restAPI) getHealth(w http.ResponseWriter, r *http.Request) {
+	var health HealthInfo
+	if err := api.rpc.Health(nil, &health); err != nil {
+		httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, health)
+}
+
+func (api *restAPI) getUptime(w http.ResponseWriter, r *http.Request) {
+	var uptime float64
+	if err := api.rpc.Uptime(nil, &uptime); err != nil {
+		httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, uptime)
+}
+
+func (api *restAPI) getSummary(w http.ResponseWriter, r *http.Request) {
+	var summary Summary
+	if err := api.rpc.Summary(nil, &summary); err != nil {
+		httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, summary)
+}
+
+func (api *restAPI) getApps(w http.ResponseWriter, r *http.Request) {
+	httputil.WriteJSON(w, r, http.StatusOK, api.node.Apps())
+}
+
+func (api *restAPI) app(w http.ResponseWriter, r *http.Request) (*AppState, bool) {
+	name := chi.URLParam(r, "app")
+	for _, app := range api.node.Apps() {
+		if app.Name == name {
+			return app, true
+		}
+	}
+	httputil.WriteJSON(w, r, http.StatusNotFound, fmt.Errorf("can not find app of name %s", name))
+	return nil, false
+}
+
+func (api *restAPI) getApp(w http.ResponseWriter, r *http.Request) {
+	if app, ok := api.app(w, r); ok {
+		httputil.WriteJSON(w, r, http.StatusOK, app)
+	}
+}
+
+func (api *restAPI) putApp(w http.ResponseWriter, r *http.Request) {
+	app, ok := api.app(w, r)
+	if !ok {
+		return
+	}
+	var reqBody struct {
+		Autostart *bool      `json:"autostart,omitempty"`
+		Status    *AppStatus `json:"status,omitempty"`
+	}
+	if err := httputil.ReadJSON(r, &reqBody); err != nil {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if reqBody.Autostart != nil && *reqBody.Autostart != app.AutoStart {
+		if err := api.node.SetAutoStart(app.Name, *reqBody.Autostart); err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if reqBody.Status != nil {
+		var err error
+		switch *reqBody.Status {
+		case AppStatusStopped:
+			err = api.node.StopApp(app.Name)
+		case AppStatusRunning:
+			err = api.node.StartApp(app.Name)
+		default:
+			httputil.WriteJSON(w, r, http.StatusBadRequest,
+				fmt.Errorf("value of 'status' field is %d when expecting 0 or 1", *reqBody.Status))
+			return
+		}
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	app, _ = api.app(w, r)
+	httputil.WriteJSON(w, r, http.StatusOK, app)
+}
+
+func (api *restAPI) getTransports(w http.ResponseWriter, r *http.Request) {
+	qLogs, err := httputil.BoolFromQuery(r, "logs", true)
+	if err != nil {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+		return
+	}
+	in := TransportsIn{ShowLogs: qLogs}
+	if types := r.URL.Query()["type"]; len(types) > 0 {
+		in.FilterTypes = types
+	}
+	transports := make([]*TransportSummary, 0)
+	if err := api.rpc.Transports(&in, &transports); err != nil {
+		httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, transports)
+}
+
+func (api *restAPI) postTransport(w http.ResponseWriter, r *http.Request) {
+	var reqBody struct {
+		Remote cipher.PubKey `json:"remote_pk"`
+		TpType string        `json:"transport_type"`
+		Public bool          `json:"public"`
+		Labels []string      `json:"labels"`
+	}
+	if err := httputil.ReadJSON(r, &reqBody); err != nil {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+		return
+	}
+	in := AddTransportIn{
+		RemotePK: reqBody.Remote,
+		TpType:   reqBody.TpType,
+		Public:   reqBody.Public,
+		Timeout:  30 * time.Second,
+		Labels:   reqBody.Labels,
+	}
+	var summary TransportSummary
+	if err := api.rpc.AddTransport(&in, &summary); err != nil {
+		httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, summary)
+}
+
+func (api *restAPI) transportID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	tid, err := uuid.Parse(chi.URLParam(r, "tid"))
+	if err != nil {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+		return tid, false
+	}
+	return tid, true
+}
+
+func (api *restAPI) getTransport(w http.ResponseWriter, r *http.Request) {
+	tid, ok := api.transportID(w, r)
+	if !ok {
+		return
+	}
+	var summary TransportSummary
+	if err := api.rpc.Transport(&tid, &summary); err != nil {
+		httputil.WriteJSON(w, r, http.StatusNotFound, err)
+		return
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, summary)
+}
+
+func (api *restAPI) deleteTransport(w http.ResponseWriter, r *http.Request) {
+	tid, ok := api.transportID(w, r)
+	if !ok {
+		return
+	}
+	if err := api.rpc.RemoveTransport(&tid, nil); err != nil {
+		httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, true)
+}
+
+// routeResp is a routing rule, as returned by the REST API.
+type routeResp struct {
+	Key     routing.RouteID      `json:"key"`
+	Rule    string               `json:"rule"`
+	Summary *routing.RuleSummary `json:"rule_summary,omitempty"`
+}
+
+func makeRouteResp(key routing.RouteID, rule routing.Rule, summary bool) routeResp {
+	resp := routeResp{Key: key, Rule: hex.EncodeToString(rule)}
+	if summary {
+		resp.Summary = rule.Summary()
+	}
+	return resp
+}
+
+func (api *restAPI) routeID(w http.ResponseWriter, r *http.Request) (routing.RouteID, bool) {
+	rid, err := strconv.ParseUint(chi.URLParam(r, "rid"), 10, 32)
+	if err != nil {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+		return 0, false
+	}
+	return routing.RouteID(rid), true
+}
+
+func (api *restAPI) readRule(w http.ResponseWriter, r *http.Request) (routing.Rule, bool) {
+	var summary routing.RuleSummary
+	if err := httputil.ReadJSON(r, &summary); err != nil {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+		return nil, false
+	}
+	rule, err := summary.ToRule()
+	if err != nil {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+		return nil, false
+	}
+	return rule, true
+}
+
+func (api *restAPI) getRoutes(w http.ResponseWriter, r *http.Request) {
+	qSummary, err := httputil.BoolFromQuery(r, "summary", false)
+	if err != nil {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+		return
+	}
+	var rules []*RoutingEntry
+	if err := api.rpc.RoutingRules(nil, &rules); err != nil {
+		httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	resp := make([]routeResp, len(rules))
+	for i, rule := range rules {
+		resp[i] = makeRouteResp(rule.Key, rule.Value, qSummary)
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, resp)
+}
+
+func (api *restAPI) postRoute(w http.ResponseWriter, r *http.Request) {
+	rule, ok := api.readRule(w, r)
+	if !ok {
+		return
+	}
+	var rid routing.RouteID
+	if err := api.rpc.AddRoutingRule(&rule, &rid); err != nil {
+		httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, makeRouteResp(rid, rule, true))
+}
+
+func (api *restAPI) getRoute(w http.ResponseWriter, r *http.Request) {
+	rid, ok := api.routeID(w, r)
+	if !ok {
+		return
+	}
+	qSummary, err := httputil.BoolFromQuery(r, "summary", true)
+	if err != nil {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+		return
+	}
+	var rule routing.Rule
+	if err := api.rpc.RoutingRule(&rid, &rule); err != nil {
+		httputil.WriteJSON(w, r, http.StatusNotFound, err)
+		return
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, makeRouteResp(rid, rule, qSummary))
+}
+
+func (api *restAPI) putRoute(w http.ResponseWriter, r *http.Request) {
+	rid, ok := api.routeID(w, r)
+	if !ok {
+		return
+	}
+	rule, ok := api.readRule(w, r)
+	if !ok {
+		return
+	}
+	if err := api.rpc.SetRoutingRule(&RoutingEntry{Key: rid, Value: rule}, nil); err != nil {
+		httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, makeRouteResp(rid, rule, true))
+}
+
+func (api *restAPI) deleteRoute(w http.ResponseWriter, r *http.Request) {
+	rid, ok := api.routeID(w, r)
+	if !ok {
+		return
+	}
+	if err := api.rpc.RemoveRoutingRule(&rid, nil); err != nil {
+		httputil.WriteJSON(w, r, http.StatusNotFound, err)
+		return
+	}
+	httputil.WriteJSON(w, r, http.StatusOK, true)
+}
+
+func (node *Node) recordAuthFailure(r *http.Request) {
+	node.RecordEvent(EventAuthFailed, r.RemoteAddr, "REST API %s %s", r.Method, r.URL.Path)
+}
@@ -0,0 +1,74 @@
+package visor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+func TestRESTAPI(t *testing.T) {
+	apps := []AppConfig{{App: "foo", Version: "1.0", AutoStart: false, Port: routing.Port(10)}}
+	node := &Node{conf: &Config{}, appsConf: apps, startedApps: map[string]*appBind{}}
+	srv := httptest.NewServer(node.RESTAPIHandler("secret"))
+	defer srv.Close()
+
+	do := func(method, path, token, body string) *http.Response {
+		req, err := http.NewRequest(method, srv.URL+"/api/"+APIVersion+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		for _, token := range []string{"", "wrong"} {
+			resp := do(http.MethodGet, "/apps", token, "")
+			require.NoError(t, resp.Body.Close())
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("Apps", func(t *testing.T) {
+		resp := do(http.MethodGet, "/apps", "secret", "")
+		defer func() { require.NoError(t, resp.Body.Close()) }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var states []*AppState
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&states))
+		require.Len(t, states, 1)
+		assert.Equal(t, &AppState{Name: "foo", AutoStart: false, Port: 10, Status: AppStatusStopped}, states[0])
+	})
+
+	t.Run("SetAutoStart", func(t *testing.T) {
+		resp := do(http.MethodPut, "/apps/foo", "secret", `{"autostart":true}`)
+		defer func() { require.NoError(t, resp.Body.Close()) }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var state AppState
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+		assert.True(t, state.AutoStart)
+		assert.True(t, node.appConfigs()[0].AutoStart)
+	})
+
+	t.Run("UnknownApp", func(t *testing.T) {
+		resp := do(http.MethodGet, "/apps/bar", "secret", "")
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("InvalidTransportID", func(t *testing.T) {
+		resp := do(http.MethodGet, "/transports/foo", "secret", "")
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
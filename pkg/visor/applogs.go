@@ -0,0 +1,167 @@
+package visor
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
+)
+
+// MaxAppLogChunk bounds the size of the data returned by AppLog, so that large logs are read in chunks.
+const MaxAppLogChunk = 1 << 20
+
+// ErrAppLogNotFound occurs when reading a log file which the app does not have.
+var ErrAppLogNotFound = errors.New("app log file not found")
+
+// AppLogFile is a log file of an app, within the directory in which the app runs.
+type AppLogFile struct {
+	Name    string    `json:"name"` // slash-separated path relative to the directory of the app.
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// AppLogIn is input of AppLog.
+type AppLogIn struct {
+	App    string    `json:"app"`
+	File   string    `json:"file,omitempty"`   // name of a log file of AppLogFiles, or the log store of the app if empty.
+	From   time.Time `json:"from,omitempty"`   // only logs of the log store since, unbounded if zero.
+	To     time.Time `json:"to,omitempty"`     // only logs of the log store before, unbounded if zero.
+	Offset int64     `json:"offset,omitempty"` // bytes of the file, or lines of the log store, which were read.
+}
+
+// AppLogOut is output of AppLog.
+type AppLogOut struct {
+	Data []byte `json:"data"`
+	Size int64  `json:"size"` // size of the file, or -1 for the log store.
+	Next int64  `json:"next"` // Offset of the next call, or -1 once everything is read.
+}
+
+// isAppLogFile reports whether the file in the directory of an app is a log file, including rotated log files.
+func isAppLogFile(name string) bool {
+	return strings.HasSuffix(name, ".log") || strings.Contains(name, ".log.")
+}
+
+// AppLogFiles returns the log files in the directory in which the app runs, sorted by name.
+func (node *Node) AppLogFiles(name string) ([]AppLogFile, error) {
+	dir, err := node.appDir(name)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]AppLogFile, 0)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() || !isAppLogFile(info.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, AppLogFile{Name: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, err
+}
+
+// AppLog reads a chunk of a log file of the app, or of the logs of its log store within the time range.
+func (node *Node) AppLog(in AppLogIn) (*AppLogOut, error) {
+	dir, err := node.appDir(in.App)
+	if err != nil {
+		return nil, err
+	}
+	if in.File == "" {
+		ls, err := app.NewLogStore(filepath.Join(node.dir(), in.App), in.App, "bbolt")
+		if err != nil {
+			return nil, err
+		}
+		logs, err := ls.LogsSince(in.From)
+		if err != nil {
+			return nil, err
+		}
+		return readLogLines(logs, in), nil
+	}
+
+	// Only listed files are read, so that names may not escape the directory of the app.
+	files, err := node.AppLogFiles(in.App)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.Name == in.File {
+			file, err := os.Open(filepath.Join(dir, filepath.FromSlash(f.Name))) //nolint:gosec
+			if err != nil {
+				return nil, err
+			}
+			defer func() { _ = file.Close() }() //nolint:errcheck
+			return readLogFile(file, f.Size, in.Offset)
+		}
+	}
+	return nil, ErrAppLogNotFound
+}
+
+// appDir returns the directory in which the app runs.
+func (node *Node) appDir(name string) (string, error) {
+	for _, ac := range node.appConfigs() {
+		if ac.App == name {
+			return filepath.Join(node.localPath, name), nil
+		}
+	}
+	return "", ErrUnknownApp
+}
+
+// readLogFile reads a chunk of a log file of the size from the offset.
+func readLogFile(r io.ReaderAt, size, offset int64) (*AppLogOut, error) {
+	out := &AppLogOut{Size: size, Next: -1}
+	if offset < 0 || offset >= size {
+		out.Data = []byte{}
+		return out, nil
+	}
+	n := size - offset
+	if n > MaxAppLogChunk {
+		n = MaxAppLogChunk
+	}
+	out.Data = make([]byte, n)
+	if _, err := r.ReadAt(out.Data, offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if offset+n < size {
+		out.Next = offset + n
+	}
+	return out, nil
+}
+
+// readLogLines reads a chunk of the lines of app logs since in.From which are before in.To, skipping the lines
+// which were read.
+func readLogLines(logs []string, in AppLogIn) *AppLogOut {
+	out := &AppLogOut{Data: []byte{}, Size: -1, Next: -1}
+	var n int64
+	for _, line := range logs {
+		t := appLogTime(line)
+		if !in.From.IsZero() && t.Before(in.From) || !in.To.IsZero() && !t.Before(in.To) {
+			continue
+		}
+		if n++; n <= in.Offset {
+			continue
+		}
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		if len(out.Data) > 0 && len(out.Data)+len(line) > MaxAppLogChunk {
+			out.Next = n - 1
+			break
+		}
+		out.Data = append(out.Data, line...)
+	}
+	return out
+}
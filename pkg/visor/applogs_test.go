@@ -0,0 +1,81 @@
+package visor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeAppLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "applogs")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	node := &Node{conf: &Config{}, localPath: dir, appsConf: []AppConfig{{App: "chat"}, {App: "idle"}}}
+	appDir := filepath.Join(dir, "chat", "v1.0")
+	require.NoError(t, os.MkdirAll(appDir, 0750))
+	big := bytes.Repeat([]byte("x"), MaxAppLogChunk+10)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(appDir, "chat.log"), big, 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(appDir, "chat.log.1"), []byte("old\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(appDir, "data.db"), []byte("data"), 0600))
+
+	files, err := node.AppLogFiles("chat")
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "v1.0/chat.log", files[0].Name)
+	assert.Equal(t, int64(len(big)), files[0].Size)
+	assert.Equal(t, "v1.0/chat.log.1", files[1].Name)
+
+	// Apps which never ran have no log files.
+	files, err = node.AppLogFiles("idle")
+	require.NoError(t, err)
+	assert.Empty(t, files)
+	_, err = node.AppLogFiles("unknown")
+	assert.Equal(t, ErrUnknownApp, err)
+
+	// Large files are read in chunks.
+	out, err := node.AppLog(AppLogIn{App: "chat", File: "v1.0/chat.log"})
+	require.NoError(t, err)
+	assert.Len(t, out.Data, MaxAppLogChunk)
+	assert.Equal(t, int64(MaxAppLogChunk), out.Next)
+	out, err = node.AppLog(AppLogIn{App: "chat", File: "v1.0/chat.log", Offset: out.Next})
+	require.NoError(t, err)
+	assert.Len(t, out.Data, 10)
+	assert.Equal(t, int64(-1), out.Next)
+	assert.Equal(t, int64(len(big)), out.Size)
+
+	// Only listed files are read.
+	for _, name := range []string{"v1.0/data.db", "../chat/v1.0/chat.log", "v1.0/missing.log"} {
+		_, err = node.AppLog(AppLogIn{App: "chat", File: name})
+		assert.Equal(t, ErrAppLogNotFound, err, name)
+	}
+}
+
+func TestReadLogLines(t *testing.T) {
+	start := time.Date(2020, 2, 3, 4, 5, 0, 0, time.UTC)
+	var logs []string
+	for i := 0; i < 4; i++ {
+		at := start.Add(time.Duration(i) * time.Minute).Format(time.RFC3339Nano)
+		logs = append(logs, "["+at+"] INFO [app]: "+strings.Repeat("x", MaxAppLogChunk/3))
+	}
+
+	out := readLogLines(logs, AppLogIn{From: start.Add(time.Minute), To: start.Add(3 * time.Minute)})
+	assert.Equal(t, logs[1]+"\n"+logs[2]+"\n", string(out.Data))
+	assert.Equal(t, int64(-1), out.Next)
+	assert.Equal(t, int64(-1), out.Size)
+
+	// Lines are read in chunks of whole lines.
+	out = readLogLines(logs, AppLogIn{})
+	assert.Equal(t, logs[0]+"\n"+logs[1]+"\n", string(out.Data))
+	require.Equal(t, int64(2), out.Next)
+	out = readLogLines(logs, AppLogIn{Offset: out.Next})
+	assert.Equal(t, logs[2]+"\n"+logs[3]+"\n", string(out.Data))
+	assert.Equal(t, int64(-1), out.Next)
+}
@@ -0,0 +1,212 @@
+package visor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AppUsageInterval is the interval at which the resource usage of apps is sampled.
+const AppUsageInterval = 5 * time.Second
+
+// appLimitSamples is the number of consecutive samples exceeding a limit after which the limit action is taken,
+// so that short spikes are tolerated.
+const appLimitSamples = 3
+
+// Actions taken when an app exceeds its limits.
+const (
+	AppLimitAlert   = "alert"   // log a warning.
+	AppLimitRestart = "restart" // restart the app.
+)
+
+// ErrAppUsageUnsupported occurs when sampling the resource usage of processes is not supported on the platform.
+var ErrAppUsageUnsupported = errors.New("app usage is not supported on this platform")
+
+// AppUsage is the resource usage of a running app.
+type AppUsage struct {
+	CPU    float64   `json:"cpu_percent"` // percentage of a CPU core used since the previous sample.
+	Memory uint64    `json:"memory"`      // resident set size in bytes.
+	Time   time.Time `json:"time"`        // time of the sample.
+}
+
+// AppLimits are limits of the resource usage of an app.
+type AppLimits struct {
+	CPU    float64 `json:"max_cpu_percent,omitempty"` // disabled if 0.
+	Memory uint64  `json:"max_memory,omitempty"`      // bytes, disabled if 0.
+	Action string  `json:"action,omitempty"`          // AppLimitAlert (default) or AppLimitRestart.
+}
+
+// Validate checks the limits.
+func (l *AppLimits) Validate() error {
+	switch l.Action {
+	case "", AppLimitAlert, AppLimitRestart:
+	default:
+		return fmt.Errorf("invalid limit action '%s'", l.Action)
+	}
+	if l.CPU < 0 {
+		return fmt.Errorf("invalid max_cpu_percent %v", l.CPU)
+	}
+	return nil
+}
+
+// exceeded returns a description of the exceeded limits, or an empty string if the usage is within the limits.
+func (l *AppLimits) exceeded(u AppUsage) string {
+	switch {
+	case l.CPU > 0 && u.CPU > l.CPU:
+		return fmt.Sprintf("cpu %.1f%% > %.1f%%", u.CPU, l.CPU)
+	case l.Memory > 0 && u.Memory > l.Memory:
+		return fmt.Sprintf("memory %d > %d bytes", u.Memory, l.Memory)
+	default:
+		return ""
+	}
+}
+
+// procUsage returns the total CPU time and resident set size of a process.
+// It is a variable so that it may be replaced in tests.
+var procUsage = readProcUsage
+
+type appSample struct {
+	pid      int
+	cpuTime  time.Duration
+	usage    AppUsage
+	exceeded int // number of consecutive samples exceeding the limits.
+}
+
+// appUsage records the resource usage of apps, keyed by app name.
+type appUsage struct {
+	samples map[string]*appSample
+	mx      sync.Mutex
+}
+
+func newAppUsage() *appUsage {
+	return &appUsage{samples: make(map[string]*appSample)}
+}
+
+// sample records the usage of the process, returning the number of consecutive samples exceeding the limits and a
+// description of the exceeded limits.
+func (u *appUsage) sample(app string, pid int, limits *AppLimits, now time.Time) (int, string, error) {
+	cpuTime, rss, err := procUsage(pid)
+	if err != nil {
+		return 0, "", err
+	}
+
+	u.mx.Lock()
+	defer u.mx.Unlock()
+
+	s := &appSample{pid: pid, cpuTime: cpuTime, usage: AppUsage{Memory: rss, Time: now}}
+	if prev, ok := u.samples[app]; ok && prev.pid == pid {
+		if elapsed := now.Sub(prev.usage.Time); elapsed > 0 {
+			s.usage.CPU = float64(cpuTime-prev.cpuTime) / float64(elapsed) * 100
+		}
+		s.exceeded = prev.exceeded
+	}
+	u.samples[app] = s
+
+	if limits == nil {
+		return 0, "", nil
+	}
+	reason := limits.exceeded(s.usage)
+	if reason == "" {
+		s.exceeded = 0
+	} else {
+		s.exceeded++
+	}
+	return s.exceeded, reason, nil
+}
+
+func (u *appUsage) get(app string) *AppUsage {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	s, ok := u.samples[app]
+	if !ok {
+		return nil
+	}
+	usage := s.usage
+	return &usage
+}
+
+// monitorApps periodically samples the resource usage of running apps, and enforces their limits.
+func (node *Node) monitorApps(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := node.sampleApps(now); err == ErrAppUsageUnsupported {
+				node.logger.Warn("Not monitoring apps: ", err)
+				return
+			}
+		}
+	}
+}
+
+func (node *Node) sampleApps(now time.Time) error {
+	limits := make(map[string]*AppLimits)
+	for _, ac := range node.appConfigs() {
+		limits[ac.App] = ac.Limits
+	}
+
+	node.startedMu.RLock()
+	pids := make(map[string]int, len(node.startedApps))
+	for name, bind := range node.startedApps {
+		if bind.pid > 0 {
+			pids[name] = bind.pid
+		}
+	}
+	node.startedMu.RUnlock()
+
+	for name, pid := range pids {
+		exceeded, reason, err := node.appUsage.sample(name, pid, limits[name], now)
+		if err == ErrAppUsageUnsupported {
+			return err
+		}
+		if err != nil {
+			node.logger.WithError(err).Debugf("Failed to sample usage of app %s", name)
+			continue
+		}
+		if exceeded != appLimitSamples {
+			continue
+		}
+		if limits[name].Action != AppLimitRestart {
+			node.logger.Warnf("App %s exceeds its limits: %s", name, reason)
+			continue
+		}
+		node.logger.Warnf("App %s exceeds its limits, restarting: %s", name, reason)
+		go node.restartApp(name)
+	}
+
+	// Forget the usage of apps which are no longer running.
+	node.appUsage.mx.Lock()
+	for name := range node.appUsage.samples {
+		if _, ok := pids[name]; !ok {
+			delete(node.appUsage.samples, name)
+		}
+	}
+	node.appUsage.mx.Unlock()
+	return nil
+}
+
+func (node *Node) restartApp(name string) {
+	if err := node.StopApp(name); err != nil {
+		node.logger.WithError(err).Warnf("Failed to stop app %s", name)
+		return
+	}
+	// Wait for the app to exit, as it may not be started while it is running.
+	for i := 0; i < 50 && node.isAppRunning(name); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := node.StartApp(name); err != nil {
+		node.logger.WithError(err).Warnf("Failed to restart app %s", name)
+	}
+}
+
+func (node *Node) isAppRunning(name string) bool {
+	node.startedMu.RLock()
+	defer node.startedMu.RUnlock()
+	return node.startedApps[name] != nil
+}
@@ -0,0 +1,39 @@
+package visor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the number of clock ticks per second used by /proc/<pid>/stat, which is 100 on all supported
+// Linux architectures.
+const clockTicks = 100
+
+// readProcUsage reads the CPU time and resident set size of a process from /proc/<pid>/stat.
+func readProcUsage(pid int) (time.Duration, uint64, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	// The command name (2nd field) may contain spaces, so fields are counted from its closing parenthesis.
+	i := strings.LastIndexByte(string(data), ')')
+	if i < 0 {
+		return 0, 0, fmt.Errorf("invalid /proc/%d/stat", pid)
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 22 {
+		return 0, 0, fmt.Errorf("invalid /proc/%d/stat", pid)
+	}
+	var vals [3]uint64
+	for j, idx := range []int{11, 12, 21} { // utime, stime and rss.
+		if vals[j], err = strconv.ParseUint(fields[idx], 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid /proc/%d/stat: %v", pid, err)
+		}
+	}
+	cpuTime := time.Duration(vals[0]+vals[1]) * time.Second / clockTicks
+	return cpuTime, vals[2] * uint64(os.Getpagesize()), nil
+}
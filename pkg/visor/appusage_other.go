@@ -0,0 +1,9 @@
+// +build !linux
+
+package visor
+
+import "time"
+
+func readProcUsage(int) (time.Duration, uint64, error) {
+	return 0, 0, ErrAppUsageUnsupported
+}
@@ -0,0 +1,62 @@
+package visor
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppUsage(t *testing.T) {
+	var cpuTime time.Duration
+	orig := procUsage
+	procUsage = func(int) (time.Duration, uint64, error) { return cpuTime, 1024, nil }
+	defer func() { procUsage = orig }()
+
+	u := newAppUsage()
+	limits := &AppLimits{CPU: 50, Action: AppLimitRestart}
+	now := time.Now()
+
+	exceeded, _, err := u.sample("foo", 10, limits, now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, exceeded)
+	assert.Equal(t, &AppUsage{Memory: 1024, Time: now}, u.get("foo"))
+
+	for i := 1; i <= appLimitSamples; i++ {
+		now = now.Add(time.Second)
+		cpuTime += 800 * time.Millisecond
+		exceeded, reason, err := u.sample("foo", 10, limits, now)
+		require.NoError(t, err)
+		assert.Equal(t, i, exceeded)
+		assert.NotEmpty(t, reason)
+		assert.InDelta(t, 80, u.get("foo").CPU, 0.01)
+	}
+
+	now = now.Add(time.Second)
+	cpuTime += 100 * time.Millisecond
+	exceeded, reason, err := u.sample("foo", 10, limits, now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, exceeded)
+	assert.Empty(t, reason)
+
+	// A new process of the app starts from scratch.
+	exceeded, _, err = u.sample("foo", 11, limits, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 0, exceeded)
+	assert.Equal(t, float64(0), u.get("foo").CPU)
+
+	assert.Nil(t, u.get("bar"))
+	assert.Error(t, (&AppLimits{Action: "kill"}).Validate())
+}
+
+func TestReadProcUsage(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("only supported on linux")
+	}
+	_, rss, err := readProcUsage(os.Getpid())
+	require.NoError(t, err)
+	assert.True(t, rss > 0)
+}
@@ -0,0 +1,319 @@
+package visor
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/seal"
+)
+
+// BackupVersion is the version of the format of backups.
+const BackupVersion = "1"
+
+// Files of backups.
+const (
+	backupManifest     = "manifest.json"
+	backupConfig       = "config.json"
+	backupPtyWhitelist = "dmsgpty_whitelist.json" // dmsg_pty.authorization_file of the config.
+	backupSTCPTable    = "stcp_pk_table"          // stcp.pk_table_file of the config.
+)
+
+var (
+	// ErrEmptyBackup occurs when restoring a backup without a config.
+	ErrEmptyBackup = errors.New("backup does not contain a config")
+	// ErrUnsafeBackupPath occurs when a file of a backup would be restored outside of the directory of the config.
+	ErrUnsafeBackupPath = errors.New("backup path is outside of the directory of the config")
+)
+
+// BackupManifest describes the contents of a backup.
+type BackupManifest struct {
+	Version string        `json:"version"`
+	PubKey  cipher.PubKey `json:"public_key"`
+	Created time.Time     `json:"created"`
+	Files   []string      `json:"files"`
+
+	// KeySealed is set when the secret key of the node is sealed. The key of the config of the backup is then
+	// sealed with the passphrase of the backup instead, as the key may have been sealed with a passphrase in the OS
+	// keyring, which is not part of the backup.
+	KeySealed bool `json:"key_sealed,omitempty"`
+}
+
+// RestoreIn is input of Restore.
+type RestoreIn struct {
+	Backup     []byte `json:"backup"`
+	Passphrase string `json:"passphrase"`
+}
+
+// RestoreResult reports a restored backup.
+type RestoreResult struct {
+	Manifest BackupManifest `json:"manifest"`
+	Written  []string       `json:"written"` // paths of the restored files.
+}
+
+// Backup bundles the config file of the node, which includes its keys and persistent transports, with its dmsgpty
+// whitelist and stcp PK table file into a gzipped tar archive encrypted with the passphrase. A sealed secret key is
+// sealed with the passphrase of the backup (see BackupManifest.KeySealed).
+func (node *Node) Backup(passphrase string) ([]byte, error) {
+	node.reloadMu.Lock()
+	defer node.reloadMu.Unlock()
+
+	files := make(map[string][]byte)
+	if path := node.conf.Path(); path != "" {
+		data, err := ioutil.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, err
+		}
+		files[backupConfig] = data
+	} else {
+		obj, err := node.conf.jsonObject()
+		if err != nil {
+			return nil, err
+		}
+		if files[backupConfig], err = json.MarshalIndent(obj, "", "  "); err != nil {
+			return nil, err
+		}
+	}
+	keySealed := node.conf.Node.SealedSecKey != ""
+	if keySealed {
+		data, err := resealBackupKey(files[backupConfig], node.conf.Node.StaticSecKey, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		files[backupConfig] = data
+	}
+	optional := map[string]string{backupSTCPTable: node.conf.STCP.TableFile}
+	if node.conf.DmsgPty != nil {
+		optional[backupPtyWhitelist] = node.conf.DmsgPty.AuthFile
+	}
+	for name, path := range optional {
+		if path == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Clean(path))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		files[name] = data
+	}
+
+	archive, err := writeBackup(node.conf.Node.StaticPubKey, keySealed, files)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := seal.Seal(archive, []byte(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	node.logger.Infof("Created backup of %d files", len(files))
+	return []byte(sealed), nil
+}
+
+// resealBackupKey replaces the sealed secret key of the config with the secret key sealed with the passphrase.
+func resealBackupKey(config []byte, sk cipher.SecKey, passphrase string) ([]byte, error) {
+	if sk.Null() {
+		return nil, ErrKeySealed
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(config, &obj); err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+	keys, ok := obj["node"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid config: no keys")
+	}
+	sealed, err := seal.Seal(sk[:], []byte(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	delete(keys, "static_secret_key")
+	keys["sealed_secret_key"] = sealed
+	return json.MarshalIndent(obj, "", "  ")
+}
+
+// Restore restores a backup of a node (see RestoreBackup) over the config file of the node.
+// The restored state takes effect after the node is restarted.
+func (node *Node) Restore(in RestoreIn) (*RestoreResult, error) {
+	node.reloadMu.Lock()
+	defer node.reloadMu.Unlock()
+
+	path := node.conf.Path()
+	if path == "" {
+		return nil, ErrNoConfigPath
+	}
+	res, err := RestoreBackup(in.Backup, in.Passphrase, path)
+	if err != nil {
+		return nil, err
+	}
+	node.logger.Infof("Restored backup of %s, the restored state takes effect after a restart", res.Manifest.PubKey)
+	node.RecordEvent(EventConfigChanged, path, "restored backup of %s", res.Manifest.PubKey)
+	return res, nil
+}
+
+// RestoreBackup decrypts a backup with the passphrase, and writes the config to configPath, and the other files to
+// the paths named by the restored config (relative to the working directory if relative). Replaced files are kept
+// with the suffix '.bak'. As the paths are taken from the backup, nothing is restored unless each path is either
+// relative without '..' elements, or within the directory of configPath.
+//
+// If the secret key is sealed (see BackupManifest.KeySealed), the passphrase of the backup unseals it.
+func RestoreBackup(backup []byte, passphrase, configPath string) (*RestoreResult, error) {
+	archive, err := seal.Open(string(backup), []byte(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	manifest, files, err := readBackup(archive)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := files[backupConfig]
+	if !ok {
+		return nil, ErrEmptyBackup
+	}
+	var conf Config
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("invalid config in backup: %v", err)
+	}
+
+	paths := map[string]string{backupConfig: configPath, backupSTCPTable: conf.STCP.TableFile}
+	if conf.DmsgPty != nil {
+		paths[backupPtyWhitelist] = conf.DmsgPty.AuthFile
+	}
+	for _, name := range manifest.Files {
+		if path := paths[name]; name != backupConfig && path != "" && !isSafeBackupPath(path, configPath) {
+			return nil, fmt.Errorf("%v: %s", ErrUnsafeBackupPath, path)
+		}
+	}
+	res := &RestoreResult{Manifest: *manifest, Written: []string{}}
+	for _, name := range manifest.Files {
+		path := paths[name]
+		if path == "" {
+			continue
+		}
+		if err := restoreFile(path, files[name]); err != nil {
+			return res, fmt.Errorf("failed to restore %s: %v", path, err)
+		}
+		res.Written = append(res.Written, path)
+	}
+	return res, nil
+}
+
+// isSafeBackupPath returns whether the path of a file of a backup is relative without '..' elements, or is within
+// the directory of the config.
+func isSafeBackupPath(path, configPath string) bool {
+	path = filepath.Clean(path)
+	if !filepath.IsAbs(path) {
+		return path != ".." && !strings.HasPrefix(path, ".."+string(filepath.Separator))
+	}
+	dir, err := filepath.Abs(filepath.Dir(configPath))
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func restoreFile(path string, data []byte) error {
+	path = filepath.Clean(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if old, err := ioutil.ReadFile(path); err == nil {
+		if err := writeFileAtomic(path+".bak", old, 0600); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+// writeBackup writes the files into a gzipped tar archive, preceded by the manifest.
+func writeBackup(pk cipher.PubKey, keySealed bool, files map[string][]byte) ([]byte, error) {
+	manifest := BackupManifest{Version: BackupVersion, PubKey: pk, Created: time.Now().UTC(), KeySealed: keySealed}
+	for _, name := range []string{backupConfig, backupPtyWhitelist, backupSTCPTable} {
+		if _, ok := files[name]; ok {
+			manifest.Files = append(manifest.Files, name)
+		}
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	write := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: manifest.Created}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := write(backupManifest, data); err != nil {
+		return nil, err
+	}
+	for _, name := range manifest.Files {
+		if err := write(name, files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readBackup reads the manifest and files of an archive written by writeBackup.
+func readBackup(archive []byte) (*BackupManifest, map[string][]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid backup: %v", err)
+	}
+	tr := tar.NewReader(zr)
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid backup: %v", err)
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid backup: %v", err)
+		}
+		files[hdr.Name] = data
+	}
+
+	var manifest BackupManifest
+	if err := json.Unmarshal(files[backupManifest], &manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid backup manifest: %v", err)
+	}
+	if manifest.Version != BackupVersion {
+		return nil, nil, fmt.Errorf("unsupported backup version '%s'", manifest.Version)
+	}
+	for _, name := range manifest.Files {
+		if _, ok := files[name]; !ok {
+			return nil, nil, fmt.Errorf("invalid backup: missing %s", name)
+		}
+	}
+	return &manifest, files, nil
+}
@@ -0,0 +1,121 @@
+package visor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/seal"
+)
+
+func TestNodeBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_backup")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	remote, _ := cipher.GenerateKeyPair()
+	conf := &Config{Version: ConfigVersion}
+	conf.Node.StaticPubKey, conf.Node.StaticSecKey = cipher.GenerateKeyPair()
+	conf.PersistentTransports = []transport.PersistentTransport{{PK: remote, Type: "dmsg"}}
+	conf.DmsgPty = &DmsgPtyConfig{AuthFile: filepath.Join(dir, "old", "whitelist.json")}
+	conf.path = filepath.Join(dir, "old", "config.json")
+	data, err := json.Marshal(conf)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "old"), 0700))
+	require.NoError(t, ioutil.WriteFile(conf.path, data, 0600))
+	whitelist := []byte(`{"` + remote.Hex() + `":true}`)
+	require.NoError(t, ioutil.WriteFile(conf.DmsgPty.AuthFile, whitelist, 0600))
+
+	node := &Node{conf: conf, logger: logging.MustGetLogger("test")}
+	backup, err := node.Backup("passphrase")
+	require.NoError(t, err)
+	assert.NotContains(t, string(backup), conf.Node.StaticSecKey.Hex())
+
+	// Restore on a "fresh machine", where the config and whitelist are gone.
+	require.NoError(t, os.Remove(conf.DmsgPty.AuthFile))
+	require.NoError(t, os.Remove(conf.path))
+	path := conf.path
+	_, err = RestoreBackup(backup, "wrong", path)
+	assert.Equal(t, seal.ErrWrongPassphrase, err)
+
+	// The whitelist is outside of the directory of the config, so nothing is restored.
+	newPath := filepath.Join(dir, "new", "config.json")
+	_, err = RestoreBackup(backup, "passphrase", newPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrUnsafeBackupPath.Error())
+	_, err = os.Stat(newPath)
+	assert.True(t, os.IsNotExist(err))
+
+	res, err := RestoreBackup(backup, "passphrase", path)
+	require.NoError(t, err)
+	assert.Equal(t, conf.Node.StaticPubKey, res.Manifest.PubKey)
+	assert.Equal(t, []string{path, conf.DmsgPty.AuthFile}, res.Written)
+
+	restored, err := ReadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, conf.Node.StaticSecKey, restored.Node.StaticSecKey)
+	assert.Equal(t, conf.PersistentTransports, restored.PersistentTransports)
+	data, err = ioutil.ReadFile(conf.DmsgPty.AuthFile)
+	require.NoError(t, err)
+	assert.Equal(t, whitelist, data)
+
+	// Replaced files are kept.
+	node.conf.path = path
+	_, err = node.Restore(RestoreIn{Backup: backup, Passphrase: "passphrase"})
+	require.NoError(t, err)
+	_, err = os.Stat(path + ".bak")
+	assert.NoError(t, err)
+}
+
+func TestNodeBackup_sealedKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_backup")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	// The key is sealed with a passphrase which is not part of the backup, such as one in the OS keyring.
+	conf := &Config{Version: ConfigVersion}
+	conf.Node.StaticPubKey, conf.Node.StaticSecKey = cipher.GenerateKeyPair()
+	conf.Node.SealedSecKey, err = seal.Seal(conf.Node.StaticSecKey[:], []byte("keyring"))
+	require.NoError(t, err)
+	node := &Node{conf: conf, logger: logging.MustGetLogger("test")}
+	backup, err := node.Backup("passphrase")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "config.json")
+	res, err := RestoreBackup(backup, "passphrase", path)
+	require.NoError(t, err)
+	assert.True(t, res.Manifest.KeySealed)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), conf.Node.StaticSecKey.Hex())
+	var restored Config
+	require.NoError(t, json.Unmarshal(data, &restored))
+	require.True(t, restored.Sealed())
+	assert.Equal(t, seal.ErrWrongPassphrase, restored.Unseal([]byte("keyring")))
+	require.NoError(t, restored.Unseal([]byte("passphrase")))
+	assert.Equal(t, conf.Node.StaticSecKey, restored.Node.StaticSecKey)
+}
+
+func TestIsSafeBackupPath(t *testing.T) {
+	configPath := filepath.Join(os.TempDir(), "skywire", "config.json")
+	for path, safe := range map[string]bool{
+		"pk_table":                 true,
+		"./dmsgpty/whitelist.json": true,
+		"a/../pk_table":            true,
+		"../pk_table":              false,
+		"a/../../pk_table":         false,
+		filepath.Join(filepath.Dir(configPath), "pk_table"): true,
+		filepath.Join(os.TempDir(), "pk_table"):             false,
+	} {
+		assert.Equal(t, safe, isSafeBackupPath(path, configPath), path)
+	}
+}
@@ -0,0 +1,427 @@
+package visor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/buildinfo"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+// ConfigProblem is a problem of a config found by CheckConfig.
+type ConfigProblem struct {
+	Field   string `json:"field,omitempty"` // JSON path of the field, such as 'apps[1].port', empty if not of a field.
+	Message string `json:"message"`
+}
+
+func (p ConfigProblem) String() string {
+	if p.Field == "" {
+		return p.Message
+	}
+	return p.Field + ": " + p.Message
+}
+
+// CheckConfig fully parses and validates the config data, as of the given profile, without starting any component
+// of the node. It reports unknown fields, invalid keys and values, missing app binaries and conflicting ports and
+// addresses, and returns no problems if the config is valid.
+func CheckConfig(data []byte, profile string) []ConfigProblem {
+	var problems []ConfigProblem
+	add := func(field, format string, args ...interface{}) {
+		problems = append(problems, ConfigProblem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if _, migrated, err := MigrateConfig(data); err != nil {
+		add("", "%v", err)
+		return problems
+	} else if migrated != nil {
+		data = migrated
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		add("", "invalid JSON: %v", err)
+		return problems
+	}
+	confType := reflect.TypeOf(Config{})
+	for _, field := range unknownFields(obj, confType, "") {
+		add(field, "unknown field")
+	}
+	// Profiles and identities are partial configs.
+	for _, key := range []string{"profiles", "identities"} {
+		partials, _ := obj[key].(map[string]interface{}) //nolint:errcheck
+		for name, partial := range partials {
+			for _, field := range unknownFields(partial, confType, key+"."+name) {
+				add(field, "unknown field")
+			}
+		}
+	}
+
+	conf := new(Config)
+	if err := json.Unmarshal(data, conf); err != nil {
+		add("", "%v", err)
+		return problems
+	}
+	if err := conf.ApplyProfile(profile); err != nil {
+		add("profiles", "%v", err)
+		return problems
+	}
+	confs, err := conf.IdentityConfigs()
+	if err != nil {
+		add("identities", "%v", err)
+		return problems
+	}
+	if err := checkIdentities(confs); err != nil {
+		add("identities", "%v", err)
+	}
+	for _, c := range confs {
+		prefix := ""
+		if name := c.Identity(); name != "" {
+			prefix = "identities." + name + "."
+		}
+		for _, p := range c.check() {
+			if p.Field != "" || prefix != "" {
+				p.Field = prefix + p.Field
+			}
+			problems = append(problems, p)
+		}
+	}
+	return problems
+}
+
+// CheckConfig checks the config data with CheckConfig, as of the profile of the node. If data is empty, the file the
+// config of the node was read from is checked, such as before it is reloaded.
+func (node *Node) CheckConfig(data []byte) ([]ConfigProblem, error) {
+	if len(data) == 0 {
+		path := node.conf.Path()
+		if path == "" {
+			return nil, ErrNoConfigPath
+		}
+		var err error
+		if data, err = ioutil.ReadFile(filepath.Clean(path)); err != nil {
+			return nil, err
+		}
+	}
+	return CheckConfig(data, node.conf.Profile()), nil
+}
+
+// check validates the config of a single node.
+func (c *Config) check() []ConfigProblem {
+	var problems []ConfigProblem
+	add := func(field string, err error) {
+		if err != nil {
+			problems = append(problems, ConfigProblem{Field: field, Message: err.Error()})
+		}
+	}
+	errorf := func(field, format string, args ...interface{}) {
+		add(field, fmt.Errorf(format, args...))
+	}
+
+	// Keys.
+	pk := c.Node.StaticPubKey
+	switch {
+	case pk.Null():
+		errorf("node.static_public_key", "missing public key")
+	case c.Sealed():
+	case c.Node.StaticSecKey.Null():
+		errorf("node.static_secret_key", "missing secret key")
+	default:
+		if derived, err := c.Node.StaticSecKey.PubKey(); err != nil {
+			errorf("node.static_secret_key", "invalid secret key: %v", err)
+		} else if derived != pk {
+			errorf("node.static_secret_key", "secret key does not match public key %s", pk)
+		}
+	}
+
+	// Services and networks.
+	if _, err := c.MessagingConfig(); err != nil && !c.isNetworkDisabled("dmsg") {
+		add("messaging.discovery", err)
+	}
+	if _, err := c.STCPTable(); err != nil {
+		add("stcp.pk_table_file", err)
+	}
+	add("transport.cipher_suites", transport.ValidateCipherSuites(c.Transport.CipherSuites))
+	if c.Transport.FEC != nil {
+		add("transport.fec", c.Transport.FEC.Validate())
+	}
+	if _, _, err := c.TransportQuotas(); err != nil {
+		add("transport.quotas", err)
+	}
+	for i, pt := range c.PersistentTransports {
+		field := fmt.Sprintf("persistent_transports[%d]", i)
+		if pt.PK.Null() {
+			errorf(field+".pk", "missing public key")
+		}
+		if pt.Label != "" {
+			if _, err := transport.NormalizeLabels([]string{pt.Label}); err != nil {
+				add(field+".label", err)
+			}
+		}
+	}
+
+	// Node settings.
+	if c.LogLevel != "" {
+		if _, err := logging.LevelFromString(c.LogLevel); err != nil {
+			add("log_level", err)
+		}
+	}
+	add("reward_address", ValidateRewardAddress(c.RewardAddress))
+	if _, err := c.StaticLocation(); err != nil {
+		add("location", err)
+	}
+	if c.Restart != nil {
+		add("restart", c.Restart.Validate())
+	}
+
+	problems = append(problems, c.checkApps()...)
+	problems = append(problems, c.checkAddresses()...)
+	problems = append(problems, c.checkHypervisors()...)
+	return problems
+}
+
+// checkApps checks that apps are unique, do not share routing ports, and that their binaries are executable.
+func (c *Config) checkApps() []ConfigProblem {
+	var problems []ConfigProblem
+	errorf := func(field, format string, args ...interface{}) {
+		problems = append(problems, ConfigProblem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	names := make(map[string]int)
+	ports := make(map[routing.Port]int)
+	for i, ac := range c.Apps {
+		field := fmt.Sprintf("apps[%d]", i)
+		if ac.App == "" {
+			errorf(field+".app", "missing app name")
+			continue
+		}
+		if j, ok := names[ac.App]; ok {
+			errorf(field+".app", "app %s is also configured by apps[%d]", ac.App, j)
+		}
+		names[ac.App] = i
+		if app, ok := reservedPorts[ac.Port]; ok && app != ac.App {
+			errorf(field+".port", "port %d is reserved for %s", ac.Port, app)
+		} else if j, ok := ports[ac.Port]; ok {
+			errorf(field+".port", "port %d is also used by apps[%d]", ac.Port, j)
+		}
+		ports[ac.Port] = i
+		if ac.Limits != nil {
+			if err := ac.Limits.Validate(); err != nil {
+				errorf(field+".limits", "%v", err)
+			}
+		}
+
+		version := ac.Version
+		if version == "" {
+			version = DefaultAppVersion
+		}
+		bin := app.BinaryPath(c.AppsPath, ac.App, version)
+		info, err := os.Stat(bin)
+		switch {
+		case err != nil:
+			errorf(field, "app binary %s: %v", bin, err)
+		case info.IsDir():
+			errorf(field, "app binary %s is a directory", bin)
+		case runtime.GOOS != "windows" && info.Mode()&0111 == 0:
+			errorf(field, "app binary %s is not executable", bin)
+		default:
+			if v, err := buildinfo.ReadAppProtocol(bin); err == nil {
+				if err := buildinfo.CompatibleAppProtocol(v); err != nil {
+					errorf(field, "app binary %s: %v", bin, err)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// checkAddresses checks that the listeners of the node do not share TCP addresses or dmsg ports.
+func (c *Config) checkAddresses() []ConfigProblem {
+	var problems []ConfigProblem
+	errorf := func(field, format string, args ...interface{}) {
+		problems = append(problems, ConfigProblem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	addrs := make(map[string]string)
+	useAddr := func(field, addr string) {
+		if addr == "" {
+			return
+		}
+		if other, ok := addrs[addr]; ok {
+			errorf(field, "address %s is also used by %s", addr, other)
+			return
+		}
+		addrs[addr] = field
+	}
+	useAddr("interfaces.rpc", c.Interfaces.RPCAddress)
+	useAddr("stcp.local_address", c.STCP.LocalAddr)
+	if c.RESTAPI != nil {
+		useAddr("rest_api.address", c.RESTAPI.Address)
+	}
+	if c.DmsgPty != nil && c.DmsgPty.CLINet == "tcp" {
+		useAddr("dmsg_pty.cli_address", c.DmsgPty.CLIAddr)
+	}
+
+	ports := map[uint16]string{
+		skyenv.DmsgTransportPort:  "transports",
+		skyenv.DmsgAwaitSetupPort: "setup",
+	}
+	usePort := func(field string, port uint16) {
+		if other, ok := ports[port]; ok {
+			errorf(field, "dmsg port %d is also used by %s", port, other)
+			return
+		}
+		ports[port] = field
+	}
+	if c.DmsgPty != nil {
+		usePort("dmsg_pty.port", c.DmsgPty.Port)
+	}
+	if c.DmsgHTTP != nil {
+		port := c.DmsgHTTP.Port
+		if port == 0 {
+			port = skyenv.DmsgHTTPPort
+		}
+		usePort("dmsg_http.port", port)
+	}
+	if c.DmsgRPC != nil {
+		port := c.DmsgRPC.Port
+		if port == 0 {
+			port = skyenv.DmsgRPCPort
+		}
+		usePort("dmsg_rpc.port", port)
+	}
+	return problems
+}
+
+// unknownFields returns the paths of the fields of the decoded JSON value v which are unknown to decoding into t.
+// Fields are matched case-insensitively, as by encoding/json.
+func unknownFields(v interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return nil
+	}
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			ft, ok := fields[key]
+			if !ok {
+				for name, typ := range fields {
+					if strings.EqualFold(name, key) {
+						ft, ok = typ, true
+						break
+					}
+				}
+			}
+			if !ok {
+				unknown = append(unknown, join(key))
+				continue
+			}
+			unknown = append(unknown, unknownFields(obj[key], ft, join(key))...)
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			unknown = append(unknown, unknownFields(obj[key], t.Elem(), join(key))...)
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, elem := range arr {
+			unknown = append(unknown, unknownFields(elem, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return unknown
+}
+
+// jsonFields returns the types of the fields of the struct type t by their JSON keys.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for key, ft := range jsonFields(f.Type) {
+				fields[key] = ft
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue // unexported.
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// checkHypervisors checks that hypervisors are unique, and that each can connect to the node.
+func (c *Config) checkHypervisors() []ConfigProblem {
+	var problems []ConfigProblem
+	errorf := func(field, format string, args ...interface{}) {
+		problems = append(problems, ConfigProblem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	pks := make(map[cipher.PubKey]int)
+	for i, h := range c.Hypervisors {
+		field := fmt.Sprintf("hypervisors[%d]", i)
+		if h.PubKey.Null() {
+			errorf(field+".public_key", "missing public key")
+			continue
+		}
+		if j, ok := pks[h.PubKey]; ok {
+			errorf(field+".public_key", "hypervisor %s is also configured by hypervisors[%d]", h.PubKey, j)
+		}
+		pks[h.PubKey] = i
+		if h.Addr == "" && c.DmsgRPC == nil {
+			errorf(field+".address", "hypervisors without an address connect over dmsg, which requires 'dmsg_rpc'")
+		}
+	}
+	return problems
+}
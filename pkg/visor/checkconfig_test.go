@@ -0,0 +1,83 @@
+package visor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/buildinfo"
+)
+
+func TestCheckConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_checkconfig")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "skychat.v1.0"), []byte("#!/bin/sh\n"), 0700))
+
+	conf := &Config{Version: ConfigVersion, AppsPath: dir, LocalPath: dir}
+	conf.Node.StaticPubKey, conf.Node.StaticSecKey = cipher.GenerateKeyPair()
+	conf.Messaging.Discovery = "http://dmsg.discovery"
+	conf.Interfaces.RPCAddress = "localhost:3435"
+	conf.Apps = []AppConfig{{App: "skychat", Version: "1.0", Port: 1}}
+	check := func(edit func(obj map[string]interface{})) []string {
+		data, err := json.Marshal(conf)
+		require.NoError(t, err)
+		var obj map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &obj))
+		if edit != nil {
+			edit(obj)
+		}
+		data, err = json.Marshal(obj)
+		require.NoError(t, err)
+		var problems []string
+		for _, p := range CheckConfig(data, "") {
+			problems = append(problems, p.String())
+		}
+		return problems
+	}
+
+	assert.Empty(t, check(nil))
+
+	_, otherSK := cipher.GenerateKeyPair()
+	problems := check(func(obj map[string]interface{}) {
+		obj["unknown"] = true
+		obj["stcp"].(map[string]interface{})["local_address"] = "localhost:3435"
+		obj["node"].(map[string]interface{})["static_secret_key"] = otherSK.Hex()
+		apps := obj["apps"].([]interface{})
+		apps[0].(map[string]interface{})["prot"] = 1
+		obj["apps"] = append(apps, map[string]interface{}{"app": "skyproxy", "version": "1.0", "port": 1})
+	})
+	assert.Equal(t, []string{
+		"apps[0].prot: unknown field",
+		"unknown: unknown field",
+		"node.static_secret_key: secret key does not match public key " + conf.Node.StaticPubKey.Hex(),
+		"apps[1].port: port 1 is reserved for skychat",
+		"apps[1]: app binary " + filepath.Join(dir, "skyproxy.v1.0") + ": stat " + filepath.Join(dir, "skyproxy.v1.0") +
+			": no such file or directory",
+		"stcp.local_address: address localhost:3435 is also used by interfaces.rpc",
+	}, problems)
+
+	hvPK, _ := cipher.GenerateKeyPair()
+	assert.Equal(t, []string{
+		"hypervisors[1].public_key: hypervisor " + hvPK.Hex() + " is also configured by hypervisors[0]",
+		"hypervisors[1].address: hypervisors without an address connect over dmsg, which requires 'dmsg_rpc'",
+	}, check(func(obj map[string]interface{}) {
+		obj["hypervisors"] = []interface{}{
+			map[string]interface{}{"public_key": hvPK.Hex(), "address": "localhost:7080"},
+			map[string]interface{}{"public_key": hvPK.Hex(), "address": ""},
+		}
+	}))
+
+	bin := filepath.Join(dir, "skychat.v1.0")
+	require.NoError(t, ioutil.WriteFile(bin, []byte("\x00skywire:app-protocol=1.0.0\x00"), 0700))
+	assert.Equal(t, []string{
+		"apps[0]: app binary " + bin + ": app protocol version 1.0.0 is incompatible with supported version " +
+			buildinfo.AppProtocolVersion,
+	}, check(nil))
+}
@@ -0,0 +1,122 @@
+package visor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// MaxClockSkew is the skew of the local clock beyond which clock skew is reported. Settlement handshakes are
+// rejected beyond the nonce window of remotes (transport.DefaultNonceWindow), and discovery entries with skewed
+// timestamps may be rejected sooner.
+const MaxClockSkew = time.Minute
+
+// ClockCheckInterval is the interval at which the local clock is checked after startup.
+const ClockCheckInterval = time.Hour
+
+// ErrNoClockSource occurs when the clock is checked but no discovery service responded with a Date header.
+var ErrNoClockSource = errors.New("no service responded with a Date header")
+
+// ClockStatus is the result of comparing the local clock with the Date header of a discovery service.
+type ClockStatus struct {
+	Source    string        `json:"source,omitempty"` // URL of the service the clock was compared with.
+	Skew      time.Duration `json:"skew"`             // positive if the local clock is ahead.
+	CheckedAt time.Time     `json:"checked_at"`
+	Error     string        `json:"error,omitempty"` // why the clock could not be checked.
+}
+
+// Skewed returns whether the local clock is skewed beyond MaxClockSkew.
+func (s *ClockStatus) Skewed() bool {
+	return s.Error == "" && (s.Skew > MaxClockSkew || s.Skew < -MaxClockSkew)
+}
+
+// Warning describes the skew of the clock, or returns an empty string if the clock is not skewed.
+func (s *ClockStatus) Warning() string {
+	if !s.Skewed() {
+		return ""
+	}
+	skew, direction := s.Skew, "ahead of"
+	if skew < 0 {
+		skew, direction = -skew, "behind"
+	}
+	return fmt.Sprintf("clock skew detected: local clock is %s %s %s", skew.Round(time.Second), direction, s.Source)
+}
+
+// measureClockSkew compares the local clock with the Date header of the response of url.
+func measureClockSkew(ctx context.Context, url string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	end := time.Now()
+	_ = resp.Body.Close() //nolint:errcheck
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid Date header: %v", err)
+	}
+	// The Date header is truncated to seconds, and is assumed to be set halfway through the request.
+	local := start.Add(end.Sub(start) / 2)
+	return local.Sub(date.Add(time.Second / 2)), nil
+}
+
+// checkClock compares the local clock with the first discovery service which responds with a Date header.
+func (node *Node) checkClock(ctx context.Context) *ClockStatus {
+	conf := node.conf
+	var sources []string
+	for _, addr := range []string{conf.Messaging.Discovery, conf.Transport.Discovery, conf.Routing.RouteFinder} {
+		if addr != "" {
+			sources = append(sources, healthURL(addr))
+		}
+	}
+
+	status := &ClockStatus{Error: ErrNoClockSource.Error()}
+	for _, url := range sources {
+		skew, err := measureClockSkew(ctx, url)
+		if err != nil {
+			node.logger.WithError(err).Debugf("Failed to check clock against %s", url)
+			continue
+		}
+		status = &ClockStatus{Source: url, Skew: skew}
+		break
+	}
+	status.CheckedAt = time.Now()
+
+	node.clockMu.Lock()
+	node.clock = status
+	node.clockMu.Unlock()
+	return status
+}
+
+// monitorClock checks the local clock on startup and every ClockCheckInterval, warning if it is skewed.
+func (node *Node) monitorClock(ctx context.Context) {
+	ticker := time.NewTicker(ClockCheckInterval)
+	defer ticker.Stop()
+	for {
+		if status := node.checkClock(ctx); status.Skewed() {
+			node.logger.Warnf("%s: transport handshakes and discovery entries may be rejected", status.Warning())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ClockStatus returns the result of the latest check of the local clock, or nil if it was not checked yet.
+func (node *Node) ClockStatus() *ClockStatus {
+	node.clockMu.Lock()
+	defer node.clockMu.Unlock()
+	return node.clock
+}
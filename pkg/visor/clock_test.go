@@ -0,0 +1,45 @@
+package visor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeCheckClock(t *testing.T) {
+	var offset time.Duration
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Date", time.Now().Add(offset).UTC().Format(http.TimeFormat))
+	}))
+	defer srv.Close()
+
+	node := &Node{conf: &Config{}, logger: logging.MustGetLogger("test")}
+	assert.Nil(t, node.ClockStatus())
+	status := node.checkClock(context.TODO())
+	assert.Equal(t, ErrNoClockSource.Error(), status.Error)
+	assert.False(t, status.Skewed())
+
+	node.conf.Messaging.Discovery = "http://127.0.0.1:1" // unreachable, the next service is checked.
+	node.conf.Transport.Discovery = srv.URL
+	status = node.checkClock(context.TODO())
+	require.Empty(t, status.Error)
+	assert.Equal(t, srv.URL+"/health", status.Source)
+	assert.False(t, status.Skewed())
+	assert.Empty(t, status.Warning())
+
+	offset = -5 * time.Minute
+	status = node.checkClock(context.TODO())
+	assert.True(t, status.Skewed())
+	assert.InDelta(t, float64(5*time.Minute), float64(status.Skew), float64(2*time.Second))
+	assert.Equal(t, "clock skew detected: local clock is 5m0s ahead of "+srv.URL+"/health", status.Warning())
+	assert.Equal(t, status, node.ClockStatus())
+
+	offset = 5 * time.Minute
+	assert.Contains(t, node.checkClock(context.TODO()).Warning(), "local clock is 5m0s behind")
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/SkycoinProject/dmsg"
@@ -14,45 +15,103 @@ import (
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/dmsgpty"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
 	trClient "github.com/SkycoinProject/skywire-mainnet/pkg/transport-discovery/client"
 )
 
+// DefaultShutdownGracePeriod is the default duration of the graceful shutdown of the visor.
+const DefaultShutdownGracePeriod = Duration(5 * time.Second)
+
 // Config defines configuration parameters for Node.
 // TODO(evanlinjin): Instead of having nested structs, make separate types for each field.
 // TODO(evanlinjin): Use pointers to allow nil-configs for non-crucial fields.
 type Config struct {
-	Version string `json:"version"`
+	Version string `json:"version"` // version of the config format, upgraded on startup (see MigrateConfigFile).
 
 	Node struct {
 		StaticPubKey cipher.PubKey `json:"static_public_key"`
 		StaticSecKey cipher.SecKey `json:"static_secret_key"`
+		// SealedSecKey is the secret key encrypted with a passphrase, in place of static_secret_key (see Unseal).
+		SealedSecKey string `json:"sealed_secret_key,omitempty"`
 	} `json:"node"`
 
 	STCP struct {
 		PubKeyTable map[cipher.PubKey]string `json:"pk_table"`
+		TableFile   string                   `json:"pk_table_file,omitempty"` // reloaded on change, overrides pk_table entries
 		LocalAddr   string                   `json:"local_address"`
+		PortMapping bool                     `json:"port_mapping,omitempty"` // NAT-PMP/UPnP mapping of local_address
+		Multiplex   bool                     `json:"multiplex,omitempty"`    // share one TCP connection per remote
+		TLS         bool                     `json:"tls,omitempty"`          // wrap connections in TLS (remotes must also enable it)
+		Simulation  *STCPSimulationConfig    `json:"simulation,omitempty"`   // dev mode: simulated network conditions
 	} `json:"stcp"`
 
+	// Networks enables additional network drivers registered with snet, keyed by network type.
+	// Values are passed to the drivers as options.
+	Networks map[string]json.RawMessage `json:"networks,omitempty"`
+
+	// NetworkPreference orders networks for outbound dials, such as of transports of unspecified type.
+	// Unlisted networks follow in the default order (dmsg, stcp, then additional networks by name).
+	NetworkPreference []string `json:"network_preference,omitempty"`
+
+	// DisabledNetworks are neither initiated nor used for transports (such as 'dmsg' for air-gapped deployments).
+	DisabledNetworks []string `json:"disabled_networks,omitempty"`
+
 	Messaging struct {
-		Discovery   string `json:"discovery"`
-		ServerCount int    `json:"server_count"`
+		Discovery   string   `json:"discovery"`
+		ServerCount int      `json:"server_count"`         // min number of concurrent dmsg server sessions, defaults to 1.
+		CacheFile   string   `json:"cache_file,omitempty"` // persists discovery responses for use during discovery outages.
+		CacheTTL    Duration `json:"cache_ttl,omitempty"`  // max age of cached discovery responses, defaults to 24h.
 	} `json:"messaging"`
 
 	DmsgPty *DmsgPtyConfig `json:"dmsg_pty,omitempty"`
 
+	// DmsgHTTP serves the HTTP API of the visor (such as metrics) over dmsg, if set.
+	DmsgHTTP *DmsgHTTPConfig `json:"dmsg_http,omitempty"`
+
+	// DmsgRPC serves the RPC of the visor to hypervisors over dmsg, if set, so that visors without a reachable TCP
+	// address may be managed without dialing out to hypervisors.
+	DmsgRPC *DmsgRPCConfig `json:"dmsg_rpc,omitempty"`
+
+	// RESTAPI serves the REST API of the visor (also served over dmsg if 'dmsg_http' is set), if set.
+	RESTAPI *RESTAPIConfig `json:"rest_api,omitempty"`
+
 	Transport struct {
 		Discovery string `json:"discovery"`
 		LogStore  struct {
 			Type     string `json:"type"`
 			Location string `json:"location"`
 		} `json:"log_store"`
+		LabelStore struct {
+			Type     string `json:"type"`
+			Location string `json:"location"`
+		} `json:"label_store"`
+		Maintenance   *TransportMaintenanceConfig `json:"maintenance,omitempty"`
+		MTU           uint16                      `json:"mtu,omitempty"`            // max packet payload size, negotiated with remotes
+		CipherSuites  []string                    `json:"cipher_suites,omitempty"`  // allowed cipher suites in order of preference
+		FEC           *transport.FECConfig        `json:"fec,omitempty"`            // forward error correction for lossy networks
+		StatsInterval Duration                    `json:"stats_interval,omitempty"` // interval of stats uploads to discovery (disabled if 0)
+		NonceFile     string                      `json:"nonce_file,omitempty"`     // persists settlement nonces to reject replays across restarts
+		RequireNonces bool                        `json:"require_nonces,omitempty"` // rejects transports of legacy visors which do not send settlement nonces
+		ResumeBuffer  int                         `json:"resume_buffer,omitempty"`  // bytes of sent packets retained to resume transports (disabled if negative)
+		Quotas        []QuotaConfig               `json:"quotas,omitempty"`         // bandwidth quotas per remote visor or for relaying
+		QuotaFile     string                      `json:"quota_file,omitempty"`     // persists quota usage, defaults to <local_path>/bandwidth_usage.json
 	} `json:"transport"`
 
+	PersistentTransports []transport.PersistentTransport `json:"persistent_transports,omitempty"`
+
+	// EventLog configures the log of significant events of the node.
+	EventLog *EventLogConfig `json:"event_log,omitempty"`
+
+	// RotatedKeys are previous keypairs of the node (see Node.RotateKeys).
+	RotatedKeys []RotatedKey `json:"rotated_keys,omitempty"`
+
 	Routing struct {
-		SetupNodes         []cipher.PubKey `json:"setup_nodes"`
-		RouteFinder        string          `json:"route_finder"`
-		RouteFinderTimeout Duration        `json:"route_finder_timeout"`
+		SetupNodes         []cipher.PubKey    `json:"setup_nodes"`
+		RouteFinder        string             `json:"route_finder"`
+		RouteFinderTimeout Duration           `json:"route_finder_timeout"`
+		TransportWeights   map[string]float64 `json:"transport_weights,omitempty"` // route cost of each transport type
 		Table              struct {
 			Type     string `json:"type"`
 			Location string `json:"location"`
@@ -60,21 +119,78 @@ type Config struct {
 	} `json:"routing"`
 
 	Uptime struct {
-		Tracker string `json:"tracker"`
+		Tracker  string   `json:"tracker"`
+		Interval Duration `json:"interval,omitempty"` // between heartbeats, defaults to 1m.
 	} `json:"uptime"`
 
+	// RewardAddress is the Skycoin address which rewards of the node are paid to, if set.
+	RewardAddress string `json:"reward_address,omitempty"`
+
+	// Location is the self-reported geographic location of the node, if set.
+	Location *LocationConfig `json:"location,omitempty"`
+
+	// NetworkMonitor reports anonymized health data to a network monitor, if explicitly enabled.
+	NetworkMonitor *NetworkMonitorConfig `json:"network_monitor,omitempty"`
+
 	Apps []AppConfig `json:"apps"`
 
 	TrustedNodes []cipher.PubKey    `json:"trusted_nodes"`
 	Hypervisors  []HypervisorConfig `json:"hypervisors"`
 
+	// TrustedVisors are remote visors whose transports and loops are accepted while the node is draining, and are
+	// closed last when shutting down. Limits on transports and loops do not apply to them.
+	TrustedVisors []cipher.PubKey `json:"trusted_visors,omitempty"`
+
 	AppsPath  string `json:"apps_path"`
 	LocalPath string `json:"local_path"`
 
 	LogLevel        string   `json:"log_level"`
 	ShutdownTimeout Duration `json:"shutdown_timeout"` // time value, examples: 10s, 1m, etc
 
+	// ShutdownGracePeriod bounds the graceful shutdown (see Node.Shutdown), defaults to 5s.
+	// It should be shorter than shutdown_timeout, after which the visor is terminated.
+	ShutdownGracePeriod Duration `json:"shutdown_grace_period,omitempty"`
+
 	Interfaces InterfaceConfig `json:"interfaces"`
+
+	// Restart makes the visor periodically restart itself, if set.
+	Restart *RestartConfig `json:"restart,omitempty"`
+
+	// Update enables updates of the visor to signed releases, as requested through RPC.
+	Update *UpdaterConfig `json:"update,omitempty"`
+
+	// Profiles are named partial configs, which are merged over the config when selected on startup (such as
+	// "home" or "datacenter" configs, which share the node keys but differ in services and transports).
+	Profiles map[string]json.RawMessage `json:"profiles,omitempty"`
+
+	// Identities are additional nodes run by the visor process, each with its own keys, transports, apps and
+	// routes. They are partial configs merged over the config (see IdentityConfigs).
+	Identities map[string]json.RawMessage `json:"identities,omitempty"`
+
+	path     string // file the config was read from, empty if not read from a file.
+	profile  string // name of the applied profile, empty if none was applied.
+	identity string // name of the identity, empty for the primary identity.
+}
+
+// ReadConfig reads the config from the file at path.
+func ReadConfig(path string) (*Config, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config: %s", err)
+	}
+	defer func() { _ = f.Close() }() //nolint:errcheck
+
+	conf := new(Config)
+	if err := json.NewDecoder(f).Decode(conf); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %s", path, err)
+	}
+	conf.path = path
+	return conf, nil
+}
+
+// Path returns the file the config was read from, or an empty string if it was not read from a file.
+func (c *Config) Path() string {
+	return c.path
 }
 
 // MessagingConfig returns config for dmsg client.
@@ -94,6 +210,18 @@ func (c *Config) MessagingConfig() (*DmsgConfig, error) {
 	}, nil
 }
 
+// DmsgServerCount returns the validated minimum number of concurrent dmsg server sessions.
+func (c *Config) DmsgServerCount() (int, error) {
+	switch n := c.Messaging.ServerCount; {
+	case n < 0:
+		return 0, fmt.Errorf("invalid messaging server_count of %d: must not be negative", n)
+	case n == 0:
+		return 1, nil
+	default:
+		return n, nil
+	}
+}
+
 // DmsgPtyHost instantiates a host from the dmsgpty config.
 func (c *Config) DmsgPtyHost(dmsgC *dmsg.Client) (*dmsgpty.Host, error) {
 	if c.DmsgPty == nil {
@@ -119,6 +247,29 @@ func (c *Config) TransportDiscovery() (transport.DiscoveryClient, error) {
 	return trClient.NewHTTP(c.Transport.Discovery, c.Node.StaticPubKey, c.Node.StaticSecKey)
 }
 
+// STCPTable returns the entries of the stcp PK table, merged from pk_table and pk_table_file.
+func (c *Config) STCPTable() (map[cipher.PubKey]string, error) {
+	entries := make(map[cipher.PubKey]string, len(c.STCP.PubKeyTable))
+	for pk, addr := range c.STCP.PubKeyTable {
+		addr, err := stcp.ParseAddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stcp pk_table address of %s: %v", pk, err)
+		}
+		entries[pk] = addr
+	}
+	if c.STCP.TableFile == "" {
+		return entries, nil
+	}
+	fileEntries, err := stcp.ReadTableFile(c.STCP.TableFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("invalid stcp pk_table_file: %v", err)
+	}
+	for pk, addr := range fileEntries {
+		entries[pk] = addr
+	}
+	return entries, nil
+}
+
 // TransportLogStore returns configure transport.LogStore.
 func (c *Config) TransportLogStore() (transport.LogStore, error) {
 	if c.Transport.LogStore.Type == "file" {
@@ -128,6 +279,15 @@ func (c *Config) TransportLogStore() (transport.LogStore, error) {
 	return transport.InMemoryTransportLogStore(), nil
 }
 
+// TransportLabelStore returns configured transport.LabelStore.
+func (c *Config) TransportLabelStore() (transport.LabelStore, error) {
+	if c.Transport.LabelStore.Type == "file" {
+		return transport.FileTransportLabelStore(c.Transport.LabelStore.Location)
+	}
+
+	return transport.InMemoryTransportLabelStore(), nil
+}
+
 // RoutingTable returns configure routing.Table.
 func (c *Config) RoutingTable() (routing.Table, error) {
 	if c.Routing.Table.Type == "boltdb" {
@@ -136,12 +296,27 @@ func (c *Config) RoutingTable() (routing.Table, error) {
 	return routing.InMemoryRoutingTable(), nil
 }
 
+// RouteFinderTransportWeights returns the validated cost weights of transport types used in route finding.
+func (c *Config) RouteFinderTransportWeights() (map[string]float64, error) {
+	for tpType, w := range c.Routing.TransportWeights {
+		if w <= 0 {
+			return nil, fmt.Errorf("weight of transport type '%s' must be positive", tpType)
+		}
+	}
+	return c.Routing.TransportWeights, nil
+}
+
 // AppsConfig decodes AppsConfig from a local json config file.
 func (c *Config) AppsConfig() ([]AppConfig, error) {
 	apps := make([]AppConfig, 0)
 	for _, app := range c.Apps {
 		if app.Version == "" {
-			app.Version = c.Version
+			app.Version = DefaultAppVersion
+		}
+		if app.Limits != nil {
+			if err := app.Limits.Validate(); err != nil {
+				return nil, fmt.Errorf("app %s: %v", app.App, err)
+			}
 		}
 		apps = append(apps, app)
 	}
@@ -189,7 +364,7 @@ func ensureDir(path string) (string, error) {
 // HypervisorConfig represents hypervisor configuration.
 type HypervisorConfig struct {
 	PubKey cipher.PubKey `json:"public_key"`
-	Addr   string        `json:"address"`
+	Addr   string        `json:"address"` // dialed by the visor, empty if the hypervisor connects over dmsg.
 }
 
 // DmsgConfig represents dmsg configuration.
@@ -201,6 +376,131 @@ type DmsgConfig struct {
 	RetryDelay time.Duration
 }
 
+// TransportNetworks returns the network types used for transports: dmsg, stcp and any enabled network drivers,
+// ordered by network_preference and excluding disabled_networks.
+func (c *Config) TransportNetworks() []string {
+	networks := []string{dmsg.Type, snet.STcpType}
+	extra := make([]string, 0, len(c.Networks))
+	for network := range c.Networks {
+		extra = append(extra, network)
+	}
+	sort.Strings(extra)
+	networks = append(networks, extra...)
+
+	rank := make(map[string]int, len(c.NetworkPreference))
+	for i, network := range c.NetworkPreference {
+		if _, ok := rank[network]; !ok {
+			rank[network] = i
+		}
+	}
+	sort.SliceStable(networks, func(i, j int) bool {
+		ri, iok := rank[networks[i]]
+		rj, jok := rank[networks[j]]
+		if iok && jok {
+			return ri < rj
+		}
+		return iok && !jok
+	})
+
+	enabled := networks[:0]
+	for _, network := range networks {
+		if !c.isNetworkDisabled(network) {
+			enabled = append(enabled, network)
+		}
+	}
+	return enabled
+}
+
+func (c *Config) isNetworkDisabled(network string) bool {
+	for _, disabled := range c.DisabledNetworks {
+		if disabled == network {
+			return true
+		}
+	}
+	return false
+}
+
+// STCPSimulationConfig configures network conditions which are simulated on stcp connections.
+// It is intended for local testing only.
+type STCPSimulationConfig struct {
+	Latency Duration `json:"latency"`        // delay added to each write
+	Jitter  Duration `json:"jitter"`         // maximum random deviation from latency
+	Loss    float64  `json:"loss,omitempty"` // probability of a write being dropped, in the range [0, 1]
+}
+
+// STCPNetSim returns the simulated network conditions of stcp connections, or nil if not configured.
+func (c *Config) STCPNetSim() *stcp.NetSim {
+	sim := c.STCP.Simulation
+	if sim == nil {
+		return nil
+	}
+	return &stcp.NetSim{
+		Latency: time.Duration(sim.Latency),
+		Jitter:  time.Duration(sim.Jitter),
+		Loss:    sim.Loss,
+	}
+}
+
+// TransportMaintenanceConfig configures the automatic transport maintenance policy.
+type TransportMaintenanceConfig struct {
+	MinTransports int             `json:"min_transports"`
+	MaxTransports int             `json:"max_transports"`           // 0 disables pruning
+	Type          string          `json:"type"`                     // type of created transports
+	Seeds         []cipher.PubKey `json:"seeds,omitempty"`          // defaults to trusted_nodes
+	CheckInterval Duration        `json:"check_interval,omitempty"` // defaults to 1m
+}
+
+// TransportMaintenance returns the transport maintenance policy.
+// The bool is false if the policy is not configured.
+func (c *Config) TransportMaintenance() (transport.MaintenanceConfig, bool) {
+	m := c.Transport.Maintenance
+	if m == nil || m.MinTransports <= 0 {
+		return transport.MaintenanceConfig{}, false
+	}
+	tpType := m.Type
+	if networks := c.TransportNetworks(); tpType == "" && len(networks) > 0 {
+		tpType = networks[0]
+	}
+	return transport.MaintenanceConfig{
+		MinTransports: m.MinTransports,
+		MaxTransports: m.MaxTransports,
+		Type:          tpType,
+		Seeds:         m.Seeds,
+		Interval:      time.Duration(m.CheckInterval),
+	}, true
+}
+
+// QuotaConfig configures a bandwidth quota (see transport.Quota).
+type QuotaConfig struct {
+	Remote cipher.PubKey `json:"remote,omitempty"` // remote visor of the quota, unless relay is set.
+	Relay  bool          `json:"relay,omitempty"`  // caps the bytes relayed for other visors.
+	Bytes  uint64        `json:"bytes"`            // maximum bytes per period.
+	Period string        `json:"period,omitempty"` // 'monthly' (default) or 'rolling'.
+	Window Duration      `json:"window,omitempty"` // window of rolling quotas, defaults to 720h.
+}
+
+// TransportQuotas returns the bandwidth quotas, and the file persisting their usage.
+func (c *Config) TransportQuotas() ([]transport.Quota, string, error) {
+	quotas := make([]transport.Quota, len(c.Transport.Quotas))
+	for i, q := range c.Transport.Quotas {
+		quotas[i] = transport.Quota{
+			Remote: q.Remote,
+			Relay:  q.Relay,
+			Bytes:  q.Bytes,
+			Period: transport.QuotaPeriod(q.Period),
+			Window: time.Duration(q.Window),
+		}
+		if err := quotas[i].Validate(); err != nil {
+			return nil, "", fmt.Errorf("quota %d: %v", i, err)
+		}
+	}
+	file := c.Transport.QuotaFile
+	if file == "" && len(quotas) > 0 {
+		file = filepath.Join(c.LocalPath, "bandwidth_usage.json")
+	}
+	return quotas, file, nil
+}
+
 // DmsgPtyConfig configures the dmsgpty-host.
 type DmsgPtyConfig struct {
 	Port     uint16 `json:"port"`
@@ -209,6 +509,16 @@ type DmsgPtyConfig struct {
 	CLIAddr  string `json:"cli_address"`
 }
 
+// DmsgHTTPConfig configures the HTTP API served over dmsg.
+type DmsgHTTPConfig struct {
+	Port uint16 `json:"port"` // defaults to skyenv.DmsgHTTPPort if 0.
+}
+
+// DmsgRPCConfig configures the RPC served to hypervisors over dmsg.
+type DmsgRPCConfig struct {
+	Port uint16 `json:"port"` // defaults to skyenv.DmsgRPCPort if 0.
+}
+
 // AppConfig defines app startup parameters.
 type AppConfig struct {
 	Version   string       `json:"version"`
@@ -216,11 +526,14 @@ type AppConfig struct {
 	AutoStart bool         `json:"auto_start"`
 	Port      routing.Port `json:"port"`
 	Args      []string     `json:"args"`
+	Limits    *AppLimits   `json:"limits,omitempty"` // resource usage limits, enforced while the app runs.
 }
 
 // InterfaceConfig defines listening interfaces for skywire visor.
 type InterfaceConfig struct {
 	RPCAddress string `json:"rpc"` // RPC address and port for command-line interface (leave blank to disable RPC interface).
+	// RPCToken is required to make mutating RPC calls on the RPC interface, if set (see RPCClient.Authenticate).
+	RPCToken string `json:"rpc_token,omitempty"`
 }
 
 // Duration wraps around time.Duration to allow parsing from and to JSON
@@ -139,3 +139,34 @@ func TestLocalDir(t *testing.T) {
 	_, err = os.Stat(dir)
 	assert.NoError(t, err)
 }
+
+func TestTransportNetworks(t *testing.T) {
+	conf := Config{Networks: map[string]json.RawMessage{"b": nil, "a": nil}}
+	assert.Equal(t, []string{"dmsg", "stcp", "a", "b"}, conf.TransportNetworks())
+
+	conf.NetworkPreference = []string{"b", "stcp", "unknown"}
+	assert.Equal(t, []string{"b", "stcp", "dmsg", "a"}, conf.TransportNetworks())
+
+	conf.DisabledNetworks = []string{"dmsg", "b"}
+	assert.Equal(t, []string{"stcp", "a"}, conf.TransportNetworks())
+
+	conf.Transport.Maintenance = &TransportMaintenanceConfig{MinTransports: 1}
+	mConf, ok := conf.TransportMaintenance()
+	require.True(t, ok)
+	assert.Equal(t, "stcp", mConf.Type)
+}
+
+func TestDmsgServerCount(t *testing.T) {
+	for count, want := range map[int]int{0: 1, 1: 1, 3: 3} {
+		conf := Config{}
+		conf.Messaging.ServerCount = count
+		got, err := conf.DmsgServerCount()
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	conf := Config{}
+	conf.Messaging.ServerCount = -1
+	_, err := conf.DmsgServerCount()
+	assert.Error(t, err)
+}
@@ -0,0 +1,343 @@
+package visor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RedactedValue replaces the values of secret fields in configs returned by EffectiveConfig and in config diffs.
+// Fields of config patches with this value are left unchanged.
+const RedactedValue = "[redacted]"
+
+// secretFields are the keys of the config fields which are redacted.
+var secretFields = map[string]bool{
+	"static_secret_key": true,
+	"sealed_secret_key": true,
+	"secret_key":        true,
+	"rpc_token":         true,
+}
+
+// reloadableFields are the top-level config fields which are applied at runtime by Reload.
+var reloadableFields = map[string]bool{
+	"apps":                  true,
+	"log_level":             true,
+	"persistent_transports": true,
+	"reward_address":        true,
+	"trusted_nodes":         true,
+	"trusted_visors":        true,
+}
+
+// ErrInvalidConfigPatch occurs when a config patch is not a JSON object.
+var ErrInvalidConfigPatch = errors.New("config patch must be a JSON object")
+
+// ConfigChange is a change of a field of the config file.
+type ConfigChange struct {
+	Field string          `json:"field"`         // JSON path of the field, such as 'apps[1].port'.
+	Old   json.RawMessage `json:"old,omitempty"` // absent if the field is added.
+	New   json.RawMessage `json:"new,omitempty"` // absent if the field is removed.
+}
+
+// ConfigDiff previews the changes of a config patch.
+type ConfigDiff struct {
+	Changes         []ConfigChange  `json:"changes"`
+	Problems        []ConfigProblem `json:"problems,omitempty"` // problems of the patched config, see CheckConfig.
+	Reloadable      []string        `json:"reloadable"`         // changed top-level fields applied by Reload.
+	RestartRequired []string        `json:"restart_required"`   // changed top-level fields applied on restart.
+}
+
+// UpdateConfigIn is input of UpdateConfig.
+type UpdateConfigIn struct {
+	Patch   json.RawMessage `json:"patch"`
+	Restart bool            `json:"restart"` // restarts the node if changes require a restart.
+}
+
+// UpdateConfigOut is output of UpdateConfig.
+type UpdateConfigOut struct {
+	ConfigDiff
+	Reload     *ReloadResult `json:"reload,omitempty"`
+	Restarting bool          `json:"restarting"`
+}
+
+// EffectiveConfig returns the config the node runs with, as JSON, with secret fields redacted.
+func (node *Node) EffectiveConfig() (json.RawMessage, error) {
+	node.reloadMu.Lock()
+	data, err := json.Marshal(node.conf)
+	node.reloadMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	var obj interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(redactConfig(obj), "", "  ")
+}
+
+// DiffConfig previews the changes of a JSON merge patch (RFC 7386) of the config file of the node, without
+// changing the file. The patch applies to the fields of the identity of the node.
+func (node *Node) DiffConfig(patch []byte) (*ConfigDiff, error) {
+	diff, _, err := node.patchConfigFile(patch)
+	return diff, err
+}
+
+// UpdateConfig applies a JSON merge patch (RFC 7386) to the config file of the node, and reloads the config.
+// The config file is only changed if the patched config has no problems. If in.Restart is set and changes require
+// a restart, the node is restarted with RequestRestart.
+func (node *Node) UpdateConfig(in UpdateConfigIn) (*UpdateConfigOut, error) {
+	diff, data, err := node.patchConfigFile(in.Patch)
+	if err != nil {
+		return nil, err
+	}
+	if len(diff.Problems) > 0 {
+		return nil, fmt.Errorf("patched config has problems: %v", diff.Problems)
+	}
+	out := &UpdateConfigOut{ConfigDiff: *diff}
+	if len(diff.Changes) == 0 {
+		return out, nil
+	}
+
+	path := filepath.Clean(node.conf.Path())
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(path, data, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	if out.Reload, err = node.Reload(); err != nil {
+		return nil, fmt.Errorf("config was updated, but failed to reload: %v", err)
+	}
+	if in.Restart && len(out.Reload.RestartRequired) > 0 {
+		if err := node.RequestRestart(); err != nil {
+			return nil, fmt.Errorf("config was updated, but failed to restart: %v", err)
+		}
+		out.Restarting = true
+	}
+	return out, nil
+}
+
+// patchConfigFile applies the patch to the config file, returning the diff and the data of the patched file.
+func (node *Node) patchConfigFile(patch []byte) (*ConfigDiff, []byte, error) {
+	path := node.conf.Path()
+	if path == "" {
+		return nil, nil, ErrNoConfigPath
+	}
+	data, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, nil, err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	obj, err := node.identityObject(raw, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	diff, err := patchConfig(obj, patch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if data, err = json.MarshalIndent(raw, "", "  "); err != nil {
+		return nil, nil, err
+	}
+	diff.Problems = CheckConfig(data, node.conf.Profile())
+	return diff, data, nil
+}
+
+// patchConfig applies the JSON merge patch to the config, as a generic JSON object, returning the diff.
+func patchConfig(obj map[string]interface{}, patch []byte) (*ConfigDiff, error) {
+	var p map[string]interface{}
+	if err := json.Unmarshal(patch, &p); err != nil || p == nil {
+		return nil, ErrInvalidConfigPatch
+	}
+	var prev map[string]interface{}
+	if err := remarshal(obj, &prev); err != nil {
+		return nil, err
+	}
+	mergeConfigPatch(obj, p)
+	return newConfigDiff(prev, obj), nil
+}
+
+// newConfigDiff returns the diff between the previous and the next config, as generic JSON objects.
+func newConfigDiff(prev, next map[string]interface{}) *ConfigDiff {
+	diff := &ConfigDiff{Changes: diffJSON(prev, next, ""), Reloadable: []string{}, RestartRequired: []string{}}
+	seen := make(map[string]bool)
+	for _, c := range diff.Changes {
+		key := c.Field
+		if i := strings.IndexAny(key, ".["); i >= 0 {
+			key = key[:i]
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if reloadableFields[key] {
+			diff.Reloadable = append(diff.Reloadable, key)
+		} else {
+			diff.RestartRequired = append(diff.RestartRequired, key)
+		}
+	}
+	return diff
+}
+
+// mergeConfigPatch applies the JSON merge patch to obj: objects are merged, null values remove fields, and other
+// values replace fields. Values which are RedactedValue are ignored.
+func mergeConfigPatch(obj, patch map[string]interface{}) {
+	for key, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(obj, key)
+		case map[string]interface{}:
+			dst, ok := obj[key].(map[string]interface{})
+			if !ok {
+				dst = make(map[string]interface{})
+				obj[key] = dst
+			}
+			mergeConfigPatch(dst, v)
+		case string:
+			if v != RedactedValue {
+				obj[key] = v
+			}
+		default:
+			obj[key] = restoreRedacted(v, obj[key])
+		}
+	}
+}
+
+// restoreRedacted replaces the RedactedValue values of next, such as of arrays which are replaced as a whole, with
+// the values at the same place of prev.
+func restoreRedacted(next, prev interface{}) interface{} {
+	switch n := next.(type) {
+	case string:
+		if n == RedactedValue {
+			return prev
+		}
+	case map[string]interface{}:
+		p, _ := prev.(map[string]interface{}) //nolint:errcheck
+		for key, v := range n {
+			n[key] = restoreRedacted(v, p[key])
+		}
+	case []interface{}:
+		p, _ := prev.([]interface{}) //nolint:errcheck
+		for i, v := range n {
+			var pv interface{}
+			if i < len(p) {
+				pv = p[i]
+			}
+			n[i] = restoreRedacted(v, pv)
+		}
+	}
+	return next
+}
+
+// diffJSON returns the changes between the generic JSON values, at the path. Objects are compared by field, arrays
+// of equal length by element, and other values as a whole.
+func diffJSON(prev, next interface{}, path string) []ConfigChange {
+	changes := make([]ConfigChange, 0)
+	switch p := prev.(type) {
+	case map[string]interface{}:
+		n, ok := next.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(p)+len(n))
+		for key := range p {
+			keys = append(keys, key)
+		}
+		for key := range n {
+			if _, ok := p[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+			pv, inPrev := p[key]
+			nv, inNext := n[key]
+			switch {
+			case !inPrev:
+				changes = append(changes, ConfigChange{Field: field, New: marshalRedacted(key, nv)})
+			case !inNext:
+				changes = append(changes, ConfigChange{Field: field, Old: marshalRedacted(key, pv)})
+			default:
+				changes = append(changes, diffJSON(pv, nv, field)...)
+			}
+		}
+		return changes
+	case []interface{}:
+		n, ok := next.([]interface{})
+		if !ok || len(p) != len(n) {
+			break
+		}
+		for i := range p {
+			changes = append(changes, diffJSON(p[i], n[i], fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return changes
+	}
+
+	key := path
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		key = key[i+1:]
+	}
+	// Changes of secret fields are reported, although their values are redacted.
+	oldData, newData := marshalRedacted(key, prev), marshalRedacted(key, next)
+	if !bytes.Equal(oldData, newData) || (secretFields[key] && !jsonEqual(prev, next)) {
+		changes = append(changes, ConfigChange{Field: path, Old: oldData, New: newData})
+	}
+	return changes
+}
+
+func jsonEqual(a, b interface{}) bool {
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
+
+// marshalRedacted marshals the value of the field with the key, redacting secret fields.
+func marshalRedacted(key string, v interface{}) json.RawMessage {
+	if secretFields[key] && v != nil && v != "" {
+		v = RedactedValue
+	} else {
+		v = redactConfig(v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// redactConfig replaces the values of secret fields of the generic JSON value.
+func redactConfig(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, fv := range v {
+			if secretFields[key] && fv != nil && fv != "" {
+				redacted[key] = RedactedValue
+			} else {
+				redacted[key] = redactConfig(fv)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, ev := range v {
+			redacted[i] = redactConfig(ev)
+		}
+		return redacted
+	default:
+		return v
+	}
+}
@@ -0,0 +1,107 @@
+package visor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchConfig(t *testing.T) {
+	obj := map[string]interface{}{
+		"node":      map[string]interface{}{"static_public_key": "pk", "static_secret_key": "sk"},
+		"log_level": "info",
+		"apps":      []interface{}{map[string]interface{}{"app": "skychat", "port": 1.0}},
+		"apps_path": "./apps",
+	}
+	patch := `{
+		"node": {"static_secret_key": "[redacted]"},
+		"log_level": "debug",
+		"apps": [{"app": "skychat", "port": 2}],
+		"apps_path": null,
+		"restart": {"after": "24h"}
+	}`
+	diff, err := patchConfig(obj, []byte(patch))
+	require.NoError(t, err)
+
+	assert.Equal(t, []ConfigChange{
+		{Field: "apps[0].port", Old: json.RawMessage(`1`), New: json.RawMessage(`2`)},
+		{Field: "apps_path", Old: json.RawMessage(`"./apps"`)},
+		{Field: "log_level", Old: json.RawMessage(`"info"`), New: json.RawMessage(`"debug"`)},
+		{Field: "restart", New: json.RawMessage(`{"after":"24h"}`)},
+	}, diff.Changes)
+	assert.Equal(t, []string{"apps", "log_level"}, diff.Reloadable)
+	assert.Equal(t, []string{"apps_path", "restart"}, diff.RestartRequired)
+	assert.Equal(t, "sk", obj["node"].(map[string]interface{})["static_secret_key"])
+
+	// Changes of secrets are reported with redacted values.
+	diff, err = patchConfig(obj, []byte(`{"node": {"static_secret_key": "other"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []ConfigChange{{
+		Field: "node.static_secret_key",
+		Old:   json.RawMessage(`"[redacted]"`),
+		New:   json.RawMessage(`"[redacted]"`),
+	}}, diff.Changes)
+
+	_, err = patchConfig(obj, []byte(`[]`))
+	assert.Equal(t, ErrInvalidConfigPatch, err)
+}
+
+func TestNode_UpdateConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_configedit")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "skywire-config.json")
+
+	initial := &Config{Version: ConfigVersion, LogLevel: "info", AppsPath: "./apps"}
+	initial.Node.StaticPubKey, initial.Node.StaticSecKey = cipher.GenerateKeyPair()
+	initial.Messaging.Discovery = "http://dmsg.discovery.skywire.skycoin.com"
+	data, err := json.Marshal(initial)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	conf, err := ReadConfig(path)
+	require.NoError(t, err)
+	node := &Node{conf: conf, startedApps: map[string]*appBind{},
+		Logger: logging.NewMasterLogger(), logger: logging.MustGetLogger("test")}
+
+	effective, err := node.EffectiveConfig()
+	require.NoError(t, err)
+	assert.Contains(t, string(effective), initial.Node.StaticPubKey.Hex())
+	assert.NotContains(t, string(effective), initial.Node.StaticSecKey.Hex())
+
+	// Patches with problems are not applied.
+	diff, err := node.DiffConfig([]byte(`{"log_level": "debug", "unknown": true}`))
+	require.NoError(t, err)
+	assert.NotEmpty(t, diff.Problems)
+	_, err = node.UpdateConfig(UpdateConfigIn{Patch: []byte(`{"log_level": "debug", "unknown": true}`)})
+	require.Error(t, err)
+
+	out, err := node.UpdateConfig(UpdateConfigIn{Patch: []byte(`{"log_level": "debug", "apps_path": "./bin"}`)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"log_level"}, out.Reload.Applied)
+	assert.Equal(t, []string{"apps_path"}, out.Reload.RestartRequired)
+	assert.False(t, out.Restarting)
+	assert.Equal(t, "debug", node.conf.LogLevel)
+
+	saved, err := ReadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "./bin", saved.AppsPath)
+	assert.Equal(t, initial.Node.StaticSecKey, saved.Node.StaticSecKey)
+
+	// Restarts are requested with the restarter of the node.
+	_, err = node.UpdateConfig(UpdateConfigIn{Patch: []byte(`{"apps_path": "./other"}`), Restart: true})
+	assert.EqualError(t, err, "config was updated, but failed to restart: "+ErrRestartUnsupported.Error())
+	restarted := make(chan struct{})
+	node.SetRestarter(func() { close(restarted) })
+	out, err = node.UpdateConfig(UpdateConfigIn{Patch: []byte(`{"apps_path": "./bin"}`), Restart: true})
+	require.NoError(t, err)
+	assert.True(t, out.Restarting)
+	<-restarted
+}
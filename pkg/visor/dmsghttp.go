@@ -0,0 +1,54 @@
+package visor
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/dmsghttp"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/httputil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
+)
+
+// HTTPHandler returns the HTTP API of the visor, which serves its summary at '/summary', the given metrics
+// handler (if not nil) at '/metrics', and the REST API at '/api/' if the 'rest_api' config field is defined.
+func (node *Node) HTTPHandler(metrics http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/summary", func(w http.ResponseWriter, r *http.Request) {
+		var summary Summary
+		if err := (&RPC{node: node}).Summary(nil, &summary); err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		httputil.WriteJSON(w, r, http.StatusOK, summary)
+	})
+	if metrics != nil {
+		mux.Handle("/metrics", metrics)
+	}
+	if node.conf.RESTAPI != nil {
+		mux.Handle("/api/", node.RESTAPIHandler(node.conf.RESTAPI.Token))
+	}
+	return mux
+}
+
+// ServeDmsgHTTP serves the handler over dmsg on the port of the 'dmsg_http' config, until the node is closed.
+// This allows hypervisors and tooling to reach the HTTP API of visors which have no public IP address.
+func (node *Node) ServeDmsgHTTP(h http.Handler) error {
+	if node.conf.DmsgHTTP == nil {
+		return errors.New("'dmsg_http' config field not defined")
+	}
+	port := node.conf.DmsgHTTP.Port
+	if port == 0 {
+		port = skyenv.DmsgHTTPPort
+	}
+	l, err := node.n.Listen(snet.DmsgType, port)
+	if err != nil {
+		return err
+	}
+	node.httpMu.Lock()
+	node.httpListener = l
+	node.httpMu.Unlock()
+
+	node.logger.Infof("Serving HTTP API over dmsg on port %d", port)
+	return dmsghttp.Serve(l, h)
+}
@@ -0,0 +1,50 @@
+package visor
+
+import (
+	"net/rpc"
+	"strings"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
+)
+
+// serveDmsgRPC serves rpcSvr over dmsg on the port of the 'dmsg_rpc' config, until the network of the node is
+// closed. Only the configured hypervisors may connect, as authenticated by their dmsg public keys.
+func (node *Node) serveDmsgRPC(rpcSvr *rpc.Server) error {
+	port := node.conf.DmsgRPC.Port
+	if port == 0 {
+		port = skyenv.DmsgRPCPort
+	}
+	hypervisors := make(map[cipher.PubKey]struct{}, len(node.conf.Hypervisors))
+	for _, h := range node.conf.Hypervisors {
+		hypervisors[h.PubKey] = struct{}{}
+	}
+
+	l, err := node.n.Listen(snet.DmsgType, port)
+	if err != nil {
+		return err
+	}
+	node.logger.Infof("Serving RPC to hypervisors over dmsg on port %d", port)
+	for {
+		conn, err := l.AcceptConn()
+		if err != nil {
+			if strings.Contains(err.Error(), "closed") {
+				return nil
+			}
+			return err
+		}
+		if _, ok := hypervisors[conn.RemotePK()]; !ok {
+			node.logger.Warnf("Rejected RPC connection over dmsg from %s: not a hypervisor", conn.RemotePK())
+			_ = conn.Close() //nolint:errcheck
+			continue
+		}
+		node.logger.Infof("Hypervisor %s connected over dmsg", conn.RemotePK())
+		go func(pk cipher.PubKey) {
+			node.hypervisors.connected(pk, HypervisorNetworkDmsg)
+			rpcSvr.ServeConn(conn)
+			node.hypervisors.disconnected(pk)
+		}(conn.RemotePK())
+	}
+}
@@ -0,0 +1,127 @@
+package visor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// EnvPrefix is the prefix of environment variables overriding config fields.
+const EnvPrefix = "SKYWIRE_"
+
+// envOverride overrides a config field with the value of the environment variable EnvPrefix+name.
+type envOverride struct {
+	name string
+	set  func(c *Config, v string) error
+}
+
+var envOverrides = []envOverride{
+	{"DMSG_DISCOVERY", setString(func(c *Config) *string { return &c.Messaging.Discovery })},
+	{"TRANSPORT_DISCOVERY", setString(func(c *Config) *string { return &c.Transport.Discovery })},
+	{"ROUTE_FINDER", setString(func(c *Config) *string { return &c.Routing.RouteFinder })},
+	{"UPTIME_TRACKER", setString(func(c *Config) *string { return &c.Uptime.Tracker })},
+	{"SETUP_NODES", func(c *Config, v string) error {
+		pks, err := parsePubKeys(v)
+		if err != nil {
+			return err
+		}
+		c.Routing.SetupNodes = pks
+		return nil
+	}},
+	{"TRUSTED_NODES", func(c *Config, v string) error {
+		pks, err := parsePubKeys(v)
+		if err != nil {
+			return err
+		}
+		c.TrustedNodes = pks
+		return nil
+	}},
+	{"STCP_ADDR", setString(func(c *Config) *string { return &c.STCP.LocalAddr })},
+	{"RPC_ADDR", setString(func(c *Config) *string { return &c.Interfaces.RPCAddress })},
+	{"REST_API_ADDR", func(c *Config, v string) error {
+		if c.RESTAPI == nil {
+			c.RESTAPI = new(RESTAPIConfig)
+		}
+		c.RESTAPI.Address = v
+		return nil
+	}},
+	{"DMSG_HTTP_PORT", func(c *Config, v string) error {
+		port, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			return err
+		}
+		if c.DmsgHTTP == nil {
+			c.DmsgHTTP = new(DmsgHTTPConfig)
+		}
+		c.DmsgHTTP.Port = uint16(port)
+		return nil
+	}},
+	{"DMSGPTY_PORT", func(c *Config, v string) error {
+		port, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			return err
+		}
+		if c.DmsgPty == nil {
+			return fmt.Errorf("dmsg_pty is not configured")
+		}
+		c.DmsgPty.Port = uint16(port)
+		return nil
+	}},
+	{"LOG_LEVEL", setString(func(c *Config) *string { return &c.LogLevel })},
+	{"APPS_PATH", setString(func(c *Config) *string { return &c.AppsPath })},
+	{"LOCAL_PATH", setString(func(c *Config) *string { return &c.LocalPath })},
+}
+
+func setString(field func(c *Config) *string) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+// parsePubKeys parses a comma-separated list of public keys.
+func parsePubKeys(v string) ([]cipher.PubKey, error) {
+	var pks []cipher.PubKey
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		var pk cipher.PubKey
+		if err := pk.Set(s); err != nil {
+			return nil, fmt.Errorf("invalid public key '%s': %v", s, err)
+		}
+		pks = append(pks, pk)
+	}
+	return pks, nil
+}
+
+// EnvOverrides returns the names of the environment variables which override config fields.
+func EnvOverrides() []string {
+	out := make([]string, len(envOverrides))
+	for i, o := range envOverrides {
+		out[i] = EnvPrefix + o.name
+	}
+	return out
+}
+
+// ApplyEnv overrides config fields with the values of environment variables, which makes containerized visors
+// configurable without templating the config file. lookup is typically os.LookupEnv.
+// Variables which are unset are ignored, while variables set to an empty string clear the field.
+// It returns the names of the applied variables.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) ([]string, error) {
+	var applied []string
+	for _, o := range envOverrides {
+		name := EnvPrefix + o.name
+		v, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := o.set(c, v); err != nil {
+			return applied, fmt.Errorf("invalid %s: %v", name, err)
+		}
+		applied = append(applied, name)
+	}
+	return applied, nil
+}
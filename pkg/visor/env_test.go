@@ -0,0 +1,44 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ApplyEnv(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+	env := map[string]string{
+		"SKYWIRE_DMSG_DISCOVERY": "http://dmsg.discovery",
+		"SKYWIRE_SETUP_NODES":    pk1.Hex() + ", " + pk2.Hex(),
+		"SKYWIRE_REST_API_ADDR":  ":8000",
+		"SKYWIRE_LOG_LEVEL":      "debug",
+		"SKYWIRE_UPTIME_TRACKER": "",
+		"OTHER":                  "ignored",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	conf := new(Config)
+	conf.Uptime.Tracker = "http://uptime.tracker"
+	conf.Transport.Discovery = "http://transport.discovery"
+
+	applied, err := conf.ApplyEnv(lookup)
+	require.NoError(t, err)
+	assert.Len(t, applied, 5)
+	assert.Equal(t, "http://dmsg.discovery", conf.Messaging.Discovery)
+	assert.Equal(t, []cipher.PubKey{pk1, pk2}, conf.Routing.SetupNodes)
+	assert.Equal(t, ":8000", conf.RESTAPI.Address)
+	assert.Equal(t, "debug", conf.LogLevel)
+	assert.Empty(t, conf.Uptime.Tracker)
+	assert.Equal(t, "http://transport.discovery", conf.Transport.Discovery)
+
+	env["SKYWIRE_TRUSTED_NODES"] = "invalid"
+	_, err = conf.ApplyEnv(lookup)
+	assert.Error(t, err)
+}
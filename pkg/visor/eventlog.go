@@ -0,0 +1,242 @@
+package visor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+// DefaultEventLogSize is the default number of events retained by the event log.
+const DefaultEventLogSize = 1000
+
+// EventType is the type of an event of the event log.
+type EventType string
+
+// Types of events recorded in the event log.
+const (
+	EventAppStarted       EventType = "app_started"
+	EventAppStopped       EventType = "app_stopped"
+	EventAppCrashed       EventType = "app_crashed"
+	EventTransportAdded   EventType = "transport_added"
+	EventTransportRemoved EventType = "transport_removed"
+	EventRouteFailed      EventType = "route_setup_failed"
+	EventConfigChanged    EventType = "config_changed"
+	EventAuthFailed       EventType = "auth_failed"
+)
+
+// Event is a significant event of the node, recorded in the event log.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    EventType `json:"type"`
+	Subject string    `json:"subject,omitempty"` // such as the app, transport or remote the event concerns.
+	Message string    `json:"message,omitempty"`
+}
+
+// EventLogConfig configures the event log.
+type EventLogConfig struct {
+	File string `json:"file,omitempty"` // defaults to 'events.log' in local_path, in memory only if "-".
+	Size int    `json:"size,omitempty"` // number of retained events, defaults to DefaultEventLogSize.
+}
+
+// EventFilter selects events of the event log.
+type EventFilter struct {
+	From  time.Time   `json:"from,omitempty"`  // inclusive, unbounded if zero.
+	To    time.Time   `json:"to,omitempty"`    // exclusive, unbounded if zero.
+	Types []EventType `json:"types,omitempty"` // all types if empty.
+}
+
+// Match returns whether the event is selected by the filter.
+func (f EventFilter) Match(e Event) bool {
+	if !f.From.IsZero() && e.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !e.Time.Before(f.To) {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// eventLog is a bounded log of events, persisted as JSON lines.
+// The file is compacted once it holds twice the retained events.
+type eventLog struct {
+	size   int
+	events []Event // oldest first.
+	path   string  // empty if not persisted.
+	lines  int     // number of events in the file.
+	mx     sync.Mutex
+}
+
+// newEventLog creates an event log, loading the retained events of the file at path if it exists.
+func newEventLog(path string, size int) (*eventLog, error) {
+	if size <= 0 {
+		size = DefaultEventLogSize
+	}
+	l := &eventLog{size: size, path: path}
+	if path == "" {
+		return l, nil
+	}
+
+	f, err := os.Open(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }() //nolint:errcheck
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // skip lines truncated by crashes.
+		}
+		l.events = append(l.events, e)
+		l.lines++
+		if len(l.events) > 2*size {
+			l.events = append(l.events[:0], l.events[len(l.events)-size:]...)
+		}
+	}
+	if len(l.events) > size {
+		l.events = append(l.events[:0], l.events[len(l.events)-size:]...)
+	}
+	return l, scanner.Err()
+}
+
+// record appends an event to the log.
+func (l *eventLog) record(e Event) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.events = append(l.events, e)
+	if len(l.events) > l.size {
+		l.events = append(l.events[:0], l.events[len(l.events)-l.size:]...)
+	}
+	if l.path == "" {
+		return nil
+	}
+
+	if l.lines >= 2*l.size {
+		return l.compact()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		l.lines++
+	}
+	return err
+}
+
+// compact rewrites the file with the retained events.
+func (l *eventLog) compact() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range l.events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	if err := writeFileAtomic(l.path, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	l.lines = len(l.events)
+	return nil
+}
+
+// query returns the retained events selected by the filter, oldest first.
+func (l *eventLog) query(f EventFilter) []Event {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	out := make([]Event, 0)
+	for _, e := range l.events {
+		if f.Match(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// eventLogPath returns the path of the file of the event log, or an empty string if it is not persisted.
+func (c *Config) eventLogPath(localPath string) string {
+	if c.EventLog != nil && c.EventLog.File != "" {
+		if c.EventLog.File == "-" {
+			return ""
+		}
+		return c.EventLog.File
+	}
+	return filepath.Join(localPath, "events.log")
+}
+
+// RecordEvent records an event in the event log of the node, such as on behalf of plugins, and publishes it to
+// subscribers.
+func (node *Node) RecordEvent(t EventType, subject, format string, args ...interface{}) {
+	e := Event{Time: time.Now(), Type: t, Subject: subject, Message: fmt.Sprintf(format, args...)}
+	node.publishEvent(e)
+	if node.events == nil {
+		return
+	}
+	if err := node.events.record(e); err != nil {
+		node.logger.WithError(err).Warn("Failed to record event")
+	}
+}
+
+// Events returns the recorded events selected by the filter, oldest first.
+func (node *Node) Events(f EventFilter) []Event {
+	if node.events == nil {
+		return []Event{}
+	}
+	return node.events.query(f)
+}
+
+// recordTransportEvent records the establishment and closure of transports.
+func (node *Node) recordTransportEvent(e transport.Event) {
+	switch e.Type {
+	case transport.EventEstablished:
+		node.RecordEvent(EventTransportAdded, e.TpID.String(), "%s transport to %s", e.TpType, e.RemotePK)
+	case transport.EventClosed:
+		node.RecordEvent(EventTransportRemoved, e.TpID.String(), "%s transport to %s: %s", e.TpType, e.RemotePK, e.Reason)
+	}
+}
+
+// recordRouteEvents records failures to set up routes, and publishes loop events, until the router is closed.
+func (node *Node) recordRouteEvents() {
+	r, ok := node.router.(routeObserver)
+	if !ok {
+		return
+	}
+	events, _ := r.Observe()
+	for e := range events {
+		if e.Type == router.EventLoopSetupFailed {
+			node.RecordEvent(EventRouteFailed, e.Loop.Remote.String(), "loop from %s: %s", e.Loop.Local, e.Error)
+			continue
+		}
+		node.publishRouteEvent(e)
+	}
+}
@@ -0,0 +1,52 @@
+package visor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_events")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "events.log")
+
+	l, err := newEventLog(path, 3)
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 8; i++ {
+		e := Event{Time: start.Add(time.Duration(i) * time.Second), Type: EventAppStarted, Subject: "foo"}
+		if i%2 == 1 {
+			e.Type = EventAppStopped
+		}
+		require.NoError(t, l.record(e))
+	}
+
+	events := l.query(EventFilter{})
+	require.Len(t, events, 3)
+	assert.Equal(t, start.Add(5*time.Second).Unix(), events[0].Time.Unix())
+
+	stopped := l.query(EventFilter{Types: []EventType{EventAppStopped}})
+	assert.Len(t, stopped, 2)
+
+	ranged := l.query(EventFilter{From: start.Add(6 * time.Second), To: start.Add(7 * time.Second)})
+	require.Len(t, ranged, 1)
+	assert.Equal(t, EventAppStarted, ranged[0].Type)
+
+	// The file is compacted, and the retained events are loaded on restart.
+	assert.True(t, l.lines <= 6)
+	reloaded, err := newEventLog(path, 3)
+	require.NoError(t, err)
+	assert.Equal(t, len(events), len(reloaded.query(EventFilter{})))
+	for i, e := range reloaded.query(EventFilter{}) {
+		assert.True(t, e.Time.Equal(events[i].Time))
+		assert.Equal(t, events[i].Type, e.Type)
+	}
+}
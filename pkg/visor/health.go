@@ -0,0 +1,176 @@
+package visor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/disc"
+)
+
+// HealthCheckTimeout is the timeout of each check of an external service.
+// Services are checked concurrently, so this is below the timeout of health requests of the hypervisor.
+const HealthCheckTimeout = 3 * time.Second
+
+// Names of the external services reported by Health.
+const (
+	ServiceDmsgDiscovery      = "dmsg_discovery"
+	ServiceTransportDiscovery = "transport_discovery"
+	ServiceRouteFinder        = "route_finder"
+	ServiceSetupNode          = "setup_node"
+)
+
+// ServiceVersionHeader is the response header from which the version of a service is reported, if set.
+// Services which do not set it are reported with the 'Server' header instead.
+const ServiceVersionHeader = "X-Skywire-Version"
+
+// ServiceHealth is the result of checking an external service the visor depends on.
+type ServiceHealth struct {
+	Service string        `json:"service"`
+	Address string        `json:"address"`           // URL of the service, or public key of setup nodes.
+	Status  int           `json:"status"`            // HTTP status code of the response, 0 if the service is unreachable.
+	Latency time.Duration `json:"latency"`           // time taken for the service to respond.
+	Version string        `json:"version,omitempty"` // version of the service, as reported by its response headers.
+	Error   string        `json:"error,omitempty"`   // why the service is unhealthy.
+}
+
+// Healthy returns whether the service responded successfully.
+func (s ServiceHealth) Healthy() bool {
+	return s.Status == http.StatusOK && s.Error == ""
+}
+
+// code summarizes the health of the service as a HTTP status code.
+func (s ServiceHealth) code() int {
+	if s.Healthy() {
+		return http.StatusOK
+	}
+	if s.Status == 0 || s.Status == http.StatusOK {
+		return http.StatusServiceUnavailable
+	}
+	return s.Status
+}
+
+// CheckHealth concurrently checks the external services of the config.
+// HTTP services are checked via their '/health' endpoints, and setup nodes via their dmsg discovery entries, which
+// are only advertised with delegated servers while setup nodes have sessions with dmsg servers.
+func CheckHealth(ctx context.Context, conf *Config) *HealthInfo {
+	var checks []func() ServiceHealth
+	if addr := conf.Messaging.Discovery; addr != "" {
+		checks = append(checks, func() ServiceHealth {
+			return checkService(ctx, ServiceDmsgDiscovery, addr, healthURL(addr), nil)
+		})
+	}
+	if addr := conf.Transport.Discovery; addr != "" {
+		checks = append(checks, func() ServiceHealth {
+			return checkService(ctx, ServiceTransportDiscovery, addr, healthURL(addr), nil)
+		})
+	}
+	if addr := conf.Routing.RouteFinder; addr != "" {
+		checks = append(checks, func() ServiceHealth {
+			return checkService(ctx, ServiceRouteFinder, addr, healthURL(addr), nil)
+		})
+	}
+	for _, pk := range conf.Routing.SetupNodes {
+		pk := pk
+		checks = append(checks, func() ServiceHealth {
+			if conf.Messaging.Discovery == "" {
+				return ServiceHealth{Service: ServiceSetupNode, Address: pk.Hex(), Error: "empty dmsg discovery"}
+			}
+			return checkService(ctx, ServiceSetupNode, pk.Hex(), entryURL(conf.Messaging.Discovery, pk), checkEntry)
+		})
+	}
+
+	services := make([]ServiceHealth, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check func() ServiceHealth) {
+			services[i] = check()
+			wg.Done()
+		}(i, check)
+	}
+	wg.Wait()
+
+	info := &HealthInfo{
+		DmsgDiscovery:      http.StatusNotFound,
+		TransportDiscovery: http.StatusNotFound,
+		RouteFinder:        http.StatusNotFound,
+		SetupNode:          http.StatusNotFound,
+		Services:           services,
+	}
+	for _, s := range services {
+		switch s.Service {
+		case ServiceDmsgDiscovery:
+			info.DmsgDiscovery = s.code()
+		case ServiceTransportDiscovery:
+			info.TransportDiscovery = s.code()
+		case ServiceRouteFinder:
+			info.RouteFinder = s.code()
+		case ServiceSetupNode:
+			// Routes may be set up as long as one setup node is healthy.
+			if info.SetupNode != http.StatusOK {
+				info.SetupNode = s.code()
+			}
+		}
+	}
+	return info
+}
+
+func checkService(ctx context.Context, service, addr, url string, check func(*http.Response) error) ServiceHealth {
+	ctx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
+	defer cancel()
+
+	s := ServiceHealth{Service: service, Address: addr}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	s.Latency = time.Since(start)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
+
+	s.Status = resp.StatusCode
+	if s.Version = resp.Header.Get(ServiceVersionHeader); s.Version == "" {
+		s.Version = resp.Header.Get("Server")
+	}
+	switch {
+	case resp.StatusCode != http.StatusOK:
+		s.Error = fmt.Sprintf("unexpected response: %s", resp.Status)
+	case check != nil:
+		if err := check(resp); err != nil {
+			s.Error = err.Error()
+		}
+	}
+	return s
+}
+
+func checkEntry(resp *http.Response) error {
+	var entry disc.Entry
+	if err := json.NewDecoder(resp.Body).Decode(&entry); err != nil {
+		return fmt.Errorf("invalid entry: %v", err)
+	}
+	if entry.Client == nil || len(entry.Client.DelegatedServers) == 0 {
+		return errors.New("no sessions with dmsg servers")
+	}
+	return nil
+}
+
+func healthURL(addr string) string {
+	return strings.TrimSuffix(addr, "/") + "/health"
+}
+
+func entryURL(discAddr string, pk cipher.PubKey) string {
+	return fmt.Sprintf("%s/messaging-discovery/entry/%s", strings.TrimSuffix(discAddr, "/"), pk)
+}
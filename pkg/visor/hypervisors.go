@@ -0,0 +1,268 @@
+package visor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/noise"
+)
+
+// Delays between attempts to connect to hypervisors, which double on each failure up to the maximum.
+const (
+	hypervisorMinRetry = time.Second
+	hypervisorMaxRetry = time.Minute
+)
+
+// Networks over which nodes connect to hypervisors.
+const (
+	HypervisorNetworkTCP  = "tcp"
+	HypervisorNetworkDmsg = "dmsg"
+)
+
+// HypervisorStatus is the status of the connection of the node to one of its hypervisors.
+type HypervisorStatus struct {
+	PubKey    cipher.PubKey `json:"public_key"`
+	Addr      string        `json:"address,omitempty"` // empty if the hypervisor connects over dmsg.
+	Connected bool          `json:"connected"`
+	Network   string        `json:"network,omitempty"` // network of the connection, if connected.
+	Since     time.Time     `json:"since,omitempty"`   // time of the last connection or disconnection.
+	LastError string        `json:"last_error,omitempty"`
+
+	conns int // open connections, over either network.
+}
+
+// hypervisorStatuses tracks the connections of the node to its hypervisors, in the order of the config.
+type hypervisorStatuses struct {
+	list []*HypervisorStatus
+	mu   sync.Mutex
+}
+
+func newHypervisorStatuses(entries []HypervisorConfig) *hypervisorStatuses {
+	s := &hypervisorStatuses{}
+	for _, entry := range entries {
+		s.list = append(s.list, &HypervisorStatus{PubKey: entry.PubKey, Addr: entry.Addr})
+	}
+	return s
+}
+
+func (s *hypervisorStatuses) status(pk cipher.PubKey) *HypervisorStatus {
+	for _, hs := range s.list {
+		if hs.PubKey == pk {
+			return hs
+		}
+	}
+	hs := &HypervisorStatus{PubKey: pk}
+	s.list = append(s.list, hs)
+	return hs
+}
+
+func (s *hypervisorStatuses) connected(pk cipher.PubKey, network string) {
+	s.mu.Lock()
+	hs := s.status(pk)
+	hs.conns++
+	hs.Connected, hs.Network, hs.Since, hs.LastError = true, network, time.Now(), ""
+	s.mu.Unlock()
+}
+
+func (s *hypervisorStatuses) disconnected(pk cipher.PubKey) {
+	s.mu.Lock()
+	hs := s.status(pk)
+	if hs.conns--; hs.conns <= 0 {
+		hs.conns = 0
+		hs.Connected, hs.Network, hs.Since = false, "", time.Now()
+	}
+	s.mu.Unlock()
+}
+
+func (s *hypervisorStatuses) failed(pk cipher.PubKey, err error) {
+	s.mu.Lock()
+	s.status(pk).LastError = err.Error()
+	s.mu.Unlock()
+}
+
+func (s *hypervisorStatuses) all() []HypervisorStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]HypervisorStatus, len(s.list))
+	for i, hs := range s.list {
+		out[i] = *hs
+	}
+	return out
+}
+
+// Hypervisors returns the status of the connection to each hypervisor of the node.
+func (node *Node) Hypervisors() []HypervisorStatus {
+	if node.hypervisors == nil {
+		return []HypervisorStatus{}
+	}
+	return node.hypervisors.all()
+}
+
+// hypervisorDialer connects to a hypervisor over TCP, serving RPC to it, and reconnects whenever the connection is
+// lost or fails. Unlike noise.RPCClientDialer, failed handshakes are retried too, so that a hypervisor which is
+// temporarily misconfigured or replaced does not orphan the node.
+type hypervisorDialer struct {
+	entry  HypervisorConfig
+	config noise.Config
+
+	conn   net.Conn
+	closed bool
+	done   chan struct{}
+	mu     sync.Mutex
+}
+
+func newHypervisorDialer(entry HypervisorConfig, pk cipher.PubKey, sk cipher.SecKey) *hypervisorDialer {
+	return &hypervisorDialer{
+		entry: entry,
+		config: noise.Config{
+			LocalPK:   pk,
+			LocalSK:   sk,
+			RemotePK:  entry.PubKey,
+			Initiator: true,
+		},
+		done: make(chan struct{}),
+	}
+}
+
+// run serves srv to the hypervisor until the dialer is closed, reporting the connection to statuses.
+func (d *hypervisorDialer) run(srv *rpc.Server, statuses *hypervisorStatuses) {
+	retry := hypervisorMinRetry
+	for {
+		conn, err := d.dial()
+		if err != nil {
+			statuses.failed(d.entry.PubKey, err)
+		} else if d.setConn(conn) {
+			statuses.connected(d.entry.PubKey, HypervisorNetworkTCP)
+			srv.ServeConn(conn)
+			statuses.disconnected(d.entry.PubKey)
+			d.setConn(nil)
+			retry = hypervisorMinRetry
+		}
+		select {
+		case <-d.done:
+			return
+		case <-time.After(retry):
+		}
+		if retry *= 2; retry > hypervisorMaxRetry {
+			retry = hypervisorMaxRetry
+		}
+	}
+}
+
+func (d *hypervisorDialer) dial() (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", d.entry.Addr, noise.AcceptHandshakeTimeout)
+	if err != nil {
+		return nil, err
+	}
+	ns, err := noise.New(noise.HandshakeXK, d.config)
+	if err != nil {
+		_ = conn.Close() //nolint:errcheck
+		return nil, err
+	}
+	nc, err := noise.WrapConn(conn, ns, noise.AcceptHandshakeTimeout)
+	if err != nil {
+		_ = conn.Close() //nolint:errcheck
+		return nil, err
+	}
+	return nc, nil
+}
+
+// setConn sets the connection to be closed on Close, or closes conn and returns false if already closed.
+func (d *hypervisorDialer) setConn(conn net.Conn) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed && conn != nil {
+		_ = conn.Close() //nolint:errcheck
+		return false
+	}
+	d.conn = conn
+	return true
+}
+
+// Close stops the dialer and closes its connection.
+func (d *hypervisorDialer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	close(d.done)
+	if d.conn != nil {
+		return d.conn.Close()
+	}
+	return nil
+}
+
+// SharedState is the state which hypervisors keep about the node, such as its tags. It is stored by the node, so
+// that all hypervisors of the node see the same state, and is replaced by the most recent update.
+type SharedState struct {
+	Tags      []string  `json:"tags"`
+	UpdatedAt time.Time `json:"updated_at"` // zero if the state was never set.
+}
+
+// sharedStateFile is the file of the local path storing the shared state.
+const sharedStateFile = "shared_state.json"
+
+// SharedState returns the state shared by the hypervisors of the node.
+func (node *Node) SharedState() (SharedState, error) {
+	node.sharedMu.Lock()
+	defer node.sharedMu.Unlock()
+	if err := node.loadSharedState(); err != nil {
+		return SharedState{}, err
+	}
+	return *node.shared, nil
+}
+
+// SetSharedState replaces the shared state, unless it was updated after state, and returns the resulting state.
+func (node *Node) SetSharedState(state SharedState) (SharedState, error) {
+	node.sharedMu.Lock()
+	defer node.sharedMu.Unlock()
+	if err := node.loadSharedState(); err != nil {
+		return SharedState{}, err
+	}
+	if !state.UpdatedAt.After(node.shared.UpdatedAt) {
+		return *node.shared, nil
+	}
+	if node.localPath != "" {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return SharedState{}, err
+		}
+		if err := writeFileAtomic(filepath.Join(node.localPath, sharedStateFile), data, 0600); err != nil {
+			return SharedState{}, err
+		}
+	}
+	node.shared = &state
+	return state, nil
+}
+
+// loadSharedState reads the shared state from the local path on first use. The state is only kept in memory if the
+// node has no local path.
+func (node *Node) loadSharedState() error {
+	if node.shared != nil {
+		return nil
+	}
+	state := SharedState{}
+	if node.localPath != "" {
+		data, err := ioutil.ReadFile(filepath.Join(node.localPath, sharedStateFile))
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return err
+		default:
+			if err := json.Unmarshal(data, &state); err != nil {
+				return err
+			}
+		}
+	}
+	node.shared = &state
+	return nil
+}
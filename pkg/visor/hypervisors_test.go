@@ -0,0 +1,87 @@
+package visor
+
+import (
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/noise"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHypervisorDialer(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	hvPK, hvSK := cipher.GenerateKeyPair()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	entry := HypervisorConfig{PubKey: hvPK, Addr: l.Addr().String()}
+	node := &Node{hypervisors: newHypervisorStatuses([]HypervisorConfig{entry})}
+	srv := rpc.NewServer()
+	require.NoError(t, srv.RegisterName(RPCPrefix, &RPC{node: node}))
+
+	d := newHypervisorDialer(entry, pk, sk)
+	exited := make(chan struct{})
+	go func() {
+		d.run(srv, node.hypervisors)
+		close(exited)
+	}()
+
+	// Failed handshakes are retried.
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	conn, err = noise.WrapListener(l, hvPK, hvSK, false, noise.HandshakeXK).Accept()
+	require.NoError(t, err)
+
+	var statuses []HypervisorStatus
+	require.NoError(t, rpc.NewClient(conn).Call(RPCPrefix+".Hypervisors", &struct{}{}, &statuses))
+	require.Len(t, statuses, 1)
+	assert.Equal(t, hvPK, statuses[0].PubKey)
+	assert.True(t, statuses[0].Connected)
+	assert.Equal(t, HypervisorNetworkTCP, statuses[0].Network)
+	assert.Empty(t, statuses[0].LastError)
+
+	require.NoError(t, d.Close())
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("dialer did not exit on close")
+	}
+	assert.False(t, node.Hypervisors()[0].Connected)
+}
+
+func TestNode_SetSharedState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_sharedstate")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	node := &Node{localPath: dir}
+	state, err := node.SharedState()
+	require.NoError(t, err)
+	assert.True(t, state.UpdatedAt.IsZero())
+
+	now := time.Now().UTC().Round(time.Second)
+	set := SharedState{Tags: []string{"site:berlin"}, UpdatedAt: now}
+	state, err = node.SetSharedState(set)
+	require.NoError(t, err)
+	assert.Equal(t, set, state)
+
+	// Older updates, such as of a hypervisor which was offline, do not replace newer ones.
+	state, err = node.SetSharedState(SharedState{Tags: []string{"old"}, UpdatedAt: now.Add(-time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, set, state)
+
+	// The state is stored in the local path.
+	state, err = (&Node{localPath: dir}).SharedState()
+	require.NoError(t, err)
+	assert.Equal(t, set.Tags, state.Tags)
+	assert.True(t, set.UpdatedAt.Equal(state.UpdatedAt))
+}
@@ -0,0 +1,162 @@
+package visor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// ErrIdentityKeys occurs when an identity of the config does not have its own node keys.
+var ErrIdentityKeys = errors.New("identities must have their own node keys")
+
+// IdentityNames returns the sorted names of the additional identities of the config.
+func (c *Config) IdentityNames() []string {
+	names := make([]string, 0, len(c.Identities))
+	for name := range c.Identities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Identity returns the name of the identity of the config, or an empty string for the primary identity.
+func (c *Config) Identity() string {
+	return c.identity
+}
+
+// IdentityConfigs returns the config of each identity run by the visor process: the config itself for the primary
+// identity, followed by the additional identities in order of name.
+// Each identity is merged over the config like a profile (see ApplyProfile), and must override the node keys.
+// Its local_path defaults to a directory named after the identity in the local_path of the config.
+// Listening addresses, local_path and the locations of stores must differ between identities.
+func (c *Config) IdentityConfigs() ([]*Config, error) {
+	confs := []*Config{c}
+	for _, name := range c.IdentityNames() {
+		conf, err := c.identityConfig(name)
+		if err != nil {
+			return nil, fmt.Errorf("identity '%s': %v", name, err)
+		}
+		confs = append(confs, conf)
+	}
+	if err := checkIdentities(confs); err != nil {
+		return nil, err
+	}
+	return confs, nil
+}
+
+// identityConfig returns the config of the named identity.
+func (c *Config) identityConfig(name string) (*Config, error) {
+	identity, ok := c.Identities[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown identity (available identities: %v)", c.IdentityNames())
+	}
+	var override map[string]interface{}
+	if err := json.Unmarshal(identity, &override); err != nil {
+		return nil, err
+	}
+	if _, ok := override["node"]; !ok {
+		return nil, ErrIdentityKeys
+	}
+	delete(override, "identities")
+	delete(override, "profiles")
+	if _, ok := override["local_path"]; !ok {
+		override["local_path"] = filepath.Join(c.LocalPath, name)
+	}
+
+	base, err := c.jsonObject()
+	if err != nil {
+		return nil, err
+	}
+	delete(base, "identities")
+	delete(base, "node")         // keys of the primary identity, which may be sealed unlike those of the identity.
+	delete(base, "rotated_keys") // old keys of the primary identity.
+	data, err := json.Marshal(mergeJSON(base, override))
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &Config{path: c.path, profile: c.profile, identity: name}
+	if err := json.Unmarshal(data, conf); err != nil {
+		return nil, err
+	}
+	if conf.Node.StaticPubKey.Null() {
+		return nil, ErrIdentityKeys
+	}
+	return conf, nil
+}
+
+// checkIdentities checks that identities do not share keys, listening addresses, local paths or stores.
+func checkIdentities(confs []*Config) error {
+	pks := make(map[cipher.PubKey]string, len(confs))
+	used := make(map[string]string)
+	use := func(field, value, identity string) error {
+		if value == "" {
+			return nil
+		}
+		key := field + "=" + value
+		if other, ok := used[key]; ok {
+			return fmt.Errorf("identities '%s' and '%s' share %s %s", other, identity, field, value)
+		}
+		used[key] = identity
+		return nil
+	}
+
+	for _, conf := range confs {
+		name := conf.identity
+		if name == "" {
+			name = "primary"
+		}
+		pk := conf.Node.StaticPubKey
+		if other, ok := pks[pk]; ok {
+			return fmt.Errorf("identities '%s' and '%s' share public key %s", other, name, pk)
+		}
+		pks[pk] = name
+
+		localPath, err := filepath.Abs(conf.LocalPath)
+		if err != nil {
+			return err
+		}
+		if err := use("local_path", localPath, name); err != nil {
+			return err
+		}
+		if err := use("interfaces.rpc", conf.Interfaces.RPCAddress, name); err != nil {
+			return err
+		}
+		if err := use("stcp.local_address", conf.STCP.LocalAddr, name); err != nil {
+			return err
+		}
+		if err := use("transport.quota_file", conf.Transport.QuotaFile, name); err != nil {
+			return err
+		}
+		if conf.Transport.LogStore.Type == "file" {
+			if err := use("transport.log_store.location", conf.Transport.LogStore.Location, name); err != nil {
+				return err
+			}
+		}
+		if conf.Transport.LabelStore.Type == "file" {
+			if err := use("transport.label_store.location", conf.Transport.LabelStore.Location, name); err != nil {
+				return err
+			}
+		}
+		if conf.Routing.Table.Type == "boltdb" {
+			if err := use("routing.table.location", conf.Routing.Table.Location, name); err != nil {
+				return err
+			}
+		}
+		if conf.RESTAPI != nil {
+			if err := use("rest_api.address", conf.RESTAPI.Address, name); err != nil {
+				return err
+			}
+		}
+		if conf.DmsgPty != nil {
+			if err := use("dmsg_pty.cli_address", conf.DmsgPty.CLIAddr, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package visor
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_IdentityConfigs(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	pk1, sk1 := cipher.GenerateKeyPair()
+	keys := func(pk cipher.PubKey, sk cipher.SecKey) string {
+		return fmt.Sprintf(`"node":{"static_public_key":"%s","static_secret_key":"%s"}`, pk, sk)
+	}
+
+	conf := new(Config)
+	conf.Version = ConfigVersion
+	conf.Node.StaticPubKey, conf.Node.StaticSecKey = pk, sk
+	conf.Messaging.Discovery = "http://dmsg.discovery"
+	conf.LocalPath = "local"
+	conf.Interfaces.RPCAddress = "localhost:3435"
+	conf.Identities = map[string]json.RawMessage{
+		"one": json.RawMessage(`{` + keys(pk1, sk1) + `,"interfaces":{"rpc":"localhost:3436"}}`),
+	}
+
+	confs, err := conf.IdentityConfigs()
+	require.NoError(t, err)
+	require.Len(t, confs, 2)
+	assert.Equal(t, conf, confs[0])
+	assert.Empty(t, confs[0].Identity())
+
+	one := confs[1]
+	assert.Equal(t, "one", one.Identity())
+	assert.Equal(t, pk1, one.Node.StaticPubKey)
+	assert.Equal(t, sk1, one.Node.StaticSecKey)
+	assert.Equal(t, "localhost:3436", one.Interfaces.RPCAddress)
+	assert.Equal(t, filepath.Join("local", "one"), one.LocalPath)
+	assert.Equal(t, conf.Messaging.Discovery, one.Messaging.Discovery)
+	assert.Empty(t, one.Identities)
+
+	// Identities need their own keys and listening addresses.
+	conf.Identities["two"] = json.RawMessage(`{"interfaces":{"rpc":"localhost:3437"}}`)
+	_, err = conf.IdentityConfigs()
+	assert.Error(t, err)
+
+	conf.Identities["two"] = json.RawMessage(`{` + keys(pk1, sk1) + `,"interfaces":{"rpc":"localhost:3437"}}`)
+	_, err = conf.IdentityConfigs()
+	assert.Error(t, err)
+
+	pk2, sk2 := cipher.GenerateKeyPair()
+	conf.Identities["two"] = json.RawMessage(`{` + keys(pk2, sk2) + `}`)
+	_, err = conf.IdentityConfigs()
+	assert.Error(t, err)
+
+	conf.Identities["two"] = json.RawMessage(`{` + keys(pk2, sk2) + `,"interfaces":{"rpc":""}}`)
+	confs, err = conf.IdentityConfigs()
+	require.NoError(t, err)
+	assert.Len(t, confs, 3)
+}
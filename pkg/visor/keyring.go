@@ -0,0 +1,73 @@
+package visor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// KeyringService is the service under which passphrases of sealed secret keys are stored in the OS keyring, with the
+// public key of the node as the account.
+const KeyringService = "skywire-visor"
+
+// ErrKeyringUnsupported occurs when the OS keyring is not supported on the platform.
+var ErrKeyringUnsupported = errors.New("OS keyring is not supported on this platform")
+
+// runKeyring runs a command of the OS keyring, returning its output. It is a variable so that it may be replaced
+// in tests.
+var runKeyring = func(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...) //nolint:gosec
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// keyringPassphrase returns the passphrase stored in the OS keyring for the public key.
+// The keyring is accessed with secret-tool (libsecret) on Linux, and with security on macOS.
+func keyringPassphrase(pk cipher.PubKey) ([]byte, error) {
+	var out []byte
+	var err error
+	switch runtime.GOOS {
+	case "linux":
+		out, err = runKeyring(nil, "secret-tool", "lookup", "service", KeyringService, "account", pk.Hex())
+	case "darwin":
+		out, err = runKeyring(nil, "security", "find-generic-password", "-s", KeyringService, "-a", pk.Hex(), "-w")
+	default:
+		return nil, ErrKeyringUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	out = bytes.TrimRight(out, "\n")
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no passphrase for %s in keyring", pk)
+	}
+	return out, nil
+}
+
+// storeKeyringPassphrase stores the passphrase in the OS keyring for the public key.
+func storeKeyringPassphrase(pk cipher.PubKey, passphrase []byte) error {
+	var err error
+	switch runtime.GOOS {
+	case "linux":
+		_, err = runKeyring(passphrase, "secret-tool", "store", "--label", "Skywire visor "+pk.Hex(),
+			"service", KeyringService, "account", pk.Hex())
+	case "darwin":
+		// security only accepts the password as an argument, so the passphrase is random (see SealKey).
+		_, err = runKeyring(nil, "security", "add-generic-password", "-U", "-s", KeyringService, "-a", pk.Hex(),
+			"-w", string(passphrase))
+	default:
+		return ErrKeyringUnsupported
+	}
+	return err
+}
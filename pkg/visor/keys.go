@@ -0,0 +1,245 @@
+package visor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+	trClient "github.com/SkycoinProject/skywire-mainnet/pkg/transport-discovery/client"
+)
+
+// DefaultKeyGracePeriod is the default period for which a rotated key remains announced as deprecated.
+const DefaultKeyGracePeriod = 7 * 24 * time.Hour
+
+// RotatedKey is a previous keypair of the node, retired by a key rotation.
+type RotatedKey struct {
+	PubKey cipher.PubKey `json:"public_key"`
+	SecKey cipher.SecKey `json:"secret_key"`
+	// Until is the end of the grace period, during which the key is announced as deprecated in favor of the
+	// current key, and its transports are re-established under the current key.
+	Until time.Time `json:"until"`
+	// Transports are the transports of the key at the time of the rotation.
+	Transports []transport.PersistentTransport `json:"transports,omitempty"`
+}
+
+// DeprecatedKey announces a previous public key of the node.
+type DeprecatedKey struct {
+	PubKey cipher.PubKey `json:"public_key"`
+	Until  time.Time     `json:"until"`
+}
+
+// KeyRotation is the result of a key rotation.
+type KeyRotation struct {
+	OldPubKey  cipher.PubKey `json:"old_public_key"`
+	NewPubKey  cipher.PubKey `json:"new_public_key"`
+	Until      time.Time     `json:"until"`      // end of the grace period of the old key.
+	Transports int           `json:"transports"` // number of transports to migrate to the new key.
+}
+
+// DeprecatedKeys returns the rotated keys which are within their grace period at t.
+func (c *Config) DeprecatedKeys(t time.Time) []DeprecatedKey {
+	var out []DeprecatedKey
+	for _, k := range c.RotatedKeys {
+		if t.Before(k.Until) {
+			out = append(out, DeprecatedKey{PubKey: k.PubKey, Until: k.Until})
+		}
+	}
+	return out
+}
+
+// MaintainedTransports returns the persistent transports, and the transports of rotated keys within their grace
+// period at t, which are re-established under the current key.
+func (c *Config) MaintainedTransports(t time.Time) []transport.PersistentTransport {
+	out := append([]transport.PersistentTransport(nil), c.PersistentTransports...)
+	seen := make(map[transport.PersistentTransport]bool, len(out))
+	for _, pt := range out {
+		seen[transport.PersistentTransport{PK: pt.PK, Type: pt.Type}] = true
+	}
+	for _, k := range c.RotatedKeys {
+		if !t.Before(k.Until) {
+			continue
+		}
+		for _, pt := range k.Transports {
+			key := transport.PersistentTransport{PK: pt.PK, Type: pt.Type}
+			if seen[key] || pt.PK == c.Node.StaticPubKey {
+				continue
+			}
+			seen[key] = true
+			out = append(out, pt)
+		}
+	}
+	return out
+}
+
+// RotateKeys replaces the keypair of the node in its config file with a newly generated one, and retires the
+// current keypair as a rotated key with the given grace period. The config file is updated atomically, and the
+// new keypair takes effect when the node is restarted.
+func (node *Node) RotateKeys(grace time.Duration) (*KeyRotation, error) {
+	node.reloadMu.Lock()
+	defer node.reloadMu.Unlock()
+
+	path := node.conf.Path()
+	if path == "" {
+		return nil, ErrNoConfigPath
+	}
+	if node.conf.Node.SealedSecKey != "" {
+		return nil, ErrRotateSealedKey
+	}
+	if grace <= 0 {
+		grace = DefaultKeyGracePeriod
+	}
+
+	var tps []transport.PersistentTransport
+	if node.tm != nil {
+		node.tm.WalkTransports(func(tp *transport.ManagedTransport) bool {
+			tps = append(tps, transport.PersistentTransport{PK: tp.Remote(), Type: tp.Type()})
+			return true
+		})
+	}
+	old := RotatedKey{
+		PubKey:     node.conf.Node.StaticPubKey,
+		SecKey:     node.conf.Node.StaticSecKey,
+		Until:      time.Now().Add(grace).UTC(),
+		Transports: tps,
+	}
+	pk, sk := cipher.GenerateKeyPair()
+
+	// The file is edited as generic JSON, so that profiles and fields overridden by the environment are retained.
+	err := node.editConfigFile(path, func(obj map[string]interface{}) error {
+		keys, _ := obj["node"].(map[string]interface{}) //nolint:errcheck
+		if keys == nil || keys["static_public_key"] != old.PubKey.Hex() {
+			return fmt.Errorf("keys of %s differ from those of the running node", path)
+		}
+		keys["static_public_key"], keys["static_secret_key"] = pk.Hex(), sk.Hex()
+
+		var rotated []RotatedKey
+		if v, ok := obj["rotated_keys"]; ok {
+			if err := remarshal(v, &rotated); err != nil {
+				return fmt.Errorf("invalid rotated_keys: %v", err)
+			}
+		}
+		now := time.Now()
+		kept := rotated[:0]
+		for _, k := range rotated {
+			if now.Before(k.Until) {
+				kept = append(kept, k)
+			}
+		}
+		obj["rotated_keys"] = append(kept, old)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	node.logger.Infof("Rotated keys from %s to %s, the new keys take effect after a restart", old.PubKey, pk)
+	node.RecordEvent(EventConfigChanged, path, "rotated keys from %s to %s", old.PubKey, pk)
+
+	return &KeyRotation{OldPubKey: old.PubKey, NewPubKey: pk, Until: old.Until, Transports: len(tps)}, nil
+}
+
+// deregisterRotatedKeys removes the transports of rotated keys past their grace period from the transport
+// discovery, so that they are no longer used for routes.
+func (node *Node) deregisterRotatedKeys(ctx context.Context) {
+	addr := node.conf.Transport.Discovery
+	if addr == "" {
+		return
+	}
+	now := time.Now()
+	for _, k := range node.conf.RotatedKeys {
+		if now.Before(k.Until) {
+			continue
+		}
+		log := node.logger.WithField("pk", k.PubKey)
+		client, err := trClient.NewHTTP(addr, k.PubKey, k.SecKey)
+		if err != nil {
+			log.WithError(err).Warn("Failed to connect to transport discovery with rotated key")
+			continue
+		}
+		entries, err := client.GetTransportsByEdge(ctx, k.PubKey)
+		if err != nil {
+			log.WithError(err).Debug("Failed to get transports of rotated key")
+			continue
+		}
+		for _, e := range entries {
+			if err := client.DeleteTransport(ctx, e.Entry.ID); err != nil {
+				log.WithError(err).Warnf("Failed to deregister transport %s of rotated key", e.Entry.ID)
+			}
+		}
+		if len(entries) > 0 {
+			log.Infof("Deregistered %d transports of rotated key", len(entries))
+		}
+	}
+}
+
+// editConfigFile edits the config file at path as generic JSON, passing the object of the identity of the node to
+// edit. The file is replaced atomically.
+func (node *Node) editConfigFile(path string, edit func(obj map[string]interface{}) error) error {
+	path = filepath.Clean(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	obj, err := node.identityObject(raw, path)
+	if err != nil {
+		return err
+	}
+	if err := edit(obj); err != nil {
+		return err
+	}
+	if data, err = json.MarshalIndent(raw, "", "  "); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, info.Mode().Perm())
+}
+
+// identityObject returns the object of the identity of the node in the config file at path, decoded as raw.
+// The fields of additional identities are in their entries of identities.
+func (node *Node) identityObject(raw map[string]interface{}, path string) (map[string]interface{}, error) {
+	name := node.conf.Identity()
+	if name == "" {
+		return raw, nil
+	}
+	identities, _ := raw["identities"].(map[string]interface{}) //nolint:errcheck
+	obj, _ := identities[name].(map[string]interface{})         //nolint:errcheck
+	if obj == nil {
+		return nil, fmt.Errorf("identity '%s' is missing from %s", name, path)
+	}
+	return obj, nil
+}
+
+func remarshal(in, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// writeFileAtomic writes data to a temporary file which then replaces the file at path, so that the file is never
+// observed partially written.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp) //nolint:errcheck
+		return err
+	}
+	return nil
+}
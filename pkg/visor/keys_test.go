@@ -0,0 +1,64 @@
+package visor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+func TestNodeRotateKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_keys")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "skywire-config.json")
+
+	remote, _ := cipher.GenerateKeyPair()
+	initial := &Config{Version: ConfigVersion}
+	initial.Node.StaticPubKey, initial.Node.StaticSecKey = cipher.GenerateKeyPair()
+	expiredPK, expiredSK := cipher.GenerateKeyPair()
+	initial.RotatedKeys = []RotatedKey{{PubKey: expiredPK, SecKey: expiredSK, Until: time.Now().Add(-time.Hour)}}
+	data, err := json.Marshal(initial)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	conf, err := ReadConfig(path)
+	require.NoError(t, err)
+	node := &Node{conf: conf, logger: logging.MustGetLogger("test")}
+
+	res, err := node.RotateKeys(time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, initial.Node.StaticPubKey, res.OldPubKey)
+
+	rotated, err := ReadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, res.NewPubKey, rotated.Node.StaticPubKey)
+	pk, err := rotated.Node.StaticSecKey.PubKey()
+	require.NoError(t, err)
+	assert.Equal(t, res.NewPubKey, pk)
+
+	require.Len(t, rotated.RotatedKeys, 1)
+	assert.Equal(t, initial.Node.StaticSecKey, rotated.RotatedKeys[0].SecKey)
+	assert.Equal(t, []DeprecatedKey{{PubKey: res.OldPubKey, Until: res.Until}}, rotated.DeprecatedKeys(time.Now()))
+	assert.Empty(t, rotated.DeprecatedKeys(res.Until))
+
+	rotated.RotatedKeys[0].Transports = []transport.PersistentTransport{{PK: remote, Type: "dmsg"}}
+	rotated.PersistentTransports = []transport.PersistentTransport{{PK: remote, Type: "dmsg", Label: "x"}}
+	assert.Equal(t, rotated.PersistentTransports, rotated.MaintainedTransports(time.Now()))
+	rotated.PersistentTransports = nil
+	assert.Equal(t, rotated.RotatedKeys[0].Transports, rotated.MaintainedTransports(time.Now()))
+	assert.Empty(t, rotated.MaintainedTransports(res.Until))
+
+	// The running node no longer matches the keys of the file.
+	_, err = node.RotateKeys(time.Hour)
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package visor
+
+import (
+	"context"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/geo"
+)
+
+// maxLocationBackoff bounds the delay between failed lookups of the location of the node.
+const maxLocationBackoff = time.Hour
+
+// geoLookup detects the location of the node. It is a variable so that it may be replaced in tests.
+var geoLookup = geo.Lookup
+
+// LocationConfig configures the self-reported geographic location of the node, which is reported in the Summary
+// and in transport stats uploads to transport discovery, so that hypervisors and network maps may place the node.
+type LocationConfig struct {
+	Lat       float64 `json:"lat,omitempty"`
+	Lon       float64 `json:"lon,omitempty"`
+	Region    string  `json:"region,omitempty"`
+	Auto      bool    `json:"auto,omitempty"`       // detects the location with a geo-IP lookup if no location is set.
+	LookupURL string  `json:"lookup_url,omitempty"` // geo-IP service of Auto, defaults to geo.DefaultLookupURL.
+}
+
+// StaticLocation returns the configured location, or nil if no location is set.
+func (c *Config) StaticLocation() (*geo.Location, error) {
+	lc := c.Location
+	if lc == nil || lc.Lat == 0 && lc.Lon == 0 && lc.Region == "" {
+		return nil, nil
+	}
+	loc := &geo.Location{Lat: lc.Lat, Lon: lc.Lon, Region: lc.Region}
+	return loc, loc.Validate()
+}
+
+// Location returns the self-reported location of the node, or nil if unknown.
+func (node *Node) Location() *geo.Location {
+	node.locationMu.Lock()
+	defer node.locationMu.Unlock()
+	return node.location
+}
+
+func (node *Node) setLocation(loc *geo.Location) {
+	node.locationMu.Lock()
+	node.location = loc
+	node.locationMu.Unlock()
+	if node.tm != nil {
+		node.tm.SetLocation(loc)
+	}
+}
+
+// detectLocation looks up the location of the node until it succeeds or ctx is done, backing off exponentially.
+func (node *Node) detectLocation(ctx context.Context, url string) {
+	if url == "" {
+		url = geo.DefaultLookupURL
+	}
+	backoff := time.Minute
+	for {
+		lookupCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		loc, err := geoLookup(lookupCtx, url)
+		cancel()
+		if err == nil {
+			node.logger.Infof("Detected location: %v,%v (%s)", loc.Lat, loc.Lon, loc.Region)
+			node.setLocation(loc)
+			return
+		}
+		node.logger.WithError(err).Warn("Failed to detect location")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxLocationBackoff {
+			backoff = maxLocationBackoff
+		}
+	}
+}
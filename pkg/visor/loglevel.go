@@ -0,0 +1,190 @@
+package visor
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// moduleKey is the key of the module name in the data of log entries of logging.Logger.
+const moduleKey = "_module"
+
+// SetLogLevelIn is input of SetLogLevel.
+type SetLogLevelIn struct {
+	Module string `json:"module,omitempty"` // all modules if empty.
+	Level  string `json:"level"`            // resets the module to the global level if empty.
+}
+
+// LogLevels are the log levels of the node.
+type LogLevels struct {
+	Global  string            `json:"global"`
+	Modules map[string]string `json:"modules,omitempty"` // modules with levels other than the global level.
+}
+
+// levelFilter is a logrus.Formatter which drops entries below the level of their module.
+// The level of the filtered loggers is set to the most verbose level, so that entries reach the filter.
+type levelFilter struct {
+	logrus.Formatter
+	levels *logLevels
+}
+
+// Format implements logrus.Formatter.
+func (f *levelFilter) Format(e *logrus.Entry) ([]byte, error) {
+	if !f.levels.enabled(e) {
+		return nil, nil
+	}
+	return f.Formatter.Format(e)
+}
+
+// logLevels holds the global and per-module log levels of the loggers of the node.
+type logLevels struct {
+	global  logrus.Level
+	modules map[string]logrus.Level
+	loggers []*logrus.Logger
+	mx      sync.RWMutex
+}
+
+// newLogLevels installs per-module log levels on the loggers, initially at the global level.
+func newLogLevels(global logrus.Level, loggers ...*logrus.Logger) *logLevels {
+	l := &logLevels{global: global, modules: make(map[string]logrus.Level), loggers: loggers}
+	for _, lg := range loggers {
+		if f, ok := lg.Formatter.(*levelFilter); ok {
+			f.levels = l // replace the levels of a previous node.
+		} else {
+			lg.Formatter = &levelFilter{Formatter: lg.Formatter, levels: l}
+		}
+	}
+	l.apply()
+	return l
+}
+
+func (l *logLevels) enabled(e *logrus.Entry) bool {
+	l.mx.RLock()
+	defer l.mx.RUnlock()
+	lvl, ok := l.modules[fmt.Sprint(e.Data[moduleKey])]
+	if !ok {
+		lvl = l.global
+	}
+	return e.Level <= lvl
+}
+
+// set sets the level of the module, or the global level if module is empty.
+func (l *logLevels) set(module string, lvl logrus.Level) {
+	l.mx.Lock()
+	if module == "" {
+		l.global = lvl
+	} else {
+		l.modules[module] = lvl
+	}
+	l.mx.Unlock()
+	l.apply()
+}
+
+// reset sets the module to the global level.
+func (l *logLevels) reset(module string) {
+	l.mx.Lock()
+	delete(l.modules, module)
+	l.mx.Unlock()
+	l.apply()
+}
+
+// apply sets the level of the loggers to the most verbose level.
+func (l *logLevels) apply() {
+	l.mx.RLock()
+	max := l.global
+	for _, lvl := range l.modules {
+		if lvl > max {
+			max = lvl
+		}
+	}
+	l.mx.RUnlock()
+	for _, lg := range l.loggers {
+		lg.SetLevel(max)
+	}
+}
+
+func (l *logLevels) get() LogLevels {
+	l.mx.RLock()
+	defer l.mx.RUnlock()
+	out := LogLevels{Global: l.global.String()}
+	if len(l.modules) > 0 {
+		out.Modules = make(map[string]string, len(l.modules))
+		for m, lvl := range l.modules {
+			out.Modules[m] = lvl.String()
+		}
+	}
+	return out
+}
+
+// globalLogger returns the logger of the logging package, which backs the loggers of most packages.
+func globalLogger() *logrus.Logger {
+	if e, ok := logging.MustGetLogger("").FieldLogger.(*logrus.Entry); ok {
+		return e.Logger
+	}
+	return nil
+}
+
+// levels returns the log levels of the node. If install is set, they are installed on the loggers of the node if
+// they are not yet. Until then, only the global level of the master logger of the node applies.
+func (node *Node) levels(install bool) *logLevels {
+	node.logLevelsMu.Lock()
+	defer node.logLevelsMu.Unlock()
+	if node.logLevels == nil && install && node.Logger != nil {
+		loggers := []*logrus.Logger{node.Logger.Logger}
+		if lg := globalLogger(); lg != nil && lg != node.Logger.Logger {
+			loggers = append(loggers, lg)
+		}
+		node.logLevels = newLogLevels(node.Logger.GetLevel(), loggers...)
+	}
+	return node.logLevels
+}
+
+// SetLogLevel sets the log level of a module of the node without a restart, or the global level if module is empty.
+// An empty level resets the module to the global level.
+func (node *Node) SetLogLevel(module, level string) error {
+	levels := node.levels(true)
+	if levels == nil {
+		return ErrNotImplemented
+	}
+	if level == "" {
+		if module == "" {
+			return fmt.Errorf("empty log level")
+		}
+		levels.reset(module)
+		node.logger.Infof("Reset log level of module %s", module)
+		return nil
+	}
+	lvl, err := logging.LevelFromString(level)
+	if err != nil {
+		return err
+	}
+	levels.set(module, lvl)
+	if module == "" {
+		node.logger.Infof("Set log level to %s", lvl)
+	} else {
+		node.logger.Infof("Set log level of module %s to %s", module, lvl)
+	}
+	return nil
+}
+
+// LogLevels returns the log levels of the node.
+func (node *Node) LogLevels() LogLevels {
+	if levels := node.levels(false); levels != nil {
+		return levels.get()
+	}
+	if node.Logger == nil {
+		return LogLevels{}
+	}
+	return LogLevels{Global: node.Logger.GetLevel().String()}
+}
+
+// setGlobalLogLevel sets the global log level, keeping the levels of modules if any are set.
+func (node *Node) setGlobalLogLevel(lvl logrus.Level) {
+	if levels := node.levels(false); levels != nil {
+		levels.set("", lvl)
+		return
+	}
+	node.Logger.SetLevel(lvl)
+}
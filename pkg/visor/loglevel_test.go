@@ -0,0 +1,53 @@
+package visor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogLevels(t *testing.T) {
+	var buf bytes.Buffer
+	master := logging.NewMasterLogger()
+	master.Out = &buf
+	master.SetLevel(logrus.InfoLevel)
+	levels := newLogLevels(logrus.InfoLevel, master.Logger)
+
+	foo, bar := master.PackageLogger("foo"), master.PackageLogger("bar")
+	logged := func(l *logging.Logger) bool {
+		buf.Reset()
+		l.Debug("debug")
+		return buf.Len() > 0
+	}
+	assert.False(t, logged(foo))
+
+	levels.set("foo", logrus.DebugLevel)
+	assert.True(t, logged(foo))
+	assert.False(t, logged(bar))
+	assert.Equal(t, LogLevels{Global: "info", Modules: map[string]string{"foo": "debug"}}, levels.get())
+
+	levels.reset("foo")
+	assert.False(t, logged(foo))
+	assert.Equal(t, logrus.InfoLevel, master.GetLevel())
+
+	levels.set("", logrus.DebugLevel)
+	assert.True(t, logged(bar))
+}
+
+func TestNodeSetLogLevel(t *testing.T) {
+	master := logging.NewMasterLogger()
+	master.SetLevel(logrus.InfoLevel)
+	node := &Node{Logger: master, logger: master.PackageLogger("test")}
+	assert.Equal(t, LogLevels{Global: "info"}, node.LogLevels())
+
+	require.NoError(t, node.SetLogLevel("router", "debug"))
+	assert.Equal(t, "debug", node.LogLevels().Modules["router"])
+	require.NoError(t, node.SetLogLevel("router", ""))
+	assert.Empty(t, node.LogLevels().Modules)
+	assert.Error(t, node.SetLogLevel("", ""))
+	assert.Error(t, node.SetLogLevel("", "verbose"))
+}
@@ -0,0 +1,223 @@
+package visor
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
+)
+
+// DefaultTailLines is the number of lines returned by TailLogs if no lines are requested.
+const DefaultTailLines = 100
+
+// DefaultTailWait is the maximum duration TailLogs waits for new lines if no wait is requested.
+const DefaultTailWait = 10 * time.Second
+
+// visorLogSize is the number of lines of visor logs kept for TailLogs.
+const visorLogSize = 1000
+
+// tailPollInterval is the interval at which app logs are polled for new lines.
+var tailPollInterval = 500 * time.Millisecond
+
+// TailLogsIn is input of TailLogs.
+type TailLogsIn struct {
+	App   string        `json:"app,omitempty"`   // logs of the visor if empty.
+	After time.Time     `json:"after,omitempty"` // the last lines are returned if zero.
+	Until time.Time     `json:"until,omitempty"` // only lines logged before, unbounded if zero.
+	Lines int           `json:"lines,omitempty"` // DefaultTailLines if zero.
+	Wait  time.Duration `json:"wait,omitempty"`  // DefaultTailWait if zero, no waiting if negative.
+}
+
+// TailLogsOut is output of TailLogs.
+type TailLogsOut struct {
+	Lines []string  `json:"lines"`
+	Next  time.Time `json:"next"`           // After of the next TailLogs call.
+	Done  bool      `json:"done,omitempty"` // whether Until has passed, so that no more lines will be returned.
+}
+
+// ansiEscape matches the color escapes of formatted log lines.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+type logLine struct {
+	t    time.Time
+	line string
+}
+
+// logTail is a logrus.Hook which keeps the last lines logged by the visor.
+type logTail struct {
+	lines  []logLine // ring buffer of visorLogSize lines.
+	next   int
+	notify chan struct{} // closed and replaced when lines are added.
+	mx     sync.Mutex
+}
+
+func newLogTail() *logTail {
+	return &logTail{notify: make(chan struct{})}
+}
+
+// Levels implements logrus.Hook.
+func (lt *logTail) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (lt *logTail) Fire(e *logrus.Entry) error {
+	// Entries filtered by per-module log levels are formatted to nothing.
+	b, err := e.Logger.Formatter.Format(e)
+	if err != nil || len(b) == 0 {
+		return err
+	}
+	line := strings.TrimRight(ansiEscape.ReplaceAllString(string(b), ""), "\n")
+
+	lt.mx.Lock()
+	defer lt.mx.Unlock()
+	l := logLine{t: e.Time, line: line}
+	if len(lt.lines) < visorLogSize {
+		lt.lines = append(lt.lines, l)
+	} else {
+		lt.lines[lt.next] = l
+		lt.next = (lt.next + 1) % visorLogSize
+	}
+	close(lt.notify)
+	lt.notify = make(chan struct{})
+	return nil
+}
+
+// since returns the lines logged after t, and a channel which is closed once more lines are logged.
+func (lt *logTail) since(t time.Time) ([]logLine, <-chan struct{}) {
+	lt.mx.Lock()
+	defer lt.mx.Unlock()
+	var lines []logLine
+	for i := range lt.lines {
+		if l := lt.lines[(lt.next+i)%len(lt.lines)]; l.t.After(t) {
+			lines = append(lines, l)
+		}
+	}
+	return lines, lt.notify
+}
+
+// TailLogs returns the lines logged by the visor, or by an app, after in.After and before in.Until. If there are
+// none, it waits up to in.Wait for new lines, so that logs can be followed by calling it repeatedly with the returned
+// Next until Done.
+func (node *Node) TailLogs(ctx context.Context, in TailLogsIn) (*TailLogsOut, error) {
+	if in.Lines <= 0 {
+		in.Lines = DefaultTailLines
+	}
+	done := !in.Until.IsZero() && !time.Now().Before(in.Until)
+	switch {
+	case in.Wait < 0 || done:
+		in.Wait = 0
+	case in.Wait == 0:
+		in.Wait = DefaultTailWait
+	}
+	ctx, cancel := context.WithTimeout(ctx, in.Wait)
+	defer cancel()
+
+	var (
+		lines []logLine
+		err   error
+	)
+	if in.App == "" {
+		lines, err = node.tailVisorLogs(ctx, in.After)
+	} else {
+		lines, err = node.tailAppLogs(ctx, in.App, in.After)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !in.Until.IsZero() {
+		n := 0
+		for _, l := range lines {
+			if l.t.Before(in.Until) {
+				lines[n] = l
+				n++
+			}
+		}
+		lines = lines[:n]
+	}
+
+	out := &TailLogsOut{Lines: make([]string, 0, len(lines)), Next: in.After, Done: done}
+	if len(lines) > in.Lines {
+		lines = lines[len(lines)-in.Lines:]
+	}
+	for _, l := range lines {
+		out.Lines = append(out.Lines, l.line)
+	}
+	if len(lines) > 0 {
+		out.Next = lines[len(lines)-1].t
+	}
+	return out, nil
+}
+
+func (node *Node) tailVisorLogs(ctx context.Context, after time.Time) ([]logLine, error) {
+	if node.logTail == nil {
+		return nil, nil
+	}
+	for {
+		lines, notify := node.logTail.since(after)
+		if len(lines) > 0 {
+			return lines, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-notify:
+		}
+	}
+}
+
+func (node *Node) tailAppLogs(ctx context.Context, name string, after time.Time) ([]logLine, error) {
+	known := false
+	for _, ac := range node.appConfigs() {
+		known = known || ac.App == name
+	}
+	if !known {
+		return nil, ErrUnknownApp
+	}
+	ls, err := app.NewLogStore(filepath.Join(node.dir(), name), name, "bbolt")
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+	for {
+		logs, err := ls.LogsSince(after)
+		if err != nil {
+			return nil, err
+		}
+		var lines []logLine
+		for _, log := range logs {
+			l := logLine{t: appLogTime(log), line: strings.TrimRight(log, "\n")}
+			// LogsSince includes the line logged at after, unless after is the time of a line.
+			if after.IsZero() || l.t.After(after) {
+				lines = append(lines, l)
+			}
+		}
+		if len(lines) > 0 {
+			return lines, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// appLogTime returns the time of a line of app logs, which starts with the RFC3339Nano time in brackets.
+func appLogTime(line string) time.Time {
+	end := strings.IndexByte(line, ']')
+	if !strings.HasPrefix(line, "[") || end < 0 {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339Nano, line[1:end]) //nolint:errcheck
+	return t
+}
@@ -0,0 +1,79 @@
+package visor
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeTailLogs(t *testing.T) {
+	ml := logging.NewMasterLogger()
+	ml.Out = ioutil.Discard
+	node := &Node{conf: &Config{}, logTail: newLogTail()}
+	ml.AddHook(node.logTail)
+	logger := ml.PackageLogger("test")
+
+	logger.Info("first")
+	logger.Info("second")
+	out, err := node.TailLogs(context.TODO(), TailLogsIn{Lines: 1})
+	require.NoError(t, err)
+	require.Len(t, out.Lines, 1)
+	assert.Contains(t, out.Lines[0], "second")
+	assert.NotContains(t, out.Lines[0], "\x1b[")
+
+	// New lines are waited for.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		logger.Info("third")
+	}()
+	out, err = node.TailLogs(context.TODO(), TailLogsIn{After: out.Next})
+	require.NoError(t, err)
+	require.Len(t, out.Lines, 1)
+	assert.Contains(t, out.Lines[0], "third")
+
+	next := out.Next
+	out, err = node.TailLogs(context.TODO(), TailLogsIn{After: next, Wait: 50 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Empty(t, out.Lines)
+	assert.Equal(t, next, out.Next)
+
+	// Lines are not waited for if the wait is negative, or once the end of the range has passed.
+	start := time.Now()
+	out, err = node.TailLogs(context.TODO(), TailLogsIn{After: next, Wait: -1})
+	require.NoError(t, err)
+	assert.Empty(t, out.Lines)
+	assert.False(t, out.Done)
+	logger.Info("fourth")
+	until := time.Now()
+	time.Sleep(time.Millisecond)
+	logger.Info("fifth")
+	out, err = node.TailLogs(context.TODO(), TailLogsIn{After: next, Until: until, Lines: 10})
+	require.NoError(t, err)
+	require.Len(t, out.Lines, 1)
+	assert.Contains(t, out.Lines[0], "fourth")
+	assert.True(t, out.Done)
+	assert.True(t, time.Since(start) < DefaultTailWait)
+
+	// The oldest lines are dropped.
+	for i := 0; i < visorLogSize+10; i++ {
+		logger.Info("line")
+	}
+	lines, _ := node.logTail.since(time.Time{})
+	assert.Len(t, lines, visorLogSize)
+	assert.NotContains(t, lines[0].line, "third")
+
+	_, err = node.TailLogs(context.TODO(), TailLogsIn{App: "unknown"})
+	assert.Equal(t, ErrUnknownApp, err)
+}
+
+func TestAppLogTime(t *testing.T) {
+	want := time.Date(2020, 2, 3, 4, 5, 6, 700, time.UTC)
+	line := "[" + want.Format(time.RFC3339Nano) + "] INFO [app]: started"
+	assert.True(t, want.Equal(appLogTime(line)))
+	assert.True(t, appLogTime("started").IsZero())
+}
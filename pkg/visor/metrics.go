@@ -0,0 +1,42 @@
+package visor
+
+import (
+	"bytes"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Metrics returns the Prometheus metrics of the node in the text exposition format, such as for hypervisors which
+// aggregate the metrics of visors that cannot be scraped directly.
+func (node *Node) Metrics() ([]byte, error) {
+	var collectors []prometheus.Collector
+	if node.tmMet != nil {
+		collectors = append(collectors, node.tmMet)
+	}
+	if node.dMet != nil {
+		collectors = append(collectors, node.dMet)
+	}
+	if node.n != nil {
+		collectors = append(collectors, node.DmsgMetrics())
+	}
+
+	reg := prometheus.NewRegistry()
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.FmtText)
+	for _, mf := range families {
+		if err := enc.Encode(mf); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,107 @@
+package visor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ConfigVersion is the version of configs generated by this release.
+const ConfigVersion = "1.1"
+
+// DefaultAppVersion is the version of apps which do not specify one.
+const DefaultAppVersion = "1.0"
+
+// configMigration upgrades a config, decoded as generic JSON, from one version to the next.
+type configMigration struct {
+	from, to string
+	migrate  func(conf map[string]interface{}) error
+}
+
+// configMigrations are applied in order, and end at ConfigVersion.
+var configMigrations = []configMigration{
+	{"1.0", "1.1", migrateConfig1_0},
+}
+
+// migrateConfig1_0 pins the versions of apps, which default to DefaultAppVersion since 1.1 rather than to the
+// version of the config.
+func migrateConfig1_0(conf map[string]interface{}) error {
+	apps, ok := conf["apps"].([]interface{})
+	if !ok {
+		return nil
+	}
+	for _, app := range apps {
+		app, ok := app.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid app: %v", app)
+		}
+		if v, _ := app["version"].(string); v == "" { //nolint:errcheck
+			app["version"] = "1.0"
+		}
+	}
+	return nil
+}
+
+// MigrateConfig upgrades the JSON config data to ConfigVersion.
+// It returns the version of the data, and the upgraded data, which is nil if no migration is required.
+func MigrateConfig(data []byte) (from string, migrated []byte, err error) {
+	var conf map[string]interface{}
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return "", nil, fmt.Errorf("failed to decode config: %v", err)
+	}
+	from, _ = conf["version"].(string) //nolint:errcheck
+	if from == ConfigVersion {
+		return from, nil, nil
+	}
+
+	start := -1
+	for i, m := range configMigrations {
+		if m.from == from {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return from, nil, fmt.Errorf("unsupported config version '%s' (latest supported version is %s)", from, ConfigVersion)
+	}
+	for _, m := range configMigrations[start:] {
+		if err := m.migrate(conf); err != nil {
+			return from, nil, fmt.Errorf("failed to migrate config from %s to %s: %v", m.from, m.to, err)
+		}
+		conf["version"] = m.to
+	}
+
+	if migrated, err = json.MarshalIndent(conf, "", "  "); err != nil {
+		return from, nil, err
+	}
+	return from, migrated, nil
+}
+
+// MigrateConfigFile upgrades the config file at path to ConfigVersion in place, after backing it up to
+// '<path>.v<version>.bak'. It returns the previous version of the config, and whether it was migrated.
+func MigrateConfigFile(path string) (from string, ok bool, err error) {
+	path = filepath.Clean(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", false, err
+	}
+	from, migrated, err := MigrateConfig(data)
+	if err != nil || migrated == nil {
+		return from, false, err
+	}
+
+	backup := fmt.Sprintf("%s.v%s.bak", path, from)
+	if err := ioutil.WriteFile(backup, data, info.Mode().Perm()); err != nil {
+		return from, false, fmt.Errorf("failed to back up config: %v", err)
+	}
+	if err := writeFileAtomic(path, migrated, info.Mode().Perm()); err != nil {
+		return from, false, err
+	}
+	return from, true, nil
+}
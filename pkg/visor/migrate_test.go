@@ -0,0 +1,53 @@
+package visor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_migrate")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "skywire-config.json")
+
+	data := []byte(`{"version":"1.0","apps":[{"app":"foo","port":10},{"app":"bar","version":"2.0","port":11}],"unknown":true}`)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	from, ok, err := MigrateConfigFile(path)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1.0", from)
+
+	backup, err := ioutil.ReadFile(path + ".v1.0.bak")
+	require.NoError(t, err)
+	assert.Equal(t, data, backup)
+
+	conf, err := ReadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, ConfigVersion, conf.Version)
+	apps, err := conf.AppsConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", apps[0].Version)
+	assert.Equal(t, "2.0", apps[1].Version)
+
+	// Fields unknown to this release are preserved.
+	var raw map[string]interface{}
+	migrated, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(migrated, &raw))
+	assert.Equal(t, true, raw["unknown"])
+
+	_, ok, err = MigrateConfigFile(path)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = MigrateConfig([]byte(`{"version":"9.9"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package visor
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/nmclient"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+// DefaultNetworkMonitorInterval is the default interval between reports to the network monitor.
+const DefaultNetworkMonitorInterval = time.Hour
+
+// newNetworkMonitorClient creates the client of the network monitor. It is a variable so that it may be replaced
+// in tests.
+var newNetworkMonitorClient = nmclient.NewHTTP
+
+// NetworkMonitorConfig configures the reporting of anonymized health data (version, uptime and transport counts)
+// to a network monitor. Nothing is reported unless Enabled is set.
+type NetworkMonitorConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Address  string   `json:"address"`
+	Interval Duration `json:"interval,omitempty"` // between reports, defaults to 1h.
+}
+
+// networkMonitor periodically reports anonymized health data to the network monitor.
+type networkMonitor struct {
+	addr     string
+	interval time.Duration
+	instance string // random for each run, so that reports are not linked to the node.
+	client   nmclient.APIClient
+}
+
+func newNetworkMonitor(conf *NetworkMonitorConfig) *networkMonitor {
+	if conf == nil || !conf.Enabled || conf.Address == "" {
+		return nil
+	}
+	interval := time.Duration(conf.Interval)
+	if interval <= 0 {
+		interval = DefaultNetworkMonitorInterval
+	}
+	return &networkMonitor{
+		addr:     conf.Address,
+		interval: interval,
+		instance: hex.EncodeToString(cipher.RandByte(16)),
+		client:   newNetworkMonitorClient(conf.Address),
+	}
+}
+
+// healthReport returns the anonymized health report of the node.
+func (node *Node) healthReport() *nmclient.Report {
+	report := &nmclient.Report{
+		Instance:   node.netMonitor.instance,
+		Version:    Version,
+		Uptime:     time.Since(node.startedAt).Seconds(),
+		Transports: make(map[string]int),
+		Time:       time.Now().UTC(),
+	}
+	if node.tm != nil {
+		node.tm.WalkTransports(func(tp *transport.ManagedTransport) bool {
+			report.Transports[tp.Type()]++
+			return true
+		})
+	}
+	return report
+}
+
+// reportHealth reports health data to the network monitor until ctx is done.
+func (node *Node) reportHealth(ctx context.Context) {
+	m := node.netMonitor
+	node.logger.Info("Reporting anonymized health data to network monitor ", m.addr)
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		reqCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		if err := m.client.SubmitReport(reqCtx, node.healthReport()); err != nil {
+			node.logger.WithError(err).Warn("Failed to report to network monitor")
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package visor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/nmclient"
+)
+
+type reportRecorder chan *nmclient.Report
+
+func (r reportRecorder) SubmitReport(_ context.Context, report *nmclient.Report) error {
+	r <- report
+	return nil
+}
+
+func TestNetworkMonitor(t *testing.T) {
+	assert.Nil(t, newNetworkMonitor(nil))
+	assert.Nil(t, newNetworkMonitor(&NetworkMonitorConfig{Address: "http://monitor"}))
+
+	reports := make(reportRecorder, 1)
+	newNetworkMonitorClient = func(string) nmclient.APIClient { return reports }
+	defer func() { newNetworkMonitorClient = nmclient.NewHTTP }()
+
+	pk, _ := cipher.GenerateKeyPair()
+	conf := &Config{NetworkMonitor: &NetworkMonitorConfig{Enabled: true, Address: "http://monitor"}}
+	conf.Node.StaticPubKey = pk
+	node := &Node{conf: conf, logger: logging.MustGetLogger("test"), startedAt: time.Now()}
+	node.netMonitor = newNetworkMonitor(conf.NetworkMonitor)
+	require.NotNil(t, node.netMonitor)
+	assert.Equal(t, DefaultNetworkMonitorInterval, node.netMonitor.interval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go node.reportHealth(ctx)
+
+	report := <-reports
+	assert.Equal(t, Version, report.Version)
+	assert.NotEmpty(t, report.Instance)
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), pk.Hex())
+}
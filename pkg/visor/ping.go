@@ -0,0 +1,107 @@
+package visor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
+)
+
+// Defaults of the fields of PingIn.
+const (
+	DefaultPingCount    = 4
+	DefaultPingSize     = 32
+	DefaultPingInterval = time.Second
+	DefaultPingTimeout  = 5 * time.Second
+)
+
+// ErrPingUnsupported occurs when pinging with a router which cannot ping.
+var ErrPingUnsupported = errors.New("router does not support ping")
+
+// pinger is implemented by routers which measure the latency and loss of routes to visors, such as router.Router.
+type pinger interface {
+	Ping(ctx context.Context, remote cipher.PubKey, conf router.PingConfig) ([]router.PingReply, error)
+}
+
+// PingIn is input of Ping.
+type PingIn struct {
+	PK       cipher.PubKey
+	Count    int           // DefaultPingCount if zero.
+	Size     int           // DefaultPingSize if zero.
+	Interval time.Duration // DefaultPingInterval if zero.
+	Timeout  time.Duration // DefaultPingTimeout if zero.
+}
+
+// PingOut is output of Ping.
+type PingOut struct {
+	PK       cipher.PubKey      `json:"pk"`
+	Replies  []router.PingReply `json:"replies"`
+	Sent     int                `json:"sent"`
+	Received int                `json:"received"`
+	Loss     float64            `json:"loss"` // fraction of the packets which were lost.
+	MinRTT   time.Duration      `json:"min_rtt"`
+	AvgRTT   time.Duration      `json:"avg_rtt"`
+	MaxRTT   time.Duration      `json:"max_rtt"`
+}
+
+// Ping sets up a loop to the router of the remote visor, and measures the round-trip time and loss of packets sent
+// over it.
+func (node *Node) Ping(ctx context.Context, in PingIn) (*PingOut, error) {
+	p, ok := node.router.(pinger)
+	if !ok {
+		return nil, ErrPingUnsupported
+	}
+	conf := router.PingConfig{
+		Count:    in.Count,
+		Size:     in.Size,
+		Interval: in.Interval,
+		Timeout:  in.Timeout,
+	}
+	if conf.Count <= 0 {
+		conf.Count = DefaultPingCount
+	}
+	if conf.Size <= 0 {
+		conf.Size = DefaultPingSize
+	}
+	if conf.Interval <= 0 {
+		conf.Interval = DefaultPingInterval
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = DefaultPingTimeout
+	}
+
+	replies, err := p.Ping(ctx, in.PK, conf)
+	if err != nil {
+		return nil, err
+	}
+	return newPingOut(in.PK, replies), nil
+}
+
+// newPingOut summarizes the replies of pings.
+func newPingOut(pk cipher.PubKey, replies []router.PingReply) *PingOut {
+	out := &PingOut{PK: pk, Replies: replies, Sent: len(replies)}
+	var total time.Duration
+	for _, r := range replies {
+		if r.Lost {
+			continue
+		}
+		if out.Received == 0 || r.RTT < out.MinRTT {
+			out.MinRTT = r.RTT
+		}
+		if r.RTT > out.MaxRTT {
+			out.MaxRTT = r.RTT
+		}
+		total += r.RTT
+		out.Received++
+	}
+	if out.Received > 0 {
+		out.AvgRTT = total / time.Duration(out.Received)
+	}
+	if out.Sent > 0 {
+		out.Loss = float64(out.Sent-out.Received) / float64(out.Sent)
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+package visor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
+)
+
+func TestNewPingOut(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	out := newPingOut(pk, []router.PingReply{
+		{Seq: 0, RTT: 30 * time.Millisecond},
+		{Seq: 1, Lost: true},
+		{Seq: 2, RTT: 10 * time.Millisecond},
+		{Seq: 3, RTT: 20 * time.Millisecond},
+	})
+	assert.Equal(t, 4, out.Sent)
+	assert.Equal(t, 3, out.Received)
+	assert.Equal(t, 0.25, out.Loss)
+	assert.Equal(t, 10*time.Millisecond, out.MinRTT)
+	assert.Equal(t, 20*time.Millisecond, out.AvgRTT)
+	assert.Equal(t, 30*time.Millisecond, out.MaxRTT)
+
+	out = newPingOut(pk, []router.PingReply{{Seq: 0, Lost: true}})
+	assert.Equal(t, 1.0, out.Loss)
+	assert.Zero(t, out.AvgRTT)
+}
+
+func TestPing_Unsupported(t *testing.T) {
+	node := &Node{router: &mockRouter{}}
+	_, err := node.Ping(context.TODO(), PingIn{})
+	assert.Equal(t, ErrPingUnsupported, err)
+}
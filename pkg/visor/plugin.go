@@ -0,0 +1,143 @@
+package visor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+// Plugin is custom behavior compiled into the visor (such as billing, alerting or policy), which is notified of the
+// lifecycle and events of the node.
+// Event handlers are called sequentially from a single goroutine per event source, so they should not block.
+type Plugin interface {
+	// OnStart is called when the node starts. Errors prevent the node from starting.
+	OnStart(node *Node) error
+	// OnTransportEvent is called on changes in the state of transports.
+	OnTransportEvent(e transport.Event)
+	// OnRouteEvent is called when loops of local apps are created or closed.
+	OnRouteEvent(e router.Event)
+	// OnShutdown is called when the node is closed.
+	OnShutdown()
+}
+
+// NopPlugin implements Plugin with no-ops, to be embedded by plugins which only implement some of the handlers.
+type NopPlugin struct{}
+
+// OnStart implements Plugin.
+func (NopPlugin) OnStart(*Node) error { return nil }
+
+// OnTransportEvent implements Plugin.
+func (NopPlugin) OnTransportEvent(transport.Event) {}
+
+// OnRouteEvent implements Plugin.
+func (NopPlugin) OnRouteEvent(router.Event) {}
+
+// OnShutdown implements Plugin.
+func (NopPlugin) OnShutdown() {}
+
+var (
+	pluginsMx sync.RWMutex
+	plugins   = make(map[string]Plugin)
+)
+
+// RegisterPlugin makes a plugin part of all nodes started afterwards, typically from the init function of the
+// package implementing the plugin.
+// RegisterPlugin panics if the plugin is nil, or if a plugin is already registered under the name.
+func RegisterPlugin(name string, p Plugin) {
+	pluginsMx.Lock()
+	defer pluginsMx.Unlock()
+
+	if p == nil {
+		panic("visor: RegisterPlugin plugin is nil")
+	}
+	if _, dup := plugins[name]; dup {
+		panic(fmt.Sprintf("visor: RegisterPlugin called twice for plugin '%s'", name))
+	}
+	plugins[name] = p
+}
+
+// Plugins returns a sorted list of the names of the registered plugins.
+func Plugins() []string {
+	pluginsMx.RLock()
+	defer pluginsMx.RUnlock()
+
+	out := make([]string, 0, len(plugins))
+	for name := range plugins {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// routeObserver is implemented by routers which publish route events, such as router.Router.
+type routeObserver interface {
+	Observe() (<-chan router.Event, func())
+}
+
+type namedPlugin struct {
+	name string
+	Plugin
+}
+
+// startPlugins starts the registered plugins in order of name, and dispatches events to them.
+func (node *Node) startPlugins() error {
+	pluginsMx.RLock()
+	started := make([]namedPlugin, 0, len(plugins))
+	for name, p := range plugins {
+		started = append(started, namedPlugin{name, p})
+	}
+	pluginsMx.RUnlock()
+	if len(started) == 0 {
+		return nil
+	}
+	sort.Slice(started, func(i, j int) bool { return started[i].name < started[j].name })
+
+	for _, p := range started {
+		node.logger.Infof("Starting plugin %s", p.name)
+		if err := p.OnStart(node); err != nil {
+			return fmt.Errorf("plugin %s: %v", p.name, err)
+		}
+	}
+
+	node.pluginsMu.Lock()
+	node.plugins = started
+	node.pluginsMu.Unlock()
+
+	if node.tm != nil {
+		go func() {
+			events, _ := node.tm.Observe()
+			for e := range events {
+				for _, p := range started {
+					p.OnTransportEvent(e)
+				}
+			}
+		}()
+	}
+	if r, ok := node.router.(routeObserver); ok {
+		go func() {
+			events, _ := r.Observe()
+			for e := range events {
+				for _, p := range started {
+					p.OnRouteEvent(e)
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// stopPlugins notifies the started plugins of the node's shutdown.
+func (node *Node) stopPlugins() {
+	node.pluginsMu.Lock()
+	started := node.plugins
+	node.plugins = nil
+	node.pluginsMu.Unlock()
+
+	for _, p := range started {
+		node.logger.Infof("Stopping plugin %s", p.name)
+		p.OnShutdown()
+	}
+}
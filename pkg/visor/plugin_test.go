@@ -0,0 +1,86 @@
+package visor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+type recordingPlugin struct {
+	NopPlugin
+	mx       sync.Mutex
+	node     *Node
+	routes   []router.Event
+	shutdown bool
+}
+
+func (p *recordingPlugin) OnStart(node *Node) error {
+	p.mx.Lock()
+	p.node = node
+	p.mx.Unlock()
+	return nil
+}
+
+func (p *recordingPlugin) OnRouteEvent(e router.Event) {
+	p.mx.Lock()
+	p.routes = append(p.routes, e)
+	p.mx.Unlock()
+}
+
+func (p *recordingPlugin) OnShutdown() {
+	p.mx.Lock()
+	p.shutdown = true
+	p.mx.Unlock()
+}
+
+// observingRouter is a mockRouter which publishes route events.
+type observingRouter struct {
+	*mockRouter
+	events chan router.Event
+}
+
+func (r *observingRouter) Observe() (<-chan router.Event, func()) {
+	return r.events, func() {}
+}
+
+func TestPlugins(t *testing.T) {
+	p := new(recordingPlugin)
+	RegisterPlugin("recording", p)
+	defer func() {
+		pluginsMx.Lock()
+		delete(plugins, "recording")
+		pluginsMx.Unlock()
+	}()
+	assert.Contains(t, Plugins(), "recording")
+	assert.Panics(t, func() { RegisterPlugin("recording", p) })
+
+	r := &observingRouter{mockRouter: new(mockRouter), events: make(chan router.Event, 1)}
+	node := &Node{conf: &Config{}, router: r, startedApps: map[string]*appBind{}, logger: logging.MustGetLogger("test")}
+	require.NoError(t, node.startPlugins())
+
+	p.mx.Lock()
+	assert.Equal(t, node, p.node)
+	p.mx.Unlock()
+
+	e := router.Event{Type: router.EventLoopCreated, Loop: routing.Loop{}, Time: time.Now()}
+	r.events <- e
+	testhelpers.Eventually(t, func() bool {
+		p.mx.Lock()
+		defer p.mx.Unlock()
+		return len(p.routes) == 1
+	}, time.Second, 10*time.Millisecond)
+	close(r.events)
+
+	require.NoError(t, node.Close())
+	p.mx.Lock()
+	assert.True(t, p.shutdown)
+	p.mx.Unlock()
+}
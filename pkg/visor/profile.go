@@ -0,0 +1,99 @@
+package visor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ProfileEnv is the environment variable selecting the config profile, if not selected by flag.
+const ProfileEnv = EnvPrefix + "PROFILE"
+
+// ErrProfileKeys occurs when a config profile overrides the keys of the node, which are shared by all profiles.
+var ErrProfileKeys = errors.New("profiles may not override the node keys")
+
+// ProfileNames returns the sorted names of the profiles of the config.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the name of the applied profile, or an empty string if no profile was applied.
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+// ApplyProfile merges the named profile over the config. Objects are merged recursively, while other values
+// (including arrays) of the profile replace those of the config. An empty name applies no profile.
+func (c *Config) ApplyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown config profile '%s' (available profiles: %v)", name, c.ProfileNames())
+	}
+
+	var override map[string]interface{}
+	if err := json.Unmarshal(profile, &override); err != nil {
+		return fmt.Errorf("invalid config profile '%s': %v", name, err)
+	}
+	if _, ok := override["node"]; ok {
+		return ErrProfileKeys
+	}
+	delete(override, "profiles")
+
+	base, err := c.jsonObject()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(mergeJSON(base, override))
+	if err != nil {
+		return err
+	}
+
+	merged := Config{path: c.path, profile: name}
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return fmt.Errorf("invalid config profile '%s': %v", name, err)
+	}
+	*c = merged
+	return nil
+}
+
+// jsonObject returns the config as a generic JSON object.
+func (c *Config) jsonObject() (map[string]interface{}, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	// Sealed secret keys are omitted, as null keys are invalid.
+	if c.Node.StaticSecKey.Null() {
+		if keys, ok := obj["node"].(map[string]interface{}); ok {
+			delete(keys, "static_secret_key")
+		}
+	}
+	return obj, nil
+}
+
+// mergeJSON recursively merges the JSON object override into base.
+func mergeJSON(base, override map[string]interface{}) map[string]interface{} {
+	for k, v := range override {
+		if vm, ok := v.(map[string]interface{}); ok {
+			if bm, ok := base[k].(map[string]interface{}); ok {
+				base[k] = mergeJSON(bm, vm)
+				continue
+			}
+		}
+		base[k] = v
+	}
+	return base
+}
@@ -0,0 +1,45 @@
+package visor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ApplyProfile(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	conf := new(Config)
+	conf.Version = ConfigVersion
+	conf.Node.StaticPubKey, conf.Node.StaticSecKey = pk, sk
+	conf.Messaging.Discovery = "http://dmsg.discovery"
+	conf.Messaging.ServerCount = 2
+	conf.Transport.Discovery = "http://transport.discovery"
+	conf.ShutdownTimeout = Duration(10 * time.Second)
+	conf.Profiles = map[string]json.RawMessage{
+		"test":  json.RawMessage(`{"messaging":{"discovery":"http://localhost:9090"},"trusted_nodes":[]}`),
+		"keys":  json.RawMessage(`{"node":{}}`),
+		"other": json.RawMessage(`{}`),
+	}
+	assert.Equal(t, []string{"keys", "other", "test"}, conf.ProfileNames())
+
+	require.NoError(t, conf.ApplyProfile(""))
+	assert.Empty(t, conf.Profile())
+
+	base := *conf
+	assert.Error(t, conf.ApplyProfile("unknown"))
+	assert.Equal(t, ErrProfileKeys, conf.ApplyProfile("keys"))
+
+	require.NoError(t, conf.ApplyProfile("test"))
+	assert.Equal(t, "test", conf.Profile())
+	assert.Equal(t, "http://localhost:9090", conf.Messaging.Discovery)
+	assert.Equal(t, 2, conf.Messaging.ServerCount)
+	assert.Equal(t, base.Transport.Discovery, conf.Transport.Discovery)
+	assert.Equal(t, base.ShutdownTimeout, conf.ShutdownTimeout)
+	assert.Equal(t, pk, conf.Node.StaticPubKey)
+	assert.Equal(t, sk, conf.Node.StaticSecKey)
+	assert.Len(t, conf.Profiles, 3)
+}
@@ -0,0 +1,54 @@
+package visor
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// ErrDmsgPtyDisabled occurs when managing the dmsgpty host of a node which has 'dmsg_pty' unset.
+var ErrDmsgPtyDisabled = errors.New("dmsgpty is disabled")
+
+// PtyWhitelist returns the sorted public keys allowed to open remote ptys on the node.
+func (node *Node) PtyWhitelist() ([]cipher.PubKey, error) {
+	if node.pty == nil {
+		return nil, ErrDmsgPtyDisabled
+	}
+	all, err := node.pty.Whitelist().All()
+	if err != nil {
+		return nil, err
+	}
+	pks := make([]cipher.PubKey, 0, len(all))
+	for pk, ok := range all {
+		if ok {
+			pks = append(pks, pk)
+		}
+	}
+	sort.Slice(pks, func(i, j int) bool { return pks[i].Hex() < pks[j].Hex() })
+	return pks, nil
+}
+
+// AddPtyWhitelist allows the public keys to open remote ptys on the node.
+func (node *Node) AddPtyWhitelist(pks ...cipher.PubKey) error {
+	if node.pty == nil {
+		return ErrDmsgPtyDisabled
+	}
+	if err := node.pty.Whitelist().Add(pks...); err != nil {
+		return err
+	}
+	node.RecordEvent(EventConfigChanged, "dmsgpty", "whitelisted %v", pks)
+	return nil
+}
+
+// RemovePtyWhitelist disallows the public keys to open remote ptys on the node.
+func (node *Node) RemovePtyWhitelist(pks ...cipher.PubKey) error {
+	if node.pty == nil {
+		return ErrDmsgPtyDisabled
+	}
+	if err := node.pty.Whitelist().Remove(pks...); err != nil {
+		return err
+	}
+	node.RecordEvent(EventConfigChanged, "dmsgpty", "removed %v from whitelist", pks)
+	return nil
+}
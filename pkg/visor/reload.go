@@ -0,0 +1,171 @@
+package visor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNoConfigPath occurs when reloading the config of a node whose config was not read from a file.
+var ErrNoConfigPath = errors.New("config was not read from a file")
+
+// ReloadResult reports the changes of a config reload, by the JSON keys of the changed top-level fields.
+type ReloadResult struct {
+	Applied         []string `json:"applied"`          // changes applied at runtime.
+	RestartRequired []string `json:"restart_required"` // changes which take effect after the node is restarted.
+}
+
+// Reload re-reads the config from the file the node's config was read from, and applies the changes which are safe
+// to apply at runtime:
+//   - apps: running apps keep their previous config until they are restarted.
+//   - log_level
+//   - persistent_transports: new persistent transports are established on the next check.
+//   - reward_address
+//   - trusted_nodes: used by transport maintenance from its next iteration.
+//   - trusted_visors
+//
+// Other changes are reported, and take effect after the node is restarted.
+func (node *Node) Reload() (*ReloadResult, error) {
+	path := node.conf.Path()
+	if path == "" {
+		return nil, ErrNoConfigPath
+	}
+	if from, ok, err := MigrateConfigFile(path); err != nil {
+		return nil, err
+	} else if ok {
+		node.logger.Infof("Migrated config from version %s to %s", from, ConfigVersion)
+	}
+	conf, err := ReadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := conf.ApplyProfile(node.conf.Profile()); err != nil {
+		return nil, err
+	}
+	// Overrides of the environment still apply, so that they are not reported as changes.
+	if _, err := conf.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if name := node.conf.Identity(); name != "" {
+		if conf, err = conf.identityConfig(name); err != nil {
+			return nil, fmt.Errorf("identity '%s': %v", name, err)
+		}
+	}
+	return node.applyConfig(conf)
+}
+
+func (node *Node) applyConfig(conf *Config) (*ReloadResult, error) {
+	node.reloadMu.Lock()
+	defer node.reloadMu.Unlock()
+
+	changed, err := changedFields(node.conf, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate all applicable changes before applying any.
+	apps, err := conf.AppsConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid AppsConfig: %s", err)
+	}
+	var lvl logrus.Level
+	if changed["log_level"] {
+		if lvl, err = logging.LevelFromString(conf.LogLevel); err != nil {
+			return nil, fmt.Errorf("invalid log_level: %s", err)
+		}
+	}
+	if changed["reward_address"] {
+		if err := ValidateRewardAddress(conf.RewardAddress); err != nil {
+			return nil, err
+		}
+	}
+	if changed["persistent_transports"] && node.tm != nil {
+		if err := node.tm.SetPersistentTransports(conf.MaintainedTransports(time.Now())); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &ReloadResult{Applied: []string{}, RestartRequired: []string{}}
+	for key := range changed {
+		switch key {
+		case "apps":
+			node.appsMu.Lock()
+			node.appsConf = apps
+			node.appsMu.Unlock()
+			node.conf.Apps = conf.Apps
+		case "log_level":
+			node.setGlobalLogLevel(lvl)
+			node.conf.LogLevel = conf.LogLevel
+		case "persistent_transports":
+			node.conf.PersistentTransports = conf.PersistentTransports
+		case "reward_address":
+			node.setRewardAddress(conf.RewardAddress)
+		case "trusted_nodes":
+			if node.tm != nil {
+				node.tm.SetDefaultNodes(conf.TrustedNodes)
+			}
+			node.conf.TrustedNodes = conf.TrustedNodes
+		case "trusted_visors":
+			if node.tm != nil {
+				node.tm.SetTrustedVisors(conf.TrustedVisors)
+			}
+			node.conf.TrustedVisors = conf.TrustedVisors
+		default:
+			res.RestartRequired = append(res.RestartRequired, key)
+			continue
+		}
+		res.Applied = append(res.Applied, key)
+	}
+	sort.Strings(res.Applied)
+	sort.Strings(res.RestartRequired)
+
+	node.logger.Infof("Reloaded config: applied %v, restart required for %v", res.Applied, res.RestartRequired)
+	if len(changed) > 0 {
+		node.RecordEvent(EventConfigChanged, node.conf.Path(), "reloaded: applied %v, restart required for %v",
+			res.Applied, res.RestartRequired)
+	}
+	return res, nil
+}
+
+// changedFields returns the JSON keys of the top-level fields which differ between the configs.
+func changedFields(prev, next *Config) (map[string]bool, error) {
+	oldFields, err := configFields(prev)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := configFields(next)
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[string]bool)
+	for key, v := range newFields {
+		if !bytes.Equal(v, oldFields[key]) {
+			changed[key] = true
+		}
+	}
+	for key := range oldFields {
+		if _, ok := newFields[key]; !ok {
+			changed[key] = true
+		}
+	}
+	return changed, nil
+}
+
+func configFields(c *Config) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
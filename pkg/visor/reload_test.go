@@ -0,0 +1,85 @@
+package visor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+func TestNodeReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_reload")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "skywire-config.json")
+
+	writeConfig := func(c *Config) {
+		data, err := json.Marshal(c)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	}
+
+	initial := &Config{Version: ConfigVersion, LogLevel: "info", AppsPath: "./apps"}
+	initial.Node.StaticPubKey, initial.Node.StaticSecKey = cipher.GenerateKeyPair()
+	initial.Apps = []AppConfig{{App: "foo", Version: "1.0", Port: routing.Port(10)}}
+	writeConfig(initial)
+
+	conf, err := ReadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, conf.Path())
+
+	apps, err := conf.AppsConfig()
+	require.NoError(t, err)
+	node := &Node{conf: conf, appsConf: apps, startedApps: map[string]*appBind{},
+		Logger: logging.NewMasterLogger(), logger: logging.MustGetLogger("test")}
+
+	t.Run("Unchanged", func(t *testing.T) {
+		res, err := node.Reload()
+		require.NoError(t, err)
+		assert.Empty(t, res.Applied)
+		assert.Empty(t, res.RestartRequired)
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		pk, _ := cipher.GenerateKeyPair()
+		next := *initial
+		next.LogLevel = "debug"
+		next.AppsPath = "./other-apps"
+		next.TrustedNodes = []cipher.PubKey{pk}
+		next.Apps = []AppConfig{{App: "foo", Version: "1.0", Port: routing.Port(10), Args: []string{"-x"}}}
+		writeConfig(&next)
+
+		res, err := node.Reload()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"apps", "log_level", "trusted_nodes"}, res.Applied)
+		assert.Equal(t, []string{"apps_path"}, res.RestartRequired)
+
+		assert.Equal(t, []string{"-x"}, node.appConfigs()[0].Args)
+		assert.Equal(t, "debug", node.Logger.GetLevel().String())
+		assert.Equal(t, "./apps", node.conf.AppsPath)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		next := *initial
+		next.LogLevel = "loud"
+		writeConfig(&next)
+
+		_, err := node.Reload()
+		require.Error(t, err)
+		assert.Equal(t, "debug", node.Logger.GetLevel().String())
+		assert.Equal(t, []string{"-x"}, node.appConfigs()[0].Args)
+	})
+
+	t.Run("NoPath", func(t *testing.T) {
+		_, err := (&Node{conf: &Config{}}).Reload()
+		assert.Equal(t, ErrNoConfigPath, err)
+	})
+}
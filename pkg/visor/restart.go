@@ -0,0 +1,76 @@
+package visor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// restartAtLayout is the layout of the time of day of scheduled restarts.
+const restartAtLayout = "15:04"
+
+// RestartConfig configures the visor to periodically restart itself, which keeps long-running visors on
+// memory-constrained boards healthy without external cron jobs.
+// If both After and At are set, the visor restarts on whichever comes first.
+type RestartConfig struct {
+	After Duration `json:"after,omitempty"` // uptime after which to restart, disabled if 0.
+	At    string   `json:"at,omitempty"`    // local time of day to restart at, in the format "15:04", disabled if empty.
+}
+
+// Validate checks the restart config.
+func (c *RestartConfig) Validate() error {
+	if c.After < 0 {
+		return fmt.Errorf("invalid restart after %v", time.Duration(c.After))
+	}
+	if c.At != "" {
+		if _, err := time.Parse(restartAtLayout, c.At); err != nil {
+			return fmt.Errorf("invalid restart at '%s': expected format HH:MM", c.At)
+		}
+	}
+	if c.After == 0 && c.At == "" {
+		return errors.New("restart requires 'after' or 'at'")
+	}
+	return nil
+}
+
+// Next returns the time of the next restart of a visor started at start, or false if no restart is configured.
+func (c *RestartConfig) Next(start, now time.Time) (time.Time, bool) {
+	var next time.Time
+	if c.After > 0 {
+		next = start.Add(time.Duration(c.After))
+	}
+	if at, err := time.Parse(restartAtLayout, c.At); c.At != "" && err == nil {
+		t := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// ErrRestartUnsupported occurs when requesting a restart of a node whose process cannot restart itself.
+var ErrRestartUnsupported = errors.New("the visor does not support restarting itself")
+
+// SetRestarter sets the function which restarts the process of the node, such as by stopping the node and
+// re-executing the visor, as requested by RequestRestart.
+func (node *Node) SetRestarter(restart func()) {
+	node.restartMx.Lock()
+	node.restarter = restart
+	node.restartMx.Unlock()
+}
+
+// RequestRestart requests the restart of the process of the node, with the function set by SetRestarter.
+func (node *Node) RequestRestart() error {
+	node.restartMx.Lock()
+	restart := node.restarter
+	node.restartMx.Unlock()
+	if restart == nil {
+		return ErrRestartUnsupported
+	}
+	node.logger.Info("Restart requested")
+	go restart()
+	return nil
+}
@@ -0,0 +1,38 @@
+package visor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestartConfig_Next(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := start.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		conf RestartConfig
+		want time.Time
+	}{
+		{"after", RestartConfig{After: Duration(6 * time.Hour)}, start.Add(6 * time.Hour)},
+		{"at today", RestartConfig{At: "12:30"}, time.Date(2020, 1, 1, 12, 30, 0, 0, time.UTC)},
+		{"at tomorrow", RestartConfig{At: "04:00"}, time.Date(2020, 1, 2, 4, 0, 0, 0, time.UTC)},
+		{"after first", RestartConfig{After: Duration(2 * time.Hour), At: "23:00"}, start.Add(2 * time.Hour)},
+		{"at first", RestartConfig{After: Duration(48 * time.Hour), At: "23:00"}, time.Date(2020, 1, 1, 23, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, tt.conf.Validate())
+			next, ok := tt.conf.Next(start, now)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, next)
+		})
+	}
+
+	_, ok := (&RestartConfig{}).Next(start, now)
+	assert.False(t, ok)
+	assert.Error(t, (&RestartConfig{}).Validate())
+	assert.Error(t, (&RestartConfig{At: "25:00"}).Validate())
+}
@@ -0,0 +1,62 @@
+package visor
+
+import (
+	"fmt"
+
+	skycipher "github.com/SkycoinProject/skycoin/src/cipher"
+)
+
+// ValidateRewardAddress checks that the reward address is a valid Skycoin address. An empty address is valid, and
+// means that the node has no reward address.
+func ValidateRewardAddress(addr string) error {
+	if addr == "" {
+		return nil
+	}
+	if _, err := skycipher.DecodeBase58Address(addr); err != nil {
+		return fmt.Errorf("invalid reward address '%s': %v", addr, err)
+	}
+	return nil
+}
+
+// RewardAddress returns the Skycoin address which rewards of the node are paid to, or an empty string if unset.
+func (node *Node) RewardAddress() string {
+	node.reloadMu.Lock()
+	defer node.reloadMu.Unlock()
+	return node.conf.RewardAddress
+}
+
+// SetRewardAddress sets the reward address of the node, or unsets it if addr is empty. The address is reported
+// with uptime heartbeats and transport stats uploads, and saved to the config file of the node, if any.
+func (node *Node) SetRewardAddress(addr string) error {
+	if err := ValidateRewardAddress(addr); err != nil {
+		return err
+	}
+
+	node.reloadMu.Lock()
+	defer node.reloadMu.Unlock()
+
+	if path := node.conf.Path(); path != "" {
+		err := node.editConfigFile(path, func(obj map[string]interface{}) error {
+			if addr == "" {
+				delete(obj, "reward_address")
+			} else {
+				obj["reward_address"] = addr
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	node.setRewardAddress(addr)
+	node.RecordEvent(EventConfigChanged, "reward_address", "set reward address to '%s'", addr)
+	return nil
+}
+
+// setRewardAddress applies the reward address. The caller must hold reloadMu.
+func (node *Node) setRewardAddress(addr string) {
+	node.conf.RewardAddress = addr
+	if node.tm != nil {
+		node.tm.SetRewardAddress(addr)
+	}
+}
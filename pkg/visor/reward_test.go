@@ -0,0 +1,50 @@
+package visor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	skycipher "github.com/SkycoinProject/skycoin/src/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeRewardAddress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_reward")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "skywire-config.json")
+
+	initial := &Config{Version: ConfigVersion}
+	initial.Node.StaticPubKey, initial.Node.StaticSecKey = cipher.GenerateKeyPair()
+	data, err := json.Marshal(initial)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	conf, err := ReadConfig(path)
+	require.NoError(t, err)
+	node := &Node{conf: conf, logger: logging.MustGetLogger("test")}
+	assert.Empty(t, node.RewardAddress())
+
+	assert.Error(t, node.SetRewardAddress("not-an-address"))
+	assert.Empty(t, node.RewardAddress())
+
+	pk, _ := skycipher.GenerateKeyPair()
+	addr := skycipher.AddressFromPubKey(pk).String()
+	require.NoError(t, node.SetRewardAddress(addr))
+	assert.Equal(t, addr, node.RewardAddress())
+	saved, err := ReadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, addr, saved.RewardAddress)
+
+	require.NoError(t, node.SetRewardAddress(""))
+	assert.Empty(t, node.RewardAddress())
+	saved, err = ReadConfig(path)
+	require.NoError(t, err)
+	assert.Empty(t, saved.RewardAddress)
+}
@@ -2,8 +2,8 @@ package visor
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"net/http"
 	"path/filepath"
 	"time"
 
@@ -12,7 +12,9 @@ import (
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/geo"
 )
 
 const (
@@ -44,28 +46,22 @@ type RPC struct {
 type HealthInfo struct {
 	TransportDiscovery int `json:"transport_discovery"`
 	RouteFinder        int `json:"route_finder"`
-	SetupNode          int `json:"setup_node"`
-}
-
-// Health returns health information about the visor
-func (r *RPC) Health(_ *struct{}, out *HealthInfo) error {
-	out.TransportDiscovery = http.StatusOK
-	out.RouteFinder = http.StatusOK
-	out.SetupNode = http.StatusOK
+	SetupNode          int `json:"setup_node"` // healthy if any setup node is healthy.
+	DmsgDiscovery      int `json:"dmsg_discovery"`
 
-	_, err := r.node.conf.TransportDiscovery()
-	if err != nil {
-		out.TransportDiscovery = http.StatusNotFound
-	}
+	Services []ServiceHealth `json:"services"` // results of the checks of each service.
 
-	if r.node.conf.Routing.RouteFinder == "" {
-		out.RouteFinder = http.StatusNotFound
-	}
+	Clock    *ClockStatus `json:"clock,omitempty"`    // latest check of the local clock.
+	Warnings []string     `json:"warnings,omitempty"` // such as of clock skew.
+}
 
-	if len(r.node.conf.Routing.SetupNodes) == 0 {
-		out.SetupNode = http.StatusNotFound
+// Health actively checks the external services of the visor, reporting services which are not configured as
+// http.StatusNotFound, and unreachable services as http.StatusServiceUnavailable.
+func (r *RPC) Health(_ *struct{}, out *HealthInfo) error {
+	*out = *CheckHealth(context.Background(), r.node.conf)
+	if out.Clock = r.node.ClockStatus(); out.Clock != nil && out.Clock.Skewed() {
+		out.Warnings = append(out.Warnings, out.Clock.Warning())
 	}
-
 	return nil
 }
 
@@ -79,6 +75,17 @@ func (r *RPC) Uptime(_ *struct{}, out *float64) error {
 	return nil
 }
 
+/*
+	<<< METRICS >>>
+*/
+
+// Metrics returns the Prometheus metrics of the node in the text exposition format.
+func (r *RPC) Metrics(_ *struct{}, out *[]byte) error {
+	metrics, err := r.node.Metrics()
+	*out = metrics
+	return err
+}
+
 /*
 	<<< APP LOGS >>>
 */
@@ -107,29 +114,64 @@ func (r *RPC) LogsSince(in *AppLogsRequest, out *[]string) error {
 	return nil
 }
 
+// AppLogFiles returns the log files of an app.
+func (r *RPC) AppLogFiles(name *string, out *[]AppLogFile) error {
+	files, err := r.node.AppLogFiles(*name)
+	*out = files
+	return err
+}
+
+// AppLog reads a chunk of a log file of an app, or of the logs of its log store within a time range.
+func (r *RPC) AppLog(in *AppLogIn, out *AppLogOut) error {
+	res, err := r.node.AppLog(*in)
+	if err != nil {
+		return err
+	}
+	*out = *res
+	return nil
+}
+
+// TailLogs returns new lines logged by the visor or an app, waiting for them if necessary.
+func (r *RPC) TailLogs(in *TailLogsIn, out *TailLogsOut) error {
+	res, err := r.node.TailLogs(context.Background(), *in)
+	if err != nil {
+		return err
+	}
+	*out = *res
+	return nil
+}
+
 /*
 	<<< NODE SUMMARY >>>
 */
 
 // TransportSummary summarizes a Transport.
 type TransportSummary struct {
-	ID      uuid.UUID           `json:"id"`
-	Local   cipher.PubKey       `json:"local_pk"`
-	Remote  cipher.PubKey       `json:"remote_pk"`
-	Type    string              `json:"type"`
-	Log     *transport.LogEntry `json:"log,omitempty"`
-	IsSetup bool                `json:"is_setup"`
+	ID          uuid.UUID           `json:"id"`
+	Local       cipher.PubKey       `json:"local_pk"`
+	Remote      cipher.PubKey       `json:"remote_pk"`
+	Type        string              `json:"type"`
+	Log         *transport.LogEntry `json:"log,omitempty"`
+	IsSetup     bool                `json:"is_setup"`
+	Labels      []string            `json:"labels,omitempty"`
+	MTU         uint16              `json:"mtu"`
+	CipherSuite string              `json:"cipher_suite,omitempty"`
+	Persistent  bool                `json:"persistent,omitempty"`
 }
 
 func newTransportSummary(tm *transport.Manager, tp *transport.ManagedTransport,
 	includeLogs bool, isSetup bool) *TransportSummary {
 
 	summary := &TransportSummary{
-		ID:      tp.Entry.ID,
-		Local:   tm.Local(),
-		Remote:  tp.Remote(),
-		Type:    tp.Type(),
-		IsSetup: isSetup,
+		ID:          tp.Entry.ID,
+		Local:       tm.Local(),
+		Remote:      tp.Remote(),
+		Type:        tp.Type(),
+		IsSetup:     isSetup,
+		Labels:      tm.Labels(tp.Entry.ID),
+		MTU:         tp.MTU(),
+		CipherSuite: tp.CipherSuite(),
+		Persistent:  tm.IsPersistent(tp),
 	}
 	if includeLogs {
 		summary.Log = tp.LogEntry
@@ -145,6 +187,22 @@ type Summary struct {
 	Apps            []*AppState         `json:"apps"`
 	Transports      []*TransportSummary `json:"transports"`
 	RoutesCount     int                 `json:"routes_count"`
+	STCPEndpoints   []string            `json:"stcp_endpoints,omitempty"`
+	Networks        map[string]string   `json:"networks,omitempty"` // reasons networks are unavailable, empty if available.
+	DmsgServers     []snet.DmsgServer   `json:"dmsg_servers,omitempty"`
+	UptimeTracker   *UptimeStatus       `json:"uptime_tracker,omitempty"`
+	Profile         string              `json:"config_profile,omitempty"`
+	RewardAddress   string              `json:"reward_address,omitempty"`
+	Identity        string              `json:"identity,omitempty"`        // empty for the primary identity of the visor.
+	Location        *geo.Location       `json:"location,omitempty"`        // self-reported location of the node.
+	DeprecatedKeys  []DeprecatedKey     `json:"deprecated_keys,omitempty"` // previous keys of the node within their grace period.
+	DmsgSessions    []snet.DmsgSession  `json:"dmsg_sessions,omitempty"`
+	STCP            *STCPStatus         `json:"stcp,omitempty"`
+	RoutesByType    map[string]int      `json:"routes_by_type,omitempty"`
+	SetupNode       *cipher.PubKey      `json:"setup_node,omitempty"` // setup node last used to set up routes.
+	AppHealth       []*AppHealth        `json:"app_health,omitempty"`
+	RecentErrors    []Event             `json:"recent_errors,omitempty"`
+	SharedState     *SharedState        `json:"shared_state,omitempty"` // nil if never set by a hypervisor.
 }
 
 // Summary provides a summary of the AppNode.
@@ -162,6 +220,34 @@ func (r *RPC) Summary(_ *struct{}, out *Summary) error {
 		Apps:            r.node.Apps(),
 		Transports:      summaries,
 		RoutesCount:     r.node.rt.Count(),
+		Profile:         r.node.conf.Profile(),
+		Identity:        r.node.conf.Identity(),
+		Location:        r.node.Location(),
+		RewardAddress:   r.node.RewardAddress(),
+		DeprecatedKeys:  r.node.conf.DeprecatedKeys(time.Now()),
+		RoutesByType:    routesByType(r.node.rt),
+		AppHealth:       r.node.appHealth(),
+		RecentErrors:    r.node.recentErrors(),
+	}
+	if sn, ok := r.node.router.(setupNodeReporter); ok {
+		if pk, ok := sn.SetupNode(); ok {
+			out.SetupNode = &pk
+		}
+	}
+	if r.node.uptime != nil {
+		out.UptimeTracker = r.node.uptime.Status()
+	}
+	if state, err := r.node.SharedState(); err == nil && !state.UpdatedAt.IsZero() {
+		out.SharedState = &state
+	}
+	if r.node.n != nil {
+		out.Networks = r.node.n.Availability()
+		out.DmsgServers = r.node.n.DmsgServers()
+		out.DmsgSessions = r.node.n.DmsgSessions()
+		if c := r.node.n.STcp(); c != nil {
+			out.STCPEndpoints = c.Endpoints()
+			out.STCP = newSTCPStatus(c, r.node.conf.STCP.LocalAddr)
+		}
 	}
 	return nil
 }
@@ -270,6 +356,7 @@ type AddTransportIn struct {
 	TpType   string
 	Public   bool
 	Timeout  time.Duration
+	Labels   []string
 }
 
 // AddTransport creates a transport for the node.
@@ -281,10 +368,20 @@ func (r *RPC) AddTransport(in *AddTransportIn, out *TransportSummary) error {
 		defer cancel()
 	}
 
+	labels, err := transport.NormalizeLabels(in.Labels)
+	if err != nil {
+		return err
+	}
+
 	tp, err := r.node.tm.SaveTransport(ctx, in.RemotePK, in.TpType)
 	if err != nil {
 		return err
 	}
+	if len(labels) > 0 {
+		if err := r.node.tm.SetLabels(tp.Entry.ID, labels); err != nil {
+			return err
+		}
+	}
 	*out = *newTransportSummary(r.node.tm, tp, false, r.node.router.SetupIsTrusted(tp.Remote()))
 	return nil
 }
@@ -295,6 +392,71 @@ func (r *RPC) RemoveTransport(tid *uuid.UUID, _ *struct{}) error {
 	return nil
 }
 
+// SetTransportLabelsIn is input of SetTransportLabels.
+type SetTransportLabelsIn struct {
+	ID     uuid.UUID
+	Labels []string // removes the labels if empty.
+}
+
+// SetTransportLabels replaces the labels of a transport.
+func (r *RPC) SetTransportLabels(in *SetTransportLabelsIn, out *TransportSummary) error {
+	tp := r.node.tm.Transport(in.ID)
+	if tp == nil {
+		return ErrNotFound
+	}
+	if err := r.node.tm.SetLabels(in.ID, in.Labels); err != nil {
+		return err
+	}
+	*out = *newTransportSummary(r.node.tm, tp, false, r.node.router.SetupIsTrusted(tp.Remote()))
+	return nil
+}
+
+// TransportStats are the bandwidth and uptime statistics of a transport.
+type TransportStats struct {
+	transport.Stats
+	Quota *transport.QuotaUsage `json:"quota,omitempty"` // usage of the bandwidth quota of the remote, if any.
+}
+
+// TransportStats returns the statistics of a transport.
+func (r *RPC) TransportStats(tid *uuid.UUID, out *TransportStats) error {
+	tp := r.node.tm.Transport(*tid)
+	if tp == nil {
+		return ErrNotFound
+	}
+	out.Stats = tp.Stats()
+	for _, u := range r.node.tm.Quotas().Usage() {
+		if !u.Relay && u.Remote == tp.Remote() {
+			u := u
+			out.Quota = &u
+		}
+	}
+	return nil
+}
+
+// TransportBandwidth returns the bandwidth statistics of the transport of the ID, which may no longer be managed, or of
+// all managed transports if the ID is nil.
+func (r *RPC) TransportBandwidth(tid *uuid.UUID, out *[]transport.BandwidthStats) error {
+	if *tid == uuid.Nil {
+		*out = r.node.tm.AllBandwidthStats()
+		return nil
+	}
+	stats, err := r.node.tm.BandwidthStats(*tid)
+	if err == transport.ErrNoBandwidthStats {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	*out = []transport.BandwidthStats{*stats}
+	return nil
+}
+
+// BandwidthUsage returns the usage of the bandwidth quotas of the node.
+func (r *RPC) BandwidthUsage(_ *struct{}, out *[]transport.QuotaUsage) error {
+	*out = r.node.tm.Quotas().Usage()
+	return nil
+}
+
 /*
 	<<< AVAILABLE TRANSPORTS >>>
 */
@@ -313,6 +475,33 @@ func (r *RPC) DiscoverTransportsByPK(pk *cipher.PubKey, out *[]*transport.EntryW
 	return nil
 }
 
+// QueryTransportsIn is input for QueryTransportsByPK.
+type QueryTransportsIn struct {
+	PK    cipher.PubKey
+	Query transport.EdgeQuery
+}
+
+// QueryTransportsOut is output for QueryTransportsByPK.
+type QueryTransportsOut struct {
+	Entries []*transport.EntryWithStatus
+	Total   int
+}
+
+// QueryTransportsByPK obtains a filtered page of available transports via the transport discovery via given public key.
+func (r *RPC) QueryTransportsByPK(in *QueryTransportsIn, out *QueryTransportsOut) error {
+	tpD, err := r.node.conf.TransportDiscovery()
+	if err != nil {
+		return err
+	}
+	entries, total, err := tpD.QueryTransportsByEdge(context.Background(), in.PK, in.Query)
+	if err != nil {
+		return err
+	}
+	out.Entries = entries
+	out.Total = total
+	return nil
+}
+
 // DiscoverTransportByID obtains available transports via the transport discovery via a given transport ID.
 func (r *RPC) DiscoverTransportByID(id *uuid.UUID, out *transport.EntryWithStatus) error {
 	tpD, err := r.node.conf.TransportDiscovery()
@@ -327,6 +516,273 @@ func (r *RPC) DiscoverTransportByID(id *uuid.UUID, out *transport.EntryWithStatu
 	return nil
 }
 
+/*
+	<<< STCP PK TABLE >>>
+*/
+
+// STCPEntryIn is input for AddSTCPEntry.
+type STCPEntryIn struct {
+	PK   cipher.PubKey
+	Addr string
+}
+
+// STCPTable obtains the entries of the stcp PK table.
+func (r *RPC) STCPTable(_ *struct{}, out *map[cipher.PubKey]string) error {
+	*out = r.node.STCPTable()
+	return nil
+}
+
+// AddSTCPEntry adds or replaces an entry of the stcp PK table.
+func (r *RPC) AddSTCPEntry(in *STCPEntryIn, _ *struct{}) error {
+	return r.node.AddSTCPEntry(in.PK, in.Addr)
+}
+
+// RemoveSTCPEntry removes an entry of the stcp PK table.
+func (r *RPC) RemoveSTCPEntry(pk *cipher.PubKey, _ *struct{}) error {
+	return r.node.RemoveSTCPEntry(*pk)
+}
+
+/*
+	<<< CONFIG RELOAD >>>
+*/
+
+// Reload re-reads the config of the node, applying the changes which are safe to apply at runtime.
+func (r *RPC) Reload(_ *struct{}, out *ReloadResult) error {
+	res, err := r.node.Reload()
+	if err != nil {
+		return err
+	}
+	*out = *res
+	return nil
+}
+
+// CheckConfig validates the given config without applying it, or the config file of the node if no config is given.
+func (r *RPC) CheckConfig(in *[]byte, out *[]ConfigProblem) error {
+	problems, err := r.node.CheckConfig(*in)
+	if err != nil {
+		return err
+	}
+	*out = problems
+	return nil
+}
+
+// EffectiveConfig returns the config the node runs with, with secret fields redacted.
+func (r *RPC) EffectiveConfig(_ *struct{}, out *json.RawMessage) error {
+	conf, err := r.node.EffectiveConfig()
+	if err != nil {
+		return err
+	}
+	*out = conf
+	return nil
+}
+
+// DiffConfig previews the changes of a JSON merge patch of the config file of the node.
+func (r *RPC) DiffConfig(patch *[]byte, out *ConfigDiff) error {
+	diff, err := r.node.DiffConfig(*patch)
+	if err != nil {
+		return err
+	}
+	*out = *diff
+	return nil
+}
+
+// UpdateConfig applies a JSON merge patch to the config file of the node, and reloads or restarts the node.
+func (r *RPC) UpdateConfig(in *UpdateConfigIn, out *UpdateConfigOut) error {
+	res, err := r.node.UpdateConfig(*in)
+	if err != nil {
+		return err
+	}
+	*out = *res
+	return nil
+}
+
+/*
+	<<< UPDATES >>>
+*/
+
+// Update starts an update of the node to the latest release.
+func (r *RPC) Update(in *UpdateIn, out *UpdateStatus) error {
+	status, err := r.node.Update(*in)
+	*out = status
+	return err
+}
+
+// UpdateStatus obtains the status of the last update of the node.
+func (r *RPC) UpdateStatus(_ *struct{}, out *UpdateStatus) error {
+	status, err := r.node.UpdateStatus()
+	*out = status
+	return err
+}
+
+/*
+	<<< DMSGPTY >>>
+*/
+
+// PtyWhitelist returns the public keys allowed to open remote ptys on the node.
+func (r *RPC) PtyWhitelist(_ *struct{}, out *[]cipher.PubKey) error {
+	pks, err := r.node.PtyWhitelist()
+	*out = pks
+	return err
+}
+
+// AddPtyWhitelist allows the public keys to open remote ptys on the node.
+func (r *RPC) AddPtyWhitelist(in *[]cipher.PubKey, _ *struct{}) error {
+	return r.node.AddPtyWhitelist(*in...)
+}
+
+// RemovePtyWhitelist disallows the public keys to open remote ptys on the node.
+func (r *RPC) RemovePtyWhitelist(in *[]cipher.PubKey, _ *struct{}) error {
+	return r.node.RemovePtyWhitelist(*in...)
+}
+
+/*
+	<<< TRUSTED VISORS >>>
+*/
+
+// TrustedVisors returns the public keys of the trusted visors of the node.
+func (r *RPC) TrustedVisors(_ *struct{}, out *[]cipher.PubKey) error {
+	*out = r.node.TrustedVisors()
+	return nil
+}
+
+// AddTrustedVisors trusts the visors of the public keys.
+func (r *RPC) AddTrustedVisors(in *[]cipher.PubKey, _ *struct{}) error {
+	return r.node.AddTrustedVisors(*in...)
+}
+
+// RemoveTrustedVisors no longer trusts the visors of the public keys.
+func (r *RPC) RemoveTrustedVisors(in *[]cipher.PubKey, _ *struct{}) error {
+	return r.node.RemoveTrustedVisors(*in...)
+}
+
+/*
+	<<< REWARD ADDRESS >>>
+*/
+
+// SetRewardAddress sets the reward address of the node, or unsets it if empty.
+func (r *RPC) SetRewardAddress(in *string, _ *struct{}) error {
+	return r.node.SetRewardAddress(*in)
+}
+
+/*
+	<<< LOGGING >>>
+*/
+
+// SetLogLevel sets the log level of a module, or the global level if in.Module is empty.
+// An empty in.Level resets the module to the global level.
+func (r *RPC) SetLogLevel(in *SetLogLevelIn, _ *struct{}) error {
+	return r.node.SetLogLevel(in.Module, in.Level)
+}
+
+// LogLevels returns the log levels of the node.
+func (r *RPC) LogLevels(_ *struct{}, out *LogLevels) error {
+	*out = r.node.LogLevels()
+	return nil
+}
+
+/*
+	<<< EVENT LOG >>>
+*/
+
+// Events returns the recorded events of the node selected by the filter, oldest first.
+func (r *RPC) Events(in *EventFilter, out *[]Event) error {
+	*out = r.node.Events(*in)
+	return nil
+}
+
+// Subscribe returns the events published after in.After, waiting up to in.Wait for any to be published.
+// Subscribers receive events as they happen by calling Subscribe in a loop with the returned Next as After.
+func (r *RPC) Subscribe(in *SubscribeIn, out *SubscribeOut) error {
+	res, err := r.node.Subscribe(context.Background(), *in)
+	if res != nil {
+		*out = *res
+	}
+	return err
+}
+
+/*
+	<<< KEY ROTATION >>>
+*/
+
+// RotateKeys replaces the keypair of the node in its config file, retiring the current keypair for the given grace
+// period (DefaultKeyGracePeriod if 0).
+func (r *RPC) RotateKeys(grace *time.Duration, out *KeyRotation) error {
+	res, err := r.node.RotateKeys(*grace)
+	if err != nil {
+		return err
+	}
+	*out = *res
+	return nil
+}
+
+// SealKey replaces the secret key of the node in its config file with the key sealed with a passphrase.
+func (r *RPC) SealKey(in *SealKeyIn, _ *struct{}) error {
+	return r.node.SealKey(*in)
+}
+
+// Unseal unseals the secret key of a visor waiting to be unsealed (see ServeUnseal). As running nodes are unsealed,
+// it always fails with ErrKeyNotSealed.
+func (r *RPC) Unseal(_ *string, _ *struct{}) error {
+	return ErrKeyNotSealed
+}
+
+/*
+	<<< BACKUP >>>
+*/
+
+// Backup returns a backup of the config and state files of the node, encrypted with the passphrase.
+func (r *RPC) Backup(passphrase *string, out *[]byte) error {
+	backup, err := r.node.Backup(*passphrase)
+	if err != nil {
+		return err
+	}
+	*out = backup
+	return nil
+}
+
+// Restore restores a backup over the config and state files of the node, taking effect after a restart.
+func (r *RPC) Restore(in *RestoreIn, out *RestoreResult) error {
+	res, err := r.node.Restore(*in)
+	if res != nil {
+		*out = *res
+	}
+	return err
+}
+
+/*
+	<<< DMSG SESSIONS >>>
+*/
+
+// DmsgSessions obtains statistics of the sessions with dmsg servers.
+func (r *RPC) DmsgSessions(_ *struct{}, out *[]snet.DmsgSession) error {
+	*out = r.node.n.DmsgSessions()
+	return nil
+}
+
+/*
+	<<< HYPERVISORS >>>
+*/
+
+// Hypervisors obtains the status of the connection to each hypervisor of the node.
+func (r *RPC) Hypervisors(_ *struct{}, out *[]HypervisorStatus) error {
+	*out = r.node.Hypervisors()
+	return nil
+}
+
+// SharedState obtains the state shared by the hypervisors of the node.
+func (r *RPC) SharedState(_ *struct{}, out *SharedState) error {
+	state, err := r.node.SharedState()
+	*out = state
+	return err
+}
+
+// SetSharedState replaces the state shared by the hypervisors, unless it was updated since.
+func (r *RPC) SetSharedState(in *SharedState, out *SharedState) error {
+	state, err := r.node.SetSharedState(*in)
+	*out = state
+	return err
+}
+
 /*
 	<<< ROUTES MANAGEMENT >>>
 */
@@ -352,16 +808,30 @@ func (r *RPC) RoutingRule(key *routing.RouteID, rule *routing.Rule) error {
 	return err
 }
 
+// RuleInfos obtains the decoded rules of the RoutingTable, with their next hops and expiry.
+func (r *RPC) RuleInfos(_ *struct{}, out *[]RuleInfo) error {
+	var err error
+	*out, err = r.node.RuleInfos()
+	return err
+}
+
 // AddRoutingRule adds a RoutingRule and returns a Key in which the rule is stored under.
 func (r *RPC) AddRoutingRule(rule *routing.Rule, routeID *routing.RouteID) error {
 	var err error
-	*routeID, err = r.node.rt.AddRule(*rule)
-	return err
+	if *routeID, err = r.node.rt.AddRule(*rule); err != nil {
+		return err
+	}
+	r.node.touchRule(*routeID)
+	return nil
 }
 
 // SetRoutingRule sets a routing rule.
 func (r *RPC) SetRoutingRule(in *RoutingEntry, out *struct{}) error {
-	return r.node.rt.SetRule(in.Key, in.Value)
+	if err := r.node.rt.SetRule(in.Key, in.Value); err != nil {
+		return err
+	}
+	r.node.touchRule(in.Key)
+	return nil
 }
 
 // RemoveRoutingRule removes a RoutingRule based on given RouteID key.
@@ -369,6 +839,48 @@ func (r *RPC) RemoveRoutingRule(key *routing.RouteID, _ *struct{}) error {
 	return r.node.rt.DeleteRules(*key)
 }
 
+// FindRoutesIn is input of FindRoutes.
+type FindRoutesIn struct {
+	Dst     cipher.PubKey
+	MinHops uint16
+	MaxHops uint16 // DefaultMaxHops if zero.
+}
+
+// FindRoutesOut is output of FindRoutes.
+type FindRoutesOut struct {
+	Forward []routing.Route `json:"forward"`
+	Reverse []routing.Route `json:"reverse"`
+}
+
+// DefaultMaxHops is the maximum number of hops of routes found by FindRoutes if none is given.
+const DefaultMaxHops = 50
+
+// FindRoutes looks up routes between the node and the destination via the route finder, without setting them up.
+func (r *RPC) FindRoutes(in *FindRoutesIn, out *FindRoutesOut) error {
+	if r.node.rf == nil {
+		return errors.New("route finder is not configured")
+	}
+	if in.MaxHops == 0 {
+		in.MaxHops = DefaultMaxHops
+	}
+	fwd, rev, err := r.node.rf.PairedRoutes(r.node.conf.Node.StaticPubKey, in.Dst, in.MinHops, in.MaxHops)
+	if err != nil {
+		return err
+	}
+	out.Forward, out.Reverse = fwd, rev
+	return nil
+}
+
+// Ping sets up a loop to the remote visor, and measures the round-trip time and loss of packets sent over it.
+func (r *RPC) Ping(in *PingIn, out *PingOut) error {
+	res, err := r.node.Ping(context.Background(), *in)
+	if err != nil {
+		return err
+	}
+	*out = *res
+	return nil
+}
+
 /*
 	<<< LOOPS MANAGEMENT >>>
 	>>> TODO(evanlinjin): Implement.
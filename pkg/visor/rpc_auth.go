@@ -0,0 +1,163 @@
+package visor
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/gob"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"strings"
+)
+
+// RPCTokenEnv is the environment variable holding the RPC token of clients such as skywire-cli.
+const RPCTokenEnv = EnvPrefix + "RPC_TOKEN"
+
+// ErrRPCUnauthorized occurs when a mutating RPC call is made on an unauthenticated connection.
+var ErrRPCUnauthorized = errors.New("unauthorized: authenticate with the RPC token of the visor")
+
+// readOnlyRPCMethods are the RPC methods which may be called without authentication.
+// Other methods, including those added in the future, require authentication if an RPC token is configured.
+var readOnlyRPCMethods = map[string]bool{
+	"Authenticate":           true,
+	"Health":                 true,
+	"Uptime":                 true,
+	"Metrics":                true,
+	"Summary":                true,
+	"Apps":                   true,
+	"TransportTypes":         true,
+	"Transports":             true,
+	"Transport":              true,
+	"TransportStats":         true,
+	"TransportBandwidth":     true,
+	"BandwidthUsage":         true,
+	"DiscoverTransportsByPK": true,
+	"QueryTransportsByPK":    true,
+	"DiscoverTransportByID":  true,
+	"STCPTable":              true,
+	"DmsgSessions":           true,
+	"Hypervisors":            true,
+	"UpdateStatus":           true,
+	"SharedState":            true,
+	"RoutingRules":           true,
+	"RuleInfos":              true,
+	"RoutingRule":            true,
+	"FindRoutes":             true,
+	"Loops":                  true,
+	"PtyWhitelist":           true,
+	"TrustedVisors":          true,
+	"LogLevels":              true,
+	"AppLogFiles":            true,
+	"AppLog":                 true,
+	"TailLogs":               true,
+}
+
+// Authenticate authenticates the connection with the RPC token of the visor. The token is checked as the request
+// is read (see authCodec), so the method itself has nothing left to do.
+func (r *RPC) Authenticate(_ *string, _ *struct{}) error {
+	return nil
+}
+
+// serveAuthRPC accepts connections on l, serving RPC requests which require authentication with token for mutating
+// calls. It returns when l is closed.
+func (node *Node) serveAuthRPC(srv *rpc.Server, l net.Listener, token string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go srv.ServeCodec(newAuthCodec(conn, token, func() {
+			node.RecordEvent(EventAuthFailed, conn.RemoteAddr().String(), "RPC authentication")
+		}))
+	}
+}
+
+// authCodec is a gob rpc.ServerCodec which rejects mutating calls until the connection is authenticated.
+type authCodec struct {
+	rwc    io.ReadWriteCloser
+	dec    *gob.Decoder
+	enc    *gob.Encoder
+	encBuf *bufio.Writer
+	closed bool
+
+	token  string
+	failed func()
+	authed bool
+	method string // method of the request being read.
+}
+
+func newAuthCodec(conn io.ReadWriteCloser, token string, failed func()) *authCodec {
+	buf := bufio.NewWriter(conn)
+	return &authCodec{
+		rwc:    conn,
+		dec:    gob.NewDecoder(conn),
+		enc:    gob.NewEncoder(buf),
+		encBuf: buf,
+		token:  token,
+		failed: failed,
+	}
+}
+
+func (c *authCodec) ReadRequestHeader(r *rpc.Request) error {
+	if err := c.dec.Decode(r); err != nil {
+		return err
+	}
+	c.method = strings.TrimPrefix(r.ServiceMethod, RPCPrefix+".")
+	return nil
+}
+
+func (c *authCodec) ReadRequestBody(body interface{}) error {
+	switch {
+	case c.method == "Authenticate":
+		var token string
+		if err := c.dec.Decode(&token); err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(c.token)) != 1 {
+			c.failed()
+			return ErrRPCUnauthorized
+		}
+		c.authed = true
+		if p, ok := body.(*string); ok {
+			*p = token
+		}
+		return nil
+	case !c.authed && !readOnlyRPCMethods[c.method]:
+		// The body is read to keep the connection usable, but the request is rejected.
+		if err := c.dec.Decode(body); err != nil {
+			return err
+		}
+		return ErrRPCUnauthorized
+	default:
+		return c.dec.Decode(body)
+	}
+}
+
+func (c *authCodec) WriteResponse(r *rpc.Response, body interface{}) (err error) {
+	if err = c.enc.Encode(r); err != nil {
+		if c.encBuf.Flush() == nil {
+			// Gob couldn't encode the header. Should not happen, so if it does, shut down the connection.
+			_ = c.Close() //nolint:errcheck
+		}
+		return
+	}
+	if err = c.enc.Encode(body); err != nil {
+		if c.encBuf.Flush() == nil {
+			// Was a gob problem encoding the body but the header has been written.
+			// Shut down the connection to signal that the connection is broken.
+			_ = c.Close() //nolint:errcheck
+		}
+		return
+	}
+	return c.encBuf.Flush()
+}
+
+func (c *authCodec) Close() error {
+	if c.closed {
+		// Only call c.rwc.Close once; otherwise the semantics are undefined.
+		return nil
+	}
+	c.closed = true
+	return c.rwc.Close()
+}
@@ -0,0 +1,44 @@
+package visor
+
+import (
+	"net"
+	"net/rpc"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPCAuthentication(t *testing.T) {
+	node := &Node{conf: &Config{}, startedApps: map[string]*appBind{}, startedAt: time.Now(),
+		logger: logging.MustGetLogger("test")}
+
+	srv := rpc.NewServer()
+	require.NoError(t, srv.RegisterName(RPCPrefix, &RPC{node: node}))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+	go node.serveAuthRPC(srv, l, "secret")
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	client := NewRPCClient(rpc.NewClient(conn), RPCPrefix)
+
+	_, err = client.Uptime()
+	require.NoError(t, err)
+
+	err = client.StopApp("foo")
+	require.Error(t, err)
+	assert.Equal(t, ErrRPCUnauthorized.Error(), err.Error())
+
+	err = client.Authenticate("wrong")
+	require.Error(t, err)
+	assert.Equal(t, ErrRPCUnauthorized.Error(), err.Error())
+
+	require.NoError(t, client.Authenticate("secret"))
+	err = client.StopApp("foo")
+	require.Error(t, err)
+	assert.Equal(t, ErrAppNotRunning.Error(), err.Error())
+}
@@ -1,11 +1,15 @@
 package visor
 
 import (
+	"context"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"net/http"
 	"net/rpc"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,37 +21,93 @@ import (
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/geo"
 )
 
 // RPCClient represents a RPC Client implementation.
 type RPCClient interface {
+	Authenticate(token string) error
+
 	Summary() (*Summary, error)
 	Exec(command string) ([]byte, error)
 
 	Health() (*HealthInfo, error)
 	Uptime() (float64, error)
+	Metrics() ([]byte, error)
 
 	Apps() ([]*AppState, error)
 	StartApp(appName string) error
 	StopApp(appName string) error
 	SetAutoStart(appName string, autostart bool) error
 	LogsSince(timestamp time.Time, appName string) ([]string, error)
+	TailLogs(in TailLogsIn) (*TailLogsOut, error)
+	AppLogFiles(appName string) ([]AppLogFile, error)
+	AppLog(in AppLogIn) (*AppLogOut, error)
 
 	TransportTypes() ([]string, error)
 	Transports(types []string, pks []cipher.PubKey, logs bool) ([]*TransportSummary, error)
 	Transport(tid uuid.UUID) (*TransportSummary, error)
-	AddTransport(remote cipher.PubKey, tpType string, public bool, timeout time.Duration) (*TransportSummary, error)
+	AddTransport(remote cipher.PubKey, tpType string, public bool, timeout time.Duration, labels []string) (*TransportSummary, error)
 	RemoveTransport(tid uuid.UUID) error
+	SetTransportLabels(tid uuid.UUID, labels []string) (*TransportSummary, error)
+	TransportStats(tid uuid.UUID) (*TransportStats, error)
+	TransportBandwidth(tid uuid.UUID) ([]transport.BandwidthStats, error)
+	BandwidthUsage() ([]transport.QuotaUsage, error)
 
 	DiscoverTransportsByPK(pk cipher.PubKey) ([]*transport.EntryWithStatus, error)
+	QueryTransportsByPK(pk cipher.PubKey, q transport.EdgeQuery) ([]*transport.EntryWithStatus, int, error)
 	DiscoverTransportByID(id uuid.UUID) (*transport.EntryWithStatus, error)
 
+	STCPTable() (map[cipher.PubKey]string, error)
+	AddSTCPEntry(pk cipher.PubKey, addr string) error
+	RemoveSTCPEntry(pk cipher.PubKey) error
+
+	Reload() (*ReloadResult, error)
+	CheckConfig(data []byte) ([]ConfigProblem, error)
+	EffectiveConfig() (json.RawMessage, error)
+	DiffConfig(patch []byte) (*ConfigDiff, error)
+	UpdateConfig(patch []byte, restart bool) (*UpdateConfigOut, error)
+	Update(in UpdateIn) (*UpdateStatus, error)
+	UpdateStatus() (*UpdateStatus, error)
+	RotateKeys(grace time.Duration) (*KeyRotation, error)
+	SealKey(passphrase string, keyring bool) error
+	Unseal(passphrase string) error
+
+	Backup(passphrase string) ([]byte, error)
+	Restore(backup []byte, passphrase string) (*RestoreResult, error)
+
+	SetLogLevel(module, level string) error
+	LogLevels() (*LogLevels, error)
+
+	Events(filter EventFilter) ([]Event, error)
+	Subscribe(in SubscribeIn) (*SubscribeOut, error)
+
+	PtyWhitelist() ([]cipher.PubKey, error)
+	AddPtyWhitelist(pks ...cipher.PubKey) error
+	RemovePtyWhitelist(pks ...cipher.PubKey) error
+
+	TrustedVisors() ([]cipher.PubKey, error)
+	AddTrustedVisors(pks ...cipher.PubKey) error
+	RemoveTrustedVisors(pks ...cipher.PubKey) error
+
+	SetRewardAddress(addr string) error
+
+	DmsgSessions() ([]snet.DmsgSession, error)
+
+	Hypervisors() ([]HypervisorStatus, error)
+	SharedState() (*SharedState, error)
+	SetSharedState(state SharedState) (*SharedState, error)
+
 	RoutingRules() ([]*RoutingEntry, error)
+	RuleInfos() ([]RuleInfo, error)
 	RoutingRule(key routing.RouteID) (routing.Rule, error)
 	AddRoutingRule(rule routing.Rule) (routing.RouteID, error)
 	SetRoutingRule(key routing.RouteID, rule routing.Rule) error
 	RemoveRoutingRule(key routing.RouteID) error
+	FindRoutes(in FindRoutesIn) (*FindRoutesOut, error)
+	Ping(in PingIn) (*PingOut, error)
 
 	Loops() ([]LoopInfo, error)
 }
@@ -69,6 +129,11 @@ func (rc *rpcClient) Call(method string, args, reply interface{}) error {
 	return rc.client.Call(rc.prefix+"."+method, args, reply)
 }
 
+// Authenticate calls Authenticate, which allows mutating calls on the connection.
+func (rc *rpcClient) Authenticate(token string) error {
+	return rc.Call("Authenticate", &token, &struct{}{})
+}
+
 // Summary calls Summary.
 func (rc *rpcClient) Summary() (*Summary, error) {
 	out := new(Summary)
@@ -90,6 +155,13 @@ func (rc *rpcClient) Uptime() (float64, error) {
 	return out, err
 }
 
+// Metrics calls Metrics.
+func (rc *rpcClient) Metrics() ([]byte, error) {
+	out := make([]byte, 0)
+	err := rc.Call("Metrics", &struct{}{}, &out)
+	return out, err
+}
+
 // Exec calls Exec.
 func (rc *rpcClient) Exec(command string) ([]byte, error) {
 	output := make([]byte, 0)
@@ -137,6 +209,27 @@ func (rc *rpcClient) LogsSince(timestamp time.Time, appName string) ([]string, e
 	return res, nil
 }
 
+// TailLogs calls TailLogs.
+func (rc *rpcClient) TailLogs(in TailLogsIn) (*TailLogsOut, error) {
+	out := new(TailLogsOut)
+	err := rc.Call("TailLogs", &in, out)
+	return out, err
+}
+
+// AppLogFiles calls AppLogFiles.
+func (rc *rpcClient) AppLogFiles(appName string) ([]AppLogFile, error) {
+	files := make([]AppLogFile, 0)
+	err := rc.Call("AppLogFiles", &appName, &files)
+	return files, err
+}
+
+// AppLog calls AppLog.
+func (rc *rpcClient) AppLog(in AppLogIn) (*AppLogOut, error) {
+	out := new(AppLogOut)
+	err := rc.Call("AppLog", &in, out)
+	return out, err
+}
+
 // TransportTypes calls TransportTypes.
 func (rc *rpcClient) TransportTypes() ([]string, error) {
 	var types []string
@@ -163,13 +256,14 @@ func (rc *rpcClient) Transport(tid uuid.UUID) (*TransportSummary, error) {
 }
 
 // AddTransport calls AddTransport.
-func (rc *rpcClient) AddTransport(remote cipher.PubKey, tpType string, public bool, timeout time.Duration) (*TransportSummary, error) {
+func (rc *rpcClient) AddTransport(remote cipher.PubKey, tpType string, public bool, timeout time.Duration, labels []string) (*TransportSummary, error) {
 	var summary TransportSummary
 	err := rc.Call("AddTransport", &AddTransportIn{
 		RemotePK: remote,
 		TpType:   tpType,
 		Public:   public,
 		Timeout:  timeout,
+		Labels:   labels,
 	}, &summary)
 	return &summary, err
 }
@@ -179,18 +273,243 @@ func (rc *rpcClient) RemoveTransport(tid uuid.UUID) error {
 	return rc.Call("RemoveTransport", &tid, &struct{}{})
 }
 
+// SetTransportLabels calls SetTransportLabels.
+func (rc *rpcClient) SetTransportLabels(tid uuid.UUID, labels []string) (*TransportSummary, error) {
+	out := new(TransportSummary)
+	err := rc.Call("SetTransportLabels", &SetTransportLabelsIn{ID: tid, Labels: labels}, out)
+	return out, err
+}
+
+// TransportStats calls TransportStats.
+func (rc *rpcClient) TransportStats(tid uuid.UUID) (*TransportStats, error) {
+	out := new(TransportStats)
+	err := rc.Call("TransportStats", &tid, out)
+	return out, err
+}
+
+// TransportBandwidth calls TransportBandwidth.
+func (rc *rpcClient) TransportBandwidth(tid uuid.UUID) ([]transport.BandwidthStats, error) {
+	var stats []transport.BandwidthStats
+	err := rc.Call("TransportBandwidth", &tid, &stats)
+	return stats, err
+}
+
+// BandwidthUsage calls BandwidthUsage.
+func (rc *rpcClient) BandwidthUsage() ([]transport.QuotaUsage, error) {
+	var usage []transport.QuotaUsage
+	err := rc.Call("BandwidthUsage", &struct{}{}, &usage)
+	return usage, err
+}
+
 func (rc *rpcClient) DiscoverTransportsByPK(pk cipher.PubKey) ([]*transport.EntryWithStatus, error) {
 	var entries []*transport.EntryWithStatus
 	err := rc.Call("DiscoverTransportsByPK", &pk, &entries)
 	return entries, err
 }
 
+// QueryTransportsByPK calls QueryTransportsByPK.
+func (rc *rpcClient) QueryTransportsByPK(pk cipher.PubKey, q transport.EdgeQuery) ([]*transport.EntryWithStatus, int, error) {
+	var out QueryTransportsOut
+	err := rc.Call("QueryTransportsByPK", &QueryTransportsIn{PK: pk, Query: q}, &out)
+	return out.Entries, out.Total, err
+}
+
 func (rc *rpcClient) DiscoverTransportByID(id uuid.UUID) (*transport.EntryWithStatus, error) {
 	var entry transport.EntryWithStatus
 	err := rc.Call("DiscoverTransportByID", &id, &entry)
 	return &entry, err
 }
 
+// STCPTable calls STCPTable.
+func (rc *rpcClient) STCPTable() (map[cipher.PubKey]string, error) {
+	entries := make(map[cipher.PubKey]string)
+	err := rc.Call("STCPTable", &struct{}{}, &entries)
+	return entries, err
+}
+
+// AddSTCPEntry calls AddSTCPEntry.
+func (rc *rpcClient) AddSTCPEntry(pk cipher.PubKey, addr string) error {
+	return rc.Call("AddSTCPEntry", &STCPEntryIn{PK: pk, Addr: addr}, &struct{}{})
+}
+
+// RemoveSTCPEntry calls RemoveSTCPEntry.
+func (rc *rpcClient) RemoveSTCPEntry(pk cipher.PubKey) error {
+	return rc.Call("RemoveSTCPEntry", &pk, &struct{}{})
+}
+
+// Reload calls Reload.
+func (rc *rpcClient) Reload() (*ReloadResult, error) {
+	var res ReloadResult
+	err := rc.Call("Reload", &struct{}{}, &res)
+	return &res, err
+}
+
+// CheckConfig calls CheckConfig.
+func (rc *rpcClient) CheckConfig(data []byte) ([]ConfigProblem, error) {
+	var problems []ConfigProblem
+	err := rc.Call("CheckConfig", &data, &problems)
+	return problems, err
+}
+
+// EffectiveConfig calls EffectiveConfig.
+func (rc *rpcClient) EffectiveConfig() (json.RawMessage, error) {
+	var conf json.RawMessage
+	err := rc.Call("EffectiveConfig", &struct{}{}, &conf)
+	return conf, err
+}
+
+// DiffConfig calls DiffConfig.
+func (rc *rpcClient) DiffConfig(patch []byte) (*ConfigDiff, error) {
+	var diff ConfigDiff
+	err := rc.Call("DiffConfig", &patch, &diff)
+	return &diff, err
+}
+
+// UpdateConfig calls UpdateConfig.
+func (rc *rpcClient) UpdateConfig(patch []byte, restart bool) (*UpdateConfigOut, error) {
+	var out UpdateConfigOut
+	err := rc.Call("UpdateConfig", &UpdateConfigIn{Patch: patch, Restart: restart}, &out)
+	return &out, err
+}
+
+// Update calls Update.
+func (rc *rpcClient) Update(in UpdateIn) (*UpdateStatus, error) {
+	var status UpdateStatus
+	err := rc.Call("Update", &in, &status)
+	return &status, err
+}
+
+// UpdateStatus calls UpdateStatus.
+func (rc *rpcClient) UpdateStatus() (*UpdateStatus, error) {
+	var status UpdateStatus
+	err := rc.Call("UpdateStatus", &struct{}{}, &status)
+	return &status, err
+}
+
+// PtyWhitelist calls PtyWhitelist.
+func (rc *rpcClient) PtyWhitelist() ([]cipher.PubKey, error) {
+	var pks []cipher.PubKey
+	err := rc.Call("PtyWhitelist", &struct{}{}, &pks)
+	return pks, err
+}
+
+// AddPtyWhitelist calls AddPtyWhitelist.
+func (rc *rpcClient) AddPtyWhitelist(pks ...cipher.PubKey) error {
+	return rc.Call("AddPtyWhitelist", &pks, &struct{}{})
+}
+
+// RemovePtyWhitelist calls RemovePtyWhitelist.
+func (rc *rpcClient) RemovePtyWhitelist(pks ...cipher.PubKey) error {
+	return rc.Call("RemovePtyWhitelist", &pks, &struct{}{})
+}
+
+// TrustedVisors calls TrustedVisors.
+func (rc *rpcClient) TrustedVisors() ([]cipher.PubKey, error) {
+	var pks []cipher.PubKey
+	err := rc.Call("TrustedVisors", &struct{}{}, &pks)
+	return pks, err
+}
+
+// AddTrustedVisors calls AddTrustedVisors.
+func (rc *rpcClient) AddTrustedVisors(pks ...cipher.PubKey) error {
+	return rc.Call("AddTrustedVisors", &pks, &struct{}{})
+}
+
+// RemoveTrustedVisors calls RemoveTrustedVisors.
+func (rc *rpcClient) RemoveTrustedVisors(pks ...cipher.PubKey) error {
+	return rc.Call("RemoveTrustedVisors", &pks, &struct{}{})
+}
+
+// SetRewardAddress calls SetRewardAddress.
+func (rc *rpcClient) SetRewardAddress(addr string) error {
+	return rc.Call("SetRewardAddress", &addr, &struct{}{})
+}
+
+// SetLogLevel calls SetLogLevel.
+func (rc *rpcClient) SetLogLevel(module, level string) error {
+	return rc.Call("SetLogLevel", &SetLogLevelIn{Module: module, Level: level}, &struct{}{})
+}
+
+// LogLevels calls LogLevels.
+func (rc *rpcClient) LogLevels() (*LogLevels, error) {
+	out := new(LogLevels)
+	err := rc.Call("LogLevels", &struct{}{}, out)
+	return out, err
+}
+
+// Events calls Events.
+func (rc *rpcClient) Events(filter EventFilter) ([]Event, error) {
+	var events []Event
+	err := rc.Call("Events", &filter, &events)
+	return events, err
+}
+
+// Subscribe calls Subscribe.
+func (rc *rpcClient) Subscribe(in SubscribeIn) (*SubscribeOut, error) {
+	out := new(SubscribeOut)
+	err := rc.Call("Subscribe", &in, out)
+	return out, err
+}
+
+// RotateKeys calls RotateKeys.
+func (rc *rpcClient) RotateKeys(grace time.Duration) (*KeyRotation, error) {
+	var res KeyRotation
+	err := rc.Call("RotateKeys", &grace, &res)
+	return &res, err
+}
+
+// SealKey calls SealKey.
+func (rc *rpcClient) SealKey(passphrase string, keyring bool) error {
+	return rc.Call("SealKey", &SealKeyIn{Passphrase: passphrase, Keyring: keyring}, &struct{}{})
+}
+
+// Unseal calls Unseal.
+func (rc *rpcClient) Unseal(passphrase string) error {
+	return rc.Call("Unseal", &passphrase, &struct{}{})
+}
+
+// Backup calls Backup.
+func (rc *rpcClient) Backup(passphrase string) ([]byte, error) {
+	var backup []byte
+	err := rc.Call("Backup", &passphrase, &backup)
+	return backup, err
+}
+
+// Restore calls Restore.
+func (rc *rpcClient) Restore(backup []byte, passphrase string) (*RestoreResult, error) {
+	res := new(RestoreResult)
+	err := rc.Call("Restore", &RestoreIn{Backup: backup, Passphrase: passphrase}, res)
+	return res, err
+}
+
+// DmsgSessions calls DmsgSessions.
+func (rc *rpcClient) DmsgSessions() ([]snet.DmsgSession, error) {
+	var sessions []snet.DmsgSession
+	err := rc.Call("DmsgSessions", &struct{}{}, &sessions)
+	return sessions, err
+}
+
+// Hypervisors calls Hypervisors.
+func (rc *rpcClient) Hypervisors() ([]HypervisorStatus, error) {
+	var statuses []HypervisorStatus
+	err := rc.Call("Hypervisors", &struct{}{}, &statuses)
+	return statuses, err
+}
+
+// SharedState calls SharedState.
+func (rc *rpcClient) SharedState() (*SharedState, error) {
+	state := new(SharedState)
+	err := rc.Call("SharedState", &struct{}{}, state)
+	return state, err
+}
+
+// SetSharedState calls SetSharedState.
+func (rc *rpcClient) SetSharedState(state SharedState) (*SharedState, error) {
+	out := new(SharedState)
+	err := rc.Call("SetSharedState", &state, out)
+	return out, err
+}
+
 // RoutingRules calls RoutingRules.
 func (rc *rpcClient) RoutingRules() ([]*RoutingEntry, error) {
 	var entries []*RoutingEntry
@@ -205,6 +524,13 @@ func (rc *rpcClient) RoutingRule(key routing.RouteID) (routing.Rule, error) {
 	return rule, err
 }
 
+// RuleInfos calls RuleInfos.
+func (rc *rpcClient) RuleInfos() ([]RuleInfo, error) {
+	var infos []RuleInfo
+	err := rc.Call("RuleInfos", &struct{}{}, &infos)
+	return infos, err
+}
+
 // AddRoutingRule calls AddRoutingRule.
 func (rc *rpcClient) AddRoutingRule(rule routing.Rule) (routing.RouteID, error) {
 	var tid routing.RouteID
@@ -222,6 +548,20 @@ func (rc *rpcClient) RemoveRoutingRule(key routing.RouteID) error {
 	return rc.Call("RemoveRoutingRule", &key, &struct{}{})
 }
 
+// FindRoutes calls FindRoutes.
+func (rc *rpcClient) FindRoutes(in FindRoutesIn) (*FindRoutesOut, error) {
+	var out FindRoutesOut
+	err := rc.Call("FindRoutes", &in, &out)
+	return &out, err
+}
+
+// Ping calls Ping.
+func (rc *rpcClient) Ping(in PingIn) (*PingOut, error) {
+	var out PingOut
+	err := rc.Call("Ping", &in, &out)
+	return &out, err
+}
+
 // Loops calls Loops.
 func (rc *rpcClient) Loops() ([]LoopInfo, error) {
 	var loops []LoopInfo
@@ -229,6 +569,14 @@ func (rc *rpcClient) Loops() ([]LoopInfo, error) {
 	return loops, err
 }
 
+// MockRPCClient is a RPCClient which mocks a visor, and can make the apps of the visor crash.
+type MockRPCClient interface {
+	RPCClient
+
+	// CrashApp stops the app, recording its crash in the event log.
+	CrashApp(appName string, at time.Time) error
+}
+
 // MockRPCClient mocks RPCClient.
 type mockRPCClient struct {
 	startedAt time.Time
@@ -236,21 +584,38 @@ type mockRPCClient struct {
 	tpTypes   []string
 	rt        routing.Table
 	appls     app.LogStore
+	conf      map[string]interface{} // config, as generic JSON.
+	update    *UpdateStatus          // last update, nil if never updated.
+	appLogs   map[string][]string    // logs of the log store of each app.
+	events    []Event
+	stream    *eventStream
+	ptyWL     map[cipher.PubKey]bool
 	sync.RWMutex
 }
 
-// NewMockRPCClient creates a new mock RPCClient.
+// mockKeyPair generates a key pair from r, so that mock data can be reproduced from the seed of r.
+func mockKeyPair(r *rand.Rand) (cipher.PubKey, cipher.SecKey) {
+	seed := make([]byte, 32)
+	r.Read(seed) //nolint:errcheck
+	pk, sk, err := cipher.GenerateDeterministicKeyPair(seed)
+	if err != nil {
+		panic(err)
+	}
+	return pk, sk
+}
+
+// NewMockRPCClient creates a new mock RPCClient, which implements MockRPCClient. The mock data is derived from r.
 func NewMockRPCClient(r *rand.Rand, maxTps int, maxRules int) (cipher.PubKey, RPCClient, error) {
 	log := logging.MustGetLogger("mock-rpc-client")
 
 	types := []string{"messaging", "native"}
-	localPK, _ := cipher.GenerateKeyPair()
+	localPK, localSK := mockKeyPair(r)
 
 	log.Infof("generating mock client with: localPK(%s) maxTps(%d) maxRules(%d)", localPK, maxTps, maxRules)
 
 	tps := make([]*TransportSummary, r.Intn(maxTps+1))
 	for i := range tps {
-		remotePK, _ := cipher.GenerateKeyPair()
+		remotePK, _ := mockKeyPair(r)
 		tps[i] = &TransportSummary{
 			ID:     transport.MakeTransportID(localPK, remotePK, types[r.Int()%len(types)]),
 			Local:  localPK,
@@ -263,7 +628,7 @@ func NewMockRPCClient(r *rand.Rand, maxTps int, maxRules int) (cipher.PubKey, RP
 	rt := routing.InMemoryRoutingTable()
 	ruleKeepAlive := router.DefaultRouteKeepAlive
 	for i := 0; i < r.Intn(maxRules+1); i++ {
-		remotePK, _ := cipher.GenerateKeyPair()
+		remotePK, _ := mockKeyPair(r)
 		var lpRaw, rpRaw [2]byte
 		if _, err := r.Read(lpRaw[:]); err != nil {
 			return cipher.PubKey{}, nil, err
@@ -277,7 +642,11 @@ func NewMockRPCClient(r *rand.Rand, maxTps int, maxRules int) (cipher.PubKey, RP
 		if err != nil {
 			panic(err)
 		}
-		fwdRule := routing.ForwardRule(ruleKeepAlive, routing.RouteID(r.Uint32()), uuid.New(), fwdRID)
+		var tpID uuid.UUID
+		if _, err := r.Read(tpID[:]); err != nil {
+			return cipher.PubKey{}, nil, err
+		}
+		fwdRule := routing.ForwardRule(ruleKeepAlive, routing.RouteID(r.Uint32()), tpID, fwdRID)
 		if err := rt.SetRule(fwdRID, fwdRule); err != nil {
 			panic(err)
 		}
@@ -293,6 +662,7 @@ func NewMockRPCClient(r *rand.Rand, maxTps int, maxRules int) (cipher.PubKey, RP
 		log.Infof("rt[%2db]: %v %v", i, appRID, appRule.Summary().AppFields)
 	}
 	log.Printf("rtCount: %d", rt.Count())
+	location := &geo.Location{Lat: r.Float64()*120 - 60, Lon: r.Float64()*360 - 180}
 	client := &mockRPCClient{
 		s: &Summary{
 			PubKey:          localPK,
@@ -304,14 +674,41 @@ func NewMockRPCClient(r *rand.Rand, maxTps int, maxRules int) (cipher.PubKey, RP
 			},
 			Transports:  tps,
 			RoutesCount: rt.Count(),
+			Location:    location,
+		},
+		tpTypes: types,
+		rt:      rt,
+		conf: map[string]interface{}{
+			"version": ConfigVersion,
+			"node": map[string]interface{}{
+				"static_public_key": localPK.Hex(),
+				"static_secret_key": localSK.Hex(),
+			},
+			"log_level": "info",
 		},
-		tpTypes:   types,
-		rt:        rt,
+		stream:    newEventStream(),
 		startedAt: time.Now(),
 	}
+	client.appLogs = mockAppLogs(client.s.Apps, client.startedAt)
 	return localPK, client, nil
 }
 
+// mockAppLogs returns logs of the apps, as logged up to now.
+func mockAppLogs(apps []*AppState, now time.Time) map[string][]string {
+	logs := make(map[string][]string, len(apps))
+	for _, a := range apps {
+		for i := 10; i > 0; i-- {
+			t := now.Add(-time.Duration(i) * time.Minute).UTC()
+			logs[a.Name] = append(logs[a.Name],
+				fmt.Sprintf("[%s] INFO [%s]: mock log line %d\n", t.Format(time.RFC3339Nano), a.Name, 10-i))
+		}
+	}
+	return logs
+}
+
+// mockAppLogFile is the name of the only log file of mock apps, which holds the logs of the log store.
+const mockAppLogFile = "mock/app.log"
+
 func (mc *mockRPCClient) do(write bool, f func() error) error {
 	if write {
 		mc.Lock()
@@ -323,6 +720,11 @@ func (mc *mockRPCClient) do(write bool, f func() error) error {
 	return f()
 }
 
+// Authenticate implements RPCClient.
+func (mc *mockRPCClient) Authenticate(string) error {
+	return nil
+}
+
 // Summary implements RPCClient.
 func (mc *mockRPCClient) Summary() (*Summary, error) {
 	var out Summary
@@ -346,6 +748,7 @@ func (mc *mockRPCClient) Health() (*HealthInfo, error) {
 		TransportDiscovery: http.StatusOK,
 		RouteFinder:        http.StatusOK,
 		SetupNode:          http.StatusOK,
+		DmsgDiscovery:      http.StatusOK,
 	}
 
 	return hi, nil
@@ -356,6 +759,17 @@ func (mc *mockRPCClient) Uptime() (float64, error) {
 	return time.Since(mc.startedAt).Seconds(), nil
 }
 
+// Metrics implements RPCClient.
+func (mc *mockRPCClient) Metrics() ([]byte, error) {
+	var metrics []byte
+	err := mc.do(false, func() error {
+		metrics = []byte(fmt.Sprintf("# HELP skywire_visor_transports Number of transports.\n"+
+			"# TYPE skywire_visor_transports gauge\nskywire_visor_transports %d\n", len(mc.s.Transports)))
+		return nil
+	})
+	return metrics, err
+}
+
 // Exec implements RPCClient.
 func (mc *mockRPCClient) Exec(command string) ([]byte, error) {
 	return []byte("mock"), nil
@@ -401,6 +815,47 @@ func (mc *mockRPCClient) LogsSince(timestamp time.Time, _ string) ([]string, err
 	return mc.appls.LogsSince(timestamp)
 }
 
+// TailLogs implements RPCClient.
+func (mc *mockRPCClient) TailLogs(TailLogsIn) (*TailLogsOut, error) {
+	return nil, ErrNotImplemented
+}
+
+// AppLogFiles implements RPCClient.
+func (mc *mockRPCClient) AppLogFiles(appName string) ([]AppLogFile, error) {
+	var files []AppLogFile
+	err := mc.do(false, func() error {
+		logs, ok := mc.appLogs[appName]
+		if !ok {
+			return ErrUnknownApp
+		}
+		files = []AppLogFile{{Name: mockAppLogFile, Size: int64(len(strings.Join(logs, ""))), ModTime: mc.startedAt}}
+		return nil
+	})
+	return files, err
+}
+
+// AppLog implements RPCClient.
+func (mc *mockRPCClient) AppLog(in AppLogIn) (*AppLogOut, error) {
+	var out *AppLogOut
+	err := mc.do(false, func() error {
+		logs, ok := mc.appLogs[in.App]
+		switch {
+		case !ok:
+			return ErrUnknownApp
+		case in.File == "":
+			out = readLogLines(logs, in)
+			return nil
+		case in.File != mockAppLogFile:
+			return ErrAppLogNotFound
+		}
+		data := strings.Join(logs, "")
+		var err error
+		out, err = readLogFile(strings.NewReader(data), int64(len(data)), in.Offset)
+		return err
+	})
+	return out, err
+}
+
 // TransportTypes implements RPCClient.
 func (mc *mockRPCClient) TransportTypes() ([]string, error) {
 	return mc.tpTypes, nil
@@ -458,16 +913,23 @@ func (mc *mockRPCClient) Transport(tid uuid.UUID) (*TransportSummary, error) {
 }
 
 // AddTransport implements RPCClient.
-func (mc *mockRPCClient) AddTransport(remote cipher.PubKey, tpType string, public bool, _ time.Duration) (*TransportSummary, error) {
+func (mc *mockRPCClient) AddTransport(remote cipher.PubKey, tpType string, public bool, _ time.Duration, labels []string) (*TransportSummary, error) {
+	labels, err := transport.NormalizeLabels(labels)
+	if err != nil {
+		return nil, err
+	}
 	summary := &TransportSummary{
 		ID:     transport.MakeTransportID(mc.s.PubKey, remote, tpType),
 		Local:  mc.s.PubKey,
 		Remote: remote,
 		Type:   tpType,
 		Log:    new(transport.LogEntry),
+		Labels: labels,
 	}
 	return summary, mc.do(true, func() error {
 		mc.s.Transports = append(mc.s.Transports, summary)
+		mc.record(Event{Time: time.Now(), Type: EventTransportAdded, Subject: summary.ID.String(),
+			Message: fmt.Sprintf("%s transport to %s", tpType, remote)})
 		return nil
 	})
 }
@@ -478,6 +940,8 @@ func (mc *mockRPCClient) RemoveTransport(tid uuid.UUID) error {
 		for i, tp := range mc.s.Transports {
 			if tp.ID == tid {
 				mc.s.Transports = append(mc.s.Transports[:i], mc.s.Transports[i+1:]...)
+				mc.record(Event{Time: time.Now(), Type: EventTransportRemoved, Subject: tid.String(),
+					Message: fmt.Sprintf("%s transport to %s", tp.Type, tp.Remote)})
 				return nil
 			}
 		}
@@ -485,14 +949,347 @@ func (mc *mockRPCClient) RemoveTransport(tid uuid.UUID) error {
 	})
 }
 
+// SetTransportLabels implements RPCClient.
+func (mc *mockRPCClient) SetTransportLabels(tid uuid.UUID, labels []string) (*TransportSummary, error) {
+	labels, err := transport.NormalizeLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	var summary TransportSummary
+	err = mc.do(true, func() error {
+		for _, tp := range mc.s.Transports {
+			if tp.ID == tid {
+				tp.Labels = labels
+				summary = *tp
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	return &summary, err
+}
+
+// TransportStats implements RPCClient.
+func (mc *mockRPCClient) TransportStats(tid uuid.UUID) (*TransportStats, error) {
+	var stats TransportStats
+	err := mc.do(false, func() error {
+		for _, tp := range mc.s.Transports {
+			if tp.ID == tid {
+				stats.ID, stats.Reporter, stats.Timestamp = tid, mc.s.PubKey, time.Now().Unix()
+				if tp.Log != nil {
+					stats.SentBytes, stats.RecvBytes = tp.Log.SentBytes, tp.Log.RecvBytes
+				}
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	return &stats, err
+}
+
+// TransportBandwidth implements RPCClient. The mock has no rates nor reconnects.
+func (mc *mockRPCClient) TransportBandwidth(tid uuid.UUID) ([]transport.BandwidthStats, error) {
+	stats := make([]transport.BandwidthStats, 0)
+	err := mc.do(false, func() error {
+		for _, tp := range mc.s.Transports {
+			if tid != uuid.Nil && tp.ID != tid {
+				continue
+			}
+			s := transport.BandwidthStats{ID: tp.ID, Remote: tp.Remote, Type: tp.Type, Managed: true}
+			if tp.Log != nil {
+				s.SentBytes, s.RecvBytes = tp.Log.SentBytes, tp.Log.RecvBytes
+			}
+			stats = append(stats, s)
+		}
+		if tid != uuid.Nil && len(stats) == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// BandwidthUsage implements RPCClient.
+func (mc *mockRPCClient) BandwidthUsage() ([]transport.QuotaUsage, error) {
+	return nil, ErrNotImplemented
+}
+
 func (mc *mockRPCClient) DiscoverTransportsByPK(pk cipher.PubKey) ([]*transport.EntryWithStatus, error) {
 	return nil, ErrNotImplemented
 }
 
+func (mc *mockRPCClient) QueryTransportsByPK(pk cipher.PubKey, q transport.EdgeQuery) ([]*transport.EntryWithStatus, int, error) {
+	return nil, 0, ErrNotImplemented
+}
+
 func (mc *mockRPCClient) DiscoverTransportByID(id uuid.UUID) (*transport.EntryWithStatus, error) {
 	return nil, ErrNotImplemented
 }
 
+// STCPTable implements RPCClient.
+func (mc *mockRPCClient) STCPTable() (map[cipher.PubKey]string, error) {
+	return nil, ErrNotImplemented
+}
+
+// AddSTCPEntry implements RPCClient.
+func (mc *mockRPCClient) AddSTCPEntry(cipher.PubKey, string) error {
+	return ErrNotImplemented
+}
+
+// RemoveSTCPEntry implements RPCClient.
+func (mc *mockRPCClient) RemoveSTCPEntry(cipher.PubKey) error {
+	return ErrNotImplemented
+}
+
+// Reload implements RPCClient.
+func (mc *mockRPCClient) Reload() (*ReloadResult, error) {
+	return nil, ErrNotImplemented
+}
+
+// CheckConfig implements RPCClient.
+func (mc *mockRPCClient) CheckConfig([]byte) ([]ConfigProblem, error) {
+	return nil, ErrNotImplemented
+}
+
+// EffectiveConfig implements RPCClient.
+func (mc *mockRPCClient) EffectiveConfig() (json.RawMessage, error) {
+	var conf json.RawMessage
+	err := mc.do(false, func() error {
+		var err error
+		conf, err = json.MarshalIndent(redactConfig(mc.conf), "", "  ")
+		return err
+	})
+	return conf, err
+}
+
+// DiffConfig implements RPCClient.
+func (mc *mockRPCClient) DiffConfig(patch []byte) (*ConfigDiff, error) {
+	var diff *ConfigDiff
+	err := mc.do(false, func() error {
+		var conf map[string]interface{}
+		if err := remarshal(mc.conf, &conf); err != nil {
+			return err
+		}
+		var err error
+		diff, err = patchConfig(conf, patch)
+		return err
+	})
+	return diff, err
+}
+
+// UpdateConfig implements RPCClient. Changes which require a restart are applied immediately.
+func (mc *mockRPCClient) UpdateConfig(patch []byte, restart bool) (*UpdateConfigOut, error) {
+	var out *UpdateConfigOut
+	err := mc.do(true, func() error {
+		diff, err := patchConfig(mc.conf, patch)
+		if err != nil {
+			return err
+		}
+		out = &UpdateConfigOut{
+			ConfigDiff: *diff,
+			Reload:     &ReloadResult{Applied: diff.Reloadable, RestartRequired: diff.RestartRequired},
+			Restarting: restart && len(diff.RestartRequired) > 0,
+		}
+		return nil
+	})
+	return out, err
+}
+
+// Update implements RPCClient. The mock is always up to date.
+func (mc *mockRPCClient) Update(UpdateIn) (*UpdateStatus, error) {
+	var status UpdateStatus
+	err := mc.do(true, func() error {
+		now := time.Now()
+		mc.update = &UpdateStatus{
+			State:          UpdateUpToDate,
+			CurrentVersion: Version,
+			LatestVersion:  Version,
+			StartedAt:      now,
+			FinishedAt:     now,
+		}
+		status = *mc.update
+		return nil
+	})
+	return &status, err
+}
+
+// UpdateStatus implements RPCClient.
+func (mc *mockRPCClient) UpdateStatus() (*UpdateStatus, error) {
+	status := UpdateStatus{State: UpdateIdle, CurrentVersion: Version}
+	err := mc.do(false, func() error {
+		if mc.update != nil {
+			status = *mc.update
+		}
+		return nil
+	})
+	return &status, err
+}
+
+// PtyWhitelist implements RPCClient.
+func (mc *mockRPCClient) PtyWhitelist() ([]cipher.PubKey, error) {
+	pks := make([]cipher.PubKey, 0)
+	err := mc.do(false, func() error {
+		for pk := range mc.ptyWL {
+			pks = append(pks, pk)
+		}
+		return nil
+	})
+	sort.Slice(pks, func(i, j int) bool { return pks[i].Hex() < pks[j].Hex() })
+	return pks, err
+}
+
+// AddPtyWhitelist implements RPCClient.
+func (mc *mockRPCClient) AddPtyWhitelist(pks ...cipher.PubKey) error {
+	return mc.do(true, func() error {
+		if mc.ptyWL == nil {
+			mc.ptyWL = make(map[cipher.PubKey]bool)
+		}
+		for _, pk := range pks {
+			mc.ptyWL[pk] = true
+		}
+		return nil
+	})
+}
+
+// RemovePtyWhitelist implements RPCClient.
+func (mc *mockRPCClient) RemovePtyWhitelist(pks ...cipher.PubKey) error {
+	return mc.do(true, func() error {
+		for _, pk := range pks {
+			delete(mc.ptyWL, pk)
+		}
+		return nil
+	})
+}
+
+// TrustedVisors implements RPCClient.
+func (mc *mockRPCClient) TrustedVisors() ([]cipher.PubKey, error) {
+	return nil, ErrNotImplemented
+}
+
+// AddTrustedVisors implements RPCClient.
+func (mc *mockRPCClient) AddTrustedVisors(...cipher.PubKey) error {
+	return ErrNotImplemented
+}
+
+// RemoveTrustedVisors implements RPCClient.
+func (mc *mockRPCClient) RemoveTrustedVisors(...cipher.PubKey) error {
+	return ErrNotImplemented
+}
+
+// SetRewardAddress implements RPCClient.
+func (mc *mockRPCClient) SetRewardAddress(string) error {
+	return ErrNotImplemented
+}
+
+// SetLogLevel implements RPCClient.
+func (mc *mockRPCClient) SetLogLevel(string, string) error {
+	return ErrNotImplemented
+}
+
+// LogLevels implements RPCClient.
+func (mc *mockRPCClient) LogLevels() (*LogLevels, error) {
+	return nil, ErrNotImplemented
+}
+
+// Events implements RPCClient.
+func (mc *mockRPCClient) Events(filter EventFilter) ([]Event, error) {
+	var events []Event
+	err := mc.do(false, func() error {
+		for _, e := range mc.events {
+			if filter.Match(e) {
+				events = append(events, e)
+			}
+		}
+		return nil
+	})
+	return events, err
+}
+
+// CrashApp implements MockRPCClient.
+func (mc *mockRPCClient) CrashApp(appName string, at time.Time) error {
+	return mc.do(true, func() error {
+		for _, app := range mc.s.Apps {
+			if app.Name == appName {
+				app.Status = AppStatusStopped
+				mc.record(Event{Time: at, Type: EventAppCrashed, Subject: appName, Message: "exit status 1"})
+				return nil
+			}
+		}
+		return fmt.Errorf("app of name '%s' does not exist", appName)
+	})
+}
+
+// record records the event in the event log, and publishes it to subscribers. mc must be locked for writing.
+func (mc *mockRPCClient) record(e Event) {
+	mc.events = append(mc.events, e)
+	mc.stream.publish(eventKind(e.Type), e)
+}
+
+// Subscribe implements RPCClient.
+func (mc *mockRPCClient) Subscribe(in SubscribeIn) (*SubscribeOut, error) {
+	return mc.stream.subscribe(context.Background(), in)
+}
+
+// RotateKeys implements RPCClient.
+func (mc *mockRPCClient) RotateKeys(time.Duration) (*KeyRotation, error) {
+	return nil, ErrNotImplemented
+}
+
+// SealKey implements RPCClient.
+func (mc *mockRPCClient) SealKey(string, bool) error {
+	return ErrNotImplemented
+}
+
+// Unseal implements RPCClient.
+func (mc *mockRPCClient) Unseal(string) error {
+	return ErrNotImplemented
+}
+
+// Backup implements RPCClient.
+func (mc *mockRPCClient) Backup(string) ([]byte, error) {
+	return nil, ErrNotImplemented
+}
+
+// Restore implements RPCClient.
+func (mc *mockRPCClient) Restore([]byte, string) (*RestoreResult, error) {
+	return nil, ErrNotImplemented
+}
+
+// DmsgSessions implements RPCClient.
+func (mc *mockRPCClient) DmsgSessions() ([]snet.DmsgSession, error) {
+	return nil, ErrNotImplemented
+}
+
+// Hypervisors implements RPCClient.
+func (mc *mockRPCClient) Hypervisors() ([]HypervisorStatus, error) {
+	return []HypervisorStatus{}, nil
+}
+
+// SharedState implements RPCClient.
+func (mc *mockRPCClient) SharedState() (*SharedState, error) {
+	state := new(SharedState)
+	err := mc.do(false, func() error {
+		if mc.s.SharedState != nil {
+			*state = *mc.s.SharedState
+		}
+		return nil
+	})
+	return state, err
+}
+
+// SetSharedState implements RPCClient.
+func (mc *mockRPCClient) SetSharedState(state SharedState) (*SharedState, error) {
+	out := new(SharedState)
+	err := mc.do(true, func() error {
+		if mc.s.SharedState == nil || state.UpdatedAt.After(mc.s.SharedState.UpdatedAt) {
+			mc.s.SharedState = &state
+		}
+		*out = *mc.s.SharedState
+		return nil
+	})
+	return out, err
+}
+
 // RoutingRules implements RPCClient.
 func (mc *mockRPCClient) RoutingRules() ([]*RoutingEntry, error) {
 	var entries []*RoutingEntry
@@ -503,6 +1300,25 @@ func (mc *mockRPCClient) RoutingRules() ([]*RoutingEntry, error) {
 	return entries, err
 }
 
+// RuleInfos implements RPCClient. The mock does not track the activity of rules.
+func (mc *mockRPCClient) RuleInfos() ([]RuleInfo, error) {
+	var infos []RuleInfo
+	err := mc.do(false, func() error {
+		nextHop := func(tid uuid.UUID) (cipher.PubKey, bool) {
+			for _, tp := range mc.s.Transports {
+				if tp.ID == tid {
+					return tp.Remote, true
+				}
+			}
+			return cipher.PubKey{}, false
+		}
+		var err error
+		infos, err = ruleInfos(mc.rt, nextHop, nil)
+		return err
+	})
+	return infos, err
+}
+
 // RoutingRule implements RPCClient.
 func (mc *mockRPCClient) RoutingRule(key routing.RouteID) (routing.Rule, error) {
 	return mc.rt.Rule(key)
@@ -523,6 +1339,52 @@ func (mc *mockRPCClient) RemoveRoutingRule(key routing.RouteID) error {
 	return mc.rt.DeleteRules(key)
 }
 
+// FindRoutes implements RPCClient. Only direct routes, over the transports to the destination, are found.
+func (mc *mockRPCClient) FindRoutes(in FindRoutesIn) (*FindRoutesOut, error) {
+	out := &FindRoutesOut{Forward: []routing.Route{}, Reverse: []routing.Route{}}
+	err := mc.do(false, func() error {
+		if in.MinHops > 1 {
+			return nil
+		}
+		for _, tp := range mc.s.Transports {
+			if tp.Remote != in.Dst {
+				continue
+			}
+			out.Forward = append(out.Forward, routing.Route{{From: mc.s.PubKey, To: in.Dst, Transport: tp.ID}})
+			out.Reverse = append(out.Reverse, routing.Route{{From: in.Dst, To: mc.s.PubKey, Transport: tp.ID}})
+		}
+		return nil
+	})
+	return out, err
+}
+
+// Ping implements RPCClient. Only visors with transports to the mock visor reply, after a random round-trip time.
+func (mc *mockRPCClient) Ping(in PingIn) (*PingOut, error) {
+	var out *PingOut
+	err := mc.do(false, func() error {
+		count := in.Count
+		if count <= 0 {
+			count = DefaultPingCount
+		}
+		reachable := false
+		for _, tp := range mc.s.Transports {
+			if tp.Remote == in.PK {
+				reachable = true
+			}
+		}
+		replies := make([]router.PingReply, count)
+		for i := range replies {
+			replies[i] = router.PingReply{Seq: i, Lost: !reachable}
+			if reachable {
+				replies[i].RTT = time.Duration(10+rand.Intn(40)) * time.Millisecond // nolint:gosec
+			}
+		}
+		out = newPingOut(in.PK, replies)
+		return nil
+	})
+	return out, err
+}
+
 // Loops implements RPCClient.
 func (mc *mockRPCClient) Loops() ([]LoopInfo, error) {
 	var loops []LoopInfo
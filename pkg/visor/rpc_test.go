@@ -1,13 +1,16 @@
 package visor
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/disc"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,13 +21,30 @@ import (
 
 func TestHealth(t *testing.T) {
 	sPK, sSK := cipher.GenerateKeyPair()
+	setupPK, _ := cipher.GenerateKeyPair()
+	srvPK, _ := cipher.GenerateKeyPair()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set(ServiceVersionHeader, "1.2.3")
+			w.WriteHeader(http.StatusOK)
+		case "/messaging-discovery/entry/" + setupPK.Hex():
+			entry := disc.Entry{Static: setupPK, Client: &disc.Client{DelegatedServers: []cipher.PubKey{srvPK}}}
+			require.NoError(t, json.NewEncoder(w).Encode(entry))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
 
 	c := &Config{}
 	c.Node.StaticPubKey = sPK
 	c.Node.StaticSecKey = sSK
-	c.Transport.Discovery = "foo"
-	c.Routing.SetupNodes = []cipher.PubKey{sPK}
-	c.Routing.RouteFinder = "foo"
+	c.Messaging.Discovery = srv.URL
+	c.Transport.Discovery = srv.URL
+	c.Routing.SetupNodes = []cipher.PubKey{setupPK}
+	c.Routing.RouteFinder = srv.URL
 
 	t.Run("Report all the services as available", func(t *testing.T) {
 		rpc := &RPC{&Node{conf: c}}
@@ -32,9 +52,33 @@ func TestHealth(t *testing.T) {
 		err := rpc.Health(nil, h)
 		require.NoError(t, err)
 
-		// Transport discovery needs to be mocked or will always fail
+		assert.Equal(t, http.StatusOK, h.DmsgDiscovery)
+		assert.Equal(t, http.StatusOK, h.TransportDiscovery)
 		assert.Equal(t, http.StatusOK, h.SetupNode)
 		assert.Equal(t, http.StatusOK, h.RouteFinder)
+		require.Len(t, h.Services, 4)
+		for _, s := range h.Services {
+			assert.True(t, s.Healthy(), s.Service)
+			if s.Service != ServiceSetupNode {
+				assert.Equal(t, "1.2.3", s.Version, s.Service)
+			}
+		}
+	})
+
+	t.Run("Report unreachable services as unavailable", func(t *testing.T) {
+		unknownPK, _ := cipher.GenerateKeyPair()
+		c := *c
+		c.Routing.RouteFinder = "http://127.0.0.1:1"
+		c.Routing.SetupNodes = []cipher.PubKey{unknownPK}
+
+		rpc := &RPC{&Node{conf: &c}}
+		h := &HealthInfo{}
+		err := rpc.Health(nil, h)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, h.TransportDiscovery)
+		assert.Equal(t, http.StatusServiceUnavailable, h.RouteFinder)
+		assert.Equal(t, http.StatusNotFound, h.SetupNode)
 	})
 
 	t.Run("Report as unavailable", func(t *testing.T) {
@@ -45,6 +89,7 @@ func TestHealth(t *testing.T) {
 
 		assert.Equal(t, http.StatusNotFound, h.SetupNode)
 		assert.Equal(t, http.StatusNotFound, h.RouteFinder)
+		assert.Empty(t, h.Services)
 	})
 }
 
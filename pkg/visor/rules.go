@@ -0,0 +1,77 @@
+package visor
+
+import (
+	"sort"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// RuleInfo is a decoded routing rule of the routing table.
+type RuleInfo struct {
+	RouteID routing.RouteID      `json:"route_id"`
+	Summary *routing.RuleSummary `json:"summary"`
+
+	// NextHop is the remote of the next transport of forward rules, if the node has the transport.
+	NextHop *cipher.PubKey `json:"next_hop,omitempty"`
+
+	// ExpiresAt is when the rule expires unless it is used, if the router tracks the activity of the rule.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ruleActivityTracker is implemented by routers which track the activity of routing rules, such as router.Router.
+type ruleActivityTracker interface {
+	RuleActivity(routeID routing.RouteID) (time.Time, bool)
+	TouchRule(routeID routing.RouteID)
+}
+
+// RuleInfos returns the decoded rules of the routing table, sorted by route ID.
+func (node *Node) RuleInfos() ([]RuleInfo, error) {
+	tracker, _ := node.router.(ruleActivityTracker)
+	nextHop := func(tid uuid.UUID) (cipher.PubKey, bool) {
+		if node.tm == nil {
+			return cipher.PubKey{}, false
+		}
+		if tp := node.tm.Transport(tid); tp != nil {
+			return tp.Remote(), true
+		}
+		return cipher.PubKey{}, false
+	}
+	return ruleInfos(node.rt, nextHop, tracker)
+}
+
+// ruleInfos decodes the rules of the routing table, resolving the next hops of forward rules with nextHop, and their
+// expiry with tracker if not nil.
+func ruleInfos(rt routing.Table, nextHop func(tid uuid.UUID) (cipher.PubKey, bool),
+	tracker ruleActivityTracker) ([]RuleInfo, error) {
+	infos := make([]RuleInfo, 0, rt.Count())
+	err := rt.RangeRules(func(routeID routing.RouteID, rule routing.Rule) bool {
+		info := RuleInfo{RouteID: routeID, Summary: rule.Summary()}
+		if fwd := info.Summary.ForwardFields; fwd != nil {
+			if pk, ok := nextHop(fwd.NextTID); ok {
+				info.NextHop = &pk
+			}
+		}
+		if tracker != nil {
+			if last, ok := tracker.RuleActivity(routeID); ok {
+				expiresAt := last.Add(rule.KeepAlive())
+				info.ExpiresAt = &expiresAt
+			}
+		}
+		infos = append(infos, info)
+		return true
+	})
+	sort.Slice(infos, func(i, j int) bool { return infos[i].RouteID < infos[j].RouteID })
+	return infos, err
+}
+
+// touchRule keeps a rule which is added by hand alive for its keep-alive duration, if the router tracks the activity
+// of rules.
+func (node *Node) touchRule(routeID routing.RouteID) {
+	if tracker, ok := node.router.(ruleActivityTracker); ok {
+		tracker.TouchRule(routeID)
+	}
+}
@@ -0,0 +1,70 @@
+package visor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+type fakeRuleTracker map[routing.RouteID]time.Time
+
+func (t fakeRuleTracker) RuleActivity(routeID routing.RouteID) (time.Time, bool) {
+	last, ok := t[routeID]
+	return last, ok
+}
+
+func (t fakeRuleTracker) TouchRule(routeID routing.RouteID) {
+	t[routeID] = time.Now()
+}
+
+func TestRuleInfos(t *testing.T) {
+	remotePK, _ := cipher.GenerateKeyPair()
+	nextPK, _ := cipher.GenerateKeyPair()
+	knownTID, unknownTID := uuid.New(), uuid.New()
+
+	rt := routing.InMemoryRoutingTable()
+	appID, err := rt.AddRule(routing.AppRule(time.Minute, 0, 2, remotePK, 1, 2))
+	require.NoError(t, err)
+	fwdID, err := rt.AddRule(routing.ForwardRule(time.Hour, 3, knownTID, 0))
+	require.NoError(t, err)
+	lostID, err := rt.AddRule(routing.ForwardRule(time.Hour, 4, unknownTID, 0))
+	require.NoError(t, err)
+
+	nextHop := func(tid uuid.UUID) (cipher.PubKey, bool) {
+		return nextPK, tid == knownTID
+	}
+	last := time.Now().Truncate(time.Second)
+	tracker := fakeRuleTracker{appID: last, fwdID: last}
+
+	infos, err := ruleInfos(rt, nextHop, tracker)
+	require.NoError(t, err)
+	require.Len(t, infos, 3)
+
+	assert.Equal(t, appID, infos[0].RouteID)
+	assert.Equal(t, routing.RuleApp, infos[0].Summary.Type)
+	assert.Nil(t, infos[0].NextHop)
+	require.NotNil(t, infos[0].ExpiresAt)
+	assert.Equal(t, last.Add(time.Minute), *infos[0].ExpiresAt)
+
+	assert.Equal(t, fwdID, infos[1].RouteID)
+	require.NotNil(t, infos[1].NextHop)
+	assert.Equal(t, nextPK, *infos[1].NextHop)
+	require.NotNil(t, infos[1].ExpiresAt)
+	assert.Equal(t, last.Add(time.Hour), *infos[1].ExpiresAt)
+
+	assert.Equal(t, lostID, infos[2].RouteID)
+	assert.Nil(t, infos[2].NextHop)
+	assert.Nil(t, infos[2].ExpiresAt)
+
+	infos, err = ruleInfos(rt, nextHop, nil)
+	require.NoError(t, err)
+	for _, info := range infos {
+		assert.Nil(t, info.ExpiresAt)
+	}
+}
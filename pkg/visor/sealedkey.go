@@ -0,0 +1,193 @@
+package visor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"sync"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/seal"
+)
+
+// KeyPassphraseEnv is the environment variable holding the passphrase of sealed secret keys.
+const KeyPassphraseEnv = EnvPrefix + "KEY_PASSPHRASE"
+
+var (
+	// ErrKeySealed occurs when the secret key of a config is sealed, and no passphrase is available to unseal it.
+	ErrKeySealed = errors.New("secret key is sealed")
+	// ErrKeyNotSealed occurs when unsealing a secret key which is not sealed.
+	ErrKeyNotSealed = errors.New("secret key is not sealed")
+	// ErrRotateSealedKey occurs when rotating sealed keys, which would store the new keys unsealed.
+	ErrRotateSealedKey = errors.New("sealed keys may not be rotated: rotate the keys before sealing them")
+)
+
+// SealKeyIn is input of SealKey.
+type SealKeyIn struct {
+	Passphrase string `json:"passphrase,omitempty"`
+	// Keyring seals the key with a random passphrase stored in the OS keyring, instead of Passphrase.
+	Keyring bool `json:"keyring,omitempty"`
+}
+
+// Sealed returns whether the secret key of the config is sealed, and not yet unsealed.
+func (c *Config) Sealed() bool {
+	return c.Node.SealedSecKey != "" && c.Node.StaticSecKey.Null()
+}
+
+// Unseal decrypts the sealed secret key of the config with the passphrase.
+func (c *Config) Unseal(passphrase []byte) error {
+	if !c.Sealed() {
+		return ErrKeyNotSealed
+	}
+	data, err := seal.Open(c.Node.SealedSecKey, passphrase)
+	if err != nil {
+		return err
+	}
+	var sk cipher.SecKey
+	if err := sk.UnmarshalBinary(data); err != nil {
+		return fmt.Errorf("invalid sealed secret key: %v", err)
+	}
+	if pk, err := sk.PubKey(); err != nil || pk != c.Node.StaticPubKey {
+		return errors.New("sealed secret key does not match the public key")
+	}
+	c.Node.StaticSecKey = sk
+	return nil
+}
+
+// UnsealFromEnv unseals the secret key of the config with the passphrase of the environment variable
+// KeyPassphraseEnv, or with the passphrase stored in the OS keyring for the public key of the config.
+// ErrKeySealed is returned if neither is available.
+func (c *Config) UnsealFromEnv(lookup func(string) (string, bool)) error {
+	if passphrase, ok := lookup(KeyPassphraseEnv); ok {
+		return c.Unseal([]byte(passphrase))
+	}
+	if passphrase, err := keyringPassphrase(c.Node.StaticPubKey); err == nil {
+		return c.Unseal(passphrase)
+	}
+	return ErrKeySealed
+}
+
+// SealKey replaces the secret key of the node in its config file with the key sealed with the passphrase, or with
+// a random passphrase stored in the OS keyring. The config file is updated atomically.
+func (node *Node) SealKey(in SealKeyIn) error {
+	node.reloadMu.Lock()
+	defer node.reloadMu.Unlock()
+
+	path := node.conf.Path()
+	if path == "" {
+		return ErrNoConfigPath
+	}
+	pk := node.conf.Node.StaticPubKey
+	passphrase := []byte(in.Passphrase)
+	if in.Keyring {
+		if len(passphrase) > 0 {
+			return errors.New("a passphrase may not be given when sealing with the keyring")
+		}
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return err
+		}
+		passphrase = []byte(hex.EncodeToString(random))
+	}
+	sealed, err := seal.Seal(node.conf.Node.StaticSecKey[:], passphrase)
+	if err != nil {
+		return err
+	}
+	if in.Keyring {
+		if err := storeKeyringPassphrase(pk, passphrase); err != nil {
+			return fmt.Errorf("failed to store passphrase in keyring: %v", err)
+		}
+	}
+
+	err = node.editConfigFile(path, func(obj map[string]interface{}) error {
+		keys, _ := obj["node"].(map[string]interface{}) //nolint:errcheck
+		if keys == nil || keys["static_public_key"] != pk.Hex() {
+			return fmt.Errorf("keys of %s differ from those of the running node", path)
+		}
+		delete(keys, "static_secret_key")
+		keys["sealed_secret_key"] = sealed
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	node.conf.Node.SealedSecKey = sealed
+	node.logger.Infof("Sealed secret key of %s", pk)
+	node.RecordEvent(EventConfigChanged, path, "sealed secret key of %s", pk)
+	return nil
+}
+
+// unsealRPC serves Unseal calls until the sealed secret keys of the configs are unsealed.
+type unsealRPC struct {
+	confs []*Config
+	done  chan struct{}
+	mx    sync.Mutex
+}
+
+// Authenticate accepts any token, as the passphrase authenticates Unseal calls.
+func (r *unsealRPC) Authenticate(_ *string, _ *struct{}) error {
+	return nil
+}
+
+// Unseal unseals the secret keys of the configs which are sealed with the passphrase.
+func (r *unsealRPC) Unseal(passphrase *string, _ *struct{}) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	unsealed, sealed := false, false
+	for _, c := range r.confs {
+		if !c.Sealed() {
+			continue
+		}
+		if err := c.Unseal([]byte(*passphrase)); err != nil {
+			sealed = true
+			continue
+		}
+		unsealed = true
+	}
+	if !sealed {
+		select {
+		case <-r.done:
+		default:
+			close(r.done)
+		}
+	}
+	if !unsealed {
+		return seal.ErrWrongPassphrase
+	}
+	return nil
+}
+
+// ServeUnseal serves the Unseal RPC on the listener until the sealed secret keys of the configs are unsealed, so
+// that visors started without access to their passphrases may be unsealed remotely (see RPCClient.Unseal).
+// The listener is closed before returning.
+func ServeUnseal(l net.Listener, confs ...*Config) error {
+	r := &unsealRPC{confs: confs, done: make(chan struct{})}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(RPCPrefix, r); err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			go srv.ServeConn(conn)
+		}
+	}()
+
+	select {
+	case <-r.done:
+		return l.Close()
+	case err := <-errCh:
+		return err
+	}
+}
@@ -0,0 +1,135 @@
+package visor
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/seal"
+)
+
+func TestNodeSealKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_seal")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "skywire-config.json")
+
+	initial := &Config{Version: ConfigVersion}
+	initial.Node.StaticPubKey, initial.Node.StaticSecKey = cipher.GenerateKeyPair()
+	initial.Profiles = map[string]json.RawMessage{"test": json.RawMessage(`{"log_level":"debug"}`)}
+	data, err := json.Marshal(initial)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	conf, err := ReadConfig(path)
+	require.NoError(t, err)
+	node := &Node{conf: conf, logger: logging.MustGetLogger("test")}
+	require.NoError(t, node.SealKey(SealKeyIn{Passphrase: "passphrase"}))
+
+	data, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), initial.Node.StaticSecKey.Hex())
+
+	sealed, err := ReadConfig(path)
+	require.NoError(t, err)
+	assert.True(t, sealed.Sealed())
+	require.NoError(t, sealed.ApplyProfile("test"))
+	assert.True(t, sealed.Sealed())
+	_, err = NewNode(sealed, logging.NewMasterLogger())
+	assert.Equal(t, ErrKeySealed, err)
+
+	lookup := func(passphrase string) func(string) (string, bool) {
+		return func(key string) (string, bool) {
+			return passphrase, key == KeyPassphraseEnv
+		}
+	}
+	assert.Equal(t, seal.ErrWrongPassphrase, sealed.UnsealFromEnv(lookup("wrong")))
+	require.NoError(t, sealed.UnsealFromEnv(lookup("passphrase")))
+	assert.Equal(t, initial.Node.StaticSecKey, sealed.Node.StaticSecKey)
+	assert.False(t, sealed.Sealed())
+	assert.Equal(t, ErrKeyNotSealed, sealed.Unseal([]byte("passphrase")))
+
+	node.conf = sealed
+	_, err = node.RotateKeys(0)
+	assert.Equal(t, ErrRotateSealedKey, err)
+}
+
+func TestNodeSealKeyKeyring(t *testing.T) {
+	stored := make(map[string][]byte)
+	orig := runKeyring
+	runKeyring = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		account := args[len(args)-1]
+		switch {
+		case name == "secret-tool" && args[0] == "store":
+			stored[account] = stdin
+			return nil, nil
+		case name == "secret-tool" && args[0] == "lookup":
+			return append(stored[account], '\n'), nil
+		default:
+			return nil, errors.New("unsupported")
+		}
+	}
+	defer func() { runKeyring = orig }()
+
+	conf := &Config{}
+	conf.Node.StaticPubKey, conf.Node.StaticSecKey = cipher.GenerateKeyPair()
+	sk := conf.Node.StaticSecKey
+	if _, err := keyringPassphrase(conf.Node.StaticPubKey); err == ErrKeyringUnsupported {
+		t.Skip(err)
+	}
+
+	dir, err := ioutil.TempDir("", "skywire_seal")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	conf.path = filepath.Join(dir, "skywire-config.json")
+	data, err := json.Marshal(conf)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(conf.path, data, 0600))
+
+	node := &Node{conf: conf, logger: logging.MustGetLogger("test")}
+	require.NoError(t, node.SealKey(SealKeyIn{Keyring: true}))
+
+	sealed, err := ReadConfig(conf.path)
+	require.NoError(t, err)
+	require.NoError(t, sealed.UnsealFromEnv(func(string) (string, bool) { return "", false }))
+	assert.Equal(t, sk, sealed.Node.StaticSecKey)
+}
+
+func TestServeUnseal(t *testing.T) {
+	var confs []*Config
+	for i := 0; i < 2; i++ {
+		conf := new(Config)
+		pk, sk := cipher.GenerateKeyPair()
+		sealed, err := seal.Seal(sk[:], []byte("passphrase"))
+		require.NoError(t, err)
+		conf.Node.StaticPubKey, conf.Node.SealedSecKey = pk, sealed
+		confs = append(confs, conf)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	errCh := make(chan error, 1)
+	go func() { errCh <- ServeUnseal(l, confs...) }()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	client := NewRPCClient(rpc.NewClient(conn), RPCPrefix)
+	require.NoError(t, client.Authenticate("token"))
+	assert.EqualError(t, client.Unseal("wrong"), seal.ErrWrongPassphrase.Error())
+	require.NoError(t, client.Unseal("passphrase"))
+	require.NoError(t, <-errCh)
+
+	for _, conf := range confs {
+		assert.False(t, conf.Sealed())
+	}
+}
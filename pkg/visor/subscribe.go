@@ -0,0 +1,261 @@
+package visor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+// Kinds of events published to subscribers.
+const (
+	EventKindTransport = "transport"
+	EventKindRoute     = "route"
+	EventKindApp       = "app"
+	EventKindHealth    = "health"
+	EventKindNode      = "node" // config changes and auth failures.
+)
+
+// Types of events published to subscribers, which are not recorded in the event log.
+const (
+	EventRouteCreated       EventType = "route_created"
+	EventRouteClosed        EventType = "route_closed"
+	EventNetworkAvailable   EventType = "network_available"
+	EventNetworkUnavailable EventType = "network_unavailable"
+	EventUptimeFailed       EventType = "uptime_heartbeat_failed"
+)
+
+const (
+	// SubscribeBufferSize is the number of recent events retained for subscribers which fall behind.
+	SubscribeBufferSize = 256
+	// DefaultSubscribeWait is the time Subscribe waits for events if no wait is requested.
+	DefaultSubscribeWait = 10 * time.Second
+	// MaxSubscribeWait bounds the time Subscribe waits for events.
+	MaxSubscribeWait = time.Minute
+)
+
+// StreamEvent is an event published to subscribers.
+type StreamEvent struct {
+	Seq  uint64 `json:"seq"` // increases by one with each published event.
+	Kind string `json:"kind"`
+	Event
+}
+
+// SubscribeIn is input of Subscribe.
+type SubscribeIn struct {
+	After uint64        `json:"after"`           // Seq of the last received event, 0 to only receive upcoming events.
+	Kinds []string      `json:"kinds,omitempty"` // all kinds if empty.
+	Wait  time.Duration `json:"wait,omitempty"`  // defaults to DefaultSubscribeWait.
+}
+
+// SubscribeOut is output of Subscribe.
+type SubscribeOut struct {
+	Events []StreamEvent `json:"events"`
+	Next   uint64        `json:"next"`   // After of the next call.
+	Missed uint64        `json:"missed"` // number of events dropped from the buffer before they were received.
+}
+
+// eventStream retains the recent events published to subscribers, which poll it for events after a sequence number.
+type eventStream struct {
+	events []StreamEvent // oldest first.
+	seq    uint64        // Seq of the last published event.
+	notify chan struct{} // closed and replaced on publish.
+	mx     sync.Mutex
+}
+
+func newEventStream() *eventStream {
+	return &eventStream{notify: make(chan struct{})}
+}
+
+func (s *eventStream) publish(kind string, e Event) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.seq++
+	s.events = append(s.events, StreamEvent{Seq: s.seq, Kind: kind, Event: e})
+	if len(s.events) > SubscribeBufferSize {
+		s.events = append(s.events[:0], s.events[len(s.events)-SubscribeBufferSize:]...)
+	}
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// read returns the retained events after seq of the kinds, the Seq of the last published event, the number of
+// events dropped after seq, and a channel which is closed on the next publish.
+func (s *eventStream) read(after uint64, kinds []string) ([]StreamEvent, uint64, uint64, <-chan struct{}) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	out := make([]StreamEvent, 0)
+	var missed uint64
+	if len(s.events) > 0 && s.events[0].Seq > after+1 {
+		missed = s.events[0].Seq - after - 1
+	}
+	for _, e := range s.events {
+		if e.Seq > after && matchKind(kinds, e.Kind) {
+			out = append(out, e)
+		}
+	}
+	return out, s.seq, missed, s.notify
+}
+
+// wait returns the events of the kinds published after seq, waiting until any are published or ctx is done.
+// If seq is 0, only events published after the call are returned.
+func (s *eventStream) wait(ctx context.Context, in SubscribeIn) *SubscribeOut {
+	after := in.After
+	if after == 0 {
+		s.mx.Lock()
+		after = s.seq
+		s.mx.Unlock()
+	}
+
+	out := &SubscribeOut{Next: after}
+	for {
+		events, seq, missed, notify := s.read(after, in.Kinds)
+		out.Missed += missed
+		out.Next = seq
+		if len(events) > 0 {
+			out.Events = events
+			return out
+		}
+		after = seq
+		select {
+		case <-ctx.Done():
+			out.Events = events
+			return out
+		case <-notify:
+		}
+	}
+}
+
+func matchKind(kinds []string, kind string) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// eventKind returns the kind of events of the event log.
+func eventKind(t EventType) string {
+	switch t {
+	case EventAppStarted, EventAppStopped, EventAppCrashed:
+		return EventKindApp
+	case EventTransportAdded, EventTransportRemoved:
+		return EventKindTransport
+	case EventRouteFailed, EventRouteCreated, EventRouteClosed:
+		return EventKindRoute
+	case EventNetworkAvailable, EventNetworkUnavailable, EventUptimeFailed:
+		return EventKindHealth
+	default:
+		return EventKindNode
+	}
+}
+
+// publishEvent publishes an event to subscribers of the node.
+func (node *Node) publishEvent(e Event) {
+	if node.stream == nil {
+		return
+	}
+	node.stream.publish(eventKind(e.Type), e)
+}
+
+// Subscribe returns the events published after in.After, waiting up to in.Wait for any to be published.
+func (node *Node) Subscribe(ctx context.Context, in SubscribeIn) (*SubscribeOut, error) {
+	if node.stream == nil {
+		return nil, ErrNotImplemented
+	}
+	return node.stream.subscribe(ctx, in)
+}
+
+// subscribe validates the input of Subscribe, and waits for the events.
+func (s *eventStream) subscribe(ctx context.Context, in SubscribeIn) (*SubscribeOut, error) {
+	if in.Wait <= 0 {
+		in.Wait = DefaultSubscribeWait
+	}
+	if in.Wait > MaxSubscribeWait {
+		in.Wait = MaxSubscribeWait
+	}
+	for _, k := range in.Kinds {
+		switch k {
+		case EventKindTransport, EventKindRoute, EventKindApp, EventKindHealth, EventKindNode:
+		default:
+			return nil, fmt.Errorf("invalid event kind '%s'", k)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, in.Wait)
+	defer cancel()
+	return s.wait(ctx, in), nil
+}
+
+// publishTransportEvent publishes changes in the state of transports which are not recorded in the event log.
+func (node *Node) publishTransportEvent(e transport.Event) {
+	switch e.Type {
+	case transport.EventEstablished, transport.EventClosed:
+		return // published by recordTransportEvent.
+	}
+	node.publishEvent(Event{
+		Time:    time.Now(),
+		Type:    EventType("transport_" + string(e.Type)),
+		Subject: e.TpID.String(),
+		Message: fmt.Sprintf("%s transport to %s: %s", e.TpType, e.RemotePK, e.Reason),
+	})
+}
+
+// publishRouteEvent publishes the creation and closure of loops.
+func (node *Node) publishRouteEvent(e router.Event) {
+	t := EventRouteCreated
+	switch e.Type {
+	case router.EventLoopCreated:
+	case router.EventLoopClosed:
+		t = EventRouteClosed
+	default:
+		return
+	}
+	node.publishEvent(Event{Time: e.Time, Type: t, Subject: e.Loop.Remote.String(), Message: "loop from " + e.Loop.Local.String()})
+}
+
+// publishNetworkEvent publishes changes in the availability of networks.
+func (node *Node) publishNetworkEvent(e snet.NetworkEvent) {
+	if e.Available {
+		node.publishEvent(Event{Time: time.Now(), Type: EventNetworkAvailable, Subject: e.Network})
+		return
+	}
+	node.publishEvent(Event{Time: time.Now(), Type: EventNetworkUnavailable, Subject: e.Network, Message: e.Reason})
+}
+
+// Watch polls Subscribe of the RPC client, sending the events of the kinds to the returned channel until ctx is done
+// or a call fails. The channel is closed afterwards, and the error of the failed call is sent to errCh if not nil.
+func Watch(ctx context.Context, rc RPCClient, errCh chan<- error, kinds ...string) <-chan StreamEvent {
+	ch := make(chan StreamEvent)
+	go func() {
+		defer close(ch)
+		var after uint64
+		for ctx.Err() == nil {
+			out, err := rc.Subscribe(SubscribeIn{After: after, Kinds: kinds})
+			if err != nil {
+				if errCh != nil {
+					errCh <- err
+				}
+				return
+			}
+			after = out.Next
+			for _, e := range out.Events {
+				select {
+				case ch <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,47 @@
+package visor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeSubscribe(t *testing.T) {
+	node := &Node{stream: newEventStream(), logger: logging.MustGetLogger("test")}
+	node.RecordEvent(EventConfigChanged, "", "before subscribing")
+
+	// Only events published after the first call are received.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		node.RecordEvent(EventAppStarted, "foo", "v1.0 with pid 1")
+	}()
+	out, err := node.Subscribe(context.Background(), SubscribeIn{Kinds: []string{EventKindApp}, Wait: time.Second})
+	require.NoError(t, err)
+	require.Len(t, out.Events, 1)
+	assert.Equal(t, EventKindApp, out.Events[0].Kind)
+	assert.Equal(t, EventAppStarted, out.Events[0].Type)
+	assert.Equal(t, uint64(2), out.Next)
+
+	// Events of other kinds are skipped, and the call returns when the wait elapses.
+	node.RecordEvent(EventConfigChanged, "", "reloaded")
+	out, err = node.Subscribe(context.Background(), SubscribeIn{After: out.Next, Kinds: []string{EventKindApp}, Wait: 50 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Empty(t, out.Events)
+	assert.Equal(t, uint64(3), out.Next)
+
+	// Events dropped from the buffer are reported.
+	for i := 0; i < SubscribeBufferSize+2; i++ {
+		node.RecordEvent(EventAppStopped, "foo", "")
+	}
+	out, err = node.Subscribe(context.Background(), SubscribeIn{After: out.Next})
+	require.NoError(t, err)
+	assert.Len(t, out.Events, SubscribeBufferSize)
+	assert.Equal(t, uint64(2), out.Missed)
+
+	_, err = node.Subscribe(context.Background(), SubscribeIn{Kinds: []string{"foo"}})
+	assert.Error(t, err)
+}
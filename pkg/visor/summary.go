@@ -0,0 +1,93 @@
+package visor
+
+import (
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
+)
+
+// summaryErrors is the number of recent errors included in the Summary.
+const summaryErrors = 10
+
+// errorEvents are the types of events reported as errors in the Summary.
+var errorEvents = []EventType{EventAppCrashed, EventRouteFailed, EventAuthFailed}
+
+// STCPStatus is the state of the stcp listener.
+type STCPStatus struct {
+	Listening    bool   `json:"listening"`
+	LocalAddr    string `json:"local_address,omitempty"`
+	ExternalAddr string `json:"external_address,omitempty"` // obtained by port mapping.
+	Sessions     int    `json:"mux_sessions"`               // number of multiplexed sessions.
+	Error        string `json:"error,omitempty"`            // why stcp is not listening.
+}
+
+func newSTCPStatus(c *stcp.Client, localAddr string) *STCPStatus {
+	s := &STCPStatus{LocalAddr: localAddr, ExternalAddr: c.ExternalAddr(), Sessions: c.Sessions()}
+	if err := c.ServeErr(); err != nil {
+		s.Error = err.Error()
+	} else {
+		s.Listening = true
+	}
+	return s
+}
+
+// AppHealth summarizes the recent lifecycle of an app, according to the event log.
+type AppHealth struct {
+	Name      string `json:"name"`
+	Running   bool   `json:"running"`
+	Starts    int    `json:"starts"`
+	Crashes   int    `json:"crashes"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// appHealth returns the health of the configured apps.
+func (node *Node) appHealth() []*AppHealth {
+	byName := make(map[string]*AppHealth)
+	var out []*AppHealth
+	for _, ac := range node.appConfigs() {
+		h := &AppHealth{Name: ac.App, Running: node.isAppRunning(ac.App)}
+		byName[ac.App] = h
+		out = append(out, h)
+	}
+	for _, e := range node.Events(EventFilter{Types: []EventType{EventAppStarted, EventAppCrashed}}) {
+		h, ok := byName[e.Subject]
+		if !ok {
+			continue
+		}
+		switch e.Type {
+		case EventAppStarted:
+			h.Starts++
+		case EventAppCrashed:
+			h.Crashes++
+			h.LastError = e.Message
+		}
+	}
+	return out
+}
+
+// recentErrors returns the most recent error events, oldest first.
+func (node *Node) recentErrors() []Event {
+	events := node.Events(EventFilter{Types: errorEvents})
+	if len(events) > summaryErrors {
+		events = events[len(events)-summaryErrors:]
+	}
+	return events
+}
+
+// routesByType counts the rules of the routing table by type.
+func routesByType(rt routing.Table) map[string]int {
+	out := make(map[string]int)
+	if err := rt.RangeRules(func(_ routing.RouteID, rule routing.Rule) bool {
+		out[rule.Type().String()]++
+		return true
+	}); err != nil {
+		return nil
+	}
+	return out
+}
+
+// setupNodeReporter is implemented by routers which report the setup node in use, such as router.Router.
+type setupNodeReporter interface {
+	SetupNode() (cipher.PubKey, bool)
+}
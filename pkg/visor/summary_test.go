@@ -0,0 +1,37 @@
+package visor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeAppHealth(t *testing.T) {
+	events, err := newEventLog("", 0)
+	require.NoError(t, err)
+	node := &Node{appsConf: []AppConfig{{App: "foo"}, {App: "bar"}}, startedApps: map[string]*appBind{"bar": {}},
+		events: events}
+
+	node.RecordEvent(EventAppStarted, "foo", "")
+	node.RecordEvent(EventAppCrashed, "foo", "exit status 1")
+	node.RecordEvent(EventAppStarted, "foo", "")
+	node.RecordEvent(EventAppStarted, "bar", "")
+	node.RecordEvent(EventTransportAdded, "tp", "")
+	for i := 0; i < summaryErrors; i++ {
+		node.RecordEvent(EventAuthFailed, "127.0.0.1", "")
+	}
+
+	assert.Equal(t, []*AppHealth{
+		{Name: "foo", Starts: 2, Crashes: 1, LastError: "exit status 1"},
+		{Name: "bar", Running: true, Starts: 1},
+	}, node.appHealth())
+
+	errs := node.recentErrors()
+	require.Len(t, errs, summaryErrors)
+	for _, e := range errs {
+		assert.Equal(t, EventAuthFailed, e.Type)
+		assert.False(t, e.Time.After(time.Now()))
+	}
+}
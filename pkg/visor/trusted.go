@@ -0,0 +1,70 @@
+package visor
+
+import (
+	"sort"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// TrustedVisors returns the sorted public keys of the trusted visors of the node (see Config.TrustedVisors).
+func (node *Node) TrustedVisors() []cipher.PubKey {
+	node.reloadMu.Lock()
+	defer node.reloadMu.Unlock()
+
+	pks := append([]cipher.PubKey{}, node.conf.TrustedVisors...)
+	sort.Slice(pks, func(i, j int) bool { return pks[i].Hex() < pks[j].Hex() })
+	return pks
+}
+
+// AddTrustedVisors trusts the visors of the public keys.
+func (node *Node) AddTrustedVisors(pks ...cipher.PubKey) error {
+	return node.editTrustedVisors(func(trusted map[cipher.PubKey]bool) {
+		for _, pk := range pks {
+			trusted[pk] = true
+		}
+	}, "trusted %v", pks)
+}
+
+// RemoveTrustedVisors no longer trusts the visors of the public keys.
+func (node *Node) RemoveTrustedVisors(pks ...cipher.PubKey) error {
+	return node.editTrustedVisors(func(trusted map[cipher.PubKey]bool) {
+		for _, pk := range pks {
+			delete(trusted, pk)
+		}
+	}, "removed %v from trusted visors", pks)
+}
+
+// editTrustedVisors edits the trusted visors, applying them at runtime and saving them to the config file of the
+// node, if any.
+func (node *Node) editTrustedVisors(edit func(map[cipher.PubKey]bool), format string, args ...interface{}) error {
+	node.reloadMu.Lock()
+	defer node.reloadMu.Unlock()
+
+	trusted := make(map[cipher.PubKey]bool, len(node.conf.TrustedVisors))
+	for _, pk := range node.conf.TrustedVisors {
+		trusted[pk] = true
+	}
+	edit(trusted)
+	pks := make([]cipher.PubKey, 0, len(trusted))
+	for pk := range trusted {
+		pks = append(pks, pk)
+	}
+	sort.Slice(pks, func(i, j int) bool { return pks[i].Hex() < pks[j].Hex() })
+
+	path := node.conf.Path()
+	if path != "" {
+		err := node.editConfigFile(path, func(obj map[string]interface{}) error {
+			obj["trusted_visors"] = pks
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if node.tm != nil {
+		node.tm.SetTrustedVisors(pks)
+	}
+	node.conf.TrustedVisors = pks
+	node.RecordEvent(EventConfigChanged, "trusted_visors", format, args...)
+	return nil
+}
@@ -0,0 +1,55 @@
+package visor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeTrustedVisors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_trusted")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "skywire-config.json")
+
+	initial := &Config{Version: ConfigVersion}
+	initial.Node.StaticPubKey, initial.Node.StaticSecKey = cipher.GenerateKeyPair()
+	data, err := json.Marshal(initial)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	conf, err := ReadConfig(path)
+	require.NoError(t, err)
+	node := &Node{conf: conf, logger: logging.MustGetLogger("test")}
+	assert.Empty(t, node.TrustedVisors())
+
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+	want := []cipher.PubKey{pk1, pk2}
+	sort.Slice(want, func(i, j int) bool { return want[i].Hex() < want[j].Hex() })
+
+	require.NoError(t, node.AddTrustedVisors(pk1, pk2, pk1))
+	assert.Equal(t, want, node.TrustedVisors())
+	saved, err := ReadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, saved.TrustedVisors)
+
+	require.NoError(t, node.RemoveTrustedVisors(pk1))
+	assert.Equal(t, []cipher.PubKey{pk2}, node.TrustedVisors())
+	saved, err = ReadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []cipher.PubKey{pk2}, saved.TrustedVisors)
+
+	// The saved list is unchanged by a reload.
+	res, err := node.Reload()
+	require.NoError(t, err)
+	assert.NotContains(t, res.Applied, "trusted_visors")
+}
@@ -0,0 +1,435 @@
+package visor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// Bounds of the size of downloaded release manifests and binaries.
+const (
+	maxReleaseManifestSize = 1 << 20
+	maxReleaseBinarySize   = 512 << 20
+)
+
+// updateTimeout bounds the download of the release manifest and binary.
+const updateTimeout = 30 * time.Minute
+
+// updateStatusFile is the file of the local path storing the status of the last update, so that the result of
+// updates is reported after the restart into the new version.
+const updateStatusFile = "update.json"
+
+var (
+	// ErrUpdatesNotConfigured occurs when updating a node without an update config.
+	ErrUpdatesNotConfigured = errors.New("updates are not configured: set 'update' in the config of the visor")
+
+	// ErrUpdateInProgress occurs when updating a node which is already updating.
+	ErrUpdateInProgress = errors.New("an update is already in progress")
+)
+
+// UpdaterConfig configures updates of the visor from releases published at URL. Release manifests must be signed by
+// the key of PublicKey, with the hex-encoded signature published at the URL with '.sig' appended.
+type UpdaterConfig struct {
+	URL       string        `json:"url"`
+	PublicKey cipher.PubKey `json:"public_key"`
+}
+
+// Release is a release of the visor, as published in release manifests.
+type Release struct {
+	Version  string                   `json:"version"`
+	Binaries map[string]ReleaseBinary `json:"binaries"` // by "<GOOS>/<GOARCH>", such as "linux/arm64".
+}
+
+// ReleaseBinary is the visor binary of a release for a platform.
+type ReleaseBinary struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"` // hex-encoded.
+}
+
+// UpdateState is the state of an update of the node.
+type UpdateState string
+
+// States of updates.
+const (
+	UpdateIdle            UpdateState = "idle"
+	UpdateChecking        UpdateState = "checking"
+	UpdateDownloading     UpdateState = "downloading"
+	UpdateVerifying       UpdateState = "verifying"
+	UpdateApplying        UpdateState = "applying"
+	UpdateRestarting      UpdateState = "restarting"
+	UpdateUpToDate        UpdateState = "up_to_date"
+	UpdateAvailable       UpdateState = "available"        // found by a check, but not applied.
+	UpdateRestartRequired UpdateState = "restart_required" // applied, but the visor does not restart itself.
+	UpdateUpdated         UpdateState = "updated"          // applied, and running the new version.
+	UpdateFailed          UpdateState = "failed"
+)
+
+// Running returns whether the update is in progress.
+func (s UpdateState) Running() bool {
+	switch s {
+	case UpdateChecking, UpdateDownloading, UpdateVerifying, UpdateApplying:
+		return true
+	default:
+		return false
+	}
+}
+
+// UpdateStatus reports the progress and result of the last update of the node.
+type UpdateStatus struct {
+	State          UpdateState `json:"state"`
+	CurrentVersion string      `json:"current_version"`
+	LatestVersion  string      `json:"latest_version,omitempty"`
+	Downloaded     int64       `json:"downloaded_bytes,omitempty"`
+	Size           int64       `json:"size_bytes,omitempty"` // of the binary, -1 if unknown.
+	Error          string      `json:"error,omitempty"`
+	StartedAt      time.Time   `json:"started_at,omitempty"`
+	FinishedAt     time.Time   `json:"finished_at,omitempty"`
+}
+
+// UpdateIn is input for Update.
+type UpdateIn struct {
+	CheckOnly bool `json:"check_only"` // only checks for a newer release.
+}
+
+// updater updates the binary of the visor to the latest release, and restarts the visor into it.
+type updater struct {
+	conf       UpdaterConfig
+	current    string       // running version.
+	exe        string       // binary replaced by updates.
+	statusFile string       // stores the status across restarts, may be empty.
+	client     *http.Client // downloads releases.
+	restart    func() error
+
+	status UpdateStatus
+	mu     sync.Mutex
+}
+
+func newUpdater(conf UpdaterConfig, current, exe, statusFile string, restart func() error) *updater {
+	u := &updater{
+		conf:       conf,
+		current:    current,
+		exe:        exe,
+		statusFile: statusFile,
+		client:     &http.Client{Timeout: updateTimeout},
+		restart:    restart,
+		status:     UpdateStatus{State: UpdateIdle, CurrentVersion: current},
+	}
+	if statusFile == "" {
+		return u
+	}
+	data, err := ioutil.ReadFile(filepath.Clean(statusFile))
+	if err != nil {
+		return u
+	}
+	var last UpdateStatus
+	if err := json.Unmarshal(data, &last); err != nil {
+		return u
+	}
+	// The update is completed by the restart into the new version.
+	if last.State == UpdateRestarting || last.State == UpdateRestartRequired {
+		if last.LatestVersion == current {
+			last.State = UpdateUpdated
+		} else {
+			last.State = UpdateFailed
+			last.Error = fmt.Sprintf("still running version %s after restart", current)
+		}
+		last.FinishedAt = time.Now()
+	}
+	last.CurrentVersion = current
+	u.status = last
+	u.saveStatus(last)
+	return u
+}
+
+// Status returns the status of the last update.
+func (u *updater) Status() UpdateStatus {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.status
+}
+
+// Start starts an update, which continues in the background, and returns its initial status.
+func (u *updater) Start(in UpdateIn) (UpdateStatus, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.status.State.Running() {
+		return u.status, ErrUpdateInProgress
+	}
+	u.status = UpdateStatus{State: UpdateChecking, CurrentVersion: u.current, StartedAt: time.Now()}
+	go u.run(in)
+	return u.status, nil
+}
+
+func (u *updater) run(in UpdateIn) {
+	state, err := u.update(in)
+	u.mu.Lock()
+	u.status.State, u.status.FinishedAt = state, time.Now()
+	if err != nil {
+		u.status.State, u.status.Error = UpdateFailed, err.Error()
+	}
+	status := u.status
+	u.mu.Unlock()
+	u.saveStatus(status)
+
+	if state == UpdateRestarting && err == nil {
+		if err := u.restart(); err != nil {
+			u.mu.Lock()
+			u.status.State, u.status.Error = UpdateRestartRequired, err.Error()
+			status = u.status
+			u.mu.Unlock()
+			u.saveStatus(status)
+		}
+	}
+}
+
+// update applies the latest release if newer than the running version, and returns the resulting state.
+func (u *updater) update(in UpdateIn) (UpdateState, error) {
+	release, err := u.fetchRelease()
+	if err != nil {
+		return "", err
+	}
+	u.setStatus(func(s *UpdateStatus) { s.LatestVersion = release.Version })
+	newer, err := newerVersion(release.Version, u.current)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case !newer:
+		return UpdateUpToDate, nil
+	case in.CheckOnly:
+		return UpdateAvailable, nil
+	}
+
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	bin, ok := release.Binaries[platform]
+	if !ok {
+		return "", fmt.Errorf("release %s has no binary for %s", release.Version, platform)
+	}
+	want, err := hex.DecodeString(bin.SHA256)
+	if err != nil || len(want) != sha256.Size {
+		return "", fmt.Errorf("release %s has an invalid checksum for %s", release.Version, platform)
+	}
+
+	tmp := u.exe + ".update"
+	defer func() { _ = os.Remove(tmp) }() //nolint:errcheck
+	sum, err := u.download(bin.URL, tmp)
+	if err != nil {
+		return "", fmt.Errorf("failed to download binary: %v", err)
+	}
+	u.setStatus(func(s *UpdateStatus) { s.State = UpdateVerifying })
+	if !bytes.Equal(sum, want) {
+		return "", errors.New("checksum of the downloaded binary does not match the release")
+	}
+
+	u.setStatus(func(s *UpdateStatus) { s.State = UpdateApplying })
+	if err := replaceBinary(u.exe, tmp); err != nil {
+		return "", fmt.Errorf("failed to replace binary: %v", err)
+	}
+	return UpdateRestarting, nil
+}
+
+// fetchRelease fetches the release manifest, and verifies its signature.
+func (u *updater) fetchRelease() (*Release, error) {
+	manifest, err := u.get(u.conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release manifest: %v", err)
+	}
+	rawSig, err := u.get(u.conf.URL + ".sig")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signature of release manifest: %v", err)
+	}
+	var sig cipher.Sig
+	if err := sig.UnmarshalText([]byte(strings.TrimSpace(string(rawSig)))); err != nil {
+		return nil, fmt.Errorf("invalid signature of release manifest: %v", err)
+	}
+	if err := cipher.VerifyPubKeySignedPayload(u.conf.PublicKey, sig, manifest); err != nil {
+		return nil, fmt.Errorf("release manifest is not signed by %s: %v", u.conf.PublicKey, err)
+	}
+	var release Release
+	if err := json.Unmarshal(manifest, &release); err != nil {
+		return nil, fmt.Errorf("invalid release manifest: %v", err)
+	}
+	if release.Version == "" {
+		return nil, errors.New("invalid release manifest: missing version")
+	}
+	return &release, nil
+}
+
+func (u *updater) get(url string) ([]byte, error) {
+	resp, err := u.client.Get(url) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with status %d", url, resp.StatusCode)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxReleaseManifestSize))
+}
+
+// download downloads the binary at url to path, reporting progress, and returns its SHA-256 checksum.
+func (u *updater) download(url, path string) ([]byte, error) {
+	resp, err := u.client.Get(url) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with status %d", url, resp.StatusCode)
+	}
+	if resp.ContentLength > maxReleaseBinarySize {
+		return nil, fmt.Errorf("binary exceeds %d bytes", maxReleaseBinarySize)
+	}
+	u.setStatus(func(s *UpdateStatus) { s.State, s.Size = UpdateDownloading, resp.ContentLength })
+
+	f, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	w := &progressWriter{w: io.MultiWriter(f, h), progress: func(n int64) {
+		u.setStatus(func(s *UpdateStatus) { s.Downloaded = n })
+	}}
+	n, err := io.Copy(w, io.LimitReader(resp.Body, maxReleaseBinarySize+1))
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil && n > maxReleaseBinarySize {
+		err = fmt.Errorf("binary exceeds %d bytes", maxReleaseBinarySize)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func (u *updater) setStatus(update func(s *UpdateStatus)) {
+	u.mu.Lock()
+	update(&u.status)
+	u.mu.Unlock()
+}
+
+func (u *updater) saveStatus(status UpdateStatus) {
+	if u.statusFile == "" {
+		return
+	}
+	data, err := json.Marshal(status)
+	if err == nil {
+		err = writeFileAtomic(u.statusFile, data, 0600)
+	}
+	if err != nil {
+		log.WithError(err).Warn("Failed to save update status")
+	}
+}
+
+// progressWriter reports the number of bytes written.
+type progressWriter struct {
+	w        io.Writer
+	n        int64
+	progress func(n int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	w.progress(w.n)
+	return n, err
+}
+
+// replaceBinary replaces the binary at path with the binary at tmp. The replaced binary is kept with '.old' appended,
+// as running binaries may not be removed on some platforms.
+func replaceBinary(path, tmp string) error {
+	old := path + ".old"
+	_ = os.Remove(old) //nolint:errcheck
+	if err := os.Rename(path, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Rename(old, path) //nolint:errcheck
+		return err
+	}
+	return nil
+}
+
+// newerVersion returns whether the version v is newer than current. Versions are dot-separated numbers, optionally
+// prefixed by 'v' and suffixed by a pre-release, such as "v0.2.1-rc1", which precedes the release.
+func newerVersion(v, current string) (bool, error) {
+	parse := func(v string) ([]int, string, error) {
+		v = strings.TrimPrefix(v, "v")
+		pre := ""
+		if i := strings.IndexByte(v, '-'); i >= 0 {
+			v, pre = v[:i], v[i+1:]
+		}
+		var nums []int
+		for _, part := range strings.Split(v, ".") {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return nil, "", fmt.Errorf("invalid version '%s'", v)
+			}
+			nums = append(nums, n)
+		}
+		return nums, pre, nil
+	}
+	a, aPre, err := parse(v)
+	if err != nil {
+		return false, err
+	}
+	b, bPre, err := parse(current)
+	if err != nil {
+		return false, err
+	}
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return x > y, nil
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return false, nil
+	case aPre == "":
+		return true, nil
+	case bPre == "":
+		return false, nil
+	default:
+		return aPre > bPre, nil
+	}
+}
+
+// Update starts an update of the node to the latest release, which continues in the background. The node restarts
+// into the new version once applied.
+func (node *Node) Update(in UpdateIn) (UpdateStatus, error) {
+	if node.updater == nil {
+		return UpdateStatus{}, ErrUpdatesNotConfigured
+	}
+	return node.updater.Start(in)
+}
+
+// UpdateStatus returns the status of the last update of the node.
+func (node *Node) UpdateStatus() (UpdateStatus, error) {
+	if node.updater == nil {
+		return UpdateStatus{}, ErrUpdatesNotConfigured
+	}
+	return node.updater.Status(), nil
+}
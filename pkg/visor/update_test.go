@@ -0,0 +1,148 @@
+package visor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewerVersion(t *testing.T) {
+	cases := []struct {
+		v, current string
+		newer      bool
+	}{
+		{"0.0.2", "0.0.1", true},
+		{"v0.1.0", "0.0.9", true},
+		{"0.10.0", "0.9.0", true},
+		{"1.0", "1.0.0", false},
+		{"1.0.0", "1.0.0-rc1", true},
+		{"1.0.0-rc2", "1.0.0-rc1", true},
+		{"1.0.0-rc1", "1.0.0", false},
+		{"0.9.9", "1.0.0", false},
+	}
+	for _, tc := range cases {
+		newer, err := newerVersion(tc.v, tc.current)
+		require.NoError(t, err)
+		assert.Equal(t, tc.newer, newer, "%s > %s", tc.v, tc.current)
+	}
+	_, err := newerVersion("latest", "1.0.0")
+	assert.Error(t, err)
+}
+
+func TestUpdater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skywire_update")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	pk, sk := cipher.GenerateKeyPair()
+	binary := []byte("new visor binary")
+	sum := sha256.Sum256(binary)
+	checksum := hex.EncodeToString(sum[:])
+
+	var manifest []byte
+	var sig cipher.Sig
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release.json":
+			_, _ = w.Write(manifest) //nolint:errcheck
+		case "/release.json.sig":
+			_, _ = w.Write([]byte(sig.Hex() + "\n")) //nolint:errcheck
+		case "/visor":
+			_, _ = w.Write(binary) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	publish := func(version, checksum string) {
+		release := Release{Version: version, Binaries: map[string]ReleaseBinary{
+			runtime.GOOS + "/" + runtime.GOARCH: {URL: srv.URL + "/visor", SHA256: checksum},
+		}}
+		var err error
+		manifest, err = json.Marshal(release)
+		require.NoError(t, err)
+		sig, err = cipher.SignPayload(manifest, sk)
+		require.NoError(t, err)
+	}
+	exe := filepath.Join(dir, "skywire-visor")
+	require.NoError(t, ioutil.WriteFile(exe, []byte("old visor binary"), 0755))
+	statusFile := filepath.Join(dir, updateStatusFile)
+	restarts := make(chan struct{}, 1)
+	newTestUpdater := func() *updater {
+		return newUpdater(UpdaterConfig{URL: srv.URL + "/release.json", PublicKey: pk}, "0.1.0", exe, statusFile,
+			func() error {
+				restarts <- struct{}{}
+				return nil
+			})
+	}
+	u := newTestUpdater()
+	update := func(in UpdateIn) UpdateStatus {
+		_, err := u.Start(in)
+		require.NoError(t, err)
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+			if status := u.Status(); !status.State.Running() {
+				return status
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("update did not finish")
+		return UpdateStatus{}
+	}
+
+	publish("0.1.0", checksum)
+	assert.Equal(t, UpdateUpToDate, update(UpdateIn{}).State)
+
+	// Manifests which are not signed by the release key are rejected.
+	publish("0.2.0", checksum)
+	manifest = append(manifest[:len(manifest)-1], " }"...)
+	status := update(UpdateIn{})
+	assert.Equal(t, UpdateFailed, status.State)
+	assert.Contains(t, status.Error, "not signed by")
+
+	publish("0.2.0", hex.EncodeToString(make([]byte, sha256.Size)))
+	status = update(UpdateIn{})
+	assert.Equal(t, UpdateFailed, status.State)
+	assert.Contains(t, status.Error, "checksum")
+	data, err := ioutil.ReadFile(exe)
+	require.NoError(t, err)
+	assert.Equal(t, "old visor binary", string(data))
+
+	publish("0.2.0", checksum)
+	assert.Equal(t, UpdateAvailable, update(UpdateIn{CheckOnly: true}).State)
+
+	status = update(UpdateIn{})
+	assert.Equal(t, UpdateRestarting, status.State)
+	assert.Equal(t, "0.2.0", status.LatestVersion)
+	assert.Equal(t, int64(len(binary)), status.Downloaded)
+	<-restarts
+	data, err = ioutil.ReadFile(exe)
+	require.NoError(t, err)
+	assert.Equal(t, binary, data)
+
+	// The result is reported after the restart.
+	status = newUpdater(UpdaterConfig{}, "0.2.0", exe, statusFile, nil).Status()
+	assert.Equal(t, UpdateUpdated, status.State)
+	assert.Equal(t, "0.2.0", status.CurrentVersion)
+
+	u.restart = func() error { return errors.New("unsupported") }
+	publish("0.3.0", checksum)
+	status = update(UpdateIn{})
+	for status.State == UpdateRestarting {
+		time.Sleep(10 * time.Millisecond)
+		status = u.Status()
+	}
+	assert.Equal(t, UpdateRestartRequired, status.State)
+}
@@ -0,0 +1,132 @@
+package visor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/utclient"
+)
+
+// DefaultUptimeInterval is the default interval between uptime heartbeats.
+const DefaultUptimeInterval = time.Minute
+
+// maxUptimeBackoff bounds the delay between heartbeats after consecutive failures.
+const maxUptimeBackoff = 10 * time.Minute
+
+// newUptimeClient creates the client of the uptime tracker. It is a variable so that it may be replaced in tests.
+var newUptimeClient = utclient.NewHTTP
+
+// UptimeStatus is the status of the heartbeats submitted to the uptime tracker.
+type UptimeStatus struct {
+	Tracker     string    `json:"tracker"`
+	LastSuccess time.Time `json:"last_success,omitempty"` // time of the last accepted heartbeat.
+	LastAttempt time.Time `json:"last_attempt,omitempty"` // time of the last heartbeat, successful or not.
+	LastError   string    `json:"last_error,omitempty"`   // error of the last heartbeat, empty if it succeeded.
+	Failures    int       `json:"consecutive_failures"`   // number of failed heartbeats since the last success.
+}
+
+// uptimeTracker periodically submits signed heartbeats to the uptime tracker.
+type uptimeTracker struct {
+	interval time.Duration
+	connect  func() (utclient.APIClient, error)
+	status   UptimeStatus
+	mx       sync.Mutex
+
+	rewardAddress func() string // reward address reported with heartbeats, may be nil.
+}
+
+func newUptimeTracker(conf *Config) *uptimeTracker {
+	interval := time.Duration(conf.Uptime.Interval)
+	if interval <= 0 {
+		interval = DefaultUptimeInterval
+	}
+	addr, pk, sk := conf.Uptime.Tracker, conf.Node.StaticPubKey, conf.Node.StaticSecKey
+	return &uptimeTracker{
+		interval: interval,
+		connect:  func() (utclient.APIClient, error) { return newUptimeClient(addr, pk, sk) },
+		status:   UptimeStatus{Tracker: addr},
+	}
+}
+
+// run submits heartbeats until ctx is done, backing off exponentially on failures.
+func (t *uptimeTracker) run(ctx context.Context, log func(err error, failures int)) {
+	var client utclient.APIClient
+	for {
+		err := t.submit(ctx, &client)
+		failures := t.record(err)
+		if err != nil {
+			log(err, failures)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(t.backoff(failures)):
+		}
+	}
+}
+
+func (t *uptimeTracker) submit(ctx context.Context, client *utclient.APIClient) error {
+	// The client fetches a nonce from the tracker on creation, so it is created lazily in case the tracker is down.
+	if *client == nil {
+		c, err := t.connect()
+		if err != nil {
+			return err
+		}
+		*client = c
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.interval)
+	defer cancel()
+	var rewardAddress string
+	if t.rewardAddress != nil {
+		rewardAddress = t.rewardAddress()
+	}
+	return (*client).UpdateNodeUptime(ctx, rewardAddress)
+}
+
+func (t *uptimeTracker) record(err error) int {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.status.LastAttempt = time.Now()
+	if err != nil {
+		t.status.LastError = err.Error()
+		t.status.Failures++
+	} else {
+		t.status.LastSuccess = t.status.LastAttempt
+		t.status.LastError = ""
+		t.status.Failures = 0
+	}
+	return t.status.Failures
+}
+
+func (t *uptimeTracker) backoff(failures int) time.Duration {
+	d := t.interval
+	for i := 0; i < failures && d < maxUptimeBackoff; i++ {
+		d *= 2
+	}
+	if d > maxUptimeBackoff {
+		d = maxUptimeBackoff
+	}
+	return d
+}
+
+// Status returns the status of the heartbeats.
+func (t *uptimeTracker) Status() *UptimeStatus {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	status := t.status
+	return &status
+}
+
+// trackUptime submits heartbeats to the uptime tracker until ctx is done.
+func (node *Node) trackUptime(ctx context.Context) {
+	node.logger.Info("Submitting heartbeats to uptime tracker ", node.uptime.status.Tracker)
+	node.uptime.run(ctx, func(err error, failures int) {
+		node.logger.WithError(err).Warnf("Failed to submit uptime heartbeat (%d consecutive failures)", failures)
+		node.publishEvent(Event{Time: time.Now(), Type: EventUptimeFailed, Subject: node.uptime.status.Tracker,
+			Message: fmt.Sprintf("%v (%d consecutive failures)", err, failures)})
+	})
+}
@@ -0,0 +1,62 @@
+package visor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+	"github.com/SkycoinProject/skywire-mainnet/internal/utclient"
+)
+
+type mockUptimeClient struct {
+	calls int32
+	fail  int32
+}
+
+func (c *mockUptimeClient) UpdateNodeUptime(context.Context, string) error {
+	atomic.AddInt32(&c.calls, 1)
+	if atomic.LoadInt32(&c.fail) == 1 {
+		return errors.New("failure")
+	}
+	return nil
+}
+
+func TestUptimeTracker(t *testing.T) {
+	client := &mockUptimeClient{fail: 1}
+	tracker := &uptimeTracker{
+		interval: 10 * time.Millisecond,
+		connect:  func() (utclient.APIClient, error) { return client, nil },
+		status:   UptimeStatus{Tracker: "http://uptime.tracker"},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failures := make(chan int, 10)
+	go tracker.run(ctx, func(_ error, n int) {
+		select {
+		case failures <- n:
+		default:
+		}
+	})
+	assert.Equal(t, 1, <-failures)
+	assert.Equal(t, 2, <-failures)
+
+	status := tracker.Status()
+	assert.Equal(t, "failure", status.LastError)
+	assert.True(t, status.LastSuccess.IsZero())
+	assert.Equal(t, 40*time.Millisecond, tracker.backoff(2))
+	assert.Equal(t, maxUptimeBackoff, tracker.backoff(100))
+
+	atomic.StoreInt32(&client.fail, 0)
+	testhelpers.Eventually(t, func() bool {
+		return tracker.Status().Failures == 0
+	}, time.Second, 10*time.Millisecond)
+	status = tracker.Status()
+	assert.Empty(t, status.LastError)
+	assert.False(t, status.LastSuccess.IsZero())
+}
@@ -19,18 +19,20 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/SkycoinProject/dmsg"
 	"github.com/SkycoinProject/dmsg/cipher"
-	"github.com/SkycoinProject/dmsg/noise"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/buildinfo"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/dmsgpty"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/metrics"
 	routeFinder "github.com/SkycoinProject/skywire-mainnet/pkg/route-finder/client"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/geo"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/util/pathutil"
 )
 
@@ -56,7 +58,7 @@ var ErrAppNotRunning = errors.New("app is not running")
 // Version is the node version.
 const Version = "0.0.1"
 
-const supportedProtocolVersion = "0.0.1"
+const supportedProtocolVersion = buildinfo.AppProtocolVersion
 
 var reservedPorts = map[routing.Port]string{0: "router", 1: "skychat", 3: "socksproxy"}
 
@@ -66,17 +68,20 @@ type AppState struct {
 	AutoStart bool         `json:"autostart"`
 	Port      routing.Port `json:"port"`
 	Status    AppStatus    `json:"status"`
+	Usage     *AppUsage    `json:"usage,omitempty"` // resource usage of the app, if running and sampled.
 }
 
 type appExecuter interface {
 	Start(cmd *exec.Cmd) (int, error)
 	Stop(pid int) error
+	Terminate(pid int) error // asks the app to exit.
 	Wait(cmd *exec.Cmd) error
 }
 
 type appBind struct {
-	conn net.Conn
-	pid  int
+	conn    net.Conn
+	pid     int
+	stopped bool // whether the app was stopped by the node, rather than exited by itself.
 }
 
 // PacketRouter performs routing of the skywire packets.
@@ -85,6 +90,7 @@ type PacketRouter interface {
 	Serve(ctx context.Context) error
 	ServeApp(conn net.Conn, port routing.Port, appConf *app.Config) error
 	SetupIsTrusted(sPK cipher.PubKey) bool
+	Drain(ctx context.Context) error
 }
 
 // Node provides messaging runtime for Apps by setting up all
@@ -94,7 +100,10 @@ type Node struct {
 	router PacketRouter
 	n      *snet.Network
 	tm     *transport.Manager
+	tmMet  *metrics.TransportMetrics
+	dMet   *metrics.DialMetrics
 	rt     routing.Table
+	rf     routeFinder.Client
 	exec   appExecuter
 	pty    *dmsgpty.Host // TODO(evanlinjin): Complete.
 
@@ -104,42 +113,116 @@ type Node struct {
 	appsPath  string
 	localPath string
 	appsConf  []AppConfig
+	appsMu    sync.RWMutex   // protects appsConf, which is replaced on config reload.
+	appUsage  *appUsage      // resource usage of running apps, may be nil.
+	uptime    *uptimeTracker // nil if no uptime tracker is configured.
+	events    *eventLog      // may be nil.
+	stream    *eventStream   // events published to subscribers, may be nil.
+	reloadMu  sync.Mutex     // serializes config reloads.
+
+	logLevels   *logLevels // per-module log levels, set on first use.
+	logLevelsMu sync.Mutex
+
+	netMonitor *networkMonitor // nil unless reporting to a network monitor is enabled.
+
+	location   *geo.Location // self-reported location, nil if unknown.
+	locationMu sync.Mutex
+
+	clock   *ClockStatus // latest check of the local clock, nil if not checked yet.
+	clockMu sync.Mutex
+
+	logTail *logTail // last lines logged by the visor, may be nil.
+
+	restarter func() // restarts the process of the node, nil if unsupported.
+	restartMx sync.Mutex
+
+	updater *updater // nil if updates are not configured.
 
 	startedMu   sync.RWMutex
 	startedApps map[string]*appBind
 	startedAt   time.Time
 
+	ready chan struct{} // closed once the node is started, see Ready.
+
 	pidMu sync.Mutex
 
+	stcpMu sync.Mutex // serializes modifications of the stcp pk_table_file.
+
 	rpcListener net.Listener
-	rpcDialers  []*noise.RPCClientDialer
+	rpcDialers  []*hypervisorDialer
+	hypervisors *hypervisorStatuses // connections to the configured hypervisors.
+
+	shared   *SharedState // state shared by the hypervisors, loaded on first use.
+	sharedMu sync.Mutex
+
+	httpListener net.Listener // serves the HTTP API over dmsg, may be nil.
+	apiListener  net.Listener // serves the REST API, may be nil.
+	httpMu       sync.Mutex
+
+	plugins   []namedPlugin // started plugins.
+	pluginsMu sync.Mutex
 }
 
 // NewNode constructs new Node.
 func NewNode(config *Config, masterLogger *logging.MasterLogger) (*Node, error) {
 	ctx := context.Background()
 
+	if config.Sealed() {
+		return nil, ErrKeySealed
+	}
+
 	node := &Node{
 		conf:        config,
 		exec:        newOSExecuter(),
 		startedApps: make(map[string]*appBind),
+		appUsage:    newAppUsage(),
+		stream:      newEventStream(),
+		ready:       make(chan struct{}),
+	}
+	if config.Uptime.Tracker != "" {
+		node.uptime = newUptimeTracker(config)
+		node.uptime.rewardAddress = node.RewardAddress
 	}
+	node.netMonitor = newNetworkMonitor(config.NetworkMonitor)
 
 	node.Logger = masterLogger
-	node.logger = node.Logger.PackageLogger("skywire")
+	node.logTail = newLogTail()
+	masterLogger.AddHook(node.logTail)
+	if name := config.Identity(); name != "" {
+		node.logger = node.Logger.PackageLogger("skywire:" + name)
+	} else {
+		node.logger = node.Logger.PackageLogger("skywire")
+	}
 
 	pk := config.Node.StaticPubKey
 	sk := config.Node.StaticSecKey
 
-	fmt.Println("min servers:", config.Messaging.ServerCount)
+	dmsgSrvCount, err := config.DmsgServerCount()
+	if err != nil {
+		return nil, err
+	}
+	stcpTable, err := config.STCPTable()
+	if err != nil {
+		return nil, err
+	}
+	node.dMet = metrics.NewDialMetrics("skywire_visor")
 	node.n = snet.New(snet.Config{
-		PubKey:        pk,
-		SecKey:        sk,
-		TpNetworks:    []string{dmsg.Type, snet.STcpType}, // TODO: Have some way to configure this.
-		DmsgDiscAddr:  config.Messaging.Discovery,
-		DmsgMinSrvs:   config.Messaging.ServerCount,
-		STCPLocalAddr: config.STCP.LocalAddr,
-		STCPTable:     config.STCP.PubKeyTable,
+		PubKey:            pk,
+		SecKey:            sk,
+		TpNetworks:        config.TransportNetworks(),
+		DisabledNetworks:  config.DisabledNetworks,
+		DmsgDiscAddr:      config.Messaging.Discovery,
+		DmsgMinSrvs:       dmsgSrvCount,
+		DmsgDiscCacheFile: config.Messaging.CacheFile,
+		DmsgDiscCacheTTL:  time.Duration(config.Messaging.CacheTTL),
+		STCPLocalAddr:     config.STCP.LocalAddr,
+		STCPTable:         stcpTable,
+		STCPPortMapping:   config.STCP.PortMapping,
+		STCPMultiplex:     config.STCP.Multiplex,
+		STCPTLS:           config.STCP.TLS,
+		STCPNetSim:        config.STCPNetSim(),
+		Options:           config.Networks,
+		Metrics:           node.dMet,
 	})
 	if err := node.n.Init(ctx); err != nil {
 		return nil, fmt.Errorf("failed to init network: %v", err)
@@ -162,29 +245,67 @@ func NewNode(config *Config, masterLogger *logging.MasterLogger) (*Node, error)
 	if err != nil {
 		return nil, fmt.Errorf("invalid TransportLogStore: %s", err)
 	}
+	labelStore, err := config.TransportLabelStore()
+	if err != nil {
+		return nil, fmt.Errorf("invalid TransportLabelStore: %s", err)
+	}
+	quotas, quotaFile, err := config.TransportQuotas()
+	if err != nil {
+		return nil, fmt.Errorf("invalid transport quotas: %s", err)
+	}
+	node.tmMet = metrics.NewTransportMetrics("skywire_visor")
 	tmConfig := &transport.ManagerConfig{
-		PubKey:          pk,
-		SecKey:          sk,
-		DefaultNodes:    config.TrustedNodes,
-		DiscoveryClient: trDiscovery,
-		LogStore:        logStore,
+		PubKey:               pk,
+		SecKey:               sk,
+		DefaultNodes:         config.TrustedNodes,
+		DiscoveryClient:      trDiscovery,
+		LogStore:             logStore,
+		LabelStore:           labelStore,
+		MTU:                  config.Transport.MTU,
+		CipherSuites:         config.Transport.CipherSuites,
+		Metrics:              node.tmMet,
+		PersistentTransports: config.MaintainedTransports(time.Now()),
+		StatsInterval:        time.Duration(config.Transport.StatsInterval),
+		NonceFile:            config.Transport.NonceFile,
+		RequireNonces:        config.Transport.RequireNonces,
+		ResumeBufferSize:     config.Transport.ResumeBuffer,
+		TrustedVisors:        config.TrustedVisors,
+		Quotas:               quotas,
+		QuotaFile:            quotaFile,
+	}
+	if config.Transport.FEC != nil {
+		tmConfig.FEC = *config.Transport.FEC
 	}
 	node.tm, err = transport.NewManager(node.n, tmConfig)
 	if err != nil {
 		return nil, fmt.Errorf("transport manager: %s", err)
 	}
+	location, err := config.StaticLocation()
+	if err != nil {
+		return nil, fmt.Errorf("invalid location: %s", err)
+	}
+	node.setLocation(location)
+	if err := ValidateRewardAddress(config.RewardAddress); err != nil {
+		return nil, err
+	}
+	node.setRewardAddress(config.RewardAddress)
 
 	node.rt, err = config.RoutingTable()
 	if err != nil {
 		return nil, fmt.Errorf("routing table: %s", err)
 	}
+	tpWeights, err := config.RouteFinderTransportWeights()
+	if err != nil {
+		return nil, fmt.Errorf("invalid route finder transport weights: %s", err)
+	}
+	node.rf = routeFinder.NewHTTP(config.Routing.RouteFinder, time.Duration(config.Routing.RouteFinderTimeout), tpWeights)
 	rConfig := &router.Config{
 		Logger:           node.Logger.PackageLogger("router"),
 		PubKey:           pk,
 		SecKey:           sk,
 		TransportManager: node.tm,
 		RoutingTable:     node.rt,
-		RouteFinder:      routeFinder.NewHTTP(config.Routing.RouteFinder, time.Duration(config.Routing.RouteFinderTimeout)),
+		RouteFinder:      node.rf,
 		SetupNodes:       config.Routing.SetupNodes,
 	}
 	r, err := router.New(node.n, rConfig)
@@ -208,6 +329,27 @@ func NewNode(config *Config, masterLogger *logging.MasterLogger) (*Node, error)
 		return nil, fmt.Errorf("invalid LocalPath: %s", err)
 	}
 
+	eventLogSize := 0
+	if config.EventLog != nil {
+		eventLogSize = config.EventLog.Size
+	}
+	node.events, err = newEventLog(config.eventLogPath(node.localPath), eventLogSize)
+	if err != nil {
+		return nil, fmt.Errorf("event log: %s", err)
+	}
+
+	if config.Update != nil {
+		exe, err := os.Executable()
+		if err == nil {
+			exe, err = filepath.EvalSymlinks(exe)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("updates: %s", err)
+		}
+		statusFile := filepath.Join(node.localPath, updateStatusFile)
+		node.updater = newUpdater(*config.Update, Version, exe, statusFile, node.RequestRestart)
+	}
+
 	if lvl, err := logging.LevelFromString(config.LogLevel); err == nil {
 		node.Logger.SetLevel(lvl)
 	}
@@ -219,14 +361,12 @@ func NewNode(config *Config, masterLogger *logging.MasterLogger) (*Node, error)
 		}
 		node.rpcListener = l
 	}
-	node.rpcDialers = make([]*noise.RPCClientDialer, len(config.Hypervisors))
-	for i, entry := range config.Hypervisors {
-		node.rpcDialers[i] = noise.NewRPCClientDialer(entry.Addr, noise.HandshakeXK, noise.Config{
-			LocalPK:   pk,
-			LocalSK:   sk,
-			RemotePK:  entry.PubKey,
-			Initiator: true,
-		})
+	node.hypervisors = newHypervisorStatuses(config.Hypervisors)
+	for _, entry := range config.Hypervisors {
+		if entry.Addr == "" {
+			continue // connects over dmsg (see 'dmsg_rpc').
+		}
+		node.rpcDialers = append(node.rpcDialers, newHypervisorDialer(entry, pk, sk))
 	}
 
 	return node, err
@@ -247,7 +387,7 @@ func (node *Node) Start() error {
 
 	pathutil.EnsureDir(node.dir())
 	node.closePreviousApps()
-	for _, ac := range node.appsConf {
+	for _, ac := range node.appConfigs() {
 		if !ac.AutoStart {
 			continue
 		}
@@ -264,16 +404,61 @@ func (node *Node) Start() error {
 	}
 	if node.rpcListener != nil {
 		node.logger.Info("Starting RPC interface on ", node.rpcListener.Addr())
-		go rpcSvr.Accept(node.rpcListener)
+		if token := node.conf.Interfaces.RPCToken; token != "" {
+			go node.serveAuthRPC(rpcSvr, node.rpcListener, token)
+		} else {
+			go rpcSvr.Accept(node.rpcListener)
+		}
 	}
 	for _, dialer := range node.rpcDialers {
-		go func(dialer *noise.RPCClientDialer) {
-			if err := dialer.Run(rpcSvr, time.Second); err != nil {
-				node.logger.Errorf("Dialer exited with error: %v", err)
+		go dialer.run(rpcSvr, node.hypervisors)
+	}
+
+	if node.conf.DmsgRPC != nil && node.n != nil {
+		go func() {
+			if err := node.serveDmsgRPC(rpcSvr); err != nil {
+				node.logger.WithError(err).Error("Failed to serve RPC over dmsg")
 			}
-		}(dialer)
+		}()
 	}
 
+	go node.logTransportEvents()
+	go node.recordRouteEvents()
+	if node.n != nil {
+		go node.logNetworkEvents()
+	}
+	if node.conf.STCP.TableFile != "" {
+		go node.watchSTCPTable(ctx)
+	}
+	if mConf, ok := node.conf.TransportMaintenance(); ok {
+		node.logger.Infof("Starting transport maintenance: min(%d) max(%d) type(%s)",
+			mConf.MinTransports, mConf.MaxTransports, mConf.Type)
+		go node.tm.Maintain(ctx, mConf)
+	}
+
+	if node.appUsage != nil {
+		go node.monitorApps(ctx, AppUsageInterval)
+	}
+	if node.uptime != nil {
+		go node.trackUptime(ctx)
+	}
+	if node.netMonitor != nil {
+		go node.reportHealth(ctx)
+	}
+	go node.monitorClock(ctx)
+	if lc := node.conf.Location; lc != nil && lc.Auto && node.Location() == nil {
+		go node.detectLocation(ctx, lc.LookupURL)
+	}
+	if len(node.conf.RotatedKeys) > 0 {
+		go node.deregisterRotatedKeys(ctx)
+	}
+
+	if err := node.startPlugins(); err != nil {
+		return err
+	}
+
+	go node.signalReady(ctx)
+
 	node.logger.Info("Starting packet router")
 	if err := node.router.Serve(ctx); err != nil {
 		return fmt.Errorf("failed to start Node: %s", err)
@@ -282,6 +467,98 @@ func (node *Node) Start() error {
 	return nil
 }
 
+func (node *Node) logTransportEvents() {
+	events, _ := node.tm.Observe()
+	for e := range events {
+		node.logger.Infof("transport event: type(%s) tpID(%s) remote(%s) network(%s) reason(%s)",
+			e.Type, e.TpID, e.RemotePK, e.TpType, e.Reason)
+		node.recordTransportEvent(e)
+		node.publishTransportEvent(e)
+	}
+}
+
+func (node *Node) logNetworkEvents() {
+	events, _ := node.n.Observe()
+	for e := range events {
+		if e.Available {
+			node.logger.Infof("network event: network(%s) is available", e.Network)
+		} else {
+			node.logger.Warnf("network event: network(%s) is unavailable: %s", e.Network, e.Reason)
+		}
+		node.publishNetworkEvent(e)
+	}
+}
+
+// stcpTableWatchInterval is the interval at which the stcp pk_table_file is checked for modifications.
+const stcpTableWatchInterval = 5 * time.Second
+
+func (node *Node) watchSTCPTable(ctx context.Context) {
+	path := node.conf.STCP.TableFile
+	node.logger.Infof("Watching stcp PK table file: %s", path)
+
+	err := stcp.WatchTableFile(ctx, path, stcpTableWatchInterval, func(fileEntries map[cipher.PubKey]string) {
+		entries := make(map[cipher.PubKey]string, len(node.conf.STCP.PubKeyTable)+len(fileEntries))
+		for pk, addr := range node.conf.STCP.PubKeyTable {
+			entries[pk] = addr
+		}
+		for pk, addr := range fileEntries {
+			entries[pk] = addr
+		}
+		node.n.STcp().Table().Reset(entries)
+		node.logger.Infof("Reloaded stcp PK table: %d entries", len(entries))
+	})
+	if err != nil && err != context.Canceled {
+		node.logger.Warnf("Stopped watching stcp PK table file: %v", err)
+	}
+}
+
+// STCPTable returns the entries of the stcp PK table.
+func (node *Node) STCPTable() map[cipher.PubKey]string {
+	return node.n.STcp().Table().Entries()
+}
+
+// AddSTCPEntry adds or replaces an entry of the stcp PK table.
+// The entry is persisted to pk_table_file if configured.
+func (node *Node) AddSTCPEntry(pk cipher.PubKey, addr string) error {
+	addr, err := stcp.ParseAddr(addr)
+	if err != nil {
+		return fmt.Errorf("invalid stcp address: %v", err)
+	}
+	node.n.STcp().Table().Add(pk, addr)
+	return node.updateSTCPTableFile(func(entries map[cipher.PubKey]string) {
+		entries[pk] = addr
+	})
+}
+
+// RemoveSTCPEntry removes an entry of the stcp PK table.
+// The entry is removed from pk_table_file if configured, however entries of pk_table are restored on reload.
+func (node *Node) RemoveSTCPEntry(pk cipher.PubKey) error {
+	node.n.STcp().Table().Remove(pk)
+	return node.updateSTCPTableFile(func(entries map[cipher.PubKey]string) {
+		delete(entries, pk)
+	})
+}
+
+func (node *Node) updateSTCPTableFile(update func(entries map[cipher.PubKey]string)) error {
+	path := node.conf.STCP.TableFile
+	if path == "" {
+		return nil
+	}
+
+	node.stcpMu.Lock()
+	defer node.stcpMu.Unlock()
+
+	entries, err := stcp.ReadTableFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		entries = make(map[cipher.PubKey]string)
+	}
+	update(entries)
+	return stcp.WriteTableFile(path, entries)
+}
+
 func (node *Node) dir() string {
 	return pathutil.NodeDir(node.conf.Node.StaticPubKey)
 }
@@ -341,11 +618,54 @@ func (node *Node) stopUnhandledApp(name string, pid int) {
 	node.logger.Infof("Found and killed hanged app %s with pid %d previously ran by this node", name, pid)
 }
 
+// Shutdown gracefully stops the node, then closes it:
+//   - new transports and loops are rejected.
+//   - loops are closed: apps are notified, and the rules of the loops are removed on remote nodes via setup nodes.
+//   - apps are asked to exit (with SIGTERM), and given until the context is done to do so.
+//   - the node is closed, which kills the remaining apps and closes the transports.
+func (node *Node) Shutdown(ctx context.Context) error {
+	node.logger.Info("Shutting down gracefully")
+	if node.tm != nil {
+		node.tm.Drain()
+	}
+	if err := node.router.Drain(ctx); err != nil {
+		node.logger.WithError(err).Warn("Failed to drain router")
+	}
+
+	node.startedMu.Lock()
+	for name, bind := range node.startedApps {
+		bind.stopped = true
+		if err := node.exec.Terminate(bind.pid); err != nil {
+			node.logger.WithError(err).Warnf("(%s) failed to terminate app", name)
+		}
+	}
+	node.startedMu.Unlock()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for node.runningApps() > 0 {
+		select {
+		case <-ctx.Done():
+			node.logger.Warnf("Grace period expired with %d apps running", node.runningApps())
+			return node.Close()
+		case <-ticker.C:
+		}
+	}
+	return node.Close()
+}
+
+func (node *Node) runningApps() int {
+	node.startedMu.RLock()
+	defer node.startedMu.RUnlock()
+	return len(node.startedApps)
+}
+
 // Close safely stops spawned Apps and messaging Node.
 func (node *Node) Close() (err error) {
 	if node == nil {
 		return nil
 	}
+	node.stopPlugins()
 	if node.rpcListener != nil {
 		if err = node.rpcListener.Close(); err != nil {
 			node.logger.WithError(err).Error("failed to stop RPC interface")
@@ -353,6 +673,18 @@ func (node *Node) Close() (err error) {
 			node.logger.Info("RPC interface stopped successfully")
 		}
 	}
+	node.httpMu.Lock()
+	if node.httpListener != nil {
+		if err = node.httpListener.Close(); err != nil {
+			node.logger.WithError(err).Error("failed to stop dmsg HTTP API")
+		}
+	}
+	if node.apiListener != nil {
+		if err = node.apiListener.Close(); err != nil {
+			node.logger.WithError(err).Error("failed to stop REST API")
+		}
+	}
+	node.httpMu.Unlock()
 	for i, dialer := range node.rpcDialers {
 		if err = dialer.Close(); err != nil {
 			node.logger.WithError(err).Errorf("(%d) failed to stop RPC dialer", i)
@@ -362,6 +694,7 @@ func (node *Node) Close() (err error) {
 	}
 	node.startedMu.Lock()
 	for a, bind := range node.startedApps {
+		bind.stopped = true
 		if err = node.stopApp(a, bind); err != nil {
 			node.logger.WithError(err).Errorf("(%s) failed to stop app", a)
 		} else {
@@ -377,6 +710,39 @@ func (node *Node) Close() (err error) {
 	return err
 }
 
+// TransportMetrics returns the Prometheus metrics of the transport subsystem.
+// These are not registered, as registration is left to the caller.
+func (node *Node) TransportMetrics() *metrics.TransportMetrics {
+	return node.tmMet
+}
+
+// DmsgMetrics returns the Prometheus metrics of the sessions with dmsg servers.
+// These are not registered, as registration is left to the caller.
+func (node *Node) DmsgMetrics() *metrics.DmsgMetrics {
+	return metrics.NewDmsgMetrics("skywire_visor", func() []metrics.DmsgSessionStats {
+		sessions := node.n.DmsgSessions()
+		stats := make([]metrics.DmsgSessionStats, len(sessions))
+		for i, s := range sessions {
+			stats[i] = metrics.DmsgSessionStats{
+				Server:     s.Server.String(),
+				Connected:  s.Connected,
+				Streams:    s.Streams,
+				BytesSent:  s.BytesSent,
+				BytesRecv:  s.BytesRecv,
+				Reconnects: s.Reconnects,
+				RTT:        s.RTT.Seconds(),
+			}
+		}
+		return stats
+	})
+}
+
+// DialMetrics returns the Prometheus metrics of dials via snet.
+// These are not registered, as registration is left to the caller.
+func (node *Node) DialMetrics() *metrics.DialMetrics {
+	return node.dMet
+}
+
 // Exec executes a shell command. It returns combined stdout and stderr output and an error.
 func (node *Node) Exec(command string) ([]byte, error) {
 	args := strings.Split(command, " ")
@@ -387,13 +753,16 @@ func (node *Node) Exec(command string) ([]byte, error) {
 // Apps returns list of AppStates for all registered apps.
 func (node *Node) Apps() []*AppState {
 	res := make([]*AppState, 0)
-	for _, app := range node.appsConf {
-		state := &AppState{app.App, app.AutoStart, app.Port, AppStatusStopped}
+	for _, app := range node.appConfigs() {
+		state := &AppState{Name: app.App, AutoStart: app.AutoStart, Port: app.Port, Status: AppStatusStopped}
 		node.startedMu.RLock()
 		if node.startedApps[app.App] != nil {
 			state.Status = AppStatusRunning
 		}
 		node.startedMu.RUnlock()
+		if state.Status == AppStatusRunning && node.appUsage != nil {
+			state.Usage = node.appUsage.get(app.App)
+		}
 
 		res = append(res, state)
 	}
@@ -401,9 +770,15 @@ func (node *Node) Apps() []*AppState {
 	return res
 }
 
+func (node *Node) appConfigs() []AppConfig {
+	node.appsMu.RLock()
+	defer node.appsMu.RUnlock()
+	return append([]AppConfig(nil), node.appsConf...)
+}
+
 // StartApp starts registered App.
 func (node *Node) StartApp(appName string) error {
-	for _, appC := range node.appsConf {
+	for _, appC := range node.appConfigs() {
 		if appC.App != appName {
 			continue
 		}
@@ -427,10 +802,33 @@ func (node *Node) StartApp(appName string) error {
 	return ErrUnknownApp
 }
 
+// checkAppProtocol refuses to launch apps built with an incompatible app protocol version. Apps which do not embed
+// their version, such as those built before versions were embedded, are launched with a warning.
+func (node *Node) checkAppProtocol(config *AppConfig) error {
+	v, err := buildinfo.ReadAppProtocol(app.BinaryPath(node.appsPath, config.App, config.Version))
+	switch err {
+	case nil:
+	case buildinfo.ErrNoAppProtocol:
+		node.logger.Warnf("App %s.v%s does not embed its app protocol version, and may be incompatible",
+			config.App, config.Version)
+		return nil
+	default:
+		return nil // the binary is reported on launch.
+	}
+	if err := buildinfo.CompatibleAppProtocol(v); err != nil {
+		return fmt.Errorf("app %s.v%s: %v: rebuild the app against this version of skywire, or update the visor",
+			config.App, config.Version, err)
+	}
+	return nil
+}
+
 // SpawnApp configures and starts new App.
 func (node *Node) SpawnApp(config *AppConfig, startCh chan<- struct{}) (err error) {
 	node.logger.Infof("Starting %s.v%s", config.App, config.Version)
 	node.logger.Warnf("here: config.Args: %+v, with len %d", config.Args, len(config.Args))
+	if err := node.checkAppProtocol(config); err != nil {
+		return err
+	}
 	conn, cmd, err := app.Command(
 		&app.Config{ProtocolVersion: supportedProtocolVersion, AppName: config.App, AppVersion: config.Version},
 		node.appsPath,
@@ -440,7 +838,7 @@ func (node *Node) SpawnApp(config *AppConfig, startCh chan<- struct{}) (err erro
 		return fmt.Errorf("failed to initialize App server: %s", err)
 	}
 
-	bind := &appBind{conn, -1}
+	bind := &appBind{conn: conn, pid: -1}
 	if app, ok := reservedPorts[config.Port]; ok && app != config.App {
 		return fmt.Errorf("can't bind to reserved port %d", config.Port)
 	}
@@ -485,6 +883,7 @@ func (node *Node) SpawnApp(config *AppConfig, startCh chan<- struct{}) (err erro
 		node.logger.Infof("storing app %s pid %d", config.App, pid)
 		node.persistPID(config.App, pid)
 		node.pidMu.Unlock()
+		node.RecordEvent(EventAppStarted, config.App, "v%s with pid %d", config.Version, pid)
 		appCh <- node.exec.Wait(cmd)
 	}()
 
@@ -497,12 +896,14 @@ func (node *Node) SpawnApp(config *AppConfig, startCh chan<- struct{}) (err erro
 		startCh <- struct{}{}
 	}
 
-	var appErr error
+	var appErr, exitErr error
 	select {
 	case err := <-appCh:
 		if err != nil {
 			if _, ok := err.(*exec.ExitError); !ok {
 				appErr = fmt.Errorf("failed to run app executable: %s", err)
+			} else {
+				exitErr = err
 			}
 		}
 	case err := <-srvCh:
@@ -513,8 +914,18 @@ func (node *Node) SpawnApp(config *AppConfig, startCh chan<- struct{}) (err erro
 
 	node.startedMu.Lock()
 	delete(node.startedApps, config.App)
+	stopped := bind.stopped
 	node.startedMu.Unlock()
 
+	switch {
+	case appErr != nil:
+		node.RecordEvent(EventAppCrashed, config.App, "%v", appErr)
+	case exitErr != nil && !stopped:
+		node.RecordEvent(EventAppCrashed, config.App, "%v", exitErr)
+	default:
+		node.RecordEvent(EventAppStopped, config.App, "")
+	}
+
 	return appErr
 }
 
@@ -532,6 +943,9 @@ func (node *Node) persistPID(name string, pid int) {
 func (node *Node) StopApp(appName string) error {
 	node.startedMu.Lock()
 	bind := node.startedApps[appName]
+	if bind != nil {
+		bind.stopped = true
+	}
 	node.startedMu.Unlock()
 
 	if bind == nil {
@@ -543,6 +957,8 @@ func (node *Node) StopApp(appName string) error {
 
 // SetAutoStart sets an app to auto start or not.
 func (node *Node) SetAutoStart(appName string, autoStart bool) error {
+	node.appsMu.Lock()
+	defer node.appsMu.Unlock()
 	for i, ac := range node.appsConf {
 		if ac.App == appName {
 			node.appsConf[i].AutoStart = autoStart
@@ -605,6 +1021,23 @@ func (exc *osExecuter) Stop(pid int) (err error) {
 	return err
 }
 
+func (exc *osExecuter) Terminate(pid int) (err error) {
+	exc.mu.Lock()
+	defer exc.mu.Unlock()
+
+	for _, process := range exc.processes {
+		if process.Pid != pid {
+			continue
+		}
+
+		if sigErr := process.Signal(syscall.SIGTERM); sigErr != nil && err == nil {
+			err = sigErr
+		}
+	}
+
+	return err
+}
+
 func (exc *osExecuter) Wait(cmd *exec.Cmd) error {
 	return cmd.Wait()
 }
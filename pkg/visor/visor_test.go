@@ -158,6 +158,35 @@ func TestNodeSpawnApp(t *testing.T) {
 	require.NoError(t, node.StopApp("skychat"))
 }
 
+func TestNodeShutdown(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	r := new(mockRouter)
+	executer := &MockExecuter{}
+	defer func() {
+		require.NoError(t, os.RemoveAll("skychat"))
+	}()
+	apps := []AppConfig{{App: "skychat", Version: "1.0", AutoStart: false, Port: 10}}
+	node := &Node{router: r, exec: executer, appsConf: apps, startedApps: map[string]*appBind{}, logger: logging.MustGetLogger("test"),
+		conf: &Config{}}
+	node.conf.Node.StaticPubKey = pk
+	pathutil.EnsureDir(node.dir())
+	defer func() {
+		require.NoError(t, os.RemoveAll(node.dir()))
+	}()
+
+	require.NoError(t, node.StartApp("skychat"))
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, 1, node.runningApps())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, node.Shutdown(ctx))
+	assert.NoError(t, ctx.Err(), "apps should exit before the grace period expires")
+	assert.True(t, r.didDrain)
+	assert.True(t, r.didClose)
+	assert.Equal(t, 0, node.runningApps())
+}
+
 func TestNodeSpawnAppValidations(t *testing.T) {
 	pk, _ := cipher.GenerateKeyPair()
 	conn, _ := net.Pipe()
@@ -169,7 +198,7 @@ func TestNodeSpawnAppValidations(t *testing.T) {
 	c := &Config{}
 	c.Node.StaticPubKey = pk
 	node := &Node{router: r, exec: executer,
-		startedApps: map[string]*appBind{"skychat": {conn, 10}},
+		startedApps: map[string]*appBind{"skychat": {conn: conn, pid: 10}},
 		logger:      logging.MustGetLogger("test"),
 		conf:        c,
 	}
@@ -243,6 +272,10 @@ func (exc *MockExecuter) Stop(pid int) error {
 	return nil
 }
 
+func (exc *MockExecuter) Terminate(pid int) error {
+	return exc.Stop(pid)
+}
+
 func (exc *MockExecuter) Wait(cmd *exec.Cmd) error {
 	<-exc.stopCh
 	return nil
@@ -254,6 +287,7 @@ type mockRouter struct {
 	ports []routing.Port
 
 	didStart bool
+	didDrain bool
 	didClose bool
 
 	errChan chan error
@@ -289,6 +323,11 @@ func (r *mockRouter) ServeApp(conn net.Conn, port routing.Port, appConf *app.Con
 	return <-r.errChan
 }
 
+func (r *mockRouter) Drain(context.Context) error {
+	r.didDrain = true
+	return nil
+}
+
 func (r *mockRouter) Close() error {
 	if r == nil {
 		return nil
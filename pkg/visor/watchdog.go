@@ -0,0 +1,75 @@
+package visor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotReady occurs when the health of a node is checked before it is ready.
+var ErrNotReady = errors.New("node is not ready")
+
+// Ready returns a channel which is closed once the modules of the node are started and the transport manager
+// is serving, so that service managers may be notified that the visor is ready.
+func (node *Node) Ready() <-chan struct{} {
+	return node.ready
+}
+
+// signalReady closes the ready channel once the transport manager is serving.
+func (node *Node) signalReady(ctx context.Context) {
+	if node.tm != nil {
+		select {
+		case <-node.tm.Ready():
+		case <-ctx.Done():
+			return
+		}
+	}
+	node.logger.Info("Node is ready")
+	close(node.ready)
+}
+
+// CheckAlive checks that the node is ready and not wedged: the transport manager must be serving, and the locks
+// serializing config reloads and app management must not be held for longer than timeout.
+// It backs watchdog pings to service managers, which restart the visor if the check keeps failing.
+func (node *Node) CheckAlive(timeout time.Duration) error {
+	select {
+	case <-node.ready:
+	default:
+		return ErrNotReady
+	}
+	if node.tm != nil && node.tm.IsClosed() {
+		return errors.New("transport manager is closed")
+	}
+	locks := []struct {
+		name string
+		l    sync.Locker
+	}{
+		{"config", &node.reloadMu},
+		{"apps", node.appsMu.RLocker()},
+		{"started apps", node.startedMu.RLocker()},
+	}
+	for _, lock := range locks {
+		if !tryLock(lock.l, timeout) {
+			return fmt.Errorf("%s lock is held for over %s", lock.name, timeout)
+		}
+	}
+	return nil
+}
+
+// tryLock reports whether l may be locked within timeout. If it may not, l is unlocked once it is acquired.
+func tryLock(l sync.Locker, timeout time.Duration) bool {
+	locked := make(chan struct{})
+	go func() {
+		l.Lock()
+		l.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
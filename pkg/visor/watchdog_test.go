@@ -0,0 +1,21 @@
+package visor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeCheckAlive(t *testing.T) {
+	node := &Node{ready: make(chan struct{})}
+	assert.Equal(t, ErrNotReady, node.CheckAlive(time.Second))
+
+	close(node.ready)
+	assert.NoError(t, node.CheckAlive(time.Second))
+
+	node.reloadMu.Lock()
+	assert.EqualError(t, node.CheckAlive(10*time.Millisecond), "config lock is held for over 10ms")
+	node.reloadMu.Unlock()
+	assert.NoError(t, node.CheckAlive(time.Second))
+}